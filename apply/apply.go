@@ -0,0 +1,494 @@
+// Package apply 는 unified diff 형식의 patch 를 읽어 파일 내용에 적용한다. (git apply)
+//
+//	files, err := apply.Parse(data, 1)
+//	for _, f := range files {
+//		out, err := f.Apply(old)
+//	}
+//
+// "diff --git" 머리가 있는 patch(새 파일, 삭제, 모드 변경, rename, copy)와 "---", "+++" 만 있는
+// 일반 unified diff 를 모두 읽는다. 메일처럼 patch 앞뒤에 다른 글이 있어도 된다.
+// binary patch 는 전체 해시가 있는 "GIT binary patch" (diff --binary, format-patch) 만 적용할 수 있다.
+package apply
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/tmdgusya/gogit/base85"
+	"github.com/tmdgusya/gogit/diff"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// ErrCorrupt: patch 의 형식이 잘못되었다
+var ErrCorrupt = errors.New("corrupt patch")
+
+// File: 파일 하나에 대한 patch
+type File struct {
+	// OldPath, NewPath: 바뀌기 전과 후의 경로. 새 파일이면 OldPath, 지운 파일이면 NewPath 가 비어 있다
+	OldPath, NewPath string
+	// OldMode, NewMode: patch 에 적힌 모드. 적혀 있지 않으면 0 이다
+	OldMode, NewMode object.Mode
+	IsNew, IsDelete  bool
+	IsRename, IsCopy bool
+	// OldHash, NewHash: index 줄의 blob 해시. 짧은 해시일 수 있고 index 줄이 없으면 비어 있다
+	OldHash, NewHash string
+	// Binary: 바이너리 파일의 patch. Forward 가 없으면("Binary files ... differ") 적용할 수 없다
+	Binary            bool
+	Forward, Backward *BinaryHunk
+	Fragments         []Fragment
+}
+
+// BinaryHunk: "GIT binary patch" 의 hunk 하나. Data 는 압축을 푼 내용이다
+type BinaryHunk struct {
+	// Delta: Data 가 옛 내용에 대한 delta 다. false 면 Data 가 새 내용 그대로다 (literal)
+	Delta bool
+	Data  []byte
+}
+
+// Fragment: hunk 하나 ("@@ -OldStart,OldLines +NewStart,NewLines @@")
+type Fragment struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []Line
+}
+
+// Line: hunk 의 한 줄. Op 는 ' ', '-', '+' 이고 Text 는 줄바꿈을 포함한다
+// ("\ No newline at end of file" 이 붙은 줄은 줄바꿈이 없다)
+type Line struct {
+	Op   byte
+	Text string
+}
+
+// FailedError: hunk 를 맞출 곳을 찾지 못했다. git 과 같이 "patch failed: <path>:<line>" 이다
+type FailedError struct {
+	Path string
+	Line int
+}
+
+func (e *FailedError) Error() string {
+	return fmt.Sprintf("patch failed: %s:%d", e.Path, e.Line)
+}
+
+// Parse: data 에 든 patch 들을 순서대로 읽는다. 경로의 앞 strip 개 구성 요소(a/, b/ 등)를 뗀다. (git apply -p)
+// patch 가 하나도 없으면 에러다.
+func Parse(data []byte, strip int) ([]*File, error) {
+	lines := diff.SplitLines(data)
+	var files []*File
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			f, next, err := parseGitHeader(lines, i, strip)
+			if err != nil {
+				return nil, err
+			}
+			if next, err = parseFragments(f, lines, next); err != nil {
+				return nil, err
+			}
+			files, i = append(files, f), next
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			f := &File{}
+			f.OldPath = traditionalName(line[4:], strip)
+			f.NewPath = traditionalName(lines[i+1][4:], strip)
+			f.IsNew, f.IsDelete = f.OldPath == "", f.NewPath == ""
+			next, err := parseFragments(f, lines, i+2)
+			if err != nil {
+				return nil, err
+			}
+			files, i = append(files, f), next
+		default:
+			i++
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no valid patches in input")
+	}
+	return files, nil
+}
+
+// parseGitHeader: "diff --git" 줄과 뒤따르는 확장 머리를 읽는다. 다음에 읽을 줄 번호를 돌려준다
+func parseGitHeader(lines []string, i int, strip int) (*File, int, error) {
+	f := &File{}
+	header := strings.TrimSuffix(lines[i], "\n")
+	f.OldPath, f.NewPath = gitHeaderNames(strings.TrimPrefix(header, "diff --git "), strip)
+	for i++; i < len(lines); i++ {
+		line := strings.TrimSuffix(lines[i], "\n")
+		var err error
+		switch {
+		case strings.HasPrefix(line, "old mode "):
+			f.OldMode, err = parseMode(line[len("old mode "):])
+		case strings.HasPrefix(line, "new mode "):
+			f.NewMode, err = parseMode(line[len("new mode "):])
+		case strings.HasPrefix(line, "deleted file mode "):
+			f.IsDelete = true
+			f.OldMode, err = parseMode(line[len("deleted file mode "):])
+		case strings.HasPrefix(line, "new file mode "):
+			f.IsNew = true
+			f.NewMode, err = parseMode(line[len("new file mode "):])
+		case strings.HasPrefix(line, "rename from "):
+			f.IsRename = true
+			f.OldPath, _, err = diff.UnquotePath(line[len("rename from "):])
+		case strings.HasPrefix(line, "rename to "):
+			f.IsRename = true
+			f.NewPath, _, err = diff.UnquotePath(line[len("rename to "):])
+		case strings.HasPrefix(line, "copy from "):
+			f.IsCopy = true
+			f.OldPath, _, err = diff.UnquotePath(line[len("copy from "):])
+		case strings.HasPrefix(line, "copy to "):
+			f.IsCopy = true
+			f.NewPath, _, err = diff.UnquotePath(line[len("copy to "):])
+		case strings.HasPrefix(line, "index "):
+			// "index <old>..<new> <mode>": 모드는 바뀌지 않은 파일의 모드
+			hashes, mode, ok := strings.Cut(line[len("index "):], " ")
+			f.OldHash, f.NewHash, _ = strings.Cut(hashes, "..")
+			if ok {
+				f.OldMode, err = parseMode(mode)
+				f.NewMode = f.OldMode
+			}
+		case strings.HasPrefix(line, "similarity index "), strings.HasPrefix(line, "dissimilarity index "):
+		case strings.HasPrefix(line, "Binary files "):
+			f.Binary = true
+		case line == "GIT binary patch":
+			f.Binary = true
+			if f.Forward, i, err = parseBinaryHunk(lines, i+1); err == nil && i < len(lines) {
+				// 거꾸로 적용할 때 쓰는 두 번째 hunk 는 없을 수 있다
+				if line := lines[i]; strings.HasPrefix(line, "literal ") || strings.HasPrefix(line, "delta ") {
+					f.Backward, i, err = parseBinaryHunk(lines, i)
+				}
+			}
+			if err != nil {
+				return nil, 0, fmt.Errorf("%w at line %d: %v", ErrCorrupt, i+1, err)
+			}
+			return f.finish(), i, nil
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			// 경로는 diff --git 줄과 rename 줄이 더 정확하다. /dev/null 만 본다
+			if strings.TrimSuffix(line[4:], "\n") == "/dev/null" {
+				f.IsNew = true
+			}
+			if strings.TrimSuffix(strings.TrimSuffix(lines[i+1][4:], "\n"), "\t") == "/dev/null" {
+				f.IsDelete = true
+			}
+			i += 2
+			return f.finish(), i, nil
+		default:
+			return f.finish(), i, nil
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w at line %d: %v", ErrCorrupt, i+1, err)
+		}
+	}
+	return f.finish(), i, nil
+}
+
+// parseBinaryHunk: "literal <크기>" 또는 "delta <크기>" 와 base85 줄들, 끝의 빈 줄을 읽는다. 다음에 읽을 줄 번호를 돌려준다
+func parseBinaryHunk(lines []string, i int) (*BinaryHunk, int, error) {
+	if i >= len(lines) {
+		return nil, i, errors.New("missing binary hunk")
+	}
+	kind, sizeText, _ := strings.Cut(strings.TrimSuffix(lines[i], "\n"), " ")
+	size, err := strconv.Atoi(sizeText)
+	if err != nil || kind != "literal" && kind != "delta" {
+		return nil, i, fmt.Errorf("bad binary hunk header %q", strings.TrimSuffix(lines[i], "\n"))
+	}
+	var compressed []byte
+	for i++; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		if line == "" {
+			i++
+			break
+		}
+		data, err := base85.DecodeLine(line)
+		if err != nil {
+			return nil, i, err
+		}
+		compressed = append(compressed, data...)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, i, err
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, i, err
+	}
+	if len(data) != size {
+		return nil, i, fmt.Errorf("binary hunk is %d bytes, expected %d", len(data), size)
+	}
+	return &BinaryHunk{Delta: kind == "delta", Data: data}, i, nil
+}
+
+// finish: 새 파일과 지운 파일은 없는 쪽 경로를 비운다
+func (f *File) finish() *File {
+	if f.IsNew {
+		f.OldPath = ""
+	}
+	if f.IsDelete {
+		f.NewPath = ""
+	}
+	return f
+}
+
+// gitHeaderNames: "a/<path> b/<path>" 의 두 경로. 따옴표가 없는 경로에 공백이 있으면
+// git 과 같이 두 경로가 같다고 보고 가운데에서 나눈다.
+func gitHeaderNames(s string, strip int) (string, string) {
+	if strings.HasPrefix(s, `"`) {
+		a, rest, err := diff.UnquotePath(s)
+		if err == nil {
+			b, _, _ := diff.UnquotePath(strings.TrimPrefix(rest, " "))
+			return stripPath(a, strip), stripPath(b, strip)
+		}
+	}
+	if n := (len(s) - 1) / 2; len(s)%2 == 1 && s[n] == ' ' && stripPath(s[:n], strip) == stripPath(s[n+1:], strip) {
+		return stripPath(s[:n], strip), stripPath(s[n+1:], strip)
+	}
+	a, b, _ := strings.Cut(s, " ")
+	if strings.HasSuffix(b, `"`) {
+		b, _, _ = diff.UnquotePath(b)
+	}
+	return stripPath(a, strip), stripPath(b, strip)
+}
+
+// traditionalName: "--- <path>[\t<time>]" 의 경로. /dev/null 이면 빈 문자열
+func traditionalName(s string, strip int) string {
+	s = strings.TrimSuffix(s, "\n")
+	p, _, err := diff.UnquotePath(s)
+	if err != nil || !strings.HasPrefix(s, `"`) {
+		p, _, _ = strings.Cut(s, "\t")
+	}
+	if p == "/dev/null" {
+		return ""
+	}
+	return stripPath(p, strip)
+}
+
+// stripPath: 앞의 strip 개 구성 요소를 뗀다. 그만큼 없으면 그대로 둔다
+func stripPath(p string, strip int) string {
+	for range strip {
+		_, rest, ok := strings.Cut(p, "/")
+		if !ok {
+			break
+		}
+		p = rest
+	}
+	return p
+}
+
+func parseMode(s string) (object.Mode, error) {
+	n, err := strconv.ParseUint(strings.TrimSpace(s), 8, 32)
+	return object.Mode(n), err
+}
+
+// parseFragments: i 번째 줄부터 이어지는 hunk 들을 읽는다. hunk 가 아닌 줄에서 멈춘다
+func parseFragments(f *File, lines []string, i int) (int, error) {
+	for i < len(lines) && strings.HasPrefix(lines[i], "@@ -") {
+		var frag Fragment
+		if err := parseHunkHeader(lines[i], &frag); err != nil {
+			return 0, fmt.Errorf("%w at line %d: %v", ErrCorrupt, i+1, err)
+		}
+		start := i
+		i++
+		oldLeft, newLeft := frag.OldLines, frag.NewLines
+		for (oldLeft > 0 || newLeft > 0) && i < len(lines) {
+			line := lines[i]
+			op, text := byte(' '), "\n"
+			if line != "\n" {
+				// 편집기가 공백을 지운 빈 문맥 줄은 " \n" 으로 본다 (git 과 같음)
+				op, text = line[0], line[1:]
+			}
+			switch op {
+			case ' ':
+				oldLeft--
+				newLeft--
+			case '-':
+				oldLeft--
+			case '+':
+				newLeft--
+			case '\\':
+				noNewline(&frag)
+				i++
+				continue
+			default:
+				return 0, fmt.Errorf("%w at line %d: hunk ends early", ErrCorrupt, i+1)
+			}
+			frag.Lines = append(frag.Lines, Line{Op: op, Text: text})
+			i++
+		}
+		if oldLeft != 0 || newLeft != 0 {
+			return 0, fmt.Errorf("%w at line %d: hunk has wrong line counts", ErrCorrupt, start+1)
+		}
+		if i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+			noNewline(&frag)
+			i++
+		}
+		f.Fragments = append(f.Fragments, frag)
+	}
+	return i, nil
+}
+
+// noNewline: "\ No newline at end of file" 은 바로 앞 줄의 줄바꿈을 없앤다
+func noNewline(frag *Fragment) {
+	if n := len(frag.Lines); n > 0 {
+		frag.Lines[n-1].Text = strings.TrimSuffix(frag.Lines[n-1].Text, "\n")
+	}
+}
+
+// parseHunkHeader: "@@ -a[,b] +c[,d] @@ ..." (줄 수를 생략하면 1)
+func parseHunkHeader(line string, frag *Fragment) error {
+	rest := strings.TrimPrefix(line, "@@ -")
+	ranges, _, ok := strings.Cut(rest, " @@")
+	if !ok {
+		return errors.New("bad hunk header")
+	}
+	oldRange, newRange, ok := strings.Cut(ranges, " +")
+	if !ok {
+		return errors.New("bad hunk header")
+	}
+	var err error
+	if frag.OldStart, frag.OldLines, err = parseRange(oldRange); err != nil {
+		return err
+	}
+	frag.NewStart, frag.NewLines, err = parseRange(newRange)
+	return err
+}
+
+func parseRange(s string) (start, count int, err error) {
+	first, second, ok := strings.Cut(s, ",")
+	if start, err = strconv.Atoi(first); err != nil {
+		return 0, 0, errors.New("bad hunk header")
+	}
+	count = 1
+	if ok {
+		if count, err = strconv.Atoi(second); err != nil {
+			return 0, 0, errors.New("bad hunk header")
+		}
+	}
+	return start, count, nil
+}
+
+// Reverse: 거꾸로 적용하는 patch (git apply -R)
+func (f *File) Reverse() *File {
+	r := &File{
+		OldPath: f.NewPath, NewPath: f.OldPath,
+		OldMode: f.NewMode, NewMode: f.OldMode,
+		IsNew: f.IsDelete, IsDelete: f.IsNew,
+		IsRename: f.IsRename, IsCopy: f.IsCopy,
+		OldHash: f.NewHash, NewHash: f.OldHash,
+		Binary: f.Binary, Forward: f.Backward, Backward: f.Forward,
+	}
+	for _, frag := range f.Fragments {
+		rf := Fragment{OldStart: frag.NewStart, OldLines: frag.NewLines, NewStart: frag.OldStart, NewLines: frag.OldLines}
+		for _, l := range frag.Lines {
+			switch l.Op {
+			case '-':
+				l.Op = '+'
+			case '+':
+				l.Op = '-'
+			}
+			rf.Lines = append(rf.Lines, l)
+		}
+		r.Fragments = append(r.Fragments, rf)
+	}
+	return r
+}
+
+// Path: 에러 메시지에 쓸 경로
+func (f *File) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// Apply: old 내용에 hunk 들을 차례로 적용한 결과.
+// 각 hunk 의 바뀌기 전 줄들(문맥과 지우는 줄)이 그대로 있는 곳을 적힌 줄 번호에서 가까운 곳부터 찾는다.
+// git 과 같이 앞쪽 문맥이 없는 첫 줄 hunk 는 파일 처음에, 뒤쪽 문맥이 없는 hunk 는 파일 끝에 맞아야 한다.
+func (f *File) Apply(old []byte) ([]byte, error) {
+	if f.Binary {
+		return f.applyBinary(old)
+	}
+	lines := diff.SplitLines(old)
+	var out strings.Builder
+	pos, offset := 0, 0
+	for _, frag := range f.Fragments {
+		var pre, post []string
+		for _, l := range frag.Lines {
+			if l.Op != '+' {
+				pre = append(pre, l.Text)
+			}
+			if l.Op != '-' {
+				post = append(post, l.Text)
+			}
+		}
+		trailing := 0
+		for i := len(frag.Lines) - 1; i >= 0 && frag.Lines[i].Op == ' '; i-- {
+			trailing++
+		}
+		matchBegin := frag.OldStart <= 1
+		matchEnd := trailing == 0
+
+		expected := max(frag.OldStart-1, 0) + offset
+		if frag.OldLines == 0 {
+			// 지우는 줄도 문맥도 없는 hunk 는 OldStart 줄 뒤에 넣는다
+			expected = frag.OldStart + offset
+		}
+		at := -1
+		for d := 0; at < 0; d++ {
+			lo, hi := expected-d, expected+d
+			if lo < pos && hi > len(lines)-len(pre) {
+				break
+			}
+			for _, c := range []int{lo, hi} {
+				if c < pos || c > len(lines)-len(pre) || matchBegin && c != 0 || matchEnd && c != len(lines)-len(pre) {
+					continue
+				}
+				if equalLines(lines[c:c+len(pre)], pre) {
+					at = c
+					break
+				}
+			}
+		}
+		if at < 0 {
+			return nil, &FailedError{Path: f.Path(), Line: frag.OldStart}
+		}
+		for _, l := range lines[pos:at] {
+			out.WriteString(l)
+		}
+		for _, l := range post {
+			out.WriteString(l)
+		}
+		pos = at + len(pre)
+		offset = at - expected + offset
+	}
+	for _, l := range lines[pos:] {
+		out.WriteString(l)
+	}
+	return []byte(out.String()), nil
+}
+
+// applyBinary: binary patch 는 위치를 찾을 수 없으므로 옛 내용의 해시가 index 줄과 같아야 한다
+func (f *File) applyBinary(old []byte) ([]byte, error) {
+	if f.Forward == nil || !object.IsHash(f.OldHash) && !f.IsNew {
+		return nil, fmt.Errorf("cannot apply binary patch to '%s' without full index line", f.Path())
+	}
+	if !f.IsNew && object.AlgorithmOf(f.OldHash).Sum(object.Format(object.TypeBlob, old)) != f.OldHash {
+		return nil, fmt.Errorf("the patch applies to '%s' (%s), which does not match the current contents.", f.Path(), f.OldHash)
+	}
+	if f.Forward.Delta {
+		return object.ApplyDelta(old, f.Forward.Data)
+	}
+	return f.Forward.Data, nil
+}
+
+func equalLines(a, b []string) bool {
+	for i := range b {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
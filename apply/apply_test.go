@@ -0,0 +1,145 @@
+package apply
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+const gitPatch = `From 1234 Mon Sep 17 00:00:00 2001
+Subject: [PATCH] example
+
+---
+diff --git a/a.txt b/a.txt
+index 1111111..2222222 100644
+--- a/a.txt
++++ b/a.txt
+@@ -2,3 +2,3 @@
+ two
+-three
++THREE
+ four
+diff --git a/new.sh b/new.sh
+new file mode 100755
+index 0000000..3333333
+--- /dev/null
++++ b/new.sh
+@@ -0,0 +1 @@
++echo hi
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+index 4444444..0000000
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/x.txt b/y.txt
+similarity index 100%
+rename from x.txt
+rename to y.txt
+diff --git a/run b/run
+old mode 100644
+new mode 100755
+-- 
+2.40.0
+`
+
+func TestParseGitHeaders(t *testing.T) {
+	files, err := Parse([]byte(gitPatch), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 5 {
+		t.Fatalf("got %d patches, want 5", len(files))
+	}
+	modify, create, remove, rename, chmod := files[0], files[1], files[2], files[3], files[4]
+	if modify.OldPath != "a.txt" || modify.NewPath != "a.txt" || modify.OldHash != "1111111" || len(modify.Fragments) != 1 {
+		t.Errorf("modify = %+v", modify)
+	}
+	if !create.IsNew || create.OldPath != "" || create.NewPath != "new.sh" || create.NewMode != object.ModeExecutable {
+		t.Errorf("create = %+v", create)
+	}
+	if !remove.IsDelete || remove.OldPath != "old.txt" || remove.NewPath != "" {
+		t.Errorf("remove = %+v", remove)
+	}
+	if !rename.IsRename || rename.OldPath != "x.txt" || rename.NewPath != "y.txt" || len(rename.Fragments) != 0 {
+		t.Errorf("rename = %+v", rename)
+	}
+	if chmod.OldMode != object.ModeRegular || chmod.NewMode != object.ModeExecutable {
+		t.Errorf("chmod = %+v", chmod)
+	}
+}
+
+func TestParseTraditional(t *testing.T) {
+	patch := "--- orig/a.txt\t2024-01-01 00:00:00\n+++ new/a.txt\t2024-01-01 00:00:00\n@@ -1 +1 @@\n-a\n+b\n"
+	files, err := Parse([]byte(patch), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].OldPath != "a.txt" || files[0].NewPath != "a.txt" {
+		t.Fatalf("files = %+v", files[0])
+	}
+	out, err := files[0].Apply([]byte("a\n"))
+	if err != nil || string(out) != "b\n" {
+		t.Errorf("Apply = %q, %v", out, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse([]byte("just some text\n"), 1); err == nil {
+		t.Error("no patches: no error")
+	}
+	short := "--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n a\n-b\n"
+	if _, err := Parse([]byte(short), 1); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("short hunk: err = %v, want ErrCorrupt", err)
+	}
+	header := "--- a/a.txt\n+++ b/a.txt\n@@ -x +1 @@\n-a\n+b\n"
+	if _, err := Parse([]byte(header), 1); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("bad header: err = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestApplyWithOffset(t *testing.T) {
+	files, err := Parse([]byte(gitPatch), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// hunk 는 2번째 줄부터라고 적혀 있지만 앞에 두 줄이 더 있어도 맞는 곳을 찾는다
+	old := "zero\nzero\none\ntwo\nthree\nfour\n"
+	out, err := files[0].Apply([]byte(old))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "zero\nzero\none\ntwo\nTHREE\nfour\n"; string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	back, err := files[0].Reverse().Apply(out)
+	if err != nil || string(back) != old {
+		t.Errorf("Reverse = %q, %v; want %q", back, err, old)
+	}
+}
+
+func TestApplyFailed(t *testing.T) {
+	files, err := Parse([]byte(gitPatch), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = files[0].Apply([]byte("one\ntwo\nsomething else\nfour\n"))
+	var failed *FailedError
+	if !errors.As(err, &failed) || failed.Path != "a.txt" || failed.Line != 2 {
+		t.Fatalf("err = %v, want patch failed: a.txt:2", err)
+	}
+}
+
+func TestApplyNoNewline(t *testing.T) {
+	patch := "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n"
+	files, err := Parse([]byte(patch), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := files[0].Apply([]byte("a"))
+	if err != nil || string(out) != "a\n" {
+		t.Errorf("Apply = %q, %v; want %q", out, err, "a\n")
+	}
+}
@@ -0,0 +1,247 @@
+// Package archive 는 tree 하나를 tar 나 zip 으로 내보낸다. (git archive)
+//
+//	archive.Write(ctx, os.Stdout, store, treeHash, archive.Options{Format: archive.Tar, ModTime: commit.Committer.When})
+//
+// 같은 tree 와 옵션이면 항상 같은 바이트가 나온다. 모든 항목의 시간은 ModTime 이고,
+// 권한은 git 과 같이 파일 0664, 실행 파일과 디렉토리 0775, 심볼릭 링크 0777 이다.
+// submodule(gitlink)은 빈 디렉토리로 들어간다.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Format: 아카이브 형식
+type Format string
+
+const (
+	Tar Format = "tar"
+	Zip Format = "zip"
+)
+
+// FormatFor: 파일 이름의 확장자로 형식을 고른다. (.tar, .zip) 모르는 확장자면 ok 가 false
+func FormatFor(name string) (Format, bool) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".tar":
+		return Tar, true
+	case ".zip":
+		return Zip, true
+	}
+	return "", false
+}
+
+// ParseFormat: --format 값
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case Tar, Zip:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown archive format '%s'", s)
+}
+
+// Options: 내보내기 옵션
+type Options struct {
+	Format Format
+	// Prefix: 모든 경로 앞에 붙는다. 디렉토리로 쓰려면 "project/" 처럼 / 로 끝나야 한다.
+	Prefix string
+	// ModTime: 모든 항목의 수정 시간. 보통 커밋의 committer 시간
+	ModTime time.Time
+	// Commit: tree 를 가진 커밋. 비어 있지 않으면 git 처럼 tar 의 pax 전역 헤더 / zip 의 주석에 남긴다.
+	Commit string
+}
+
+// entry: 아카이브에 들어가는 항목 하나
+type entry struct {
+	name string
+	mode fs.FileMode
+	hash string
+	// size: blob 의 크기 (디렉토리는 0)
+	size int64
+	// link: 심볼릭 링크의 대상
+	link string
+}
+
+type writer interface {
+	add(e entry, content io.Reader) error
+	Close() error
+}
+
+// Write: tree 를 opts.Format 형식으로 w 에 쓴다. blob 은 메모리에 올리지 않고 흘려 보낸다.
+func Write(ctx context.Context, w io.Writer, s object.Storer, tree string, opts Options) error {
+	var aw writer
+	switch opts.Format {
+	case Tar, "":
+		tw, err := newTarWriter(w, opts)
+		if err != nil {
+			return err
+		}
+		aw = tw
+	case Zip:
+		aw = newZipWriter(w, opts)
+	default:
+		return fmt.Errorf("unknown archive format '%s'", opts.Format)
+	}
+
+	if dir := strings.TrimSuffix(opts.Prefix, "/"); opts.Prefix != "" && strings.HasSuffix(opts.Prefix, "/") {
+		// git 과 같이 접두사 디렉토리 자체도 항목으로 넣는다
+		if err := aw.add(entry{name: dir + "/", mode: fs.ModeDir | 0775}, nil); err != nil {
+			return err
+		}
+	}
+
+	walker, err := object.NewTreeWalker(s, tree)
+	if err != nil {
+		return err
+	}
+	err = walker.ForEachContext(ctx, func(we object.WalkEntry) error {
+		e := entry{name: opts.Prefix + we.Path, hash: we.Hash}
+		switch we.Mode {
+		case object.ModeTree, object.ModeGitlink:
+			e.name += "/"
+			e.mode = fs.ModeDir | 0775
+			return aw.add(e, nil)
+		case object.ModeSymlink:
+			data, err := readBlob(s, we.Hash)
+			if err != nil {
+				return err
+			}
+			e.mode = fs.ModeSymlink | 0777
+			e.link = string(data)
+			return aw.add(e, nil)
+		case object.ModeExecutable:
+			e.mode = 0775
+		default:
+			e.mode = 0664
+		}
+		r, err := s.Open(we.Hash)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if r.Type != object.TypeBlob {
+			return fmt.Errorf("%w: %s is a %s, not a blob", object.ErrWrongType, we.Hash, r.Type)
+		}
+		// 손상된 내용을 아카이브에 쓰지 않도록 큰 파일도 먼저 해시를 확인한다
+		if err := r.Spool(); err != nil {
+			return err
+		}
+		e.size = r.Size
+		return aw.add(e, r)
+	})
+	if err != nil {
+		return err
+	}
+	return aw.Close()
+}
+
+func readBlob(s object.Storer, hash string) ([]byte, error) {
+	r, err := s.Open(hash)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+type tarWriter struct {
+	tw      *tar.Writer
+	modTime time.Time
+}
+
+func newTarWriter(w io.Writer, opts Options) (*tarWriter, error) {
+	t := &tarWriter{tw: tar.NewWriter(w), modTime: opts.ModTime}
+	if opts.Commit != "" {
+		// git get-tar-commit-id 가 읽는 전역 헤더
+		err := t.tw.WriteHeader(&tar.Header{
+			Typeflag:   tar.TypeXGlobalHeader,
+			PAXRecords: map[string]string{"comment": opts.Commit},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (t *tarWriter) add(e entry, content io.Reader) error {
+	hdr := &tar.Header{
+		Name:    e.name,
+		Mode:    int64(e.mode.Perm()),
+		Size:    e.size,
+		ModTime: t.modTime,
+		Uname:   "root",
+		Gname:   "root",
+	}
+	switch {
+	case e.mode.IsDir():
+		hdr.Typeflag = tar.TypeDir
+	case e.mode&fs.ModeSymlink != 0:
+		hdr.Typeflag = tar.TypeSymlink
+		hdr.Linkname = e.link
+	default:
+		hdr.Typeflag = tar.TypeReg
+	}
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if content != nil {
+		if _, err := io.Copy(t.tw, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *tarWriter) Close() error {
+	return t.tw.Close()
+}
+
+type zipWriter struct {
+	zw      *zip.Writer
+	modTime time.Time
+}
+
+func newZipWriter(w io.Writer, opts Options) *zipWriter {
+	z := &zipWriter{zw: zip.NewWriter(w), modTime: opts.ModTime}
+	if opts.Commit != "" {
+		z.zw.SetComment(opts.Commit)
+	}
+	return z
+}
+
+func (z *zipWriter) add(e entry, content io.Reader) error {
+	hdr := &zip.FileHeader{Name: e.name, Modified: z.modTime, Method: zip.Deflate}
+	hdr.SetMode(e.mode)
+	if e.mode.IsDir() {
+		hdr.Method = zip.Store
+	}
+	if e.mode&fs.ModeSymlink != 0 {
+		// zip 의 심볼릭 링크는 대상 경로를 내용으로 가진 항목이다
+		hdr.Method = zip.Store
+		content = strings.NewReader(e.link)
+	}
+	w, err := z.zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	if content != nil {
+		if _, err := io.Copy(w, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (z *zipWriter) Close() error {
+	return z.zw.Close()
+}
@@ -0,0 +1,161 @@
+// Package attr 는 .gitattributes 형식의 경로별 속성을 읽는다.
+//
+//	rules := attr.Parse(data)
+//	driver, ok := rules.Get("cmd/main.go", "diff")
+//
+// 한 줄은 "<패턴> <속성>..." 이고 속성은 네 가지로 적는다.
+//
+//	name        켠다 (값 "true")
+//	-name       끈다 (값 "false")
+//	!name       정하지 않은 상태로 되돌린다
+//	name=value  값을 준다
+//
+// "/" 가 없는 패턴은 파일 이름과, 있는 패턴은 루트 기준 전체 경로와 맞춰 본다. "*", "?", "[...]", "**" 를 쓸 수 있다.
+// 같은 속성을 여러 줄이 정하면 뒤의 줄이 이긴다. binary 는 git 과 같이 -diff -merge -text 를 뜻한다.
+package attr
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Rules: 파싱한 속성 규칙
+type Rules struct {
+	rules []rule
+}
+
+type rule struct {
+	pattern *regexp.Regexp
+	// basename: "/" 가 없는 패턴이라 파일 이름과만 맞춰 본다
+	basename bool
+	attrs    []attribute
+}
+
+type attribute struct {
+	name string
+	// value: nil 이면 정하지 않은 상태로 되돌린다 (!name)
+	value *string
+}
+
+// macros: git 이 미리 정의해 둔 속성 묶음
+var macros = map[string][]string{
+	"binary": {"-diff", "-merge", "-text"},
+}
+
+// Parse: .gitattributes 형식의 내용들을 읽는다. 뒤에 준 내용의 규칙이 우선한다.
+// 해석할 수 없는 패턴의 줄은 git 과 같이 무시한다.
+func Parse(data ...[]byte) *Rules {
+	r := &Rules{}
+	for _, d := range data {
+		for _, line := range strings.Split(string(d), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			rl, ok := parseRule(fields[0], fields[1:])
+			if ok {
+				r.rules = append(r.rules, rl)
+			}
+		}
+	}
+	return r
+}
+
+func parseRule(pattern string, fields []string) (rule, bool) {
+	rl := rule{basename: !strings.Contains(strings.TrimSuffix(pattern, "/"), "/")}
+	re, err := regexp.Compile(GlobRegexp(strings.TrimPrefix(pattern, "/")))
+	if err != nil {
+		return rule{}, false
+	}
+	rl.pattern = re
+	for _, f := range fields {
+		if expanded, ok := macros[f]; ok {
+			for _, m := range expanded {
+				rl.attrs = append(rl.attrs, parseAttribute(m))
+			}
+		}
+		rl.attrs = append(rl.attrs, parseAttribute(f))
+	}
+	return rl, true
+}
+
+func parseAttribute(f string) attribute {
+	value := func(v string) *string { return &v }
+	switch {
+	case strings.HasPrefix(f, "-"):
+		return attribute{name: f[1:], value: value("false")}
+	case strings.HasPrefix(f, "!"):
+		return attribute{name: f[1:]}
+	}
+	if name, v, ok := strings.Cut(f, "="); ok {
+		return attribute{name: name, value: value(v)}
+	}
+	return attribute{name: f, value: value("true")}
+}
+
+// GlobRegexp: gitattributes 와 gitignore 의 glob 을 정규식으로 바꾼다.
+// "*" 와 "?" 는 "/" 를 넘지 않고, "**/" 는 디렉토리 여러 단계(없음 포함), "/**" 는 그 아래 전부다.
+func GlobRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// Get: 경로("/" 로 구분된 루트 기준 경로)의 속성 값. 어느 규칙도 정하지 않았으면 false
+func (r *Rules) Get(p string, name string) (string, bool) {
+	base := p
+	if i := strings.LastIndexByte(p, '/'); i >= 0 {
+		base = p[i+1:]
+	}
+	for i := len(r.rules) - 1; i >= 0; i-- {
+		rl := r.rules[i]
+		target := p
+		if rl.basename {
+			target = base
+		}
+		if !rl.pattern.MatchString(target) {
+			continue
+		}
+		for j := len(rl.attrs) - 1; j >= 0; j-- {
+			if a := rl.attrs[j]; a.name == name {
+				if a.value == nil {
+					return "", false
+				}
+				return *a.value, true
+			}
+		}
+	}
+	return "", false
+}
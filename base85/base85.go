@@ -0,0 +1,99 @@
+// Package base85 는 git 의 binary patch 가 쓰는 base85 인코딩이다.
+// 4 바이트를 big-endian 정수로 읽어 85 진법 5 글자로 쓴다. 글자표는 git 과 같다. (RFC 1924 와 같은 글자들)
+//
+// binary patch 의 한 줄은 길이 글자('A'-'Z' 는 1-26, 'a'-'z' 는 27-52 바이트)와 그 줄의 인코딩이다.
+//
+//	EncodeLine(data[:52])  // "z" + 65 글자
+package base85
+
+import (
+	"errors"
+	"fmt"
+)
+
+const alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz!#$%&()*+-;<=>?@^_`{|}~"
+
+// MaxLine: 한 줄에 담는 최대 바이트 수
+const MaxLine = 52
+
+var decodeTable [256]int
+
+func init() {
+	for i := range decodeTable {
+		decodeTable[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		decodeTable[alphabet[i]] = i
+	}
+}
+
+// Encode: data 를 인코딩한다. 길이가 4 의 배수가 아니면 0 을 채운다
+func Encode(data []byte) string {
+	out := make([]byte, 0, (len(data)+3)/4*5)
+	for i := 0; i < len(data); i += 4 {
+		var acc uint32
+		for j := 0; j < 4; j++ {
+			acc <<= 8
+			if i+j < len(data) {
+				acc |= uint32(data[i+j])
+			}
+		}
+		var group [5]byte
+		for j := 4; j >= 0; j-- {
+			group[j] = alphabet[acc%85]
+			acc /= 85
+		}
+		out = append(out, group[:]...)
+	}
+	return string(out)
+}
+
+// Decode: s 를 풀어 앞의 n 바이트를 돌려준다
+func Decode(s string, n int) ([]byte, error) {
+	if len(s)%5 != 0 || len(s)/5*4 < n {
+		return nil, errors.New("base85: bad length")
+	}
+	out := make([]byte, 0, len(s)/5*4)
+	for i := 0; i < len(s); i += 5 {
+		var acc uint64
+		for j := 0; j < 5; j++ {
+			d := decodeTable[s[i+j]]
+			if d < 0 {
+				return nil, fmt.Errorf("base85: invalid character %q", s[i+j])
+			}
+			acc = acc*85 + uint64(d)
+		}
+		if acc > 0xffffffff {
+			return nil, errors.New("base85: overflow")
+		}
+		out = append(out, byte(acc>>24), byte(acc>>16), byte(acc>>8), byte(acc))
+	}
+	return out[:n], nil
+}
+
+// EncodeLine: 길이 글자를 붙인 한 줄. data 는 MaxLine 바이트 이하여야 한다
+func EncodeLine(data []byte) string {
+	n := len(data)
+	c := byte('A' + n - 1)
+	if n > 26 {
+		c = byte('a' + n - 27)
+	}
+	return string(c) + Encode(data)
+}
+
+// DecodeLine: EncodeLine 으로 쓴 한 줄을 푼다
+func DecodeLine(line string) ([]byte, error) {
+	if line == "" {
+		return nil, errors.New("base85: empty line")
+	}
+	var n int
+	switch c := line[0]; {
+	case c >= 'A' && c <= 'Z':
+		n = int(c-'A') + 1
+	case c >= 'a' && c <= 'z':
+		n = int(c-'a') + 27
+	default:
+		return nil, fmt.Errorf("base85: invalid line length %q", c)
+	}
+	return Decode(line[1:], n)
+}
@@ -0,0 +1,127 @@
+// Package bisect 는 나쁜 커밋과 좋은 커밋들 사이에서 다음에 시험할 커밋을 고른다. (git bisect)
+//
+//	next, _ := bisect.Next(ctx, store, bad, goods, skipped)
+//	if next.Done { fmt.Println(next.Commit, "is the first bad commit") }
+//
+// 후보는 나쁜 커밋에서 도달 가능하지만 어떤 좋은 커밋에서도 도달할 수 없는 커밋들이다.
+// 후보마다 자기에게서 도달 가능한 후보의 수(w)를 세고, 전체가 n 일 때 min(w, n-w) 가 가장 큰 커밋을 고른다.
+// 그 커밋이 좋든 나쁘든 후보가 절반 가까이 줄어든다.
+package bisect
+
+import (
+	"context"
+	"errors"
+	"math/bits"
+	"slices"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// ErrBadIsGood: 나쁜 커밋이 좋은 커밋의 조상이다
+var ErrBadIsGood = errors.New("the bad commit is an ancestor of a good commit")
+
+// Step: Next 의 결과
+type Step struct {
+	// Commit: 다음에 시험할 커밋. Done 이면 처음으로 나빠진 커밋
+	Commit string
+	Done   bool
+	// Left: Commit 을 시험한 뒤에도 남는 후보 수 (많은 쪽 기준)
+	Left int
+	// Steps: 끝날 때까지 대략 남은 시험 횟수
+	Steps int
+	// Skipped: 건너뛴 커밋만 남았을 때, 처음으로 나빠진 커밋일 수 있는 커밋들 (나쁜 커밋 포함). 이때 Commit 은 비어 있다
+	Skipped []string
+}
+
+// Next: bad 와 good 들 사이에서 다음에 시험할 커밋을 고른다. skip 의 커밋은 고르지 않는다.
+func Next(ctx context.Context, s object.Storer, bad string, good []string, skip map[string]bool) (*Step, error) {
+	hidden, err := object.Reachable(ctx, s, good)
+	if err != nil {
+		return nil, err
+	}
+	if hidden[bad] {
+		return nil, ErrBadIsGood
+	}
+
+	// 자식이 부모보다 먼저 나온다
+	var order []string
+	parents := map[string][]string{}
+	it := object.NewCommitIter(s, []string{bad}, object.OrderTopo)
+	it.Hide(hidden)
+	err = it.ForEachContext(ctx, func(hash string, c *object.Commit) error {
+		order = append(order, hash)
+		for _, p := range c.Parents {
+			if !hidden[p] {
+				parents[hash] = append(parents[hash], p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 부모부터 센다. 후보 부모가 하나면 부모의 수에 하나를 더하면 되고, merge 만 직접 센다.
+	// (후보가 아닌 부모의 조상은 모두 좋은 커밋에서 도달 가능하므로 후보가 아니다)
+	weight := make(map[string]int, len(order))
+	for _, hash := range slices.Backward(order) {
+		switch ps := parents[hash]; len(ps) {
+		case 0:
+			weight[hash] = 1
+		case 1:
+			weight[hash] = weight[ps[0]] + 1
+		default:
+			weight[hash] = countAncestors(hash, parents)
+		}
+	}
+
+	n := len(order)
+	best, bestDist := "", -1
+	for _, hash := range order {
+		if skip[hash] && hash != bad {
+			continue
+		}
+		w := weight[hash]
+		if dist := min(w, n-w); dist > bestDist {
+			best, bestDist = hash, dist
+		}
+	}
+	if best == bad {
+		if n > 1 {
+			// 나쁜 커밋 말고 남은 후보는 모두 건너뛴 커밋이다
+			return &Step{Skipped: order}, nil
+		}
+		return &Step{Commit: bad, Done: true}, nil
+	}
+	return &Step{Commit: best, Left: n - weight[best] - 1, Steps: estimateSteps(n)}, nil
+}
+
+// countAncestors: hash 에서 도달 가능한 후보의 수 (자기 포함)
+func countAncestors(hash string, parents map[string][]string) int {
+	seen := map[string]bool{hash: true}
+	stack := []string{hash}
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, p := range parents[h] {
+			if !seen[p] {
+				seen[p] = true
+				stack = append(stack, p)
+			}
+		}
+	}
+	return len(seen)
+}
+
+// estimateSteps: 후보가 n 개일 때 남은 시험 횟수의 어림값 (git 과 같은 계산)
+func estimateSteps(n int) int {
+	if n < 3 {
+		return 0
+	}
+	log := bits.Len(uint(n)) - 1
+	x := n - 1<<log
+	if 1<<log < 3*x {
+		return log
+	}
+	return log - 1
+}
@@ -0,0 +1,148 @@
+package bisect
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// commitGraph: 빈 tree 를 가리키는 커밋들을 만든다. parents[i] 는 i 번째 커밋의 부모 번호들이다.
+func commitGraph(t *testing.T, parents [][]int) (*object.MemoryStore, []string) {
+	t.Helper()
+	s := object.NewMemoryStore()
+	tree, err := object.WriteObject(s, &object.Tree{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := object.Signature{Name: "T", Email: "t@example.com", When: time.Unix(1700000000, 0).UTC()}
+	hashes := make([]string, len(parents))
+	for i, ps := range parents {
+		c := &object.Commit{Tree: tree, Author: sig, Committer: sig, Message: string(rune('a'+i)) + "\n"}
+		for _, p := range ps {
+			c.Parents = append(c.Parents, hashes[p])
+		}
+		sig.When = sig.When.Add(time.Minute)
+		if hashes[i], err = object.WriteObject(s, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, hashes
+}
+
+// linear: 0 <- 1 <- ... <- n-1
+func linear(n int) [][]int {
+	parents := make([][]int, n)
+	for i := 1; i < n; i++ {
+		parents[i] = []int{i - 1}
+	}
+	return parents
+}
+
+// TestNextFindsFirstBad: 어떤 커밋이 처음으로 나빠졌든 좋고 나쁨을 표시해 나가면 그 커밋에 도달한다.
+func TestNextFindsFirstBad(t *testing.T) {
+	ctx := context.Background()
+	s, hashes := commitGraph(t, linear(16))
+	for culprit := 1; culprit < len(hashes); culprit++ {
+		bad, good := hashes[len(hashes)-1], []string{hashes[0]}
+		tests := 0
+		for {
+			step, err := Next(ctx, s, bad, good, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if step.Done {
+				if step.Commit != hashes[culprit] {
+					t.Errorf("culprit %d: found %s", culprit, step.Commit)
+				}
+				break
+			}
+			tests++
+			if slices.Index(hashes, step.Commit) >= culprit {
+				bad = step.Commit
+			} else {
+				good = append(good, step.Commit)
+			}
+		}
+		// 후보 15 개는 4 번 안에 끝난다
+		if tests > 4 {
+			t.Errorf("culprit %d: %d tests", culprit, tests)
+		}
+	}
+}
+
+func TestNextPicksMidpoint(t *testing.T) {
+	s, hashes := commitGraph(t, linear(9))
+	step, err := Next(context.Background(), s, hashes[8], []string{hashes[0]}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 후보는 1..8 의 8 개. 4 를 시험하면 어느 쪽이든 4 개가 남는다
+	if step.Commit != hashes[4] && step.Commit != hashes[5] {
+		t.Errorf("picked %d", slices.Index(hashes, step.Commit))
+	}
+	if step.Done || step.Steps != 2 {
+		t.Errorf("step = %+v", step)
+	}
+}
+
+func TestNextMerge(t *testing.T) {
+	//     1 - 2
+	//   /       \
+	// 0           5 - 6
+	//   \       /
+	//     3 - 4
+	s, hashes := commitGraph(t, [][]int{{}, {0}, {1}, {0}, {3}, {2, 4}, {5}})
+	step, err := Next(context.Background(), s, hashes[6], []string{hashes[0]}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 후보 6 개 중 5 는 5 개, 2 와 4 는 2 개의 후보에 도달하므로 2 나 4 가 반에 가장 가깝다
+	if i := slices.Index(hashes, step.Commit); i != 2 && i != 4 {
+		t.Errorf("picked %d", i)
+	}
+}
+
+func TestNextSkipped(t *testing.T) {
+	s, hashes := commitGraph(t, linear(4))
+	skip := map[string]bool{hashes[1]: true, hashes[2]: true}
+	step, err := Next(context.Background(), s, hashes[3], []string{hashes[0]}, skip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step.Commit != "" || step.Done || len(step.Skipped) != 3 {
+		t.Fatalf("step = %+v", step)
+	}
+	for _, h := range hashes[1:] {
+		if !slices.Contains(step.Skipped, h) {
+			t.Errorf("Skipped misses %d", slices.Index(hashes, h))
+		}
+	}
+
+	// 하나만 건너뛰면 다른 후보를 고른다
+	step, err = Next(context.Background(), s, hashes[3], []string{hashes[0]}, map[string]bool{hashes[2]: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step.Commit != hashes[1] {
+		t.Errorf("picked %d, want 1", slices.Index(hashes, step.Commit))
+	}
+}
+
+func TestNextBadIsGood(t *testing.T) {
+	s, hashes := commitGraph(t, linear(3))
+	if _, err := Next(context.Background(), s, hashes[0], []string{hashes[2]}, nil); !errors.Is(err, ErrBadIsGood) {
+		t.Errorf("err = %v, want ErrBadIsGood", err)
+	}
+}
+
+func TestEstimateSteps(t *testing.T) {
+	for n, want := range map[int]int{1: 0, 2: 0, 3: 1, 4: 1, 8: 2, 15: 3, 16: 3, 1000: 9} {
+		if got := estimateSteps(n); got != want {
+			t.Errorf("estimateSteps(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// Package blame 은 파일의 줄마다 그 줄을 마지막으로 바꾼 커밋을 찾는다. (git blame)
+//
+//	res, _ := blame.File(ctx, store, head, "src/main.go", blame.Options{Start: 10, End: 20})
+//	res.WriteText(os.Stdout)
+//
+// 커밋을 최근 것부터 거슬러 올라가며, 부모와 diff 해서 바뀌지 않은 줄의 책임을 부모에게 넘긴다.
+// 어느 부모에게도 넘기지 못한 줄이 그 커밋의 줄이다. merge 는 첫 부모부터 차례로 넘긴다.
+// 부모에 같은 경로가 없으면 내용이 같은 rename 을 따라간다. (내용이 바뀐 rename 과 복사는 따라가지 않는다)
+package blame
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/tmdgusya/gogit/diff"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Options: 찾을 줄 범위
+type Options struct {
+	// Start, End: 1부터 센 줄 번호 (양 끝 포함). 0 이면 파일의 처음/끝
+	Start, End int
+}
+
+// Line: 결과 파일의 줄 하나
+type Line struct {
+	// Commit: 이 줄을 들여온 커밋
+	Commit string
+	// Path, Number: 그 커밋에서의 경로와 줄 번호 (1부터). 이름이 바뀐 파일이면 Path 가 지금 경로와 다르다
+	Path   string
+	Number int
+	// Final: 결과 파일에서의 줄 번호 (1부터)
+	Final int
+	// Text: 줄 내용 (줄바꿈 포함. 파일 끝의 마지막 줄은 없을 수 있다)
+	Text string
+	// Boundary: 뿌리 커밋이라 더 거슬러 올라갈 수 없다
+	Boundary bool
+	// Previous, PreviousPath: 그 커밋의 첫 부모에 있던 같은 파일. 그 커밋에서 생긴 파일이면 비어 있다
+	Previous     string
+	PreviousPath string
+}
+
+// Result: File 의 결과
+type Result struct {
+	Path  string
+	Lines []Line
+	// Commits: Lines 에 나온 커밋들
+	Commits map[string]*object.Commit
+}
+
+// entry: 아직 책임을 정하지 못한 줄. 둘 다 0부터 센다
+type entry struct {
+	final int
+	line  int
+}
+
+// suspect: 어떤 커밋의 어떤 경로 버전에 남은 줄들
+type suspect struct {
+	commit  string
+	c       *object.Commit
+	path    string
+	blob    string
+	lines   []string
+	entries []entry
+}
+
+type blamer struct {
+	ctx     context.Context
+	s       object.Storer
+	commits map[string]*object.Commit
+	// pending: 아직 처리하지 않은 suspect (같은 커밋과 경로로 넘어온 줄을 모은다)
+	pending map[string]*suspect
+	queue   suspectQueue
+	result  *Result
+}
+
+// File: commit 의 path 파일을 blame 한다.
+func File(ctx context.Context, s object.Storer, commit, path string, opts Options) (*Result, error) {
+	b := &blamer{
+		ctx:     ctx,
+		s:       s,
+		commits: map[string]*object.Commit{},
+		pending: map[string]*suspect{},
+		result:  &Result{Path: path, Commits: map[string]*object.Commit{}},
+	}
+	c, err := b.commit(commit)
+	if err != nil {
+		return nil, err
+	}
+	file, ok, err := object.LookupPath(s, c.Tree, path)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || file.Mode.ObjectType() != object.TypeBlob {
+		return nil, fmt.Errorf("no such path '%s' in %s", path, commit)
+	}
+	sus, err := b.suspect(commit, path, file.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := opts.Start, opts.End
+	if start == 0 {
+		start = 1
+	}
+	if end == 0 || end > len(sus.lines) {
+		end = len(sus.lines)
+	}
+	if start < 1 || (start > len(sus.lines) && len(sus.lines) > 0) {
+		return nil, fmt.Errorf("file %s has only %d lines", path, len(sus.lines))
+	}
+	if start > end && len(sus.lines) > 0 {
+		return nil, fmt.Errorf("invalid line range %d,%d", opts.Start, opts.End)
+	}
+	for i := start - 1; i < end; i++ {
+		sus.entries = append(sus.entries, entry{final: i, line: i})
+	}
+
+	for b.queue.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sus := heap.Pop(&b.queue).(*suspect)
+		delete(b.pending, sus.commit+"\x00"+sus.path)
+		if err := b.pass(sus); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(b.result.Lines, func(i, j int) bool { return b.result.Lines[i].Final < b.result.Lines[j].Final })
+	return b.result, nil
+}
+
+func (b *blamer) commit(hash string) (*object.Commit, error) {
+	if c, ok := b.commits[hash]; ok {
+		return c, nil
+	}
+	c, err := object.ReadCommit(b.s, hash)
+	if err != nil {
+		return nil, err
+	}
+	b.commits[hash] = c
+	return c, nil
+}
+
+// suspect: commit 의 path 버전. 처리를 기다리는 것이 있으면 그것을, 없으면 새로 만들어 큐에 넣는다
+func (b *blamer) suspect(commit, path, blob string) (*suspect, error) {
+	key := commit + "\x00" + path
+	if sus, ok := b.pending[key]; ok {
+		return sus, nil
+	}
+	c, err := b.commit(commit)
+	if err != nil {
+		return nil, err
+	}
+	_, content, err := b.s.Read(blob)
+	if err != nil {
+		return nil, err
+	}
+	sus := &suspect{commit: commit, c: c, path: path, blob: blob, lines: diff.SplitLines(content)}
+	b.pending[key] = sus
+	heap.Push(&b.queue, sus)
+	return sus, nil
+}
+
+// pass: 부모들에게 바뀌지 않은 줄을 넘기고, 남은 줄을 이 커밋의 것으로 정한다
+func (b *blamer) pass(sus *suspect) error {
+	remaining := sus.entries
+	var previous, previousPath string
+	for i, parent := range sus.c.Parents {
+		if len(remaining) == 0 {
+			break
+		}
+		path, blob, err := b.parentFile(sus, parent)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			continue
+		}
+		if i == 0 {
+			previous, previousPath = parent, path
+		}
+		target, err := b.suspect(parent, path, blob)
+		if err != nil {
+			return err
+		}
+		if blob == sus.blob {
+			target.entries = append(target.entries, remaining...)
+			remaining = nil
+			break
+		}
+		old := unchanged(diff.Lines(target.lines, sus.lines), len(sus.lines))
+		var kept []entry
+		for _, e := range remaining {
+			if o := old[e.line]; o >= 0 {
+				target.entries = append(target.entries, entry{final: e.final, line: o})
+			} else {
+				kept = append(kept, e)
+			}
+		}
+		remaining = kept
+	}
+
+	if len(remaining) > 0 {
+		b.result.Commits[sus.commit] = sus.c
+	}
+	for _, e := range remaining {
+		b.result.Lines = append(b.result.Lines, Line{
+			Commit:       sus.commit,
+			Path:         sus.path,
+			Number:       e.line + 1,
+			Final:        e.final + 1,
+			Text:         sus.lines[e.line],
+			Boundary:     len(sus.c.Parents) == 0,
+			Previous:     previous,
+			PreviousPath: previousPath,
+		})
+	}
+	return nil
+}
+
+// parentFile: 부모에서의 파일. 같은 경로가 없으면 이 커밋에서 이름만 바뀐 파일을 찾는다. 없으면 빈 문자열
+func (b *blamer) parentFile(sus *suspect, parent string) (path, blob string, err error) {
+	p, err := b.commit(parent)
+	if err != nil {
+		return "", "", err
+	}
+	if e, ok, err := object.LookupPath(b.s, p.Tree, sus.path); err != nil {
+		return "", "", err
+	} else if ok && e.Mode.ObjectType() == object.TypeBlob {
+		return sus.path, e.Hash, nil
+	}
+	changes, err := diff.Trees(b.s, p.Tree, sus.c.Tree)
+	if err != nil {
+		return "", "", err
+	}
+	for _, ch := range changes {
+		if ch.Status() == 'R' && ch.To.Path == sus.path {
+			return ch.From.Path, ch.From.Hash, nil
+		}
+	}
+	return "", "", nil
+}
+
+// unchanged: 새 파일의 줄마다 diff 에서 바뀌지 않은 옛 줄의 번호. 바뀐 줄이면 -1
+func unchanged(edits []diff.Edit, n int) []int {
+	old := make([]int, n)
+	oldPos, newPos := 0, 0
+	for _, e := range edits {
+		for newPos < e.NewPos {
+			old[newPos] = oldPos
+			oldPos++
+			newPos++
+		}
+		for i := 0; i < e.NewLen; i++ {
+			old[newPos] = -1
+			newPos++
+		}
+		oldPos += e.OldLen
+	}
+	for ; newPos < n; newPos++ {
+		old[newPos] = oldPos
+		oldPos++
+	}
+	return old
+}
+
+// suspectQueue: 커미터 시각이 늦은 커밋부터 (git 과 같은 순서)
+type suspectQueue []*suspect
+
+func (q suspectQueue) Len() int { return len(q) }
+func (q suspectQueue) Less(i, j int) bool {
+	ti, tj := q[i].c.Committer.When, q[j].c.Committer.When
+	if !ti.Equal(tj) {
+		return ti.After(tj)
+	}
+	return q[i].commit < q[j].commit
+}
+func (q suspectQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *suspectQueue) Push(x any)   { *q = append(*q, x.(*suspect)) }
+func (q *suspectQueue) Pop() any {
+	old := *q
+	sus := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return sus
+}
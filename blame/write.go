@@ -0,0 +1,112 @@
+package blame
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tmdgusya/gogit/diff"
+)
+
+// WriteText: git blame 의 기본 형식
+//
+//	1a2b3c4d (Author Name 2023-11-15 07:13:20 +0900  1) line
+//
+// 뿌리 커밋은 해시 앞에 ^ 를 붙인다. 다른 경로에서 온 줄이 있으면 해시 뒤에 그 커밋에서의 경로를 쓴다.
+func (r *Result) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	authorWidth, pathWidth, showPath := 0, 0, false
+	for _, l := range r.Lines {
+		authorWidth = max(authorWidth, utf8.RuneCountInString(r.Commits[l.Commit].Author.Name))
+		pathWidth = max(pathWidth, utf8.RuneCountInString(l.Path))
+		showPath = showPath || l.Path != r.Path
+	}
+	numberWidth := 1
+	if len(r.Lines) > 0 {
+		numberWidth = len(fmt.Sprint(r.Lines[len(r.Lines)-1].Final))
+	}
+
+	for _, l := range r.Lines {
+		author := r.Commits[l.Commit].Author
+		hash := l.Commit[:8]
+		if l.Boundary {
+			hash = "^" + l.Commit[:7]
+		}
+		bw.WriteString(hash)
+		if showPath {
+			fmt.Fprintf(bw, " %s%s", l.Path, strings.Repeat(" ", pathWidth-utf8.RuneCountInString(l.Path)))
+		}
+		pad := strings.Repeat(" ", authorWidth-utf8.RuneCountInString(author.Name))
+		fmt.Fprintf(bw, " (%s%s %s %*d) %s", author.Name, pad, author.When.Format("2006-01-02 15:04:05 -0700"), numberWidth, l.Final, l.Text)
+		if !strings.HasSuffix(l.Text, "\n") {
+			bw.WriteString("\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// WritePorcelain: 도구가 읽기 쉬운 git blame --porcelain 형식
+//
+//	<hash> <원래 줄> <지금 줄> <묶음의 줄 수>
+//	author ...                    (커밋이 처음 나올 때만)
+//	filename <경로>
+//	\t<줄 내용>
+//	<hash> <원래 줄> <지금 줄>      (같은 묶음의 다음 줄)
+//	\t<줄 내용>
+//
+// 묶음은 같은 커밋에서 온 이어진 줄들이다.
+func (r *Result) WritePorcelain(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	shown := map[string]bool{}
+	for i := 0; i < len(r.Lines); {
+		first := r.Lines[i]
+		n := 1
+		for i+n < len(r.Lines) {
+			next := r.Lines[i+n]
+			if next.Commit != first.Commit || next.Path != first.Path || next.Number != first.Number+n || next.Final != first.Final+n {
+				break
+			}
+			n++
+		}
+
+		fmt.Fprintf(bw, "%s %d %d %d\n", first.Commit, first.Number, first.Final, n)
+		if !shown[first.Commit] {
+			shown[first.Commit] = true
+			c := r.Commits[first.Commit]
+			for _, sig := range []struct {
+				role string
+				name string
+				mail string
+				when int64
+				tz   string
+			}{
+				{"author", c.Author.Name, c.Author.Email, c.Author.When.Unix(), c.Author.When.Format("-0700")},
+				{"committer", c.Committer.Name, c.Committer.Email, c.Committer.When.Unix(), c.Committer.When.Format("-0700")},
+			} {
+				fmt.Fprintf(bw, "%s %s\n%s-mail <%s>\n%s-time %d\n%s-tz %s\n", sig.role, sig.name, sig.role, sig.mail, sig.role, sig.when, sig.role, sig.tz)
+			}
+			fmt.Fprintf(bw, "summary %s\n", c.Subject())
+			if first.Boundary {
+				bw.WriteString("boundary\n")
+			}
+			if first.Previous != "" {
+				fmt.Fprintf(bw, "previous %s %s\n", first.Previous, diff.QuotePath(first.PreviousPath))
+			}
+			fmt.Fprintf(bw, "filename %s\n", diff.QuotePath(first.Path))
+		}
+		for j := range n {
+			l := r.Lines[i+j]
+			if j > 0 {
+				fmt.Fprintf(bw, "%s %d %d\n", l.Commit, l.Number, l.Final)
+			}
+			fmt.Fprintf(bw, "\t%s", l.Text)
+			if !strings.HasSuffix(l.Text, "\n") {
+				bw.WriteString("\n")
+			}
+		}
+		i += n
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,211 @@
+// Package bundle 은 git bundle(v2) 파일을 읽고 쓴다. 네트워크 없이 저장소를 옮길 때 쓴다.
+//
+//	# v2 git bundle
+//	-<hash> <제목>          (prerequisite: 받는 쪽에 이미 있어야 하는 커밋)
+//	<hash> refs/heads/main  (bundle 이 담은 ref)
+//	                        (빈 줄)
+//	PACK...                 (prerequisite 이후의 객체들을 담은 pack)
+//
+// prerequisite 가 없으면 전체 이력을 담은 bundle 이다.
+//
+// SHA-256 저장소의 bundle 은 git 과 같이 v3 형식이고, 첫 줄 다음에 해시 함수를 적는다.
+//
+//	# v3 git bundle
+//	@object-format=sha256
+package bundle
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// v2, v3 bundle 의 첫 줄
+const (
+	signature   = "# v2 git bundle"
+	signatureV3 = "# v3 git bundle"
+)
+
+// ErrNotBundle: bundle 형식이 아닌 파일
+var ErrNotBundle = errors.New("not a v2 or v3 git bundle")
+
+// Ref: bundle 이 담은 ref 하나
+type Ref struct {
+	Name string
+	Hash string
+}
+
+// Prerequisite: 받는 쪽에 이미 있어야 하는 커밋
+type Prerequisite struct {
+	Hash string
+	// Comment: 커밋의 제목 (사람이 읽으라고 넣는 것)
+	Comment string
+}
+
+// Header: pack 앞의 내용
+type Header struct {
+	// Algorithm: 해시와 pack 의 해시 함수. nil 이면 SHA-1
+	Algorithm     *object.Algorithm
+	Prerequisites []Prerequisite
+	Refs          []Ref
+}
+
+// ReadHeader: 헤더를 읽는다. r 은 pack 의 시작에 멈춰 있게 된다.
+func ReadHeader(r *bufio.Reader) (*Header, error) {
+	line, err := r.ReadString('\n')
+	line = strings.TrimSuffix(line, "\n")
+	if err != nil || line != signature && line != signatureV3 {
+		return nil, ErrNotBundle
+	}
+	h := &Header{Algorithm: object.SHA1}
+	v3 := line == signatureV3
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("%w: truncated header", ErrNotBundle)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return h, nil
+		}
+		if capability, ok := strings.CutPrefix(line, "@"); ok && v3 {
+			key, value, _ := strings.Cut(capability, "=")
+			if key != "object-format" {
+				return nil, fmt.Errorf("%w: unknown capability '%s'", ErrNotBundle, capability)
+			}
+			if h.Algorithm, err = object.ParseAlgorithm(value); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrNotBundle, err)
+			}
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "-"); ok {
+			hash, comment, _ := strings.Cut(rest, " ")
+			if !h.Algorithm.IsHash(hash) {
+				return nil, fmt.Errorf("%w: bad prerequisite line %q", ErrNotBundle, line)
+			}
+			h.Prerequisites = append(h.Prerequisites, Prerequisite{Hash: hash, Comment: comment})
+			continue
+		}
+		hash, name, ok := strings.Cut(line, " ")
+		if !ok || !h.Algorithm.IsHash(hash) {
+			return nil, fmt.Errorf("%w: bad ref line %q", ErrNotBundle, line)
+		}
+		h.Refs = append(h.Refs, Ref{Name: name, Hash: hash})
+	}
+}
+
+// Write: 헤더를 쓴다. 이어서 pack 을 쓰면 bundle 이 된다. SHA-1 이 아니면 v3 형식으로 쓴다.
+func (h *Header) Write(w io.Writer) error {
+	var b strings.Builder
+	if h.Algorithm == nil || h.Algorithm == object.SHA1 {
+		b.WriteString(signature + "\n")
+	} else {
+		b.WriteString(signatureV3 + "\n")
+		b.WriteString("@object-format=" + h.Algorithm.Name + "\n")
+	}
+	for _, p := range h.Prerequisites {
+		fmt.Fprintf(&b, "-%s %s\n", p.Hash, p.Comment)
+	}
+	for _, ref := range h.Refs {
+		fmt.Fprintf(&b, "%s %s\n", ref.Hash, ref.Name)
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Create: refs 에서 도달 가능하지만 exclude 에서는 도달할 수 없는 객체들을 담은 bundle 을 w 에 쓴다.
+// prerequisite 는 담긴 커밋의 부모 중 exclude 쪽에 있는 커밋들이다. 담을 커밋이 없으면 에러다.
+func Create(ctx context.Context, w io.Writer, s object.Storer, refs []Ref, exclude []string) (*Header, error) {
+	return CreateFiltered(ctx, w, s, refs, exclude, nil)
+}
+
+// CreateFiltered: Create 와 같지만 filter 가 false 를 돌려준 blob 은 pack 에 넣지 않는다.
+// 그런 bundle 은 받는 쪽에서 객체가 모자라므로 공개 배포처럼 일부 파일을 숨겨야 할 때만 쓴다.
+func CreateFiltered(ctx context.Context, w io.Writer, s object.Storer, refs []Ref, exclude []string, filter object.BlobFilter) (*Header, error) {
+	var heads []string
+	for _, ref := range refs {
+		heads = append(heads, ref.Hash)
+	}
+	objects, err := object.ReachableObjectsFilter(ctx, s, heads, filter)
+	if err != nil {
+		return nil, err
+	}
+	have := map[string]bool{}
+	if len(exclude) > 0 {
+		excluded, err := object.ReachableObjects(ctx, s, exclude)
+		if err != nil {
+			return nil, err
+		}
+		for _, hash := range excluded {
+			have[hash] = true
+		}
+	}
+
+	h := &Header{Algorithm: s.Algorithm(), Refs: refs}
+	var hashes []string
+	boundary := map[string]bool{}
+	for _, hash := range objects {
+		if have[hash] {
+			continue
+		}
+		hashes = append(hashes, hash)
+		// prerequisite 를 찾으려면 커밋의 부모만 보면 된다. 종류는 헤더만 읽어 확인한다
+		r, err := s.Open(hash)
+		if err != nil {
+			return nil, err
+		}
+		typ := r.Type
+		r.Close()
+		if typ != object.TypeCommit {
+			continue
+		}
+		commit, err := object.ReadCommit(s, hash)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range commit.Parents {
+			if have[parent] && !boundary[parent] {
+				boundary[parent] = true
+				h.Prerequisites = append(h.Prerequisites, Prerequisite{Hash: parent, Comment: subject(s, parent)})
+			}
+		}
+	}
+	if len(hashes) == 0 {
+		return nil, errors.New("refusing to create empty bundle")
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := h.Write(bw); err != nil {
+		return nil, err
+	}
+	if _, err := object.EncodePack(bw, s, hashes); err != nil {
+		return nil, err
+	}
+	return h, bw.Flush()
+}
+
+func subject(s object.Storer, hash string) string {
+	commit, err := object.ReadCommit(s, hash)
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(commit.Message, "\n")
+	return line
+}
+
+// Missing: 저장소에 없는 prerequisite 들. 비어 있으면 이 bundle 을 풀 수 있다.
+func (h *Header) Missing(s object.Storer) []Prerequisite {
+	var missing []Prerequisite
+	for _, p := range h.Prerequisites {
+		if !s.Has(p.Hash) {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
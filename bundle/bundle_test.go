@@ -0,0 +1,161 @@
+package bundle
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// history: 커밋 n 개의 선형 이력을 만든다. 커밋마다 내용이 다른 파일 하나를 담는다.
+func history(t *testing.T, s object.Storer, n int) []string {
+	t.Helper()
+	sig := object.Signature{Name: "T", Email: "t@example.com", When: time.Unix(1700000000, 0).UTC()}
+	var commits []string
+	for i := 0; i < n; i++ {
+		blob, err := object.WriteObject(s, &object.Blob{Data: []byte(strings.Repeat("x", i+1) + "\n")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree, err := object.WriteObject(s, &object.Tree{Entries: []object.TreeEntry{{Name: "f", Mode: object.ModeRegular, Hash: blob}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := &object.Commit{Tree: tree, Parents: commits[max(len(commits)-1, 0):], Author: sig, Committer: sig,
+			Message: "commit " + string(rune('a'+i)) + "\n"}
+		hash, err := object.WriteObject(s, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		commits = append(commits, hash)
+	}
+	return commits
+}
+
+func TestCreateAndIndex(t *testing.T) {
+	ctx := context.Background()
+	src := object.NewStore(vfs.NewOS(t.TempDir()))
+	commits := history(t, src, 3)
+	refs := []Ref{{Name: "refs/heads/main", Hash: commits[2]}}
+
+	var buf bytes.Buffer
+	h, err := Create(ctx, &buf, src, refs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Prerequisites) != 0 {
+		t.Errorf("full bundle has prerequisites %v", h.Prerequisites)
+	}
+	if !strings.HasPrefix(buf.String(), "# v2 git bundle\n"+commits[2]+" refs/heads/main\n\nPACK") {
+		t.Errorf("bundle starts with %q", buf.String()[:min(buf.Len(), 80)])
+	}
+
+	r := bufio.NewReader(&buf)
+	got, err := ReadHeader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Algorithm != object.SHA1 || len(got.Refs) != 1 || got.Refs[0] != refs[0] {
+		t.Fatalf("header = %+v", got)
+	}
+	dst := object.NewStore(vfs.NewOS(t.TempDir()))
+	if _, err := dst.IndexPack(r); err != nil {
+		t.Fatal(err)
+	}
+	all, err := object.ReachableObjects(ctx, src, []string{commits[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hash := range all {
+		if !dst.Has(hash) {
+			t.Errorf("unbundled store lacks %s", hash)
+		}
+	}
+}
+
+func TestCreateIncremental(t *testing.T) {
+	ctx := context.Background()
+	src := object.NewStore(vfs.NewOS(t.TempDir()))
+	commits := history(t, src, 3)
+
+	var buf bytes.Buffer
+	h, err := Create(ctx, &buf, src, []Ref{{Name: "refs/heads/main", Hash: commits[2]}}, []string{commits[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Prerequisite{Hash: commits[0], Comment: "commit a"}
+	if len(h.Prerequisites) != 1 || h.Prerequisites[0] != want {
+		t.Fatalf("prerequisites = %+v, want %+v", h.Prerequisites, want)
+	}
+	if missing := h.Missing(src); len(missing) != 0 {
+		t.Errorf("source store misses %v", missing)
+	}
+	empty := object.NewMemoryStore()
+	if missing := h.Missing(empty); len(missing) != 1 || missing[0] != want {
+		t.Errorf("Missing = %v, want %v", missing, want)
+	}
+
+	// 받는 쪽에 prerequisite 가 있으면 pack 만으로 이력이 채워진다
+	dst := object.NewStore(vfs.NewOS(t.TempDir()))
+	history(t, dst, 1)
+	r := bufio.NewReader(&buf)
+	if _, err := ReadHeader(r); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.IndexPack(r); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := object.ReachableObjects(ctx, dst, []string{commits[2]}); err != nil {
+		t.Errorf("history incomplete after unbundle: %v", err)
+	}
+
+	if _, err := Create(ctx, &bytes.Buffer{}, src, []Ref{{Name: "refs/heads/main", Hash: commits[2]}}, []string{commits[2]}); err == nil {
+		t.Error("empty bundle: no error")
+	}
+}
+
+func TestHeaderV3(t *testing.T) {
+	h := &Header{
+		Algorithm:     object.SHA256,
+		Prerequisites: []Prerequisite{{Hash: strings.Repeat("a", 64), Comment: "base"}},
+		Refs:          []Ref{{Name: "refs/heads/main", Hash: strings.Repeat("b", 64)}},
+	}
+	var buf bytes.Buffer
+	if err := h.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "# v3 git bundle\n@object-format=sha256\n") {
+		t.Errorf("header = %q", buf.String())
+	}
+	got, err := ReadHeader(bufio.NewReader(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Algorithm != object.SHA256 || got.Prerequisites[0] != h.Prerequisites[0] || got.Refs[0] != h.Refs[0] {
+		t.Errorf("header = %+v", got)
+	}
+}
+
+func TestReadHeaderErrors(t *testing.T) {
+	hash := strings.Repeat("a", 40)
+	tests := map[string]string{
+		"not a bundle":       "PACK\x00\x00\x00\x02",
+		"truncated":          "# v2 git bundle\n" + hash + " refs/heads/main\n",
+		"bad ref line":       "# v2 git bundle\nnot-a-hash refs/heads/main\n\n",
+		"bad prerequisite":   "# v2 git bundle\n-abc base\n\n",
+		"sha256 hash in v2":  "# v2 git bundle\n" + strings.Repeat("a", 64) + " refs/heads/main\n\n",
+		"unknown capability": "# v3 git bundle\n@filter=blob:none\n\n",
+		"unknown format":     "# v3 git bundle\n@object-format=md5\n\n",
+	}
+	for name, data := range tests {
+		if _, err := ReadHeader(bufio.NewReader(strings.NewReader(data))); !errors.Is(err, ErrNotBundle) {
+			t.Errorf("%s: err = %v, want ErrNotBundle", name, err)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Package changelog 는 conventional commit 메시지로 릴리스 노트를 만든다.
+//
+//	feat(parser): add streaming mode      -> Features
+//	fix: handle empty tree                -> Bug Fixes
+//	refactor!: drop Options.Legacy        -> BREAKING CHANGES
+//
+// 본문 끝의 "BREAKING CHANGE: ..." 꼬리말도 호환성이 깨지는 변경으로 본다.
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// "type(scope)!: subject"
+var subjectPattern = regexp.MustCompile(`^(\w+)(?:\(([^)]+)\))?(!)?: (.+)$`)
+
+// Change: conventional commit 하나
+type Change struct {
+	Hash    string
+	Type    string
+	Scope   string
+	Subject string
+	// Breaking: "!" 가 있거나 BREAKING CHANGE 꼬리말이 있음
+	Breaking bool
+	// BreakingNote: 꼬리말의 설명. 없으면 Subject 를 쓴다.
+	BreakingNote string
+}
+
+// Parse: 커밋 메시지를 해석한다. conventional commit 형식이 아니면 false
+func Parse(hash string, message string) (Change, bool) {
+	subject, body, _ := strings.Cut(message, "\n")
+	m := subjectPattern.FindStringSubmatch(strings.TrimSpace(subject))
+	if m == nil {
+		return Change{}, false
+	}
+
+	c := Change{
+		Hash:     hash,
+		Type:     strings.ToLower(m[1]),
+		Scope:    m[2],
+		Subject:  m[4],
+		Breaking: m[3] == "!",
+	}
+	for _, line := range strings.Split(body, "\n") {
+		for _, key := range []string{"BREAKING CHANGE:", "BREAKING-CHANGE:"} {
+			if note, ok := strings.CutPrefix(line, key); ok {
+				c.Breaking = true
+				c.BreakingNote = strings.TrimSpace(note)
+			}
+		}
+	}
+	return c, true
+}
+
+// Collect: to 에서 도달 가능하지만 from 에서는 도달할 수 없는 커밋(from..to)을 최신순으로 해석한다.
+// from 이 빈 문자열이면 to 의 전체 이력. merge commit 과 conventional 형식이 아닌 커밋은 건너뛴다.
+func Collect(ctx context.Context, s object.Storer, from string, to string) ([]Change, error) {
+	it := object.NewCommitIter(s, []string{to}, object.OrderDate)
+	if from != "" {
+		hidden, err := object.Reachable(ctx, s, []string{from})
+		if err != nil {
+			return nil, err
+		}
+		it.Hide(hidden)
+	}
+
+	var changes []Change
+	err := it.ForEachContext(ctx, func(hash string, commit *object.Commit) error {
+		if len(commit.Parents) > 1 {
+			return nil
+		}
+		if c, ok := Parse(hash, commit.Message); ok {
+			changes = append(changes, c)
+		}
+		return nil
+	})
+	return changes, err
+}
+
+// Bump: 다음 버전에서 올려야 할 자리
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+// BumpFor: 호환성이 깨지면 major, 기능이 추가되면 minor, 버그 수정만 있으면 patch
+func BumpFor(changes []Change) Bump {
+	bump := BumpNone
+	for _, c := range changes {
+		switch {
+		case c.Breaking:
+			return BumpMajor
+		case c.Type == "feat":
+			bump = max(bump, BumpMinor)
+		case c.Type == "fix" || c.Type == "perf":
+			bump = max(bump, BumpPatch)
+		}
+	}
+	return bump
+}
+
+// Version: "v1.2.3" 형식의 semver 태그. Prefix 는 "v" 또는 빈 문자열
+type Version struct {
+	Prefix              string
+	Major, Minor, Patch int
+}
+
+// ParseVersion: "v1.2.3", "1.2.3", "refs/tags/v1.2.3" 을 해석한다. pre-release 꼬리는 받지 않는다.
+func ParseVersion(s string) (Version, error) {
+	name := strings.TrimPrefix(s, "refs/tags/")
+	var v Version
+	if rest, ok := strings.CutPrefix(name, "v"); ok {
+		v.Prefix = "v"
+		name = rest
+	}
+
+	parts := strings.Split(name, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("not a semver tag: %s", s)
+	}
+	nums := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("not a semver tag: %s", s)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// Next: bump 만큼 올린 버전. 아래 자리는 0 으로 돌아간다.
+func (v Version) Next(bump Bump) Version {
+	switch bump {
+	case BumpMajor:
+		return Version{Prefix: v.Prefix, Major: v.Major + 1}
+	case BumpMinor:
+		return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor + 1}
+	case BumpPatch:
+		return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+	return v
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+}
+
+// 출력 순서와 제목. 여기 없는 타입(docs, chore 등)은 릴리스 노트에 넣지 않는다.
+var sections = []struct {
+	typ   string
+	title string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"revert", "Reverts"},
+}
+
+// WriteMarkdown: changes 를 종류별로 묶은 Markdown 릴리스 노트를 쓴다.
+func WriteMarkdown(w io.Writer, title string, changes []Change) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n", title)
+
+	var breaking []string
+	for _, c := range changes {
+		if c.Breaking {
+			note := c.BreakingNote
+			if note == "" {
+				note = c.Subject
+			}
+			breaking = append(breaking, item(c, note))
+		}
+	}
+	writeSection(&b, "BREAKING CHANGES", breaking)
+
+	for _, section := range sections {
+		var items []string
+		for _, c := range changes {
+			if c.Type == section.typ {
+				items = append(items, item(c, c.Subject))
+			}
+		}
+		writeSection(&b, section.title, items)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeSection(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s\n\n", title)
+	for _, it := range items {
+		fmt.Fprintf(b, "- %s\n", it)
+	}
+}
+
+func item(c Change, text string) string {
+	short := c.Hash
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	if c.Scope != "" {
+		return fmt.Sprintf("**%s:** %s (%s)", c.Scope, text, short)
+	}
+	return fmt.Sprintf("%s (%s)", text, short)
+}
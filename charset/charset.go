@@ -0,0 +1,159 @@
+// Package charset 는 커밋 메시지를 encoding 헤더에 적힌 인코딩과 UTF-8 사이에서 바꾼다.
+//
+// 외부 의존성 없이 바이트 하나가 문자 하나인 서유럽 인코딩만 지원한다.
+//
+//	UTF-8, US-ASCII, ISO-8859-1(latin1), ISO-8859-15(latin9), Windows-1252(cp1252)
+//
+// 이름은 대소문자와 '-', '_' 를 가리지 않는다. ("utf8", "ISO_8859-1" 도 된다)
+// EUC-KR, Shift_JIS, GBK, Big5 같은 다중 바이트 인코딩은 지원하지 않는다. log/show 는 그런 커밋의 메시지를
+// 바꾸지 않고 출력하면서 경고한다.
+package charset
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnsupported: 지원하지 않는 인코딩 이름. errors.ErrUnsupported 도 감싼다.
+	ErrUnsupported = fmt.Errorf("unsupported encoding: %w", errors.ErrUnsupported)
+	// ErrIllegal: 인코딩에 정의되지 않은 바이트가 있음
+	ErrIllegal = errors.New("illegal byte sequence")
+)
+
+// UTF8: 기본 인코딩 (encoding 헤더가 없는 커밋)
+const UTF8 = "UTF-8"
+
+type charset struct {
+	// high: 0x80-0xFF 바이트의 문자. nil 이면 UTF-8
+	high *[128]rune
+	// ascii: 0x80 이상을 쓸 수 없다
+	ascii bool
+}
+
+var latin1, latin9, cp1252 [128]rune
+
+// undefined: 인코딩에 정의되지 않은 바이트
+const undefined rune = -1
+
+func init() {
+	for i := range latin1 {
+		latin1[i] = rune(0x80 + i)
+	}
+	latin9 = latin1
+	for b, r := range map[byte]rune{
+		0xA4: '€', 0xA6: 'Š', 0xA8: 'š', 0xB4: 'Ž', 0xB8: 'ž', 0xBC: 'Œ', 0xBD: 'œ', 0xBE: 'Ÿ',
+	} {
+		latin9[b-0x80] = r
+	}
+	// 0x80-0x9F 만 다르다. 정의되지 않은 다섯 자리는 iconv 와 같이 바꿀 수 없는 바이트다.
+	cp1252 = latin1
+	for i, r := range []rune{
+		'€', undefined, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', undefined, 'Ž', undefined,
+		undefined, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', undefined, 'ž', 'Ÿ',
+	} {
+		cp1252[i] = r
+	}
+}
+
+func lookup(name string) (charset, bool) {
+	key := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+	switch key {
+	case "utf8":
+		return charset{}, true
+	case "usascii", "ascii":
+		return charset{ascii: true}, true
+	case "iso88591", "latin1", "l1":
+		return charset{high: &latin1}, true
+	case "iso885915", "latin9", "l9":
+		return charset{high: &latin9}, true
+	case "windows1252", "cp1252":
+		return charset{high: &cp1252}, true
+	}
+	return charset{}, false
+}
+
+// Supported: name 을 바꿀 수 있는지
+func Supported(name string) bool {
+	_, ok := lookup(name)
+	return ok
+}
+
+// Same: 두 이름이 같은 인코딩인지. 빈 이름은 UTF-8 이다.
+func Same(a, b string) bool {
+	if a == "" {
+		a = UTF8
+	}
+	if b == "" {
+		b = UTF8
+	}
+	ca, okA := lookup(a)
+	cb, okB := lookup(b)
+	if !okA || !okB {
+		return strings.EqualFold(a, b)
+	}
+	return ca == cb
+}
+
+// ToUTF8: name 인코딩의 data 를 UTF-8 문자열로 바꾼다. 정의되지 않은 바이트가 있으면 ErrIllegal
+// UTF-8 과 US-ASCII 는 그대로 돌려준다. (잘못된 바이트도 고치지 않는다)
+func ToUTF8(name string, data []byte) (string, error) {
+	cs, ok := lookup(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupported, name)
+	}
+	if cs.high == nil {
+		return string(data), nil
+	}
+	var b strings.Builder
+	b.Grow(len(data))
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case cs.high[c-0x80] == undefined:
+			return "", fmt.Errorf("%w: 0x%02x in %s", ErrIllegal, c, name)
+		default:
+			b.WriteRune(cs.high[c-0x80])
+		}
+	}
+	return b.String(), nil
+}
+
+// FromUTF8: UTF-8 문자열을 name 인코딩으로 바꾼다. 그 인코딩에 없는 문자가 있으면 ErrIllegal
+func FromUTF8(name string, s string) ([]byte, error) {
+	cs, ok := lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, name)
+	}
+	if cs.high == nil && !cs.ascii {
+		return []byte(s), nil
+	}
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r < 0x80 {
+			out = append(out, byte(r))
+			continue
+		}
+		c, ok := encodeRune(cs, r)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q has no %s encoding", ErrIllegal, r, name)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func encodeRune(cs charset, r rune) (byte, bool) {
+	if cs.ascii || r == utf8.RuneError {
+		return 0, false
+	}
+	for i, h := range cs.high {
+		if h == r {
+			return byte(0x80 + i), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/gogit"
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// resetTo: 작업 트리와 HEAD 를 commit 으로 되돌린다.
+func resetTo(t *testing.T, repo *gogit.Repository, commit string) {
+	t.Helper()
+	if err := resetWorkTree(context.Background(), repo, commit); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateHead(commit, "reset: moving to "+commit); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// formatPatches: since..HEAD 의 커밋들을 patch 파일로 쓰고 경로들을 순서대로 돌려준다.
+func formatPatches(t *testing.T, repo *gogit.Repository, since string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := cmdFormatPatch(context.Background(), repo, []string{"-o", dir, since}); err != nil {
+		t.Fatal(err)
+	}
+	patches, err := filepath.Glob(filepath.Join(dir, "*.patch"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return patches
+}
+
+// TestFormatPatchAmRoundTrip: format-patch 로 쓴 patch 를 am 하면 같은 tree, 작성자, 메시지의 커밋이 생긴다.
+func TestFormatPatchAmRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "a.txt", "one\ntwo\n")
+	base := commitWorkTree(t, repo, "base")
+
+	t.Setenv("GOGIT_AUTHOR_NAME", "홍길동")
+	writeWorkFile(t, repo, "a.txt", "one\nTWO\n")
+	commitWorkTree(t, repo, "change a\n\nexplain why")
+	writeWorkFile(t, repo, "dir/b.txt", "b\n")
+	want := commitWorkTree(t, repo, "add b")
+
+	patches := formatPatches(t, repo, base)
+	if len(patches) != 2 || !strings.HasSuffix(patches[0], "0001-change-a.patch") {
+		t.Fatalf("patches = %v", patches)
+	}
+
+	resetTo(t, repo, base)
+	if err := cmdAm(ctx, repo, patches); err != nil {
+		t.Fatal(err)
+	}
+	if vfs.Exists(repo.FS, amDir) {
+		t.Error("am state left behind")
+	}
+	got, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotTree, _ := repo.ResolveTree(got)
+	wantTree, _ := repo.ResolveTree(want)
+	if gotTree != wantTree {
+		t.Errorf("tree = %s, want %s", gotTree, wantTree)
+	}
+	second, err := object.ReadCommit(repo.Objects, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := object.ReadCommit(repo.Objects, second.Parents[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Author.Name != "홍길동" || first.Message != "change a\n\nexplain why\n" {
+		t.Errorf("first commit author %q, message %q", first.Author.Name, first.Message)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo.WorkTree, "dir", "b.txt")); string(data) != "b\n" {
+		t.Errorf("dir/b.txt = %q", data)
+	}
+}
+
+func mustResolve(t *testing.T, repo *gogit.Repository, rev string) string {
+	t.Helper()
+	hash, err := repo.ResolveCommit(rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+// setupAmConflict: 적용되지 않는 patch 하나로 am 을 멈춘 저장소와 멈추기 전의 HEAD 를 돌려준다.
+func setupAmConflict(t *testing.T) (*gogit.Repository, string) {
+	t.Helper()
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "a.txt", "one\ntwo\n")
+	base := commitWorkTree(t, repo, "base")
+	writeWorkFile(t, repo, "a.txt", "one\nTWO\n")
+	commitWorkTree(t, repo, "upper")
+	patches := formatPatches(t, repo, base)
+
+	resetTo(t, repo, base)
+	writeWorkFile(t, repo, "a.txt", "one\ndeux\n")
+	head := commitWorkTree(t, repo, "french")
+
+	if err := cmdAm(ctx, repo, patches); !errors.Is(err, errPatchFailed) {
+		t.Fatalf("am err = %v, want errPatchFailed", err)
+	}
+	if !vfs.Exists(repo.FS, amDir) {
+		t.Fatal("am did not keep its state")
+	}
+	if err := cmdAm(ctx, repo, patches); err == nil || !strings.Contains(err.Error(), "in progress") {
+		t.Fatalf("second am err = %v, want in progress", err)
+	}
+	return repo, head
+}
+
+func TestAmAbort(t *testing.T) {
+	repo, head := setupAmConflict(t)
+	if err := cmdAm(context.Background(), repo, []string{"--abort"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustResolve(t, repo, "HEAD"); got != head {
+		t.Errorf("HEAD = %s, want %s", got, head)
+	}
+	if vfs.Exists(repo.FS, amDir) {
+		t.Error("am state left behind")
+	}
+	if err := cmdAm(context.Background(), repo, []string{"--abort"}); err == nil {
+		t.Error("--abort without a session: no error")
+	}
+}
+
+func TestAmSkip(t *testing.T) {
+	repo, head := setupAmConflict(t)
+	if err := cmdAm(context.Background(), repo, []string{"--skip"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustResolve(t, repo, "HEAD"); got != head {
+		t.Errorf("HEAD = %s, want %s", got, head)
+	}
+	if vfs.Exists(repo.FS, amDir) {
+		t.Error("am state left behind")
+	}
+}
+
+func TestAmContinue(t *testing.T) {
+	ctx := context.Background()
+	repo, head := setupAmConflict(t)
+	if err := cmdAm(ctx, repo, []string{"--continue"}); err == nil {
+		t.Fatal("--continue without changes: no error")
+	}
+	writeWorkFile(t, repo, "a.txt", "one\nDEUX\n")
+	if err := cmdAm(ctx, repo, []string{"--continue"}); err != nil {
+		t.Fatal(err)
+	}
+	commit, err := object.ReadCommit(repo.Objects, mustResolve(t, repo, "HEAD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commit.Parents) != 1 || commit.Parents[0] != head || commit.Message != "upper\n" {
+		t.Errorf("commit parents %v, message %q", commit.Parents, commit.Message)
+	}
+	if vfs.Exists(repo.FS, amDir) {
+		t.Error("am state left behind")
+	}
+}
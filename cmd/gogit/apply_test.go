@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writePatch: patch 를 임시 파일에 쓰고 경로를 돌려준다.
+func writePatch(t *testing.T, patch string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "change.patch")
+	if err := os.WriteFile(p, []byte(patch), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+const applyTestPatch = `diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,2 @@
+ one
+-two
++TWO
+diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++new
+`
+
+func TestApplyWorkTree(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "a.txt", "one\ntwo\n")
+	commitWorkTree(t, repo, "base")
+	patch := writePatch(t, applyTestPatch)
+
+	if err := cmdApply(ctx, repo, []string{"--check", patch}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(repo.WorkTree, "new.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("--check wrote new.txt: %v", err)
+	}
+
+	if err := cmdApply(ctx, repo, []string{patch}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"a.txt": "one\nTWO\n", "new.txt": "new\n"} {
+		if data, _ := os.ReadFile(filepath.Join(repo.WorkTree, name)); string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+
+	if err := cmdApply(ctx, repo, []string{"-R", patch}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo.WorkTree, "a.txt")); string(data) != "one\ntwo\n" {
+		t.Errorf("after -R a.txt = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(repo.WorkTree, "new.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("after -R new.txt still exists: %v", err)
+	}
+}
+
+// TestApplyFailureWritesNothing: patch 하나라도 맞지 않으면 어떤 파일도 바꾸지 않는다.
+func TestApplyFailureWritesNothing(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "a.txt", "one\nthree\n")
+	commitWorkTree(t, repo, "base")
+	patch := writePatch(t, applyTestPatch)
+
+	if err := cmdApply(ctx, repo, []string{patch}); !errors.Is(err, errPatchFailed) {
+		t.Fatalf("err = %v, want errPatchFailed", err)
+	}
+	if _, err := os.Stat(filepath.Join(repo.WorkTree, "new.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("new.txt written after a failed patch: %v", err)
+	}
+
+	// 이미 있는 파일을 새로 만드는 patch 도 거부한다
+	writeWorkFile(t, repo, "a.txt", "one\ntwo\n")
+	writeWorkFile(t, repo, "new.txt", "mine\n")
+	if err := cmdApply(ctx, repo, []string{patch}); !errors.Is(err, errPatchFailed) {
+		t.Fatalf("err = %v, want errPatchFailed", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo.WorkTree, "a.txt")); string(data) != "one\ntwo\n" {
+		t.Errorf("a.txt = %q, want unchanged", data)
+	}
+}
+
+// TestApplyCached: --cached 는 작업 트리를 건드리지 않고 HEAD 의 tree 에 적용한다.
+func TestApplyCached(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "a.txt", "one\ntwo\n")
+	commitWorkTree(t, repo, "base")
+	// 작업 트리가 HEAD 와 달라도 --cached 는 HEAD 를 기준으로 한다
+	writeWorkFile(t, repo, "a.txt", "local\n")
+	patch := writePatch(t, applyTestPatch)
+
+	if err := cmdApply(ctx, repo, []string{"--cached", patch}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo.WorkTree, "a.txt")); string(data) != "local\n" {
+		t.Errorf("a.txt = %q, want untouched", data)
+	}
+	if err := cmdApply(ctx, repo, []string{"--cached", "--index", patch}); err == nil {
+		t.Error("--cached --index: no error")
+	}
+}
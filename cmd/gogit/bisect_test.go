@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// TestBisectRun: bisect run 은 처음으로 나빠진 커밋을 찾고, reset 은 원래 브랜치와 작업 트리로 돌아가 상태를 지운다.
+func TestBisectRun(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	var commits []string
+	for i := 0; i < 10; i++ {
+		writeWorkFile(t, repo, "n.txt", strconv.Itoa(i)+"\n")
+		commits = append(commits, commitWorkTree(t, repo, "commit "+strconv.Itoa(i)))
+	}
+	head, err := repo.Refs.Read("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmdBisect(ctx, repo, []string{"start", commits[9], commits[0]}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdBisect(ctx, repo, []string{"start"}); err == nil {
+		t.Error("second start: no error")
+	}
+	// n.txt 가 6 이상이면 나쁘다
+	if err := cmdBisect(ctx, repo, []string{"run", "sh", "-c", `test "$(cat n.txt)" -lt 6`}); err != nil {
+		t.Fatal(err)
+	}
+	log, err := vfs.ReadFile(repo.FS, bisectLogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# first bad commit: [" + commits[6] + "]"; !strings.Contains(string(log), want) {
+		t.Errorf("log does not contain %q:\n%s", want, log)
+	}
+
+	if err := cmdBisect(ctx, repo, []string{"reset"}); err != nil {
+		t.Fatal(err)
+	}
+	if ref, err := repo.Refs.Read("HEAD"); err != nil || ref.Target != head.Target {
+		t.Errorf("HEAD = %+v, %v; want branch %s", ref, err, head.Target)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo.WorkTree, "n.txt")); string(data) != "9\n" {
+		t.Errorf("n.txt = %q, want 9", data)
+	}
+	for _, name := range []string{bisectStartFile, bisectLogFile} {
+		if vfs.Exists(repo.FS, name) {
+			t.Errorf("%s left behind", name)
+		}
+	}
+	list, err := repo.Refs.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range list {
+		if strings.HasPrefix(ref.Name, bisectRefs) {
+			t.Errorf("%s left behind", ref.Name)
+		}
+	}
+}
+
+func TestBisectErrors(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "n.txt", "0\n")
+	commitWorkTree(t, repo, "commit 0")
+
+	if err := cmdBisect(ctx, repo, []string{"good"}); err == nil || !strings.Contains(err.Error(), "not bisecting") {
+		t.Errorf("good before start: err = %v", err)
+	}
+	// 잘못된 리비전이면 상태를 남기지 않는다
+	if err := cmdBisect(ctx, repo, []string{"start", "nope"}); err == nil {
+		t.Error("start with a bad revision: no error")
+	}
+	if vfs.Exists(repo.FS, bisectStartFile) {
+		t.Error("start with a bad revision left state behind")
+	}
+	if err := cmdBisect(ctx, repo, []string{"start"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdBisect(ctx, repo, []string{"run", "true"}); err == nil {
+		t.Error("run without good and bad: no error")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/gogit"
+	"github.com/tmdgusya/gogit/bundle"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// TestBundleCreateVerifyUnbundle: 증분 bundle 은 prerequisite 가 없는 저장소에서 거부되고, 전체 bundle 을 푼 뒤에는 풀린다.
+func TestBundleCreateVerifyUnbundle(t *testing.T) {
+	ctx := context.Background()
+	src := newTestRepo(t)
+	writeWorkFile(t, src, "a.txt", "one\n")
+	base := commitWorkTree(t, src, "base")
+	writeWorkFile(t, src, "a.txt", "two\n")
+	tip := commitWorkTree(t, src, "tip")
+	head, err := src.Refs.Read("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch := head.Target
+
+	dir := t.TempDir()
+	full := filepath.Join(dir, "full.bundle")
+	incremental := filepath.Join(dir, "incremental.bundle")
+	if err := cmdBundle(ctx, src, []string{"create", full, branch}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdBundle(ctx, src, []string{"create", incremental, base + ".." + branch}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdBundle(ctx, src, []string{"create", filepath.Join(dir, "empty.bundle"), tip + ".." + branch}); err == nil {
+		t.Error("empty bundle: no error")
+	}
+	if err := cmdBundle(ctx, src, []string{"verify", full}); err != nil {
+		t.Errorf("verify in the source repository: %v", err)
+	}
+
+	dst := newTestRepo(t)
+	if err := cmdBundle(ctx, dst, []string{"verify", incremental}); err == nil || !strings.Contains(err.Error(), base) {
+		t.Errorf("verify without prerequisite: err = %v", err)
+	}
+	if _, err := unbundle(dst, incremental); err == nil {
+		t.Error("unbundle without prerequisite: no error")
+	}
+	if dst.Objects.Has(tip) {
+		t.Error("refused unbundle wrote objects")
+	}
+
+	h, err := unbundle(dst, full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Refs) != 1 || h.Refs[0] != (bundle.Ref{Name: branch, Hash: tip}) {
+		t.Errorf("refs = %+v", h.Refs)
+	}
+	if _, err := object.ReachableObjects(ctx, dst.Objects, []string{tip}); err != nil {
+		t.Errorf("history incomplete after unbundle: %v", err)
+	}
+	if err := cmdBundle(ctx, dst, []string{"verify", incremental}); err != nil {
+		t.Errorf("verify after full unbundle: %v", err)
+	}
+}
+
+func TestBundleVerifyNotABundle(t *testing.T) {
+	repo := newTestRepo(t)
+	file := filepath.Join(t.TempDir(), "junk")
+	if err := os.WriteFile(file, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdBundle(context.Background(), repo, []string{"verify", file}); !errors.Is(err, bundle.ErrNotBundle) {
+		t.Errorf("err = %v, want ErrNotBundle", err)
+	}
+}
+
+// TestUnbundleObjectFormat: 저장소와 해시 함수가 다른 bundle 은 풀지 않는다.
+func TestUnbundleObjectFormat(t *testing.T) {
+	ctx := context.Background()
+	src := newTestRepo(t)
+	writeWorkFile(t, src, "a.txt", "one\n")
+	commitWorkTree(t, src, "base")
+	file := filepath.Join(t.TempDir(), "sha1.bundle")
+	if err := cmdBundle(ctx, src, []string{"create", file, "HEAD"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := gogit.InitWithOptions(t.TempDir(), gogit.Options{ObjectFormat: "sha256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unbundle(dst, file); err == nil || !strings.Contains(err.Error(), "object format") {
+		t.Errorf("err = %v, want object format mismatch", err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/gogit/refs"
+)
+
+// TestChangelogBumpCreatesTag: --bump 은 다음 버전 태그를 reflog 와 함께 만들고, 이미 있으면 거부한다.
+func TestChangelogBumpCreatesTag(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "a.txt", "a\n")
+	first := commitWorkTree(t, repo, "feat: first")
+	if err := repo.UpdateRef("refs/tags/v1.0.0", first, "tag"); err != nil {
+		t.Fatal(err)
+	}
+	writeWorkFile(t, repo, "a.txt", "b\n")
+	head := commitWorkTree(t, repo, "fix: second")
+
+	if err := cmdChangelog(ctx, repo, []string{"--bump", "v1.0.0..HEAD"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := repo.Refs.Resolve("refs/tags/v1.0.1"); err != nil || got != head {
+		t.Fatalf("v1.0.1 = %s, %v; want %s", got, err, head)
+	}
+	entries, err := refs.ReadReflog(repo.Refs, "refs/tags/v1.0.1")
+	if err != nil || len(entries) != 1 || entries[0].Message != "changelog: release v1.0.1" {
+		t.Fatalf("reflog = %+v, %v", entries, err)
+	}
+
+	err = cmdChangelog(ctx, repo, []string{"--bump", "v1.0.0..HEAD"})
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("second bump err = %v, want already exists", err)
+	}
+}
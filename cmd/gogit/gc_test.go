@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// 새 tree 가 다시 쓴 오래된 blob 은 write-tree 가 수정 시각을 새로 하므로 gc 가 지우지 않는다
+func TestGCKeepsObjectsReusedByNewTree(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	blob, err := repo.Objects.Write(object.TypeBlob, []byte("old\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	loose := filepath.Join(repo.GogitDir, "objects", blob[:2], blob[2:])
+	if err := os.Chtimes(loose, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	writeWorkFile(t, repo, "f", "old\n")
+	tree, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := gcRepository(ctx, repo, time.Now().Add(-14*24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := object.ReadTree(repo.Objects, tree); err != nil {
+		t.Fatal(err)
+	}
+	if !repo.Objects.Has(blob) {
+		t.Errorf("gc pruned blob %s that the new tree %s points to", blob, tree)
+	}
+}
+
+// 어디에서도 쓰지 않는 오래된 객체는 지운다
+func TestGCPrunesOldUnreachableObjects(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	blob, err := repo.Objects.Write(object.TypeBlob, []byte("garbage\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(repo.GogitDir, "objects", blob[:2], blob[2:]), old, old); err != nil {
+		t.Fatal(err)
+	}
+	_, _, pruned, err := gcRepository(ctx, repo, time.Now().Add(-14*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 1 || repo.Objects.Has(blob) {
+		t.Errorf("pruned %d objects, blob still present: %v", pruned, repo.Objects.Has(blob))
+	}
+}
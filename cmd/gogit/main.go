@@ -0,0 +1,10025 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmdgusya/gogit"
+	"github.com/tmdgusya/gogit/apply"
+	"github.com/tmdgusya/gogit/archive"
+	"github.com/tmdgusya/gogit/attr"
+	"github.com/tmdgusya/gogit/bisect"
+	"github.com/tmdgusya/gogit/blame"
+	"github.com/tmdgusya/gogit/bundle"
+	"github.com/tmdgusya/gogit/changelog"
+	"github.com/tmdgusya/gogit/charset"
+	"github.com/tmdgusya/gogit/config"
+	"github.com/tmdgusya/gogit/diff"
+	"github.com/tmdgusya/gogit/dump"
+	"github.com/tmdgusya/gogit/events"
+	"github.com/tmdgusya/gogit/fastimport"
+	"github.com/tmdgusya/gogit/fsck"
+	"github.com/tmdgusya/gogit/graph"
+	"github.com/tmdgusya/gogit/grep"
+	"github.com/tmdgusya/gogit/ignore"
+	"github.com/tmdgusya/gogit/mbox"
+	"github.com/tmdgusya/gogit/merge"
+	"github.com/tmdgusya/gogit/notes"
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/ops"
+	"github.com/tmdgusya/gogit/policy"
+	"github.com/tmdgusya/gogit/pretty"
+	"github.com/tmdgusya/gogit/provenance"
+	"github.com/tmdgusya/gogit/refs"
+	"github.com/tmdgusya/gogit/rerere"
+	"github.com/tmdgusya/gogit/shortlog"
+	"github.com/tmdgusya/gogit/signing"
+	"github.com/tmdgusya/gogit/sizer"
+	"github.com/tmdgusya/gogit/stack"
+	"github.com/tmdgusya/gogit/submodule"
+	"github.com/tmdgusya/gogit/subtree"
+	"github.com/tmdgusya/gogit/vfs"
+	"github.com/tmdgusya/gogit/whitespace"
+	"github.com/tmdgusya/gogit/worktree"
+)
+
+func main() {
+	opts, args, err := parseGlobalOptions(os.Args[1:])
+	if err != nil {
+		fatal(err)
+	}
+
+	if len(args) < 1 {
+		fmt.Println("Usage: gogit [-C <path>] [--git-compat] [--no-verify] [--explain] [--events-fd <n>] <command> [args...]")
+		os.Exit(exitFailure)
+	}
+
+	// Ctrl-C 를 받으면 ctx 가 취소되어 오래 걸리는 명령이 정리하고 멈춘다.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx = events.WithStream(ctx, opts.events)
+	opts.events.Emit(events.Event{Event: "start", Version: events.Version, Command: args[0], Args: args[1:]})
+
+	// init, clone, selftest 는 저장소를 새로 만드는 명령이므로 루트 탐색 대상에서 제외하고,
+	// diff --no-index 는 저장소 밖의 파일을 비교한다
+	var repo *gogit.Repository
+	if args[0] != "init" && args[0] != "clone" && args[0] != "selftest" && !(args[0] == "diff" && slices.Contains(args, "--no-index")) {
+		repo, err = openRepo(opts.Options)
+		if err != nil {
+			emitResult(opts.events, err)
+			fatal(err)
+		}
+	}
+	var explain *explainer
+	if opts.explain && repo != nil {
+		explain = startExplain(repo)
+	}
+	var refsBefore ops.State
+	if opts.events != nil && repo != nil {
+		refsBefore, _ = ops.Capture(repo.Refs)
+	}
+
+	// ref 를 바꾼 명령은 undo 할 수 있도록 기록한다
+	var journal *ops.Op
+	if repo != nil && repo.FS != nil && args[0] != "undo" {
+		journal = journalStart(ctx, repo, args)
+	}
+
+	switch args[0] {
+	case "init":
+		err = cmdInit(opts.Options, args[1:])
+		if err == nil {
+			fmt.Println("Initializing repository...")
+		}
+	case "clone":
+		err = cmdClone(ctx, opts.Options, args[1:])
+	case "selftest":
+		err = cmdSelftest(ctx, opts.Options, args[1:])
+	case "hash-object":
+		if len(args) < 2 {
+			fmt.Println("Usage: gogit hash-object <filename>")
+			os.Exit(exitFailure)
+		}
+		err = cmdHashObject(ctx, repo, args[1])
+		if err == nil {
+			fmt.Println("Hashing object...")
+		}
+	case "cat-file":
+		err = cmdCatFile(repo, args[1:])
+	case "check":
+		err = cmdCheck(ctx, repo, args[1:])
+	case "check-whitespace":
+		err = cmdCheckWhitespace(ctx, repo, args[1:])
+	case "changelog":
+		err = cmdChangelog(ctx, repo, args[1:])
+	case "subtree":
+		err = cmdSubtree(ctx, repo, args[1:])
+	case "provenance":
+		err = cmdProvenance(ctx, repo, args[1:])
+	case "sizer":
+		err = cmdSizer(ctx, repo, args[1:])
+	case "gc":
+		err = cmdGC(ctx, repo, args[1:])
+	case "fsck":
+		err = cmdFsck(ctx, repo, opts.Options, args[1:])
+	case "prune":
+		err = cmdPrune(ctx, repo, args[1:])
+	case "reflog":
+		err = cmdReflog(repo, args[1:])
+	case "count-objects":
+		err = cmdCountObjects(repo, args[1:])
+	case "log":
+		err = cmdLog(ctx, repo, args[1:])
+	case "shortlog":
+		err = cmdShortlog(ctx, repo, args[1:])
+	case "show":
+		err = cmdShow(repo, args[1:])
+	case "diff":
+		err = cmdDiff(ctx, repo, args[1:])
+	case "rev-list":
+		err = cmdRevList(ctx, repo, args[1:])
+	case "ls-tree":
+		err = cmdLsTree(repo, args[1:])
+	case "archive":
+		err = cmdArchive(ctx, repo, args[1:])
+	case "bundle":
+		err = cmdBundle(ctx, repo, args[1:])
+	case "remote":
+		err = cmdRemote(repo, args[1:])
+	case "push":
+		err = cmdPush(ctx, repo, args[1:])
+	case "sync-mirror":
+		err = cmdSyncMirror(ctx, repo, args[1:])
+	case "fetch":
+		err = cmdFetch(ctx, repo, args[1:])
+	case "graph":
+		err = cmdGraph(ctx, repo, args[1:])
+	case "debug":
+		err = cmdDebug(repo, args[1:])
+	case "fast-export":
+		err = cmdFastExport(ctx, repo, args[1:])
+	case "fast-import":
+		err = cmdFastImport(ctx, repo, args[1:])
+	case "blame":
+		err = cmdBlame(ctx, repo, args[1:])
+	case "describe":
+		err = cmdDescribe(ctx, repo, args[1:])
+	case "grep":
+		err = cmdGrep(ctx, repo, args[1:])
+	case "bisect":
+		err = cmdBisect(ctx, repo, args[1:])
+	case "write-tree":
+		err = cmdWriteTree(ctx, repo)
+	case "commit":
+		err = cmdCommit(ctx, repo, args[1:])
+	case "commit-tree":
+		err = cmdCommitTree(repo, args[1:])
+	case "restore":
+		err = cmdRestore(ctx, repo, args[1:])
+	case "apply":
+		err = cmdApply(ctx, repo, args[1:])
+	case "format-patch":
+		err = cmdFormatPatch(ctx, repo, args[1:])
+	case "am":
+		err = cmdAm(ctx, repo, args[1:])
+	case "clean":
+		err = cmdClean(ctx, repo, args[1:])
+	case "cherry-pick":
+		err = cmdCherryPick(ctx, repo, args[1:])
+	case "revert":
+		err = cmdRevert(ctx, repo, args[1:])
+	case "rerere":
+		err = cmdRerere(repo, args[1:])
+	case "rebase":
+		err = cmdRebase(ctx, repo, args[1:])
+	case "stack":
+		err = cmdStack(ctx, repo, args[1:])
+	case "undo":
+		err = cmdUndo(ctx, repo, args[1:])
+	case "wip":
+		err = cmdWip(ctx, repo, args[1:])
+	case "stash":
+		err = cmdStash(ctx, repo, args[1:])
+	case "notes":
+		err = cmdNotes(repo, args[1:])
+	case "tag":
+		err = cmdTag(repo, args[1:])
+	case "verify-commit":
+		err = cmdVerifyCommit(repo, args[1:])
+	case "verify-tag":
+		err = cmdVerifyTag(repo, args[1:])
+	case "worktree":
+		err = cmdWorktree(ctx, opts.Options, repo, args[1:])
+	case "submodule":
+		err = cmdSubmodule(ctx, opts.Options, repo, args[1:])
+	default:
+		fmt.Printf("Unknown command: %s\n", args[0])
+		emitResult(opts.events, fmt.Errorf("unknown command: %s", args[0]))
+		os.Exit(exitFailure)
+	}
+	if journal != nil {
+		journalEnd(repo, journal)
+	}
+	if explain != nil {
+		explain.report(repo)
+	}
+	if repo != nil && refsBefore != nil {
+		emitRefs(opts.events, repo, refsBefore)
+	}
+	emitResult(opts.events, err)
+
+	// 차이가 있거나 맞는 줄이 없다는 것은 결과이므로 에러 메시지 없이 종료 코드만 1 이다
+	if errors.Is(err, errDiffFound) || errors.Is(err, errNoMatch) {
+		os.Exit(exitFailure)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// 종료 코드
+// 스크립트가 실패 원인을 구분할 수 있도록 라이브러리의 에러 값마다 코드를 나눈다.
+const (
+	exitFailure       = 1   // 그 밖의 모든 실패 (check 위반 포함)
+	exitNotARepo      = 3   // 저장소를 찾지 못함
+	exitNotFound      = 4   // 객체, ref, 리비전이 없음
+	exitInvalidObject = 5   // 객체 형식이 잘못되었거나 내용이 손상됨
+	exitInterrupted   = 130 // Ctrl-C 로 취소됨 (셸의 128+SIGINT 관례)
+)
+
+var (
+	// errCheckFailed: check 가 위반을 찾음. 위반 내용은 이미 출력했다.
+	errCheckFailed = errors.New("policy check failed")
+	// errProvenanceBroken: provenance verify 가 어긋난 커밋을 찾음. 커밋 목록은 이미 출력했다.
+	errProvenanceBroken = errors.New("provenance chain is broken")
+	// errFsckFailed: fsck 가 망가진 객체나 없는 객체를 찾음. 문제는 이미 출력했다.
+	errFsckFailed = errors.New("repository is corrupt")
+	// errConflict: merge 에 충돌이 남음. 충돌한 파일은 이미 출력했다.
+	errConflict = errors.New("merge conflict")
+	// errPatchFailed: apply 할 수 없는 patch 가 있음. 이유는 이미 출력했다.
+	errPatchFailed = errors.New("patch does not apply")
+	// errBadSignature: verify-commit/verify-tag 가 서명이 없거나 맞지 않는 객체를 찾음. 이유는 이미 출력했다.
+	errBadSignature = errors.New("signature verification failed")
+)
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, gogit.ErrNotARepository):
+		return exitNotARepo
+	case errors.Is(err, gogit.ErrObjectNotFound),
+		errors.Is(err, gogit.ErrUnknownRevision),
+		errors.Is(err, refs.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, gogit.ErrInvalidObject),
+		errors.Is(err, gogit.ErrCorruptObject):
+		return exitInvalidObject
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	default:
+		return exitFailure
+	}
+}
+
+// emitRefs: before 이후 바뀐 ref 마다 ref 이벤트를 보낸다
+func emitRefs(s *events.Stream, repo *gogit.Repository, before ops.State) {
+	if s == nil {
+		return
+	}
+	after, err := ops.Capture(repo.Refs)
+	if err != nil {
+		return
+	}
+	for _, name := range (&ops.Op{Before: before, After: after}).Changed() {
+		s.Ref(name, before[name], after[name])
+	}
+}
+
+// emitResult: 명령의 결과 이벤트. 종료 코드는 main 이 실제로 쓰는 값과 같다
+func emitResult(s *events.Stream, err error) {
+	ok, code, msg := err == nil, 0, ""
+	switch {
+	case errors.Is(err, errDiffFound) || errors.Is(err, errNoMatch):
+		code = exitFailure
+	case err != nil:
+		code, msg = exitCode(err), err.Error()
+	}
+	s.Emit(events.Event{Event: "result", OK: &ok, ExitCode: &code, Error: msg})
+}
+
+// fatal: 에러를 출력하고 종류에 맞는 코드로 종료한다. os.Exit 는 main 패키지에서만 호출한다.
+func fatal(err error) {
+	fmt.Printf("Error: %v\n", err)
+	os.Exit(exitCode(err))
+}
+
+// globalOptions: 명령어 앞에 오는 전역 옵션. 저장소를 여는 옵션과 CLI 에만 있는 옵션
+type globalOptions struct {
+	gogit.Options
+	// explain: 명령이 끝난 뒤 만든 객체와 움직인 ref 를 설명한다
+	explain bool
+	// events: --events-fd 로 받은 이벤트 스트림. 없으면 nil
+	events *events.Stream
+}
+
+// 전역 옵션 파싱
+// 명령어 앞에 오는 -C <path>, --git-compat, --no-verify, --explain 을 처리한다.
+// -C 는 git 과 동일하게 여러 번 주면 순서대로 이동한다.
+// --git-compat 을 주면 .gogit 대신 .git 을 사용한다. 객체 포맷이 git 과 같기 때문에
+// 기존 git 체크아웃 안에서 gogit 명령을 실행하고 결과를 비교해 볼 수 있다.
+// --no-verify 는 객체를 읽을 때 해시를 확인하지 않는다. 망가진 저장소에서 남은 것을 꺼낼 때 쓴다.
+// (명령 뒤에 오는 commit --no-verify 는 hook 을 건너뛰는 다른 옵션이다)
+// --explain 은 git 을 배우는 사람을 위해 명령이 객체와 ref 에 한 일을 보여 준다. (explainer 참고)
+// --events-fd <n> 은 진행과 결과를 JSON 줄로 파일 디스크립터 n 에 쓴다. 사람이 읽는 출력은 그대로다. (events 패키지)
+func parseGlobalOptions(args []string) (globalOptions, []string, error) {
+	var opts globalOptions
+
+	for len(args) > 0 {
+		switch args[0] {
+		case "-C":
+			if len(args) < 2 {
+				return opts, nil, fmt.Errorf("-C requires a path")
+			}
+			if err := os.Chdir(args[1]); err != nil {
+				return opts, nil, fmt.Errorf("cannot change to '%s': %v", args[1], err)
+			}
+			args = args[2:]
+		case "--git-compat":
+			opts.DirName = ".git"
+			args = args[1:]
+		case "--no-verify":
+			opts.NoVerify = true
+			args = args[1:]
+		case "--explain":
+			opts.explain = true
+			args = args[1:]
+		case "--events-fd":
+			if len(args) < 2 {
+				return opts, nil, fmt.Errorf("--events-fd requires a file descriptor")
+			}
+			fd, err := strconv.Atoi(args[1])
+			if err != nil || fd < 0 {
+				return opts, nil, fmt.Errorf("invalid --events-fd: %s", args[1])
+			}
+			f := os.NewFile(uintptr(fd), "events")
+			if _, err := f.Stat(); err != nil {
+				return opts, nil, fmt.Errorf("--events-fd %d: %v", fd, err)
+			}
+			opts.events = events.New(f)
+			args = args[2:]
+		default:
+			return opts, args, nil
+		}
+	}
+	return opts, args, nil
+}
+
+// explainer: --explain 의 기록. 명령 전의 ref 들과, 명령이 새로 만든 객체를 object.Store 에서 직접 받아 모은다.
+type explainer struct {
+	before ops.State
+
+	mu      sync.Mutex
+	created []explainedObject
+}
+
+type explainedObject struct {
+	hash string
+	typ  object.Type
+}
+
+func startExplain(repo *gogit.Repository) *explainer {
+	e := &explainer{}
+	if before, err := ops.Capture(repo.Refs); err == nil {
+		e.before = before
+	}
+	if store, ok := repo.Objects.(*object.Store); ok {
+		store.OnWrite(func(hash string, typ object.Type) {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			e.created = append(e.created, explainedObject{hash: hash, typ: typ})
+		})
+	}
+	return e
+}
+
+// report: 만든 객체를 종류별로, 움직인 ref 를 이름순으로 stderr 에 설명한다.
+// 객체마다 실제 내용을 다시 읽어 크기, 항목 수, 가리키는 tree 와 부모를 보여 준다.
+func (e *explainer) report(repo *gogit.Repository) {
+	order := map[object.Type]int{object.TypeBlob: 0, object.TypeTree: 1, object.TypeCommit: 2, object.TypeTag: 3}
+	e.mu.Lock()
+	created := slices.Clone(e.created)
+	e.mu.Unlock()
+	slices.SortStableFunc(created, func(a, b explainedObject) int { return order[a.typ] - order[b.typ] })
+
+	var b strings.Builder
+	b.WriteString("explain:\n")
+	if len(created) == 0 {
+		b.WriteString("  no new objects were written to the object store\n")
+	} else {
+		fmt.Fprintf(&b, "  %d new objects were written to the object store:\n", len(created))
+	}
+	for _, o := range created {
+		fmt.Fprintf(&b, "    %-6s %s  %s\n", o.typ, o.hash, describeObject(repo, o.hash))
+	}
+
+	after, err := ops.Capture(repo.Refs)
+	if err == nil && e.before != nil {
+		moved := (&ops.Op{Before: e.before, After: after}).Changed()
+		if len(moved) == 0 {
+			b.WriteString("  no refs moved\n")
+		}
+		for _, name := range moved {
+			fmt.Fprintf(&b, "  ref %s moved: %s -> %s", name, describeRefValue(e.before[name]), describeRefValue(after[name]))
+			if target, _ := ops.Target(after["HEAD"]); target == name {
+				b.WriteString(" (HEAD points to it, so HEAD moved too)")
+			}
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("  index: gogit has no index; the work tree is snapshotted directly when a command needs it\n")
+	io.WriteString(os.Stderr, b.String())
+}
+
+// describeObject: 객체 내용에서 뽑은 한 줄 설명
+func describeObject(repo *gogit.Repository, hash string) string {
+	obj, err := object.ReadObject(repo.Objects, hash)
+	if err != nil {
+		return fmt.Sprintf("(unreadable: %v)", err)
+	}
+	switch o := obj.(type) {
+	case *object.Blob:
+		return fmt.Sprintf("file content, %d bytes", len(o.Data))
+	case *object.Tree:
+		return fmt.Sprintf("directory listing with %d entries", len(o.Entries))
+	case *object.Commit:
+		desc := "snapshot of tree " + o.Tree[:7]
+		switch len(o.Parents) {
+		case 0:
+			desc += ", no parent (root commit)"
+		case 1:
+			desc += ", parent " + o.Parents[0][:7]
+		default:
+			desc += fmt.Sprintf(", %d parents (merge)", len(o.Parents))
+		}
+		return fmt.Sprintf("%s: %q", desc, o.Subject())
+	case *object.Tag:
+		return fmt.Sprintf("tag %q pointing at %s %s", o.Name, o.ObjectType, o.Object[:7])
+	}
+	return ""
+}
+
+func describeRefValue(v string) string {
+	if v == "" {
+		return "(did not exist)"
+	}
+	if target, symbolic := ops.Target(v); symbolic {
+		return "points to " + target
+	}
+	return v[:7]
+}
+
+// 저장소 열기
+// GOGIT_DIR 이 있으면 그대로 사용하고 (git 과 같이 이때 workTree 는 현재 디렉토리),
+// 없으면 현재 디렉토리부터 상위로 올라가며 저장소를 찾는다.
+// GOGIT_WORK_TREE 는 어느 경우든 workTree 를 덮어쓴다.
+func openRepo(opts gogit.Options) (*gogit.Repository, error) {
+	var repo *gogit.Repository
+	var err error
+
+	if dir := os.Getenv("GOGIT_DIR"); dir != "" {
+		gogitDir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		repo, err = gogit.OpenDir(gogitDir, cwd)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		repo, err = gogit.OpenWithOptions(".", opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if workTree := os.Getenv("GOGIT_WORK_TREE"); workTree != "" {
+		abs, err := filepath.Abs(workTree)
+		if err != nil {
+			return nil, err
+		}
+		repo.WorkTree = abs
+	}
+	if store, ok := repo.Objects.(*object.Store); ok && opts.NoVerify {
+		store.SetVerify(false)
+	}
+
+	return repo, nil
+}
+
+// Init: 저장소 초기화
+//
+//	init [--bare] [--object-format=(sha1|sha256)]
+//
+// GOGIT_DIR 이 지정되어 있으면 그 위치에, 아니면 현재 디렉토리에 만든다.
+// --object-format=sha256 은 git 의 SHA-256 저장소와 같은 형식으로 만든다. (객체 이름이 64자리)
+func cmdInit(opts gogit.Options, args []string) error {
+	const usage = "usage: gogit init [--bare] [--object-format=(sha1|sha256)]"
+	for _, arg := range args {
+		switch {
+		case arg == "--bare":
+			opts.Bare = true
+		case strings.HasPrefix(arg, "--object-format="):
+			opts.ObjectFormat = strings.TrimPrefix(arg, "--object-format=")
+		default:
+			return errors.New(usage)
+		}
+	}
+	var repo *gogit.Repository
+	var err error
+
+	if dir := os.Getenv("GOGIT_DIR"); dir != "" {
+		workTree := "."
+		if opts.Bare {
+			workTree = ""
+		}
+		repo, err = gogit.InitDirWithOptions(dir, workTree, opts)
+	} else {
+		repo, err = gogit.InitWithOptions(".", opts)
+	}
+	if err != nil {
+		return fmt.Errorf("initializing repository: %w", err)
+	}
+
+	fmt.Printf("Initialized emtpy goGit repository in %s\n", repo.GogitDir)
+	return nil
+}
+
+// Hash-Object: Blob 생성
+// filename 은 사용자가 입력한 경로이므로 현재 디렉토리 기준으로 읽는다.
+// 큰 파일도 메모리에 올리지 않도록 스트리밍으로 저장한다.
+func cmdHashObject(ctx context.Context, repo *gogit.Repository, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("reading file %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("reading file %s: %w", filename, err)
+	}
+
+	hash, err := object.WriteStreamContext(ctx, repo.Objects, object.TypeBlob, info.Size(), f)
+	if err != nil {
+		return fmt.Errorf("saving object: %w", err)
+	}
+	fmt.Printf("Hash: %s\n", hash)
+
+	fmt.Println(hash)
+	return nil
+}
+
+// Cat-File: 객체 조회
+//
+//	cat-file -p <object>             내용 출력 (tree 는 ls-tree 형식, 나머지는 그대로)
+//	cat-file -t <object>             타입
+//	cat-file -s <object>             크기
+//	cat-file --batch[-check]         표준 입력의 줄마다 "<sha> <type> <size>" (--batch 는 내용까지)
+//	cat-file --batch-all-objects --batch[-check]
+//	                                 도달 가능성과 상관없이 저장소의 모든 객체를 해시 순으로
+func cmdCatFile(repo *gogit.Repository, args []string) error {
+	if len(args) == 1 && (args[0] == "--batch" || args[0] == "--batch-check") {
+		return catFileBatch(repo, os.Stdin, os.Stdout, args[0] == "--batch")
+	}
+	if len(args) == 2 && slices.Contains(args, "--batch-all-objects") {
+		mode := args[0]
+		if mode == "--batch-all-objects" {
+			mode = args[1]
+		}
+		if mode == "--batch" || mode == "--batch-check" {
+			return catFileAll(repo, os.Stdout, mode == "--batch")
+		}
+	}
+	if len(args) != 2 {
+		fmt.Println("Usage: gogit cat-file (-p | -t | -s) <object> | --batch | --batch-check [--batch-all-objects]")
+		os.Exit(exitFailure)
+	}
+
+	hash, err := repo.ResolveRevision(args[1])
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "-p":
+		return catFilePretty(repo, hash)
+	case "-t", "-s":
+		// 헤더만 읽으면 되므로 내용 전체를 읽지 않는다
+		r, err := repo.Objects.Open(hash)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if args[0] == "-t" {
+			fmt.Println(r.Type)
+		} else {
+			fmt.Println(r.Size)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown cat-file option: %s", args[0])
+}
+
+// 배치 모드: 프로세스 하나로 많은 객체를 조회한다.
+// 이름은 cat-file -p 와 같이 리비전도 받는다. 찾지 못하면 "<name> missing" 을 출력하고 계속한다.
+// --batch 는 헤더 줄 다음에 내용과 빈 줄을 출력한다. 내용은 스트리밍으로 복사한다.
+func catFileBatch(repo *gogit.Repository, in io.Reader, out io.Writer, contents bool) error {
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" {
+			continue
+		}
+
+		hash, err := repo.ResolveRevision(name)
+		var r *object.ObjectReader
+		if err == nil {
+			r, err = repo.Objects.Open(hash)
+		}
+		if errors.Is(err, gogit.ErrObjectNotFound) || errors.Is(err, gogit.ErrUnknownRevision) {
+			fmt.Fprintf(w, "%s missing\n", name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeBatchEntry(w, hash, r, contents); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// catFileAll: 저장소의 모든 객체를 배치 모드 형식으로 출력한다.
+func catFileAll(repo *gogit.Repository, out io.Writer, contents bool) error {
+	hashes, err := object.AllHashes(repo.Objects)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+	for _, hash := range hashes {
+		r, err := repo.Objects.Open(hash)
+		if err != nil {
+			return err
+		}
+		if err := writeBatchEntry(w, hash, r, contents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBatchEntry: "<sha> <type> <size>" 줄과 (contents 면) 내용, 빈 줄. r 은 닫는다.
+// 손상된 내용이 나가지 않도록 해시를 확인한 뒤에 헤더 줄부터 쓴다.
+func writeBatchEntry(w io.Writer, hash string, r *object.ObjectReader, contents bool) error {
+	defer r.Close()
+	if contents {
+		if err := r.Spool(); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "%s %s %d\n", hash, r.Type, r.Size); err != nil {
+		return err
+	}
+	if !contents {
+		return nil
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// -p: git 과 같은 형식이라 스크립트가 그대로 파싱할 수 있다.
+func catFilePretty(repo *gogit.Repository, hash string) error {
+	r, err := repo.Objects.Open(hash)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if r.Type != object.TypeTree {
+		// 큰 객체도 해시를 확인한 뒤에 출력한다
+		if err := r.Spool(); err != nil {
+			return err
+		}
+		_, err = io.Copy(os.Stdout, r)
+		return err
+	}
+
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var tree object.Tree
+	if err := tree.DecodeAlgorithm(payload, repo.Objects.Algorithm()); err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	for _, e := range tree.Entries {
+		fmt.Fprintln(w, formatTreeEntry(e))
+	}
+	return w.Flush()
+}
+
+// ls-tree 와 같은 "<mode> <type> <hash>\t<name>" 형식. 모드는 6자리로 맞춘다.
+func formatTreeEntry(e object.TreeEntry) string {
+	return fmt.Sprintf("%06o %s %s\t%s", uint32(e.Mode), e.Mode.ObjectType(), e.Hash, e.Name)
+}
+
+// Check: 커밋이 저장소 규칙([check], [receive] 설정)을 지키는지 검사
+// 인자가 없으면 HEAD 를 검사한다. 위반이 하나라도 있으면 exit 1
+// --stdin 은 pre-receive hook 처럼 "<old> <new> <ref>" 줄을 읽어 push 로 들어오는 커밋을 모두 검사한다.
+func cmdCheck(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit check [<rev>... | --stdin]"
+	stdin := false
+	var revs []string
+	for _, arg := range args {
+		switch {
+		case arg == "--stdin":
+			stdin = true
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			revs = append(revs, arg)
+		}
+	}
+	if stdin && len(revs) > 0 {
+		return errors.New(usage)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	p, err := policy.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	if stdin {
+		if revs, err = pushedCommits(ctx, repo, os.Stdin); err != nil {
+			return err
+		}
+	} else if len(revs) == 0 {
+		revs = []string{"HEAD"}
+	}
+
+	failed := false
+	for _, rev := range revs {
+		hash, err := repo.ResolveCommit(rev)
+		if err != nil {
+			return err
+		}
+
+		violations, err := p.CheckContext(ctx, repo.Objects, hash)
+		if err != nil {
+			return fmt.Errorf("checking %s: %w", rev, err)
+		}
+		for _, v := range violations {
+			fmt.Println(v)
+			failed = true
+		}
+	}
+
+	if failed {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// CheckWhitespace: core.whitespace 규칙으로 더한 줄의 공백 문제를 git diff --check 형식으로 출력한다.
+// 인자가 없으면 작업 트리를 HEAD 와, 리비전 하나면 그 커밋을, 범위(A..B, ^A B)면 범위 안의 커밋을 각 첫 부모와 비교한다.
+// --stdin 은 pre-receive hook 처럼 "<old> <new> <ref>" 줄을 읽어 push 로 들어오는 커밋을 검사한다.
+// 새 ref 면 기존 ref 에서 도달할 수 없는 커밋만 보고, 지우는 ref 는 건너뛴다.
+// 문제가 하나라도 있으면 exit 1 이므로 hook 에서 그대로 push 를 막는 데 쓸 수 있다.
+func cmdCheckWhitespace(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit check-whitespace [<rev> | <revision-range>... | --stdin]"
+	stdin := false
+	var revs []string
+	for _, arg := range args {
+		switch {
+		case arg == "--stdin":
+			stdin = true
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			revs = append(revs, arg)
+		}
+	}
+	if stdin && len(revs) > 0 {
+		return errors.New(usage)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	value, _ := cfg.Get("core.whitespace")
+	rules, err := whitespace.Parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid core.whitespace: %w", err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	failed := false
+	report := func(header string, problems []whitespace.Problem) {
+		if len(problems) == 0 {
+			return
+		}
+		if header != "" {
+			fmt.Fprintln(w, header)
+		}
+		for _, p := range problems {
+			fmt.Fprintln(w, p)
+		}
+		failed = true
+	}
+
+	if stdin {
+		if revs, err = pushedRevs(repo, os.Stdin); err != nil {
+			return err
+		}
+		if len(revs) == 0 {
+			return nil
+		}
+	}
+
+	switch {
+	case len(revs) == 0:
+		if err := repo.RequireWorkTree("check-whitespace"); err != nil {
+			return err
+		}
+		from, err := repo.ResolveTree("HEAD")
+		if err != nil && !errors.Is(err, refs.ErrNotFound) {
+			return err
+		}
+		to, err := snapshotWorkTree(ctx, repo)
+		if err != nil {
+			return err
+		}
+		changes, err := diff.Trees(repo.Objects, from, to)
+		if err != nil {
+			return err
+		}
+		problems, err := rules.CheckChanges(repo.Objects, changes)
+		if err != nil {
+			return err
+		}
+		report("", problems)
+	case len(revs) == 1 && !stdin && !strings.Contains(revs[0], "..") && !strings.HasPrefix(revs[0], "^"):
+		hash, err := repo.ResolveCommit(revs[0])
+		if err != nil {
+			return err
+		}
+		commit, err := object.ReadCommit(repo.Objects, hash)
+		if err != nil {
+			return err
+		}
+		problems, err := policy.CommitWhitespace(repo.Objects, rules, commit)
+		if err != nil {
+			return err
+		}
+		report("", problems)
+	default:
+		set, err := repo.ResolveRevSet(ctx, revs)
+		if err != nil {
+			return err
+		}
+		var hashes []string
+		var commits []*object.Commit
+		err = set.Iter(repo.Objects, object.OrderTopo).ForEachContext(ctx, func(hash string, c *object.Commit) error {
+			// merge commit 이 들여온 줄은 부모 쪽 커밋에서 검사한다
+			if len(c.Parents) <= 1 {
+				hashes = append(hashes, hash)
+				commits = append(commits, c)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// 오래된 커밋부터 보여 준다
+		for i := len(commits) - 1; i >= 0; i-- {
+			problems, err := policy.CommitWhitespace(repo.Objects, rules, commits[i])
+			if err != nil {
+				return err
+			}
+			report("commit "+hashes[i], problems)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// pushedCommits: pre-receive 입력으로 들어오는 커밋들. 오래된 것부터
+func pushedCommits(ctx context.Context, repo *gogit.Repository, r io.Reader) ([]string, error) {
+	revs, err := pushedRevs(repo, r)
+	if err != nil || len(revs) == 0 {
+		return nil, err
+	}
+	set, err := repo.ResolveRevSet(ctx, revs)
+	if err != nil {
+		return nil, err
+	}
+	var hashes []string
+	err = set.Iter(repo.Objects, object.OrderTopo).ForEachContext(ctx, func(hash string, _ *object.Commit) error {
+		hashes = append(hashes, hash)
+		return nil
+	})
+	slices.Reverse(hashes)
+	return hashes, err
+}
+
+// pushedRevs: pre-receive 입력("<old> <new> <ref>" 줄)을 rev-list 인자로 바꾼다.
+// 갱신은 old..new, 새 ref 는 new 에서 기존 ref 들을 뺀 것이다. 지우는 ref(new 가 0)는 검사할 것이 없다.
+func pushedRevs(repo *gogit.Repository, r io.Reader) ([]string, error) {
+	var revs []string
+	var existing []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 || !object.IsHash(fields[0]) || !object.IsHash(fields[1]) {
+			return nil, fmt.Errorf("invalid pre-receive line: %q", scanner.Text())
+		}
+		old, new := fields[0], fields[1]
+		switch {
+		case object.IsZeroHash(new):
+		case object.IsZeroHash(old):
+			if existing == nil {
+				hashes, err := repo.AllRefs()
+				if err != nil {
+					return nil, err
+				}
+				existing = []string{}
+				for _, hash := range hashes {
+					existing = append(existing, "^"+hash)
+				}
+				revs = append(revs, existing...)
+			}
+			revs = append(revs, new)
+		default:
+			revs = append(revs, old+".."+new)
+		}
+	}
+	return revs, scanner.Err()
+}
+
+// Changelog: <from>..<to> 사이의 conventional commit 으로 Markdown 릴리스 노트를 출력
+// to 를 생략하면 HEAD, from 을 생략하면 처음부터.
+// --bump 를 주면 from 의 semver 태그를 올린 버전을 제목으로 쓰고 to 에 그 태그를 만든다.
+func cmdChangelog(ctx context.Context, repo *gogit.Repository, args []string) error {
+	bump := false
+	var rangeArg string
+	for _, arg := range args {
+		switch {
+		case arg == "--bump":
+			bump = true
+		case rangeArg == "" && strings.Contains(arg, ".."):
+			rangeArg = arg
+		default:
+			return fmt.Errorf("usage: gogit changelog [--bump] <from>..<to>")
+		}
+	}
+	if rangeArg == "" {
+		return fmt.Errorf("usage: gogit changelog [--bump] <from>..<to>")
+	}
+
+	fromRev, toRev, _ := strings.Cut(rangeArg, "..")
+	if toRev == "" {
+		toRev = "HEAD"
+	}
+	to, err := repo.ResolveCommit(toRev)
+	if err != nil {
+		return err
+	}
+	var from string
+	if fromRev != "" {
+		if from, err = repo.ResolveCommit(fromRev); err != nil {
+			return err
+		}
+	}
+
+	changes, err := changelog.Collect(ctx, repo.Objects, from, to)
+	if err != nil {
+		return err
+	}
+
+	title := "Unreleased"
+	var next changelog.Version
+	if bump {
+		current := changelog.Version{Prefix: "v"}
+		if fromRev != "" {
+			if current, err = changelog.ParseVersion(fromRev); err != nil {
+				return err
+			}
+		}
+		level := changelog.BumpFor(changes)
+		if level == changelog.BumpNone {
+			return fmt.Errorf("no feat or fix commits in %s, nothing to release", rangeArg)
+		}
+		next = current.Next(level)
+		if _, err := repo.Refs.Resolve("refs/tags/" + next.String()); err == nil {
+			return fmt.Errorf("tag '%s' already exists", next)
+		}
+		title = next.String()
+	}
+
+	if err := changelog.WriteMarkdown(os.Stdout, title, changes); err != nil {
+		return err
+	}
+	if bump {
+		return repo.UpdateRef("refs/tags/"+next.String(), to, "changelog: release "+next.String())
+	}
+	return nil
+}
+
+// Subtree: 하위 디렉토리 단위의 이력 다루기
+//
+//	subtree split --prefix=<dir> [-b <branch>] [<rev>]   dir 의 이력만 떼어 낸 커밋 해시 출력
+//	subtree add   --prefix=<dir> [-m <msg>] <commit>     commit 을 dir 아래로 들여온다
+//	subtree merge --prefix=<dir> [-m <msg>] <commit>     dir 을 commit 의 내용으로 갱신한다
+//
+// split -b 는 브랜치가 이미 있으면 split 결과가 그 브랜치에서 fast-forward 될 때만 옮긴다.
+// add/merge 는 현재 브랜치에 merge commit 을 만들고 작업 트리도 그 내용으로 바꾼다. 작업 트리는 HEAD 와 같아야 한다.
+func cmdSubtree(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit subtree (split|add|merge) --prefix=<dir> [options] [<commit>]"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	action := args[0]
+	var prefix, branch, message string
+	var positional []string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--prefix="):
+			prefix = strings.TrimPrefix(arg, "--prefix=")
+		case (arg == "--prefix" || arg == "-P" || arg == "-b" || arg == "-m") && i+1 < len(args):
+			i++
+			switch arg {
+			case "-b":
+				branch = args[i]
+			case "-m":
+				message = args[i]
+			default:
+				prefix = args[i]
+			}
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" || len(positional) > 1 {
+		return errors.New(usage)
+	}
+
+	switch action {
+	case "split":
+		rev := "HEAD"
+		if len(positional) == 1 {
+			rev = positional[0]
+		}
+		head, err := repo.ResolveCommit(rev)
+		if err != nil {
+			return err
+		}
+		hash, err := subtree.Split(ctx, repo.Objects, head, prefix)
+		if err != nil {
+			return err
+		}
+		if branch != "" {
+			ref := "refs/heads/" + branch
+			old, err := repo.Refs.Resolve(ref)
+			switch {
+			case errors.Is(err, refs.ErrNotFound):
+			case err != nil:
+				return err
+			case old != hash:
+				ancestors, err := object.Reachable(ctx, repo.Objects, []string{hash})
+				if err != nil {
+					return err
+				}
+				if !ancestors[old] {
+					return fmt.Errorf("branch '%s' is not an ancestor of commit '%s'", branch, hash)
+				}
+			}
+			if err := repo.UpdateRef(ref, hash, "subtree split: "+prefix); err != nil {
+				return err
+			}
+		}
+		fmt.Println(hash)
+		return nil
+
+	case "add", "merge":
+		if len(positional) != 1 {
+			return errors.New(usage)
+		}
+		head, err := repo.ResolveCommit("HEAD")
+		if err != nil {
+			return err
+		}
+		headTree, err := repo.ResolveTree(head)
+		if err != nil {
+			return err
+		}
+		// 다음 commit 이 작업 트리를 통째로 스냅샷하므로 작업 트리도 새 커밋과 같아야 한다
+		if !repo.IsBare() {
+			if err := requireCleanWorkTree(ctx, repo, headTree, "subtree "+action); err != nil {
+				return err
+			}
+		}
+		commit, err := repo.ResolveCommit(positional[0])
+		if err != nil {
+			return err
+		}
+		opts := subtree.CommitOptions{Message: message}
+		if opts.Author, err = repo.Author(); err != nil {
+			return err
+		}
+		if opts.Committer, err = repo.Committer(); err != nil {
+			return err
+		}
+
+		store, err := repo.CommitStorer()
+		if err != nil {
+			return err
+		}
+		var hash string
+		if action == "add" {
+			hash, err = subtree.Add(store, head, prefix, commit, opts)
+		} else {
+			hash, err = subtree.Merge(ctx, store, head, prefix, commit, opts)
+		}
+		if errors.Is(err, subtree.ErrUpToDate) {
+			fmt.Println("Subtree is already at commit " + commit)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !repo.IsBare() {
+			tree, err := repo.ResolveTree(hash)
+			if err != nil {
+				return err
+			}
+			if err := worktree.Checkout(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, headTree, tree); err != nil {
+				return err
+			}
+		}
+		if err := repo.UpdateHead(hash, "subtree "+action+": "+prefix); err != nil {
+			return err
+		}
+		fmt.Println(hash)
+		return nil
+	}
+	return errors.New(usage)
+}
+
+// Provenance: 커밋의 provenance 해시 체인 검사
+// 체인은 config 에 provenance.enabled = true 를 두면 새 커밋마다 붙는다.
+//
+//	provenance verify [<rev>]   rev(기본 HEAD)에서 도달 가능한 커밋의 체인 값을 다시 계산한다
+func cmdProvenance(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit provenance verify [<rev>]"
+	if len(args) == 0 || args[0] != "verify" || len(args) > 2 {
+		return errors.New(usage)
+	}
+	rev := "HEAD"
+	if len(args) == 2 {
+		rev = args[1]
+	}
+	head, err := repo.ResolveCommit(rev)
+	if err != nil {
+		return err
+	}
+
+	report, err := provenance.Verify(ctx, repo.Objects, head)
+	if err != nil {
+		return err
+	}
+	for _, b := range report.Broken {
+		fmt.Println(b)
+	}
+	fmt.Printf("%d chained, %d without provenance, %d broken\n", report.Chained, report.Unchained, len(report.Broken))
+	if !report.OK() {
+		return errProvenanceBroken
+	}
+	return nil
+}
+
+// Sizer: 저장소 크기 분석. 가장 큰 blob/tree, 가장 깊은 경로, 확장자별 크기, 월별 증가량을 출력한다.
+// 리비전을 주지 않으면 모든 ref 와 HEAD 에서 도달 가능한 객체를 센다.
+//
+//	sizer [--top <n>] [<rev>...]
+func cmdSizer(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit sizer [--top <n>] [<rev>...]"
+	var opts sizer.Options
+	var heads []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--top" && i+1 < len(args):
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n <= 0 {
+				return errors.New(usage)
+			}
+			opts.Top = n
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			hash, err := repo.ResolveCommit(arg)
+			if err != nil {
+				return err
+			}
+			heads = append(heads, hash)
+		}
+	}
+	if len(heads) == 0 {
+		var err error
+		if heads, err = repo.AllRefs(); err != nil {
+			return err
+		}
+	}
+
+	report, err := sizer.Analyze(ctx, repo.Objects, heads, opts)
+	if err != nil {
+		return err
+	}
+	return report.WriteText(os.Stdout)
+}
+
+// setOutputEncoding: log/show 의 출력 인코딩을 정한다.
+// i18n.logOutputEncoding, 없으면 i18n.commitEncoding, 둘 다 없으면 UTF-8 (git 과 동일)
+// charset 패키지가 바꿀 수 없는 encoding 헤더를 만나면 인코딩마다 한 번 경고한다.
+func setOutputEncoding(repo *gogit.Repository, pw *pretty.Writer) error {
+	warned := map[string]bool{}
+	pw.SetUnsupportedEncoding(func(encoding string) {
+		if !warned[encoding] {
+			warned[encoding] = true
+			fmt.Fprintf(os.Stderr, "warning: unsupported commit encoding %q; messages are shown unconverted\n", encoding)
+		}
+	})
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	name, ok := cfg.Get("i18n.logOutputEncoding")
+	if !ok {
+		name, _ = cfg.Get("i18n.commitEncoding")
+	}
+	if name != "" && !charset.Supported(name) {
+		fmt.Fprintf(os.Stderr, "warning: unsupported output encoding %q; messages are shown unconverted\n", name)
+		return nil
+	}
+	pw.SetOutputEncoding(name)
+	return nil
+}
+
+// setMailmap: --use-mailmap / --no-use-mailmap 을 주지 않았으면(use 가 nil) config 의 log.mailmap 을 따른다.
+// git 과 같이 기본값은 true 다.
+func setMailmap(repo *gogit.Repository, pw *pretty.Writer, use *bool) error {
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	enabled, err := cfg.GetBool("log.mailmap", true)
+	if err != nil {
+		return err
+	}
+	if use != nil {
+		enabled = *use
+	}
+	if !enabled {
+		return nil
+	}
+	m, err := repo.Mailmap()
+	if err != nil {
+		return err
+	}
+	if !m.Empty() {
+		pw.SetMailmap(m.Lookup)
+	}
+	return nil
+}
+
+// mailmapFlag: --use-mailmap 류 옵션이면 그 값
+func mailmapFlag(arg string) (use bool, ok bool) {
+	switch arg {
+	case "--use-mailmap", "--mailmap":
+		return true, true
+	case "--no-use-mailmap", "--no-mailmap":
+		return false, true
+	}
+	return false, false
+}
+
+// commitPatch: 커밋이 첫 부모에 대해 바꾼 내용. root commit 은 빈 tree 와 비교하고 merge 는 빈 문자열
+func commitPatch(repo *gogit.Repository, commit *object.Commit) (string, error) {
+	if len(commit.Parents) > 1 {
+		return "", nil
+	}
+	var from string
+	if len(commit.Parents) == 1 {
+		parent, err := object.ReadCommit(repo.Objects, commit.Parents[0])
+		if err != nil {
+			return "", err
+		}
+		from = parent.Tree
+	}
+	changes, err := diff.Trees(repo.Objects, from, commit.Tree)
+	if err != nil {
+		return "", err
+	}
+	opts, err := diffOptions(repo)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := diff.WritePatch(&b, repo.Objects, changes, opts); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// diffOptions: patch 출력 옵션
+// hunk 머리의 함수 줄은 파일의 diff 속성(diff=<driver>)이 정한 driver 로 고르고, 속성이 없으면 git 의 기본 규칙을 쓴다.
+// config 의 diff.<driver>.xfuncname 이 있으면 내장 패턴보다 우선한다. repo 가 nil 이면 늘 기본 규칙이다. (diff --no-index)
+func diffOptions(repo *gogit.Repository) (*diff.Options, error) {
+	rules := attr.Parse()
+	xfuncname := func(driver string) (string, bool) { return "", false }
+	if repo != nil {
+		var err error
+		if rules, err = repo.Attributes(); err != nil {
+			return nil, err
+		}
+		cfg, err := repo.Config()
+		if err != nil {
+			return nil, err
+		}
+		xfuncname = func(driver string) (string, bool) { return cfg.Get("diff." + driver + ".xfuncname") }
+	}
+
+	custom := map[string]diff.FuncMatcher{}
+	funcName := func(p string) diff.FuncMatcher {
+		driver, ok := rules.Get(p, "diff")
+		if !ok || driver == "true" || driver == "false" {
+			return nil
+		}
+		if m, ok := custom[driver]; ok {
+			return m
+		}
+		if pattern, ok := xfuncname(driver); ok {
+			m, err := diff.FuncPattern(pattern)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: diff.%s.xfuncname: %v\n", driver, err)
+			}
+			custom[driver] = m
+			return m
+		}
+		m, _ := diff.Driver(driver)
+		return m
+	}
+	return &diff.Options{Context: diff.DefaultContext, FuncName: funcName}, nil
+}
+
+// GC: 도달 가능한 객체를 packfile 하나로 묶고, 도달할 수 없는 오래된 객체를 지우고, ref 를 packed-refs 로 모은다.
+//
+//	gc [--prune=<date> | --no-prune]
+//
+// 도달 가능성은 모든 ref, HEAD, reflog 에 남은 커밋, 진행 중인 cherry-pick/revert/rebase 의 커밋에서 따진다.
+// 도달할 수 없는 객체는 <date>(기본값은 gc.pruneExpire 설정, 없으면 "2.weeks.ago")보다 오래된 것만 지운다.
+// 방금 write-tree 로 만든 객체처럼 아직 ref 에 걸리지 않은 객체를 지우지 않기 위해서다.
+// 예전 pack 에만 있던 도달할 수 없는 객체는 pack 이 유예 기간 안이면 loose 객체로 꺼내 둔다.
+func cmdGC(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit gc [--prune=<date> | --no-prune]"
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	prune := "2.weeks.ago"
+	if v, ok := cfg.Get("gc.pruneExpire"); ok {
+		prune = v
+	}
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--prune="):
+			prune = strings.TrimPrefix(arg, "--prune=")
+		case arg == "--no-prune":
+			prune = "never"
+		default:
+			return errors.New(usage)
+		}
+	}
+	// never 면 아무것도 지우지 않도록 가장 이른 시각으로 둔다
+	var expire time.Time
+	if prune != "never" {
+		if expire, err = gogit.ParseDate(prune, time.Now()); err != nil {
+			return fmt.Errorf("invalid --prune date: %w", err)
+		}
+	}
+
+	name, packed, pruned, err := gcRepository(ctx, repo, expire)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		fmt.Printf("Nothing to pack; pruned %d unreachable objects\n", pruned)
+		return nil
+	}
+	fmt.Printf("Packed %d objects into %s; pruned %d unreachable objects\n", packed, name, pruned)
+	return nil
+}
+
+// gcRepository: gc 의 본체. expire 보다 오래된 도달할 수 없는 객체를 지운다.
+// 새 pack 의 이름(묶을 객체가 없으면 빈 문자열), 묶은 객체 수, 지운 객체 수를 돌려준다.
+func gcRepository(ctx context.Context, repo *gogit.Repository, expire time.Time) (name string, packed, pruned int, err error) {
+	store, ok := repo.Objects.(*object.Store)
+	if !ok {
+		return "", 0, 0, fmt.Errorf("gc: object store cannot be packed: %w", errors.ErrUnsupported)
+	}
+	if p, ok := repo.Refs.(refs.Packer); ok {
+		if err := p.Pack(); err != nil {
+			return "", 0, 0, err
+		}
+	}
+
+	roots, err := gcRoots(repo, time.Time{})
+	if err != nil {
+		return "", 0, 0, err
+	}
+	reachable, err := object.ReachableObjects(ctx, store, roots)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	keep := make(map[string]bool, len(reachable))
+	for _, hash := range reachable {
+		keep[hash] = true
+	}
+
+	oldPacks, err := store.Packs()
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if len(reachable) > 0 {
+		if name, err = store.WritePack(reachable); err != nil {
+			return "", 0, 0, err
+		}
+	}
+
+	for _, p := range oldPacks {
+		if p.Name == name {
+			continue
+		}
+		for _, hash := range p.Hashes {
+			if keep[hash] {
+				continue
+			}
+			if !p.ModTime.After(expire) {
+				pruned++
+				continue
+			}
+			raw, err := store.ReadRaw(hash)
+			if err != nil {
+				return "", 0, 0, err
+			}
+			if err := store.WriteRaw(hash, raw); err != nil {
+				return "", 0, 0, err
+			}
+		}
+		if err := store.RemovePack(p.Name); err != nil {
+			return "", 0, 0, err
+		}
+	}
+	err = store.ForEachLoose(func(hash string, modTime time.Time) error {
+		if !keep[hash] {
+			if modTime.After(expire) {
+				return nil
+			}
+			pruned++
+		}
+		return store.RemoveLoose(hash)
+	})
+	if err != nil {
+		return "", 0, 0, err
+	}
+	return name, len(reachable), pruned, nil
+}
+
+// Prune: 어디에서도 도달할 수 없는 loose 객체를 지운다. pack 에도 들어 있는 loose 객체도 지운다.
+//
+//	prune [-n] [-v] [--expire <time>]
+//
+// --expire 를 주면 그보다 오래된 객체만 지우고, reflog 도 그 시각 이후의 기록만 도달 가능성에 넣는다.
+// (오래된 reflog 기록만 붙잡고 있던 객체는 지워진다) 주지 않으면 모든 reflog 기록을 보고 나이와 상관없이 지운다.
+// pack 안의 객체는 건드리지 않는다. (gc 가 다시 묶을 때 정리한다)
+// -n 은 지울 객체를 보여 주기만 하고, -v 는 지우면서 보여 준다.
+func cmdPrune(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit prune [-n] [-v] [--expire <time>]"
+	dryRun, verbose := false, false
+	var expire, since time.Time
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value := ""
+		switch {
+		case arg == "-n" || arg == "--dry-run":
+			dryRun = true
+			continue
+		case arg == "-v" || arg == "--verbose":
+			verbose = true
+			continue
+		case arg == "--expire" && i+1 < len(args):
+			i++
+			value = args[i]
+		case strings.HasPrefix(arg, "--expire="):
+			value = strings.TrimPrefix(arg, "--expire=")
+		default:
+			return errors.New(usage)
+		}
+		t, err := gogit.ParseDate(value, time.Now())
+		if err != nil {
+			return fmt.Errorf("invalid --expire date: %w", err)
+		}
+		expire, since = t, t
+	}
+	if expire.IsZero() {
+		expire = time.Now()
+	}
+
+	store, ok := repo.Objects.(*object.Store)
+	if !ok {
+		return fmt.Errorf("prune: object store has no loose objects: %w", errors.ErrUnsupported)
+	}
+	roots, err := gcRoots(repo, since)
+	if err != nil {
+		return err
+	}
+	reachable, err := object.ReachableObjects(ctx, store, roots)
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]bool, len(reachable))
+	for _, hash := range reachable {
+		keep[hash] = true
+	}
+	packs, err := store.Packs()
+	if err != nil {
+		return err
+	}
+	packed := map[string]bool{}
+	for _, p := range packs {
+		for _, hash := range p.Hashes {
+			packed[hash] = true
+		}
+	}
+
+	return store.ForEachLoose(func(hash string, modTime time.Time) error {
+		if !packed[hash] && (keep[hash] || modTime.After(expire)) {
+			return nil
+		}
+		if dryRun || verbose {
+			typ := object.Type("unknown")
+			if t, _, err := store.Read(hash); err == nil {
+				typ = t
+			}
+			fmt.Println(hash, typ)
+		}
+		if dryRun {
+			return nil
+		}
+		return store.RemoveLoose(hash)
+	})
+}
+
+// Reflog: ref 가 움직인 기록을 보여 주거나 오래된 기록을 지운다.
+//
+//	reflog [show] [<ref>]                                            기록을 최근 것부터 (기본값 HEAD)
+//	reflog expire [--expire=<time>] [-n] [--all | <ref>...]          <time> 보다 오래된 기록을 지운다
+//
+// expire 의 기본 시각은 gc.reflogExpire 설정, 없으면 git 과 같은 "90.days.ago" 다.
+// 기록을 지우면 그 기록만 붙잡고 있던 객체를 prune 이나 gc 가 지울 수 있게 된다.
+func cmdReflog(repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit reflog [show] [<ref>] | expire [--expire=<time>] [-n] [--all | <ref>...]"
+	if len(args) > 0 && args[0] == "expire" {
+		return reflogExpire(repo, args[1:])
+	}
+	if len(args) > 0 && args[0] == "show" {
+		args = args[1:]
+	}
+	if len(args) > 1 {
+		return errors.New(usage)
+	}
+	name := "HEAD"
+	if len(args) == 1 {
+		name = args[0]
+	}
+	full, err := fullRefName(repo, name)
+	if err != nil {
+		return err
+	}
+	entries, err := refs.ReadReflog(repo.Refs, full)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for i := len(entries) - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "%s %s@{%d}: %s\n", entries[i].New[:7], name, len(entries)-1-i, entries[i].Message)
+	}
+	_, err = io.WriteString(os.Stdout, b.String())
+	return err
+}
+
+// fullRefName: "main" 같은 짧은 이름을 refs/heads/main 처럼 있는 ref 의 전체 이름으로 바꾼다.
+func fullRefName(repo *gogit.Repository, name string) (string, error) {
+	for _, candidate := range []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name} {
+		if _, err := repo.Refs.Read(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", refs.ErrNotFound, name)
+}
+
+func reflogExpire(repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit reflog expire [--expire=<time>] [-n] [--all | <ref>...]"
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	value := "90.days.ago"
+	if v, ok := cfg.Get("gc.reflogExpire"); ok {
+		value = v
+	}
+	all, dryRun := false, false
+	var names []string
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--expire="):
+			value = strings.TrimPrefix(arg, "--expire=")
+		case arg == "--all":
+			all = true
+		case arg == "-n" || arg == "--dry-run":
+			dryRun = true
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			full, err := fullRefName(repo, arg)
+			if err != nil {
+				return err
+			}
+			names = append(names, full)
+		}
+	}
+	if all == (len(names) > 0) {
+		return errors.New(usage)
+	}
+	// never 면 아무것도 지우지 않도록 가장 이른 시각으로 둔다
+	var expire time.Time
+	if value != "never" {
+		if expire, err = gogit.ParseDate(value, time.Now()); err != nil {
+			return fmt.Errorf("invalid --expire date: %w", err)
+		}
+	}
+	if all {
+		list, err := repo.Refs.List()
+		if err != nil {
+			return err
+		}
+		names = append(names, "HEAD")
+		for _, ref := range list {
+			names = append(names, ref.Name)
+		}
+	}
+
+	for _, name := range names {
+		keep := func(e refs.ReflogEntry) bool { return !e.Who.When.Before(expire) }
+		var removed int
+		if dryRun {
+			entries, err := refs.ReadReflog(repo.Refs, name)
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				if !keep(e) {
+					removed++
+				}
+			}
+		} else if removed, err = refs.ExpireReflog(repo.Refs, name, keep); err != nil {
+			return err
+		}
+		switch {
+		case removed > 0 && dryRun:
+			fmt.Printf("%s: would expire %d entries\n", name, removed)
+		case removed > 0:
+			fmt.Printf("%s: %d entries expired\n", name, removed)
+		}
+	}
+	return nil
+}
+
+// gcRoots: gc 가 지우면 안 되는 객체들의 시작점. 이미 없는 객체(reflog 의 오래된 항목 등)는 뺀다.
+// reflog 와 undo 기록은 since 이후의 것만 본다. since 가 zero 면 모든 기록을 본다.
+func gcRoots(repo *gogit.Repository, since time.Time) ([]string, error) {
+	list, err := repo.Refs.List()
+	if err != nil {
+		return nil, err
+	}
+	var hashes []string
+	names := []string{"HEAD"}
+	for _, ref := range list {
+		names = append(names, ref.Name)
+		if ref.Hash != "" {
+			hashes = append(hashes, ref.Hash)
+		}
+	}
+	if head, err := repo.Refs.Resolve("HEAD"); err == nil {
+		hashes = append(hashes, head)
+	}
+	// 다른 작업 트리의 detached HEAD (브랜치는 위에서 이미 셌다)
+	worktrees, err := repo.Worktrees()
+	if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		return nil, err
+	}
+	for _, wt := range worktrees {
+		if wt.Head.Hash != "" {
+			hashes = append(hashes, wt.Head.Hash)
+		}
+	}
+	for _, name := range names {
+		entries, err := refs.ReadReflog(repo.Refs, name)
+		if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.Who.When.Before(since) {
+				continue
+			}
+			hashes = append(hashes, e.Old, e.New)
+		}
+	}
+	// undo 로 되돌아갈 수 있는 커밋
+	journal, err := ops.List(repo.FS)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range journal {
+		if op.Time.Before(since) {
+			continue
+		}
+		for _, state := range []ops.State{op.Before, op.After} {
+			for _, v := range state {
+				if _, symbolic := ops.Target(v); !symbolic {
+					hashes = append(hashes, v)
+				}
+			}
+		}
+	}
+	for _, name := range []string{cherryPick.stateFile, revert.stateFile, rebaseDir + "/orig-head", rebaseDir + "/onto", rebaseDir + "/autostash"} {
+		data, err := vfs.ReadFile(repo.FS, name)
+		if err == nil {
+			hashes = append(hashes, strings.TrimSpace(string(data)))
+		}
+	}
+
+	var roots []string
+	for _, hash := range hashes {
+		if !object.IsZeroHash(hash) && repo.Objects.Has(hash) && !slices.Contains(roots, hash) {
+			roots = append(roots, hash)
+		}
+	}
+	return roots, nil
+}
+
+// Fsck: 모든 객체를 다시 해시하고 문법을 검사하고, ref 에서 가리키는 객체가 모두 있는지 확인한다.
+//
+//	fsck [--no-dangling]
+//
+// 어디에서도 가리키지 않는 객체는 dangling 으로 알려 주지만 문제로 보지는 않는다.
+// reflog 와 진행 중인 cherry-pick/revert/rebase 가 붙잡고 있는 객체는 gc 와 같이 도달 가능한 것으로 본다.
+// 저장소 디렉토리 이름의 tree 항목은 문제로 본다. --git-compat 이면 ".git" 만이고 ".gogit" 은 평범한 디렉토리다.
+// 문제가 하나라도 있으면 종료 코드 1 로 끝난다.
+func cmdFsck(ctx context.Context, repo *gogit.Repository, repoOpts gogit.Options, args []string) error {
+	const usage = "usage: gogit fsck [--no-dangling]"
+	dangling := true
+	for _, arg := range args {
+		switch arg {
+		case "--dangling":
+			dangling = true
+		case "--no-dangling":
+			dangling = false
+		default:
+			return errors.New(usage)
+		}
+	}
+
+	list, err := repo.Refs.List()
+	if err != nil {
+		return err
+	}
+	opts := fsck.Options{Refs: map[string]string{}, RepoDir: repoOpts.RepoDirName()}
+	for _, ref := range list {
+		if ref.Target == "" {
+			opts.Refs[ref.Name] = ref.Hash
+		}
+	}
+	if head, err := repo.Refs.Resolve("HEAD"); err == nil {
+		opts.Refs["HEAD"] = head
+	} else if !errors.Is(err, refs.ErrNotFound) {
+		return err
+	}
+	if opts.Extra, err = gcRoots(repo, time.Time{}); err != nil {
+		return err
+	}
+
+	report, err := fsck.Check(ctx, repo.Objects, opts)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteText(os.Stdout, dangling); err != nil {
+		return err
+	}
+	if !report.OK() {
+		return errFsckFailed
+	}
+	return nil
+}
+
+// CountObjects: 객체 디렉토리의 loose 객체와 pack 의 수와 크기를 보여 준다. (크기는 KiB)
+//
+//	count-objects [-v]
+//
+// -v 는 git 과 같은 "key: value" 형식으로 pack, 지워도 되는 loose 객체(prune-packable), garbage 까지 보여 준다.
+// garbage 파일은 경고로 하나씩 알려 준다.
+func cmdCountObjects(repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit count-objects [-v]"
+	verbose := false
+	for _, arg := range args {
+		if arg != "-v" && arg != "--verbose" {
+			return errors.New(usage)
+		}
+		verbose = true
+	}
+	store, ok := repo.Objects.(*object.Store)
+	if !ok {
+		return fmt.Errorf("count-objects: object store has no directory: %w", errors.ErrUnsupported)
+	}
+	c, err := store.Count()
+	if err != nil {
+		return err
+	}
+	if !verbose {
+		fmt.Printf("%d objects, %d kilobytes\n", c.Count, c.Size/1024)
+		return nil
+	}
+	for _, name := range c.Garbage {
+		fmt.Fprintf(os.Stderr, "warning: garbage found: %s\n", filepath.Join(repo.CommonDir, "objects", name))
+	}
+	fmt.Printf("count: %d\n", c.Count)
+	fmt.Printf("size: %d\n", c.Size/1024)
+	fmt.Printf("in-pack: %d\n", c.InPack)
+	fmt.Printf("packs: %d\n", c.Packs)
+	fmt.Printf("size-pack: %d\n", c.SizePack/1024)
+	fmt.Printf("prune-packable: %d\n", c.PrunePackable)
+	fmt.Printf("garbage: %d\n", len(c.Garbage))
+	fmt.Printf("size-garbage: %d\n", c.SizeGarbage/1024)
+	return nil
+}
+
+// worktreeCommands: 작업 트리의 파일을 바꾸는 명령. 실행 전 작업 트리를 기록해 두어 undo 가 파일도 되돌린다.
+var worktreeCommands = map[string]bool{"cherry-pick": true, "revert": true, "rebase": true, "stack": true, "subtree": true, "am": true}
+
+// journalStart: 명령 전의 ref 들을 기록한다. 기록하지 못해도 명령은 실행하고 경고만 한다.
+func journalStart(ctx context.Context, repo *gogit.Repository, args []string) *ops.Op {
+	before, err := ops.Capture(repo.Refs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: not recording operation: %v\n", err)
+		return nil
+	}
+	op := &ops.Op{Command: strings.Join(args, " "), Before: before}
+	if worktreeCommands[args[0]] && !repo.IsBare() {
+		if op.Tree, err = snapshotWorkTree(ctx, repo); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: not recording operation: %v\n", err)
+			return nil
+		}
+	}
+	return op
+}
+
+// journalEnd: ref 가 바뀌었으면 ops 에 기록한다. 명령이 실패했어도(충돌로 멈춘 rebase 등) 바뀐 것은 남긴다.
+func journalEnd(repo *gogit.Repository, op *ops.Op) {
+	after, err := ops.Capture(repo.Refs)
+	if err == nil {
+		op.After = after
+		if len(op.Changed()) == 0 {
+			return
+		}
+		_, err = ops.Record(repo.FS, *op)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not record operation: %v\n", err)
+	}
+}
+
+// Undo: 가장 최근에 ref 를 바꾼 gogit 명령(commit, cherry-pick, rebase 등)을 되돌린다.
+//
+//	undo          ref 들을 그 명령 전으로 되돌린다. 거듭하면 더 이전의 명령을 되돌린다
+//	undo --list   기록된 명령들 (최근 것부터)
+//
+// commit 처럼 파일을 바꾸지 않은 명령은 ref 만 되돌리므로 작업 트리의 내용은 그대로 남는다. (git reset --soft)
+// rebase 처럼 파일을 바꾼 명령은 작업 트리도 명령 전의 상태로 되돌린다. 이때는 작업 트리에 커밋하지 않은 변경이 없어야 한다.
+// 그 뒤에 다른 도구가 같은 ref 를 바꾸었으면 덮어쓰지 않고 멈춘다.
+func cmdUndo(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit undo [--list]"
+	if repo.FS == nil {
+		return fmt.Errorf("undo: repository has no operation journal: %w", errors.ErrUnsupported)
+	}
+	list, err := ops.List(repo.FS)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(args) == 1 && args[0] == "--list":
+		return printOps(list)
+	case len(args) != 0:
+		return errors.New(usage)
+	}
+
+	for _, state := range []string{rebaseDir, amDir, bisectStartFile, cherryPick.stateFile, revert.stateFile} {
+		if vfs.Exists(repo.FS, state) {
+			return errors.New("an operation is in progress (rebase, am, bisect, cherry-pick or revert); finish or abort it before undo")
+		}
+	}
+	op, ok := ops.LastUndoable(list)
+	if !ok {
+		return errors.New("nothing to undo")
+	}
+	current, err := ops.Capture(repo.Refs)
+	if err != nil {
+		return err
+	}
+	changed := op.Changed()
+	for _, name := range changed {
+		if current[name] != op.After[name] {
+			return fmt.Errorf("cannot undo '%s': %s has changed since", op.Command, name)
+		}
+	}
+
+	// 파일을 되돌려야 하면 먼저 작업 트리가 깨끗한지 확인한다
+	var work string
+	if op.Tree != "" {
+		if err := repo.RequireWorkTree("undo"); err != nil {
+			return err
+		}
+		if work, err = snapshotWorkTree(ctx, repo); err != nil {
+			return err
+		}
+		headTree, err := repo.ResolveTree("HEAD")
+		if err != nil {
+			return err
+		}
+		if work != headTree {
+			return errors.New("your local changes would be overwritten by undo; commit them first (or use \"gogit wip save\")")
+		}
+	}
+
+	// HEAD 는 가리키는 브랜치를 되돌린 뒤에 마지막으로 바꾼다
+	if i := slices.Index(changed, "HEAD"); i >= 0 {
+		changed = append(slices.Delete(changed, i, i+1), "HEAD")
+	}
+	message := "undo: " + op.Command
+	for _, name := range changed {
+		v, ok := op.Before[name]
+		switch target, symbolic := ops.Target(v); {
+		case !ok:
+			err = refs.Delete(repo.Refs, name)
+		case symbolic:
+			err = repo.Refs.SetSymbolic(name, target)
+		default:
+			err = repo.UpdateRef(name, v, message)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if op.Tree != "" {
+		if err := worktree.Checkout(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, work, op.Tree); err != nil {
+			return err
+		}
+	}
+
+	after, err := ops.Capture(repo.Refs)
+	if err != nil {
+		return err
+	}
+	if _, err := ops.Record(repo.FS, ops.Op{Command: "undo", Undoes: op.ID, Before: current, After: after}); err != nil {
+		return err
+	}
+	fmt.Printf("Undid operation %d: %s\n", op.ID, op.Command)
+	return nil
+}
+
+// printOps: 기록을 최근 것부터, 바뀐 ref 와 함께 출력한다.
+func printOps(list []ops.Op) error {
+	undone := map[int]bool{}
+	for _, op := range list {
+		if op.Undoes != 0 {
+			undone[op.Undoes] = true
+		}
+	}
+	short := func(v string) string {
+		if v == "" {
+			return "(none)"
+		}
+		if _, symbolic := ops.Target(v); symbolic || len(v) < 7 {
+			return v
+		}
+		return v[:7]
+	}
+	var b strings.Builder
+	for i := len(list) - 1; i >= 0; i-- {
+		op := list[i]
+		command := op.Command
+		if op.Undoes != 0 {
+			command = fmt.Sprintf("undo of %d", op.Undoes)
+		}
+		fmt.Fprintf(&b, "%d  %s  %s", op.ID, op.Time.Format("2006-01-02 15:04:05"), command)
+		if undone[op.ID] {
+			b.WriteString("  (undone)")
+		}
+		b.WriteString("\n")
+		for _, name := range op.Changed() {
+			fmt.Fprintf(&b, "    %s: %s -> %s\n", name, short(op.Before[name]), short(op.After[name]))
+		}
+	}
+	_, err := io.WriteString(os.Stdout, b.String())
+	return err
+}
+
+// wipRef: wip save 가 스냅샷을 쌓아 두는 ref. 목록은 이 ref 의 reflog 다. (refs/stash 와 같은 방식)
+const wipRef = "refs/wip"
+
+// Wip: 작업 트리 전체(추적하지 않는 파일 포함)를 커밋으로 저장해 두었다가 그대로 되돌린다.
+// 스냅샷은 commit 과 같으므로 HEAD 에 없는 파일 중 .gitignore 등의 무시 규칙에 맞는 것은 저장하지 않고,
+// restore 도 그런 파일은 건드리지 않는다. (snapshotWorkTree 참고)
+// stash 와 달리 save 는 작업 트리를 건드리지 않고, restore 는 merge 없이 저장한 상태를 그대로 꺼낸다.
+//
+//	wip [list]                 저장한 스냅샷들 (wip@{0} 이 가장 최근)
+//	wip save [<message>]       지금 상태를 시각과 메시지를 붙여 저장한다
+//	wip restore [<n>]          wip@{n}(기본값 0)의 상태로 작업 트리를 되돌린다. HEAD 는 그대로다
+//
+// restore 하기 전의 작업 트리가 HEAD 와 다르고 저장해 둔 적도 없으면 먼저 저장하므로 restore 로 잃는 것은 없다.
+func cmdWip(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit wip [list] | save [<message>] | restore [<n>]"
+	if len(args) == 0 || (args[0] == "list" && len(args) == 1) {
+		return wipList(repo)
+	}
+	if err := repo.RequireWorkTree("wip"); err != nil {
+		return err
+	}
+	switch {
+	case args[0] == "save" && len(args) <= 2:
+		message := ""
+		if len(args) == 2 {
+			message = args[1]
+		}
+		tree, err := snapshotWorkTree(ctx, repo)
+		if err != nil {
+			return err
+		}
+		hash, err := wipSave(repo, tree, message)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Saved working state %s\n", hash[:7])
+		return nil
+	case args[0] == "restore" && len(args) <= 2:
+		n := 0
+		if len(args) == 2 {
+			arg := strings.TrimSuffix(strings.TrimPrefix(args[1], "wip@{"), "}")
+			var err error
+			if n, err = strconv.Atoi(arg); err != nil || n < 0 {
+				return errors.New(usage)
+			}
+		}
+		return wipRestore(ctx, repo, n)
+	}
+	return errors.New(usage)
+}
+
+// wipEntries: 저장한 스냅샷들. 가장 최근 것이 앞이다.
+func wipEntries(repo *gogit.Repository) ([]refs.ReflogEntry, error) {
+	entries, err := refs.ReadReflog(repo.Refs, wipRef)
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+func wipList(repo *gogit.Repository) error {
+	entries, err := wipEntries(repo)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for i, e := range entries {
+		fmt.Fprintf(&b, "wip@{%d}: %s\n", i, e.Message)
+	}
+	_, err = io.WriteString(os.Stdout, b.String())
+	return err
+}
+
+// wipSave: tree 를 HEAD(없으면 부모 없이) 위의 커밋으로 만들어 refs/wip 에 쌓는다.
+func wipSave(repo *gogit.Repository, tree string, message string) (string, error) {
+	branch := "(no branch)"
+	if ref, err := repo.Refs.Read("HEAD"); err == nil && ref.Target != "" {
+		branch = strings.TrimPrefix(ref.Target, "refs/heads/")
+	}
+	var parents []string
+	if head, err := repo.ResolveCommit("HEAD"); err == nil {
+		parents = []string{head}
+	}
+	subject := fmt.Sprintf("WIP on %s: %s", branch, time.Now().Format("2006-01-02 15:04:05"))
+	if message != "" {
+		subject += " " + message
+	}
+	author, err := repo.Author()
+	if err != nil {
+		return "", err
+	}
+	hash, err := createCommit(repo, parents, tree, author, subject+"\n")
+	if err != nil {
+		return "", err
+	}
+	return hash, repo.UpdateRef(wipRef, hash, subject)
+}
+
+func wipRestore(ctx context.Context, repo *gogit.Repository, n int) error {
+	entries, err := wipEntries(repo)
+	if err != nil {
+		return err
+	}
+	if n >= len(entries) {
+		return fmt.Errorf("wip@{%d}: %w", n, gogit.ErrUnknownRevision)
+	}
+	target, err := repo.ResolveTree(entries[n].New)
+	if err != nil {
+		return err
+	}
+	current, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if current == target {
+		fmt.Println("Working tree already matches the saved state.")
+		return nil
+	}
+
+	// 저장하지 않은 변경이 있으면 먼저 저장한다
+	headTree := ""
+	if head, err := repo.ResolveCommit("HEAD"); err == nil {
+		if headTree, err = repo.ResolveTree(head); err != nil {
+			return err
+		}
+	}
+	saved := current == headTree
+	for _, e := range entries {
+		if saved {
+			break
+		}
+		tree, err := repo.ResolveTree(e.New)
+		if err != nil {
+			return err
+		}
+		saved = tree == current
+	}
+	if !saved {
+		hash, err := wipSave(repo, current, fmt.Sprintf("(before restoring wip@{%d})", n))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Saved working state %s before restoring\n", hash[:7])
+	}
+
+	if err := worktree.Checkout(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, current, target); err != nil {
+		return err
+	}
+	fmt.Printf("Restored wip@{%d}: %s\n", n, entries[n].Message)
+	return nil
+}
+
+// Worktree: 한 저장소에 작업 트리를 여러 개 두고 각각 다른 브랜치를 받아 둔다. (git worktree)
+//
+//	worktree add [-b <new-branch>] [--detach] <path> [<commit-ish>]
+//	worktree list [--porcelain]
+//	worktree remove [-f] <worktree>
+//
+// add 는 commit-ish 가 브랜치면 그 브랜치를, 아니면 그 커밋을 detached HEAD 로 받는다.
+// commit-ish 가 없으면 git 과 같이 path 의 마지막 부분을 이름으로 HEAD 에서 새 브랜치를 만든다.
+// 한 브랜치는 한 작업 트리에만 받을 수 있다. 각 작업 트리의 HEAD 와 진행 중인 작업은
+// .gogit/worktrees/<name> 에 따로 있고 객체와 브랜치는 함께 쓴다. (gogit.Repository.AddWorktree 참고)
+// remove 는 HEAD 와 다른 파일(추적하지 않는 파일 포함)이 있으면 -f 없이는 지우지 않는다.
+func cmdWorktree(ctx context.Context, opts gogit.Options, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit worktree add [-b <new-branch>] [--detach] <path> [<commit-ish>] | list [--porcelain] | remove [-f] <worktree>"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "add":
+		var branch, path, rev string
+		detach := false
+		for i := 1; i < len(args); i++ {
+			switch arg := args[i]; {
+			case arg == "-b" && i+1 < len(args):
+				i++
+				branch = args[i]
+			case arg == "--detach":
+				detach = true
+			case strings.HasPrefix(arg, "-"):
+				return errors.New(usage)
+			case path == "":
+				path = arg
+			case rev == "":
+				rev = arg
+			default:
+				return errors.New(usage)
+			}
+		}
+		if path == "" || branch != "" && detach {
+			return errors.New(usage)
+		}
+		return worktreeAdd(ctx, opts, repo, path, rev, branch, detach)
+	case "list":
+		switch {
+		case len(args) == 1:
+			return worktreeList(repo, false)
+		case len(args) == 2 && args[1] == "--porcelain":
+			return worktreeList(repo, true)
+		}
+	case "remove":
+		force := false
+		var target string
+		for _, arg := range args[1:] {
+			switch {
+			case arg == "-f" || arg == "--force":
+				force = true
+			case target == "" && !strings.HasPrefix(arg, "-"):
+				target = arg
+			default:
+				return errors.New(usage)
+			}
+		}
+		if target == "" {
+			return errors.New(usage)
+		}
+		return worktreeRemove(ctx, repo, target, force)
+	}
+	return errors.New(usage)
+}
+
+func worktreeAdd(ctx context.Context, opts gogit.Options, repo *gogit.Repository, path, rev, branch string, detach bool) error {
+	// 받을 것을 정한다: 기존 브랜치, 새 브랜치, 또는 detached HEAD
+	start := cmp.Or(rev, "HEAD")
+	if rev != "" && branch == "" && !detach {
+		if _, err := repo.Refs.Resolve("refs/heads/" + rev); err == nil {
+			branch = rev
+		}
+	} else if rev == "" && branch == "" && !detach {
+		branch = filepath.Base(path)
+	}
+	commit, err := repo.ResolveCommit(start)
+	if err != nil {
+		return err
+	}
+	newBranch := branch != "" && branch != rev
+	if newBranch {
+		if _, err := repo.Refs.Resolve("refs/heads/" + branch); err == nil {
+			return fmt.Errorf("a branch named '%s' already exists", branch)
+		}
+	}
+	if branch != "" {
+		worktrees, err := repo.Worktrees()
+		if err != nil {
+			return err
+		}
+		for _, wt := range worktrees {
+			if wt.Head.Target == "refs/heads/"+branch {
+				return fmt.Errorf("'%s' is already checked out at '%s'", branch, wt.Path)
+			}
+		}
+	}
+
+	switch {
+	case newBranch:
+		fmt.Printf("Preparing worktree (new branch '%s')\n", branch)
+	case branch != "":
+		fmt.Printf("Preparing worktree (checking out '%s')\n", branch)
+	default:
+		fmt.Printf("Preparing worktree (detached HEAD %s)\n", commit[:7])
+	}
+	linked, err := repo.AddWorktree(path, opts)
+	if err != nil {
+		return err
+	}
+	if newBranch {
+		if err := repo.UpdateRef("refs/heads/"+branch, commit, "branch: Created from "+start); err != nil {
+			return err
+		}
+	}
+	if branch != "" {
+		err = linked.Refs.SetSymbolic("HEAD", "refs/heads/"+branch)
+	} else {
+		err = linked.UpdateRef("HEAD", commit, "worktree add: "+commit)
+	}
+	if err != nil {
+		return err
+	}
+	tree, err := repo.ResolveTree(commit)
+	if err != nil {
+		return err
+	}
+	if err := worktree.Checkout(ctx, vfs.NewOS(linked.WorkTree), repo.Objects, "", tree); err != nil {
+		return err
+	}
+	c, err := object.ReadCommit(repo.Objects, commit)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("HEAD is now at %s %s\n", commit[:7], c.Subject())
+	return nil
+}
+
+func worktreeList(repo *gogit.Repository, porcelain bool) error {
+	worktrees, err := repo.Worktrees()
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	width := 0
+	for _, wt := range worktrees {
+		width = max(width, len(wt.Path))
+	}
+	for _, wt := range worktrees {
+		hash := wt.Head.Hash
+		if wt.Head.Target != "" {
+			// 아직 커밋이 없는 브랜치면 해시가 없다
+			hash, _ = repo.Refs.Resolve(wt.Head.Target)
+		}
+		if porcelain {
+			fmt.Fprintf(&b, "worktree %s\n", wt.Path)
+			switch {
+			case wt.Bare:
+				b.WriteString("bare\n")
+			case wt.Head.Target != "":
+				fmt.Fprintf(&b, "HEAD %s\nbranch %s\n", cmp.Or(hash, repo.Objects.Algorithm().ZeroHash()), wt.Head.Target)
+			default:
+				fmt.Fprintf(&b, "HEAD %s\ndetached\n", hash)
+			}
+			if wt.Prunable {
+				b.WriteString("prunable gitdir file points to non-existent location\n")
+			}
+			b.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(&b, "%-*s ", width+1, wt.Path)
+		switch {
+		case wt.Bare:
+			b.WriteString("(bare)")
+		case wt.Head.Target != "":
+			fmt.Fprintf(&b, "%-7s [%s]", shortHash(hash), strings.TrimPrefix(wt.Head.Target, "refs/heads/"))
+		default:
+			fmt.Fprintf(&b, "%s (detached HEAD)", shortHash(hash))
+		}
+		if wt.Prunable {
+			b.WriteString(" prunable")
+		}
+		b.WriteString("\n")
+	}
+	_, err = io.WriteString(os.Stdout, b.String())
+	return err
+}
+
+// shortHash: 목록에 보일 7 글자 해시. 커밋이 없으면 0 으로 채운다
+func shortHash(hash string) string {
+	if hash == "" {
+		return "0000000"
+	}
+	return hash[:7]
+}
+
+func worktreeRemove(ctx context.Context, repo *gogit.Repository, target string, force bool) error {
+	worktrees, err := repo.Worktrees()
+	if err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	var found *gogit.Worktree
+	for i, wt := range worktrees {
+		if wt.Path == abs || wt.Name != "" && wt.Name == target {
+			found = &worktrees[i]
+			break
+		}
+	}
+	switch {
+	case found == nil:
+		return fmt.Errorf("'%s' is not a working tree", target)
+	case found.Name == "":
+		return fmt.Errorf("'%s' is a main working tree", target)
+	}
+
+	if !found.Prunable {
+		if !force {
+			linked, err := gogit.OpenDir(filepath.Join(repo.CommonDir, "worktrees", found.Name), found.Path)
+			if err != nil {
+				return err
+			}
+			head, err := linked.ResolveTree("HEAD")
+			if err != nil {
+				return err
+			}
+			tree, err := snapshotWorkTree(ctx, linked)
+			if err != nil {
+				return err
+			}
+			if tree != head {
+				return fmt.Errorf("'%s' contains modified or untracked files, use --force to delete it", target)
+			}
+		}
+		if err := os.RemoveAll(found.Path); err != nil {
+			return err
+		}
+	}
+	return repo.RemoveWorktree(found.Name)
+}
+
+// Submodule: 다른 저장소를 작업 트리의 한 디렉토리에 받아 두고, 상위 tree 에는 그 커밋만 gitlink 로 적는다.
+//
+//	submodule add <url> <path>                 url 을 path 에 clone 하고 목록 파일(.gogitmodules)에 적는다
+//	submodule init [<path>...]                 목록 파일의 url 을 config 의 submodule.<name>.url 로 옮긴다
+//	submodule update [--init] [<path>...]      받지 않은 것은 clone 하고, HEAD 에 적힌 커밋을 detached HEAD 로 받는다
+//	submodule status [<path>...]               HEAD 에 적힌 커밋과 받아 둔 상태
+//
+// 원격은 clone 과 같이 bundle 파일이다. "./", "../" 로 시작하는 url 은 git 과 같이 origin 의 url
+// (없으면 작업 트리) 기준이다. 받아 둔 submodule 은 작업 트리 스냅샷에서 HEAD 커밋의 gitlink 가 되므로
+// add 나 submodule 안에서 커밋을 옮긴 뒤 commit 하면 상위 tree 에 새 커밋이 적힌다.
+// status 의 앞 글자는 '-' 가 받지 않음, '+' 가 받아 둔 HEAD 가 상위 HEAD 에 적힌 것과 다름이다.
+func cmdSubmodule(ctx context.Context, opts gogit.Options, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit submodule add <url> <path> | init [<path>...] | update [--init] [<path>...] | status [<path>...]"
+	if err := repo.RequireWorkTree("submodule"); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		args = []string{"status"}
+	}
+	action, args := args[0], args[1:]
+	opts.Bare = false
+	if action == "add" {
+		if len(args) != 2 || strings.HasPrefix(args[0], "-") || strings.HasPrefix(args[1], "-") {
+			return errors.New(usage)
+		}
+		path, err := repoPath(repo, args[1])
+		if err != nil {
+			return err
+		}
+		return submoduleAdd(ctx, opts, repo, args[0], path)
+	}
+	initFirst := false
+	if action == "update" && len(args) > 0 && args[0] == "--init" {
+		initFirst, args = true, args[1:]
+	}
+	var paths []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return errors.New(usage)
+		}
+		p, err := repoPath(repo, arg)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, p)
+	}
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+
+	switch action {
+	case "init":
+		return submoduleInit(repo, pathspecMatcher(paths))
+	case "update":
+		if initFirst {
+			if err := submoduleInit(repo, pathspecMatcher(paths)); err != nil {
+				return err
+			}
+		}
+		return submoduleUpdate(ctx, opts, repo, pathspecMatcher(paths))
+	case "status":
+		return submoduleStatus(ctx, repo, pathspecMatcher(paths))
+	}
+	return errors.New(usage)
+}
+
+func submoduleAdd(ctx context.Context, opts gogit.Options, repo *gogit.Repository, url, path string) error {
+	modules, err := repo.Submodules()
+	if err != nil {
+		return err
+	}
+	if _, ok := submodule.ByPath(modules, path); ok {
+		return fmt.Errorf("'%s' already exists in %s", path, repo.ModulesFile())
+	}
+	// 상대 경로 url 이 아닌 파일 경로는 어디서 실행해도 같은 곳을 가리키게 절대 경로로 적는다
+	if !submodule.IsRelativeURL(url) && !filepath.IsAbs(url) {
+		if url, err = filepath.Abs(url); err != nil {
+			return err
+		}
+	}
+	m := submodule.Module{Name: path, Path: path, URL: url}
+	resolved, err := submoduleURL(repo, url)
+	if err != nil {
+		return err
+	}
+
+	// 목록 파일에 적을 수 없는 경로(.git 안 등)면 clone 하기 전에 멈춘다
+	file := filepath.Join(repo.WorkTree, repo.ModulesFile())
+	data, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if data, err = submodule.Add(data, m); err != nil {
+		return err
+	}
+
+	dir := filepath.Join(repo.WorkTree, filepath.FromSlash(path))
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("'%s' already exists and is not an empty directory", path)
+	}
+	_, statErr := os.Stat(dir)
+	if err := clone(ctx, opts, resolved, dir, false); err != nil {
+		if errors.Is(statErr, fs.ErrNotExist) {
+			os.RemoveAll(dir)
+		}
+		return err
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return err
+	}
+	return repo.EditConfig(func(data []byte) ([]byte, error) {
+		return config.Add(data, "submodule."+m.Name+".url", resolved)
+	})
+}
+
+// submoduleURL: 목록 파일의 url 을 실제로 가져올 곳으로 푼다
+func submoduleURL(repo *gogit.Repository, url string) (string, error) {
+	if !submodule.IsRelativeURL(url) {
+		return url, nil
+	}
+	base := repo.WorkTree
+	remote, err := repo.Remote("origin")
+	switch {
+	case err == nil:
+		base = remote.URLs[0]
+	case !errors.Is(err, gogit.ErrNoSuchRemote):
+		return "", err
+	}
+	return filepath.Join(base, url), nil
+}
+
+// submoduleInit: match 에 맞는 submodule 중 config 에 url 이 없는 것을 등록한다
+func submoduleInit(repo *gogit.Repository, match func(string) bool) error {
+	modules, err := repo.Submodules()
+	if err != nil {
+		return err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	for _, m := range modules {
+		if !match(m.Path) {
+			continue
+		}
+		if _, ok := cfg.Get("submodule." + m.Name + ".url"); ok {
+			continue
+		}
+		if m.URL == "" {
+			return fmt.Errorf("no url found for submodule path '%s' in %s", m.Path, repo.ModulesFile())
+		}
+		url, err := submoduleURL(repo, m.URL)
+		if err != nil {
+			return err
+		}
+		err = repo.EditConfig(func(data []byte) ([]byte, error) {
+			return config.Add(data, "submodule."+m.Name+".url", url)
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Submodule '%s' (%s) registered for path '%s'\n", m.Name, url, m.Path)
+	}
+	return nil
+}
+
+// gitlinks: HEAD 의 tree 에 있는 submodule 항목 중 match 에 맞는 것
+func gitlinks(repo *gogit.Repository, match func(string) bool) ([]object.WalkEntry, error) {
+	tree, err := repo.ResolveTree("HEAD")
+	if errors.Is(err, gogit.ErrUnknownRevision) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	walker, err := object.NewTreeWalker(repo.Objects, tree)
+	if err != nil {
+		return nil, err
+	}
+	var result []object.WalkEntry
+	err = walker.ForEach(func(e object.WalkEntry) error {
+		if e.Mode == object.ModeGitlink && match(e.Path) {
+			result = append(result, e)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// submoduleUpdate: 등록한(init) submodule 을 받아 두고 HEAD 에 적힌 커밋으로 옮긴다. 등록하지 않은 것은 건너뛴다.
+func submoduleUpdate(ctx context.Context, opts gogit.Options, repo *gogit.Repository, match func(string) bool) error {
+	links, err := gitlinks(repo, match)
+	if err != nil {
+		return err
+	}
+	modules, err := repo.Submodules()
+	if err != nil {
+		return err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		m, ok := submodule.ByPath(modules, link.Path)
+		if !ok {
+			return fmt.Errorf("no submodule mapping found in %s for path '%s'", repo.ModulesFile(), link.Path)
+		}
+		url, ok := cfg.Get("submodule." + m.Name + ".url")
+		if !ok {
+			continue
+		}
+		sub, err := repo.OpenSubmodule(link.Path)
+		cloned := errors.Is(err, gogit.ErrNotARepository)
+		if cloned {
+			if err := clone(ctx, opts, url, filepath.Join(repo.WorkTree, filepath.FromSlash(link.Path)), false); err != nil {
+				return fmt.Errorf("clone of '%s' into submodule path '%s' failed: %w", url, link.Path, err)
+			}
+			sub, err = repo.OpenSubmodule(link.Path)
+		}
+		if err != nil {
+			return err
+		}
+		// 방금 clone 한 것은 브랜치에 있으므로 같은 커밋이어도 git 과 같이 detach 한다
+		if head, err := sub.Refs.Resolve("HEAD"); err == nil && head == link.Hash && !cloned {
+			continue
+		}
+		if !sub.Objects.Has(link.Hash) {
+			if err := cmdFetch(ctx, sub, []string{"origin"}); err != nil {
+				return err
+			}
+			if !sub.Objects.Has(link.Hash) {
+				return fmt.Errorf("fetched in submodule path '%s', but it did not contain %s", link.Path, link.Hash)
+			}
+		}
+		if err := submoduleCheckout(ctx, sub, link.Hash); err != nil {
+			return fmt.Errorf("unable to checkout '%s' in submodule path '%s': %w", link.Hash, link.Path, err)
+		}
+		fmt.Printf("Submodule path '%s': checked out '%s'\n", link.Path, link.Hash)
+	}
+	return nil
+}
+
+// submoduleCheckout: submodule 의 작업 트리를 commit 으로 바꾸고 HEAD 를 commit 에 detach 한다.
+// 커밋하지 않은 변경이 있으면 건드리지 않는다.
+func submoduleCheckout(ctx context.Context, sub *gogit.Repository, commit string) error {
+	current, err := sub.ResolveTree("HEAD")
+	if err != nil && !errors.Is(err, gogit.ErrUnknownRevision) {
+		return err
+	}
+	if current != "" {
+		if err := requireCleanWorkTree(ctx, sub, current, "checkout"); err != nil {
+			return err
+		}
+	}
+	target, err := sub.ResolveTree(commit)
+	if err != nil {
+		return err
+	}
+	if err := worktree.Checkout(ctx, vfs.NewOS(sub.WorkTree), sub.Objects, current, target); err != nil {
+		return err
+	}
+	return sub.UpdateRef("HEAD", commit, "submodule update: checkout "+commit)
+}
+
+func submoduleStatus(ctx context.Context, repo *gogit.Repository, match func(string) bool) error {
+	links, err := gitlinks(repo, match)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, link := range links {
+		sub, err := repo.OpenSubmodule(link.Path)
+		if errors.Is(err, gogit.ErrNotARepository) {
+			fmt.Fprintf(&b, "-%s %s\n", link.Hash, link.Path)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		head, err := sub.Refs.Resolve("HEAD")
+		if err != nil {
+			fmt.Fprintf(&b, "-%s %s\n", link.Hash, link.Path)
+			continue
+		}
+		flag := ' '
+		if head != link.Hash {
+			flag = '+'
+		}
+		fmt.Fprintf(&b, "%c%s %s%s\n", flag, head, link.Path, submoduleDescribe(ctx, sub, head))
+	}
+	_, err = io.WriteString(os.Stdout, b.String())
+	return err
+}
+
+// submoduleDescribe: status 에 붙일 " (이름)". 태그로 describe 하고, 안 되면 커밋을 가리키는 브랜치 이름을 쓴다
+func submoduleDescribe(ctx context.Context, sub *gogit.Repository, commit string) string {
+	if name, err := sub.Describe(ctx, commit, true); err == nil {
+		return " (" + name + ")"
+	}
+	list, err := sub.Refs.List()
+	if err != nil {
+		return ""
+	}
+	for _, prefix := range []string{"refs/heads/", "refs/remotes/"} {
+		for _, ref := range list {
+			if strings.HasPrefix(ref.Name, prefix) && ref.Hash == commit {
+				return " (" + strings.TrimPrefix(ref.Name, "refs/") + ")"
+			}
+		}
+	}
+	return ""
+}
+
+// Notes: 커밋을 다시 쓰지 않고 메모(CI 결과 등)를 붙인다. 메모는 refs/notes/commits 의 tree 에 객체 해시를 이름으로 둔다.
+// git notes 와 같은 형식이라 git 과 함께 쓸 수 있다.
+//
+//	notes [list] [<object>]                               메모마다 "<메모 blob> <객체>". 객체를 주면 그 메모의 blob
+//	notes add [-f] [-m <msg>]... [-F <file>] [<object>]   메모를 붙인다. 이미 있으면 -f 로 덮어쓴다
+//	notes show [<object>]                                 메모 내용
+//	notes remove [<object>...]                            메모를 지운다
+//
+// <object> 의 기본값은 HEAD 다. --ref <ref> 로 다른 메모 ref 를 쓸 수 있고, 없으면 config 의 core.notesRef 를 따른다.
+// 메모를 바꿀 때마다 notes ref 에 커밋이 하나씩 쌓이므로 메모의 이력도 남는다.
+func cmdNotes(repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit notes [--ref <notes-ref>] [list [<object>] | add [-f] [-m <msg>]... [-F <file>] [<object>] | show [<object>] | remove [<object>...]]"
+	var refName string
+	for len(args) > 0 && strings.HasPrefix(args[0], "--ref") {
+		if v, ok := strings.CutPrefix(args[0], "--ref="); ok {
+			refName, args = v, args[1:]
+		} else if args[0] == "--ref" && len(args) > 1 {
+			refName, args = args[1], args[2:]
+		} else {
+			return errors.New(usage)
+		}
+	}
+	ref, err := notesRef(repo, refName)
+	if err != nil {
+		return err
+	}
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+
+	objects := func(max int) ([]string, error) {
+		if len(args) > max {
+			return nil, errors.New(usage)
+		}
+		names := args
+		if len(names) == 0 {
+			names = []string{"HEAD"}
+		}
+		var hashes []string
+		for _, name := range names {
+			hash, err := repo.ResolveRevision(name)
+			if err != nil {
+				return nil, err
+			}
+			hashes = append(hashes, hash)
+		}
+		return hashes, nil
+	}
+
+	switch sub {
+	case "list":
+		if len(args) == 0 {
+			return notesList(repo, ref)
+		}
+		hashes, err := objects(1)
+		if err != nil {
+			return err
+		}
+		note, err := findNote(repo, ref, hashes[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(note.Blob)
+		return nil
+	case "show":
+		hashes, err := objects(1)
+		if err != nil {
+			return err
+		}
+		note, err := findNote(repo, ref, hashes[0])
+		if err != nil {
+			return err
+		}
+		_, data, err := repo.Objects.Read(note.Blob)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	case "add":
+		return notesAdd(repo, ref, args, usage)
+	case "remove":
+		hashes, err := objects(len(args))
+		if err != nil {
+			return err
+		}
+		for _, hash := range hashes {
+			fmt.Fprintf(os.Stderr, "Removing note for object %s\n", hash)
+			if err := setNote(repo, ref, hash, "", "Notes removed by 'gogit notes remove'"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return errors.New(usage)
+}
+
+// notesRef: --ref 로 준 이름, 없으면 core.notesRef, 그것도 없으면 refs/notes/commits
+func notesRef(repo *gogit.Repository, name string) (string, error) {
+	if name == "" {
+		cfg, err := repo.Config()
+		if err != nil {
+			return "", err
+		}
+		name, _ = cfg.Get("core.notesRef")
+	}
+	if name == "" {
+		return notes.DefaultRef, nil
+	}
+	return notes.ExpandRef(name), nil
+}
+
+// notesTree: notes ref 가 가리키는 커밋과 그 tree. ref 가 아직 없으면 둘 다 빈 문자열
+func notesTree(repo *gogit.Repository, ref string) (commit string, tree string, err error) {
+	commit, err = repo.Refs.Resolve(ref)
+	if errors.Is(err, refs.ErrNotFound) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	c, err := object.ReadCommit(repo.Objects, commit)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", ref, err)
+	}
+	return commit, c.Tree, nil
+}
+
+// findNote: hash 에 붙은 메모. 없으면 git 과 같은 에러
+func findNote(repo *gogit.Repository, ref string, hash string) (notes.Note, error) {
+	_, tree, err := notesTree(repo, ref)
+	if err != nil {
+		return notes.Note{}, err
+	}
+	note, ok, err := notes.Find(repo.Objects, tree, hash)
+	if err != nil {
+		return notes.Note{}, err
+	}
+	if !ok {
+		return notes.Note{}, fmt.Errorf("no note found for object %s.", hash)
+	}
+	return note, nil
+}
+
+func notesList(repo *gogit.Repository, ref string) error {
+	_, tree, err := notesTree(repo, ref)
+	if err != nil {
+		return err
+	}
+	list, err := notes.List(repo.Objects, tree)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	for _, note := range list {
+		fmt.Fprintf(w, "%s %s\n", note.Blob, note.Object)
+	}
+	return w.Flush()
+}
+
+// notesEditMsg: 편집기로 메모를 쓸 때 쓰는 파일 (git 과 같은 이름)
+const notesEditMsg = "NOTES_EDITMSG"
+
+// notesAdd: -m 은 문단마다 빈 줄을 두고 잇는다. -m 도 -F 도 없으면 편집기를 연다.
+// 메시지가 비면 git 과 같이 메모를 붙이지 않고, 있던 메모는 지운다.
+func notesAdd(repo *gogit.Repository, ref string, args []string, usage string) error {
+	force := false
+	var messages []string
+	var target string
+	given := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-f" || arg == "--force":
+			force = true
+		case (arg == "-m" || arg == "-F") && i+1 < len(args):
+			i++
+			given = true
+			if arg == "-m" {
+				messages = append(messages, args[i])
+				continue
+			}
+			var data []byte
+			var err error
+			if args[i] == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(args[i])
+			}
+			if err != nil {
+				return err
+			}
+			messages = append(messages, string(data))
+		case strings.HasPrefix(arg, "-m") && len(arg) > 2:
+			given = true
+			messages = append(messages, arg[2:])
+		case strings.HasPrefix(arg, "-") || target != "":
+			return errors.New(usage)
+		default:
+			target = arg
+		}
+	}
+	hash, err := repo.ResolveRevision(cmp.Or(target, "HEAD"))
+	if err != nil {
+		return err
+	}
+	_, tree, err := notesTree(repo, ref)
+	if err != nil {
+		return err
+	}
+	_, exists, err := notes.Find(repo.Objects, tree, hash)
+	if err != nil {
+		return err
+	}
+	if exists && !force {
+		return fmt.Errorf("Cannot add notes. Found existing notes for object %s. Use '-f' to overwrite existing notes", hash)
+	}
+
+	var message string
+	if given {
+		for _, m := range messages {
+			message += cleanupMessage(m, false) + "\n"
+		}
+		message = cleanupMessage(message, false)
+	} else {
+		help := "\n#\n# Write/edit the notes for the following object:\n# " + hash + "\n"
+		if err := vfs.WriteFile(repo.FS, notesEditMsg, []byte(help)); err != nil {
+			return err
+		}
+		if err := launchEditor(repo, notesEditMsg); err != nil {
+			return err
+		}
+		data, err := vfs.ReadFile(repo.FS, notesEditMsg)
+		if err != nil {
+			return err
+		}
+		message = cleanupMessage(string(data), true)
+	}
+
+	if message == "" {
+		if !exists {
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "Removing note for object %s\n", hash)
+		return setNote(repo, ref, hash, "", "Notes removed by 'gogit notes add'")
+	}
+	if exists {
+		fmt.Fprintf(os.Stderr, "Overwriting existing notes for object %s\n", hash)
+	}
+	blob, err := repo.Objects.Write(object.TypeBlob, []byte(message))
+	if err != nil {
+		return err
+	}
+	return setNote(repo, ref, hash, blob, "Notes added by 'gogit notes add'")
+}
+
+// setNote: notes tree 에서 hash 의 메모를 blob 으로 바꾼(blob 이 빈 문자열이면 지운) 커밋을 notes ref 에 쌓는다.
+func setNote(repo *gogit.Repository, ref string, hash string, blob string, message string) error {
+	parent, tree, err := notesTree(repo, ref)
+	if err != nil {
+		return err
+	}
+	if tree, err = notes.Set(repo.Objects, tree, hash, blob); err != nil {
+		return err
+	}
+	var parents []string
+	if parent != "" {
+		parents = []string{parent}
+	}
+	committer, err := repo.Committer()
+	if err != nil {
+		return err
+	}
+	commit, err := createCommit(repo, parents, tree, committer, message+"\n")
+	if err != nil {
+		return err
+	}
+	return repo.UpdateRef(ref, commit, "notes: "+message)
+}
+
+// setNotes: show 와 log 가 커밋 아래에 메모를 보여 주게 한다. ref 가 빈 문자열이면 core.notesRef 나 기본 ref
+// 메모 ref 가 없으면 아무것도 하지 않는다.
+func setNotes(repo *gogit.Repository, pw *pretty.Writer, ref string) error {
+	ref, err := notesRef(repo, ref)
+	if err != nil {
+		return err
+	}
+	_, tree, err := notesTree(repo, ref)
+	if err != nil || tree == "" {
+		return err
+	}
+	list, err := notes.List(repo.Objects, tree)
+	if err != nil {
+		return err
+	}
+	blobs := make(map[string]string, len(list))
+	for _, note := range list {
+		blobs[note.Object] = note.Blob
+	}
+	pw.SetNotes(func(hash string) string {
+		blob, ok := blobs[hash]
+		if !ok {
+			return ""
+		}
+		_, data, err := repo.Objects.Read(blob)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	})
+	return nil
+}
+
+// Tag: tag 를 만들고, 나열하고, 지운다.
+//
+//	tag [-l [<pattern>]]                                          tag 이름을 나열한다 (pattern 은 glob)
+//	tag [-a | -s | -u <key>] [-f] [-m <msg>]... [-F <file>] <name> [<commit>]
+//	tag -d <name>...
+//
+// -a, -s, -u, -m, -F 중 하나가 있으면 annotated tag 를 만들고, 없으면 commit(기본 HEAD)을 바로 가리키는 lightweight tag 를 만든다.
+// 메시지를 주지 않은 annotated tag 는 TAG_EDITMSG 를 편집기로 연다.
+// -s 는 tag 에 서명하고 -u 는 그 키로 서명한다. tag.gpgSign 이 true 면 annotated tag 는 늘 서명한다. (signing 패키지 참고)
+// 이미 있는 tag 는 -f 가 있어야 바꾼다.
+func cmdTag(repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit tag [-a | -s | -u <key>] [-f] [-m <msg>]... [-F <file>] <name> [<commit>] | -l [<pattern>] | -d <name>..."
+	var list, del, annotate, sign, force, given bool
+	var key string
+	var messages []string
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-l" || arg == "--list":
+			list = true
+		case arg == "-d" || arg == "--delete":
+			del = true
+		case arg == "-a" || arg == "--annotate":
+			annotate = true
+		case arg == "-s" || arg == "--sign":
+			annotate, sign = true, true
+		case (arg == "-u" || arg == "--local-user") && i+1 < len(args):
+			i++
+			annotate, sign, key = true, true, args[i]
+		case strings.HasPrefix(arg, "--local-user="):
+			annotate, sign, key = true, true, strings.TrimPrefix(arg, "--local-user=")
+		case arg == "-f" || arg == "--force":
+			force = true
+		case (arg == "-m" || arg == "--message") && i+1 < len(args):
+			i++
+			annotate, given = true, true
+			messages = append(messages, args[i])
+		case (arg == "-F" || arg == "--file") && i+1 < len(args):
+			i++
+			var data []byte
+			var err error
+			if args[i] == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(args[i])
+			}
+			if err != nil {
+				return err
+			}
+			annotate, given = true, true
+			messages = append(messages, string(data))
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	switch {
+	case del:
+		if list || annotate || len(rest) == 0 {
+			return errors.New(usage)
+		}
+		for _, name := range rest {
+			hash, err := repo.Refs.Resolve("refs/tags/" + name)
+			if err != nil {
+				return fmt.Errorf("tag '%s' not found.", name)
+			}
+			if err := refs.Delete(repo.Refs, "refs/tags/"+name); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted tag '%s' (was %s)\n", name, shortHash(hash))
+		}
+		return nil
+	case list || len(rest) == 0:
+		if annotate || len(rest) > 1 {
+			return errors.New(usage)
+		}
+		return tagList(repo, rest)
+	}
+	if len(rest) > 2 {
+		return errors.New(usage)
+	}
+
+	name := rest[0]
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " ~^:?*[\\") || strings.Contains(name, "..") {
+		return fmt.Errorf("'%s' is not a valid tag name.", name)
+	}
+	ref := "refs/tags/" + name
+	old, err := repo.Refs.Resolve(ref)
+	exists := err == nil
+	if exists && !force {
+		return fmt.Errorf("tag '%s' already exists", name)
+	}
+	rev := "HEAD"
+	if len(rest) == 2 {
+		rev = rest[1]
+	}
+	target, err := repo.ResolveRevision(rev)
+	if err != nil {
+		return err
+	}
+
+	if !sign && annotate {
+		cfg, err := repo.Config()
+		if err != nil {
+			return err
+		}
+		if sign, err = cfg.GetBool("tag.gpgSign", false); err != nil {
+			return err
+		}
+	}
+	hash := target
+	if annotate {
+		if hash, err = createTag(repo, name, target, messages, given, sign, key); err != nil {
+			return err
+		}
+	}
+	if err := repo.Refs.Update(ref, hash); err != nil {
+		return err
+	}
+	if exists && old != hash {
+		fmt.Printf("Updated tag '%s' (was %s)\n", name, shortHash(old))
+	}
+	return nil
+}
+
+// tagEditMsg: 편집기로 tag 메시지를 쓸 때 쓰는 파일 (git 과 같은 이름)
+const tagEditMsg = "TAG_EDITMSG"
+
+// createTag: target 을 가리키는 annotated tag 객체를 저장한다. given 이 아니면 편집기로 메시지를 받는다.
+func createTag(repo *gogit.Repository, name, target string, messages []string, given, sign bool, key string) (string, error) {
+	typ, _, err := repo.Objects.Read(target)
+	if err != nil {
+		return "", err
+	}
+	tagger, err := repo.Committer()
+	if err != nil {
+		return "", err
+	}
+
+	var message string
+	if given {
+		for _, m := range messages {
+			message += cleanupMessage(m, false) + "\n"
+		}
+		message = cleanupMessage(message, false)
+	} else {
+		help := "\n#\n# Write a message for tag:\n#   " + name + "\n# Lines starting with '#' will be ignored.\n"
+		if err := vfs.WriteFile(repo.FS, tagEditMsg, []byte(help)); err != nil {
+			return "", err
+		}
+		if err := launchEditor(repo, tagEditMsg); err != nil {
+			return "", err
+		}
+		data, err := vfs.ReadFile(repo.FS, tagEditMsg)
+		if err != nil {
+			return "", err
+		}
+		if message = cleanupMessage(string(data), true); message == "" {
+			return "", errors.New("no tag message?")
+		}
+	}
+
+	t := &object.Tag{Object: target, ObjectType: typ, Name: name, Tagger: tagger, Message: message}
+	if sign {
+		signer, err := signerFor(repo, key)
+		if err != nil {
+			return "", err
+		}
+		if err := signer.SignTag(t); err != nil {
+			return "", err
+		}
+	}
+	return object.WriteObject(repo.Objects, t)
+}
+
+// tagList: tag 이름을 정렬해서 출력한다. patterns 가 있으면 glob 에 맞는 것만
+func tagList(repo *gogit.Repository, patterns []string) error {
+	all, err := repo.Refs.List()
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, r := range all {
+		name, ok := strings.CutPrefix(r.Name, "refs/tags/")
+		if !ok {
+			continue
+		}
+		matched := len(patterns) == 0
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, name); ok {
+				matched = true
+			}
+		}
+		if matched {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+// Verify-Commit: 커밋의 서명을 확인한다. gpg 나 ssh-keygen 의 출력을 stderr 로 보여 준다.
+//
+//	verify-commit [-v] <commit>...
+//
+// 서명이 없거나 맞지 않는 커밋이 하나라도 있으면 실패한다. -v 는 커밋 내용도 출력한다.
+func cmdVerifyCommit(repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit verify-commit [-v] <commit>..."
+	return verifySignatures(repo, args, usage, func(name string) ([]byte, []byte, string, error) {
+		hash, err := repo.ResolveCommit(name)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		c, err := object.ReadCommit(repo.Objects, hash)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		payload, signature, err := signing.CommitSignature(c)
+		return c.Encode(), payload, signature, err
+	})
+}
+
+// Verify-Tag: annotated tag 의 서명을 확인한다. gpg 나 ssh-keygen 의 출력을 stderr 로 보여 준다.
+//
+//	verify-tag [-v] <tag>...
+//
+// 서명이 없거나 맞지 않는 tag 가 하나라도 있으면 실패한다. -v 는 tag 내용도 출력한다.
+func cmdVerifyTag(repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit verify-tag [-v] <tag>..."
+	return verifySignatures(repo, args, usage, func(name string) ([]byte, []byte, string, error) {
+		hash, err := repo.ResolveRevision(name)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		typ, data, err := repo.Objects.Read(hash)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		if typ != object.TypeTag {
+			return nil, nil, "", fmt.Errorf("%s: cannot verify a non-tag object of type %s.", name, typ)
+		}
+		var t object.Tag
+		if err := t.Decode(data); err != nil {
+			return nil, nil, "", err
+		}
+		payload, signature, err := signing.TagSignature(&t)
+		return data, payload, signature, err
+	})
+}
+
+// verifySignatures: verify-commit 과 verify-tag 의 공통 부분. read 는 이름에서 객체 내용, 서명한 내용, 서명을 꺼낸다.
+func verifySignatures(repo *gogit.Repository, args []string, usage string, read func(name string) (content, payload []byte, signature string, err error)) error {
+	verbose := false
+	var names []string
+	for _, arg := range args {
+		switch {
+		case arg == "-v" || arg == "--verbose":
+			verbose = true
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			names = append(names, arg)
+		}
+	}
+	if len(names) == 0 {
+		return errors.New(usage)
+	}
+	signer, err := signerFor(repo, "")
+	if err != nil {
+		return err
+	}
+	failed := false
+	for _, name := range names {
+		content, payload, signature, err := read(name)
+		if errors.Is(err, signing.ErrNoSignature) {
+			fmt.Fprintf(os.Stderr, "%s: no signature found\n", name)
+			failed = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if verbose {
+			os.Stdout.Write(content)
+		}
+		result, err := signer.Verify(payload, signature)
+		if err != nil {
+			return err
+		}
+		os.Stderr.WriteString(result.Output)
+		failed = failed || !result.Good
+	}
+	if failed {
+		return errBadSignature
+	}
+	return nil
+}
+
+// Stack: 서로 위에 쌓인 브랜치들의 관계를 기록하고, 아래 브랜치가 움직이면 위의 브랜치들을 다시 올린다.
+//
+//	stack [list]                      기록된 스택을 트리로 보여 준다
+//	stack track <parent> [<branch>]   branch(기본값은 현재 브랜치)가 parent 위에 쌓여 있다고 기록한다
+//	stack untrack [<branch>]          기록을 지운다. 그 위의 브랜치들은 branch 의 부모 위로 옮겨 기록한다
+//	stack restack                     부모가 움직인 브랜치를 아래부터 차례로 rebase --onto 로 옮긴다
+//
+// restack 중에 충돌이 나면 멈춘다. rebase --continue 로 마무리한 뒤 restack 을 다시 실행하면 나머지를 이어서 옮긴다.
+// 끝나면 시작할 때의 브랜치로 돌아온다.
+func cmdStack(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit stack [list] | track <parent> [<branch>] | untrack [<branch>] | restack"
+	branches, err := stack.Load(repo.FS)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 || args[0] == "list" {
+		if len(args) > 1 {
+			return errors.New(usage)
+		}
+		return printStack(repo, branches)
+	}
+
+	switch {
+	case args[0] == "track" && (len(args) == 2 || len(args) == 3):
+		name, err := stackBranchArg(repo, args[2:])
+		if err != nil {
+			return err
+		}
+		parent := strings.TrimPrefix(args[1], "refs/heads/")
+		tip, err := repo.Refs.Resolve("refs/heads/" + name)
+		if err != nil {
+			return err
+		}
+		parentTip, err := repo.Refs.Resolve("refs/heads/" + parent)
+		if err != nil {
+			return err
+		}
+		base, err := stack.ForkPoint(ctx, repo.Objects, tip, parentTip)
+		if err != nil {
+			return err
+		}
+		if branches, err = stack.Set(branches, stack.Branch{Name: name, Parent: parent, Base: base}); err != nil {
+			return err
+		}
+		if err := stack.Save(repo.FS, branches); err != nil {
+			return err
+		}
+		fmt.Printf("Branch '%s' is stacked on '%s'.\n", name, parent)
+		return nil
+	case args[0] == "untrack" && len(args) <= 2:
+		name, err := stackBranchArg(repo, args[1:])
+		if err != nil {
+			return err
+		}
+		branches, ok := stack.Remove(branches, name)
+		if !ok {
+			return fmt.Errorf("branch '%s' is not in a stack", name)
+		}
+		if err := stack.Save(repo.FS, branches); err != nil {
+			return err
+		}
+		fmt.Printf("Branch '%s' is no longer stacked.\n", name)
+		return nil
+	case args[0] == "restack" && len(args) == 1:
+		return stackRestack(ctx, repo, branches)
+	}
+	return errors.New(usage)
+}
+
+// stackBranchArg: 인자로 준 브랜치 이름, 없으면 현재 브랜치
+func stackBranchArg(repo *gogit.Repository, args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.TrimPrefix(args[0], "refs/heads/"), nil
+	}
+	ref, err := repo.Refs.Read("HEAD")
+	if err != nil {
+		return "", err
+	}
+	if ref.Target == "" {
+		return "", errors.New("HEAD is detached; name a branch")
+	}
+	return strings.TrimPrefix(ref.Target, "refs/heads/"), nil
+}
+
+// printStack: 스택을 아래 브랜치부터 들여 써서 출력한다. 부모가 움직여 restack 이 필요한 브랜치는 표시한다.
+func printStack(repo *gogit.Repository, branches []stack.Branch) error {
+	var b strings.Builder
+	var walk func(parent string, depth int) error
+	walk = func(parent string, depth int) error {
+		parentTip, _ := repo.Refs.Resolve("refs/heads/" + parent)
+		for _, br := range stack.Children(branches, parent) {
+			fmt.Fprintf(&b, "%s%s", strings.Repeat("  ", depth), br.Name)
+			_, err := repo.Refs.Resolve("refs/heads/" + br.Name)
+			switch {
+			case errors.Is(err, refs.ErrNotFound):
+				b.WriteString(" (missing)")
+			case err != nil:
+				return err
+			case parentTip != br.Base:
+				b.WriteString(" (needs restack)")
+			}
+			b.WriteString("\n")
+			if err := walk(br.Name, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, root := range stack.Roots(branches) {
+		b.WriteString(root + "\n")
+		if err := walk(root, 1); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(os.Stdout, b.String())
+	return err
+}
+
+// stackRestack: 부모가 움직인 브랜치를 부모가 먼저 오는 순서로 옮긴다. 브랜치마다 옮긴 뒤 바로 기록하므로
+// 중간에 멈춰도 이미 옮긴 브랜치는 다시 옮기지 않는다.
+func stackRestack(ctx context.Context, repo *gogit.Repository, branches []stack.Branch) error {
+	if err := repo.RequireWorkTree("stack restack"); err != nil {
+		return err
+	}
+	if vfs.Exists(repo.FS, rebaseDir) {
+		return errors.New("a rebase is in progress; finish it with \"gogit rebase --continue\" first")
+	}
+	start, err := repo.Refs.Read("HEAD")
+	if err != nil {
+		return err
+	}
+	startCommit, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	startTree, err := repo.ResolveTree(startCommit)
+	if err != nil {
+		return err
+	}
+	current, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if current != startTree {
+		return errors.New("your local changes would be overwritten by restack; commit them first")
+	}
+
+	moved := false
+	for _, br := range stack.Order(branches) {
+		tip, err := repo.Refs.Resolve("refs/heads/" + br.Name)
+		if errors.Is(err, refs.ErrNotFound) {
+			fmt.Fprintf(os.Stderr, "warning: skipping '%s': branch not found\n", br.Name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		parentTip, err := repo.Refs.Resolve("refs/heads/" + br.Parent)
+		if errors.Is(err, refs.ErrNotFound) {
+			fmt.Fprintf(os.Stderr, "warning: skipping '%s': parent branch '%s' not found\n", br.Name, br.Parent)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if parentTip == br.Base {
+			continue
+		}
+		// 이미 부모의 끝 위에 있으면 (충돌을 해결하고 rebase --continue 로 마친 경우 등) 기록만 고친다
+		ancestors, err := object.Reachable(ctx, repo.Objects, []string{tip})
+		if err != nil {
+			return err
+		}
+		if !ancestors[parentTip] {
+			fmt.Printf("Restacking %s onto %s\n", br.Name, br.Parent)
+			if err := cmdRebase(ctx, repo, []string{"--no-autostash", "--onto", parentTip, br.Base, br.Name}); err != nil {
+				fmt.Fprintln(os.Stderr, "hint: after the rebase is finished, run \"gogit stack restack\" again")
+				return err
+			}
+			moved = true
+		}
+		br.Base = parentTip
+		if branches, err = stack.Set(branches, br); err != nil {
+			return err
+		}
+		if err := stack.Save(repo.FS, branches); err != nil {
+			return err
+		}
+	}
+	if !moved {
+		fmt.Println("All stacked branches are up to date.")
+		return nil
+	}
+
+	// 시작할 때의 브랜치로 돌아온다
+	head, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	headTree, err := repo.ResolveTree(head)
+	if err != nil {
+		return err
+	}
+	target := startCommit
+	if start.Target != "" {
+		if target, err = repo.Refs.Resolve(start.Target); err != nil {
+			return err
+		}
+	}
+	targetTree, err := repo.ResolveTree(target)
+	if err != nil {
+		return err
+	}
+	if err := worktree.Checkout(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, headTree, targetTree); err != nil {
+		return err
+	}
+	if start.Target != "" {
+		return repo.SwitchHead(start.Target, "stack restack: returning to "+start.Target)
+	}
+	return repo.UpdateRef("HEAD", target, "stack restack: returning to "+target)
+}
+
+// Diff: 두 상태 사이의 변경을 patch 로 출력한다.
+//
+//	diff [-U<n>] [--] [<path>...]                 HEAD 와 작업 트리
+//	diff [-U<n>] <rev> [--] [<path>...]           rev 와 작업 트리
+//	diff [-U<n>] <rev> <rev> [--] [<path>...]     두 리비전 (<rev>..<rev> 도 된다)
+//	diff [-U<n>] --no-index <path> <path>         저장소와 상관없는 두 파일이나 디렉토리
+//
+// --name-only 는 경로만, --name-status 는 상태 글자(A/D/M/R/T)와 경로를, --stat 과 --summary 는
+// 줄 수 그래프와 생성/삭제/모드 변경 요약을 patch 대신 출력한다.
+// index 가 없으므로 작업 트리와 비교할 때는 HEAD 와 비교한다. path 는 현재 디렉토리 기준이고 그 아래의 변경만 보여 준다.
+// --no-index 는 저장소 밖에서도 동작하고, git 과 같이 차이가 있으면 종료 코드 1 로 끝난다.
+func cmdDiff(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit diff [-U<n>] [--name-only | --name-status | --stat | --summary] [<rev> [<rev>]] [--] [<path>...] | --no-index <path> <path>"
+	noIndex := slices.Contains(args, "--no-index")
+	var format string
+	opts, err := diffOptions(repo)
+	if err != nil {
+		return err
+	}
+	var operands, paths []string
+	dashdash := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			paths = append(paths, args[i+1:]...)
+			dashdash = true
+			i = len(args)
+		case arg == "--no-index":
+		case arg == "--name-only" || arg == "--name-status" || arg == "--stat" || arg == "--summary":
+			format = arg
+		case strings.HasPrefix(arg, "-U") || strings.HasPrefix(arg, "--unified="):
+			n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(arg, "-U"), "--unified="))
+			if err != nil || n < 0 {
+				return errors.New(usage)
+			}
+			opts.Context = n
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			return errors.New(usage)
+		default:
+			operands = append(operands, arg)
+		}
+	}
+	if noIndex {
+		operands = append(operands, paths...)
+		if len(operands) != 2 {
+			return errors.New(usage)
+		}
+		return diffNoIndex(ctx, operands[0], operands[1], opts)
+	}
+
+	// "--" 가 없으면 리비전으로 해석되는 앞쪽 인자까지를 리비전으로 본다
+	// 나머지는 경로여야 한다. 리비전도 경로도 아니면 오타일 수 있으니 빈 diff 대신 에러를 낸다. (git 과 동일)
+	var revs []string
+	for i, arg := range operands {
+		if len(revs) == 2 || !isRevision(repo, arg) {
+			if !dashdash {
+				for _, p := range operands[i:] {
+					if err := checkPathOperand(p); err != nil {
+						return err
+					}
+				}
+			}
+			paths = append(operands[i:], paths...)
+			break
+		}
+		if from, to, ok := strings.Cut(arg, ".."); ok && len(revs) == 0 {
+			revs = append(revs, cmp.Or(from, "HEAD"), cmp.Or(to, "HEAD"))
+			continue
+		}
+		revs = append(revs, arg)
+	}
+
+	var from, to string
+	switch len(revs) {
+	case 0, 1:
+		if err := repo.RequireWorkTree("diff"); err != nil {
+			return err
+		}
+		rev := "HEAD"
+		if len(revs) == 1 {
+			rev = revs[0]
+		}
+		if from, err = repo.ResolveTree(rev); err != nil {
+			return err
+		}
+		if to, err = snapshotWorkTree(ctx, repo); err != nil {
+			return err
+		}
+	default:
+		if from, err = repo.ResolveTree(revs[0]); err != nil {
+			return err
+		}
+		if to, err = repo.ResolveTree(revs[1]); err != nil {
+			return err
+		}
+	}
+
+	changes, err := diff.Trees(repo.Objects, from, to)
+	if err != nil {
+		return err
+	}
+	if len(paths) > 0 {
+		specs := make([]string, len(paths))
+		for i, p := range paths {
+			if specs[i], err = repoPath(repo, p); err != nil {
+				return err
+			}
+		}
+		match := pathspecMatcher(specs)
+		changes = slices.DeleteFunc(changes, func(c diff.Change) bool {
+			return !(c.From.Hash != "" && match(c.From.Path) || c.To.Hash != "" && match(c.To.Path))
+		})
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	switch format {
+	case "--name-only":
+		for _, c := range changes {
+			fmt.Fprintln(w, diff.QuotePath(c.Path()))
+		}
+	case "--name-status":
+		for _, c := range changes {
+			switch st := c.Status(); st {
+			case 'R':
+				fmt.Fprintf(w, "R100\t%s\t%s\n", diff.QuotePath(c.From.Path), diff.QuotePath(c.To.Path))
+			default:
+				fmt.Fprintf(w, "%c\t%s\n", st, diff.QuotePath(c.Path()))
+			}
+		}
+	case "--stat":
+		if len(changes) == 0 {
+			break
+		}
+		stats, err := diff.Stats(repo.Objects, changes)
+		if err != nil {
+			return err
+		}
+		if err := diff.WriteStat(w, stats, 80); err != nil {
+			return err
+		}
+	case "--summary":
+		if err := diff.WriteSummary(w, changes); err != nil {
+			return err
+		}
+	default:
+		if err := diff.WritePatch(w, repo.Objects, changes, opts); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// isRevision: arg 가 리비전(또는 "A..B")으로 해석되는지
+func isRevision(repo *gogit.Repository, arg string) bool {
+	if from, to, ok := strings.Cut(arg, ".."); ok {
+		return isRevision(repo, cmp.Or(from, "HEAD")) && isRevision(repo, cmp.Or(to, "HEAD"))
+	}
+	_, err := repo.ResolveRevision(arg)
+	return err == nil
+}
+
+// checkPathOperand: "--" 없이 받은 경로 인자가 작업 트리에 있는지. 와일드카드가 든 pathspec 은 그대로 받는다.
+func checkPathOperand(arg string) error {
+	if strings.ContainsAny(arg, "*?[") {
+		return nil
+	}
+	if _, err := os.Lstat(arg); err != nil {
+		return fmt.Errorf("ambiguous argument '%s': %w or path not in the working tree (use '--' to separate paths from revisions)", arg, gogit.ErrUnknownRevision)
+	}
+	return nil
+}
+
+// errDiffFound: diff --no-index 가 차이를 찾음. 차이는 이미 출력했다.
+var errDiffFound = errors.New("files differ")
+
+// diffNoIndex: 저장소 밖의 두 파일이나 디렉토리를 메모리 저장소에 담아 비교한다.
+// 출력의 경로는 인자로 받은 경로 그대로다. (git 과 같이 앞의 "/" 는 뺀다)
+func diffNoIndex(ctx context.Context, a, b string, opts *diff.Options) error {
+	store := object.NewMemoryStore()
+	fromInfo, err := os.Stat(a)
+	if err != nil {
+		return err
+	}
+	toInfo, err := os.Stat(b)
+	if err != nil {
+		return err
+	}
+	label := func(p string) string {
+		return strings.TrimPrefix(filepath.ToSlash(filepath.Clean(p)), "/")
+	}
+
+	var changes []diff.Change
+	switch {
+	case fromInfo.IsDir() && toInfo.IsDir():
+		fromTree, err := worktree.WriteTree(ctx, vfs.NewOS(a), store, nil)
+		if err != nil {
+			return err
+		}
+		toTree, err := worktree.WriteTree(ctx, vfs.NewOS(b), store, nil)
+		if err != nil {
+			return err
+		}
+		if changes, err = diff.Trees(store, fromTree, toTree); err != nil {
+			return err
+		}
+		for i, c := range changes {
+			// 한쪽에만 있는 파일은 git 과 같이 있는 쪽의 경로로 보여 준다
+			fromPath, toPath := path.Join(label(a), c.From.Path), path.Join(label(b), c.To.Path)
+			if c.From.Hash == "" {
+				fromPath = toPath
+			}
+			if c.To.Hash == "" {
+				toPath = fromPath
+			}
+			changes[i].From.Path, changes[i].To.Path = fromPath, toPath
+		}
+	case fromInfo.IsDir() || toInfo.IsDir():
+		return fmt.Errorf("cannot compare a file with a directory: %s, %s", a, b)
+	default:
+		from, err := writeNoIndexFile(store, a, fromInfo)
+		if err != nil {
+			return err
+		}
+		to, err := writeNoIndexFile(store, b, toInfo)
+		if err != nil {
+			return err
+		}
+		from.Path, to.Path = label(a), label(b)
+		if from.Hash != to.Hash || from.Mode != to.Mode {
+			changes = []diff.Change{{From: from, To: to}}
+		}
+	}
+
+	opts.Paired = true
+	if err := diff.WritePatch(os.Stdout, store, changes, opts); err != nil {
+		return err
+	}
+	if len(changes) > 0 {
+		return errDiffFound
+	}
+	return nil
+}
+
+func writeNoIndexFile(s object.Storer, name string, info fs.FileInfo) (diff.File, error) {
+	mode := object.ModeRegular
+	if info.Mode()&0111 != 0 {
+		mode = object.ModeExecutable
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return diff.File{}, err
+	}
+	hash, err := s.Write(object.TypeBlob, data)
+	if err != nil {
+		return diff.File{}, err
+	}
+	return diff.File{Mode: mode, Hash: hash}, nil
+}
+
+// Show: 객체를 사람이 읽기 좋게 출력. 인자가 없으면 HEAD
+//
+//	commit  log -p 와 같이 메타데이터와 첫 부모에 대한 patch (merge 의 combined diff 는 출력하지 않음)
+//	tag     tag 이름, tagger, 메시지 뒤에 가리키는 객체
+//	tree    "tree <이름>" 뒤에 항목 이름 (디렉토리는 '/' 를 붙임)
+//	blob    내용 그대로
+//
+//	-s, --no-patch                       patch 를 출력하지 않음
+//	--oneline, --pretty=<f>, --format=<f> 커밋의 출력 형식 (log 와 같음)
+//	--[no-]use-mailmap                   .mailmap 으로 작성자를 바꿀지 (log 와 같음)
+//	--notes[=<ref>], --no-notes          커밋에 붙은 메모 (log 와 같음)
+func cmdShow(repo *gogit.Repository, args []string) error {
+	format := pretty.Medium
+	abbrev := false
+	patch := true
+	var useMailmap *bool
+	showNotes := true
+	var notesRefName string
+	var names []string
+	for _, arg := range args {
+		if use, ok := mailmapFlag(arg); ok {
+			useMailmap = &use
+			continue
+		}
+		switch {
+		case arg == "-s" || arg == "--no-patch":
+			patch = false
+		case arg == "-p" || arg == "--patch":
+			patch = true
+		case arg == "--oneline":
+			format, abbrev = pretty.Oneline, true
+		case arg == "--notes" || arg == "--no-notes" || strings.HasPrefix(arg, "--notes="):
+			showNotes = arg != "--no-notes"
+			notesRefName = strings.TrimPrefix(strings.TrimPrefix(arg, "--notes"), "=")
+		case strings.HasPrefix(arg, "--pretty=") || strings.HasPrefix(arg, "--format="):
+			name, v, _ := strings.Cut(arg, "=")
+			f, err := pretty.ParseFormat(v)
+			if err != nil {
+				return err
+			}
+			if name == "--format" && f.Name == "format" {
+				f.Separator = false
+			}
+			format = f
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown show option: %s", arg)
+		default:
+			names = append(names, arg)
+		}
+	}
+	if len(names) == 0 {
+		names = []string{"HEAD"}
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	pw := pretty.NewWriter(w, format, abbrev)
+	if err := setOutputEncoding(repo, pw); err != nil {
+		return err
+	}
+	if err := setMailmap(repo, pw, useMailmap); err != nil {
+		return err
+	}
+	if showNotes {
+		if err := setNotes(repo, pw, notesRefName); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		hash, err := repo.ResolveRevision(name)
+		if err != nil {
+			return err
+		}
+		// tag 는 가리키는 객체까지 이어서 보여 준다
+		for hash != "" {
+			obj, err := object.ReadObject(repo.Objects, hash)
+			if err != nil {
+				return err
+			}
+			next := ""
+			switch o := obj.(type) {
+			case *object.Commit:
+				if err := pw.Write(hash, o); err != nil {
+					return err
+				}
+				if patch && len(o.Parents) > 1 {
+					pw.WriteMergeSeparator()
+				} else if patch {
+					text, err := commitPatch(repo, o)
+					if err != nil {
+						return err
+					}
+					if err := pw.WritePatch(text); err != nil {
+						return err
+					}
+				}
+			case *object.Tag:
+				if pw.Shown() {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "tag %s\n", o.Name)
+				// git 과 같이 oneline 은 tagger 를 빼고, 날짜는 medium 에서만 보인다
+				if o.Tagger != (object.Signature{}) && format.Name != "oneline" {
+					fmt.Fprintf(w, "Tagger: %s <%s>\n", o.Tagger.Name, o.Tagger.Email)
+					if format.Name == "medium" {
+						fmt.Fprintf(w, "Date:   %s\n", o.Tagger.When.Format(pretty.DateFormat))
+					}
+				}
+				fmt.Fprintf(w, "\n%s", o.Message)
+				pw.MarkShown()
+				next = o.Object
+			case *object.Tree:
+				if pw.Shown() {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "tree %s\n\n", name)
+				for _, e := range o.Entries {
+					if e.Mode == object.ModeTree {
+						fmt.Fprintf(w, "%s/\n", e.Name)
+					} else {
+						fmt.Fprintln(w, e.Name)
+					}
+				}
+				pw.MarkShown()
+			case *object.Blob:
+				w.Write(o.Data)
+			}
+			hash = next
+		}
+	}
+	return w.Flush()
+}
+
+// Write-Tree: 작업 트리를 tree 객체로 저장하고 해시를 출력
+// 바뀌지 않은 디렉토리는 .gogit/tree-cache 에 기록된 tree 를 다시 써서 파일을 읽지 않는다.
+func cmdWriteTree(ctx context.Context, repo *gogit.Repository) error {
+	if err := repo.RequireWorkTree("write-tree"); err != nil {
+		return err
+	}
+
+	hash, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		return err
+	}
+	fmt.Println(hash)
+	return nil
+}
+
+// snapshotWorkTree: 작업 트리를 tree 로 저장한다. .gogit/tree-cache 로 바뀌지 않은 디렉토리는 읽지 않는다.
+// core.fileMode 가 false 면 실행 비트는 작업 트리 대신 HEAD 의 tree 를 따른다.
+// HEAD 에 없는 파일 중 clean 과 같은 무시 규칙(.gitignore, info/exclude, core.excludesFile)에 맞는 것은 넣지 않는다.
+func snapshotWorkTree(ctx context.Context, repo *gogit.Repository) (string, error) {
+	var cache *worktree.TreeCache
+	if repo.FS != nil {
+		cache = worktree.LoadTreeCache(repo.FS, "tree-cache")
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", err
+	}
+	fileMode, err := cfg.GetBool("core.fileMode", true)
+	if err != nil {
+		return "", err
+	}
+	// HEAD 의 tree 는 받아 두지 않은 submodule 과 (core.fileMode = false 일 때) 실행 비트에 쓴다
+	head, err := repo.ResolveTree("HEAD")
+	if err != nil && !errors.Is(err, gogit.ErrUnknownRevision) {
+		return "", err
+	}
+	excludes, err := repo.Excludes()
+	if err != nil {
+		return "", err
+	}
+	opts := worktree.Options{Base: head, KeepModes: !fileMode, Ignore: excludes}
+	hash, err := worktree.WriteTreeWithOptions(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, cache, opts)
+	if err != nil {
+		return "", err
+	}
+	if cache != nil {
+		if err := cache.Save(repo.FS, "tree-cache"); err != nil {
+			return "", err
+		}
+	}
+	return hash, nil
+}
+
+// Commit: 작업 트리의 스냅샷으로 HEAD 위에 새 커밋을 만들고 브랜치를 옮긴다.
+//
+//	commit [-m <message>]... [-F <file>] [-s] [-n] [-S[<key>] | --no-gpg-sign] [--amend [--no-edit]]
+//
+// index 가 없으므로 작업 트리 전체가 커밋된다. 단 추적하지 않는 파일 중 무시 규칙에 맞는 것은 빠진다.
+// -m 도 -F 도 없으면 COMMIT_EDITMSG 에 바뀐 파일 목록을 주석으로 단 템플릿을 써서 편집기로 연다.
+// 저장된 메시지에서 "#" 줄과 줄 끝 공백을 지우고, 남은 것이 없으면 커밋하지 않는다.
+// -F - 는 표준 입력에서 메시지를 읽고, -s 는 메시지 끝에 커미터의 Signed-off-by 줄을 붙인다.
+// --amend 는 HEAD 커밋을 지금의 작업 트리와 새 메시지로 다시 만든다. 부모와 작성자는 그대로이고 커미터만 바뀐다.
+// 이때 메시지를 주지 않으면 원래 메시지를 편집기로 열고, --no-edit 이면 원래 메시지를 그대로 쓴다.
+// git 과 같이 pre-commit, prepare-commit-msg, commit-msg, post-commit hook 을 실행하며,
+// -n(--no-verify) 은 pre-commit 과 commit-msg 를 건너뛴다.
+// -S(--gpg-sign) 는 커밋에 서명한다. 키를 붙이면 user.signingKey 대신 쓰고, commit.gpgSign 이 true 면 늘 서명한다. (signing 패키지 참고)
+func cmdCommit(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit commit [-m <message>]... [-F <file>] [-s] [-n] [-S[<key>] | --no-gpg-sign] [--amend [--no-edit]]"
+	if err := repo.RequireWorkTree("commit"); err != nil {
+		return err
+	}
+	var paragraphs []string
+	amend, noEdit, signoff, noVerify := false, false, false, false
+	// 둘 다 주지 않았으면 commit.gpgSign 을 따른다
+	sign, noSign := false, false
+	var signKey string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-m" && i+1 < len(args):
+			i++
+			paragraphs = append(paragraphs, strings.TrimRight(args[i], "\n")+"\n")
+		case strings.HasPrefix(args[i], "--message="):
+			paragraphs = append(paragraphs, strings.TrimRight(strings.TrimPrefix(args[i], "--message="), "\n")+"\n")
+		case (args[i] == "-F" || args[i] == "--file") && i+1 < len(args):
+			i++
+			var data []byte
+			var err error
+			if args[i] == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(args[i])
+			}
+			if err != nil {
+				return err
+			}
+			paragraphs = append(paragraphs, string(data))
+		case args[i] == "-s" || args[i] == "--signoff":
+			signoff = true
+		case args[i] == "--amend":
+			amend = true
+		case args[i] == "--no-edit":
+			noEdit = true
+		case args[i] == "-n" || args[i] == "--no-verify":
+			noVerify = true
+		case strings.HasPrefix(args[i], "-S") || args[i] == "--gpg-sign" || strings.HasPrefix(args[i], "--gpg-sign="):
+			sign, noSign = true, false
+			signKey = strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(args[i], "-S"), "--gpg-sign"), "=")
+		case args[i] == "--no-gpg-sign":
+			sign, noSign = false, true
+		default:
+			return errors.New(usage)
+		}
+	}
+	if noEdit && !amend {
+		return errors.New("--no-edit only makes sense with --amend")
+	}
+
+	// 작업 트리가 곧 커밋할 내용이므로 pre-commit 이 고친 파일도 커밋에 들어간다
+	if !noVerify {
+		if err := runHook(repo, "pre-commit"); err != nil {
+			return err
+		}
+	}
+	if err := rerereUpdate(repo); err != nil {
+		return err
+	}
+	tree, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	// amend 는 HEAD 를 대신하므로 바뀐 파일도 HEAD 의 부모와 비교한다
+	var parents []string
+	var amended *object.Commit
+	action := "commit (initial)"
+	head, err := repo.ResolveCommit("HEAD")
+	switch {
+	case amend && err != nil:
+		return fmt.Errorf("there is nothing to amend: %w", err)
+	case amend:
+		if amended, err = object.ReadCommit(repo.Objects, head); err != nil {
+			return err
+		}
+		parents = amended.Parents
+		action = "commit (amend)"
+	case err == nil:
+		parents = []string{head}
+		action = "commit"
+	}
+	var base string
+	if len(parents) > 0 {
+		if base, err = repo.ResolveTree(parents[0]); err != nil {
+			return err
+		}
+	}
+	if !amend && base == tree && parents != nil {
+		return errors.New("nothing to commit, working tree clean")
+	}
+
+	var signature string
+	if signoff {
+		committer, err := repo.Committer()
+		if err != nil {
+			return err
+		}
+		signature = "Signed-off-by: " + committer.Name + " <" + committer.Email + ">"
+	}
+
+	// prepare-commit-msg 에 넘기는 메시지 출처 (git 과 같은 이름)
+	var message, source string
+	edit := false
+	switch {
+	case len(paragraphs) > 0:
+		message = addSignoff(cleanupMessage(strings.Join(paragraphs, "\n"), false), signature)
+		source = "message"
+	case noEdit:
+		message = addSignoff(amended.Message, signature)
+		source = "commit"
+	default:
+		edit = true
+		template := ""
+		if amended != nil {
+			template = amended.Message
+			source = "commit"
+		}
+		status, err := commitStatus(repo, base, tree, parents == nil)
+		if err != nil {
+			return err
+		}
+		message = addSignoff(template, signature) + commitHelp + status
+	}
+	if message, err = commitMessage(repo, message, source, head, edit, noVerify); err != nil {
+		return err
+	}
+
+	signer, err := commitSigner(repo, sign, noSign, signKey)
+	if err != nil {
+		return err
+	}
+	var hash string
+	if amend {
+		hash, err = amendCommit(repo, head, tree, message, signer)
+	} else {
+		var author object.Signature
+		if author, err = repo.Author(); err != nil {
+			return err
+		}
+		hash, err = createSignedCommit(repo, parents, tree, author, message, signer)
+	}
+	if err != nil {
+		return err
+	}
+	subject, _, _ := strings.Cut(message, "\n")
+	if err := repo.UpdateHead(hash, action+": "+subject); err != nil {
+		return err
+	}
+	printCommitSummary(repo, hash, message, len(parents) == 0)
+	// post-commit 의 결과는 커밋에 영향을 주지 않는다
+	runHook(repo, "post-commit")
+	return nil
+}
+
+// commitSigner: 커밋에 서명할 Signer. -S 도 --no-gpg-sign 도 없으면 commit.gpgSign 을 따르고, 서명하지 않으면 nil
+func commitSigner(repo *gogit.Repository, sign, noSign bool, key string) (*signing.Signer, error) {
+	if !sign && !noSign {
+		cfg, err := repo.Config()
+		if err != nil {
+			return nil, err
+		}
+		if sign, err = cfg.GetBool("commit.gpgSign", false); err != nil {
+			return nil, err
+		}
+	}
+	if !sign {
+		return nil, nil
+	}
+	return signerFor(repo, key)
+}
+
+// commitMessage: 메시지를 COMMIT_EDITMSG 에 쓰고 git 과 같은 순서로 hook 과 편집기를 거쳐 최종 메시지를 읽는다.
+// prepare-commit-msg 에는 파일과 메시지 출처(source 가 "commit" 이면 커밋도)를 넘기고, edit 면 편집기를 연다.
+// noVerify 가 아니면 commit-msg 가 파일을 확인한다. 편집한 메시지만 "#" 줄을 지운다.
+func commitMessage(repo *gogit.Repository, message, source, commit string, edit, noVerify bool) (string, error) {
+	if err := vfs.WriteFile(repo.FS, commitEditMsg, []byte(message)); err != nil {
+		return "", err
+	}
+	file := filepath.Join(repo.GogitDir, commitEditMsg)
+	args := []string{file}
+	if source != "" {
+		args = append(args, source)
+	}
+	if source == "commit" {
+		args = append(args, commit)
+	}
+	if err := runHook(repo, "prepare-commit-msg", args...); err != nil {
+		return "", err
+	}
+	if edit {
+		if err := launchEditor(repo, commitEditMsg); err != nil {
+			return "", err
+		}
+	}
+	if !noVerify {
+		if err := runHook(repo, "commit-msg", file); err != nil {
+			return "", err
+		}
+	}
+	data, err := vfs.ReadFile(repo.FS, commitEditMsg)
+	if err != nil {
+		return "", err
+	}
+	message = cleanupMessage(string(data), edit)
+	if message == "" {
+		return "", errors.New("aborting commit due to empty commit message")
+	}
+	return message, nil
+}
+
+// runHook: 저장소의 hook 을 실행한다. git 과 같이 stdin 은 비우고 hook 의 출력은 모두 stderr 로 보낸다.
+func runHook(repo *gogit.Repository, name string, args ...string) error {
+	return repo.RunHook(name, nil, os.Stderr, os.Stderr, args...)
+}
+
+// addSignoff: 메시지 끝에 trailer 줄을 붙인다. 마지막 문단이 이미 trailer 들이면 그 아래에 잇고,
+// 마지막 줄이 같은 trailer 면 그대로 둔다. trailer 가 빈 문자열이면 아무것도 하지 않는다.
+func addSignoff(message string, trailer string) string {
+	if trailer == "" {
+		return message
+	}
+	body := strings.TrimRight(message, "\n")
+	paragraphs := strings.Split(body, "\n\n")
+	last := strings.Split(paragraphs[len(paragraphs)-1], "\n")
+	if last[len(last)-1] == trailer {
+		return message
+	}
+	if body == "" {
+		// 편집기에서 첫 줄을 쓸 수 있게 비워 둔다
+		return "\n" + trailer + "\n"
+	}
+	isTrailer := len(paragraphs) > 1
+	for _, line := range last {
+		key, _, ok := strings.Cut(line, ": ")
+		isTrailer = isTrailer && ok && key != "" && !strings.ContainsAny(key, " \t")
+	}
+	if isTrailer {
+		return body + "\n" + trailer + "\n"
+	}
+	return body + "\n\n" + trailer + "\n"
+}
+
+// commitStatus: 커밋 메시지 템플릿 뒤에 붙일, git 과 같은 형식의 주석 처리된 상태 요약
+func commitStatus(repo *gogit.Repository, base, tree string, initial bool) (string, error) {
+	changes, err := diff.Trees(repo.Objects, base, tree)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("#\n")
+	if ref, err := repo.Refs.Read("HEAD"); err == nil && ref.Target != "" {
+		fmt.Fprintf(&b, "# On branch %s\n", strings.TrimPrefix(ref.Target, "refs/heads/"))
+	} else if head, err := repo.ResolveCommit("HEAD"); err == nil {
+		fmt.Fprintf(&b, "# HEAD detached at %s\n", head[:7])
+	}
+	if initial {
+		b.WriteString("#\n# Initial commit\n#\n")
+	}
+	if len(changes) == 0 {
+		b.WriteString("# No changes\n")
+		return b.String(), nil
+	}
+	b.WriteString("# Changes to be committed:\n")
+	for _, c := range changes {
+		label, name := "modified:", c.Path()
+		switch c.Status() {
+		case 'A':
+			label = "new file:"
+		case 'D':
+			label = "deleted:"
+		case 'R':
+			label, name = "renamed:", c.From.Path+" -> "+c.To.Path
+		case 'T':
+			label = "typechange:"
+		}
+		fmt.Fprintf(&b, "#\t%-12s%s\n", label, name)
+	}
+	b.WriteString("#\n")
+	return b.String(), nil
+}
+
+// Commit-Tree: tree 로 커밋을 만들고 해시를 출력 (ref 는 바꾸지 않는다)
+//
+//	commit-tree <tree> [-p <parent>]... [-m <message>]... [-F <file>]
+//
+// -p 를 여러 번 주면 merge commit 이 된다. 같은 부모는 한 번만 쓴다.
+// -m 을 여러 번 주면 문단으로 잇고, -m 도 -F 도 없으면 표준 입력에서 메시지를 읽는다.
+func cmdCommitTree(repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit commit-tree <tree> [-p <parent>]... [-m <message>]... [-F <file>]"
+	var treeArg string
+	var parents, paragraphs []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-p", "-m", "-F":
+			if i+1 >= len(args) {
+				return errors.New(usage)
+			}
+			i++
+			switch arg {
+			case "-p":
+				hash, err := repo.ResolveCommit(args[i])
+				if err != nil {
+					return err
+				}
+				if slices.Contains(parents, hash) {
+					fmt.Fprintf(os.Stderr, "error: duplicate parent %s ignored\n", hash)
+					continue
+				}
+				parents = append(parents, hash)
+			case "-m":
+				paragraphs = append(paragraphs, strings.TrimRight(args[i], "\n")+"\n")
+			case "-F":
+				var data []byte
+				var err error
+				if args[i] == "-" {
+					data, err = io.ReadAll(os.Stdin)
+				} else {
+					data, err = os.ReadFile(args[i])
+				}
+				if err != nil {
+					return err
+				}
+				paragraphs = append(paragraphs, string(data))
+			}
+		default:
+			if treeArg != "" || strings.HasPrefix(arg, "-") {
+				return errors.New(usage)
+			}
+			treeArg = arg
+		}
+	}
+	if treeArg == "" {
+		return errors.New(usage)
+	}
+	message := strings.Join(paragraphs, "\n")
+	if len(paragraphs) == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		message = string(data)
+	}
+
+	tree, err := repo.ResolveTree(treeArg)
+	if err != nil {
+		return err
+	}
+	author, err := repo.Author()
+	if err != nil {
+		return err
+	}
+	committer, err := repo.Committer()
+	if err != nil {
+		return err
+	}
+	store, err := repo.CommitStorer()
+	if err != nil {
+		return err
+	}
+	hash, err := object.WriteObject(store, &object.Commit{
+		Tree:      tree,
+		Parents:   parents,
+		Author:    author,
+		Committer: committer,
+		Message:   message,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(hash)
+	return nil
+}
+
+// Apply: patch 를 작업 트리에 적용한다. (git apply) 메일로 받은 patch 나 diff 의 출력을 그대로 줄 수 있다.
+//
+//	apply [--check] [-R] [--cached | --index] [-p<n>] [-v] [<patch>...]   patch 파일이 없으면 표준 입력
+//
+//	--check     적용할 수 있는지만 확인하고 아무것도 바꾸지 않는다
+//	-R          거꾸로 적용한다
+//	--cached    index 가 없으므로 작업 트리 대신 HEAD 의 tree 에 적용하고 결과 tree 의 해시를 출력한다
+//	            (commit-tree 로 바로 커밋할 수 있다)
+//	--index     작업 트리에 적용하되, 바꿀 파일이 작업 트리와 HEAD 에서 같아야 한다
+//	-p<n>       경로 앞의 구성 요소를 n 개 뗀다 (기본 1)
+//	-v          patch 마다 확인하고 적용한 것을 출력한다
+//
+// 새 파일, 지운 파일, 모드 변경, rename 과 copy 를 다룬다. 모든 patch 를 먼저 확인해서
+// 하나라도 맞지 않으면 아무것도 바꾸지 않는다.
+func cmdApply(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit apply [--check] [-R] [--cached | --index] [-p<n>] [-v] [<patch>...]"
+	check, reverse, cached, index, verbose := false, false, false, false, false
+	strip := 1
+	var files []string
+	for _, arg := range args {
+		switch {
+		case arg == "--check":
+			check = true
+		case arg == "-R" || arg == "--reverse":
+			reverse = true
+		case arg == "--cached":
+			cached = true
+		case arg == "--index":
+			index = true
+		case arg == "-v" || arg == "--verbose":
+			verbose = true
+		case strings.HasPrefix(arg, "-p") && len(arg) > 2:
+			n, err := strconv.Atoi(arg[2:])
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid -p value: %s", arg[2:])
+			}
+			strip = n
+		case arg == "-":
+			files = append(files, arg)
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			files = append(files, arg)
+		}
+	}
+	if cached && index {
+		return errors.New("--cached and --index cannot be used together")
+	}
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var data []byte
+	for _, name := range files {
+		var content []byte
+		var err error
+		if name == "-" {
+			content, err = io.ReadAll(os.Stdin)
+		} else {
+			content, err = os.ReadFile(name)
+		}
+		if err != nil {
+			return err
+		}
+		data = append(data, content...)
+	}
+	patches, err := apply.Parse(data, strip)
+	if err != nil {
+		return err
+	}
+	if reverse {
+		for i, p := range patches {
+			patches[i] = p.Reverse()
+		}
+	}
+
+	head, err := repo.ResolveTree("HEAD")
+	if err != nil && !errors.Is(err, gogit.ErrUnknownRevision) {
+		return err
+	}
+	base, where := head, "HEAD"
+	if !cached {
+		if err := repo.RequireWorkTree("apply"); err != nil {
+			return err
+		}
+		if base, err = snapshotWorkTree(ctx, repo); err != nil {
+			return err
+		}
+		where = "working directory"
+	}
+	if base == "" {
+		if base, err = object.WriteObject(repo.Objects, &object.Tree{}); err != nil {
+			return err
+		}
+	}
+
+	var match string
+	if index {
+		match = head
+	}
+	tree, err := applyPatches(repo, patches, base, match, where, verbose)
+	if err != nil {
+		return err
+	}
+	switch {
+	case check:
+		return nil
+	case cached:
+		fmt.Println(tree)
+		return nil
+	}
+	return worktree.CheckoutPaths(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, base, tree, nil)
+}
+
+// applyPatches: patch 들을 tree 에 차례로 적용한 결과 tree 를 저장한다. 작업 트리는 건드리지 않는다.
+// match 가 빈 문자열이 아니면 바꿀 파일이 그 tree 에서도 같아야 한다. (apply --index)
+// 맞지 않는 patch 는 git 과 같은 에러를 출력하고 끝까지 확인한 뒤 errPatchFailed 를 돌려준다.
+func applyPatches(repo *gogit.Repository, patches []*apply.File, tree, match, where string, verbose bool) (string, error) {
+	failed := false
+	report := func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+		failed = true
+	}
+	lookup := func(tree, p string) (object.TreeEntry, bool, error) {
+		if tree == "" {
+			return object.TreeEntry{}, false, nil
+		}
+		e, ok, err := object.LookupPath(repo.Objects, tree, p)
+		return e, ok && e.Mode != object.ModeTree, err
+	}
+	for _, p := range patches {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Checking patch %s...\n", p.Path())
+		}
+		var old []byte
+		mode := object.ModeRegular
+		if !p.IsNew {
+			entry, ok, err := lookup(tree, p.OldPath)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				report("%s: No such file or directory", p.OldPath)
+				continue
+			}
+			if match != "" {
+				h, ok, err := lookup(match, p.OldPath)
+				if err != nil {
+					return "", err
+				}
+				if !ok || h != entry {
+					report("%s: does not match HEAD", p.OldPath)
+					continue
+				}
+			}
+			if p.OldMode != 0 && p.OldMode != entry.Mode {
+				fmt.Fprintf(os.Stderr, "warning: %s has type %06o, expected %06o\n", p.OldPath, uint32(entry.Mode), uint32(p.OldMode))
+			}
+			if _, old, err = repo.Objects.Read(entry.Hash); err != nil {
+				return "", err
+			}
+			mode = entry.Mode
+		}
+		if !p.IsDelete && p.NewPath != p.OldPath {
+			if _, ok, err := lookup(tree, p.NewPath); err != nil {
+				return "", err
+			} else if ok {
+				report("%s: already exists in %s", p.NewPath, where)
+				continue
+			}
+		}
+
+		out, err := p.Apply(old)
+		if err != nil {
+			report("%v", err)
+			var failure *apply.FailedError
+			if errors.As(err, &failure) {
+				report("%s: patch does not apply", p.Path())
+			}
+			continue
+		}
+		if !p.IsCopy && !p.IsNew && (p.IsDelete || p.OldPath != p.NewPath) {
+			if p.IsDelete && len(out) > 0 {
+				report("%s: removal patch leaves file contents", p.OldPath)
+				continue
+			}
+			if tree, err = object.ReplacePath(repo.Objects, tree, p.OldPath, nil); err != nil {
+				return "", err
+			}
+		}
+		if !p.IsDelete {
+			if p.NewMode != 0 {
+				mode = p.NewMode
+			}
+			hash, err := repo.Objects.Write(object.TypeBlob, out)
+			if err != nil {
+				return "", err
+			}
+			if tree, err = object.ReplacePath(repo.Objects, tree, p.NewPath, &object.TreeEntry{Mode: mode, Hash: hash}); err != nil {
+				return "", err
+			}
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Applied patch %s cleanly.\n", p.Path())
+		}
+	}
+	if failed {
+		return "", errPatchFailed
+	}
+	return tree, nil
+}
+
+// Format-Patch: 커밋마다 메일 형식의 patch 파일을 만든다. (git format-patch) am 으로 다시 커밋할 수 있다.
+//
+//	format-patch [-o <dir>] [--stdout] [-n | -N] [--no-stat] [--start-number <n>] [-<n>] [<since> | <range>]
+//
+//	<since>       <since>..HEAD 의 커밋. <range> 는 rev-list 와 같은 범위
+//	-<n>          범위 대신(또는 범위 안에서) 마지막 n 개의 커밋
+//	-o <dir>      patch 파일을 쓸 디렉토리 (기본은 현재 디렉토리)
+//	--stdout      파일 대신 mbox 하나로 표준 출력에 쓴다
+//	-n / -N       patch 가 하나여도 [PATCH 1/1] 을 붙인다 / 여러 개여도 [PATCH] 만 붙인다
+//	--no-stat     diffstat 을 쓰지 않는다
+//
+// merge commit 은 건너뛰고 오래된 커밋부터 0001-<제목>.patch 로 쓴 뒤 파일 이름을 출력한다.
+// 메일 끝의 서명은 format.signature 이고 없으면 "gogit" 이다.
+func cmdFormatPatch(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit format-patch [-o <dir>] [--stdout] [-n | -N] [--no-stat] [--start-number <n>] [-<n>] [<since> | <range>]"
+	outDir, toStdout, numbered, noNumbered, stat := "", false, false, false, true
+	start, limit := 1, -1
+	var revs []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-o" || arg == "--output-directory":
+			if i+1 >= len(args) {
+				return errors.New(usage)
+			}
+			i++
+			outDir = args[i]
+		case strings.HasPrefix(arg, "--output-directory="):
+			outDir = strings.TrimPrefix(arg, "--output-directory=")
+		case arg == "--stdout":
+			toStdout = true
+		case arg == "-n" || arg == "--numbered":
+			numbered = true
+		case arg == "-N" || arg == "--no-numbered":
+			noNumbered = true
+		case arg == "--no-stat":
+			stat = false
+		case arg == "--start-number":
+			if i+1 >= len(args) {
+				return errors.New(usage)
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid --start-number: %s", args[i])
+			}
+			start = n
+		case len(arg) > 1 && arg[0] == '-' && isDigits(arg[1:]):
+			limit, _ = strconv.Atoi(arg[1:])
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			revs = append(revs, arg)
+		}
+	}
+	if numbered && noNumbered {
+		return errors.New("-n and -N are mutually exclusive")
+	}
+	switch {
+	case len(revs) == 0 && limit < 0:
+		return errors.New(usage)
+	case len(revs) == 0:
+		revs = []string{"HEAD"}
+	case len(revs) == 1 && limit < 0 && !strings.Contains(revs[0], ".."):
+		// 하나만 주면 거기서부터 HEAD 까지
+		revs[0] += "..HEAD"
+	}
+	set, err := repo.ResolveRevSet(ctx, revs)
+	if err != nil {
+		return err
+	}
+
+	type item struct {
+		hash   string
+		commit *object.Commit
+	}
+	var commits []item
+	errStop := errors.New("stop")
+	err = set.Iter(repo.Objects, object.OrderTopo).ForEachContext(ctx, func(hash string, c *object.Commit) error {
+		if limit >= 0 && len(commits) >= limit {
+			return errStop
+		}
+		if len(c.Parents) <= 1 {
+			commits = append(commits, item{hash, c})
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return err
+	}
+	slices.Reverse(commits)
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	signature, ok := cfg.Get("format.signature")
+	if !ok {
+		signature = "gogit"
+	}
+	opts, err := diffOptions(repo)
+	if err != nil {
+		return err
+	}
+	opts.Binary = true
+	numbered = !noNumbered && (numbered || len(commits) > 1)
+	for i, it := range commits {
+		var from string
+		if len(it.commit.Parents) == 1 {
+			parent, err := object.ReadCommit(repo.Objects, it.commit.Parents[0])
+			if err != nil {
+				return err
+			}
+			from = parent.Tree
+		}
+		changes, err := diff.Trees(repo.Objects, from, it.commit.Tree)
+		if err != nil {
+			return err
+		}
+		p := mbox.Patch{Hash: it.hash, Commit: it.commit, Numbered: numbered, Number: start + i, Total: start + len(commits) - 1, Signature: signature}
+		var b strings.Builder
+		if err := diff.WritePatch(&b, repo.Objects, changes, opts); err != nil {
+			return err
+		}
+		p.Diff = b.String()
+		if stat && len(changes) > 0 {
+			var sb strings.Builder
+			stats, err := diff.Stats(repo.Objects, changes)
+			if err != nil {
+				return err
+			}
+			if err := diff.WriteStat(&sb, stats, 72); err != nil {
+				return err
+			}
+			if err := diff.WriteSummary(&sb, changes); err != nil {
+				return err
+			}
+			p.Stat = sb.String()
+		}
+
+		if toStdout {
+			if err := mbox.Write(os.Stdout, p); err != nil {
+				return err
+			}
+			continue
+		}
+		name := filepath.Join(outDir, mbox.FileName(start+i, it.commit.Message))
+		if outDir != "" {
+			if err := os.MkdirAll(outDir, 0755); err != nil {
+				return err
+			}
+		}
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		err = mbox.Write(f, p)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(name)
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// am 이 진행 중일 때의 상태 디렉토리
+//
+//	0001, 0002, ...  적용할 메일들
+//	next, last       다음에 적용할 메일의 번호와 마지막 번호
+//	orig-head        시작할 때의 HEAD (--abort 로 돌아갈 곳)
+//	signoff          있으면 커밋 메시지에 Signed-off-by 를 붙인다
+const amDir = "am"
+
+// Am: 메일(mbox) 의 patch 들을 차례로 적용해 커밋한다. (git am) 작성자와 날짜, 메시지는 메일에서 가져온다.
+//
+//	am [-s] [<mbox>...]   mbox 파일이 없으면 표준 입력
+//	am --continue         맞지 않던 patch 를 작업 트리에 직접 적용한 뒤 그 메일의 내용으로 커밋하고 이어간다
+//	am --skip             맞지 않던 patch 를 건너뛴다
+//	am --abort            HEAD 와 작업 트리를 시작 전으로 되돌린다
+//
+// -s 는 각 커밋에 커미터의 Signed-off-by 줄을 붙인다. 작업 트리는 HEAD 와 같아야 시작한다.
+// patch 가 맞지 않으면 상태를 .gogit/am 에 남기고 멈춘다. 다른 patch 들은 apply 와 같은 방식으로 적용한다.
+func cmdAm(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit am [-s] [<mbox>...] | --continue | --skip | --abort"
+	if err := repo.RequireWorkTree("am"); err != nil {
+		return err
+	}
+	signoff := false
+	var files []string
+	for _, arg := range args {
+		switch arg {
+		case "--continue", "-r", "--resolved":
+			return amContinue(ctx, repo)
+		case "--skip":
+			return amSkip(ctx, repo)
+		case "--abort":
+			return amAbort(ctx, repo)
+		case "-s", "--signoff":
+			signoff = true
+		default:
+			if strings.HasPrefix(arg, "-") && arg != "-" {
+				return errors.New(usage)
+			}
+			files = append(files, arg)
+		}
+	}
+	if vfs.Exists(repo.FS, amDir) {
+		return errors.New("an am session is already in progress (use --continue, --skip or --abort)")
+	}
+	if vfs.Exists(repo.FS, rebaseDir) {
+		return errors.New("a rebase is in progress; finish it before running am")
+	}
+	head, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	headTree, err := repo.ResolveTree(head)
+	if err != nil {
+		return err
+	}
+	if err := requireCleanWorkTree(ctx, repo, headTree, "am"); err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var mails [][]byte
+	for _, name := range files {
+		var data []byte
+		if name == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(name)
+		}
+		if err != nil {
+			return err
+		}
+		mails = append(mails, mbox.Split(data)...)
+	}
+	if len(mails) == 0 {
+		return errors.New("patch format detection failed")
+	}
+
+	if err := repo.FS.MkdirAll(amDir, 0755); err != nil {
+		return err
+	}
+	state := map[string]string{"next": "1", "last": strconv.Itoa(len(mails)), "orig-head": head}
+	if signoff {
+		state["signoff"] = "t"
+	}
+	for i, mail := range mails {
+		state[fmt.Sprintf("%04d", i+1)] = string(mail)
+	}
+	for name, content := range state {
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if err := vfs.WriteFile(repo.FS, amDir+"/"+name, []byte(content)); err != nil {
+			return err
+		}
+	}
+	return amRun(ctx, repo)
+}
+
+// amRun: next 부터 last 까지의 메일을 적용한다. 맞지 않는 patch 에서 멈추고 errPatchFailed
+func amRun(ctx context.Context, repo *gogit.Repository) error {
+	next, last, err := amPosition(repo)
+	if err != nil {
+		return err
+	}
+	for ; next <= last; next++ {
+		msg, err := amMessage(repo, next)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applying: %s\n", msg.Subject)
+		// stop: reason 이 빈 문자열이면 이유는 이미 출력했다
+		stop := func(reason string) error {
+			if reason != "" {
+				fmt.Fprintln(os.Stderr, reason)
+			}
+			fmt.Fprintf(os.Stderr, "Patch failed at %04d %s\n", next, msg.Subject)
+			fmt.Fprintln(os.Stderr, "hint: apply the change to the working tree by hand and run \"gogit am --continue\",")
+			fmt.Fprintln(os.Stderr, "hint: run \"gogit am --skip\" to drop this patch, or \"gogit am --abort\" to go back.")
+			return errPatchFailed
+		}
+		if len(bytes.TrimSpace(msg.Patch)) == 0 {
+			return stop("Patch is empty.")
+		}
+		patches, err := apply.Parse(msg.Patch, 1)
+		if err != nil {
+			return stop(err.Error())
+		}
+		head, err := repo.ResolveCommit("HEAD")
+		if err != nil {
+			return err
+		}
+		headTree, err := repo.ResolveTree(head)
+		if err != nil {
+			return err
+		}
+		tree, err := applyPatches(repo, patches, headTree, "", "index", false)
+		if errors.Is(err, errPatchFailed) {
+			return stop("")
+		}
+		if err != nil {
+			return err
+		}
+		if err := amCommit(repo, head, tree, msg); err != nil {
+			return err
+		}
+		if err := worktree.CheckoutPaths(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, headTree, tree, nil); err != nil {
+			return err
+		}
+		if err := vfs.WriteFile(repo.FS, amDir+"/next", []byte(strconv.Itoa(next+1)+"\n")); err != nil {
+			return err
+		}
+	}
+	return removeAmState(repo)
+}
+
+// amCommit: 메일의 작성자와 메시지로 head 위에 tree 를 커밋하고 HEAD 를 옮긴다
+func amCommit(repo *gogit.Repository, head, tree string, msg *mbox.Message) error {
+	message := msg.CommitMessage()
+	if vfs.Exists(repo.FS, amDir+"/signoff") {
+		committer, err := repo.Committer()
+		if err != nil {
+			return err
+		}
+		message = addSignoff(message, "Signed-off-by: "+committer.Name+" <"+committer.Email+">")
+	}
+	hash, err := createCommit(repo, []string{head}, tree, msg.Author, message)
+	if err != nil {
+		return err
+	}
+	return repo.UpdateHead(hash, "am: "+msg.Subject)
+}
+
+func amContinue(ctx context.Context, repo *gogit.Repository) error {
+	next, _, err := amPosition(repo)
+	if err != nil {
+		return err
+	}
+	msg, err := amMessage(repo, next)
+	if err != nil {
+		return err
+	}
+	head, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	headTree, err := repo.ResolveTree(head)
+	if err != nil {
+		return err
+	}
+	tree, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if tree == headTree {
+		return errors.New("no changes: apply the patch to the working tree first, or use \"gogit am --skip\"")
+	}
+	fmt.Printf("Applying: %s\n", msg.Subject)
+	if err := amCommit(repo, head, tree, msg); err != nil {
+		return err
+	}
+	if err := vfs.WriteFile(repo.FS, amDir+"/next", []byte(strconv.Itoa(next+1)+"\n")); err != nil {
+		return err
+	}
+	return amRun(ctx, repo)
+}
+
+func amSkip(ctx context.Context, repo *gogit.Repository) error {
+	next, _, err := amPosition(repo)
+	if err != nil {
+		return err
+	}
+	head, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	if err := resetWorkTree(ctx, repo, head); err != nil {
+		return err
+	}
+	if err := vfs.WriteFile(repo.FS, amDir+"/next", []byte(strconv.Itoa(next+1)+"\n")); err != nil {
+		return err
+	}
+	return amRun(ctx, repo)
+}
+
+func amAbort(ctx context.Context, repo *gogit.Repository) error {
+	if !vfs.Exists(repo.FS, amDir) {
+		return errors.New("no am session in progress")
+	}
+	data, err := vfs.ReadFile(repo.FS, amDir+"/orig-head")
+	if err != nil {
+		return err
+	}
+	orig := strings.TrimSpace(string(data))
+	if err := resetWorkTree(ctx, repo, orig); err != nil {
+		return err
+	}
+	if err := repo.UpdateHead(orig, "am --abort"); err != nil {
+		return err
+	}
+	return removeAmState(repo)
+}
+
+// amPosition: 다음에 적용할 메일의 번호와 마지막 번호
+func amPosition(repo *gogit.Repository) (next, last int, err error) {
+	if !vfs.Exists(repo.FS, amDir) {
+		return 0, 0, errors.New("no am session in progress")
+	}
+	for name, n := range map[string]*int{"next": &next, "last": &last} {
+		data, err := vfs.ReadFile(repo.FS, amDir+"/"+name)
+		if err != nil {
+			return 0, 0, err
+		}
+		if *n, err = strconv.Atoi(strings.TrimSpace(string(data))); err != nil {
+			return 0, 0, fmt.Errorf("corrupt am state: %s: %w", name, err)
+		}
+	}
+	return next, last, nil
+}
+
+func amMessage(repo *gogit.Repository, n int) (*mbox.Message, error) {
+	data, err := vfs.ReadFile(repo.FS, fmt.Sprintf("%s/%04d", amDir, n))
+	if err != nil {
+		return nil, err
+	}
+	return mbox.Parse(data)
+}
+
+func removeAmState(repo *gogit.Repository) error {
+	entries, err := repo.FS.ReadDir(amDir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := repo.FS.Remove(amDir + "/" + e.Name()); err != nil {
+			return err
+		}
+	}
+	return repo.FS.Remove(amDir)
+}
+
+// Clean: 추적하지 않는 파일을 작업 트리에서 지운다. (git clean)
+//
+//	clean (-n | -f) [-d] [-x | -X] [-q] [--] [<path>...]
+//
+//	-n, --dry-run  지울 것을 출력만 한다
+//	-f, --force    정말 지운다. clean.requireForce 가 false 가 아니면 -n 이나 -f 중 하나가 있어야 한다
+//	-d             추적하지 않는 디렉토리도 지운다. 없으면 그런 디렉토리 안은 보지 않는다
+//	-x             무시한 파일도 지운다
+//	-X             무시한 파일만 지운다
+//	-q, --quiet    지운 경로를 출력하지 않는다
+//
+// index 가 없으므로 HEAD 의 tree 에 있는 파일을 추적하는 파일로 본다. 무시 규칙은 각 디렉토리의 .gitignore 와
+// info/exclude, core.excludesFile 이다. 안의 것을 모두 지울 디렉토리는 "dir/" 하나로 지우고,
+// .git 이나 .gogit 이 있는 디렉토리는 다른 저장소이므로 건드리지 않는다.
+func cmdClean(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit clean (-n | -f) [-d] [-x | -X] [-q] [--] [<path>...]"
+	if err := repo.RequireWorkTree("clean"); err != nil {
+		return err
+	}
+	dryRun, force, quiet := false, false, false
+	c := &cleaner{ctx: ctx, work: vfs.NewOS(repo.WorkTree), tracked: map[string]bool{}}
+	var paths []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--dry-run":
+			dryRun = true
+		case arg == "--force":
+			force = true
+		case arg == "--quiet":
+			quiet = true
+		case arg == "--":
+			for _, a := range args[i+1:] {
+				p, err := repoPath(repo, a)
+				if err != nil {
+					return err
+				}
+				paths = append(paths, p)
+			}
+			i = len(args)
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for _, f := range arg[1:] {
+				switch f {
+				case 'n':
+					dryRun = true
+				case 'f':
+					force = true
+				case 'd':
+					c.dirs = true
+				case 'x':
+					c.ignored = true
+				case 'X':
+					c.onlyIgnored = true
+				case 'q':
+					quiet = true
+				default:
+					return errors.New(usage)
+				}
+			}
+		default:
+			p, err := repoPath(repo, arg)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, p)
+		}
+	}
+	if c.ignored && c.onlyIgnored {
+		return errors.New("-x and -X cannot be used together")
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	requireForce, err := cfg.GetBool("clean.requireForce", true)
+	if err != nil {
+		return err
+	}
+	if requireForce && !dryRun && !force {
+		return errors.New("clean.requireForce defaults to true and neither -n nor -f given; refusing to clean")
+	}
+
+	if c.ignore, err = repo.Excludes(); err != nil {
+		return err
+	}
+	if head, err := repo.ResolveTree("HEAD"); err == nil {
+		changes, err := diff.Trees(repo.Objects, "", head)
+		if err != nil {
+			return err
+		}
+		for _, ch := range changes {
+			p := ch.Path()
+			c.tracked[p] = true
+			for i := strings.LastIndexByte(p, '/'); i > 0; i = strings.LastIndexByte(p[:i], '/') {
+				c.tracked[p[:i]] = true
+			}
+		}
+	} else if !errors.Is(err, gogit.ErrUnknownRevision) {
+		return err
+	}
+	c.paths = paths
+	remove, _, err := c.scan("", false)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, p := range remove {
+		if repoDir, ok := strings.CutPrefix(p, "?"); ok {
+			if dryRun {
+				fmt.Printf("Would skip repository %s\n", repoDir)
+			} else if !quiet {
+				fmt.Printf("Skipping repository %s\n", repoDir)
+			}
+			continue
+		}
+		if dryRun {
+			fmt.Printf("Would remove %s\n", p)
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(repo.WorkTree, filepath.FromSlash(strings.TrimSuffix(p, "/")))); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to remove %s: %v\n", p, err)
+			failed = true
+			continue
+		}
+		if !quiet {
+			fmt.Printf("Removing %s\n", p)
+		}
+	}
+	if failed {
+		return errors.New("some paths could not be removed")
+	}
+	return nil
+}
+
+// cleaner: clean 이 지울 경로를 찾는다
+type cleaner struct {
+	ctx  context.Context
+	work vfs.Filesystem
+	// tracked: HEAD 의 파일과 그 상위 디렉토리들
+	tracked map[string]bool
+	ignore  *ignore.Matcher
+	// paths: 명령줄의 경로. 비어 있으면 작업 트리 전체
+	paths                      []string
+	dirs, ignored, onlyIgnored bool
+}
+
+// scan: dir 아래에서 지울 경로들을 경로 순서로 돌려준다. 디렉토리는 끝에 "/" 를 붙인다.
+// untracked 는 dir 이 추적하지 않는 디렉토리인지이고, 그 안에서 건너뛴 저장소는 앞에 "?" 를 붙여 알린다. (git 과 같음)
+// all 은 dir 안의 모든 것을 지우게 되는지(dir 을 통째로 지워도 되는지)다.
+func (c *cleaner) scan(dir string, untracked bool) (remove []string, all bool, err error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	if data, err := vfs.ReadFile(c.work, path.Join(dir, ".gitignore")); err == nil {
+		c.ignore.Add(dir, data)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, false, err
+	}
+	entries, err := c.work.ReadDir(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	all = true
+	for _, e := range entries {
+		p := path.Join(dir, e.Name())
+		if e.Name() == ".git" || e.Name() == ".gogit" || !c.inScope(p) {
+			all = false
+			continue
+		}
+		selected := len(c.paths) == 0 || pathspecMatcher(c.paths)(p)
+		ignored := c.ignore.Ignored(p, e.IsDir())
+		if !e.IsDir() {
+			if !c.tracked[p] && selected && c.removable(ignored) {
+				remove = append(remove, p)
+			} else {
+				all = false
+			}
+			continue
+		}
+
+		switch nested, err := c.nestedRepo(p); {
+		case err != nil:
+			return nil, false, err
+		case c.tracked[p]:
+			sub, _, err := c.scan(p, false)
+			if err != nil {
+				return nil, false, err
+			}
+			remove, all = append(remove, sub...), false
+		case !c.dirs:
+			all = false
+		case nested:
+			if untracked && selected {
+				remove = append(remove, "?"+p)
+			}
+			all = false
+		case ignored:
+			// 무시한 디렉토리는 안을 보지 않고 통째로 다룬다
+			if (c.ignored || c.onlyIgnored) && selected {
+				remove = append(remove, p+"/")
+			} else {
+				all = false
+			}
+		default:
+			sub, subAll, err := c.scan(p, true)
+			if err != nil {
+				return nil, false, err
+			}
+			if subAll && selected && (len(sub) > 0 || !c.onlyIgnored) {
+				remove = append(remove, p+"/")
+				continue
+			}
+			remove, all = append(remove, sub...), false
+		}
+	}
+	return remove, all, nil
+}
+
+// removable: 추적하지 않는 파일을 -x, -X 에 따라 지울지
+func (c *cleaner) removable(ignored bool) bool {
+	switch {
+	case c.ignored:
+		return true
+	case c.onlyIgnored:
+		return ignored
+	}
+	return !ignored
+}
+
+// inScope: p 가 명령줄의 경로 안에 있거나 명령줄 경로의 상위 디렉토리인지
+func (c *cleaner) inScope(p string) bool {
+	if len(c.paths) == 0 || pathspecMatcher(c.paths)(p) {
+		return true
+	}
+	for _, spec := range c.paths {
+		if strings.HasPrefix(spec, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedRepo: 디렉토리 p 가 다른 저장소의 작업 트리인지
+func (c *cleaner) nestedRepo(p string) (bool, error) {
+	for _, name := range []string{".git", ".gogit"} {
+		if _, err := c.work.Stat(path.Join(p, name)); err == nil {
+			return true, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// Restore: 작업 트리의 파일을 source 커밋의 내용으로 되돌린다.
+// source 에 없는 파일은 지우지만, HEAD 에도 없는(커밋된 적 없는) 파일은 남겨 둔다.
+//
+//	restore [--source=<rev>] [--worktree] [--staged] [--] <pathspec>...
+//
+// index 가 없으므로 작업 트리가 곧 스테이징된 상태다. 그래서 source 의 기본값은 HEAD 이고,
+// --staged 는 --worktree 와 함께일 때만 쓸 수 있다. (작업 트리를 건드리지 않고 스테이징만 되돌릴 수 없다)
+// pathspec 은 현재 디렉토리 기준의 파일이나 디렉토리 경로다. "." 은 현재 디렉토리 전체
+func cmdRestore(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit restore [--source=<rev>] [--worktree] [--staged] [--] <pathspec>..."
+	if err := repo.RequireWorkTree("restore"); err != nil {
+		return err
+	}
+
+	source := "HEAD"
+	worktreeFlag, staged := false, false
+	var specs []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--":
+			specs = append(specs, args[i+1:]...)
+			i = len(args)
+		case args[i] == "-W" || args[i] == "--worktree":
+			worktreeFlag = true
+		case args[i] == "-S" || args[i] == "--staged":
+			staged = true
+		case (args[i] == "-s" || args[i] == "--source") && i+1 < len(args):
+			i++
+			source = args[i]
+		case strings.HasPrefix(args[i], "--source="):
+			source = strings.TrimPrefix(args[i], "--source=")
+		case strings.HasPrefix(args[i], "-"):
+			return errors.New(usage)
+		default:
+			specs = append(specs, args[i])
+		}
+	}
+	if len(specs) == 0 {
+		return errors.New("you must specify path(s) to restore")
+	}
+	if staged && !worktreeFlag {
+		return errors.New("--staged without --worktree is not supported: there is no index, the work tree is the staged state")
+	}
+
+	sourceTree, err := repo.ResolveTree(source)
+	if err != nil {
+		return err
+	}
+	current, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, len(specs))
+	for i, spec := range specs {
+		if paths[i], err = repoPath(repo, spec); err != nil {
+			return err
+		}
+		if paths[i] == "" {
+			continue
+		}
+		// 어느 쪽에도 없는 경로는 오타일 가능성이 크다
+		found := false
+		for _, tree := range []string{sourceTree, current} {
+			_, ok, err := object.LookupPath(repo.Objects, tree, paths[i])
+			if err != nil {
+				return err
+			}
+			found = found || ok
+		}
+		if !found {
+			return fmt.Errorf("pathspec '%s' did not match any file(s) known to gogit", spec)
+		}
+	}
+
+	// HEAD 에도 source 에도 없는 파일은 커밋된 적 없는 새 파일이므로 지우지 않는다
+	headTree, _ := repo.ResolveTree("HEAD")
+	inPathspec := pathspecMatcher(paths)
+	match := func(p string) bool {
+		if !inPathspec(p) {
+			return false
+		}
+		for _, tree := range []string{sourceTree, headTree} {
+			if _, ok, _ := object.LookupPath(repo.Objects, tree, p); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return worktree.CheckoutPaths(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, current, sourceTree, match)
+}
+
+// repoPath: 현재 디렉토리 기준의 경로를 작업 트리 루트 기준의 "/" 경로로 바꾼다. 루트 자체는 빈 문자열
+func repoPath(repo *gogit.Repository, arg string) (string, error) {
+	abs, err := filepath.Abs(arg)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(repo.WorkTree, abs)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s: outside repository at '%s'", arg, repo.WorkTree)
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// relativePath: 작업 트리 루트 기준의 경로 p 를 dir(루트 기준, 루트는 빈 문자열) 기준의 "/" 경로로 바꾼다
+func relativePath(dir string, p string) string {
+	if dir == "" {
+		return p
+	}
+	rel, err := filepath.Rel(filepath.FromSlash(dir), filepath.FromSlash(p))
+	if err != nil {
+		return p
+	}
+	return filepath.ToSlash(rel)
+}
+
+// pathspecMatcher: 경로가 paths 의 어느 하나와 같거나 그 아래에 있으면 true. 빈 경로는 모든 경로와 맞는다.
+func pathspecMatcher(paths []string) func(p string) bool {
+	return func(p string) bool {
+		for _, spec := range paths {
+			if spec == "" || p == spec || strings.HasPrefix(p, spec+"/") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// mergeMsg: 충돌로 멈췄을 때 커밋 메시지와 충돌한 파일 목록을 남기는 파일 (git 과 같은 이름)
+const mergeMsg = "MERGE_MSG"
+
+// replay: 커밋 하나의 변경을 HEAD 위에 다시 적용하는 명령(cherry-pick, revert)의 공통 부분
+type replay struct {
+	command string
+	// stateFile: 충돌로 멈췄을 때 적용하던 커밋을 기록하는 파일
+	stateFile string
+	// revert: 변경을 거꾸로 적용한다. 작성자는 원래 커밋이 아니라 지금 사용자다.
+	revert bool
+}
+
+var (
+	cherryPick = replay{command: "cherry-pick", stateFile: "CHERRY_PICK_HEAD"}
+	revert     = replay{command: "revert", stateFile: "REVERT_HEAD", revert: true}
+)
+
+// Cherry-Pick: 커밋이 부모에 대해 바꾼 내용을 현재 HEAD 위에 3-way merge 로 적용하고 새 커밋을 만든다.
+// 새 커밋의 작성자와 메시지는 원래 커밋의 것을 쓴다.
+//
+//	cherry-pick <commit>     작업 트리에 커밋하지 않은 변경이 있으면 시작하지 않는다
+//	cherry-pick --continue   작업 트리에서 충돌을 해결한 뒤 커밋을 마저 만든다
+//	cherry-pick --abort      작업 트리를 HEAD 로 되돌리고 그만둔다
+//
+// 충돌이 나면 충돌 표시를 넣은 파일을 작업 트리에 쓰고 .gogit/CHERRY_PICK_HEAD 와 MERGE_MSG 를 남긴다.
+// index 가 없으므로 --continue 는 작업 트리 전체를 그대로 커밋한다.
+func cmdCherryPick(ctx context.Context, repo *gogit.Repository, args []string) error {
+	return cherryPick.run(ctx, repo, args)
+}
+
+// Revert: 커밋이 바꾼 내용을 되돌리는 새 커밋을 만든다. 메시지는 "Revert "<제목>"" 이다.
+// cherry-pick 과 같은 방식(merge 의 양쪽만 바꿔서)으로 동작하고, 옵션과 충돌 처리도 같다. (상태 파일은 REVERT_HEAD)
+//
+//	revert <commit> | --continue | --abort
+func cmdRevert(ctx context.Context, repo *gogit.Repository, args []string) error {
+	return revert.run(ctx, repo, args)
+}
+
+func (r replay) run(ctx context.Context, repo *gogit.Repository, args []string) error {
+	usage := "usage: gogit " + r.command + " (<commit> | --continue | --abort)"
+	if len(args) != 1 {
+		return errors.New(usage)
+	}
+	if err := repo.RequireWorkTree(r.command); err != nil {
+		return err
+	}
+	switch args[0] {
+	case "--continue":
+		return r.resume(ctx, repo)
+	case "--abort":
+		return r.abort(ctx, repo)
+	}
+	if strings.HasPrefix(args[0], "-") {
+		return errors.New(usage)
+	}
+	if vfs.Exists(repo.FS, r.stateFile) {
+		return fmt.Errorf("a %s is already in progress (use --continue or --abort)", r.command)
+	}
+
+	pickHash, err := repo.ResolveCommit(args[0])
+	if err != nil {
+		return err
+	}
+	pick, err := object.ReadCommit(repo.Objects, pickHash)
+	if err != nil {
+		return err
+	}
+	parentTree, err := pickParentTree(repo, pickHash, pick, r.command)
+	if err != nil {
+		return err
+	}
+
+	headHash, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	head, err := object.ReadCommit(repo.Objects, headHash)
+	if err != nil {
+		return err
+	}
+	if err := requireCleanWorkTree(ctx, repo, head.Tree, r.command); err != nil {
+		return err
+	}
+
+	// revert 는 커밋에서 부모로 가는 변경을 적용한다
+	label := pickHash[:7] + " (" + pick.Subject() + ")"
+	base, theirs, message := parentTree, pick.Tree, pick.Message
+	if r.revert {
+		base, theirs = pick.Tree, parentTree
+		label = "parent of " + label
+		message = fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s.\n", pick.Subject(), pickHash)
+	}
+	result, err := applyChange(ctx, repo, head.Tree, base, theirs, label, true)
+	if err != nil {
+		return err
+	}
+	if !result.Clean() {
+		if err := writeMergeMsg(repo, message, result.Conflicts); err != nil {
+			return err
+		}
+		if err := vfs.WriteFile(repo.FS, r.stateFile, []byte(pickHash+"\n")); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "hint: after resolving the conflicts, run \"gogit %s --continue\"\n", r.command)
+		fmt.Fprintf(os.Stderr, "hint: or run \"gogit %s --abort\" to go back\n", r.command)
+		verb := "apply"
+		if r.revert {
+			verb = "revert"
+		}
+		return fmt.Errorf("could not %s %s... %s: %w", verb, pickHash[:7], pick.Subject(), errConflict)
+	}
+	if result.Tree == head.Tree {
+		return fmt.Errorf("%s of %s is empty: nothing changes in HEAD", r.command, pickHash[:7])
+	}
+	return r.commit(repo, headHash, result.Tree, pick, message)
+}
+
+func (r replay) resume(ctx context.Context, repo *gogit.Repository) error {
+	pick, err := r.readState(repo)
+	if err != nil {
+		return err
+	}
+	message, err := readMergeMsg(repo)
+	if err != nil {
+		return err
+	}
+
+	headHash, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	head, err := object.ReadCommit(repo.Objects, headHash)
+	if err != nil {
+		return err
+	}
+	if err := rerereUpdate(repo); err != nil {
+		return err
+	}
+	tree, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if tree == head.Tree {
+		return fmt.Errorf("nothing to commit: the %s became empty (use --abort to drop it)", r.command)
+	}
+	if err := r.commit(repo, headHash, tree, pick, message); err != nil {
+		return err
+	}
+	return r.removeState(repo)
+}
+
+func (r replay) abort(ctx context.Context, repo *gogit.Repository) error {
+	if _, err := r.readState(repo); err != nil {
+		return err
+	}
+	head, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	if err := resetWorkTree(ctx, repo, head); err != nil {
+		return err
+	}
+	return r.removeState(repo)
+}
+
+func (r replay) readState(repo *gogit.Repository) (*object.Commit, error) {
+	data, err := vfs.ReadFile(repo.FS, r.stateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no %s in progress", r.command)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return object.ReadCommit(repo.Objects, strings.TrimSpace(string(data)))
+}
+
+func (r replay) removeState(repo *gogit.Repository) error {
+	for _, name := range []string{r.stateFile, mergeMsg} {
+		if err := repo.FS.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// commit: HEAD 위에 새 커밋을 만들고 HEAD 를 옮긴다.
+// cherry-pick 은 원래 커밋의 작성자를, revert 는 지금 사용자를 작성자로 쓴다.
+func (r replay) commit(repo *gogit.Repository, head string, tree string, pick *object.Commit, message string) error {
+	author := pick.Author
+	if r.revert {
+		var err error
+		if author, err = repo.Author(); err != nil {
+			return err
+		}
+	}
+	hash, err := createCommit(repo, []string{head}, tree, author, message)
+	if err != nil {
+		return err
+	}
+	subject, _, _ := strings.Cut(message, "\n")
+	if err := repo.UpdateHead(hash, r.command+": "+subject); err != nil {
+		return err
+	}
+	printCommitSummary(repo, hash, message, false)
+	return nil
+}
+
+// printCommitSummary: git 과 같이 "[<브랜치> <짧은 해시>] <제목>" 을 출력한다.
+func printCommitSummary(repo *gogit.Repository, hash string, message string, root bool) {
+	branch := "detached HEAD"
+	if ref, err := repo.Refs.Read("HEAD"); err == nil && ref.Target != "" {
+		branch = strings.TrimPrefix(ref.Target, "refs/heads/")
+	}
+	if root {
+		branch += " (root-commit)"
+	}
+	subject, _, _ := strings.Cut(message, "\n")
+	fmt.Printf("[%s %s] %s\n", branch, hash[:7], subject)
+}
+
+// pickParentTree: 적용할 커밋의 부모 tree. root commit 이면 빈 문자열(빈 tree), merge commit 은 지원하지 않는다.
+func pickParentTree(repo *gogit.Repository, hash string, pick *object.Commit, command string) (string, error) {
+	switch len(pick.Parents) {
+	case 0:
+		return "", nil
+	case 1:
+		parent, err := object.ReadCommit(repo.Objects, pick.Parents[0])
+		if err != nil {
+			return "", err
+		}
+		return parent.Tree, nil
+	}
+	return "", fmt.Errorf("commit %s is a merge; %s of a merge is not supported", hash, command)
+}
+
+// requireCleanWorkTree: index 가 없으므로 작업 트리 전체가 HEAD 의 tree 와 같아야 깨끗하다.
+func requireCleanWorkTree(ctx context.Context, repo *gogit.Repository, headTree string, command string) error {
+	current, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if current != headTree {
+		return fmt.Errorf("your local changes would be overwritten by %s; commit them first", command)
+	}
+	return nil
+}
+
+// resetWorkTree: 작업 트리를 커밋의 내용으로 되돌린다. 커밋되지 않은 변경은 버려진다.
+func resetWorkTree(ctx context.Context, repo *gogit.Repository, commit string) error {
+	tree, err := repo.ResolveTree(commit)
+	if err != nil {
+		return err
+	}
+	current, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		return err
+	}
+	// 버린 충돌은 더 해결할 것이 없다
+	if err := rerere.Clear(repo.FS); err != nil {
+		return err
+	}
+	return worktree.Checkout(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, current, tree)
+}
+
+// applyChange: base 에서 theirs 로의 변경을 headTree 위에 merge 하고 결과를 작업 트리에 쓴다.
+// 작업 트리는 headTree 와 같아야 한다. git 과 같이 충돌을 출력하고, verbose 면 합친 파일도 출력한다.
+func applyChange(ctx context.Context, repo *gogit.Repository, headTree, base, theirs, label string, verbose bool) (*merge.Result, error) {
+	result, err := merge.Trees(repo.Objects, base, headTree, theirs, merge.Options{Ours: "HEAD", Theirs: label})
+	if err != nil {
+		return nil, err
+	}
+	if err := worktree.Checkout(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, headTree, result.Tree); err != nil {
+		return nil, err
+	}
+	// 경로 순서대로 "Auto-merging" 뒤에 그 파일의 충돌을 출력한다
+	conflicts := result.Conflicts
+	for _, p := range result.Merged {
+		for len(conflicts) > 0 && conflicts[0].Path < p {
+			fmt.Println(conflicts[0].Message)
+			conflicts = conflicts[1:]
+		}
+		if verbose {
+			fmt.Println("Auto-merging " + p)
+		}
+	}
+	for _, c := range conflicts {
+		fmt.Println(c.Message)
+	}
+	if !result.Clean() {
+		stream := events.FromContext(ctx)
+		paths := make([]string, len(result.Conflicts))
+		for i, c := range result.Conflicts {
+			paths[i] = c.Path
+			stream.Conflict(c.Path, c.Message)
+		}
+		if err := rerereRecord(repo, paths); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// writeMergeMsg: 메시지 뒤에 git 과 같이 "# Conflicts:" 와 충돌한 파일 목록을 붙여 MERGE_MSG 에 쓴다.
+func writeMergeMsg(repo *gogit.Repository, message string, conflicts []merge.Conflict) error {
+	var msg strings.Builder
+	msg.WriteString(message)
+	msg.WriteString("\n# Conflicts:\n")
+	for _, c := range conflicts {
+		msg.WriteString("#\t" + c.Path + "\n")
+	}
+	return vfs.WriteFile(repo.FS, mergeMsg, []byte(msg.String()))
+}
+
+// readMergeMsg: MERGE_MSG 에서 "#" 줄을 뺀 커밋 메시지
+// "#" 줄의 파일(충돌했던 파일)에 아직 충돌 표시가 남아 있으면 에러
+func readMergeMsg(repo *gogit.Repository) (string, error) {
+	data, err := vfs.ReadFile(repo.FS, mergeMsg)
+	if err != nil {
+		return "", err
+	}
+	work := vfs.NewOS(repo.WorkTree)
+	var lines []string
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+			continue
+		}
+		p, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), "#\t")
+		if !ok {
+			continue
+		}
+		content, err := vfs.ReadFile(work, p)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if hasConflictMarkers(content) {
+			return "", fmt.Errorf("%s still has conflict markers; resolve them before continuing", p)
+		}
+	}
+	return strings.TrimRight(strings.Join(lines, ""), "\n") + "\n", nil
+}
+
+// hasConflictMarkers: 줄 처음에 "<<<<<<< " 가 있는지
+func hasConflictMarkers(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "<<<<<<<") {
+			return true
+		}
+	}
+	return false
+}
+
+// createCommit: parents 위에 tree 로 새 커밋을 저장한다. 커미터는 지금 사용자이고 ref 는 바꾸지 않는다.
+func createCommit(repo *gogit.Repository, parents []string, tree string, author object.Signature, message string) (string, error) {
+	return createSignedCommit(repo, parents, tree, author, message, nil)
+}
+
+// createSignedCommit: createCommit 과 같고, signer 가 있으면 커밋에 서명한다.
+// provenance 를 켰으면 체인 값을 먼저 붙이고 서명한다. 체인 값은 서명을 빼고 계산하므로 저장할 때 다시 붙여도 서명이 맞다.
+func createSignedCommit(repo *gogit.Repository, parents []string, tree string, author object.Signature, message string, signer *signing.Signer) (string, error) {
+	committer, err := repo.Committer()
+	if err != nil {
+		return "", err
+	}
+	store, err := repo.CommitStorer()
+	if err != nil {
+		return "", err
+	}
+	c := &object.Commit{
+		Tree:      tree,
+		Parents:   parents,
+		Author:    author,
+		Committer: committer,
+		Message:   message,
+	}
+	if signer != nil {
+		enabled, err := repo.ProvenanceEnabled()
+		if err != nil {
+			return "", err
+		}
+		if enabled {
+			if err := provenance.Stamp(repo.Objects, c); err != nil {
+				return "", err
+			}
+		}
+		if err := signer.SignCommit(c); err != nil {
+			return "", err
+		}
+	}
+	return object.WriteObject(store, c)
+}
+
+// signerFor: config 의 서명 설정. key 가 있으면 user.signingKey 대신 쓴다
+func signerFor(repo *gogit.Repository, key string) (*signing.Signer, error) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := signing.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		signer.Key = key
+	}
+	return signer, nil
+}
+
+// rebase 가 진행 중일 때의 상태 디렉토리 (git 과 같은 이름)
+//
+//	head-name  rebase 하는 브랜치 (detached HEAD 면 "detached HEAD")
+//	onto       새 base 커밋
+//	orig-head  시작할 때의 HEAD
+//	todo       아직 실행하지 않은 명령, 한 줄에 "<명령> <커밋> <제목>"
+//	current    충돌로 멈춘 명령과 커밋
+//	squash     squash/fixup 으로 합치고 있는 커밋들. 합치기가 끝나면 메시지를 정하고 지운다.
+//	autostash  시작할 때 치워 둔 작업 트리 변경의 stash 커밋 (--autostash)
+const rebaseDir = "rebase-apply"
+
+// rebaseStep: todo 의 한 줄
+type rebaseStep struct {
+	command string
+	hash    string
+	// subject: 사람이 읽도록 적어 두는 커밋 제목
+	subject string
+}
+
+// isSquash: 앞의 커밋에 합치는 명령인지
+func (s rebaseStep) isSquash() bool {
+	return s.command == "squash" || s.command == "fixup"
+}
+
+// rebaseCommands: todo 에 쓸 수 있는 명령과 줄임말
+var rebaseCommands = map[string]string{
+	"pick": "pick", "p": "pick",
+	"reword": "reword", "r": "reword",
+	"squash": "squash", "s": "squash",
+	"fixup": "fixup", "f": "fixup",
+	"drop": "drop", "d": "drop",
+	"noop": "noop",
+}
+
+// Rerere: 충돌을 해결한 방법을 기록해 두었다가 같은 충돌이 다시 나면 작업 트리에 적용한다. (reuse recorded resolution)
+// rerere.enabled 가 true 이거나, 설정이 없고 .gogit/rr-cache 가 있으면 cherry-pick, revert, rebase 의 충돌과
+// commit, --continue 때 자동으로 동작한다.
+//
+//	rerere                     해결이 끝난 파일의 해결을 기록한다
+//	rerere clear               해결 중인 충돌 목록을 지운다
+//	rerere forget <pathspec>…  해결 중인 충돌의 기록된 해결을 지운다 (다음 해결을 새로 기록한다)
+//	rerere diff                기록한 preimage 와 지금 파일의 차이
+//	rerere status              preimage 를 기록한 해결 중인 파일들
+func cmdRerere(repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit rerere [clear | forget <pathspec>... | diff | status]"
+	if err := repo.RequireWorkTree("rerere"); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return rerereUpdate(repo)
+	}
+	work := vfs.NewOS(repo.WorkTree)
+	switch args[0] {
+	case "clear":
+		if len(args) != 1 {
+			return errors.New(usage)
+		}
+		return rerere.Clear(repo.FS)
+	case "forget":
+		if len(args) == 1 {
+			return errors.New("'gogit rerere forget' without paths is not supported")
+		}
+		var paths []string
+		for _, arg := range args[1:] {
+			p, err := repoPath(repo, arg)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, p)
+		}
+		msgs, err := rerere.Forget(repo.FS, pathspecMatcher(paths))
+		printRerere(msgs)
+		return err
+	case "diff":
+		if len(args) != 1 {
+			return errors.New(usage)
+		}
+		return rerere.Diff(os.Stdout, repo.FS, work)
+	case "status":
+		if len(args) != 1 {
+			return errors.New(usage)
+		}
+		entries, err := rerere.Entries(repo.FS)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			fmt.Println(e.Path)
+		}
+		return nil
+	}
+	return errors.New(usage)
+}
+
+// rerereEnabled: rerere.enabled 설정을 따르고, 설정이 없으면 rr-cache 디렉토리가 있을 때만 켠다. (git 과 동일)
+func rerereEnabled(repo *gogit.Repository) (bool, error) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return false, err
+	}
+	return cfg.GetBool("rerere.enabled", vfs.Exists(repo.FS, rerere.CacheDir))
+}
+
+// rerereRecord: 충돌한 파일들의 preimage 를 기록하고 기록된 해결이 있으면 적용한다.
+func rerereRecord(repo *gogit.Repository, paths []string) error {
+	if ok, err := rerereEnabled(repo); !ok || err != nil {
+		return err
+	}
+	msgs, err := rerere.Record(repo.FS, vfs.NewOS(repo.WorkTree), paths)
+	printRerere(msgs)
+	return err
+}
+
+// rerereUpdate: 커밋하기 전에 해결이 끝난 파일의 해결을 기록한다.
+func rerereUpdate(repo *gogit.Repository) error {
+	if ok, err := rerereEnabled(repo); !ok || err != nil {
+		return err
+	}
+	msgs, err := rerere.Update(repo.FS, vfs.NewOS(repo.WorkTree))
+	printRerere(msgs)
+	return err
+}
+
+// printRerere: git 과 같이 rerere 의 안내는 stderr 로 출력한다.
+func printRerere(msgs []string) {
+	for _, msg := range msgs {
+		fmt.Fprintln(os.Stderr, msg)
+	}
+}
+
+// Rebase: upstream..HEAD 의 커밋을 차례로 새 base 위에 다시 적용하고 브랜치를 옮긴다.
+// merge commit 은 건너뛰고, 다시 적용해서 아무것도 바뀌지 않는 커밋은 버린다.
+//
+//	rebase [-i] [--[no-]autostash] [--onto <newbase>] <upstream> [<branch>]   newbase 를 주지 않으면 upstream 위로 옮긴다
+//	rebase --continue                           작업 트리에서 충돌을 해결한 뒤 이어서 진행한다
+//	rebase --skip                               충돌한 커밋을 버리고 이어서 진행한다
+//	rebase --abort                              작업 트리와 HEAD 를 시작 전으로 되돌린다
+//
+// -i(--interactive) 는 적용할 커밋 목록을 편집기로 열어, 순서를 바꾸거나 커밋마다 명령을 고를 수 있게 한다.
+//
+//	pick    그대로 적용한다
+//	reword  적용하고 메시지를 편집기로 고친다
+//	squash  앞의 커밋에 합친다. 합치기가 끝나면 메시지들을 이어 붙여 편집기로 연다
+//	fixup   squash 와 같지만 이 커밋의 메시지는 버린다
+//	drop    적용하지 않는다 (줄을 지워도 같다)
+//
+// <branch> 를 주면 먼저 그 브랜치로 옮긴 다음 rebase 한다. --onto 와 함께 쓰면 upstream..branch 구간만
+// 다른 base 로 옮길 수 있다. (예: rebase --onto main feature-a feature-b 로 쌓인 브랜치를 떼어 낸다)
+//
+// 진행하는 동안 HEAD 는 새 base 에서 시작하는 detached HEAD 이고, 끝나면 브랜치를 옮겨 다시 가리킨다.
+// 작업 트리에 커밋하지 않은 변경이 있거나 cherry-pick, revert 가 진행 중이면 시작하지 않는다. --autostash(또는 rebase.autoStash 설정)면
+// 변경을 stash 커밋으로 치워 두었다가 rebase 가 끝나거나 --abort 할 때 다시 적용한다.
+func cmdRebase(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit rebase [-i] [--[no-]autostash] [--onto <newbase>] <upstream> [<branch>] | --continue | --skip | --abort"
+	if err := repo.RequireWorkTree("rebase"); err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		switch args[0] {
+		case "--continue":
+			return rebaseContinue(ctx, repo)
+		case "--skip":
+			return rebaseSkip(ctx, repo)
+		case "--abort":
+			return rebaseAbort(ctx, repo)
+		}
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	autostash, err := cfg.GetBool("rebase.autoStash", false)
+	if err != nil {
+		return err
+	}
+	var ontoArg, upstreamArg, branchArg string
+	interactive := false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-i" || args[i] == "--interactive":
+			interactive = true
+		case args[i] == "--autostash":
+			autostash = true
+		case args[i] == "--no-autostash":
+			autostash = false
+		case args[i] == "--onto" && i+1 < len(args):
+			i++
+			ontoArg = args[i]
+		case strings.HasPrefix(args[i], "--onto="):
+			ontoArg = strings.TrimPrefix(args[i], "--onto=")
+		case strings.HasPrefix(args[i], "-") || branchArg != "":
+			return errors.New(usage)
+		case upstreamArg != "":
+			branchArg = args[i]
+		default:
+			upstreamArg = args[i]
+		}
+	}
+	if upstreamArg == "" {
+		return errors.New(usage)
+	}
+	if vfs.Exists(repo.FS, rebaseDir) {
+		return errors.New("a rebase is already in progress (use --continue, --skip or --abort)")
+	}
+	for _, r := range []replay{cherryPick, revert} {
+		if vfs.Exists(repo.FS, r.stateFile) {
+			return fmt.Errorf("a %s is in progress; finish it or --abort it before rebasing", r.command)
+		}
+	}
+
+	upstream, err := repo.ResolveCommit(upstreamArg)
+	if err != nil {
+		return err
+	}
+	onto := upstream
+	if ontoArg != "" {
+		if onto, err = repo.ResolveCommit(ontoArg); err != nil {
+			return err
+		}
+	}
+	head, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	headTree, err := repo.ResolveTree(head)
+	if err != nil {
+		return err
+	}
+	headName := "detached HEAD"
+	if ref, err := repo.Refs.Read("HEAD"); err == nil && ref.Target != "" {
+		headName = ref.Target
+	}
+	// 옮길 커밋들의 끝. <branch> 가 로컬 브랜치면 끝날 때 그 브랜치를 옮기고, 아니면 detached HEAD 로 끝난다.
+	orig := head
+	if branchArg != "" {
+		if hash, err := repo.Refs.Resolve("refs/heads/" + branchArg); err == nil {
+			orig, headName = hash, "refs/heads/"+branchArg
+		} else if orig, err = repo.ResolveCommit(branchArg); err != nil {
+			return err
+		} else {
+			headName = "detached HEAD"
+		}
+	}
+	current, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if current != headTree && !autostash {
+		return errors.New("your local changes would be overwritten by rebase; commit them first (or use --autostash)")
+	}
+
+	// 오래된 것부터 적용한다
+	hidden, err := object.Reachable(ctx, repo.Objects, []string{upstream})
+	if err != nil {
+		return err
+	}
+	it := object.NewCommitIter(repo.Objects, []string{orig}, object.OrderTopo)
+	it.Hide(hidden)
+	var todo []rebaseStep
+	err = it.ForEachContext(ctx, func(hash string, c *object.Commit) error {
+		if len(c.Parents) <= 1 {
+			todo = append(todo, rebaseStep{command: "pick", hash: hash, subject: c.Subject()})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	slices.Reverse(todo)
+	if interactive {
+		if todo, err = editRebaseTodo(repo, todo, upstream, onto, orig); err != nil {
+			return err
+		}
+	}
+
+	state := map[string]string{"head-name": headName, "onto": onto, "orig-head": orig}
+	for name, value := range state {
+		if err := vfs.WriteFile(repo.FS, rebaseDir+"/"+name, []byte(value+"\n")); err != nil {
+			return err
+		}
+	}
+	if err := writeRebaseTodo(repo, todo); err != nil {
+		return err
+	}
+	if current != headTree {
+		stash, err := createAutostash(repo, headName, head, current)
+		if err != nil {
+			return err
+		}
+		if err := vfs.WriteFile(repo.FS, rebaseDir+"/autostash", []byte(stash+"\n")); err != nil {
+			return err
+		}
+		fmt.Printf("Created autostash: %s\n", stash[:7])
+		if err := worktree.Checkout(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, current, headTree); err != nil {
+			return err
+		}
+	}
+
+	ontoTree, err := repo.ResolveTree(onto)
+	if err != nil {
+		return err
+	}
+	if err := worktree.Checkout(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, headTree, ontoTree); err != nil {
+		return err
+	}
+	if err := repo.UpdateRef("HEAD", onto, "rebase (start): checkout "+cmp.Or(ontoArg, upstreamArg)); err != nil {
+		return err
+	}
+	runHook(repo, "post-checkout", head, onto, "1")
+	return rebaseRun(ctx, repo)
+}
+
+// editRebaseTodo: todo 를 git 과 같은 도움말과 함께 편집기로 열고, 저장된 목록을 읽는다.
+// 목록이 비었거나 잘못되었으면 rebase 를 시작하지 않는다. drop 한 커밋은 결과에서 빠진다.
+func editRebaseTodo(repo *gogit.Repository, todo []rebaseStep, upstream, onto, orig string) ([]rebaseStep, error) {
+	var b strings.Builder
+	for _, s := range todo {
+		fmt.Fprintf(&b, "%s %s %s\n", s.command, s.hash[:7], s.subject)
+	}
+	if len(todo) == 0 {
+		b.WriteString("noop\n")
+	}
+	fmt.Fprintf(&b, "\n# Rebase %s..%s onto %s (%d commands)\n", upstream[:7], orig[:7], onto[:7], max(len(todo), 1))
+	b.WriteString(`#
+# Commands:
+# p, pick <commit> = use commit
+# r, reword <commit> = use commit, but edit the commit message
+# s, squash <commit> = use commit, but meld into previous commit
+# f, fixup <commit> = like "squash" but keep only the previous
+#                    commit's log message
+# d, drop <commit> = remove commit
+#
+# These lines can be re-ordered; they are executed from top to bottom.
+#
+# If you remove a line here THAT COMMIT WILL BE LOST.
+#
+# However, if you remove everything, the rebase will be aborted.
+#
+`)
+	if err := vfs.WriteFile(repo.FS, rebaseDir+"/todo", []byte(b.String())); err != nil {
+		return nil, err
+	}
+	steps, err := readEditedTodo(repo, todo)
+	if err != nil {
+		if rmErr := removeRebaseState(repo); rmErr != nil {
+			return nil, rmErr
+		}
+		return nil, err
+	}
+	return steps, nil
+}
+
+func readEditedTodo(repo *gogit.Repository, todo []rebaseStep) ([]rebaseStep, error) {
+	if err := launchEditor(repo, rebaseDir+"/todo"); err != nil {
+		return nil, err
+	}
+	data, err := vfs.ReadFile(repo.FS, rebaseDir+"/todo")
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []rebaseStep
+	empty := true
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		empty = false
+		fields := strings.Fields(line)
+		command, ok := rebaseCommands[fields[0]]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("invalid command '%s' on line %d of the todo list", fields[0], i+1)
+		case command == "noop":
+			continue
+		case len(fields) < 2:
+			return nil, fmt.Errorf("missing commit on line %d of the todo list: %s", i+1, line)
+		}
+		hash, err := resolveTodoCommit(repo, fields[1], todo)
+		if err != nil {
+			return nil, fmt.Errorf("line %d of the todo list: %w", i+1, err)
+		}
+		if command == "drop" {
+			continue
+		}
+		step := rebaseStep{command: command, hash: hash, subject: strings.Join(fields[2:], " ")}
+		if step.isSquash() && len(steps) == 0 {
+			return nil, fmt.Errorf("cannot '%s' without a previous commit", command)
+		}
+		steps = append(steps, step)
+	}
+	if empty {
+		return nil, errors.New("nothing to do")
+	}
+	return steps, nil
+}
+
+// resolveTodoCommit: todo 의 줄인 해시를 처음 목록에 있던 커밋 중에서 찾는다. 전체 해시는 어느 커밋이든 된다.
+func resolveTodoCommit(repo *gogit.Repository, name string, todo []rebaseStep) (string, error) {
+	if object.IsHash(name) {
+		return repo.ResolveCommit(name)
+	}
+	var found string
+	for _, s := range todo {
+		if len(name) < 4 || !strings.HasPrefix(s.hash, name) {
+			continue
+		}
+		if found != "" && found != s.hash {
+			return "", fmt.Errorf("short commit %s is ambiguous", name)
+		}
+		found = s.hash
+	}
+	if found == "" {
+		return "", fmt.Errorf("%w: %s", gogit.ErrUnknownRevision, name)
+	}
+	return found, nil
+}
+
+// rebaseRun: todo 의 명령을 하나씩 HEAD 위에 실행한다. 충돌하면 current 에 기록하고 멈춘다.
+// 명령마다 "rebase" 진행 이벤트를 보낸다. 수는 이번 실행(--continue 뒤라면 그 뒤)의 명령만 센다.
+func rebaseRun(ctx context.Context, repo *gogit.Repository) error {
+	stream := events.FromContext(ctx)
+	for done := 0; ; done++ {
+		todo, err := readRebaseTodo(repo)
+		if err != nil {
+			return err
+		}
+		// squash/fixup 이 더 이어지지 않으면 합친 커밋의 메시지를 정한다
+		if len(todo) == 0 || !todo[0].isSquash() {
+			if err := finishSquash(repo); err != nil {
+				return err
+			}
+		}
+		if len(todo) == 0 {
+			return rebaseFinish(ctx, repo)
+		}
+		step := todo[0]
+		if err := writeRebaseTodo(repo, todo[1:]); err != nil {
+			return err
+		}
+		stream.Emit(events.Event{Event: "progress", Phase: "rebase", Current: done + 1, Total: done + len(todo), Commit: step.hash, Message: step.command})
+		if err := rebaseApply(ctx, repo, step); err != nil {
+			return err
+		}
+	}
+}
+
+// rebaseApply: 명령 하나를 실행한다.
+func rebaseApply(ctx context.Context, repo *gogit.Repository, step rebaseStep) error {
+	pick, err := object.ReadCommit(repo.Objects, step.hash)
+	if err != nil {
+		return err
+	}
+	head, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	// 부모가 이미 HEAD 면 다시 만들 필요 없이 그 커밋으로 넘어간다 (git 과 같은 fast-forward)
+	if step.command == "pick" && len(pick.Parents) == 1 && pick.Parents[0] == head {
+		if err := repo.UpdateRef("HEAD", step.hash, "rebase (pick): "+pick.Subject()); err != nil {
+			return err
+		}
+		return checkoutCommits(ctx, repo, head, step.hash)
+	}
+
+	parentTree, err := pickParentTree(repo, step.hash, pick, "rebase")
+	if err != nil {
+		return err
+	}
+	headTree, err := repo.ResolveTree(head)
+	if err != nil {
+		return err
+	}
+	result, err := applyChange(ctx, repo, headTree, parentTree, pick.Tree, step.hash[:7]+" ("+pick.Subject()+")", false)
+	if err != nil {
+		return err
+	}
+	if !result.Clean() {
+		if err := writeMergeMsg(repo, pick.Message, result.Conflicts); err != nil {
+			return err
+		}
+		if err := vfs.WriteFile(repo.FS, rebaseDir+"/current", []byte(step.command+" "+step.hash+"\n")); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "hint: resolve all conflicts in the work tree, then run \"gogit rebase --continue\"")
+		fmt.Fprintln(os.Stderr, "hint: to drop this commit instead, run \"gogit rebase --skip\"")
+		fmt.Fprintln(os.Stderr, "hint: to go back to where you started, run \"gogit rebase --abort\"")
+		return fmt.Errorf("could not apply %s... %s: %w", step.hash[:7], pick.Subject(), errConflict)
+	}
+	if result.Tree == headTree && !step.isSquash() {
+		fmt.Printf("dropping %s %s -- patch contents already upstream\n", step.hash, pick.Subject())
+		return nil
+	}
+	return rebaseCommit(repo, step, pick, head, result.Tree, pick.Message)
+}
+
+// rebaseCommit: 적용한 결과 tree 로 HEAD 위에 커밋을 만든다.
+// reword 는 메시지를 편집기로 고치고, squash/fixup 은 새 커밋 대신 HEAD 커밋을 고쳐 쓴다.
+// (합친 메시지는 합치기가 끝날 때 finishSquash 가 정한다)
+func rebaseCommit(repo *gogit.Repository, step rebaseStep, pick *object.Commit, head, tree, message string) error {
+	var hash string
+	var err error
+	switch step.command {
+	case "squash", "fixup":
+		if err := addSquash(repo, step, head); err != nil {
+			return err
+		}
+		hash, err = amendCommit(repo, head, tree, "", nil)
+	case "reword":
+		if message, err = editMessage(repo, message, ""); err != nil {
+			return err
+		}
+		fallthrough
+	default:
+		hash, err = createCommit(repo, []string{head}, tree, pick.Author, message)
+	}
+	if err != nil {
+		return err
+	}
+	return repo.UpdateRef("HEAD", hash, "rebase ("+step.command+"): "+pick.Subject())
+}
+
+// amendCommit: commit 을 tree 와 message 로 바꾼 커밋을 commit 의 부모들 위에 저장한다.
+// 작성자는 그대로이고 커미터만 지금 사용자로 바뀐다. tree 나 message 가 빈 문자열이면 원래 것을 쓴다.
+// 원래 서명은 내용이 바뀌므로 버리고, signer 가 있으면 다시 서명한다.
+func amendCommit(repo *gogit.Repository, commit, tree, message string, signer *signing.Signer) (string, error) {
+	c, err := object.ReadCommit(repo.Objects, commit)
+	if err != nil {
+		return "", err
+	}
+	if tree == "" {
+		tree = c.Tree
+	}
+	if message == "" {
+		message = c.Message
+	}
+	return createSignedCommit(repo, c.Parents, tree, c.Author, message, signer)
+}
+
+// addSquash: squash 파일에 합칠 커밋을 기록한다. 처음이면 합쳐질 HEAD 커밋부터 적는다.
+func addSquash(repo *gogit.Repository, step rebaseStep, head string) error {
+	data, err := vfs.ReadFile(repo.FS, rebaseDir+"/squash")
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = []byte("pick "+head+"\n"), nil
+	}
+	if err != nil {
+		return err
+	}
+	data = append(data, step.command+" "+step.hash+"\n"...)
+	return vfs.WriteFile(repo.FS, rebaseDir+"/squash", data)
+}
+
+// finishSquash: squash 가 하나라도 있었으면 합친 커밋들의 메시지를 git 과 같은 형식으로 이어 붙여 편집기로 열고,
+// 고친 메시지로 HEAD 를 다시 쓴다. fixup 만 있었으면 HEAD 에 이미 첫 커밋의 메시지가 있다.
+func finishSquash(repo *gogit.Repository) error {
+	data, err := vfs.ReadFile(repo.FS, rebaseDir+"/squash")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if slices.ContainsFunc(lines, func(l string) bool { return strings.HasPrefix(l, "squash ") }) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "# This is a combination of %d commits.\n", len(lines))
+		for i, line := range lines {
+			command, hash, _ := strings.Cut(line, " ")
+			c, err := object.ReadCommit(repo.Objects, hash)
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			message := strings.TrimRight(c.Message, "\n")
+			switch {
+			case i == 0:
+				b.WriteString("# This is the 1st commit message:\n\n")
+			case command == "fixup":
+				fmt.Fprintf(&b, "# The commit message #%d will be skipped:\n\n", i+1)
+				message = "# " + strings.ReplaceAll(message, "\n", "\n# ")
+			default:
+				fmt.Fprintf(&b, "# This is the commit message #%d:\n\n", i+1)
+			}
+			b.WriteString(message + "\n")
+		}
+		message, err := editMessage(repo, b.String(), "")
+		if err != nil {
+			return err
+		}
+		head, err := repo.ResolveCommit("HEAD")
+		if err != nil {
+			return err
+		}
+		hash, err := amendCommit(repo, head, "", message, nil)
+		if err != nil {
+			return err
+		}
+		subject, _, _ := strings.Cut(message, "\n")
+		if err := repo.UpdateRef("HEAD", hash, "rebase (squash): "+subject); err != nil {
+			return err
+		}
+	}
+	return repo.FS.Remove(rebaseDir + "/squash")
+}
+
+// checkoutCommits: 작업 트리를 from 커밋의 내용에서 to 커밋의 내용으로 바꾼다.
+func checkoutCommits(ctx context.Context, repo *gogit.Repository, from, to string) error {
+	fromTree, err := repo.ResolveTree(from)
+	if err != nil {
+		return err
+	}
+	toTree, err := repo.ResolveTree(to)
+	if err != nil {
+		return err
+	}
+	return worktree.Checkout(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, fromTree, toTree)
+}
+
+// rebaseFinish: 브랜치를 지금의 HEAD 로 옮기고 HEAD 가 다시 브랜치를 가리키게 한 뒤 상태를 지운다.
+func rebaseFinish(ctx context.Context, repo *gogit.Repository) error {
+	headName, err := readRebaseFile(repo, "head-name")
+	if err != nil {
+		return err
+	}
+	orig, err := readRebaseFile(repo, "orig-head")
+	if err != nil {
+		return err
+	}
+	head, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+
+	if headName != "detached HEAD" {
+		onto, err := readRebaseFile(repo, "onto")
+		if err != nil {
+			return err
+		}
+		if err := repo.UpdateRef(headName, head, "rebase (finish): "+headName+" onto "+onto); err != nil {
+			return err
+		}
+		if err := repo.SwitchHead(headName, "rebase (finish): returning to "+headName); err != nil {
+			return err
+		}
+	}
+	stash, err := readRebaseFile(repo, "autostash")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := removeRebaseState(repo); err != nil {
+		return err
+	}
+	if err := applyAutostash(ctx, repo, stash); err != nil {
+		return err
+	}
+	if head == orig {
+		fmt.Printf("Current branch %s is up to date.\n", strings.TrimPrefix(headName, "refs/heads/"))
+		return nil
+	}
+	fmt.Printf("Successfully rebased and updated %s.\n", headName)
+	return nil
+}
+
+// createAutostash: 작업 트리의 변경을 git stash 와 같은 모양의 커밋으로 저장한다.
+// index 커밋(HEAD 의 tree 그대로)과 HEAD 를 부모로 하고 tree 는 작업 트리의 스냅샷이다.
+func createAutostash(repo *gogit.Repository, headName, head, tree string) (string, error) {
+	commit, err := object.ReadCommit(repo.Objects, head)
+	if err != nil {
+		return "", err
+	}
+	committer, err := repo.Committer()
+	if err != nil {
+		return "", err
+	}
+	branch := strings.TrimPrefix(headName, "refs/heads/")
+	if headName == "detached HEAD" {
+		branch = "(no branch)"
+	}
+	index, err := createCommit(repo, []string{head}, commit.Tree, committer,
+		fmt.Sprintf("index on %s: %s %s\n", branch, head[:7], commit.Subject()))
+	if err != nil {
+		return "", err
+	}
+	return createCommit(repo, []string{head, index}, tree, committer, "On "+branch+": autostash\n")
+}
+
+// applyAutostash: 치워 둔 변경을 지금 HEAD 위에 3-way merge 로 다시 적용한다. stash 가 빈 문자열이면 할 일이 없다.
+// git 과 같이 충돌하면 작업 트리는 건드리지 않고 stash 를 refs/stash 에 남긴다.
+func applyAutostash(ctx context.Context, repo *gogit.Repository, stash string) error {
+	if stash == "" {
+		return nil
+	}
+	commit, err := object.ReadCommit(repo.Objects, stash)
+	if err != nil {
+		return err
+	}
+	base, err := repo.ResolveTree(commit.Parents[0])
+	if err != nil {
+		return err
+	}
+	headTree, err := repo.ResolveTree("HEAD")
+	if err != nil {
+		return err
+	}
+	result, err := merge.Trees(repo.Objects, base, headTree, commit.Tree, merge.Options{Ours: "Updated upstream", Theirs: "Stashed changes"})
+	if err != nil {
+		return err
+	}
+	if !result.Clean() {
+		if err := repo.UpdateRef("refs/stash", stash, "autostash"); err != nil {
+			return err
+		}
+		fmt.Println("Applying autostash resulted in conflicts.")
+		fmt.Println("Your changes are safe in the stash (refs/stash).")
+		fmt.Println("You can run \"gogit stash pop\" at any time.")
+		return nil
+	}
+	if err := worktree.Checkout(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, headTree, result.Tree); err != nil {
+		return err
+	}
+	fmt.Println("Applied autostash.")
+	return nil
+}
+
+// Stash: autostash 가 충돌해서 refs/stash 에 남긴 변경을 작업 트리로 되살린다.
+//
+//	stash apply [<stash>]
+//	stash pop [<stash>]
+//
+// stash 의 기본값은 refs/stash 다. stash 커밋의 첫 부모에서 stash 까지의 변경을 HEAD 위에 3-way merge 로 적용한다.
+// 작업 트리는 HEAD 와 같아야 한다. 충돌하면 충돌 표시를 남기고, pop 이어도 git 과 같이 stash 를 지우지 않는다.
+// pop 은 refs/stash 를 reflog 의 이전 stash 로 되돌린다. 새 stash 를 만드는 명령은 아직 없다.
+func cmdStash(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit stash (apply | pop) [<stash>]"
+	if len(args) == 0 || len(args) > 2 || args[0] != "apply" && args[0] != "pop" {
+		return errors.New(usage)
+	}
+	if err := repo.RequireWorkTree("stash " + args[0]); err != nil {
+		return err
+	}
+	rev := "refs/stash"
+	if len(args) == 2 {
+		rev = args[1]
+	}
+	if err := stashApply(ctx, repo, rev); err != nil {
+		if args[0] == "pop" && errors.Is(err, errConflict) {
+			fmt.Println("The stash entry is kept in case you need it again.")
+		}
+		return err
+	}
+	if args[0] == "pop" && rev == "refs/stash" {
+		return stashDrop(repo)
+	}
+	return nil
+}
+
+// stashApply: stash 커밋의 변경을 HEAD 위에 merge 해서 작업 트리에 쓴다. 충돌하면 errConflict
+func stashApply(ctx context.Context, repo *gogit.Repository, rev string) error {
+	stash, err := repo.ResolveCommit(rev)
+	if err != nil {
+		return err
+	}
+	commit, err := object.ReadCommit(repo.Objects, stash)
+	if err != nil {
+		return err
+	}
+	if len(commit.Parents) == 0 {
+		return fmt.Errorf("%s is not a stash commit", rev)
+	}
+	base, err := repo.ResolveTree(commit.Parents[0])
+	if err != nil {
+		return err
+	}
+	headTree, err := repo.ResolveTree("HEAD")
+	if err != nil {
+		return err
+	}
+	if err := requireCleanWorkTree(ctx, repo, headTree, "stash apply"); err != nil {
+		return err
+	}
+	result, err := applyChange(ctx, repo, headTree, base, commit.Tree, "Stashed changes", false)
+	if err != nil {
+		return err
+	}
+	if !result.Clean() {
+		return fmt.Errorf("could not apply %s: %w", stash[:7], errConflict)
+	}
+	return nil
+}
+
+// stashDrop: refs/stash 를 reflog 의 이전 stash 로 되돌리고 마지막 기록을 지운다. 이전 stash 가 없으면 ref 를 지운다.
+func stashDrop(repo *gogit.Repository) error {
+	const ref = "refs/stash"
+	stash, err := repo.Refs.Resolve(ref)
+	if err != nil {
+		return err
+	}
+	entries, err := refs.ReadReflog(repo.Refs, ref)
+	if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		return err
+	}
+	if len(entries) < 2 {
+		if err := refs.Delete(repo.Refs, ref); err != nil {
+			return err
+		}
+	} else {
+		if err := repo.Refs.Update(ref, entries[len(entries)-1].Old); err != nil {
+			return err
+		}
+		i := 0
+		_, err := refs.ExpireReflog(repo.Refs, ref, func(refs.ReflogEntry) bool {
+			i++
+			return i < len(entries)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Dropped %s (%s)\n", ref, stash[:7])
+	return nil
+}
+
+func rebaseContinue(ctx context.Context, repo *gogit.Repository) error {
+	if !vfs.Exists(repo.FS, rebaseDir) {
+		return errors.New("no rebase in progress")
+	}
+	current, err := readRebaseFile(repo, "current")
+	if errors.Is(err, fs.ErrNotExist) {
+		return rebaseRun(ctx, repo)
+	}
+	if err != nil {
+		return err
+	}
+	command, hash, _ := strings.Cut(current, " ")
+	step := rebaseStep{command: command, hash: hash}
+	pick, err := object.ReadCommit(repo.Objects, step.hash)
+	if err != nil {
+		return err
+	}
+	message, err := readMergeMsg(repo)
+	if err != nil {
+		return err
+	}
+
+	head, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	headTree, err := repo.ResolveTree(head)
+	if err != nil {
+		return err
+	}
+	if err := rerereUpdate(repo); err != nil {
+		return err
+	}
+	tree, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		return err
+	}
+	// 충돌을 풀어 보니 바뀐 것이 없으면 그 커밋은 버린다
+	if tree != headTree || step.isSquash() {
+		if err := rebaseCommit(repo, step, pick, head, tree, message); err != nil {
+			return err
+		}
+	}
+	if err := removeRebaseCurrent(repo); err != nil {
+		return err
+	}
+	return rebaseRun(ctx, repo)
+}
+
+func rebaseSkip(ctx context.Context, repo *gogit.Repository) error {
+	if !vfs.Exists(repo.FS, rebaseDir) {
+		return errors.New("no rebase in progress")
+	}
+	head, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	if err := resetWorkTree(ctx, repo, head); err != nil {
+		return err
+	}
+	if err := removeRebaseCurrent(repo); err != nil {
+		return err
+	}
+	return rebaseRun(ctx, repo)
+}
+
+func rebaseAbort(ctx context.Context, repo *gogit.Repository) error {
+	if !vfs.Exists(repo.FS, rebaseDir) {
+		return errors.New("no rebase in progress")
+	}
+	headName, err := readRebaseFile(repo, "head-name")
+	if err != nil {
+		return err
+	}
+	orig, err := readRebaseFile(repo, "orig-head")
+	if err != nil {
+		return err
+	}
+	if err := resetWorkTree(ctx, repo, orig); err != nil {
+		return err
+	}
+	// 브랜치는 끝날 때만 옮기므로 HEAD 만 되돌리면 된다
+	if headName == "detached HEAD" {
+		err = repo.UpdateRef("HEAD", orig, "rebase (abort): returning to "+orig)
+	} else {
+		err = repo.SwitchHead(headName, "rebase (abort): returning to "+headName)
+	}
+	if err != nil {
+		return err
+	}
+	if err := removeRebaseCurrent(repo); err != nil {
+		return err
+	}
+	stash, err := readRebaseFile(repo, "autostash")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := removeRebaseState(repo); err != nil {
+		return err
+	}
+	return applyAutostash(ctx, repo, stash)
+}
+
+func readRebaseFile(repo *gogit.Repository, name string) (string, error) {
+	data, err := vfs.ReadFile(repo.FS, rebaseDir+"/"+name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readRebaseTodo(repo *gogit.Repository) ([]rebaseStep, error) {
+	data, err := vfs.ReadFile(repo.FS, rebaseDir+"/todo")
+	if err != nil {
+		return nil, err
+	}
+	var todo []rebaseStep
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		step := rebaseStep{command: fields[0], hash: fields[1]}
+		if len(fields) == 3 {
+			step.subject = fields[2]
+		}
+		todo = append(todo, step)
+	}
+	return todo, nil
+}
+
+func writeRebaseTodo(repo *gogit.Repository, todo []rebaseStep) error {
+	var b strings.Builder
+	for _, s := range todo {
+		fmt.Fprintf(&b, "%s %s %s\n", s.command, s.hash, s.subject)
+	}
+	return vfs.WriteFile(repo.FS, rebaseDir+"/todo", []byte(b.String()))
+}
+
+func removeRebaseCurrent(repo *gogit.Repository) error {
+	for _, name := range []string{rebaseDir + "/current", mergeMsg} {
+		if err := repo.FS.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeRebaseState(repo *gogit.Repository) error {
+	entries, err := repo.FS.ReadDir(rebaseDir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := repo.FS.Remove(rebaseDir + "/" + e.Name()); err != nil {
+			return err
+		}
+	}
+	return repo.FS.Remove(rebaseDir)
+}
+
+// commitEditMsg: 편집기로 메시지를 고칠 때 쓰는 파일 (git 과 같은 이름)
+const commitEditMsg = "COMMIT_EDITMSG"
+
+// commitHelp: 편집기로 여는 메시지 아래에 붙이는 도움말
+const commitHelp = "\n# Please enter the commit message for your changes. Lines starting\n" +
+	"# with '#' will be ignored, and an empty message aborts the commit.\n"
+
+// editMessage: 메시지와 도움말, comments(주석 줄들)를 COMMIT_EDITMSG 에 써서 편집기로 열고,
+// 저장된 내용에서 주석과 공백을 정리한 메시지를 돌려준다. 정리한 메시지가 비어 있으면 에러
+func editMessage(repo *gogit.Repository, message string, comments string) (string, error) {
+	if err := vfs.WriteFile(repo.FS, commitEditMsg, []byte(message+commitHelp+comments)); err != nil {
+		return "", err
+	}
+	if err := launchEditor(repo, commitEditMsg); err != nil {
+		return "", err
+	}
+	data, err := vfs.ReadFile(repo.FS, commitEditMsg)
+	if err != nil {
+		return "", err
+	}
+	message = cleanupMessage(string(data), true)
+	if message == "" {
+		return "", errors.New("aborting commit due to empty commit message")
+	}
+	return message, nil
+}
+
+// cleanupMessage: git 의 메시지 정리와 같이 줄 끝 공백을 지우고, 이어진 빈 줄을 하나로 줄이고, 앞뒤 빈 줄을 없앤다.
+// stripComments 면 "#" 줄도 지운다. (편집기로 쓴 메시지)
+func cleanupMessage(message string, stripComments bool) string {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if stripComments && strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// launchEditor: 저장소 디렉토리 안의 파일을 편집기로 열고 닫힐 때까지 기다린다.
+// git 과 같이 셸로 실행하므로 "code --wait" 처럼 인자가 있는 편집기도 쓸 수 있다.
+func launchEditor(repo *gogit.Repository, name string) error {
+	editor, err := repo.Editor()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, filepath.Join(repo.GogitDir, name))
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("there was a problem with the editor '%s': %w", editor, err)
+	}
+	return nil
+}
+
+// Archive: tree 를 tar 나 zip 으로 내보낸다. 작업 트리가 없어도 된다.
+//
+//	archive [--format=tar|zip] [--prefix=<prefix>/] [-o <file>] <tree-ish>
+//
+// 커밋을 주면 모든 항목의 시간이 committer 시간이 되어 언제 만들어도 같은 아카이브가 나온다.
+// tree 를 직접 주면 지금 시간을 쓴다. --format 이 없으면 -o 파일의 확장자로 고르고, 그것도 없으면 tar
+func cmdArchive(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit archive [--format=tar|zip] [--prefix=<prefix>/] [-o <file>] <tree-ish>"
+	var opts archive.Options
+	var output, rev string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--format" && i+1 < len(args):
+			i++
+			opts.Format = archive.Format(args[i])
+		case strings.HasPrefix(arg, "--format="):
+			opts.Format = archive.Format(strings.TrimPrefix(arg, "--format="))
+		case arg == "--prefix" && i+1 < len(args):
+			i++
+			opts.Prefix = args[i]
+		case strings.HasPrefix(arg, "--prefix="):
+			opts.Prefix = strings.TrimPrefix(arg, "--prefix=")
+		case (arg == "-o" || arg == "--output") && i+1 < len(args):
+			i++
+			output = args[i]
+		case strings.HasPrefix(arg, "--output="):
+			output = strings.TrimPrefix(arg, "--output=")
+		case strings.HasPrefix(arg, "-") || rev != "":
+			return errors.New(usage)
+		default:
+			rev = arg
+		}
+	}
+	if rev == "" {
+		return errors.New(usage)
+	}
+	if opts.Format == "" {
+		opts.Format = archive.Tar
+		if f, ok := archive.FormatFor(output); ok {
+			opts.Format = f
+		}
+	}
+	if _, err := archive.ParseFormat(string(opts.Format)); err != nil {
+		return err
+	}
+
+	hash, err := repo.ResolveRevision(rev)
+	if err != nil {
+		return err
+	}
+	var tree string
+	for tree == "" {
+		obj, err := object.ReadObject(repo.Objects, hash)
+		if err != nil {
+			return err
+		}
+		switch o := obj.(type) {
+		case *object.Tag:
+			hash = o.Object
+		case *object.Commit:
+			tree, opts.Commit, opts.ModTime = o.Tree, hash, o.Committer.When
+		case *object.Tree:
+			tree, opts.ModTime = hash, time.Now()
+		default:
+			return fmt.Errorf("%w: %s is a %s, not a tree-ish", object.ErrWrongType, hash, obj.Type())
+		}
+	}
+
+	if output == "" {
+		w := bufio.NewWriter(os.Stdout)
+		if err := archive.Write(ctx, w, repo.Objects, tree, opts); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = archive.Write(ctx, w, repo.Objects, tree, opts)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// 반쯤 쓴 아카이브를 남기지 않는다
+		os.Remove(output)
+	}
+	return err
+}
+
+// Bundle: 저장소의 일부를 파일 하나로 옮긴다. (git bundle v2)
+//
+//	bundle create [--anonymous] <file> <rev>...   <rev> 는 ref 이름, A..B, ^A, --all. <file> 이 - 면 표준 출력
+//	bundle verify <file>            담긴 ref 와 필요한 커밋(prerequisite)을 보여 주고, 모두 있는지 확인한다
+//	bundle list-heads <file>
+//	bundle unbundle <file>          객체만 들여오고 담긴 ref 를 출력한다. (ref 는 바꾸지 않는다)
+//
+// bundle 파일의 경로는 clone 과 fetch 의 원격 주소로 쓸 수 있다.
+//
+// --anonymous 는 인증 없이 내려받는 사람에게 줄 읽기 전용 배포본을 만든다. config 의 export.hidePath 에 적은
+// glob(전체 경로나 파일 이름 중 하나라도 맞으면)에 있는 blob 은 pack 에 넣지 않는다. 커밋과 tree 는 그대로라
+// 이력의 해시는 바뀌지 않고, 받는 쪽에는 그 파일의 내용만 없다.
+//
+//	[export]
+//		hidePath = *.key
+//		hidePath = secrets/*
+func cmdBundle(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit bundle (create [--anonymous] <file> <rev>... | verify <file> | list-heads <file> | unbundle <file>)"
+	if len(args) < 2 || args[0] != "create" && len(args) != 2 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "create":
+		if args[1] == "--anonymous" {
+			if len(args) < 3 {
+				return errors.New(usage)
+			}
+			filter, err := exportFilter(repo)
+			if err != nil {
+				return err
+			}
+			return bundleCreate(ctx, repo, args[2], args[3:], filter)
+		}
+		return bundleCreate(ctx, repo, args[1], args[2:], nil)
+	case "verify":
+		return bundleVerify(repo, args[1])
+	case "list-heads":
+		h, f, err := openBundle(args[1])
+		if err != nil {
+			return err
+		}
+		f.Close()
+		printBundleRefs(h)
+		return nil
+	case "unbundle":
+		h, err := unbundle(repo, args[1])
+		if err != nil {
+			return err
+		}
+		printBundleRefs(h)
+		return nil
+	}
+	return errors.New(usage)
+}
+
+// exportFilter: export.hidePath 의 glob 에 맞는 경로의 blob 을 빼는 filter
+func exportFilter(repo *gogit.Repository) (object.BlobFilter, error) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, err
+	}
+	globs := cfg.GetAll("export.hidePath")
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid export.hidePath %q: %w", glob, err)
+		}
+	}
+	return func(p string, _ object.Mode) bool {
+		for _, glob := range globs {
+			if ok, _ := path.Match(glob, p); ok {
+				return false
+			}
+			if ok, _ := path.Match(glob, path.Base(p)); ok {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func bundleCreate(ctx context.Context, repo *gogit.Repository, file string, args []string, filter object.BlobFilter) error {
+	var include []bundle.Ref
+	var exclude []string
+	addRef := func(rev string) error {
+		if rev == "" {
+			rev = "HEAD"
+		}
+		name, err := fullRefName(repo, rev)
+		if err != nil {
+			return fmt.Errorf("%s is not a ref; a bundle can only record refs: %w", rev, err)
+		}
+		hash, err := repo.Refs.Resolve(name)
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(include, func(r bundle.Ref) bool { return r.Name == name }) {
+			include = append(include, bundle.Ref{Name: name, Hash: hash})
+		}
+		return nil
+	}
+	addExclude := func(rev string) error {
+		if rev == "" {
+			rev = "HEAD"
+		}
+		hash, err := repo.ResolveCommit(rev)
+		if err != nil {
+			return err
+		}
+		exclude = append(exclude, hash)
+		return nil
+	}
+	for _, arg := range args {
+		var err error
+		if left, right, ok := strings.Cut(arg, ".."); ok {
+			if err = addExclude(left); err == nil {
+				err = addRef(right)
+			}
+		} else if rev, ok := strings.CutPrefix(arg, "^"); ok {
+			err = addExclude(rev)
+		} else if arg == "--all" {
+			list, lerr := repo.Refs.List()
+			if lerr != nil {
+				return lerr
+			}
+			for _, ref := range list {
+				if ref.Target == "" {
+					include = append(include, bundle.Ref{Name: ref.Name, Hash: ref.Hash})
+				}
+			}
+			err = addRef("HEAD")
+		} else if strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("unknown option '%s'", arg)
+		} else {
+			err = addRef(arg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(include) == 0 {
+		return errors.New("refusing to create empty bundle")
+	}
+
+	if file == "-" {
+		_, err := bundle.CreateFiltered(ctx, os.Stdout, repo.Objects, include, exclude, filter)
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	_, err = bundle.CreateFiltered(ctx, f, repo.Objects, include, exclude, filter)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(file)
+	}
+	return err
+}
+
+// openBundle: 헤더를 읽고 pack 의 시작에 멈춘 파일을 돌려준다.
+func openBundle(file string) (*bundle.Header, *bundleFile, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	bf := &bundleFile{Reader: bufio.NewReader(f), f: f}
+	h, err := bundle.ReadHeader(bf.Reader)
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return h, bf, nil
+}
+
+type bundleFile struct {
+	*bufio.Reader
+	f *os.File
+}
+
+func (b *bundleFile) Close() error { return b.f.Close() }
+
+func bundleVerify(repo *gogit.Repository, file string) error {
+	h, f, err := openBundle(file)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	if missing := h.Missing(repo.Objects); len(missing) > 0 {
+		var b strings.Builder
+		b.WriteString("Repository lacks these prerequisite commits:")
+		for _, p := range missing {
+			fmt.Fprintf(&b, "\n%s %s", p.Hash, p.Comment)
+		}
+		return errors.New(b.String())
+	}
+	if len(h.Refs) == 1 {
+		fmt.Println("The bundle contains this ref:")
+	} else {
+		fmt.Printf("The bundle contains these %d refs:\n", len(h.Refs))
+	}
+	printBundleRefs(h)
+	switch len(h.Prerequisites) {
+	case 0:
+		fmt.Println("The bundle records a complete history.")
+	case 1:
+		fmt.Println("The bundle requires this ref:")
+	default:
+		fmt.Printf("The bundle requires these %d refs:\n", len(h.Prerequisites))
+	}
+	for _, p := range h.Prerequisites {
+		fmt.Printf("%s %s\n", p.Hash, p.Comment)
+	}
+	fmt.Printf("The bundle uses this hash algorithm: %s\n", h.Algorithm.Name)
+	fmt.Fprintf(os.Stderr, "%s is okay\n", file)
+	return nil
+}
+
+func printBundleRefs(h *bundle.Header) {
+	for _, ref := range h.Refs {
+		fmt.Printf("%s %s\n", ref.Hash, ref.Name)
+	}
+}
+
+// unbundle: prerequisite 를 확인하고 pack 을 objects/pack 에 넣는다.
+func unbundle(repo *gogit.Repository, file string) (*bundle.Header, error) {
+	store, ok := repo.Objects.(*object.Store)
+	if !ok {
+		return nil, fmt.Errorf("unbundle: object store cannot index packs: %w", errors.ErrUnsupported)
+	}
+	h, f, err := openBundle(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if h.Algorithm != repo.Objects.Algorithm() {
+		return nil, fmt.Errorf("%s: bundle uses the %s object format, but the repository uses %s", file, h.Algorithm.Name, repo.Objects.Algorithm().Name)
+	}
+	if missing := h.Missing(repo.Objects); len(missing) > 0 {
+		return nil, fmt.Errorf("repository lacks prerequisite commit %s %s", missing[0].Hash, missing[0].Comment)
+	}
+	if _, err := store.IndexPack(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return h, nil
+}
+
+// Remote: config 의 remote 설정을 보고 고친다.
+//
+//	remote [-v]                                                  remote 이름 (-v 면 가져오고 보낼 url 과 함께)
+//	remote add <name> <url>                                      remote 를 만든다 (refs/remotes/<name>/ 로 가져온다)
+//	remote remove <name>                                         설정과 remote-tracking 브랜치를 지운다
+//	remote set-url [--push] [--add | --delete] <name> <url> [<old>]
+//	                                                             url 을 바꾼다. <old> 를 주면 정규식이 맞는 url 을 바꾼다
+//	                                                             --push 는 pushurl 을, --add 는 하나 더, --delete 는 맞는 것을 지운다
+//	remote get-url [--push] [--all] <name>                       insteadOf 를 적용한 url
+//
+// pushurl 을 여러 개 두면 push 할 때 모두에 보낸다. (미러)
+func cmdRemote(repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit remote [-v | add <name> <url> | remove <name> | set-url [--push] [--add | --delete] <name> <url> [<old>] | get-url [--push] [--all] <name>]"
+	if len(args) == 0 || args[0] == "-v" || args[0] == "--verbose" {
+		if len(args) > 1 {
+			return errors.New(usage)
+		}
+		return remoteList(repo, len(args) == 1)
+	}
+	sub, args := args[0], args[1:]
+	var push, add, del, all bool
+	var positional []string
+	for _, arg := range args {
+		switch {
+		case arg == "--push" && (sub == "set-url" || sub == "get-url"):
+			push = true
+		case arg == "--add" && sub == "set-url":
+			add = true
+		case arg == "--delete" && sub == "set-url":
+			del = true
+		case arg == "--all" && sub == "get-url":
+			all = true
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	switch {
+	case sub == "add" && len(positional) == 2:
+		return remoteAdd(repo, positional[0], positional[1])
+	case (sub == "remove" || sub == "rm") && len(positional) == 1:
+		return remoteRemove(repo, positional[0])
+	case sub == "set-url" && !(add && del) && (len(positional) == 2 || len(positional) == 3 && !add && !del):
+		return remoteSetURL(repo, positional, push, add, del)
+	case sub == "get-url" && len(positional) == 1:
+		remote, err := repo.Remote(positional[0])
+		if err != nil {
+			return err
+		}
+		urls := remote.URLs
+		if push {
+			urls = remote.PushURLs
+		}
+		if !all {
+			urls = urls[:1]
+		}
+		for _, u := range urls {
+			fmt.Println(u)
+		}
+		return nil
+	}
+	return errors.New(usage)
+}
+
+func remoteList(repo *gogit.Repository, verbose bool) error {
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	names := cfg.Subsections("remote")
+	slices.Sort(names)
+	for _, name := range names {
+		if !verbose {
+			fmt.Println(name)
+			continue
+		}
+		remote, err := repo.Remote(name)
+		if errors.Is(err, gogit.ErrNoSuchRemote) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\t%s (fetch)\n", name, remote.URLs[0])
+		for _, u := range remote.PushURLs {
+			fmt.Printf("%s\t%s (push)\n", name, u)
+		}
+	}
+	return nil
+}
+
+func remoteAdd(repo *gogit.Repository, name, url string) error {
+	// refs/remotes/<name>/ 아래에 ref 를 만들 수 있는 이름이어야 한다
+	if name == "" || strings.ContainsAny(name, " \t\n*?[:\\^~") || strings.Contains(name, "..") ||
+		strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".lock") {
+		return fmt.Errorf("'%s' is not a valid remote name", name)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(cfg.Subsections("remote"), name) {
+		return fmt.Errorf("remote %s already exists", name)
+	}
+	spec := refs.Refspec{Force: true, Src: "refs/heads/*", Dst: "refs/remotes/" + name + "/*"}
+	return repo.EditConfig(func(data []byte) ([]byte, error) {
+		data, err := config.Add(data, "remote."+name+".url", url)
+		if err != nil {
+			return nil, err
+		}
+		return config.Add(data, "remote."+name+".fetch", spec.String())
+	})
+}
+
+func remoteRemove(repo *gogit.Repository, name string) error {
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(cfg.Subsections("remote"), name) {
+		return fmt.Errorf("%w '%s'", gogit.ErrNoSuchRemote, name)
+	}
+	// 이 remote 를 따라가던 브랜치는 더 따라갈 곳이 없다
+	var branches []string
+	for _, b := range cfg.Subsections("branch") {
+		if v, _ := cfg.Get("branch." + b + ".remote"); v == name {
+			branches = append(branches, b)
+		}
+	}
+	err = repo.EditConfig(func(data []byte) ([]byte, error) {
+		data, _, err := config.RemoveSection(data, "remote", name)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range branches {
+			for _, key := range []string{"remote", "merge"} {
+				if data, _, err = config.Unset(data, "branch."+b+"."+key, nil); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+	list, err := repo.Refs.List()
+	if err != nil {
+		return err
+	}
+	for _, ref := range list {
+		if strings.HasPrefix(ref.Name, "refs/remotes/"+name+"/") {
+			if err := refs.Delete(repo.Refs, ref.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// remoteSetURL: positional 은 <name> <url> [<old>]. --delete 면 <url> 이 지울 url 의 정규식이다
+func remoteSetURL(repo *gogit.Repository, positional []string, push, add, del bool) error {
+	name, url := positional[0], positional[1]
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(cfg.Subsections("remote"), name) {
+		return fmt.Errorf("%w '%s'", gogit.ErrNoSuchRemote, name)
+	}
+	key := "remote." + name + ".url"
+	if push {
+		key = "remote." + name + ".pushurl"
+	}
+	current := cfg.GetAll(key)
+
+	return repo.EditConfig(func(data []byte) ([]byte, error) {
+		switch {
+		case add:
+			return config.Add(data, key, url)
+		case del:
+			re, err := regexp.Compile(url)
+			if err != nil {
+				return nil, err
+			}
+			data, n, err := config.Unset(data, key, re.MatchString)
+			if err != nil {
+				return nil, err
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("no such URL found: %s", url)
+			}
+			if !push && n == len(current) {
+				return nil, errors.New("will not delete all non-push URLs")
+			}
+			return data, nil
+		case len(positional) == 3:
+			re, err := regexp.Compile(positional[2])
+			if err != nil {
+				return nil, err
+			}
+			data, ok, err := config.Replace(data, key, url, re.MatchString)
+			if err == nil && !ok {
+				err = fmt.Errorf("no such URL found: %s", positional[2])
+			}
+			return data, err
+		case len(current) > 1:
+			return nil, fmt.Errorf("%s has multiple values; give the URL to replace or use --add/--delete", key)
+		case len(current) == 1:
+			data, _, err := config.Replace(data, key, url, nil)
+			return data, err
+		}
+		return config.Add(data, key, url)
+	})
+}
+
+// Fetch: 원격에서 객체와 ref 를 가져온다. 아직 네트워크 프로토콜은 없어서 원격은 bundle 파일이다.
+//
+//	fetch [-p | --prune] <remote|bundle> [<refspec>...]
+//
+// <remote> 면 remote.<remote>.url 의 bundle 에서 remote.<remote>.fetch 의 refspec 대로 가져온다.
+// url 은 url.<base>.insteadOf 로 고친 뒤에 쓴다. (gogit.Repository.Remote)
+// bundle 경로를 바로 주고 refspec 이 없으면 가져온 ref 를 FETCH_HEAD 에만 적는다.
+// fast-forward 가 아닌 갱신은 refspec 앞에 + 가 있어야 하고, 체크아웃된 브랜치에는 가져오지 않는다.
+// --prune 은 refspec 의 대상에 있지만 원격에서 사라진 ref 를 지운다.
+func cmdFetch(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit fetch [-p | --prune] <remote|bundle> [<refspec>...]"
+	prune := false
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "-p", "--prune":
+			prune = true
+		default:
+			return errors.New(usage)
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	var url string
+	var specs []refs.Refspec
+	remote, err := repo.Remote(args[0])
+	switch {
+	case err == nil:
+		url = remote.URLs[0]
+		if len(args) == 1 {
+			specs = remote.Fetch
+		}
+	case errors.Is(err, gogit.ErrNoSuchRemote):
+		// remote 이름이 아니면 주소다. 주소에도 url.<base>.insteadOf 를 적용한다
+		cfg, err := repo.Config()
+		if err != nil {
+			return err
+		}
+		url, _ = cfg.RewriteURL(args[0], false)
+	default:
+		return err
+	}
+	for _, arg := range args[1:] {
+		spec, err := refs.ParseRefspec(arg)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, spec)
+	}
+	return fetchBundle(ctx, repo, url, specs, prune)
+}
+
+// fetchBundle: bundle 을 들여오고 specs 대로 ref 를 갱신한 뒤 FETCH_HEAD 를 쓴다.
+// prune 이면 specs 의 대상 ref 중 bundle 에 원본이 없는 것을 지운다.
+func fetchBundle(ctx context.Context, repo *gogit.Repository, url string, specs []refs.Refspec, prune bool) error {
+	h, err := unbundle(repo, url)
+	if err != nil {
+		return err
+	}
+	head, _ := repo.Refs.Read("HEAD")
+
+	var fetchHead strings.Builder
+	rejected := false
+	fmt.Fprintf(os.Stderr, "From %s\n", url)
+	for _, ref := range h.Refs {
+		dst, matched := "", len(specs) == 0
+		force := false
+		for _, spec := range specs {
+			src := spec
+			if !strings.HasPrefix(src.Src, "refs/") && src.Src != "HEAD" {
+				// "main" 은 원격의 refs/heads/main 처럼 줄여 쓴 이름
+				for _, prefix := range []string{"refs/", "refs/heads/", "refs/tags/"} {
+					if ref.Name == prefix+spec.Src {
+						src.Src = ref.Name
+						break
+					}
+				}
+			}
+			if d, ok := src.Map(ref.Name); ok {
+				dst, matched, force = d, true, spec.Force
+				if dst != "" && !strings.HasPrefix(dst, "refs/") && dst != "HEAD" {
+					dst = "refs/heads/" + dst
+				}
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		kind := "'" + ref.Name + "'"
+		if name, ok := strings.CutPrefix(ref.Name, "refs/heads/"); ok {
+			kind = "branch '" + name + "'"
+		} else if name, ok := strings.CutPrefix(ref.Name, "refs/tags/"); ok {
+			kind = "tag '" + name + "'"
+		}
+		merge := "not-for-merge"
+		if fetchHead.Len() == 0 {
+			merge = ""
+		}
+		fmt.Fprintf(&fetchHead, "%s\t%s\t%s of %s\n", ref.Hash, merge, kind, url)
+		if dst == "" {
+			continue
+		}
+
+		old, err := repo.Refs.Resolve(dst)
+		if err != nil && !errors.Is(err, refs.ErrNotFound) {
+			return err
+		}
+		from, to := shortRefName(ref.Name), shortRefName(dst)
+		switch {
+		case old == ref.Hash:
+			continue
+		case dst == head.Target && !repo.IsBare():
+			fmt.Fprintf(os.Stderr, " ! %-17s %-10s -> %s  (refusing to fetch into checked-out branch)\n", "[rejected]", from, to)
+			rejected = true
+			continue
+		case old == "":
+			what := "[new ref]"
+			switch {
+			case strings.HasPrefix(dst, "refs/tags/"):
+				what = "[new tag]"
+			case strings.HasPrefix(ref.Name, "refs/heads/"):
+				what = "[new branch]"
+			}
+			fmt.Fprintf(os.Stderr, " * %-17s %-10s -> %s\n", what, from, to)
+		default:
+			ancestors, err := object.Reachable(ctx, repo.Objects, []string{ref.Hash})
+			if err != nil && !errors.Is(err, object.ErrWrongType) {
+				return err
+			}
+			fastForward := ancestors[old] && !strings.HasPrefix(dst, "refs/tags/")
+			switch {
+			case fastForward:
+				fmt.Fprintf(os.Stderr, "   %-17s %-10s -> %s\n", old[:7]+".."+ref.Hash[:7], from, to)
+			case force:
+				fmt.Fprintf(os.Stderr, " + %-17s %-10s -> %s  (forced update)\n", old[:7]+"..."+ref.Hash[:7], from, to)
+			default:
+				reason := "non-fast-forward"
+				if strings.HasPrefix(dst, "refs/tags/") {
+					reason = "would clobber existing tag"
+				}
+				fmt.Fprintf(os.Stderr, " ! %-17s %-10s -> %s  (%s)\n", "[rejected]", from, to, reason)
+				rejected = true
+				continue
+			}
+		}
+		if err := repo.UpdateRef(dst, ref.Hash, "fetch: "+url); err != nil {
+			return err
+		}
+	}
+	if prune {
+		if err := pruneRefs(repo, h.Refs, specs); err != nil {
+			return err
+		}
+	}
+	if err := vfs.WriteFile(repo.FS, "FETCH_HEAD", []byte(fetchHead.String())); err != nil {
+		return err
+	}
+	if rejected {
+		return errors.New("some refs were not updated")
+	}
+	return nil
+}
+
+// pruneRefs: specs 의 대상과 맞는 로컬 ref 중 원격 ref 에서 오지 않은 것을 지운다. (fetch --prune)
+// 체크아웃된 브랜치는 지우지 않는다.
+func pruneRefs(repo *gogit.Repository, remote []bundle.Ref, specs []refs.Refspec) error {
+	names := map[string]bool{}
+	for _, ref := range remote {
+		names[ref.Name] = true
+	}
+	list, err := repo.Refs.List()
+	if err != nil {
+		return err
+	}
+	head, _ := repo.Refs.Read("HEAD")
+	for _, ref := range list {
+		if !strings.HasPrefix(ref.Name, "refs/") || ref.Name == head.Target && !repo.IsBare() {
+			continue
+		}
+		stale := false
+		for _, spec := range specs {
+			if spec.Dst == "" {
+				continue
+			}
+			if src, ok := spec.Reverse().Map(ref.Name); ok {
+				stale = !names[src]
+				break
+			}
+		}
+		if !stale {
+			continue
+		}
+		if err := refs.Delete(repo.Refs, ref.Name); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, " - %-17s %-10s -> %s\n", "[deleted]", "(none)", shortRefName(ref.Name))
+	}
+	return nil
+}
+
+// Push: 원격의 ref 를 로컬과 똑같이 만든다. 아직 네트워크 프로토콜이 없어서 원격은 bundle 파일이고,
+// 로컬의 모든 ref 를 담은 bundle 을 새로 써서 보낸다. (백업과 복제용)
+//
+//	push --mirror [--no-verify] [<remote|bundle>]   remote 를 생략하면 origin
+//
+// 보내기 전에 pre-push hook 을 remote 이름과 주소를 인자로, 바뀌는 ref 마다
+// "<local ref> <local sha> <remote ref> <remote sha>" 줄을 stdin 으로 주어 실행한다. hook 이 실패하면 그 주소로는 보내지 않는다.
+// --no-verify 는 hook 을 건너뛴다.
+//
+// refs/ 아래의 모든 ref 와 HEAD 를 보낸다. 원격에만 있던 ref 는 지워지고, 다른 ref 는 fast-forward 가 아니어도 덮어쓴다.
+// remote 의 push url 이 여러 개(remote.<remote>.pushurl)면 모두에 보낸다.
+// remote.<remote>.mirror 가 true 면 --mirror 를 생략해도 된다. 그 밖의 push 는 아직 지원하지 않는다.
+func cmdPush(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit push --mirror [--no-verify] [<remote|bundle>]"
+	mirror, noVerify := false, false
+	var positional []string
+	for _, arg := range args {
+		switch {
+		case arg == "--mirror":
+			mirror = true
+		case arg == "--no-verify":
+			noVerify = true
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) > 1 {
+		return errors.New(usage)
+	}
+	name := "origin"
+	if len(positional) == 1 {
+		name = positional[0]
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	var urls []string
+	remote, err := repo.Remote(name)
+	switch {
+	case err == nil:
+		urls = remote.PushURLs
+		if m, err := cfg.GetBool("remote."+name+".mirror", false); err != nil {
+			return err
+		} else if m {
+			mirror = true
+		}
+	case errors.Is(err, gogit.ErrNoSuchRemote) && len(positional) == 1:
+		// remote 이름이 아니면 주소다. pushInsteadOf 를 먼저, 없으면 insteadOf 를 적용한다
+		url, ok := cfg.RewriteURL(name, true)
+		if !ok {
+			url, _ = cfg.RewriteURL(name, false)
+		}
+		urls = []string{url}
+	default:
+		return err
+	}
+	if !mirror {
+		return errors.New("only mirror pushes are supported (use --mirror, or set remote.<name>.mirror)")
+	}
+
+	list, err := repo.Refs.List()
+	if err != nil {
+		return err
+	}
+	var local []bundle.Ref
+	for _, ref := range list {
+		if ref.Target == "" && strings.HasPrefix(ref.Name, "refs/") {
+			local = append(local, bundle.Ref{Name: ref.Name, Hash: ref.Hash})
+		}
+	}
+	if head, err := repo.Refs.Resolve("HEAD"); err == nil {
+		local = append(local, bundle.Ref{Name: "HEAD", Hash: head})
+	}
+	failed := false
+	for _, url := range urls {
+		if err := pushMirror(ctx, repo, name, url, local, noVerify); err != nil {
+			fmt.Fprintf(os.Stderr, "error: failed to push to '%s': %v\n", url, err)
+			failed = true
+		}
+	}
+	if failed {
+		return errors.New("some pushes failed")
+	}
+	return nil
+}
+
+// pushMirror: url 의 bundle 을 local 의 ref 들을 담은 bundle 로 바꾸고 바뀐 ref 를 출력한다.
+// 새 bundle 은 옆에 임시 파일로 쓴 뒤 rename 하므로 실패해도 원래 bundle 이 남는다.
+// noVerify 가 아니면 쓰기 전에 pre-push hook 을 실행하고, hook 이 실패하면 아무것도 보내지 않는다.
+func pushMirror(ctx context.Context, repo *gogit.Repository, name, url string, local []bundle.Ref, noVerify bool) error {
+	remote := map[string]string{}
+	if h, f, err := openBundle(url); err == nil {
+		f.Close()
+		for _, ref := range h.Refs {
+			remote[ref.Name] = ref.Hash
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	zero := repo.Objects.Algorithm().ZeroHash()
+	var lines []string
+	var hookInput strings.Builder
+	changed := len(remote) != len(local)
+	for _, ref := range local {
+		old, ok := remote[ref.Name]
+		if old != ref.Hash {
+			changed = true
+		}
+		if ref.Name == "HEAD" || old == ref.Hash {
+			continue
+		}
+		fmt.Fprintf(&hookInput, "%s %s %s %s\n", ref.Name, ref.Hash, ref.Name, cmp.Or(old, zero))
+		short := shortRefName(ref.Name)
+		switch {
+		case !ok:
+			what := "[new reference]"
+			if strings.HasPrefix(ref.Name, "refs/heads/") {
+				what = "[new branch]"
+			} else if strings.HasPrefix(ref.Name, "refs/tags/") {
+				what = "[new tag]"
+			}
+			lines = append(lines, fmt.Sprintf(" * %-17s %s -> %s", what, short, short))
+		default:
+			ancestors, err := object.Reachable(ctx, repo.Objects, []string{ref.Hash})
+			if err != nil && !errors.Is(err, object.ErrWrongType) {
+				return err
+			}
+			if ancestors[old] {
+				lines = append(lines, fmt.Sprintf("   %-17s %s -> %s", old[:7]+".."+ref.Hash[:7], short, short))
+			} else {
+				lines = append(lines, fmt.Sprintf(" + %-17s %s -> %s (forced update)", old[:7]+"..."+ref.Hash[:7], short, short))
+			}
+		}
+	}
+	for _, ref := range slices.Sorted(maps.Keys(remote)) {
+		if ref != "HEAD" && !slices.ContainsFunc(local, func(r bundle.Ref) bool { return r.Name == ref }) {
+			fmt.Fprintf(&hookInput, "(delete) %s %s %s\n", zero, ref, remote[ref])
+			lines = append(lines, fmt.Sprintf(" - %-17s %s", "[deleted]", shortRefName(ref)))
+		}
+	}
+	if !changed {
+		fmt.Fprintln(os.Stderr, "Everything up-to-date")
+		return nil
+	}
+	if len(local) == 0 {
+		return errors.New("no refs to push")
+	}
+	if !noVerify {
+		if err := repo.RunHook("pre-push", strings.NewReader(hookInput.String()), os.Stderr, os.Stderr, name, url); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(url), "."+filepath.Base(url)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = bundle.Create(ctx, f, repo.Objects, local, nil)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), url)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "To %s\n", url)
+	for _, line := range lines {
+		fmt.Fprintln(os.Stderr, line)
+	}
+	return nil
+}
+
+// SyncMirror: upstream 에서 모든 ref 를 가져와 downstream 에 그대로 보낸다. 두 곳 사이의 복제 에이전트로 쓴다.
+//
+//	sync-mirror [--prune] [--interval=<duration>] <upstream> <downstream>
+//
+// fetch +refs/*:refs/* 뒤에 push --mirror 를 하는 것과 같다. 둘 다 remote 이름이나 bundle 경로다.
+// --prune 은 upstream 에서 사라진 ref 를 로컬에서도 지우므로 downstream 에서도 지워진다.
+// --interval 을 주면 그 간격(예: 10m)으로 인터럽트될 때까지 되풀이한다. 실패한 회차는 시각과 함께
+// 표준 에러에 적고 다음 회차를 기다린다. 한 번만 실행하면 실패가 종료 코드가 된다.
+// fetch 가 체크아웃된 브랜치를 건드리지 않도록 bare 저장소(clone --mirror)에서만 실행한다.
+func cmdSyncMirror(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit sync-mirror [--prune] [--interval=<duration>] <upstream> <downstream>"
+	prune := false
+	var interval time.Duration
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--prune" || arg == "-p":
+			prune = true
+		case arg == "--interval" && i+1 < len(args):
+			i++
+			arg = "--interval=" + args[i]
+			fallthrough
+		case strings.HasPrefix(arg, "--interval="):
+			d, err := time.ParseDuration(strings.TrimPrefix(arg, "--interval="))
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid interval: %s", strings.TrimPrefix(arg, "--interval="))
+			}
+			interval = d
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 2 {
+		return errors.New(usage)
+	}
+	if !repo.IsBare() {
+		return errors.New("sync-mirror must be run in a bare repository (create one with clone --mirror)")
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+
+	upstream := positional[0]
+	if remote, err := repo.Remote(upstream); err == nil {
+		upstream = remote.URLs[0]
+	} else if errors.Is(err, gogit.ErrNoSuchRemote) {
+		upstream, _ = cfg.RewriteURL(upstream, false)
+	} else {
+		return err
+	}
+	var downstream []string
+	if remote, err := repo.Remote(positional[1]); err == nil {
+		downstream = remote.PushURLs
+	} else if errors.Is(err, gogit.ErrNoSuchRemote) {
+		url, ok := cfg.RewriteURL(positional[1], true)
+		if !ok {
+			url, _ = cfg.RewriteURL(positional[1], false)
+		}
+		downstream = []string{url}
+	} else {
+		return err
+	}
+
+	specs := []refs.Refspec{{Force: true, Src: "refs/*", Dst: "refs/*"}}
+	syncOnce := func() error {
+		if err := fetchBundle(ctx, repo, upstream, specs, prune); err != nil {
+			return fmt.Errorf("fetch from '%s' failed: %w", upstream, err)
+		}
+		list, err := repo.Refs.List()
+		if err != nil {
+			return err
+		}
+		var local []bundle.Ref
+		for _, ref := range list {
+			if ref.Target == "" && strings.HasPrefix(ref.Name, "refs/") {
+				local = append(local, bundle.Ref{Name: ref.Name, Hash: ref.Hash})
+			}
+		}
+		if head, err := repo.Refs.Resolve("HEAD"); err == nil {
+			local = append(local, bundle.Ref{Name: "HEAD", Hash: head})
+		}
+		var failed []string
+		for _, url := range downstream {
+			if err := pushMirror(ctx, repo, positional[1], url, local, false); err != nil {
+				failed = append(failed, fmt.Sprintf("push to '%s' failed: %v", url, err))
+			}
+		}
+		if len(failed) > 0 {
+			return errors.New(strings.Join(failed, "; "))
+		}
+		return nil
+	}
+
+	if interval == 0 {
+		return syncOnce()
+	}
+	failures := 0
+	for {
+		if err := syncOnce(); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			failures++
+			fmt.Fprintf(os.Stderr, "%s error: %v (%d consecutive failure%s)\n", time.Now().Format(time.RFC3339), err, failures, plural(failures))
+		} else {
+			if failures > 0 {
+				fmt.Fprintf(os.Stderr, "%s recovered after %d failure%s\n", time.Now().Format(time.RFC3339), failures, plural(failures))
+			}
+			failures = 0
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// shortRefName: refs/heads/, refs/tags/, refs/remotes/ 를 뗀 이름
+func shortRefName(name string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "refs/remotes/"} {
+		if short, ok := strings.CutPrefix(name, prefix); ok {
+			return short
+		}
+	}
+	return name
+}
+
+// Clone: bundle 에서 새 저장소를 만든다.
+//
+//	clone [--bare | --mirror] <bundle> [<dir>]
+//
+// 원격 "origin" 을 bundle 경로로 설정하고 bundle 의 브랜치를 refs/remotes/origin/ 아래에, 태그는 그대로 가져온다.
+// bundle 의 HEAD 와 같은 커밋의 브랜치(없으면 첫 브랜치)를 만들어 체크아웃한다.
+// --bare 면 브랜치를 refs/heads/ 에 그대로 가져오고 작업 트리를 만들지 않는다.
+// --mirror 는 --bare 에 더해 refs/ 아래의 모든 ref 를 같은 이름으로 가져오고 remote.origin.mirror 를 true 로 둔다.
+// 그 뒤의 fetch 도 모든 ref 를 덮어쓰고, push 는 --mirror 가 된다.
+// <dir> 이 없으면 bundle 파일 이름에서 .bundle 을 뗀 이름이다.
+func cmdClone(ctx context.Context, opts gogit.Options, args []string) error {
+	const usage = "usage: gogit clone [--bare | --mirror] <bundle> [<dir>]"
+	var positional []string
+	mirror := false
+	for _, arg := range args {
+		switch {
+		case arg == "--bare":
+			opts.Bare = true
+		case arg == "--mirror":
+			opts.Bare, mirror = true, true
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) == 0 || len(positional) > 2 {
+		return errors.New(usage)
+	}
+	url, err := filepath.Abs(positional[0])
+	if err != nil {
+		return err
+	}
+	dir := strings.TrimSuffix(filepath.Base(url), ".bundle")
+	if len(positional) == 2 {
+		dir = positional[1]
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("destination path '%s' already exists and is not an empty directory", dir)
+	}
+	_, statErr := os.Stat(dir)
+	created := errors.Is(statErr, fs.ErrNotExist)
+
+	err = clone(ctx, opts, url, dir, mirror)
+	if err != nil && created {
+		// 반쯤 만든 저장소를 남기지 않는다
+		os.RemoveAll(dir)
+	}
+	return err
+}
+
+func clone(ctx context.Context, opts gogit.Options, url, dir string, mirror bool) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Cloning into '%s'...\n", dir)
+	// 새 저장소는 bundle 과 같은 해시 함수를 쓴다
+	h, f, err := openBundle(url)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	opts.ObjectFormat = h.Algorithm.Name
+	repo, err := gogit.InitWithOptions(dir, opts)
+	if err != nil {
+		return err
+	}
+	// main 은 clone 한 저장소를 모르므로 만든 ref 는 여기서 알린다
+	defer emitRefs(events.FromContext(ctx), repo, ops.State{})
+
+	specs := []refs.Refspec{{Force: true, Src: "refs/heads/*", Dst: "refs/remotes/origin/*"}}
+	switch {
+	case mirror:
+		specs[0].Src, specs[0].Dst = "refs/*", "refs/*"
+	case opts.Bare:
+		specs[0].Dst = "refs/heads/*"
+	}
+	err = repo.EditConfig(func(data []byte) ([]byte, error) {
+		data, err := config.Add(data, "remote.origin.url", url)
+		if err == nil {
+			data, err = config.Add(data, "remote.origin.fetch", specs[0].String())
+		}
+		if err == nil && mirror {
+			data, err = config.Add(data, "remote.origin.mirror", "true")
+		}
+		return data, err
+	})
+	if err != nil {
+		return err
+	}
+	if !mirror {
+		specs = append(specs, refs.Refspec{Src: "refs/tags/*", Dst: "refs/tags/*"})
+	}
+	if err := fetchBundle(ctx, repo, url, specs, false); err != nil {
+		return err
+	}
+
+	var branch, tip string
+	for _, ref := range h.Refs {
+		if name, ok := strings.CutPrefix(ref.Name, "refs/heads/"); ok && (branch == "" || ref.Hash == bundleHead(h) && tip != bundleHead(h)) {
+			branch, tip = name, ref.Hash
+		}
+	}
+	if branch == "" {
+		fmt.Fprintln(os.Stderr, "warning: remote HEAD refers to nonexistent ref, unable to checkout")
+		return nil
+	}
+	if err := repo.Refs.SetSymbolic("HEAD", "refs/heads/"+branch); err != nil {
+		return err
+	}
+	if opts.Bare {
+		return nil
+	}
+	if err := repo.UpdateRef("refs/heads/"+branch, tip, "clone: from "+url); err != nil {
+		return err
+	}
+	err = repo.EditConfig(func(data []byte) ([]byte, error) {
+		data, err := config.Add(data, "branch."+branch+".remote", "origin")
+		if err != nil {
+			return nil, err
+		}
+		return config.Add(data, "branch."+branch+".merge", "refs/heads/"+branch)
+	})
+	if err != nil {
+		return err
+	}
+	commit, err := object.ReadCommit(repo.Objects, tip)
+	if err != nil {
+		return err
+	}
+	// 익명 배포본(bundle create --anonymous)에서 뺀 파일은 내용이 없으므로 받지 않는다
+	missing, err := missingBlobs(ctx, repo.Objects, commit.Tree)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: %d file(s) were left out of the bundle and are not checked out\n", len(missing))
+	}
+	return worktree.CheckoutPaths(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, "", commit.Tree, func(p string) bool { return !missing[p] })
+}
+
+// missingBlobs: tree 안에서 내용(blob)이 저장소에 없는 파일의 경로
+func missingBlobs(ctx context.Context, s object.Storer, tree string) (map[string]bool, error) {
+	missing := map[string]bool{}
+	w, err := object.NewTreeWalker(s, tree)
+	if err != nil {
+		return nil, err
+	}
+	err = w.ForEachContext(ctx, func(e object.WalkEntry) error {
+		if e.Mode.ObjectType() == object.TypeBlob && !s.Has(e.Hash) {
+			missing[e.Path] = true
+		}
+		return nil
+	})
+	return missing, err
+}
+
+// bundleHead: bundle 에 담긴 HEAD 의 커밋. 없으면 빈 문자열
+func bundleHead(h *bundle.Header) string {
+	for _, ref := range h.Refs {
+		if ref.Name == "HEAD" {
+			return ref.Hash
+		}
+	}
+	return ""
+}
+
+// Graph: 커밋 DAG 를 그림으로 내보낸다.
+//
+//	graph export [--format=dot|mermaid] [--objects] [--max-count=<n>] [<revision-range>...]
+//
+// 리비전이 없으면 모든 ref 와 HEAD 에서 도달 가능한 커밋이다. ref 는 가리키는 커밋에 이름표로 붙는다.
+// --objects 는 커밋마다 tree 와 그 아래의 tree, blob 까지 그린다. (작은 저장소에서 객체 모델을 보여 줄 때)
+//
+//	gogit graph export | dot -Tsvg > graph.svg
+func cmdGraph(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit graph export [--format=dot|mermaid] [--objects] [--max-count=<n>] [<revision-range>...]"
+	if len(args) == 0 || args[0] != "export" {
+		return errors.New(usage)
+	}
+	opts := graph.Options{Format: graph.DOT}
+	var revs []string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		value, isFormat := strings.CutPrefix(arg, "--format=")
+		if arg == "--format" && i+1 < len(args) {
+			i++
+			value, isFormat = args[i], true
+		}
+		switch {
+		case isFormat:
+			f, err := graph.ParseFormat(value)
+			if err != nil {
+				return err
+			}
+			opts.Format = f
+		case arg == "--objects":
+			opts.Objects = true
+		case arg == "-n" && i+1 < len(args):
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil {
+				return errors.New(usage)
+			}
+			opts.MaxCount = n
+		case strings.HasPrefix(arg, "--max-count="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--max-count="))
+			if err != nil {
+				return errors.New(usage)
+			}
+			opts.MaxCount = n
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			return errors.New(usage)
+		default:
+			revs = append(revs, arg)
+		}
+	}
+
+	set := &gogit.RevSet{}
+	if len(revs) > 0 {
+		var err error
+		if set, err = repo.ResolveRevSet(ctx, revs); err != nil {
+			return err
+		}
+	} else {
+		all, err := repo.AllRefs()
+		if err != nil {
+			return err
+		}
+		set.Include = all
+	}
+
+	list, err := repo.Refs.List()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(list)+1)
+	for _, ref := range list {
+		if ref.Target == "" {
+			names = append(names, ref.Name)
+		}
+	}
+	names = append(names, "HEAD")
+	for _, name := range names {
+		hash, err := repo.Refs.Resolve(name)
+		if err != nil {
+			continue
+		}
+		if hash, err = gogit.PeelToCommit(repo.Objects, hash); err == nil {
+			opts.Refs = append(opts.Refs, graph.Ref{Name: name, Hash: hash})
+		}
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if err := graph.Export(ctx, w, repo.Objects, set.Iter(repo.Objects, object.OrderTopo), opts); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// Debug: 객체를 JSON 으로 보고 만드는 도구 (가르치거나 잘못된 객체의 처리를 시험할 때)
+//
+//	debug dump-object <object>         타입, 크기, 해석한 필드를 JSON 으로 출력
+//	debug make-object [-n] [<file>]    dump-object 형식의 JSON(없으면 표준 입력)으로 객체를 만들고 해시를 출력
+//
+// make-object 는 값을 검사하지 않으므로 정렬되지 않은 tree, 시간이 없는 author 같은 잘못된 객체도 만든다.
+// -n 은 저장하지 않고 해시만 계산한다. 손상된 객체를 보려면 --no-verify 와 함께 쓴다.
+func cmdDebug(repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit debug (dump-object <object> | make-object [-n] [<file>])"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "dump-object":
+		if len(args) != 2 {
+			return errors.New(usage)
+		}
+		hash, err := repo.ResolveRevision(args[1])
+		if err != nil {
+			return err
+		}
+		typ, content, err := repo.Objects.Read(hash)
+		if err != nil {
+			return err
+		}
+		data, err := dump.Encode(hash, typ, content)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	case "make-object":
+		dryRun := false
+		var file string
+		for _, arg := range args[1:] {
+			switch {
+			case arg == "-n" || arg == "--dry-run":
+				dryRun = true
+			case strings.HasPrefix(arg, "-") && arg != "-" || file != "":
+				return errors.New(usage)
+			default:
+				file = arg
+			}
+		}
+		var data []byte
+		var err error
+		if file == "" || file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return err
+		}
+		typ, content, err := dump.Decode(data)
+		if err != nil {
+			return err
+		}
+		hash := repo.Objects.Algorithm().Sum(object.Format(typ, content))
+		if !dryRun {
+			if hash, err = repo.Objects.Write(typ, content); err != nil {
+				return err
+			}
+		}
+		fmt.Println(hash)
+		return nil
+	}
+	return errors.New(usage)
+}
+
+// Fast-Export: 이력을 fast-import 스트림으로 출력 (git fast-export)
+// ref 를 주지 않으면 --all 과 같다. ref 는 브랜치나 태그 이름으로 줄여 써도 된다.
+func cmdFastExport(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit fast-export [--all | <ref>...]"
+	var names []string
+	all := len(args) == 0
+	for _, arg := range args {
+		switch {
+		case arg == "--all":
+			all = true
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			names = append(names, arg)
+		}
+	}
+
+	var exportRefs []fastimport.Ref
+	if all {
+		list, err := repo.Refs.List()
+		if err != nil {
+			return err
+		}
+		for _, ref := range list {
+			if ref.Target == "" {
+				exportRefs = append(exportRefs, fastimport.Ref{Name: ref.Name, Hash: ref.Hash})
+			}
+		}
+	}
+	for _, arg := range names {
+		name, err := fullRefName(repo, arg)
+		if err != nil {
+			return err
+		}
+		if head, err := repo.Refs.Read(name); err == nil && head.Target != "" {
+			// HEAD 는 가리키는 브랜치 이름으로 내보낸다
+			name = head.Target
+		}
+		hash, err := repo.Refs.Resolve(name)
+		if err != nil {
+			return err
+		}
+		exportRefs = append(exportRefs, fastimport.Ref{Name: name, Hash: hash})
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if err := fastimport.Export(ctx, w, repo.Objects, exportRefs); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// Fast-Import: 표준 입력의 fast-import 스트림으로 객체를 만들고 ref 를 옮긴다 (git fast-import)
+// fast-forward 가 아닌 브랜치나 이미 있는 태그는 --force 없이 바꾸지 않는다.
+func cmdFastImport(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit fast-import [--force] < <stream>"
+	force := false
+	for _, arg := range args {
+		switch arg {
+		case "--force":
+			force = true
+		default:
+			return errors.New(usage)
+		}
+	}
+
+	lookup := func(name string) (string, bool) {
+		hash, err := repo.ResolveRevision(name)
+		return hash, err == nil
+	}
+	res, err := fastimport.Import(ctx, bufio.NewReader(os.Stdin), repo.Objects, lookup, fastimport.Options{Progress: os.Stdout})
+	if err != nil {
+		return err
+	}
+
+	updated, rejected := 0, false
+	for _, ref := range res.Refs {
+		old, err := repo.Refs.Resolve(ref.Name)
+		if err != nil && !errors.Is(err, refs.ErrNotFound) {
+			return err
+		}
+		if old == ref.Hash {
+			continue
+		}
+		if old != "" && !force {
+			ancestors, err := object.Reachable(ctx, repo.Objects, []string{ref.Hash})
+			if err != nil && !errors.Is(err, object.ErrWrongType) {
+				return err
+			}
+			if strings.HasPrefix(ref.Name, "refs/tags/") || !ancestors[old] {
+				fmt.Fprintf(os.Stderr, "warning: not updating %s (new tip %s does not contain %s)\n", ref.Name, ref.Hash, old)
+				rejected = true
+				continue
+			}
+		}
+		if err := repo.UpdateRef(ref.Name, ref.Hash, "fast-import"); err != nil {
+			return err
+		}
+		updated++
+	}
+	fmt.Fprintf(os.Stderr, "gogit fast-import: %d blobs, %d commits, %d tags, %d refs updated\n", res.Blobs, res.Commits, res.Tags, updated)
+	if rejected {
+		return errors.New("some refs were not updated")
+	}
+	return nil
+}
+
+// Selftest: 임시 디렉토리에 저장소를 만들어 init, commit, branch, merge, gc 를 차례로 해 보고
+// ref, fsck, pack index, 작업 트리가 맞는지 확인한다. 다른 플랫폼에서 빌드한 gogit 이 제대로 동작하는지 볼 때 쓴다.
+//
+//	selftest [--keep] [--object-format=(sha1|sha256)]
+//
+// 저장소는 전역 옵션(--git-compat 등)과 --object-format 대로 만든다. 실패하거나 --keep 을 주면 임시 디렉토리를 남긴다.
+// index 가 없으므로 round-trip 은 gc 가 쓴 pack index 를 index-pack 으로 다시 만들어 같은지로 확인한다.
+func cmdSelftest(ctx context.Context, opts gogit.Options, args []string) error {
+	const usage = "usage: gogit selftest [--keep] [--object-format=(sha1|sha256)]"
+	keep := false
+	for _, arg := range args {
+		switch {
+		case arg == "--keep":
+			keep = true
+		case strings.HasPrefix(arg, "--object-format="):
+			opts.ObjectFormat = strings.TrimPrefix(arg, "--object-format=")
+		default:
+			return errors.New(usage)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "gogit-selftest-")
+	if err != nil {
+		return err
+	}
+	opts.Bare = false
+	st := &selftest{ctx: ctx, dir: dir, opts: opts}
+	ok := st.run()
+	if !ok || keep {
+		fmt.Printf("repository kept in %s\n", dir)
+	} else if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("selftest failed")
+	}
+	fmt.Printf("selftest passed (%d checks)\n", st.passed)
+	return nil
+}
+
+// selftest: 시나리오의 상태. 단계마다 앞 단계가 만든 커밋을 쓴다
+type selftest struct {
+	ctx    context.Context
+	dir    string
+	opts   gogit.Options
+	repo   *gogit.Repository
+	passed int
+
+	root, topic, head, merged string
+}
+
+func (st *selftest) run() bool {
+	steps := []struct {
+		name string
+		fn   func() (string, error)
+	}{
+		{"init", st.init},
+		{"commit", st.commitRoot},
+		{"branch", st.branch},
+		{"commit on branch", st.commitTopic},
+		{"commit on HEAD", st.commitHead},
+		{"merge", st.merge},
+		{"work tree matches HEAD", st.checkWorkTree},
+		{"gc", st.gc},
+		{"refs resolve", st.checkRefs},
+		{"fsck", st.fsck},
+		{"pack index round-trip", st.checkPackIndex},
+	}
+	for _, step := range steps {
+		if err := st.ctx.Err(); err != nil {
+			fmt.Printf("FAIL  %s: %v\n", step.name, err)
+			return false
+		}
+		detail, err := step.fn()
+		if err != nil {
+			fmt.Printf("FAIL  %s: %v\n", step.name, err)
+			return false
+		}
+		fmt.Printf("ok    %-24s %s\n", step.name, detail)
+		st.passed++
+	}
+	return true
+}
+
+// selftestLib: 양쪽 브랜치가 서로 다른 줄을 고쳐 줄 단위 merge 를 하게 만드는 파일
+const selftestLib = "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+
+func (st *selftest) init() (string, error) {
+	repo, err := gogit.InitWithOptions(st.dir, st.opts)
+	if err != nil {
+		return "", err
+	}
+	// 사용자의 설정과 상관없이 커밋할 수 있도록 저장소에 이름을 둔다 (환경 변수가 있으면 그것이 우선한다)
+	data, err := vfs.ReadFile(repo.FS, "config")
+	if err != nil {
+		return "", err
+	}
+	data = append(data, "[user]\n\tname = gogit selftest\n\temail = selftest@gogit.invalid\n"...)
+	if err := vfs.WriteFile(repo.FS, "config", data); err != nil {
+		return "", err
+	}
+	st.repo = repo
+	return st.dir, nil
+}
+
+func (st *selftest) writeFile(name, content string, mode os.FileMode) error {
+	p := filepath.Join(st.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(p, []byte(content), mode); err != nil {
+		return err
+	}
+	// umask 와 상관없이 실행 비트를 맞춘다
+	return os.Chmod(p, mode)
+}
+
+func (st *selftest) commit(tree, message string, parents ...string) (string, error) {
+	author, err := st.repo.Author()
+	if err != nil {
+		return "", err
+	}
+	committer, err := st.repo.Committer()
+	if err != nil {
+		return "", err
+	}
+	c := &object.Commit{Tree: tree, Parents: parents, Author: author, Committer: committer, Message: message + "\n"}
+	return object.WriteObject(st.repo.Objects, c)
+}
+
+func (st *selftest) commitRoot() (string, error) {
+	files := []struct {
+		name, content string
+		mode          os.FileMode
+	}{
+		{"README", "selftest\n", 0644},
+		{"src/lib.txt", selftestLib, 0644},
+		{"tools/run.sh", "#!/bin/sh\necho ok\n", 0755},
+	}
+	for _, f := range files {
+		if err := st.writeFile(f.name, f.content, f.mode); err != nil {
+			return "", err
+		}
+	}
+	tree, err := snapshotWorkTree(st.ctx, st.repo)
+	if err != nil {
+		return "", err
+	}
+	if st.root, err = st.commit(tree, "initial commit"); err != nil {
+		return "", err
+	}
+	if err := st.repo.UpdateHead(st.root, "commit (initial): initial commit"); err != nil {
+		return "", err
+	}
+	return st.root[:7], nil
+}
+
+func (st *selftest) branch() (string, error) {
+	if err := st.repo.UpdateRef("refs/heads/topic", st.root, "branch: Created from HEAD"); err != nil {
+		return "", err
+	}
+	return "topic", nil
+}
+
+// commitTopic: 작업 트리를 건드리지 않고 topic 에 커밋한다. 파일 하나를 더하고 lib.txt 의 끝 줄을 고친다
+func (st *selftest) commitTopic() (string, error) {
+	s := st.repo.Objects
+	tree, err := st.repo.ResolveTree(st.root)
+	if err != nil {
+		return "", err
+	}
+	edits := map[string]string{
+		"src/topic.txt": "topic\n",
+		"src/lib.txt":   strings.Replace(selftestLib, "ten\n", "ten (topic)\n", 1),
+	}
+	for _, p := range slices.Sorted(maps.Keys(edits)) {
+		blob, err := s.Write(object.TypeBlob, []byte(edits[p]))
+		if err != nil {
+			return "", err
+		}
+		if tree, err = object.ReplacePath(s, tree, p, &object.TreeEntry{Mode: object.ModeRegular, Hash: blob}); err != nil {
+			return "", err
+		}
+	}
+	if st.topic, err = st.commit(tree, "topic change", st.root); err != nil {
+		return "", err
+	}
+	if err := st.repo.UpdateRef("refs/heads/topic", st.topic, "commit: topic change"); err != nil {
+		return "", err
+	}
+	return st.topic[:7], nil
+}
+
+// commitHead: 작업 트리에서 README 와 lib.txt 의 첫 줄을 고쳐 HEAD 에 커밋한다
+func (st *selftest) commitHead() (string, error) {
+	if err := st.writeFile("README", "selftest\nmore\n", 0644); err != nil {
+		return "", err
+	}
+	if err := st.writeFile("src/lib.txt", strings.Replace(selftestLib, "one\n", "one (head)\n", 1), 0644); err != nil {
+		return "", err
+	}
+	tree, err := snapshotWorkTree(st.ctx, st.repo)
+	if err != nil {
+		return "", err
+	}
+	if st.head, err = st.commit(tree, "head change", st.root); err != nil {
+		return "", err
+	}
+	if err := st.repo.UpdateHead(st.head, "commit: head change"); err != nil {
+		return "", err
+	}
+	return st.head[:7], nil
+}
+
+func (st *selftest) merge() (string, error) {
+	s := st.repo.Objects
+	var trees [3]string
+	for i, c := range []string{st.root, st.head, st.topic} {
+		var err error
+		if trees[i], err = st.repo.ResolveTree(c); err != nil {
+			return "", err
+		}
+	}
+	res, err := merge.Trees(s, trees[0], trees[1], trees[2], merge.Options{Ours: "HEAD", Theirs: "topic"})
+	if err != nil {
+		return "", err
+	}
+	if !res.Clean() {
+		return "", fmt.Errorf("unexpected conflict: %s", res.Conflicts[0].Message)
+	}
+	if err := worktree.Checkout(st.ctx, vfs.NewOS(st.repo.WorkTree), s, trees[1], res.Tree); err != nil {
+		return "", err
+	}
+	if st.merged, err = st.commit(res.Tree, "Merge branch 'topic'", st.head, st.topic); err != nil {
+		return "", err
+	}
+	if err := st.repo.UpdateHead(st.merged, "merge topic: Merge made by gogit"); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (%d merged by line)", st.merged[:7], len(res.Merged)), nil
+}
+
+func (st *selftest) checkWorkTree() (string, error) {
+	tree, err := snapshotWorkTree(st.ctx, st.repo)
+	if err != nil {
+		return "", err
+	}
+	want, err := st.repo.ResolveTree("HEAD")
+	if err != nil {
+		return "", err
+	}
+	if tree != want {
+		return "", fmt.Errorf("work tree is %s, HEAD has %s", tree, want)
+	}
+	lib, err := os.ReadFile(filepath.Join(st.dir, "src", "lib.txt"))
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(string(lib), "one (head)\n") || !strings.Contains(string(lib), "ten (topic)\n") {
+		return "", errors.New("src/lib.txt is missing a change from one side of the merge")
+	}
+	return tree[:7], nil
+}
+
+func (st *selftest) gc() (string, error) {
+	name, packed, _, err := gcRepository(st.ctx, st.repo, time.Now())
+	if err != nil {
+		return "", err
+	}
+	store := st.repo.Objects.(*object.Store)
+	loose := 0
+	if err := store.ForEachLoose(func(string, time.Time) error { loose++; return nil }); err != nil {
+		return "", err
+	}
+	packs, err := store.Packs()
+	if err != nil {
+		return "", err
+	}
+	if loose > 0 || len(packs) != 1 {
+		return "", fmt.Errorf("expected a single pack and no loose objects, found %d packs and %d loose objects", len(packs), loose)
+	}
+	return fmt.Sprintf("%d objects in %s", packed, name), nil
+}
+
+func (st *selftest) checkRefs() (string, error) {
+	want := map[string]string{"HEAD": st.merged, "refs/heads/topic": st.topic}
+	list, err := st.repo.Refs.List()
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range list {
+		if _, ok := want[ref.Name]; !ok && ref.Target == "" {
+			want[ref.Name] = ""
+		}
+	}
+	for name, expected := range want {
+		hash, err := st.repo.Refs.Resolve(name)
+		if err != nil {
+			return "", err
+		}
+		if expected != "" && hash != expected {
+			return "", fmt.Errorf("%s is %s, expected %s", name, hash, expected)
+		}
+		if _, err := object.ReadCommit(st.repo.Objects, hash); err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return fmt.Sprintf("%d refs", len(want)), nil
+}
+
+func (st *selftest) fsck() (string, error) {
+	opts := fsck.Options{Refs: map[string]string{}, RepoDir: st.opts.RepoDirName()}
+	list, err := st.repo.Refs.List()
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range list {
+		opts.Refs[ref.Name] = ref.Hash
+	}
+	report, err := fsck.Check(st.ctx, st.repo.Objects, opts)
+	if err != nil {
+		return "", err
+	}
+	if !report.OK() {
+		var b strings.Builder
+		report.WriteText(&b, false)
+		return "", errors.New(strings.TrimSpace(b.String()))
+	}
+	if len(report.Dangling) > 0 {
+		return "", fmt.Errorf("%d dangling objects after gc", len(report.Dangling))
+	}
+	return fmt.Sprintf("%d objects", report.Checked), nil
+}
+
+// checkPackIndex: gc 가 쓴 pack 을 빈 저장소에 index-pack 해서 같은 index 가 나오는지, 객체가 모두 해시와 맞는지 본다
+func (st *selftest) checkPackIndex() (string, error) {
+	store := st.repo.Objects.(*object.Store)
+	packs, err := store.Packs()
+	if err != nil {
+		return "", err
+	}
+	p := packs[0]
+	f, err := st.repo.FS.Open("objects/pack/" + p.Name + ".pack")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	mem := vfs.NewMemory()
+	rebuiltStore := object.NewStore(mem)
+	rebuiltStore.SetAlgorithm(store.Algorithm())
+	name, err := rebuiltStore.IndexPack(f)
+	if err != nil {
+		return "", err
+	}
+	idx, err := vfs.ReadFile(st.repo.FS, "objects/pack/"+p.Name+".idx")
+	if err != nil {
+		return "", err
+	}
+	rebuilt, err := vfs.ReadFile(mem, "pack/"+name+".idx")
+	if err != nil {
+		return "", err
+	}
+	if name != p.Name || !bytes.Equal(idx, rebuilt) {
+		return "", fmt.Errorf("index-pack of %s produced a different index", p.Name)
+	}
+	for _, hash := range p.Hashes {
+		typ, content, err := store.Read(hash)
+		if err != nil {
+			return "", err
+		}
+		if got := store.Algorithm().Sum(object.Format(typ, content)); got != hash {
+			return "", fmt.Errorf("object %s reads back as %s", hash, got)
+		}
+	}
+	return fmt.Sprintf("%s.idx, %d objects", p.Name, len(p.Hashes)), nil
+}
+
+// Blame: 파일의 줄마다 그 줄을 마지막으로 바꾼 커밋, 작성자, 날짜를 보여 준다 (git blame)
+//
+//	blame [-L <start>,<end>] [--porcelain] [<rev>] [--] <file>
+//
+// <rev> 가 없으면 HEAD 의 파일을 본다. (커밋하지 않은 작업 트리의 변경은 보지 않는다)
+// -L 의 <end> 는 줄 번호 외에 +<n>(start 부터 n 줄)으로도 쓸 수 있고, 비우면 파일 끝까지다.
+// --porcelain 은 편집기 같은 도구가 읽기 위한 git blame --porcelain 형식이다.
+func cmdBlame(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit blame [-L <start>,<end>] [--porcelain] [<rev>] [--] <file>"
+	var opts blame.Options
+	porcelain := false
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-L" && i+1 < len(args):
+			i++
+			start, end, err := parseLineRange(args[i])
+			if err != nil {
+				return err
+			}
+			opts.Start, opts.End = start, end
+		case strings.HasPrefix(arg, "-L") && len(arg) > 2:
+			start, end, err := parseLineRange(arg[2:])
+			if err != nil {
+				return err
+			}
+			opts.Start, opts.End = start, end
+		case arg == "--porcelain" || arg == "-p":
+			porcelain = true
+		case arg == "--":
+			positional = append(positional, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "-"):
+			return errors.New(usage)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	rev := "HEAD"
+	switch len(positional) {
+	case 1:
+	case 2:
+		rev = positional[0]
+	default:
+		return errors.New(usage)
+	}
+
+	commit, err := repo.ResolveCommit(rev)
+	if err != nil {
+		return err
+	}
+	p := positional[len(positional)-1]
+	if !repo.IsBare() {
+		if p, err = repoPath(repo, p); err != nil {
+			return err
+		}
+	}
+	res, err := blame.File(ctx, repo.Objects, commit, p, opts)
+	if err != nil {
+		return err
+	}
+	// git 과 같이 .mailmap 의 정식 이름을 보여 준다. 커밋 객체는 캐시와 공유할 수 있어 복사해서 고친다
+	m, err := repo.Mailmap()
+	if err != nil {
+		return err
+	}
+	if !m.Empty() {
+		for hash, c := range res.Commits {
+			mapped := *c
+			mapped.Author, mapped.Committer = m.Lookup(c.Author), m.Lookup(c.Committer)
+			res.Commits[hash] = &mapped
+		}
+	}
+	if porcelain {
+		return res.WritePorcelain(os.Stdout)
+	}
+	return res.WriteText(os.Stdout)
+}
+
+// parseLineRange: -L 의 "<start>,<end>", "<start>,+<n>", "<start>," , "<start>"
+func parseLineRange(s string) (start, end int, err error) {
+	invalid := fmt.Errorf("invalid -L range '%s'", s)
+	from, to, hasTo := strings.Cut(s, ",")
+	if start, err = strconv.Atoi(from); err != nil || start < 1 {
+		return 0, 0, invalid
+	}
+	switch {
+	case !hasTo || to == "":
+		return start, 0, nil
+	case strings.HasPrefix(to, "+"):
+		n, err := strconv.Atoi(to[1:])
+		if err != nil || n < 1 {
+			return 0, 0, invalid
+		}
+		return start, start + n - 1, nil
+	}
+	if end, err = strconv.Atoi(to); err != nil || end < 1 {
+		return 0, 0, invalid
+	}
+	if end < start {
+		// git 처럼 거꾸로 준 범위는 뒤집는다
+		start, end = end, start
+	}
+	return start, end, nil
+}
+
+// Grep: 커밋한(또는 커밋할) 내용에서 정규식과 맞는 줄을 찾는다 (git grep)
+//
+//	grep [-n] [-i] [-F] [--cached] [-e] <pattern> [<tree-ish>...] [-- <path>...]
+//
+// <tree-ish> 가 없으면 작업 트리를 커밋할 때와 같은 스냅샷에서 찾으므로, 추적하지 않는 무시된 파일은 찾지 않는다.
+// git 과 같이 <path> 가 없으면 현재 디렉토리 아래만 찾고, 경로는 현재 디렉토리 기준으로 출력한다.
+// index 가 없으므로 --cached 는 HEAD 에서 찾는다. <tree-ish> 를 주면 출력의 경로 앞에 "<tree-ish>:" 를 붙인다.
+// 패턴은 Go 정규식(RE2)이고 -F 는 고정 문자열이다. 맞는 줄이 없으면 종료 코드가 1 이다.
+func cmdGrep(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit grep [-n] [-i] [-F] [--cached] [-e] <pattern> [<tree-ish>...] [-- <path>...]"
+	lineNumbers, ignoreCase, fixed, cached := false, false, false, false
+	pattern, havePattern := "", false
+	var revs, paths []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-n" || arg == "--line-number":
+			lineNumbers = true
+		case arg == "-i" || arg == "--ignore-case":
+			ignoreCase = true
+		case arg == "-F" || arg == "--fixed-strings":
+			fixed = true
+		case arg == "--cached":
+			cached = true
+		case arg == "-e" && i+1 < len(args) && !havePattern:
+			i++
+			pattern, havePattern = args[i], true
+		case arg == "--":
+			paths = append(paths, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "-") && !havePattern:
+			return errors.New(usage)
+		case !havePattern:
+			pattern, havePattern = arg, true
+		default:
+			revs = append(revs, arg)
+		}
+	}
+	if !havePattern || (cached && len(revs) > 0) {
+		return errors.New(usage)
+	}
+
+	if fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	// cwd: 현재 디렉토리의 작업 트리 루트 기준 경로. git 과 같이 경로를 주지 않으면 이 아래만 찾는다
+	var cwd string
+	if !repo.IsBare() {
+		if cwd, err = repoPath(repo, "."); err != nil {
+			return err
+		}
+		for i, p := range paths {
+			if paths[i], err = repoPath(repo, p); err != nil {
+				return err
+			}
+		}
+		if len(paths) == 0 && cwd != "" {
+			paths = []string{cwd}
+		}
+	}
+	var opts grep.Options
+	if len(paths) > 0 {
+		opts.Match = pathspecMatcher(paths)
+	}
+
+	// 찾을 tree 와 출력에 붙일 이름
+	type target struct{ tree, prefix string }
+	var targets []target
+	switch {
+	case len(revs) > 0:
+		for _, rev := range revs {
+			tree, err := repo.ResolveTree(rev)
+			if err != nil {
+				return err
+			}
+			targets = append(targets, target{tree, rev + ":"})
+		}
+	case cached:
+		tree, err := repo.ResolveTree("HEAD")
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target{tree: tree})
+	default:
+		if err := repo.RequireWorkTree("grep"); err != nil {
+			return err
+		}
+		tree, err := snapshotWorkTree(ctx, repo)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target{tree: tree})
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	found := false
+	for _, t := range targets {
+		err := grep.Tree(ctx, repo.Objects, t.tree, re, opts, func(m grep.Match) error {
+			found = true
+			name := relativePath(cwd, m.Path)
+			switch {
+			case m.Binary:
+				fmt.Fprintf(w, "Binary file %s%s matches\n", t.prefix, name)
+			case lineNumbers:
+				fmt.Fprintf(w, "%s%s:%d:%s\n", t.prefix, name, m.Line, m.Text)
+			default:
+				fmt.Fprintf(w, "%s%s:%s\n", t.prefix, name, m.Text)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !found {
+		return errNoMatch
+	}
+	return nil
+}
+
+// errNoMatch: grep 이 맞는 줄을 찾지 못함
+var errNoMatch = errors.New("no match")
+
+// Bisect: 좋은 커밋과 나쁜 커밋 사이를 이분 탐색해서 처음으로 나빠진 커밋을 찾는다.
+// 표시할 때마다 남은 후보의 가운데 커밋을 detached HEAD 로 체크아웃한다.
+//
+//	bisect start [<bad> [<good>...]]  탐색을 시작한다 (작업 트리가 깨끗해야 한다)
+//	bisect bad [<rev>]                rev(기본값 HEAD)를 나쁜 커밋으로 표시한다
+//	bisect good [<rev>...]            좋은 커밋으로 표시한다
+//	bisect skip [<rev>...]            시험할 수 없는 커밋으로 표시한다 (다음 커밋으로 고르지 않는다)
+//	bisect reset [<commit>]           시작할 때의 HEAD (또는 commit)로 돌아가고 상태를 지운다
+//	bisect log                        지금까지 표시한 기록
+//	bisect run <cmd> [<arg>...]       커밋마다 cmd 를 실행해서 종료 코드로 표시한다
+//	                                  0 은 good, 125 는 skip, 1~127 은 bad, 그 밖의 코드는 멈춘다
+//
+// 상태는 git 과 같이 저장한다. refs/bisect/ 의 ref 가 있으므로 표시한 커밋은 gc 로 지워지지 않는다.
+//
+//	BISECT_START         시작할 때 HEAD 가 가리키던 브랜치(refs/heads/...) 또는 커밋
+//	BISECT_LOG           지금까지의 명령. 주석으로 표시한 커밋의 제목을 남긴다
+//	refs/bisect/bad      나쁜 커밋
+//	refs/bisect/good-<h> 좋은 커밋들
+//	refs/bisect/skip-<h> 건너뛴 커밋들
+func cmdBisect(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit bisect (start [<bad> [<good>...]] | bad [<rev>] | good [<rev>...] | skip [<rev>...] | reset [<commit>] | log | run <cmd> [<arg>...])"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	if err := repo.RequireWorkTree("bisect"); err != nil {
+		return err
+	}
+	sub, args := args[0], args[1:]
+	if sub == "start" {
+		return bisectStart(ctx, repo, args)
+	}
+	if sub == "reset" && len(args) <= 1 {
+		return bisectReset(ctx, repo, args)
+	}
+	if !vfs.Exists(repo.FS, bisectStartFile) {
+		return errors.New("not bisecting (use \"gogit bisect start\" first)")
+	}
+	switch {
+	case sub == "bad" && len(args) <= 1, sub == "good", sub == "skip":
+		if len(args) == 0 {
+			args = []string{"HEAD"}
+		}
+		for _, rev := range args {
+			if err := bisectMark(repo, sub, rev); err != nil {
+				return err
+			}
+		}
+		_, err := bisectNext(ctx, repo)
+		return err
+	case sub == "log" && len(args) == 0:
+		data, err := vfs.ReadFile(repo.FS, bisectLogFile)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	case sub == "run" && len(args) > 0:
+		return bisectRun(ctx, repo, args)
+	}
+	return errors.New(usage)
+}
+
+const (
+	bisectStartFile = "BISECT_START"
+	bisectLogFile   = "BISECT_LOG"
+	bisectRefs      = "refs/bisect/"
+)
+
+func bisectStart(ctx context.Context, repo *gogit.Repository, args []string) error {
+	if vfs.Exists(repo.FS, bisectStartFile) {
+		return errors.New("already bisecting (use \"gogit bisect reset\" first)")
+	}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("unknown bisect start option: %s", arg)
+		}
+	}
+	// 잘못된 리비전이면 상태를 남기지 않고 멈춘다
+	for _, rev := range args {
+		if _, err := repo.ResolveCommit(rev); err != nil {
+			return err
+		}
+	}
+	head, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	headTree, err := repo.ResolveTree(head)
+	if err != nil {
+		return err
+	}
+	if err := requireCleanWorkTree(ctx, repo, headTree, "bisect"); err != nil {
+		return err
+	}
+	start := head
+	if ref, err := repo.Refs.Read("HEAD"); err == nil && ref.Target != "" {
+		start = ref.Target
+	}
+	if err := vfs.WriteFile(repo.FS, bisectStartFile, []byte(start+"\n")); err != nil {
+		return err
+	}
+	if err := bisectLog(repo, "gogit bisect start"+strings.Join(append([]string{""}, args...), " ")+"\n"); err != nil {
+		return err
+	}
+	for i, rev := range args {
+		term := "good"
+		if i == 0 {
+			term = "bad"
+		}
+		if err := bisectMark(repo, term, rev); err != nil {
+			return err
+		}
+	}
+	_, err = bisectNext(ctx, repo)
+	return err
+}
+
+// bisectMark: rev 를 term(bad, good, skip)으로 표시하고 기록에 남긴다
+func bisectMark(repo *gogit.Repository, term, rev string) error {
+	hash, err := repo.ResolveCommit(rev)
+	if err != nil {
+		return err
+	}
+	c, err := object.ReadCommit(repo.Objects, hash)
+	if err != nil {
+		return err
+	}
+	name := bisectRefs + "bad"
+	if term != "bad" {
+		name = bisectRefs + term + "-" + hash
+	}
+	if err := repo.Refs.Update(name, hash); err != nil {
+		return err
+	}
+	return bisectLog(repo, fmt.Sprintf("# %s: [%s] %s\ngogit bisect %s %s\n", term, hash, c.Subject(), term, hash))
+}
+
+func bisectLog(repo *gogit.Repository, text string) error {
+	data, err := vfs.ReadFile(repo.FS, bisectLogFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return vfs.WriteFile(repo.FS, bisectLogFile, append(data, text...))
+}
+
+// bisectState: 표시한 커밋들
+func bisectState(repo *gogit.Repository) (bad string, good []string, skip map[string]bool, err error) {
+	list, err := repo.Refs.List()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	skip = map[string]bool{}
+	for _, ref := range list {
+		switch name := strings.TrimPrefix(ref.Name, bisectRefs); {
+		case !strings.HasPrefix(ref.Name, bisectRefs):
+		case name == "bad":
+			bad = ref.Hash
+		case strings.HasPrefix(name, "good-"):
+			good = append(good, ref.Hash)
+		case strings.HasPrefix(name, "skip-"):
+			skip[ref.Hash] = true
+		}
+	}
+	return bad, good, skip, nil
+}
+
+// bisectNext: 다음에 시험할 커밋을 체크아웃한다. 처음으로 나빠진 커밋을 찾았거나
+// 건너뛴 커밋만 남아서 더 시험할 것이 없으면 done 이다.
+func bisectNext(ctx context.Context, repo *gogit.Repository) (done bool, err error) {
+	bad, good, skip, err := bisectState(repo)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case bad == "" && len(good) == 0:
+		fmt.Println("status: waiting for both good and bad commits")
+		return false, nil
+	case bad == "":
+		fmt.Printf("status: waiting for bad commit, %d good commit%s known\n", len(good), plural(len(good)))
+		return false, nil
+	case len(good) == 0:
+		fmt.Println("status: waiting for good commit(s), bad commit known")
+		return false, nil
+	}
+
+	step, err := bisect.Next(ctx, repo.Objects, bad, good, skip)
+	if err != nil {
+		return false, err
+	}
+	if step.Skipped != nil {
+		fmt.Println("There are only 'skip'ped commits left to test.")
+		fmt.Println("The first bad commit could be any of:")
+		for _, hash := range step.Skipped {
+			fmt.Println(hash)
+		}
+		return true, errors.New("bisect cannot tell the first bad commit because of skipped commits")
+	}
+	if step.Done {
+		c, err := object.ReadCommit(repo.Objects, step.Commit)
+		if err != nil {
+			return false, err
+		}
+		w := bufio.NewWriter(os.Stdout)
+		fmt.Fprintf(w, "%s is the first bad commit\n", step.Commit)
+		if err := pretty.NewWriter(w, pretty.Medium, false).Write(step.Commit, c); err != nil {
+			return false, err
+		}
+		if err := w.Flush(); err != nil {
+			return false, err
+		}
+		return true, bisectLog(repo, fmt.Sprintf("# first bad commit: [%s] %s\n", step.Commit, c.Subject()))
+	}
+
+	if err := bisectCheckout(ctx, repo, step.Commit); err != nil {
+		return false, err
+	}
+	c, err := object.ReadCommit(repo.Objects, step.Commit)
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("Bisecting: %d revision%s left to test after this (roughly %d step%s)\n", step.Left, plural(step.Left), step.Steps, plural(step.Steps))
+	fmt.Printf("[%s] %s\n", step.Commit, c.Subject())
+	return false, nil
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// bisectCheckout: commit 을 detached HEAD 로 체크아웃한다.
+// 커밋하지 않은 변경 중 체크아웃이 덮어쓸 파일이 있으면 멈춘다. (테스트가 만든 새 파일 등 상관없는 변경은 그대로 둔다)
+func bisectCheckout(ctx context.Context, repo *gogit.Repository, commit string) error {
+	head, err := repo.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	if head == commit {
+		return nil
+	}
+	headTree, err := repo.ResolveTree(head)
+	if err != nil {
+		return err
+	}
+	tree, err := repo.ResolveTree(commit)
+	if err != nil {
+		return err
+	}
+	current, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		return err
+	}
+	changes, err := diff.Trees(repo.Objects, headTree, tree)
+	if err != nil {
+		return err
+	}
+	touched := map[string]bool{}
+	for _, c := range changes {
+		touched[c.From.Path], touched[c.To.Path] = true, true
+	}
+	local, err := diff.Trees(repo.Objects, headTree, current)
+	if err != nil {
+		return err
+	}
+	for _, c := range local {
+		if (c.From.Hash != "" && touched[c.From.Path]) || (c.To.Hash != "" && touched[c.To.Path]) {
+			return fmt.Errorf("your local changes to %s would be overwritten by bisect; commit or remove them first", cmp.Or(c.To.Path, c.From.Path))
+		}
+	}
+	if err := worktree.Checkout(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, headTree, tree); err != nil {
+		return err
+	}
+	return repo.UpdateRef("HEAD", commit, "checkout: moving from "+head+" to "+commit)
+}
+
+func bisectReset(ctx context.Context, repo *gogit.Repository, args []string) error {
+	data, err := vfs.ReadFile(repo.FS, bisectStartFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Println("We are not bisecting.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	start := strings.TrimSpace(string(data))
+	branch := ""
+	target := start
+	if len(args) == 1 {
+		target = args[0]
+	} else if strings.HasPrefix(start, "refs/") {
+		branch = start
+	}
+	commit, err := repo.ResolveCommit(target)
+	if err != nil {
+		return err
+	}
+	if err := bisectCheckout(ctx, repo, commit); err != nil {
+		return err
+	}
+	if branch != "" {
+		if err := repo.Refs.SetSymbolic("HEAD", branch); err != nil {
+			return err
+		}
+	}
+
+	list, err := repo.Refs.List()
+	if err != nil {
+		return err
+	}
+	for _, ref := range list {
+		if strings.HasPrefix(ref.Name, bisectRefs) {
+			if err := refs.Delete(repo.Refs, ref.Name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range []string{bisectLogFile, bisectStartFile} {
+		if err := repo.FS.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if branch != "" {
+		fmt.Printf("Switched to branch '%s'\n", shortRefName(branch))
+	} else {
+		fmt.Printf("HEAD is now at %s\n", commit[:7])
+	}
+	return nil
+}
+
+// bisectRun: 첫 나쁜 커밋을 찾을 때까지 지금 HEAD 에서 cmd 를 실행하고 종료 코드로 표시한다
+func bisectRun(ctx context.Context, repo *gogit.Repository, args []string) error {
+	if bad, good, _, err := bisectState(repo); err != nil {
+		return err
+	} else if bad == "" || len(good) == 0 {
+		return errors.New("bisect run needs both a good and a bad commit (use \"gogit bisect good/bad\" first)")
+	}
+	for {
+		fmt.Printf("running %s\n", strings.Join(args, " "))
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Dir = repo.WorkTree
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		err := cmd.Run()
+		code := 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			return fmt.Errorf("bisect run failed: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var term string
+		switch {
+		case code == 0:
+			term = "good"
+		case code == 125:
+			term = "skip"
+		case code > 0 && code < 128:
+			term = "bad"
+		default:
+			return fmt.Errorf("bisect run failed: exit code %d from '%s' is < 0 or >= 128", code, strings.Join(args, " "))
+		}
+		if err := bisectMark(repo, term, "HEAD"); err != nil {
+			return err
+		}
+		done, err := bisectNext(ctx, repo)
+		if err != nil {
+			return err
+		}
+		if done {
+			fmt.Println("bisect found first bad commit")
+			return nil
+		}
+	}
+}
+
+// Describe: 커밋을 가장 가까운 태그로 이름 짓는다 (git describe). 빌드 때 버전을 붙이는 데 쓴다.
+//
+//	describe [--tags] [<commit>...]   commit 을 생략하면 HEAD
+//
+// 태그가 붙은 커밋이면 태그 이름을, 아니면 "<태그>-<거리>-g<짧은 해시>" 를 출력한다.
+// 기본으로 annotated 태그만 쓰고, --tags 를 주면 lightweight 태그도 쓴다.
+func cmdDescribe(ctx context.Context, repo *gogit.Repository, args []string) error {
+	tags := false
+	var revs []string
+	for _, arg := range args {
+		switch {
+		case arg == "--tags":
+			tags = true
+		case strings.HasPrefix(arg, "-"):
+			return errors.New("usage: gogit describe [--tags] [<commit>...]")
+		default:
+			revs = append(revs, arg)
+		}
+	}
+	if len(revs) == 0 {
+		revs = []string{"HEAD"}
+	}
+	for _, rev := range revs {
+		commit, err := repo.ResolveCommit(rev)
+		if err != nil {
+			return err
+		}
+		name, err := repo.Describe(ctx, commit, tags)
+		if err != nil {
+			return err
+		}
+		fmt.Println(name)
+	}
+	return nil
+}
+
+// Ls-Tree: tree 의 항목 나열
+//
+//	-r           하위 디렉토리까지 전체 경로로 나열 (디렉토리 항목 자체는 빠짐)
+//	--name-only  경로만 출력
+//	-l           blob 크기를 함께 출력 (tree/submodule 은 "-")
+func cmdLsTree(repo *gogit.Repository, args []string) error {
+	var recursive, nameOnly, long bool
+	var rev string
+	for _, arg := range args {
+		switch arg {
+		case "-r":
+			recursive = true
+		case "--name-only", "--name-status":
+			nameOnly = true
+		case "-l", "--long":
+			long = true
+		default:
+			if rev != "" || strings.HasPrefix(arg, "-") {
+				return errors.New("usage: gogit ls-tree [-r] [--name-only] [-l] <tree-ish>")
+			}
+			rev = arg
+		}
+	}
+	if rev == "" {
+		return errors.New("usage: gogit ls-tree [-r] [--name-only] [-l] <tree-ish>")
+	}
+
+	treeHash, err := repo.ResolveTree(rev)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	show := func(e object.TreeEntry) error {
+		switch {
+		case nameOnly:
+			fmt.Fprintln(w, e.Name)
+		case long:
+			size := "-"
+			if e.Mode.ObjectType() == object.TypeBlob {
+				r, err := repo.Objects.Open(e.Hash)
+				if err != nil {
+					return err
+				}
+				size = strconv.FormatInt(r.Size, 10)
+				r.Close()
+			}
+			fmt.Fprintf(w, "%06o %s %s %7s\t%s\n", uint32(e.Mode), e.Mode.ObjectType(), e.Hash, size, e.Name)
+		default:
+			fmt.Fprintln(w, formatTreeEntry(e))
+		}
+		return nil
+	}
+
+	if recursive {
+		walker, err := object.NewTreeWalker(repo.Objects, treeHash)
+		if err != nil {
+			return err
+		}
+		err = walker.ForEach(func(entry object.WalkEntry) error {
+			if entry.Mode == object.ModeTree {
+				return nil
+			}
+			return show(object.TreeEntry{Mode: entry.Mode, Name: entry.Path, Hash: entry.Hash})
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		tree, err := object.ReadTree(repo.Objects, treeHash)
+		if err != nil {
+			return err
+		}
+		for _, e := range tree.Entries {
+			if err := show(e); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// Rev-List: 커밋 해시를 최신순으로 출력
+//
+//	rev-list [--all] [--count] [--max-count=<n> | -n <n> | -n<n>] [--topo-order] <rev>... [A..B] [A...B] [^A]
+func cmdRevList(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit rev-list [--all] [--count] [--max-count=<n>] [--topo-order] <revision-range>..."
+	count := false
+	all := false
+	maxCount := -1
+	order := object.OrderDate
+	var revs []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--count":
+			count = true
+		case arg == "--all":
+			all = true
+		case arg == "--topo-order":
+			order = object.OrderTopo
+		case arg == "-n" && i+1 < len(args):
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil {
+				return errors.New(usage)
+			}
+			maxCount = n
+		case strings.HasPrefix(arg, "-n") && isDigits(arg[2:]):
+			n, err := strconv.Atoi(arg[2:])
+			if err != nil {
+				return errors.New(usage)
+			}
+			maxCount = n
+		case strings.HasPrefix(arg, "--max-count="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--max-count="))
+			if err != nil {
+				return errors.New(usage)
+			}
+			maxCount = n
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			return errors.New(usage)
+		default:
+			revs = append(revs, arg)
+		}
+	}
+	if len(revs) == 0 && !all {
+		return errors.New(usage)
+	}
+
+	set, err := repo.ResolveRevSet(ctx, revs)
+	if err != nil {
+		return err
+	}
+	if all {
+		hashes, err := repo.AllRefs()
+		if err != nil {
+			return err
+		}
+		set.Include = append(set.Include, hashes...)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	n := 0
+	errStop := errors.New("stop")
+	err = set.Iter(repo.Objects, order).ForEachContext(ctx, func(hash string, _ *object.Commit) error {
+		if maxCount >= 0 && n >= maxCount {
+			return errStop
+		}
+		n++
+		if !count {
+			fmt.Fprintln(w, hash)
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return err
+	}
+	if count {
+		fmt.Fprintln(w, n)
+	}
+	return w.Flush()
+}
+
+// Shortlog: 커밋을 작성자별로 모아 제목을 나열한다 (git shortlog). 릴리스 노트의 기여자 목록에 쓴다.
+//
+//	shortlog [-s] [-n] [-e] [-c] [<revision range>...]   리비전을 주지 않으면 HEAD
+//
+//	-s, --summary    제목 없이 커밋 수만
+//	-n, --numbered   커밋이 많은 사람부터 (기본은 이름 순서)
+//	-e, --email      이메일도 보여 주고, 이메일이 다르면 다른 사람으로 센다
+//	-c, --committer  작성자 대신 커미터로 모은다
+//
+// 한 글자 옵션은 -sn 처럼 붙여 쓸 수 있다. .mailmap 이 있으면 git 과 같이 언제나 정식 이름으로 모은다.
+func cmdShortlog(ctx context.Context, repo *gogit.Repository, args []string) error {
+	const usage = "usage: gogit shortlog [-s] [-n] [-e] [-c] [<revision range>...]"
+	var opts shortlog.Options
+	order := shortlog.ByName
+	summary := false
+	var revs []string
+	for _, arg := range args {
+		switch {
+		case arg == "--summary":
+			summary = true
+		case arg == "--numbered":
+			order = shortlog.ByCount
+		case arg == "--email":
+			opts.Email = true
+		case arg == "--committer":
+			opts.Committer = true
+		case strings.HasPrefix(arg, "--"):
+			return errors.New(usage)
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for _, c := range arg[1:] {
+				switch c {
+				case 's':
+					summary = true
+				case 'n':
+					order = shortlog.ByCount
+				case 'e':
+					opts.Email = true
+				case 'c':
+					opts.Committer = true
+				default:
+					return errors.New(usage)
+				}
+			}
+		default:
+			revs = append(revs, arg)
+		}
+	}
+	if len(revs) == 0 {
+		revs = []string{"HEAD"}
+	}
+	set, err := repo.ResolveRevSet(ctx, revs)
+	if err != nil {
+		return err
+	}
+
+	m, err := repo.Mailmap()
+	if err != nil {
+		return err
+	}
+	opts.Map = m.Lookup
+	log := shortlog.New(opts)
+	err = set.Iter(repo.Objects, object.OrderDate).ForEachContext(ctx, func(_ string, c *object.Commit) error {
+		log.Add(c)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	if err := log.Write(w, order, summary); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// Log: 커밋 이력 출력. 리비전을 주지 않으면 HEAD
+//
+//	-n <n>, -n<n>, -<n>              출력할 커밋 수 (조건에 맞는 것만 센다). --max-count=<n> 도 같음
+//	--since=<date>, --until=<date>   committer 시간 범위 (--after/--before 도 같음)
+//	--author=<regex>, --grep=<regex> 작성자 / 메시지 조건. -i 를 주면 대소문자 무시
+//	--oneline, --pretty=<format>     출력 형식 (--format=<format> 도 같음)
+//	--graph                          이력을 ASCII 그래프로 그린다 (--topo-order 로 순회)
+//	-p, -u, --patch                  각 커밋이 첫 부모에 대해 바꾼 내용을 patch 로 출력 (merge 는 제외)
+//	--[no-]use-mailmap               .mailmap 으로 작성자를 바꿀지 (--[no-]mailmap 도 같음).
+//	                                 주지 않으면 config 의 log.mailmap 을 따르고 기본은 바꾼다
+//	--notes[=<ref>], --no-notes      커밋에 붙은 메모를 보여 줄지와 그 ref. 기본은 core.notesRef 의 메모를 보여 준다
+func cmdLog(ctx context.Context, repo *gogit.Repository, args []string) error {
+	maxCount := -1
+	ignoreCase := false
+	all := false
+	graph := false
+	patch := false
+	format := pretty.Medium
+	abbrev := false
+	var useMailmap *bool
+	showNotes := true
+	var notesRefName string
+	var authors, greps, revs []string
+	var filter gogit.CommitFilter
+	now := time.Now()
+
+	value := func(i *int, name string) (string, bool) {
+		arg := args[*i]
+		if v, ok := strings.CutPrefix(arg, name+"="); ok {
+			return v, true
+		}
+		if arg == name && *i+1 < len(args) {
+			*i++
+			return args[*i], true
+		}
+		return "", false
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if v, ok := value(&i, "-n"); ok {
+			arg = "--max-count=" + v
+		} else if v, ok := strings.CutPrefix(arg, "-n"); ok && isDigits(v) {
+			arg = "--max-count=" + v
+		} else if n, err := strconv.Atoi(strings.TrimPrefix(arg, "-")); err == nil && strings.HasPrefix(arg, "-") {
+			arg = "--max-count=" + strconv.Itoa(n)
+		}
+
+		if v, ok := strings.CutPrefix(arg, "--max-count="); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid count: %s", v)
+			}
+			maxCount = n
+			continue
+		}
+		if arg == "-i" || arg == "--regexp-ignore-case" {
+			ignoreCase = true
+			continue
+		}
+		if arg == "--" {
+			continue
+		}
+		if arg == "--all" {
+			all = true
+			continue
+		}
+		if arg == "--graph" {
+			graph = true
+			continue
+		}
+		if arg == "-p" || arg == "-u" || arg == "--patch" {
+			patch = true
+			continue
+		}
+		if arg == "--oneline" {
+			format, abbrev = pretty.Oneline, true
+			continue
+		}
+		if use, ok := mailmapFlag(arg); ok {
+			useMailmap = &use
+			continue
+		}
+		if arg == "--notes" || arg == "--no-notes" || strings.HasPrefix(arg, "--notes=") {
+			showNotes = arg != "--no-notes"
+			notesRefName = strings.TrimPrefix(strings.TrimPrefix(arg, "--notes"), "=")
+			continue
+		}
+		if v, ok := strings.CutPrefix(arg, "--pretty="); ok {
+			f, err := pretty.ParseFormat(v)
+			if err != nil {
+				return err
+			}
+			format = f
+			continue
+		}
+		if v, ok := value(&i, "--format"); ok {
+			// --format=<f> 는 --pretty=tformat:<f> 와 같다
+			f, err := pretty.ParseFormat(v)
+			if err != nil {
+				return err
+			}
+			if f.Name == "format" {
+				f.Separator = false
+			}
+			format = f
+			continue
+		}
+
+		handled := false
+		for _, name := range []string{"--since", "--after", "--until", "--before"} {
+			v, ok := value(&i, name)
+			if !ok {
+				continue
+			}
+			t, err := gogit.ParseDate(v, now)
+			if err != nil {
+				return err
+			}
+			if name == "--since" || name == "--after" {
+				filter.Since = t
+			} else {
+				filter.Until = t
+			}
+			handled = true
+			break
+		}
+		if handled {
+			continue
+		}
+		if v, ok := value(&i, "--author"); ok {
+			authors = append(authors, v)
+			continue
+		}
+		if v, ok := value(&i, "--grep"); ok {
+			greps = append(greps, v)
+			continue
+		}
+		if strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("unknown log option: %s", arg)
+		}
+		revs = append(revs, arg)
+	}
+
+	compile := func(patterns []string) ([]*regexp.Regexp, error) {
+		var result []*regexp.Regexp
+		for _, p := range patterns {
+			if ignoreCase {
+				p = "(?i)" + p
+			}
+			re, err := gogit.CompileLinePattern(p)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, re)
+		}
+		return result, nil
+	}
+	var err error
+	if filter.Authors, err = compile(authors); err != nil {
+		return err
+	}
+	if filter.Greps, err = compile(greps); err != nil {
+		return err
+	}
+
+	if len(revs) == 0 && !all {
+		revs = []string{"HEAD"}
+	}
+	set, err := repo.ResolveRevSet(ctx, revs)
+	if err != nil {
+		return err
+	}
+	if all {
+		hashes, err := repo.AllRefs()
+		if err != nil {
+			return err
+		}
+		set.Include = append(set.Include, hashes...)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	pw := pretty.NewWriter(w, format, abbrev)
+	if err := setOutputEncoding(repo, pw); err != nil {
+		return err
+	}
+	if err := setMailmap(repo, pw, useMailmap); err != nil {
+		return err
+	}
+	if showNotes {
+		if err := setNotes(repo, pw, notesRefName); err != nil {
+			return err
+		}
+	}
+	order := object.OrderDate
+	if graph {
+		pw.SetGraph(pretty.NewGraph(func(hash string) bool { return !set.Hidden[hash] }))
+		order = object.OrderTopo
+	}
+	n := 0
+	errStop := errors.New("stop")
+	err = set.Iter(repo.Objects, order).ForEachContext(ctx, func(hash string, commit *object.Commit) error {
+		if !filter.Match(commit) {
+			return nil
+		}
+		if maxCount >= 0 && n >= maxCount {
+			return errStop
+		}
+		n++
+		if err := pw.Write(hash, commit); err != nil {
+			return err
+		}
+		if !patch {
+			return nil
+		}
+		text, err := commitPatch(repo, commit)
+		if err != nil {
+			return err
+		}
+		return pw.WritePatch(text)
+	})
+	if err != nil && err != errStop {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/gogit"
+)
+
+// newTestRepo: 임시 디렉토리에 저장소를 만들고 작성자/커미터를 환경 변수로 정한다.
+func newTestRepo(t *testing.T) *gogit.Repository {
+	t.Helper()
+	t.Setenv("GOGIT_AUTHOR_NAME", "Tester")
+	t.Setenv("GOGIT_AUTHOR_EMAIL", "tester@example.com")
+	t.Setenv("GOGIT_COMMITTER_NAME", "Tester")
+	t.Setenv("GOGIT_COMMITTER_EMAIL", "tester@example.com")
+	repo, err := gogit.Init(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+// writeWorkFile: 작업 트리의 name 에 content 를 쓴다. 상위 디렉토리가 없으면 만든다.
+func writeWorkFile(t *testing.T, repo *gogit.Repository, name, content string) {
+	t.Helper()
+	p := filepath.Join(repo.WorkTree, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// commitWorkTree: 작업 트리를 스냅샷해서 HEAD 위에 커밋하고 HEAD 를 옮긴다.
+func commitWorkTree(t *testing.T, repo *gogit.Repository, message string) string {
+	t.Helper()
+	ctx := context.Background()
+	tree, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parents []string
+	if head, err := repo.ResolveCommit("HEAD"); err == nil {
+		parents = []string{head}
+	}
+	author, err := repo.Author()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := createCommit(repo, parents, tree, author, message+"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateHead(hash, "commit: "+message); err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
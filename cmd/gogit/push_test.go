@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/gogit"
+)
+
+// installHook: hooks 디렉토리에 실행 가능한 sh 스크립트를 둔다
+func installHook(t *testing.T, repo *gogit.Repository, name, script string) {
+	t.Helper()
+	p := filepath.Join(repo.CommonDir, "hooks", name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// pre-push 가 실패하면 bundle 을 쓰지 않고, 성공하면 remote 이름/주소와 바뀐 ref 를 넘긴 뒤 보낸다
+func TestPushMirrorRunsPrePush(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "f", "one\n")
+	head := commitWorkTree(t, repo, "one")
+	dest := filepath.Join(t.TempDir(), "mirror.bundle")
+	record := filepath.Join(t.TempDir(), "hook.txt")
+
+	installHook(t, repo, "pre-push", "echo \"$1 $2\" > "+record+"\ncat >> "+record+"\nexit 1\n")
+	err := cmdPush(ctx, repo, []string{"--mirror", dest})
+	if err == nil {
+		t.Fatal("push succeeded although pre-push failed")
+	}
+	if _, err := os.Stat(dest); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("bundle written although pre-push failed: %v", err)
+	}
+	data, err := os.ReadFile(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zero := repo.Objects.Algorithm().ZeroHash()
+	want := dest + " " + dest + "\nrefs/heads/master " + head + " refs/heads/master " + zero + "\n"
+	if string(data) != want {
+		t.Errorf("pre-push got\n%s\nwant\n%s", data, want)
+	}
+
+	if err := cmdPush(ctx, repo, []string{"--mirror", "--no-verify", dest}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("push --no-verify did not write the bundle: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/gogit/config"
+)
+
+// TestVerifyCommit: ssh 로 서명한 커밋은 verify-commit 을 통과하고, 서명 없는 커밋은 실패한다.
+func TestVerifyCommit(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not found")
+	}
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	dir := t.TempDir()
+	key := filepath.Join(dir, "id_ed25519")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen: %v\n%s", err, out)
+	}
+	pub, err := os.ReadFile(key + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := filepath.Join(dir, "allowed_signers")
+	if err := os.WriteFile(signers, []byte("tester@example.com "+string(pub)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = repo.EditConfig(func(data []byte) ([]byte, error) {
+		for _, kv := range [][2]string{{"gpg.format", "ssh"}, {"user.signingKey", key}, {"gpg.ssh.allowedSignersFile", signers}} {
+			if data, err = config.Add(data, kv[0], kv[1]); err != nil {
+				return nil, err
+			}
+		}
+		return data, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeWorkFile(t, repo, "a.txt", "one\n")
+	unsigned := commitWorkTree(t, repo, "unsigned")
+	signer, err := signerFor(repo, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := snapshotWorkTree(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	author, err := repo.Author()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := createSignedCommit(repo, []string{unsigned}, tree, author, "signed\n", signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmdVerifyCommit(repo, []string{signed}); err != nil {
+		t.Errorf("verify-commit of a signed commit: %v", err)
+	}
+	if err := cmdVerifyCommit(repo, []string{unsigned}); err == nil {
+		t.Error("verify-commit of an unsigned commit: no error")
+	}
+	if err := cmdVerifyCommit(repo, []string{signed, unsigned}); err == nil {
+		t.Error("verify-commit with one unsigned commit: no error")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/gogit"
+)
+
+// stashRepo: f 를 base 에서 upstream 으로 바꾼 커밋 두 개와, base 위에서 f 를 local 로 바꾼 autostash 를 만든다.
+// HEAD 와 작업 트리는 upstream 커밋이다.
+func stashRepo(t *testing.T, base, upstream, local string) (*gogit.Repository, string) {
+	t.Helper()
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	write := func(content string) string {
+		t.Helper()
+		writeWorkFile(t, repo, "f", content)
+		tree, err := snapshotWorkTree(ctx, repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tree
+	}
+	author, err := repo.Author()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := createCommit(repo, nil, write(base), author, "base\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stash, err := createAutostash(repo, "refs/heads/master", root, write(local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := createCommit(repo, []string{root}, write(upstream), author, "upstream\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateHead(head, "commit: upstream"); err != nil {
+		t.Fatal(err)
+	}
+	return repo, stash
+}
+
+func readWorkFile(t *testing.T, repo *gogit.Repository) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(repo.WorkTree, "f"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// autostash 가 충돌하면 refs/stash 에 남기고, stash pop 으로 충돌 표시와 함께 되살린다
+func TestAutostashConflictPop(t *testing.T) {
+	ctx := context.Background()
+	repo, stash := stashRepo(t, "a\nb\nc\n", "a\nB\nc\n", "a\nX\nc\n")
+
+	if err := applyAutostash(ctx, repo, stash); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := repo.Refs.Resolve("refs/stash"); err != nil || got != stash {
+		t.Fatalf("refs/stash = %s, %v; want %s", got, err, stash)
+	}
+	if got := readWorkFile(t, repo); got != "a\nB\nc\n" {
+		t.Fatalf("autostash conflict changed the work tree: %q", got)
+	}
+
+	err := cmdStash(ctx, repo, []string{"pop"})
+	if !errors.Is(err, errConflict) {
+		t.Fatalf("stash pop = %v, want a conflict", err)
+	}
+	got := readWorkFile(t, repo)
+	if !strings.Contains(got, "<<<<<<< HEAD\nB\n=======\nX\n>>>>>>> Stashed changes\n") {
+		t.Errorf("work tree after stash pop:\n%s", got)
+	}
+	if got, err := repo.Refs.Resolve("refs/stash"); err != nil || got != stash {
+		t.Errorf("stash pop with conflicts dropped refs/stash: %s, %v", got, err)
+	}
+}
+
+func TestStashPopClean(t *testing.T) {
+	ctx := context.Background()
+	repo, stash := stashRepo(t, "a\nb\nc\n", "A\nb\nc\n", "a\nb\nC\n")
+	if err := repo.UpdateRef("refs/stash", stash, "autostash"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmdStash(ctx, repo, []string{"pop"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readWorkFile(t, repo); got != "A\nb\nC\n" {
+		t.Errorf("work tree after stash pop: %q", got)
+	}
+	if _, err := repo.Refs.Resolve("refs/stash"); err == nil {
+		t.Error("refs/stash still exists after a clean pop")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/gogit"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// TestSubmoduleAddUpdate: add 는 bundle 을 받아 목록 파일과 config 에 적고, 커밋하면 gitlink 가 되며,
+// 지운 뒤 update --init 은 그 커밋을 detached HEAD 로 다시 받는다.
+func TestSubmoduleAddUpdate(t *testing.T) {
+	ctx := context.Background()
+	lib := newTestRepo(t)
+	writeWorkFile(t, lib, "m.txt", "lib\n")
+	libHead := commitWorkTree(t, lib, "lib")
+	file := filepath.Join(t.TempDir(), "lib.bundle")
+	if err := cmdBundle(ctx, lib, []string{"create", file, "--all"}); err != nil {
+		t.Fatal(err)
+	}
+
+	super := newTestRepo(t)
+	t.Chdir(super.WorkTree)
+	if err := cmdSubmodule(ctx, gogit.Options{}, super, []string{"add", file, "lib"}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(super.WorkTree, "lib", "m.txt")); string(data) != "lib\n" {
+		t.Errorf("lib/m.txt = %q", data)
+	}
+	modules, err := super.Submodules()
+	if err != nil || len(modules) != 1 || modules[0].Path != "lib" || modules[0].URL != file {
+		t.Fatalf("modules = %+v, %v", modules, err)
+	}
+	if err := cmdSubmodule(ctx, gogit.Options{}, super, []string{"add", file, "lib"}); err == nil {
+		t.Error("second add of lib: no error")
+	}
+
+	commitWorkTree(t, super, "add lib")
+	entry, ok, err := object.LookupPath(super.Objects, mustTree(t, super), "lib")
+	if err != nil || !ok || entry.Mode != object.ModeGitlink || entry.Hash != libHead {
+		t.Fatalf("lib entry = %+v, %t, %v", entry, ok, err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(super.WorkTree, "lib")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdSubmodule(ctx, gogit.Options{}, super, []string{"update", "--init"}); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := super.OpenSubmodule("lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := sub.Refs.Read("HEAD")
+	if err != nil || head.Target != "" || head.Hash != libHead {
+		t.Errorf("submodule HEAD = %+v, %v; want detached at %s", head, err, libHead)
+	}
+}
+
+// TestSubmoduleAddRefusesBadPath: 적을 수 없는 경로면 clone 하지 않고 멈춘다.
+func TestSubmoduleAddRefusesBadPath(t *testing.T) {
+	ctx := context.Background()
+	lib := newTestRepo(t)
+	writeWorkFile(t, lib, "m.txt", "lib\n")
+	commitWorkTree(t, lib, "lib")
+	file := filepath.Join(t.TempDir(), "lib.bundle")
+	if err := cmdBundle(ctx, lib, []string{"create", file, "--all"}); err != nil {
+		t.Fatal(err)
+	}
+
+	super := newTestRepo(t)
+	t.Chdir(super.WorkTree)
+	for _, p := range []string{"../outside", "x/.git", "x/.gogit/hooks"} {
+		err := cmdSubmodule(ctx, gogit.Options{}, super, []string{"add", file, p})
+		if err == nil || !strings.Contains(err.Error(), p) {
+			t.Errorf("add %s: err = %v", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(super.WorkTree, "x")); err == nil {
+		t.Error("cloned into a refused path")
+	}
+	if _, err := os.Stat(filepath.Join(super.WorkTree, super.ModulesFile())); err == nil {
+		t.Error("modules file written after a refused add")
+	}
+}
+
+func mustTree(t *testing.T, repo *gogit.Repository) string {
+	t.Helper()
+	tree, err := repo.ResolveTree("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/gogit"
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/refs"
+)
+
+// libCommit: 작업 트리와 상관없이 m.txt 하나를 가진 루트 커밋을 만든다
+func libCommit(t *testing.T, repo *gogit.Repository, content string) string {
+	t.Helper()
+	blob, err := repo.Objects.Write(object.TypeBlob, []byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := object.WriteObject(repo.Objects, &object.Tree{Entries: []object.TreeEntry{{Mode: object.ModeRegular, Name: "m.txt", Hash: blob}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	author, err := repo.Author()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := createCommit(repo, nil, tree, author, "lib\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+// subtree add 는 작업 트리에도 파일을 쓰므로 다음 commit 이 들여온 디렉토리를 지우지 않는다
+func TestSubtreeAddUpdatesWorkTree(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "README", "app\n")
+	commitWorkTree(t, repo, "initial")
+	lib := libCommit(t, repo, "lib\n")
+
+	if err := cmdSubtree(ctx, repo, []string{"add", "--prefix=vendor/lib", lib}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(repo.WorkTree, "vendor", "lib", "m.txt"))
+	if err != nil || string(data) != "lib\n" {
+		t.Fatalf("vendor/lib/m.txt = %q, %v", data, err)
+	}
+
+	writeWorkFile(t, repo, "README", "app v2\n")
+	next := commitWorkTree(t, repo, "next")
+	tree, err := repo.ResolveTree(next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := object.LookupPath(repo.Objects, tree, "vendor/lib/m.txt"); err != nil || !ok {
+		t.Errorf("commit after subtree add lost vendor/lib/m.txt (%v)", err)
+	}
+}
+
+func TestSubtreeAddRefusesLocalChanges(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "README", "app\n")
+	head := commitWorkTree(t, repo, "initial")
+	writeWorkFile(t, repo, "README", "local edit\n")
+
+	err := cmdSubtree(ctx, repo, []string{"add", "--prefix=vendor/lib", libCommit(t, repo, "lib\n")})
+	if err == nil || !strings.Contains(err.Error(), "local changes") {
+		t.Fatalf("subtree add with local changes = %v", err)
+	}
+	if got, _ := repo.ResolveCommit("HEAD"); got != head {
+		t.Errorf("HEAD moved to %s", got)
+	}
+}
+
+// split -b 는 이미 있는 브랜치를 fast-forward 로만 옮기고 reflog 에 남긴다
+func TestSubtreeSplitBranch(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "lib/m.txt", "lib\n")
+	commitWorkTree(t, repo, "initial")
+
+	if err := cmdSubtree(ctx, repo, []string{"split", "--prefix=lib", "-b", "split"}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := refs.ReadReflog(repo.Refs, "refs/heads/split")
+	if err != nil || len(entries) != 1 || entries[0].Message != "subtree split: lib" {
+		t.Fatalf("reflog of split = %v, %v", entries, err)
+	}
+
+	other := libCommit(t, repo, "unrelated\n")
+	if err := repo.Refs.Update("refs/heads/split", other); err != nil {
+		t.Fatal(err)
+	}
+	err = cmdSubtree(ctx, repo, []string{"split", "--prefix=lib", "-b", "split"})
+	if err == nil || !strings.Contains(err.Error(), "not an ancestor") {
+		t.Fatalf("split over an unrelated branch = %v", err)
+	}
+	if got, _ := repo.Refs.Resolve("refs/heads/split"); got != other {
+		t.Errorf("split overwrote the branch with %s", got)
+	}
+}
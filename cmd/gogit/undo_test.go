@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/gogit/vfs"
+	"github.com/tmdgusya/gogit/worktree"
+)
+
+// TestUndoAmRestoresWorkTree: am 으로 적용한 패치를 undo 하면 HEAD 와 작업 트리의 파일이 함께 돌아간다.
+func TestUndoAmRestoresWorkTree(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "f.txt", "one\n")
+	base := commitWorkTree(t, repo, "base")
+	writeWorkFile(t, repo, "f.txt", "two\n")
+	commitWorkTree(t, repo, "change")
+
+	dir := t.TempDir()
+	if err := cmdFormatPatch(ctx, repo, []string{"-o", dir, "-1"}); err != nil {
+		t.Fatal(err)
+	}
+	patches, err := filepath.Glob(filepath.Join(dir, "*.patch"))
+	if err != nil || len(patches) != 1 {
+		t.Fatalf("patches = %v, %v", patches, err)
+	}
+
+	// base 로 돌아가서 am 으로 다시 적용한다
+	from, err := repo.ResolveTree("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := repo.ResolveTree(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := worktree.Checkout(ctx, vfs.NewOS(repo.WorkTree), repo.Objects, from, to); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateHead(base, "reset: moving to base"); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"am", patches[0]}
+	op := journalStart(ctx, repo, args)
+	if err := cmdAm(ctx, repo, args[1:]); err != nil {
+		t.Fatal(err)
+	}
+	journalEnd(repo, op)
+	if data, _ := os.ReadFile(filepath.Join(repo.WorkTree, "f.txt")); string(data) != "two\n" {
+		t.Fatalf("after am f.txt = %q", data)
+	}
+
+	if err := cmdUndo(ctx, repo, nil); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := repo.ResolveCommit("HEAD"); head != base {
+		t.Errorf("HEAD = %s, want %s", head, base)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo.WorkTree, "f.txt")); string(data) != "one\n" {
+		t.Errorf("after undo f.txt = %q, want %q", data, "one\n")
+	}
+}
+
+// TestUndoRefusesDuringAmOrBisect: am 이나 bisect 가 진행 중이면 undo 하지 않는다.
+func TestUndoRefusesDuringAmOrBisect(t *testing.T) {
+	ctx := context.Background()
+	for _, state := range []string{amDir + "/next", bisectStartFile} {
+		t.Run(state, func(t *testing.T) {
+			repo := newTestRepo(t)
+			writeWorkFile(t, repo, "f.txt", "one\n")
+			op := journalStart(ctx, repo, []string{"commit"})
+			commitWorkTree(t, repo, "base")
+			journalEnd(repo, op)
+
+			if err := vfs.WriteFile(repo.FS, state, []byte("1\n")); err != nil {
+				t.Fatal(err)
+			}
+			err := cmdUndo(ctx, repo, nil)
+			if err == nil || !strings.Contains(err.Error(), "in progress") {
+				t.Fatalf("undo err = %v, want operation in progress", err)
+			}
+		})
+	}
+}
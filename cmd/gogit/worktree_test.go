@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/gogit"
+)
+
+func TestWorktreeAddRemove(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	writeWorkFile(t, repo, "a.txt", "one\n")
+	head := commitWorkTree(t, repo, "base")
+	path := filepath.Join(t.TempDir(), "wt")
+
+	if err := cmdWorktree(ctx, gogit.Options{}, repo, []string{"add", "-b", "topic", path}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(path, "a.txt")); string(data) != "one\n" {
+		t.Errorf("checked out a.txt = %q", data)
+	}
+	if got, err := repo.Refs.Resolve("refs/heads/topic"); err != nil || got != head {
+		t.Errorf("topic = %s, %v; want %s", got, err, head)
+	}
+	linked, err := gogit.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref, err := linked.Refs.Read("HEAD"); err != nil || ref.Target != "refs/heads/topic" {
+		t.Errorf("linked HEAD = %+v, %v", ref, err)
+	}
+
+	// 같은 브랜치를 두 곳에서 받을 수 없다
+	err = cmdWorktree(ctx, gogit.Options{}, repo, []string{"add", filepath.Join(t.TempDir(), "again"), "topic"})
+	if err == nil || !strings.Contains(err.Error(), "already checked out") {
+		t.Errorf("second checkout of topic: err = %v", err)
+	}
+	if err := cmdWorktree(ctx, gogit.Options{}, repo, []string{"add", "-b", "topic", filepath.Join(t.TempDir(), "x")}); err == nil {
+		t.Error("-b with an existing branch: no error")
+	}
+
+	// 바뀐 파일이 있으면 --force 없이는 지우지 않는다
+	if err := os.WriteFile(filepath.Join(path, "a.txt"), []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdWorktree(ctx, gogit.Options{}, repo, []string{"remove", path}); err == nil {
+		t.Fatal("remove with local changes: no error")
+	}
+	if err := cmdWorktree(ctx, gogit.Options{}, repo, []string{"remove", "--force", path}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("work tree still exists: %v", err)
+	}
+	list, err := repo.Worktrees()
+	if err != nil || len(list) != 1 {
+		t.Errorf("Worktrees = %+v, %v", list, err)
+	}
+	if err := cmdWorktree(ctx, gogit.Options{}, repo, []string{"remove", repo.WorkTree}); err == nil {
+		t.Error("removed the main work tree")
+	}
+}
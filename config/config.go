@@ -0,0 +1,246 @@
+// Package config 는 git config 형식(.gogit/config)의 파일을 읽고 고친다.
+//
+//	[core]
+//		bare = false
+//	[remote "origin"]
+//		url = https://example.com/repo.git
+//
+// 섹션과 키 이름은 대소문자를 구분하지 않고, 하위 섹션("origin")은 구분한다.
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Config: 파싱된 설정. 같은 키가 여러 번 나올 수 있으므로 값은 순서대로 모두 보관한다.
+type Config struct {
+	entries []entry
+}
+
+type entry struct {
+	section    string
+	subsection string
+	key        string
+	value      string
+}
+
+// Parse: config 파일 내용을 해석한다.
+func Parse(data []byte) (*Config, error) {
+	lines, err := scan(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	for _, l := range lines {
+		if l.key != "" {
+			cfg.entries = append(cfg.entries, l.entry)
+		}
+	}
+	return cfg, nil
+}
+
+// line: 파일의 한 줄. 섹션 머리면 header, 값이 있는 줄이면 key 가 채워져 있다.
+// 빈 줄과 주석도 앞의 섹션에 속한다.
+type line struct {
+	entry
+	text   string
+	header bool
+}
+
+// scan: 파일을 줄마다 해석한다. Parse 와 편집 함수들(edit.go)이 함께 쓴다.
+func scan(data []byte) ([]line, error) {
+	var lines []line
+	var section, subsection string
+
+	text := strings.Split(string(data), "\n")
+	// 줄바꿈으로 끝나는 파일의 마지막 빈 조각은 줄이 아니다
+	if len(text) > 0 && text[len(text)-1] == "" {
+		text = text[:len(text)-1]
+	}
+	for i, raw := range text {
+		l := line{text: raw}
+		trimmed := strings.TrimSpace(raw)
+		switch {
+		case trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';':
+		case trimmed[0] == '[':
+			end := strings.Index(trimmed, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("config line %d: unterminated section header", i+1)
+			}
+			header := trimmed[1:end]
+			name, sub, hasSub := strings.Cut(header, " ")
+			section = strings.ToLower(strings.TrimSpace(name))
+			subsection = ""
+			if hasSub {
+				subsection = strings.Trim(strings.TrimSpace(sub), `"`)
+			}
+			l.header = true
+		case section == "":
+			return nil, fmt.Errorf("config line %d: key outside of section", i+1)
+		default:
+			key, value, hasValue := strings.Cut(trimmed, "=")
+			l.key = strings.ToLower(strings.TrimSpace(key))
+			if hasValue {
+				l.value = parseValue(value)
+			} else {
+				// "key" 만 있으면 true 로 본다 (git 과 동일)
+				l.value = "true"
+			}
+		}
+		l.section, l.subsection = section, subsection
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
+// 값 해석: 따옴표 밖의 주석(#, ;)을 지우고 따옴표와 이스케이프를 푼다.
+func parseValue(raw string) string {
+	var b strings.Builder
+	inQuote := false
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case c == '\\' && i+1 < len(raw):
+			i++
+			switch raw[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(raw[i])
+			}
+		case (c == '#' || c == ';') && !inQuote:
+			return strings.TrimSpace(b.String())
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// splitName: "core.bare" 또는 "remote.origin.url" 을 섹션/하위 섹션/키로 나눈다.
+func splitName(name string) (section string, subsection string, key string) {
+	first := strings.Index(name, ".")
+	last := strings.LastIndex(name, ".")
+	if first == -1 {
+		return strings.ToLower(name), "", ""
+	}
+	section = strings.ToLower(name[:first])
+	key = strings.ToLower(name[last+1:])
+	if first != last {
+		subsection = name[first+1 : last]
+	}
+	return section, subsection, key
+}
+
+// GetAll: 이름에 해당하는 모든 값 (파일에 나온 순서)
+func (c *Config) GetAll(name string) []string {
+	if c == nil {
+		return nil
+	}
+	section, subsection, key := splitName(name)
+
+	var values []string
+	for _, e := range c.entries {
+		if e.section == section && e.subsection == subsection && e.key == key {
+			values = append(values, e.value)
+		}
+	}
+	return values
+}
+
+// Get: 마지막 값 (git 과 같이 뒤에 나온 값이 앞의 값을 덮어쓴다)
+func (c *Config) Get(name string) (string, bool) {
+	values := c.GetAll(name)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// GetBool: true/yes/on/1 과 false/no/off/0 을 해석한다. 없으면 def
+func (c *Config) GetBool(name string, def bool) (bool, error) {
+	value, ok := c.Get(name)
+	if !ok {
+		return def, nil
+	}
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	}
+	return def, fmt.Errorf("bad boolean config value '%s' for '%s'", value, name)
+}
+
+// GetInt: 정수 값. k/m/g 단위를 지원한다. 없으면 def
+func (c *Config) GetInt(name string, def int64) (int64, error) {
+	value, ok := c.Get(name)
+	if !ok || value == "" {
+		return def, nil
+	}
+
+	multiplier := int64(1)
+	switch strings.ToLower(value[len(value)-1:]) {
+	case "k":
+		multiplier = 1 << 10
+	case "m":
+		multiplier = 1 << 20
+	case "g":
+		multiplier = 1 << 30
+	}
+	if multiplier != 1 {
+		value = value[:len(value)-1]
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return def, fmt.Errorf("bad numeric config value '%s' for '%s'", value, name)
+	}
+	return n * multiplier, nil
+}
+
+// Subsections: 섹션 아래의 하위 섹션 이름들 (예: remote 섹션의 "origin")
+func (c *Config) Subsections(section string) []string {
+	if c == nil {
+		return nil
+	}
+	section = strings.ToLower(section)
+
+	var names []string
+	seen := map[string]bool{}
+	for _, e := range c.entries {
+		if e.section == section && e.subsection != "" && !seen[e.subsection] {
+			seen[e.subsection] = true
+			names = append(names, e.subsection)
+		}
+	}
+	return names
+}
+
+// RewriteURL: url.<base>.insteadOf 로 url 을 고친다. 여러 개가 맞으면 가장 긴 접두사를 쓴다. (git 과 같다)
+// push 가 true 면 url.<base>.pushInsteadOf 를 쓴다. 맞는 것이 없으면 ok 가 false 다.
+func (c *Config) RewriteURL(url string, push bool) (rewritten string, ok bool) {
+	if c == nil {
+		return url, false
+	}
+	key := "insteadof"
+	if push {
+		key = "pushinsteadof"
+	}
+	best, base := "", ""
+	for _, e := range c.entries {
+		if e.section == "url" && e.key == key && strings.HasPrefix(url, e.value) && (!ok || len(e.value) > len(best)) {
+			best, base, ok = e.value, e.subsection, true
+		}
+	}
+	if !ok {
+		return url, false
+	}
+	return base + strings.TrimPrefix(url, best), true
+}
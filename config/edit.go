@@ -0,0 +1,139 @@
+package config
+
+import (
+	"strings"
+)
+
+// 편집 함수들은 config 파일 내용을 받아 고친 내용을 돌려준다.
+// 고치는 줄 말고는 주석, 빈 줄, 들여쓰기를 그대로 둔다.
+//
+//	data, err := config.Add(data, "remote.origin.pushurl", "ssh://a/repo.git")
+//	data, n, err := config.Unset(data, "remote.origin.url", nil)
+
+// Add: name 에 value 를 하나 더한다. 같은 섹션이 있으면 그 섹션(여럿이면 마지막)의 끝에,
+// 없으면 파일 끝에 섹션을 새로 만든다. 파일이 잘못되었으면 에러다.
+func Add(data []byte, name, value string) ([]byte, error) {
+	lines, err := scan(data)
+	if err != nil {
+		return nil, err
+	}
+	section, subsection, _ := splitName(name)
+	// 파일에는 주어진 대소문자 그대로 쓴다 (insteadOf 등)
+	text := "\t" + name[strings.LastIndex(name, ".")+1:] + " = " + formatValue(value)
+
+	at := -1
+	for i, l := range lines {
+		if l.section == section && l.subsection == subsection && (l.header || l.key != "") {
+			at = i + 1
+		}
+	}
+	out := texts(lines)
+	if at < 0 {
+		out = append(out, formatHeader(section, subsection), text)
+	} else {
+		out = append(out[:at], append([]string{text}, out[at:]...)...)
+	}
+	return join(out), nil
+}
+
+// Unset: name 의 값 중 match 가 true 인 것을 지우고 지운 수를 돌려준다. match 가 nil 이면 모두 지운다.
+func Unset(data []byte, name string, match func(value string) bool) ([]byte, int, error) {
+	lines, err := scan(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	section, subsection, key := splitName(name)
+	var out []string
+	n := 0
+	for _, l := range lines {
+		if l.section == section && l.subsection == subsection && l.key == key && (match == nil || match(l.value)) {
+			n++
+			continue
+		}
+		out = append(out, l.text)
+	}
+	return join(out), n, nil
+}
+
+// Replace: name 의 값 중 match 가 true 인 처음 값을 value 로 바꾼다. 바꾼 값이 없으면 ok 가 false 다.
+// match 가 nil 이면 처음 값을 바꾼다.
+func Replace(data []byte, name, value string, match func(value string) bool) (_ []byte, ok bool, err error) {
+	lines, err := scan(data)
+	if err != nil {
+		return nil, false, err
+	}
+	section, subsection, key := splitName(name)
+	out := texts(lines)
+	for i, l := range lines {
+		if l.section == section && l.subsection == subsection && l.key == key && (match == nil || match(l.value)) {
+			indent := l.text[:len(l.text)-len(strings.TrimLeft(l.text, " \t"))]
+			out[i] = indent + name[strings.LastIndex(name, ".")+1:] + " = " + formatValue(value)
+			return join(out), true, nil
+		}
+	}
+	return data, false, nil
+}
+
+// RemoveSection: 섹션을 안의 값, 주석과 함께 모두 지운다. 없었으면 ok 가 false 다.
+func RemoveSection(data []byte, section, subsection string) (_ []byte, ok bool, err error) {
+	lines, err := scan(data)
+	if err != nil {
+		return nil, false, err
+	}
+	section = strings.ToLower(section)
+	var out []string
+	for _, l := range lines {
+		if l.section == section && l.subsection == subsection {
+			ok = true
+			continue
+		}
+		out = append(out, l.text)
+	}
+	return join(out), ok, nil
+}
+
+func texts(lines []line) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = l.text
+	}
+	return out
+}
+
+func join(lines []string) []byte {
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// formatHeader: [section] 또는 [section "subsection"]
+func formatHeader(section, subsection string) string {
+	if subsection == "" {
+		return "[" + section + "]"
+	}
+	return "[" + section + " \"" + subsection + "\"]"
+}
+
+// formatValue: parseValue 가 같은 값으로 읽도록 필요하면 따옴표와 이스케이프를 붙인다
+func formatValue(value string) string {
+	var b strings.Builder
+	quote := value != strings.TrimSpace(value) || strings.ContainsAny(value, "#;")
+	for _, c := range value {
+		switch c {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	if quote {
+		return `"` + b.String() + `"`
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package gogit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// describeCandidates: git describe 와 같이 가까운 태그 후보를 이만큼 찾으면 더 찾지 않는다
+const describeCandidates = 10
+
+// describeTag: 커밋을 가리키는 태그 하나
+type describeTag struct {
+	name      string
+	annotated bool
+	// date: annotated 태그의 tagger 시각 (Unix 초). 같은 커밋의 태그 중 최신 것을 고른다
+	date int64
+}
+
+// Describe: commit 에서 도달 가능한 가장 가까운 태그로 commit 을 이름 짓는다. (git describe)
+//
+//	v1.2.3             commit 에 태그가 있을 때
+//	v1.2.3-14-gabcdef1 태그에서 14 커밋 뒤 (g 뒤는 commit 의 짧은 해시)
+//
+// 기본으로 annotated 태그만 쓰고, tags 가 true 면 lightweight 태그도 쓴다.
+// 거리는 태그에서 도달할 수 없고 commit 에서 도달 가능한 커밋의 수다. 거리가 같으면
+// committer 시각이 최신인 커밋부터 걸었을 때 먼저 만난 태그를 쓴다.
+func (r *Repository) Describe(ctx context.Context, commit string, tags bool) (string, error) {
+	names, skipped, err := r.describeTags(tags)
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 && len(skipped) == 0 {
+		return "", errors.New("no names found, cannot describe anything")
+	}
+	if t, ok := names[commit]; ok {
+		return t.name, nil
+	}
+
+	// 가까운 것부터 후보를 모은다
+	var found []string
+	unannotated := false
+	errEnough := errors.New("enough candidates")
+	err = object.NewCommitIter(r.Objects, []string{commit}, object.OrderDate).ForEachContext(ctx, func(hash string, _ *object.Commit) error {
+		unannotated = unannotated || skipped[hash]
+		if _, ok := names[hash]; ok {
+			found = append(found, hash)
+			if len(found) == describeCandidates {
+				return errEnough
+			}
+		}
+		return nil
+	})
+	if err != nil && err != errEnough {
+		return "", err
+	}
+	if len(found) == 0 {
+		if unannotated {
+			return "", fmt.Errorf("no annotated tags can describe '%s' (however, there were unannotated tags: try --tags)", commit)
+		}
+		return "", fmt.Errorf("no tags can describe '%s'", commit)
+	}
+
+	reachable, err := object.Reachable(ctx, r.Objects, []string{commit})
+	if err != nil {
+		return "", err
+	}
+	best, bestDepth := "", -1
+	for _, hash := range found {
+		hidden, err := object.Reachable(ctx, r.Objects, []string{hash})
+		if err != nil {
+			return "", err
+		}
+		depth := 0
+		for h := range reachable {
+			if !hidden[h] {
+				depth++
+			}
+		}
+		if bestDepth < 0 || depth < bestDepth {
+			best, bestDepth = hash, depth
+		}
+	}
+	return fmt.Sprintf("%s-%d-g%s", names[best].name, bestDepth, commit[:7]), nil
+}
+
+// describeTags: 커밋마다 그 커밋을 가리키는 태그 중 쓸 것 하나.
+// annotated 태그를 lightweight 태그보다, 최신 annotated 태그를 오래된 것보다 먼저 쓴다.
+// skipped 는 tags 가 false 라서 빼 놓은 lightweight 태그가 가리키는 커밋들이다.
+func (r *Repository) describeTags(tags bool) (names map[string]describeTag, skipped map[string]bool, err error) {
+	list, err := r.Refs.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	names, skipped = map[string]describeTag{}, map[string]bool{}
+	for _, ref := range list {
+		name, ok := strings.CutPrefix(ref.Name, "refs/tags/")
+		if !ok {
+			continue
+		}
+		t := describeTag{name: name}
+		hash := ref.Hash
+		// 태그를 가리키는 태그도 커밋까지 따라간다
+		for {
+			obj, err := object.ReadObject(r.Objects, hash)
+			if err != nil {
+				return nil, nil, err
+			}
+			tag, ok := obj.(*object.Tag)
+			if !ok {
+				if _, ok := obj.(*object.Commit); !ok {
+					hash = ""
+				}
+				break
+			}
+			if !t.annotated {
+				t.annotated, t.date = true, tag.Tagger.When.Unix()
+			}
+			hash = tag.Object
+		}
+		if hash == "" {
+			continue
+		}
+		if !t.annotated && !tags {
+			skipped[hash] = true
+			continue
+		}
+		if old, ok := names[hash]; ok && (old.annotated && !t.annotated || old.annotated == t.annotated && old.date >= t.date) {
+			continue
+		}
+		names[hash] = t
+	}
+	return names, skipped, nil
+}
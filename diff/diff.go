@@ -0,0 +1,204 @@
+// Package diff 는 두 tree 의 차이를 찾고 git 과 같은 patch 형식으로 출력한다.
+//
+//	changes, _ := diff.Trees(store, parentTree, commitTree)
+//	diff.WritePatch(os.Stdout, store, changes, nil)
+//
+// 내용이 같은 파일의 삭제와 추가는 이름 바꾸기(rename)로 묶는다. (내용이 조금 바뀐 rename 은 찾지 않는다)
+package diff
+
+import (
+	"path"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// File: 변경의 한쪽. Hash 가 비어 있으면 그쪽에는 파일이 없다.
+type File struct {
+	Path string
+	Mode object.Mode
+	Hash string
+}
+
+func (f File) exists() bool {
+	return f.Hash != ""
+}
+
+// Change: 파일 하나의 변경
+type Change struct {
+	From File
+	To   File
+}
+
+// Status: git diff --name-status 의 글자. A(추가), D(삭제), M(수정), R(이름 바꿈),
+// T(일반 파일, 심볼릭 링크, submodule 사이에서 종류가 바뀜)
+func (c Change) Status() byte {
+	switch {
+	case !c.From.exists():
+		return 'A'
+	case !c.To.exists():
+		return 'D'
+	case c.From.Path != c.To.Path:
+		return 'R'
+	case fileType(c.From.Mode) != fileType(c.To.Mode):
+		return 'T'
+	}
+	return 'M'
+}
+
+// Path: 변경 뒤의 경로. 삭제면 원래 경로
+func (c Change) Path() string {
+	if c.To.exists() {
+		return c.To.Path
+	}
+	return c.From.Path
+}
+
+// Trees: from tree 에서 to tree 로의 변경. 하위 디렉토리까지 내려가며, 어느 쪽이든 빈 문자열이면 빈 tree 로 본다.
+// 결과는 git 과 같은 경로 순서이고 같은 내용의 삭제+추가는 rename 으로 묶여 있다.
+func Trees(s object.Storer, from, to string) ([]Change, error) {
+	var changes []Change
+	if err := diffTrees(s, from, to, "", &changes); err != nil {
+		return nil, err
+	}
+	return DetectRenames(changes), nil
+}
+
+func readEntries(s object.Storer, hash string) ([]object.TreeEntry, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	tree, err := object.ReadTree(s, hash)
+	if err != nil {
+		return nil, err
+	}
+	entries := append([]object.TreeEntry(nil), tree.Entries...)
+	object.SortEntries(entries)
+	return entries, nil
+}
+
+func sortKey(e object.TreeEntry) string {
+	if e.Mode == object.ModeTree {
+		return e.Name + "/"
+	}
+	return e.Name
+}
+
+func diffTrees(s object.Storer, from, to string, prefix string, changes *[]Change) error {
+	a, err := readEntries(s, from)
+	if err != nil {
+		return err
+	}
+	b, err := readEntries(s, to)
+	if err != nil {
+		return err
+	}
+
+	// 파일과 디렉토리는 이름이 같아도 다른 항목이다 ("a" < "a/")
+	for len(a) > 0 || len(b) > 0 {
+		switch {
+		case len(b) == 0 || len(a) > 0 && sortKey(a[0]) < sortKey(b[0]):
+			if err := addEntry(s, a[0], prefix, true, changes); err != nil {
+				return err
+			}
+			a = a[1:]
+		case len(a) == 0 || sortKey(b[0]) < sortKey(a[0]):
+			if err := addEntry(s, b[0], prefix, false, changes); err != nil {
+				return err
+			}
+			b = b[1:]
+		default:
+			ea, eb := a[0], b[0]
+			a, b = a[1:], b[1:]
+			if ea.Hash == eb.Hash && ea.Mode == eb.Mode {
+				continue
+			}
+			p := path.Join(prefix, ea.Name)
+			if ea.Mode == object.ModeTree {
+				if err := diffTrees(s, ea.Hash, eb.Hash, p, changes); err != nil {
+					return err
+				}
+				continue
+			}
+			*changes = append(*changes, Change{
+				From: File{Path: p, Mode: ea.Mode, Hash: ea.Hash},
+				To:   File{Path: p, Mode: eb.Mode, Hash: eb.Hash},
+			})
+		}
+	}
+	return nil
+}
+
+// addEntry: 한쪽에만 있는 항목. 디렉토리면 안의 파일 하나하나가 변경이다.
+func addEntry(s object.Storer, e object.TreeEntry, prefix string, deleted bool, changes *[]Change) error {
+	p := path.Join(prefix, e.Name)
+	if e.Mode == object.ModeTree {
+		if deleted {
+			return diffTrees(s, e.Hash, "", p, changes)
+		}
+		return diffTrees(s, "", e.Hash, p, changes)
+	}
+	f := File{Path: p, Mode: e.Mode, Hash: e.Hash}
+	if deleted {
+		*changes = append(*changes, Change{From: f})
+	} else {
+		*changes = append(*changes, Change{To: f})
+	}
+	return nil
+}
+
+// emptyBlob: 빈 파일은 내용으로 짝을 찾을 수 없으므로 rename 으로 묶지 않는다.
+const emptyBlob = "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"
+
+// DetectRenames: 내용이 같은 삭제와 추가를 rename 하나로 묶는다.
+// 후보가 여럿이면 파일 이름(basename)이 같은 것을, 그다음 먼저 나온 것을 고른다.
+// rename 은 추가된 경로의 자리에 놓인다. (git 과 같은 순서)
+func DetectRenames(changes []Change) []Change {
+	var deleted []int
+	for i, c := range changes {
+		if c.Status() == 'D' && c.From.Hash != emptyBlob && c.From.Mode != object.ModeGitlink {
+			deleted = append(deleted, i)
+		}
+	}
+	if len(deleted) == 0 {
+		return changes
+	}
+
+	used := map[int]bool{}
+	renamed := map[int]int{}
+	for i, c := range changes {
+		if c.Status() != 'A' || c.To.Hash == emptyBlob {
+			continue
+		}
+		best := -1
+		for _, d := range deleted {
+			from := changes[d].From
+			if used[d] || from.Hash != c.To.Hash || fileType(from.Mode) != fileType(c.To.Mode) {
+				continue
+			}
+			if best == -1 || path.Base(from.Path) == path.Base(c.To.Path) && path.Base(changes[best].From.Path) != path.Base(c.To.Path) {
+				best = d
+			}
+		}
+		if best != -1 {
+			used[best] = true
+			renamed[i] = best
+		}
+	}
+
+	result := make([]Change, 0, len(changes)-len(renamed))
+	for i, c := range changes {
+		if used[i] {
+			continue
+		}
+		if d, ok := renamed[i]; ok {
+			c.From = changes[d].From
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// fileType: 모드의 파일 종류 (일반 파일과 실행 파일은 같은 종류)
+func fileType(m object.Mode) object.Mode {
+	return m & 0170000
+}
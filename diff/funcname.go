@@ -0,0 +1,95 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FuncMatcher: hunk 머리에 붙일 줄(함수나 절의 시작)을 고른다.
+// 줄(줄바꿈 포함)이 맞으면 붙일 문자열과 true 를 돌려준다.
+type FuncMatcher func(line string) (string, bool)
+
+// builtinDrivers: git 의 userdiff 와 같은 내장 xfuncname 패턴
+// git 과 같이 .gitattributes 에서 diff=<이름> 으로 고른 파일에만 쓴다. 확장자로 고르지 않는다.
+var builtinDrivers = map[string]string{
+	"golang": "^[ \t]*(func[ \t]*.*(\\{[ \t]*)?)\n" +
+		"^[ \t]*(type[ \t].*(struct|interface)[ \t]*(\\{[ \t]*)?)",
+	"python":   "^[ \t]*((class|(async[ \t]+)?def)[ \t].*)$",
+	"markdown": "^ {0,3}#{1,6}[ \t].*",
+}
+
+var builtinMatchers = map[string]FuncMatcher{}
+
+func init() {
+	for name, pattern := range builtinDrivers {
+		m, err := FuncPattern(pattern)
+		if err != nil {
+			panic(err)
+		}
+		builtinMatchers[name] = m
+	}
+}
+
+// Driver: 이름이 name 인 내장 driver 의 FuncMatcher. 없는 이름이면 false
+func Driver(name string) (FuncMatcher, bool) {
+	m, ok := builtinMatchers[name]
+	return m, ok
+}
+
+// FuncPattern: git 의 diff.<driver>.xfuncname 과 같은 형식의 패턴으로 FuncMatcher 를 만든다.
+// 줄마다 정규식 하나이고 "!" 로 시작하는 정규식에 맞는 줄은 고르지 않는다. 위에서부터 처음 맞는 정규식을 쓴다.
+// 정규식에 괄호 묶음이 있으면 첫 묶음을, 없으면 맞은 부분 전체를 붙인다.
+func FuncPattern(pattern string) (FuncMatcher, error) {
+	type funcRegexp struct {
+		re     *regexp.Regexp
+		negate bool
+	}
+	var res []funcRegexp
+	for _, line := range strings.Split(pattern, "\n") {
+		negate := strings.HasPrefix(line, "!")
+		re, err := regexp.Compile(strings.TrimPrefix(line, "!"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid xfuncname pattern %q: %w", line, err)
+		}
+		res = append(res, funcRegexp{re: re, negate: negate})
+	}
+	return func(line string) (string, bool) {
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		for _, r := range res {
+			m := r.re.FindStringSubmatchIndex(line)
+			if m == nil {
+				continue
+			}
+			if r.negate {
+				return "", false
+			}
+			start, end := m[0], m[1]
+			if len(m) > 2 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			return trimFuncName(line[start:end]), true
+		}
+		return "", false
+	}, nil
+}
+
+// defaultFuncName: git 의 기본 규칙. 글자나 '_', '$' 로 시작하는 줄
+func defaultFuncName(line string) (string, bool) {
+	if line == "" {
+		return "", false
+	}
+	c := line[0]
+	if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$') {
+		return "", false
+	}
+	return trimFuncName(line), true
+}
+
+// trimFuncName: 80바이트까지 자르고 끝의 공백을 뺀다.
+func trimFuncName(name string) string {
+	if len(name) > funcNameLen {
+		name = name[:funcNameLen]
+	}
+	return strings.TrimRight(name, " \t\n\r\v\f")
+}
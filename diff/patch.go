@@ -0,0 +1,309 @@
+package diff
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tmdgusya/gogit/base85"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Options: patch 출력 옵션. nil 이면 기본값
+type Options struct {
+	// Context: hunk 앞뒤로 보여 줄 줄 수
+	Context int
+	// FuncName: 파일 경로마다 hunk 머리에 붙일 줄을 고르는 규칙. nil 이거나 nil 을 돌려주면 git 의 기본 규칙
+	FuncName func(path string) FuncMatcher
+	// Paired: From 과 To 의 경로가 달라도 rename 이 아니라 한 파일의 두 버전으로 본다. (diff --no-index)
+	Paired bool
+	// Binary: 바이너리 파일도 apply 할 수 있게 전체 해시와 "GIT binary patch" 로 쓴다. (diff --binary)
+	Binary bool
+}
+
+func defaultOptions() *Options {
+	return &Options{Context: DefaultContext}
+}
+
+// abbrevLen: index 줄의 짧은 해시 길이
+const abbrevLen = 7
+
+// binaryCheckLen: 이 길이 안에 NUL 이 있으면 바이너리로 본다. (git 과 동일)
+const binaryCheckLen = 8000
+
+// WritePatch: 변경들을 "diff --git" 형식의 patch 로 쓴다.
+// 일반 파일과 심볼릭 링크처럼 종류가 바뀐 파일은 git 과 같이 삭제와 추가 두 개로 나눠 쓴다.
+func WritePatch(w io.Writer, s object.Storer, changes []Change, opts *Options) error {
+	if opts == nil {
+		opts = defaultOptions()
+	}
+	for _, c := range changes {
+		if c.From.exists() && c.To.exists() && fileType(c.From.Mode) != fileType(c.To.Mode) {
+			if err := writeFilePatch(w, s, Change{From: c.From}, opts); err != nil {
+				return err
+			}
+			c = Change{To: c.To}
+		}
+		if err := writeFilePatch(w, s, c, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFilePatch(w io.Writer, s object.Storer, c Change, opts *Options) error {
+	a, err := content(s, c.From)
+	if err != nil {
+		return err
+	}
+	b, err := content(s, c.To)
+	if err != nil {
+		return err
+	}
+
+	status := c.Status()
+	if status == 'R' && opts.Paired {
+		status = 'M'
+	}
+	oldPath, newPath := c.From.Path, c.To.Path
+	if !c.From.exists() {
+		oldPath = newPath
+	}
+	if !c.To.exists() {
+		newPath = oldPath
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "diff --git %s %s\n", QuotePath("a/"+oldPath), QuotePath("b/"+newPath))
+	switch status {
+	case 'A':
+		fmt.Fprintf(&out, "new file mode %06o\n", uint32(c.To.Mode))
+	case 'D':
+		fmt.Fprintf(&out, "deleted file mode %06o\n", uint32(c.From.Mode))
+	default:
+		if c.From.Mode != c.To.Mode {
+			fmt.Fprintf(&out, "old mode %06o\nnew mode %06o\n", uint32(c.From.Mode), uint32(c.To.Mode))
+		}
+		if status == 'R' {
+			fmt.Fprintf(&out, "similarity index 100%%\nrename from %s\nrename to %s\n", QuotePath(oldPath), QuotePath(newPath))
+		}
+	}
+
+	fromHash, toHash := c.From.Hash, c.To.Hash
+	if fromHash == toHash {
+		// 모드만 바뀌었거나 내용이 같은 rename
+		_, err := io.WriteString(w, out.String())
+		return err
+	}
+	// 없는 쪽은 다른 쪽과 같은 길이의 0 으로 쓴다 (SHA-1 은 40자리, SHA-256 은 64자리)
+	if fromHash == "" {
+		fromHash = strings.Repeat("0", len(toHash))
+	}
+	if toHash == "" {
+		toHash = strings.Repeat("0", len(fromHash))
+	}
+	binary := IsBinary(a) || IsBinary(b)
+	if binary && opts.Binary {
+		fmt.Fprintf(&out, "index %s..%s", fromHash, toHash)
+	} else {
+		fmt.Fprintf(&out, "index %s..%s", fromHash[:abbrevLen], toHash[:abbrevLen])
+	}
+	if status == 'M' && c.From.Mode == c.To.Mode {
+		fmt.Fprintf(&out, " %06o", uint32(c.To.Mode))
+	}
+	out.WriteString("\n")
+
+	oldLabel, newLabel := "a/"+oldPath, "b/"+newPath
+	if !c.From.exists() {
+		oldLabel = "/dev/null"
+	}
+	if !c.To.exists() {
+		newLabel = "/dev/null"
+	}
+
+	if binary && opts.Binary {
+		// git 과 같이 새 내용과 옛 내용(거꾸로 적용할 때 쓴다)을 차례로 쓴다
+		out.WriteString("GIT binary patch\n")
+		for _, data := range [][]byte{b, a} {
+			if err := writeBinaryLiteral(&out, data); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, out.String())
+		return err
+	}
+	if binary {
+		fmt.Fprintf(&out, "Binary files %s and %s differ\n", QuotePath(oldLabel), QuotePath(newLabel))
+		_, err := io.WriteString(w, out.String())
+		return err
+	}
+	if len(a) == 0 && len(b) == 0 {
+		// 빈 파일의 추가/삭제에는 hunk 가 없다
+		_, err := io.WriteString(w, out.String())
+		return err
+	}
+
+	fmt.Fprintf(&out, "--- %s%s\n", QuotePath(oldLabel), labelTab(oldLabel))
+	fmt.Fprintf(&out, "+++ %s%s\n", QuotePath(newLabel), labelTab(newLabel))
+	if _, err := io.WriteString(w, out.String()); err != nil {
+		return err
+	}
+	var funcName FuncMatcher
+	if opts.FuncName != nil {
+		funcName = opts.FuncName(newPath)
+	}
+	return WriteUnifiedFunc(w, SplitLines(a), SplitLines(b), opts.Context, funcName)
+}
+
+// writeBinaryLiteral: "literal <크기>" 와 zlib 으로 압축한 내용을 base85 줄로 쓴다. 끝에 빈 줄을 둔다
+func writeBinaryLiteral(out *strings.Builder, data []byte) error {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "literal %d\n", len(data))
+	for rest := z.Bytes(); len(rest) > 0; {
+		n := min(len(rest), base85.MaxLine)
+		out.WriteString(base85.EncodeLine(rest[:n]) + "\n")
+		rest = rest[n:]
+	}
+	out.WriteString("\n")
+	return nil
+}
+
+// labelTab: 공백이 있는 경로는 뒤에 탭을 붙여 끝을 표시한다. (git 과 동일)
+func labelTab(label string) string {
+	if strings.Contains(label, " ") {
+		return "\t"
+	}
+	return ""
+}
+
+// content: 파일 내용. submodule(gitlink)은 가리키는 커밋을 한 줄로 쓴다.
+func content(s object.Storer, f File) ([]byte, error) {
+	if !f.exists() {
+		return nil, nil
+	}
+	if f.Mode == object.ModeGitlink {
+		return []byte("Subproject commit " + f.Hash + "\n"), nil
+	}
+	_, data, err := s.Read(f.Hash)
+	return data, err
+}
+
+// IsBinary: 앞부분에 NUL 바이트가 있으면 바이너리 파일로 본다.
+func IsBinary(data []byte) bool {
+	if len(data) > binaryCheckLen {
+		data = data[:binaryCheckLen]
+	}
+	return bytes.IndexByte(data, 0) != -1
+}
+
+// QuotePath: 제어 문자, 따옴표, 역슬래시, ASCII 밖의 바이트가 있는 경로는 C 문자열처럼 따옴표로 감싼다.
+// (git 의 core.quotePath 기본값과 동일)
+func QuotePath(p string) string {
+	needs := false
+	for i := 0; i < len(p); i++ {
+		if c := p[i]; c < 0x20 || c == '"' || c == '\\' || c >= 0x7f {
+			needs = true
+			break
+		}
+	}
+	if !needs {
+		return p
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch c {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\v':
+			b.WriteString(`\v`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if c < 0x20 || c >= 0x7f {
+				fmt.Fprintf(&b, "\\%03o", c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// UnquotePath: QuotePath 의 반대. 따옴표로 시작하지 않으면 그대로 돌려준다.
+// 닫는 따옴표 뒤의 나머지(rest)도 돌려주므로 "a b" c 처럼 경로 뒤에 다른 값이 오는 줄도 읽을 수 있다.
+func UnquotePath(s string) (p string, rest string, err error) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, "", nil
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			return b.String(), s[i+1:], nil
+		case c != '\\':
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(s) {
+			break
+		}
+		switch c = s[i]; c {
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'v':
+			b.WriteByte('\v')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(c)
+		default:
+			if c < '0' || c > '3' || i+2 >= len(s) {
+				return "", "", fmt.Errorf("bad escape in quoted path %s", s)
+			}
+			var v byte
+			for _, d := range s[i : i+3] {
+				if d < '0' || d > '7' {
+					return "", "", fmt.Errorf("bad escape in quoted path %s", s)
+				}
+				v = v<<3 | byte(d-'0')
+			}
+			b.WriteByte(v)
+			i += 2
+		}
+	}
+	return "", "", fmt.Errorf("unterminated quoted path %s", s)
+}
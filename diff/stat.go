@@ -0,0 +1,224 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// FileStat: 파일 하나에서 더하고 지운 줄 수. 바이너리 파일은 Added/Deleted 가 새/옛 크기(바이트)다.
+type FileStat struct {
+	Change  Change
+	Added   int
+	Deleted int
+	Binary  bool
+}
+
+// Stats: 변경마다 더하고 지운 줄 수를 센다. patch 와 같은 줄 diff 를 쓰므로 patch 의 +/- 줄 수와 같다.
+func Stats(s object.Storer, changes []Change) ([]FileStat, error) {
+	stats := make([]FileStat, 0, len(changes))
+	for _, c := range changes {
+		a, err := content(s, c.From)
+		if err != nil {
+			return nil, err
+		}
+		b, err := content(s, c.To)
+		if err != nil {
+			return nil, err
+		}
+		st := FileStat{Change: c}
+		switch {
+		case c.From.Hash == c.To.Hash:
+		case IsBinary(a) || IsBinary(b):
+			st.Binary, st.Added, st.Deleted = true, len(b), len(a)
+		default:
+			for _, e := range Lines(SplitLines(a), SplitLines(b)) {
+				st.Added += e.NewLen
+				st.Deleted += e.OldLen
+			}
+		}
+		stats = append(stats, st)
+	}
+	return stats, nil
+}
+
+// WriteStat: git diff --stat 과 같이 파일마다 "이름 | 줄 수 +++--" 와 합계 줄을 쓴다.
+// width 는 한 줄의 최대 폭이고(format-patch 는 72, 터미널은 80) 넘치면 이름과 그래프를 줄인다.
+func WriteStat(w io.Writer, stats []FileStat, width int) error {
+	names := make([]string, len(stats))
+	maxLen, maxChange, numberWidth, binWidth := 0, 0, 0, 0
+	for i, st := range stats {
+		names[i] = statName(st.Change)
+		maxLen = max(maxLen, utf8.RuneCountInString(names[i]))
+		if st.Binary {
+			binWidth = max(binWidth, 14+decimalWidth(st.Added)+decimalWidth(st.Deleted))
+			numberWidth = 3
+			continue
+		}
+		maxChange = max(maxChange, st.Added+st.Deleted)
+	}
+	numberWidth = max(numberWidth, decimalWidth(maxChange))
+
+	// 폭을 나누는 방법은 git 의 show_stats 와 같다
+	width = max(width, 16+6+numberWidth)
+	graphWidth := maxChange
+	if maxChange+4 <= binWidth {
+		graphWidth = binWidth - 4
+	}
+	nameWidth := maxLen
+	if nameWidth+numberWidth+6+graphWidth > width {
+		if graphWidth > width*3/8-numberWidth-6 {
+			graphWidth = max(width*3/8-numberWidth-6, 6)
+		}
+		if nameWidth > width-numberWidth-6-graphWidth {
+			nameWidth = width - numberWidth - 6 - graphWidth
+		} else {
+			graphWidth = width - numberWidth - 6 - nameWidth
+		}
+	}
+
+	var b strings.Builder
+	files, insertions, deletions := 0, 0, 0
+	for i, st := range stats {
+		name, prefix := names[i], ""
+		if utf8.RuneCountInString(name) > nameWidth {
+			// 앞을 잘라 "..." 으로 표시하고, 가능하면 디렉토리 경계에서 시작한다
+			prefix = "..."
+			keep := max(nameWidth-3, 0)
+			for utf8.RuneCountInString(name) > keep {
+				_, size := utf8.DecodeRuneInString(name)
+				name = name[size:]
+			}
+			if slash := strings.IndexByte(name, '/'); slash >= 0 {
+				name = name[slash:]
+			}
+		}
+		padding := max(nameWidth-len(prefix)-utf8.RuneCountInString(name), 0)
+		fmt.Fprintf(&b, " %s%s%s |", prefix, name, strings.Repeat(" ", padding))
+		files++
+
+		if st.Binary {
+			fmt.Fprintf(&b, " %*s", numberWidth, "Bin")
+			if st.Added == 0 && st.Deleted == 0 {
+				b.WriteString("\n")
+				continue
+			}
+			fmt.Fprintf(&b, " %d -> %d bytes\n", st.Deleted, st.Added)
+			continue
+		}
+		added, deleted := st.Added, st.Deleted
+		insertions += added
+		deletions += deleted
+		fmt.Fprintf(&b, " %*d", numberWidth, added+deleted)
+		if added+deleted > 0 {
+			b.WriteString(" ")
+		}
+		if graphWidth <= maxChange {
+			total := scaleLinear(added+deleted, graphWidth, maxChange)
+			if total < 2 && added > 0 && deleted > 0 {
+				total = 2
+			}
+			if added < deleted {
+				added = scaleLinear(added, graphWidth, maxChange)
+				deleted = total - added
+			} else {
+				deleted = scaleLinear(deleted, graphWidth, maxChange)
+				added = total - deleted
+			}
+		}
+		fmt.Fprintf(&b, "%s%s\n", strings.Repeat("+", added), strings.Repeat("-", deleted))
+	}
+
+	fmt.Fprintf(&b, " %d file%s changed", files, plural(files))
+	if insertions > 0 || deletions == 0 {
+		fmt.Fprintf(&b, ", %d insertion%s(+)", insertions, plural(insertions))
+	}
+	if deletions > 0 || insertions == 0 {
+		fmt.Fprintf(&b, ", %d deletion%s(-)", deletions, plural(deletions))
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteSummary: git diff --summary 와 같이 만들고 지운 파일, 이름과 모드가 바뀐 파일을 한 줄씩 쓴다.
+func WriteSummary(w io.Writer, changes []Change) error {
+	var b strings.Builder
+	for _, c := range changes {
+		switch c.Status() {
+		case 'A':
+			fmt.Fprintf(&b, " create mode %06o %s\n", uint32(c.To.Mode), QuotePath(c.To.Path))
+		case 'D':
+			fmt.Fprintf(&b, " delete mode %06o %s\n", uint32(c.From.Mode), QuotePath(c.From.Path))
+		case 'R':
+			fmt.Fprintf(&b, " rename %s (100%%)\n", renameName(QuotePath(c.From.Path), QuotePath(c.To.Path)))
+			if c.From.Mode != c.To.Mode {
+				fmt.Fprintf(&b, " mode change %06o => %06o\n", uint32(c.From.Mode), uint32(c.To.Mode))
+			}
+		default:
+			// 종류가 바뀐 것(T)도 git 과 같이 모드 변경으로 보인다
+			if c.From.Mode != c.To.Mode {
+				fmt.Fprintf(&b, " mode change %06o => %06o %s\n", uint32(c.From.Mode), uint32(c.To.Mode), QuotePath(c.To.Path))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// statName: stat 에 쓰는 이름. rename 은 "dir/{old => new}" 처럼 공통 부분을 묶는다
+func statName(c Change) string {
+	if c.Status() == 'R' {
+		return renameName(QuotePath(c.From.Path), QuotePath(c.To.Path))
+	}
+	return QuotePath(c.Path())
+}
+
+// renameName: git 의 pprint_rename 과 같이 디렉토리 단위의 공통 앞뒤 부분을 빼고 바뀐 부분만 { => } 로 보인다
+func renameName(a, b string) string {
+	prefix := 0
+	for i := 0; i < len(a) && i < len(b) && a[i] == b[i]; i++ {
+		if a[i] == '/' {
+			prefix = i + 1
+		}
+	}
+	// 공통 앞부분이 있으면 그 끝의 '/' 까지 보며 공통 뒷부분을 찾는다
+	adjust := 0
+	if prefix > 0 {
+		adjust = 1
+	}
+	suffix := 0
+	for i, j := len(a)-1, len(b)-1; i >= prefix-adjust && j >= prefix-adjust && a[i] == b[j]; i, j = i-1, j-1 {
+		if a[i] == '/' {
+			suffix = len(a) - i
+		}
+	}
+	aMid := max(len(a)-prefix-suffix, 0)
+	bMid := max(len(b)-prefix-suffix, 0)
+	if prefix+suffix == 0 {
+		return a + " => " + b
+	}
+	return a[:prefix] + "{" + a[prefix:prefix+aMid] + " => " + b[prefix:prefix+bMid] + "}" + a[len(a)-suffix:]
+}
+
+// scaleLinear: 0 이 아닌 값은 적어도 1 칸을 차지하게 [0, maxChange] 를 [0, width] 로 줄인다
+func scaleLinear(n, width, maxChange int) int {
+	if n == 0 {
+		return 0
+	}
+	return 1 + n*(width-1)/maxChange
+}
+
+func decimalWidth(n int) int {
+	return len(fmt.Sprint(n))
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,102 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultContext: hunk 앞뒤로 보여 주는 기본 줄 수 (git 과 같은 3)
+const DefaultContext = 3
+
+// funcNameLen: hunk 머리에 붙이는 함수 이름 줄의 최대 길이
+const funcNameLen = 80
+
+// WriteUnified: a 와 b 의 차이를 unified diff 의 hunk 들("@@ ... @@" 부터)로 쓴다.
+// 각 hunk 머리에는 git 과 같이 hunk 앞에서 가장 가까운, 글자나 '_', '$' 로 시작하는 옛 줄을 붙인다.
+func WriteUnified(w io.Writer, a, b []string, context int) error {
+	return WriteUnifiedFunc(w, a, b, context, nil)
+}
+
+// WriteUnifiedFunc: WriteUnified 와 같지만 hunk 머리에 붙일 줄을 funcName 으로 고른다. nil 이면 기본 규칙
+func WriteUnifiedFunc(w io.Writer, a, b []string, context int, funcName FuncMatcher) error {
+	if funcName == nil {
+		funcName = defaultFuncName
+	}
+	edits := Lines(a, b)
+	var out strings.Builder
+
+	funcLine := ""
+	funcPrev := -1
+	for i := 0; i < len(edits); {
+		// 사이가 2*context 줄 이하인 변경은 한 hunk 로 묶는다
+		j := i
+		for j+1 < len(edits) && edits[j+1].OldPos-(edits[j].OldPos+edits[j].OldLen) <= 2*context {
+			j++
+		}
+		first, last := edits[i], edits[j]
+
+		s1 := max(first.OldPos-context, 0)
+		s2 := max(first.NewPos-context, 0)
+		lctx := min(context, len(a)-(last.OldPos+last.OldLen), len(b)-(last.NewPos+last.NewLen))
+		e1 := last.OldPos + last.OldLen + lctx
+		e2 := last.NewPos + last.NewLen + lctx
+
+		// 못 찾으면 직전 hunk 의 이름이 그대로 이어진다
+		for l := s1 - 1; l != funcPrev && l >= 0; l-- {
+			if name, ok := funcName(a[l]); ok {
+				funcLine = name
+				break
+			}
+		}
+		funcPrev = s1 - 1
+
+		out.WriteString("@@ -" + hunkRange(s1+1, e1-s1) + " +" + hunkRange(s2+1, e2-s2) + " @@")
+		if funcLine != "" {
+			out.WriteString(" " + funcLine)
+		}
+		out.WriteString("\n")
+
+		for ; s2 < first.NewPos; s2++ {
+			writeLine(&out, " ", b[s2])
+		}
+		p1, p2 := first.OldPos, first.NewPos
+		for k := i; k <= j; k++ {
+			e := edits[k]
+			for ; p1 < e.OldPos && p2 < e.NewPos; p1, p2 = p1+1, p2+1 {
+				writeLine(&out, " ", b[p2])
+			}
+			for p1 = e.OldPos; p1 < e.OldPos+e.OldLen; p1++ {
+				writeLine(&out, "-", a[p1])
+			}
+			for p2 = e.NewPos; p2 < e.NewPos+e.NewLen; p2++ {
+				writeLine(&out, "+", b[p2])
+			}
+		}
+		for p2 = last.NewPos + last.NewLen; p2 < e2; p2++ {
+			writeLine(&out, " ", b[p2])
+		}
+		i = j + 1
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// hunkRange: "시작,줄수". 한 줄이면 줄 수를 빼고, 빈 범위는 바로 앞 줄 번호를 쓴다.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func writeLine(out *strings.Builder, prefix string, line string) {
+	out.WriteString(prefix + line)
+	if !strings.HasSuffix(line, "\n") {
+		out.WriteString("\n\\ No newline at end of file\n")
+	}
+}
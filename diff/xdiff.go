@@ -0,0 +1,615 @@
+package diff
+
+// 줄 단위 diff
+// git 의 xdiff 와 같은 순서로 계산하므로(양 끝 다듬기, 짝 없는 줄 버리기, Myers 분할 정복,
+// indent heuristic 으로 변경 묶음 옮기기) 같은 입력이면 git 과 같은 hunk 가 나온다.
+// 아주 큰 입력에서 git 이 쓰는 근사(heuristic) 는 없으므로 그런 경우에는 결과가 다를 수 있다.
+
+import "math"
+
+// Edit: 연속된 변경 하나. 옛 줄 Old[OldPos:OldPos+OldLen] 이 새 줄 New[NewPos:NewPos+NewLen] 으로 바뀐다.
+type Edit struct {
+	OldPos, OldLen int
+	NewPos, NewLen int
+}
+
+// SplitLines: 줄바꿈을 포함한 줄들로 나눈다. 마지막 줄에 줄바꿈이 없으면 없는 그대로 둔다.
+func SplitLines(data []byte) []string {
+	var lines []string
+	start := 0
+	for i, c := range data {
+		if c == '\n' {
+			lines = append(lines, string(data[start:i+1]))
+			start = i + 1
+		}
+	}
+	if start < len(data) {
+		lines = append(lines, string(data[start:]))
+	}
+	return lines
+}
+
+// Lines: 두 줄 목록의 차이. 줄바꿈까지 같아야 같은 줄로 본다.
+func Lines(a, b []string) []Edit {
+	classes := map[string]int{}
+	var count1, count2 []int
+	classify := func(lines []string, counts *[]int) []int {
+		ha := make([]int, len(lines))
+		for i, line := range lines {
+			c, ok := classes[line]
+			if !ok {
+				c = len(classes)
+				classes[line] = c
+				count1 = append(count1, 0)
+				count2 = append(count2, 0)
+			}
+			(*counts)[c]++
+			ha[i] = c
+		}
+		return ha
+	}
+	f1 := newXfile(a, classify(a, &count1))
+	f2 := newXfile(b, classify(b, &count2))
+
+	trimEnds(f1, f2)
+	cleanupRecords(f1, count2)
+	cleanupRecords(f2, count1)
+
+	base := len(f2.rha) + 1
+	size := len(f1.rha) + len(f2.rha) + 3
+	x := &xdiff{f1: f1, f2: f2, kvdf: make([]int, size), kvdb: make([]int, size), base: base}
+	x.recsCmp(0, len(f1.rha), 0, len(f2.rha))
+
+	compact(f1, f2)
+	compact(f2, f1)
+	return buildScript(f1, f2)
+}
+
+// xfile: diff 하는 한쪽 파일
+type xfile struct {
+	recs []string
+	// ha: 줄의 종류 번호 (같은 내용이면 같은 번호)
+	ha []int
+	// rchg: 바뀐 줄 표시. 앞뒤에 false 가 하나씩 더 있어서 rchg[i+1] 이 i 번째 줄이다.
+	rchg []bool
+	// dstart/dend: 양 끝의 같은 줄을 뺀 범위 (dend 포함)
+	dstart, dend int
+	// rindex/rha: 버리지 않고 Myers 로 비교할 줄들의 원래 위치와 종류
+	rindex []int
+	rha    []int
+}
+
+func newXfile(recs []string, ha []int) *xfile {
+	return &xfile{recs: recs, ha: ha, rchg: make([]bool, len(recs)+2)}
+}
+
+func (f *xfile) changed(i int) bool  { return f.rchg[i+1] }
+func (f *xfile) set(i int, v bool)   { f.rchg[i+1] = v }
+func (f *xfile) nrec() int           { return len(f.recs) }
+func (f *xfile) match(i, j int) bool { return f.ha[i] == f.ha[j] }
+
+// trimEnds: 앞뒤의 같은 줄은 비교하지 않는다.
+func trimEnds(f1, f2 *xfile) {
+	lim := min(f1.nrec(), f2.nrec())
+	i := 0
+	for i < lim && f1.ha[i] == f2.ha[i] {
+		i++
+	}
+	f1.dstart, f2.dstart = i, i
+
+	lim -= i
+	j := 0
+	for j < lim && f1.ha[f1.nrec()-1-j] == f2.ha[f2.nrec()-1-j] {
+		j++
+	}
+	f1.dend = f1.nrec() - j - 1
+	f2.dend = f2.nrec() - j - 1
+}
+
+const (
+	maxEqLimit    = 1024
+	simscanWindow = 100
+	kpdisRun      = 4
+)
+
+// cleanupRecords: 다른 쪽에 없는 줄은 비교하지 않고 바로 바뀐 줄로 표시한다.
+// 다른 쪽에 너무 자주 나오는 줄도 짝 없는 줄 사이에 끼어 있으면 버린다.
+func cleanupRecords(f *xfile, otherCounts []int) {
+	mlim := bogosqrt(f.nrec())
+	if mlim > maxEqLimit {
+		mlim = maxEqLimit
+	}
+	dis := make([]byte, f.nrec()+1)
+	for i := f.dstart; i <= f.dend; i++ {
+		nm := 0
+		if c := f.ha[i]; c < len(otherCounts) {
+			nm = otherCounts[c]
+		}
+		switch {
+		case nm == 0:
+			dis[i] = 0
+		case nm >= mlim:
+			dis[i] = 2
+		default:
+			dis[i] = 1
+		}
+	}
+
+	for i := f.dstart; i <= f.dend; i++ {
+		if dis[i] == 1 || (dis[i] == 2 && !cleanMatch(dis, i, f.dstart, f.dend)) {
+			f.rindex = append(f.rindex, i)
+			f.rha = append(f.rha, f.ha[i])
+		} else {
+			f.set(i, true)
+		}
+	}
+}
+
+func bogosqrt(n int) int {
+	i := 1
+	for ; n > 0; n >>= 2 {
+		i <<= 1
+	}
+	return i
+}
+
+// cleanMatch: 자주 나오는 줄 i 가 짝 없는 줄들 사이에 묻혀 있어서 버려도 되는지
+func cleanMatch(dis []byte, i, s, e int) bool {
+	if i-s > simscanWindow {
+		s = i - simscanWindow
+	}
+	if e-i > simscanWindow {
+		e = i + simscanWindow
+	}
+
+	rdis0, rpdis0 := 0, 1
+	for r := 1; i-r >= s; r++ {
+		if dis[i-r] == 0 {
+			rdis0++
+		} else if dis[i-r] == 2 {
+			rpdis0++
+		} else {
+			break
+		}
+	}
+	if rdis0 == 0 {
+		return false
+	}
+	rdis1, rpdis1 := 0, 1
+	for r := 1; i+r <= e; r++ {
+		if dis[i+r] == 0 {
+			rdis1++
+		} else if dis[i+r] == 2 {
+			rpdis1++
+		} else {
+			break
+		}
+	}
+	if rdis1 == 0 {
+		return false
+	}
+	rdis1 += rdis0
+	rpdis1 += rpdis0
+	return rpdis1*kpdisRun < rpdis1+rdis1
+}
+
+type xdiff struct {
+	f1, f2 *xfile
+	// kvdf/kvdb: 대각선 k 의 앞/뒤 방향 최대 도달점. 인덱스는 k+base
+	kvdf, kvdb []int
+	base       int
+}
+
+// recsCmp: 분할 정복. 가운데 snake 를 찾아 양쪽을 다시 비교한다.
+func (x *xdiff) recsCmp(off1, lim1, off2, lim2 int) {
+	ha1, ha2 := x.f1.rha, x.f2.rha
+	for off1 < lim1 && off2 < lim2 && ha1[off1] == ha2[off2] {
+		off1++
+		off2++
+	}
+	for off1 < lim1 && off2 < lim2 && ha1[lim1-1] == ha2[lim2-1] {
+		lim1--
+		lim2--
+	}
+
+	switch {
+	case off1 == lim1:
+		for ; off2 < lim2; off2++ {
+			x.f2.set(x.f2.rindex[off2], true)
+		}
+	case off2 == lim2:
+		for ; off1 < lim1; off1++ {
+			x.f1.set(x.f1.rindex[off1], true)
+		}
+	default:
+		i1, i2 := x.split(off1, lim1, off2, lim2)
+		x.recsCmp(off1, i1, off2, i2)
+		x.recsCmp(i1, lim1, i2, lim2)
+	}
+}
+
+// split: 앞과 뒤에서 동시에 편집 경로를 늘려 가다 만나는 점
+func (x *xdiff) split(off1, lim1, off2, lim2 int) (int, int) {
+	ha1, ha2 := x.f1.rha, x.f2.rha
+	kvdf, kvdb, base := x.kvdf, x.kvdb, x.base
+
+	dmin, dmax := off1-lim2, lim1-off2
+	fmid, bmid := off1-off2, lim1-lim2
+	odd := (fmid-bmid)&1 != 0
+	fmin, fmax := fmid, fmid
+	bmin, bmax := bmid, bmid
+
+	kvdf[base+fmid] = off1
+	kvdb[base+bmid] = lim1
+
+	for {
+		if fmin > dmin {
+			fmin--
+			kvdf[base+fmin-1] = -1
+		} else {
+			fmin++
+		}
+		if fmax < dmax {
+			fmax++
+			kvdf[base+fmax+1] = -1
+		} else {
+			fmax--
+		}
+
+		for d := fmax; d >= fmin; d -= 2 {
+			var i1 int
+			if kvdf[base+d-1] >= kvdf[base+d+1] {
+				i1 = kvdf[base+d-1] + 1
+			} else {
+				i1 = kvdf[base+d+1]
+			}
+			i2 := i1 - d
+			for i1 < lim1 && i2 < lim2 && ha1[i1] == ha2[i2] {
+				i1++
+				i2++
+			}
+			kvdf[base+d] = i1
+			if odd && bmin <= d && d <= bmax && kvdb[base+d] <= i1 {
+				return i1, i2
+			}
+		}
+
+		if bmin > dmin {
+			bmin--
+			kvdb[base+bmin-1] = math.MaxInt
+		} else {
+			bmin++
+		}
+		if bmax < dmax {
+			bmax++
+			kvdb[base+bmax+1] = math.MaxInt
+		} else {
+			bmax--
+		}
+
+		for d := bmax; d >= bmin; d -= 2 {
+			var i1 int
+			if kvdb[base+d-1] < kvdb[base+d+1] {
+				i1 = kvdb[base+d-1]
+			} else {
+				i1 = kvdb[base+d+1] - 1
+			}
+			i2 := i1 - d
+			for i1 > off1 && i2 > off2 && ha1[i1-1] == ha2[i2-1] {
+				i1--
+				i2--
+			}
+			kvdb[base+d] = i1
+			if !odd && fmin <= d && d <= fmax && i1 <= kvdf[base+d] {
+				return i1, i2
+			}
+		}
+	}
+}
+
+// group: 연속된 바뀐 줄 [start, end)
+type group struct {
+	start, end int
+}
+
+func (f *xfile) groupInit() group {
+	g := group{}
+	for f.changed(g.end) {
+		g.end++
+	}
+	return g
+}
+
+func (f *xfile) groupNext(g *group) bool {
+	if g.end == f.nrec() {
+		return false
+	}
+	g.start = g.end + 1
+	for g.end = g.start; f.changed(g.end); g.end++ {
+	}
+	return true
+}
+
+func (f *xfile) groupPrevious(g *group) bool {
+	if g.start == 0 {
+		return false
+	}
+	g.end = g.start - 1
+	for g.start = g.end; f.changed(g.start - 1); g.start-- {
+	}
+	return true
+}
+
+func (f *xfile) slideDown(g *group) bool {
+	if g.end < f.nrec() && f.match(g.start, g.end) {
+		f.set(g.start, false)
+		f.set(g.end, true)
+		g.start++
+		g.end++
+		for f.changed(g.end) {
+			g.end++
+		}
+		return true
+	}
+	return false
+}
+
+func (f *xfile) slideUp(g *group) bool {
+	if g.start > 0 && f.match(g.start-1, g.end-1) {
+		g.start--
+		g.end--
+		f.set(g.start, true)
+		f.set(g.end, false)
+		for f.changed(g.start - 1) {
+			g.start--
+		}
+		return true
+	}
+	return false
+}
+
+const indentHeuristicMaxSliding = 100
+
+// compact: 같은 내용 사이에서 위치가 모호한 변경 묶음을 읽기 좋은 자리로 옮긴다.
+// 다른 쪽 파일의 변경과 나란히 놓을 수 있으면 그 자리로, 아니면 들여쓰기를 보고 고른다.
+func compact(f, fo *xfile) {
+	g := f.groupInit()
+	gOther := fo.groupInit()
+
+	for {
+		if g.end != g.start {
+			var groupSize, earliestEnd, endMatchingOther int
+			for {
+				groupSize = g.end - g.start
+				endMatchingOther = -1
+
+				for f.slideUp(&g) {
+					fo.groupPrevious(&gOther)
+				}
+				earliestEnd = g.end
+				if gOther.end > gOther.start {
+					endMatchingOther = g.end
+				}
+
+				for f.slideDown(&g) {
+					fo.groupNext(&gOther)
+					if gOther.end > gOther.start {
+						endMatchingOther = g.end
+					}
+				}
+				if groupSize == g.end-g.start {
+					break
+				}
+			}
+
+			switch {
+			case g.end == earliestEnd:
+				// 옮길 수 없음
+			case endMatchingOther != -1:
+				for gOther.end == gOther.start {
+					f.slideUp(&g)
+					fo.groupPrevious(&gOther)
+				}
+			default:
+				shift := earliestEnd
+				if g.end-groupSize-1 > shift {
+					shift = g.end - groupSize - 1
+				}
+				if g.end-indentHeuristicMaxSliding > shift {
+					shift = g.end - indentHeuristicMaxSliding
+				}
+				bestShift := -1
+				var best splitScore
+				for ; shift <= g.end; shift++ {
+					var score splitScore
+					score.add(f.measureSplit(shift))
+					score.add(f.measureSplit(shift - groupSize))
+					if bestShift == -1 || score.cmp(best) <= 0 {
+						best = score
+						bestShift = shift
+					}
+				}
+				for g.end > bestShift {
+					f.slideUp(&g)
+					fo.groupPrevious(&gOther)
+				}
+			}
+		}
+
+		if !f.groupNext(&g) {
+			break
+		}
+		fo.groupNext(&gOther)
+	}
+}
+
+const (
+	maxIndent = 200
+	maxBlanks = 20
+
+	startOfFilePenalty              = 1
+	endOfFilePenalty                = 21
+	totalBlankWeight                = -30
+	postBlankWeight                 = 6
+	relativeIndentPenalty           = -4
+	relativeIndentWithBlankPenalty  = 10
+	relativeOutdentPenalty          = 24
+	relativeOutdentWithBlankPenalty = 17
+	relativeDedentPenalty           = 23
+	relativeDedentWithBlankPenalty  = 17
+	indentWeight                    = 60
+)
+
+// indent: 줄의 들여쓰기 폭 (탭은 8칸). 공백만 있는 줄은 -1
+func indent(line string) int {
+	ret := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			ret++
+		case '\t':
+			ret += 8 - ret%8
+		case '\n', '\r', '\v', '\f':
+		default:
+			return ret
+		}
+		if ret >= maxIndent {
+			return maxIndent
+		}
+	}
+	return -1
+}
+
+type splitMeasurement struct {
+	endOfFile  bool
+	indent     int
+	preBlank   int
+	preIndent  int
+	postBlank  int
+	postIndent int
+}
+
+// measureSplit: split 번째 줄 앞에서 나눌 때 주변의 빈 줄과 들여쓰기
+func (f *xfile) measureSplit(split int) splitMeasurement {
+	var m splitMeasurement
+	if split >= f.nrec() {
+		m.endOfFile = true
+		m.indent = -1
+	} else {
+		m.indent = indent(f.recs[split])
+	}
+
+	m.preIndent = -1
+	for i := split - 1; i >= 0; i-- {
+		m.preIndent = indent(f.recs[i])
+		if m.preIndent != -1 {
+			break
+		}
+		m.preBlank++
+		if m.preBlank == maxBlanks {
+			m.preIndent = 0
+			break
+		}
+	}
+
+	m.postIndent = -1
+	for i := split + 1; i < f.nrec(); i++ {
+		m.postIndent = indent(f.recs[i])
+		if m.postIndent != -1 {
+			break
+		}
+		m.postBlank++
+		if m.postBlank == maxBlanks {
+			m.postIndent = 0
+			break
+		}
+	}
+	return m
+}
+
+type splitScore struct {
+	effectiveIndent int
+	penalty         int
+}
+
+func (s *splitScore) add(m splitMeasurement) {
+	if m.preIndent == -1 && m.preBlank == 0 {
+		s.penalty += startOfFilePenalty
+	}
+	if m.endOfFile {
+		s.penalty += endOfFilePenalty
+	}
+
+	postBlank := 0
+	if m.indent == -1 {
+		postBlank = 1 + m.postBlank
+	}
+	totalBlank := m.preBlank + postBlank
+	s.penalty += totalBlankWeight * totalBlank
+	s.penalty += postBlankWeight * postBlank
+
+	ind := m.indent
+	if ind == -1 {
+		ind = m.postIndent
+	}
+	anyBlanks := totalBlank != 0
+	s.effectiveIndent += ind
+
+	switch {
+	case ind == -1, m.preIndent == -1, ind == m.preIndent:
+	case ind > m.preIndent:
+		if anyBlanks {
+			s.penalty += relativeIndentWithBlankPenalty
+		} else {
+			s.penalty += relativeIndentPenalty
+		}
+	case m.postIndent != -1 && m.postIndent > ind:
+		// 다음 줄이 더 들여써져 있으면 새 블록의 시작
+		if anyBlanks {
+			s.penalty += relativeOutdentWithBlankPenalty
+		} else {
+			s.penalty += relativeOutdentPenalty
+		}
+	default:
+		// 블록의 끝
+		if anyBlanks {
+			s.penalty += relativeDedentWithBlankPenalty
+		} else {
+			s.penalty += relativeDedentPenalty
+		}
+	}
+}
+
+func (s splitScore) cmp(o splitScore) int {
+	c := 0
+	if s.effectiveIndent > o.effectiveIndent {
+		c = 1
+	} else if s.effectiveIndent < o.effectiveIndent {
+		c = -1
+	}
+	return indentWeight*c + (s.penalty - o.penalty)
+}
+
+// buildScript: 바뀐 줄 표시를 Edit 목록으로 바꾼다.
+func buildScript(f1, f2 *xfile) []Edit {
+	var edits []Edit
+	i1, i2 := f1.nrec(), f2.nrec()
+	for i1 >= 0 || i2 >= 0 {
+		if f1.changed(i1-1) || f2.changed(i2-1) {
+			l1, l2 := i1, i2
+			for f1.changed(i1 - 1) {
+				i1--
+			}
+			for f2.changed(i2 - 1) {
+				i2--
+			}
+			edits = append(edits, Edit{OldPos: i1, OldLen: l1 - i1, NewPos: i2, NewLen: l2 - i2})
+		}
+		i1--
+		i2--
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
@@ -0,0 +1,258 @@
+// Package dump 은 객체를 사람이 읽고 고칠 수 있는 JSON 으로 바꾸고, 그 JSON 에서 다시 객체를 만든다.
+//
+//	{
+//	  "type": "commit",
+//	  "hash": "…", "size": 184,
+//	  "tree": "…", "parents": ["…"],
+//	  "author": {"name": "A", "email": "a@x", "time": 1700000000, "tz": "+0900"},
+//	  "committer": {…},
+//	  "message": "subject\n"
+//	}
+//
+// 필드는 받은 그대로 인코딩하고 검사하지 않으므로, 정렬되지 않은 tree 나 author 가 없는 커밋처럼
+// 잘못된 객체도 만들 수 있다. (fsck 나 읽기 쪽의 에러 처리를 보여 주거나 시험할 때)
+//
+// 필드로 원래 바이트를 그대로 만들 수 없는 객체(파싱할 수 없거나 0 이 붙은 모드처럼 표준 형식이 아닌 것)는
+// 본문을 content(UTF-8) 또는 content_base64 로 함께 담는다. content 가 있으면 다른 필드보다 우선한다.
+// 그래서 Encode 한 JSON 을 Decode 하면 언제나 같은 객체(같은 해시)가 된다.
+package dump
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Object: 객체 하나의 JSON 표현
+type Object struct {
+	Type string `json:"type"`
+	// Hash, Size: Encode 가 알려 주는 값. Decode 는 무시한다
+	Hash string `json:"hash,omitempty"`
+	Size int    `json:"size"`
+	// Error: Encode 할 때 객체를 해석하지 못한 이유. Decode 는 무시한다
+	Error string `json:"error,omitempty"`
+
+	Content       *string `json:"content,omitempty"`
+	ContentBase64 *string `json:"content_base64,omitempty"`
+
+	// tree
+	Entries []Entry `json:"entries,omitempty"`
+
+	// commit
+	Tree      string     `json:"tree,omitempty"`
+	Parents   []string   `json:"parents,omitempty"`
+	Author    *Signature `json:"author,omitempty"`
+	Committer *Signature `json:"committer,omitempty"`
+
+	// tag
+	Object     string     `json:"object,omitempty"`
+	ObjectType string     `json:"object_type,omitempty"`
+	Tag        string     `json:"tag,omitempty"`
+	Tagger     *Signature `json:"tagger,omitempty"`
+
+	// commit, tag
+	Headers []Header `json:"headers,omitempty"`
+	Message *string  `json:"message,omitempty"`
+}
+
+// Entry: tree 항목. Mode 는 tree 에 적힌 8진수 문자열 그대로다. ("100644", "40000")
+type Entry struct {
+	Mode string `json:"mode"`
+	Name string `json:"name"`
+	Hash string `json:"hash"`
+}
+
+// Signature: author/committer/tagger. Time 은 유닉스 시간, TZ 는 "+0900" 형식
+type Signature struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Time  int64  `json:"time"`
+	TZ    string `json:"tz"`
+}
+
+func (s *Signature) String() string {
+	return fmt.Sprintf("%s <%s> %d %s", s.Name, s.Email, s.Time, s.TZ)
+}
+
+// Header: 그 밖의 헤더 (gpgsig, encoding 등). 여러 줄 값은 줄바꿈을 그대로 담는다
+type Header struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Encode: 객체를 JSON 으로 (들여쓰기 포함)
+func Encode(hash string, typ object.Type, content []byte) ([]byte, error) {
+	obj := &Object{Type: string(typ), Hash: hash, Size: len(content)}
+	if typ == object.TypeBlob {
+		setContent(obj, content)
+	} else if err := decodeFields(obj, typ, content); err != nil {
+		obj.Error = err.Error()
+		setContent(obj, content)
+	} else if built, err := build(obj); err != nil || !bytes.Equal(built, content) {
+		// 필드로는 같은 바이트를 만들 수 없다 (표준 형식이 아닌 객체)
+		obj.Error = "object is not in canonical form; content holds the original bytes"
+		setContent(obj, content)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(obj); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func setContent(obj *Object, content []byte) {
+	if utf8.Valid(content) && !bytes.ContainsRune(content, 0) {
+		s := string(content)
+		obj.Content = &s
+		return
+	}
+	s := base64.StdEncoding.EncodeToString(content)
+	obj.ContentBase64 = &s
+}
+
+func decodeFields(obj *Object, typ object.Type, content []byte) error {
+	// tree 항목의 해시는 객체 자신의 해시와 길이가 같다
+	algo := object.AlgorithmOf(obj.Hash)
+	if algo == nil {
+		algo = object.SHA1
+	}
+	parsed, err := object.DecodeAlgorithm(typ, content, algo)
+	if err != nil {
+		return err
+	}
+	switch o := parsed.(type) {
+	case *object.Tree:
+		obj.Entries = []Entry{}
+		for _, e := range o.Entries {
+			obj.Entries = append(obj.Entries, Entry{Mode: e.Mode.String(), Name: e.Name, Hash: e.Hash})
+		}
+	case *object.Commit:
+		obj.Tree, obj.Parents = o.Tree, o.Parents
+		obj.Author, obj.Committer = signature(o.Author), signature(o.Committer)
+		obj.Headers = headers(o.ExtraHeaders)
+		obj.Message = &o.Message
+	case *object.Tag:
+		obj.Object, obj.ObjectType, obj.Tag = o.Object, string(o.ObjectType), o.Name
+		if o.Tagger != (object.Signature{}) {
+			obj.Tagger = signature(o.Tagger)
+		}
+		obj.Headers = headers(o.ExtraHeaders)
+		obj.Message = &o.Message
+	default:
+		return fmt.Errorf("unknown object type %s", typ)
+	}
+	return nil
+}
+
+func signature(s object.Signature) *Signature {
+	if s == (object.Signature{}) {
+		return nil
+	}
+	return &Signature{Name: s.Name, Email: s.Email, Time: s.When.Unix(), TZ: s.When.Format("-0700")}
+}
+
+func headers(hs []object.Header) []Header {
+	var out []Header
+	for _, h := range hs {
+		out = append(out, Header{Key: h.Key, Value: h.Value})
+	}
+	return out
+}
+
+// Decode: JSON 에서 객체의 타입과 본문을 만든다. 필드의 값이 올바른지는 보지 않는다.
+func Decode(data []byte) (object.Type, []byte, error) {
+	var obj Object
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&obj); err != nil {
+		return "", nil, fmt.Errorf("parsing object JSON: %w", err)
+	}
+	typ := object.Type(obj.Type)
+	switch typ {
+	case object.TypeBlob, object.TypeTree, object.TypeCommit, object.TypeTag:
+	default:
+		return "", nil, fmt.Errorf("unknown object type %q", obj.Type)
+	}
+	content, err := build(&obj)
+	if err != nil {
+		return "", nil, err
+	}
+	return typ, content, nil
+}
+
+// build: content 가 있으면 그대로, 없으면 필드를 순서대로 인코딩한다.
+func build(obj *Object) ([]byte, error) {
+	switch {
+	case obj.Content != nil:
+		return []byte(*obj.Content), nil
+	case obj.ContentBase64 != nil:
+		content, err := base64.StdEncoding.DecodeString(*obj.ContentBase64)
+		if err != nil {
+			return nil, fmt.Errorf("content_base64: %w", err)
+		}
+		return content, nil
+	}
+
+	var b bytes.Buffer
+	switch object.Type(obj.Type) {
+	case object.TypeTree:
+		// 받은 순서 그대로 쓴다 (정렬하지 않는다)
+		for _, e := range obj.Entries {
+			raw, err := hex.DecodeString(e.Hash)
+			if err != nil {
+				return nil, fmt.Errorf("tree entry %q: bad hash %q", e.Name, e.Hash)
+			}
+			fmt.Fprintf(&b, "%s %s%s", e.Mode, e.Name, object.NUL)
+			b.Write(raw)
+		}
+	case object.TypeCommit:
+		header(&b, "tree", obj.Tree)
+		for _, p := range obj.Parents {
+			header(&b, "parent", p)
+		}
+		if obj.Author != nil {
+			header(&b, "author", obj.Author.String())
+		}
+		if obj.Committer != nil {
+			header(&b, "committer", obj.Committer.String())
+		}
+		writeRest(&b, obj)
+	case object.TypeTag:
+		header(&b, "object", obj.Object)
+		header(&b, "type", obj.ObjectType)
+		header(&b, "tag", obj.Tag)
+		if obj.Tagger != nil {
+			header(&b, "tagger", obj.Tagger.String())
+		}
+		writeRest(&b, obj)
+	}
+	return b.Bytes(), nil
+}
+
+// header: 빈 값이면 줄을 쓰지 않는다. 여러 줄 값은 이어지는 줄 앞에 공백을 붙인다
+func header(b *bytes.Buffer, key, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(b, "%s %s\n", key, strings.ReplaceAll(value, "\n", "\n "))
+}
+
+func writeRest(b *bytes.Buffer, obj *Object) {
+	for _, h := range obj.Headers {
+		header(b, h.Key, h.Value)
+	}
+	b.WriteString("\n")
+	if obj.Message != nil {
+		b.WriteString(*obj.Message)
+	}
+}
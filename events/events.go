@@ -0,0 +1,114 @@
+// Package events 는 명령의 진행과 결과를 기계가 읽는 JSON 줄로 내보낸다. (--events-fd)
+// GUI 처럼 gogit 을 감싸는 프로그램이 사람이 읽는 출력을 해석하지 않아도 되게 한다.
+//
+//	{"event":"start","version":1,"time":"...","command":"clone","args":["a.bundle"]}
+//	{"event":"progress","time":"...","phase":"checkout","current":3,"total":10,"path":"a.txt"}
+//	{"event":"progress","time":"...","phase":"rebase","current":1,"total":4,"commit":"<hash>"}
+//	{"event":"conflict","time":"...","path":"a.txt","message":"CONFLICT (content): ..."}
+//	{"event":"ref","time":"...","ref":"refs/heads/main","old":"<hash>","new":"<hash>"}
+//	{"event":"result","time":"...","ok":false,"exit_code":1,"error":"merge conflict"}
+//
+// ref 의 old/new 는 해시, symbolic ref 면 "ref: <target>" 이고, 없던(지운) ref 는 필드가 빠진다.
+// 필드는 더하기만 하고 이름이나 뜻을 바꾸지 않는다. 바꿔야 하면 Version 을 올린다.
+//
+// 이벤트를 쓸 곳은 context 로 넘긴다. 라이브러리 쪽은 FromContext 로 꺼내 쓰고,
+// 없으면 nil *Stream 이 되어 아무것도 쓰지 않는다.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Version: 이벤트 형식의 버전. start 이벤트에 실린다
+const Version = 1
+
+// Event: 한 줄에 쓰는 이벤트 하나. 종류에 따라 필요한 필드만 채운다
+type Event struct {
+	Event   string    `json:"event"`
+	Version int       `json:"version,omitempty"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command,omitempty"`
+	Args    []string  `json:"args,omitempty"`
+	Phase   string    `json:"phase,omitempty"`
+	Current int       `json:"current,omitempty"`
+	Total   int       `json:"total,omitempty"`
+	Path    string    `json:"path,omitempty"`
+	Commit  string    `json:"commit,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Ref     string    `json:"ref,omitempty"`
+	Old     string    `json:"old,omitempty"`
+	New     string    `json:"new,omitempty"`
+	// OK, ExitCode: result 에만 있다
+	OK       *bool  `json:"ok,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Stream: 이벤트를 w 에 한 줄씩 쓴다. 여러 goroutine 에서 같이 써도 된다.
+// 쓰기가 한 번 실패하면(읽는 쪽이 닫는 등) 그 뒤의 이벤트는 버린다. 이벤트 때문에 명령이 실패하지는 않는다.
+type Stream struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+func New(w io.Writer) *Stream {
+	return &Stream{enc: json.NewEncoder(w)}
+}
+
+// Emit: e 를 쓴다. Time 이 비어 있으면 지금 시각을 넣는다. nil Stream 이면 아무것도 하지 않는다
+func (s *Stream) Emit(e Event) {
+	if s == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = s.enc.Encode(e)
+	}
+}
+
+// Err: 처음 실패한 쓰기의 에러
+func (s *Stream) Err() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Progress: phase 단계에서 total 개 중 current 번째를 처리한다. path 는 처리하는 파일이고 없으면 빈 문자열
+func (s *Stream) Progress(phase string, current, total int, path string) {
+	s.Emit(Event{Event: "progress", Phase: phase, Current: current, Total: total, Path: path})
+}
+
+// Conflict: path 에 충돌이 남았다
+func (s *Stream) Conflict(path, message string) {
+	s.Emit(Event{Event: "conflict", Path: path, Message: message})
+}
+
+// Ref: ref 가 old 에서 new 로 바뀌었다. 만든 ref 는 old, 지운 ref 는 new 가 빈 문자열이다
+func (s *Stream) Ref(name, old, new string) {
+	s.Emit(Event{Event: "ref", Ref: name, Old: old, New: new})
+}
+
+type contextKey struct{}
+
+// WithStream: s 로 이벤트를 쓰는 context
+func WithStream(ctx context.Context, s *Stream) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext: ctx 에 담긴 Stream. 없으면 nil 이고 nil Stream 의 메서드는 아무것도 하지 않는다
+func FromContext(ctx context.Context) *Stream {
+	s, _ := ctx.Value(contextKey{}).(*Stream)
+	return s
+}
@@ -0,0 +1,209 @@
+// Package fastimport 는 git fast-import 스트림을 쓰고(Export) 읽는다(Import).
+//
+//	blob
+//	mark :1
+//	data 6
+//	hello
+//
+//	commit refs/heads/main
+//	mark :2
+//	author A <a@x> 1700000000 +0900
+//	committer A <a@x> 1700000000 +0900
+//	data 5
+//	init
+//	M 100644 :1 hello.txt
+//
+// 스트림은 객체의 바이트가 아니라 내용(파일, 이력, 메시지)을 옮기므로 git 이나 hg/svn 변환기와 주고받을 수 있다.
+// 날짜는 raw 형식("<유닉스 시간> <+hhmm>")만 다룬다.
+package fastimport
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/tmdgusya/gogit/diff"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Ref: 내보내거나 가져온 ref. Hash 는 커밋이나 annotated tag 객체다.
+type Ref struct {
+	Name string
+	Hash string
+}
+
+type exporter struct {
+	s     object.Storer
+	w     *bufio.Writer
+	marks map[string]int
+	next  int
+}
+
+// Export: refs 에서 도달 가능한 이력을 w 에 스트림으로 쓴다. (git fast-export)
+//
+// 커밋은 부모가 먼저 나오고, 각 커밋은 첫 부모와의 차이(M/D)로 쓴다. (이름 바꾸기는 D 와 M 으로)
+// 커밋은 그것에 처음 도달한 ref 로 쓰고, 끝이 이미 다른 ref 로 나온 ref 는 reset 으로 옮긴다.
+// 커밋이 아닌 것을 가리키는 tag 는 건너뛴다. 서명(gpgsig)은 옮기지 않는다. (git fast-export 의 기본값)
+func Export(ctx context.Context, w io.Writer, s object.Storer, refs []Ref) error {
+	e := &exporter{s: s, w: bufio.NewWriter(w), marks: map[string]int{}}
+	type tagRef struct {
+		ref Ref
+		tag *object.Tag
+	}
+	var tags []tagRef
+	var resets []Ref
+
+	for _, ref := range refs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		obj, err := object.ReadObject(s, ref.Hash)
+		if err != nil {
+			return err
+		}
+		switch o := obj.(type) {
+		case *object.Tag:
+			if o.ObjectType == object.TypeCommit {
+				if err := e.exportHistory(ctx, ref.Name, o.Object); err != nil {
+					return err
+				}
+				tags = append(tags, tagRef{ref, o})
+			}
+		case *object.Commit:
+			exported := e.marks[ref.Hash] != 0
+			if err := e.exportHistory(ctx, ref.Name, ref.Hash); err != nil {
+				return err
+			}
+			if exported {
+				resets = append(resets, ref)
+			}
+		}
+	}
+
+	for _, r := range resets {
+		fmt.Fprintf(e.w, "reset %s\nfrom :%d\n\n", r.Name, e.marks[r.Hash])
+	}
+	for _, t := range tags {
+		fmt.Fprintf(e.w, "tag %s\n", strings.TrimPrefix(t.ref.Name, "refs/tags/"))
+		fmt.Fprintf(e.w, "from :%d\n", e.marks[t.tag.Object])
+		if t.tag.Tagger != (object.Signature{}) {
+			fmt.Fprintf(e.w, "tagger %s\n", t.tag.Tagger)
+		}
+		e.data([]byte(t.tag.Message))
+	}
+	return e.w.Flush()
+}
+
+// exportHistory: tip 의 이력 중 아직 쓰지 않은 커밋을 부모부터 ref 이름으로 쓴다.
+func (e *exporter) exportHistory(ctx context.Context, ref, tip string) error {
+	exported := map[string]bool{}
+	for hash := range e.marks {
+		exported[hash] = true
+	}
+	it := object.NewCommitIter(e.s, []string{tip}, object.OrderTopo)
+	it.Hide(exported)
+	var commits []string
+	err := it.ForEachContext(ctx, func(hash string, _ *object.Commit) error {
+		commits = append(commits, hash)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	slices.Reverse(commits)
+	for _, hash := range commits {
+		if err := e.exportCommit(ref, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *exporter) mark(hash string) int {
+	e.next++
+	e.marks[hash] = e.next
+	return e.next
+}
+
+func (e *exporter) exportCommit(ref, hash string) error {
+	c, err := object.ReadCommit(e.s, hash)
+	if err != nil {
+		return err
+	}
+	parentTree := ""
+	if len(c.Parents) > 0 {
+		parent, err := object.ReadCommit(e.s, c.Parents[0])
+		if err != nil {
+			return err
+		}
+		parentTree = parent.Tree
+	}
+	changes, err := diff.Trees(e.s, parentTree, c.Tree)
+	if err != nil {
+		return err
+	}
+
+	// 커밋이 쓰는 blob 을 먼저 내보낸다
+	for _, ch := range changes {
+		if ch.To.Hash == "" || ch.To.Mode == object.ModeGitlink || e.marks[ch.To.Hash] != 0 {
+			continue
+		}
+		_, content, err := e.s.Read(ch.To.Hash)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(e.w, "blob\nmark :%d\n", e.mark(ch.To.Hash))
+		e.data(content)
+	}
+
+	if len(c.Parents) == 0 {
+		// 이 ref 에 앞서 쓴 커밋이 있어도 뿌리 커밋부터 새로 시작한다
+		fmt.Fprintf(e.w, "reset %s\n", ref)
+	}
+	fmt.Fprintf(e.w, "commit %s\nmark :%d\n", ref, e.mark(hash))
+	if c.Author != (object.Signature{}) {
+		fmt.Fprintf(e.w, "author %s\n", c.Author)
+	}
+	fmt.Fprintf(e.w, "committer %s\n", c.Committer)
+	if enc, ok := c.Header("encoding"); ok {
+		fmt.Fprintf(e.w, "encoding %s\n", enc)
+	}
+	e.data([]byte(c.Message))
+	for i, p := range c.Parents {
+		cmd := "merge"
+		if i == 0 {
+			cmd = "from"
+		}
+		if m := e.marks[p]; m != 0 {
+			fmt.Fprintf(e.w, "%s :%d\n", cmd, m)
+		} else {
+			fmt.Fprintf(e.w, "%s %s\n", cmd, p)
+		}
+	}
+	for _, ch := range changes {
+		if ch.From.Hash != "" && (ch.To.Hash == "" || ch.From.Path != ch.To.Path) {
+			fmt.Fprintf(e.w, "D %s\n", diff.QuotePath(ch.From.Path))
+		}
+	}
+	for _, ch := range changes {
+		switch {
+		case ch.To.Hash == "":
+		case ch.To.Mode == object.ModeGitlink:
+			fmt.Fprintf(e.w, "M %s %s %s\n", ch.To.Mode, ch.To.Hash, diff.QuotePath(ch.To.Path))
+		default:
+			fmt.Fprintf(e.w, "M %s :%d %s\n", ch.To.Mode, e.marks[ch.To.Hash], diff.QuotePath(ch.To.Path))
+		}
+	}
+	e.w.WriteString("\n")
+	return nil
+}
+
+// data: "data <길이>" 와 내용. 끝에 줄바꿈을 하나 더 붙인다 (git 과 같이, 읽는 쪽은 건너뛴다)
+func (e *exporter) data(content []byte) {
+	fmt.Fprintf(e.w, "data %d\n", len(content))
+	e.w.Write(content)
+	e.w.WriteString("\n")
+}
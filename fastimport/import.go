@@ -0,0 +1,661 @@
+package fastimport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/tmdgusya/gogit/diff"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Options: 가져오기 옵션
+type Options struct {
+	// Progress: 스트림의 progress 명령을 쓸 곳. nil 이면 버린다
+	Progress io.Writer
+}
+
+// Result: 가져온 결과
+type Result struct {
+	// Refs: 스트림이 바꾼 ref 와 마지막 값. 처음 나온 순서대로. 커밋 없이 reset 만 한 ref 는 없다
+	Refs    []Ref
+	Blobs   int
+	Commits int
+	Tags    int
+	// Marks: mark 번호와 그 객체
+	Marks map[int]string
+}
+
+type importer struct {
+	ctx    context.Context
+	s      object.Storer
+	r      *bufio.Reader
+	lookup func(string) (string, bool)
+	opts   Options
+	res    *Result
+
+	// unread: 다음 readLine 이 돌려줄 줄 (명령의 끝을 알려고 미리 읽은 줄)
+	unread *string
+	line   int
+
+	// branches: 스트림 안에서의 ref 값. 빈 문자열이면 reset 만 되어 아직 커밋이 없다
+	branches map[string]string
+	order    []string
+	// trees: 가져온 커밋의 tree (부모의 tree 를 다시 읽지 않으려고)
+	trees map[string]string
+}
+
+// Import: r 의 스트림을 읽어 객체를 s 에 쓴다. (git fast-import)
+//
+// ref 는 바꾸지 않고 Result.Refs 로 돌려준다. (fast-forward 검사와 ref 쓰기는 부르는 쪽이 한다)
+// from/merge 의 값이 mark 도, 스트림의 ref 도, 해시도 아니면 lookup 으로 저장소의 ref 를 찾는다.
+// ls, cat-blob, get-mark, 노트(N) 와 date-format=raw 가 아닌 날짜 형식은 지원하지 않는다.
+func Import(ctx context.Context, r io.Reader, s object.Storer, lookup func(string) (string, bool), opts Options) (*Result, error) {
+	im := &importer{
+		ctx:      ctx,
+		s:        s,
+		r:        bufio.NewReader(r),
+		lookup:   lookup,
+		opts:     opts,
+		res:      &Result{Marks: map[int]string{}},
+		branches: map[string]string{},
+		trees:    map[string]string{},
+	}
+	if err := im.run(); err != nil {
+		return nil, fmt.Errorf("fast-import: line %d: %w", im.line, err)
+	}
+	for _, name := range im.order {
+		if hash := im.branches[name]; hash != "" {
+			im.res.Refs = append(im.res.Refs, Ref{Name: name, Hash: hash})
+		}
+	}
+	return im.res, nil
+}
+
+func (im *importer) run() error {
+	for {
+		if err := im.ctx.Err(); err != nil {
+			return err
+		}
+		line, err := im.readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case line == "blob":
+			err = im.blob()
+		case cmd == "commit":
+			err = im.commit(arg)
+		case cmd == "tag":
+			err = im.tag(arg)
+		case cmd == "reset":
+			err = im.reset(arg)
+		case line == "checkpoint":
+		case cmd == "progress":
+			if im.opts.Progress != nil {
+				fmt.Fprintln(im.opts.Progress, line)
+			}
+		case line == "done":
+			return nil
+		case cmd == "feature":
+			err = feature(arg)
+		case cmd == "option":
+			// 다른 가져오기 도구용 option 은 무시한다 (git 도 그렇다)
+		default:
+			return fmt.Errorf("unsupported command: %s", line)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func feature(name string) error {
+	switch name {
+	case "done", "date-format=raw":
+		return nil
+	}
+	return fmt.Errorf("unsupported feature: %s", name)
+}
+
+func (im *importer) readLine() (string, error) {
+	if im.unread != nil {
+		line := *im.unread
+		im.unread = nil
+		return line, nil
+	}
+	line, err := im.r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	im.line++
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+func (im *importer) unreadLine(line string) {
+	im.unread = &line
+}
+
+// optional: 다음 줄이 "<key> <값>" 이면 값을, 아니면 그 줄을 되돌리고 false
+func (im *importer) optional(key string) (string, bool, error) {
+	line, err := im.readLine()
+	if err == io.EOF {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if value, ok := strings.CutPrefix(line, key+" "); ok {
+		return value, true, nil
+	}
+	im.unreadLine(line)
+	return "", false, nil
+}
+
+// mark: "mark :<n>" 이 있으면 그 번호, 없으면 0. original-oid 는 읽고 버린다
+func (im *importer) mark() (int, error) {
+	value, ok, err := im.optional("mark")
+	if err != nil || !ok {
+		return 0, err
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(value, ":"))
+	if err != nil || n <= 0 || !strings.HasPrefix(value, ":") {
+		return 0, fmt.Errorf("invalid mark '%s'", value)
+	}
+	if _, _, err := im.optional("original-oid"); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// dataHeader: "data <n>" 이면 길이를, "data <<<구분자>" 면 -1 과 구분자를
+func (im *importer) dataHeader() (int64, string, error) {
+	line, err := im.readLine()
+	if err == io.EOF {
+		return 0, "", io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	value, ok := strings.CutPrefix(line, "data ")
+	if !ok {
+		return 0, "", fmt.Errorf("expected data command, got '%s'", line)
+	}
+	if delim, ok := strings.CutPrefix(value, "<<"); ok {
+		return -1, delim, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, "", fmt.Errorf("invalid data length '%s'", value)
+	}
+	return n, "", nil
+}
+
+// data: data 명령의 내용 전체
+func (im *importer) data() ([]byte, error) {
+	n, delim, err := im.dataHeader()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return im.delimited(delim)
+	}
+	content := make([]byte, n)
+	if _, err := io.ReadFull(im.r, content); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	im.line += bytes.Count(content, []byte("\n"))
+	return content, im.skipLF()
+}
+
+// delimited: 구분자만 있는 줄까지. 구분자 앞의 줄바꿈은 내용에 들어간다
+func (im *importer) delimited(delim string) ([]byte, error) {
+	var b bytes.Buffer
+	for {
+		line, err := im.readLine()
+		if err == io.EOF {
+			return nil, fmt.Errorf("missing data terminator '%s'", delim)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == delim {
+			return b.Bytes(), nil
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+}
+
+// writeData: data 명령의 내용을 blob 으로 쓴다. 길이가 주어지면 메모리에 모으지 않고 흘려 쓴다
+func (im *importer) writeData() (string, error) {
+	n, delim, err := im.dataHeader()
+	if err != nil {
+		return "", err
+	}
+	if n < 0 {
+		content, err := im.delimited(delim)
+		if err != nil {
+			return "", err
+		}
+		return im.s.Write(object.TypeBlob, content)
+	}
+	hash, err := object.WriteStreamContext(im.ctx, im.s, object.TypeBlob, n, io.LimitReader(im.r, n))
+	if err != nil {
+		return "", err
+	}
+	return hash, im.skipLF()
+}
+
+// skipLF: data 뒤의 줄바꿈 하나는 있어도 되고 없어도 된다
+func (im *importer) skipLF() error {
+	c, err := im.r.ReadByte()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if c == '\n' {
+		im.line++
+		return nil
+	}
+	return im.r.UnreadByte()
+}
+
+func (im *importer) setMark(n int, hash string) {
+	if n > 0 {
+		im.res.Marks[n] = hash
+	}
+}
+
+func (im *importer) blob() error {
+	n, err := im.mark()
+	if err != nil {
+		return err
+	}
+	hash, err := im.writeData()
+	if err != nil {
+		return err
+	}
+	im.setMark(n, hash)
+	im.res.Blobs++
+	return nil
+}
+
+// resolve: from/merge/tag 의 대상. ":<mark>", 스트림의 ref, 해시, 저장소의 ref 순서로 찾는다
+func (im *importer) resolve(value string) (string, error) {
+	if mark, ok := strings.CutPrefix(value, ":"); ok {
+		n, err := strconv.Atoi(mark)
+		if err != nil {
+			return "", fmt.Errorf("invalid mark '%s'", value)
+		}
+		hash, ok := im.res.Marks[n]
+		if !ok {
+			return "", fmt.Errorf("mark %s not declared", value)
+		}
+		return hash, nil
+	}
+	name := strings.TrimSuffix(value, "^0")
+	if hash, ok := im.branches[name]; ok && hash != "" {
+		return hash, nil
+	}
+	if object.IsHash(name) {
+		return name, nil
+	}
+	if hash, ok := im.lookup(name); ok {
+		return hash, nil
+	}
+	return "", fmt.Errorf("cannot resolve '%s'", value)
+}
+
+func (im *importer) setBranch(name, hash string) {
+	if _, ok := im.branches[name]; !ok {
+		im.order = append(im.order, name)
+	}
+	im.branches[name] = hash
+}
+
+func (im *importer) reset(ref string) error {
+	hash := ""
+	value, ok, err := im.optional("from")
+	if err != nil {
+		return err
+	}
+	if ok {
+		if hash, err = im.resolve(value); err != nil {
+			return err
+		}
+	}
+	im.setBranch(ref, hash)
+	return nil
+}
+
+// signature: "<이름> <<email>> <시간> <시간대>" (date-format=raw)
+func (im *importer) signature(key string, required bool) (object.Signature, error) {
+	value, ok, err := im.optional(key)
+	if err != nil || !ok {
+		if err == nil && required {
+			err = fmt.Errorf("expected %s", key)
+		}
+		return object.Signature{}, err
+	}
+	sig, err := object.ParseSignature(value)
+	if err != nil {
+		return object.Signature{}, fmt.Errorf("%s: %w", key, err)
+	}
+	return sig, nil
+}
+
+// treeOf: 커밋의 tree
+func (im *importer) treeOf(commit string) (string, error) {
+	if tree, ok := im.trees[commit]; ok {
+		return tree, nil
+	}
+	c, err := object.ReadCommit(im.s, commit)
+	if err != nil {
+		return "", err
+	}
+	im.trees[commit] = c.Tree
+	return c.Tree, nil
+}
+
+func (im *importer) commit(ref string) error {
+	n, err := im.mark()
+	if err != nil {
+		return err
+	}
+	c := &object.Commit{}
+	if c.Author, err = im.signature("author", false); err != nil {
+		return err
+	}
+	if c.Committer, err = im.signature("committer", true); err != nil {
+		return err
+	}
+	if c.Author == (object.Signature{}) {
+		c.Author = c.Committer
+	}
+	if enc, ok, err := im.optional("encoding"); err != nil {
+		return err
+	} else if ok {
+		c.ExtraHeaders = append(c.ExtraHeaders, object.Header{Key: "encoding", Value: enc})
+	}
+	message, err := im.data()
+	if err != nil {
+		return err
+	}
+	c.Message = string(message)
+
+	// from 이 없으면 스트림에서 이 ref 의 지금 커밋 뒤에 붙는다
+	if from, ok, err := im.optional("from"); err != nil {
+		return err
+	} else if ok {
+		if strings.Trim(from, "0") != "" {
+			parent, err := im.resolve(from)
+			if err != nil {
+				return err
+			}
+			c.Parents = append(c.Parents, parent)
+		}
+	} else if tip := im.branches[ref]; tip != "" {
+		c.Parents = append(c.Parents, tip)
+	}
+	for {
+		merge, ok, err := im.optional("merge")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		parent, err := im.resolve(merge)
+		if err != nil {
+			return err
+		}
+		c.Parents = append(c.Parents, parent)
+	}
+
+	tree := ""
+	if len(c.Parents) > 0 {
+		if tree, err = im.treeOf(c.Parents[0]); err != nil {
+			return err
+		}
+	}
+	if tree, err = im.fileChanges(tree); err != nil {
+		return err
+	}
+	if tree == "" {
+		if tree, err = object.WriteObject(im.s, &object.Tree{}); err != nil {
+			return err
+		}
+	}
+	c.Tree = tree
+
+	hash, err := object.WriteObject(im.s, c)
+	if err != nil {
+		return err
+	}
+	im.trees[hash] = tree
+	im.setMark(n, hash)
+	im.setBranch(ref, hash)
+	im.res.Commits++
+	return nil
+}
+
+// fileChanges: 커밋의 M/D/C/R/deleteall 을 tree 에 적용한다. 다른 줄이 나오면 되돌리고 끝낸다
+func (im *importer) fileChanges(tree string) (string, error) {
+	for {
+		line, err := im.readLine()
+		if err == io.EOF {
+			return tree, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch {
+		case line == "deleteall":
+			tree = ""
+		case cmd == "M":
+			tree, err = im.modify(tree, arg)
+		case cmd == "D":
+			var p string
+			if p, err = onePath(arg); err == nil {
+				tree, err = replace(im.s, tree, p, nil)
+			}
+		case cmd == "C" || cmd == "R":
+			tree, err = im.copyPath(tree, arg, cmd == "R")
+		case cmd == "N":
+			return "", errors.New("notemodify (N) is not supported")
+		default:
+			if line != "" {
+				im.unreadLine(line)
+			}
+			return tree, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// replace: 빈 tree("")에서도 쓸 수 있는 object.ReplacePath. 지운 결과가 빈 tree 면 "" 를 돌려준다
+func replace(s object.Storer, tree, p string, entry *object.TreeEntry) (string, error) {
+	if entry == nil && tree == "" {
+		return "", nil
+	}
+	hash, err := object.ReplacePath(s, tree, p, entry)
+	if err != nil {
+		return "", err
+	}
+	if entry == nil {
+		if t, err := object.ReadTree(s, hash); err == nil && len(t.Entries) == 0 {
+			return "", nil
+		}
+	}
+	return hash, nil
+}
+
+// modes: M 명령에 쓸 수 있는 모드
+var modes = map[string]object.Mode{
+	"644":    object.ModeRegular,
+	"100644": object.ModeRegular,
+	"755":    object.ModeExecutable,
+	"100755": object.ModeExecutable,
+	"120000": object.ModeSymlink,
+	"160000": object.ModeGitlink,
+	"040000": object.ModeTree,
+}
+
+// modify: "M <mode> <:mark|해시|inline> <path>"
+func (im *importer) modify(tree, arg string) (string, error) {
+	fields := strings.SplitN(arg, " ", 3)
+	if len(fields) != 3 {
+		return "", fmt.Errorf("invalid filemodify 'M %s'", arg)
+	}
+	mode, ok := modes[fields[0]]
+	if !ok {
+		return "", fmt.Errorf("invalid mode '%s'", fields[0])
+	}
+	p, err := onePath(fields[2])
+	if err != nil {
+		return "", err
+	}
+
+	var hash string
+	switch ref := fields[1]; {
+	case ref == "inline":
+		if mode == object.ModeTree || mode == object.ModeGitlink {
+			return "", fmt.Errorf("inline data for mode %s", fields[0])
+		}
+		if hash, err = im.writeData(); err != nil {
+			return "", err
+		}
+		im.res.Blobs++
+	case strings.HasPrefix(ref, ":"):
+		if hash, err = im.resolve(ref); err != nil {
+			return "", err
+		}
+	case object.IsHash(ref):
+		hash = ref
+	default:
+		return "", fmt.Errorf("invalid dataref '%s'", ref)
+	}
+
+	if p == "" {
+		if mode != object.ModeTree {
+			return "", errors.New("filemodify with an empty path")
+		}
+		return hash, nil
+	}
+	return replace(im.s, tree, p, &object.TreeEntry{Mode: mode, Hash: hash})
+}
+
+// copyPath: "C <src> <dst>" / "R <src> <dst>". 따옴표 없는 src 에는 공백이 없어야 한다
+func (im *importer) copyPath(tree, arg string, rename bool) (string, error) {
+	var src, rest string
+	var err error
+	if strings.HasPrefix(arg, `"`) {
+		if src, rest, err = diff.UnquotePath(arg); err != nil {
+			return "", err
+		}
+		rest = strings.TrimPrefix(rest, " ")
+	} else {
+		src, rest, _ = strings.Cut(arg, " ")
+	}
+	dst, err := onePath(rest)
+	if err != nil {
+		return "", err
+	}
+	if src == "" || dst == "" {
+		return "", fmt.Errorf("invalid copy or rename '%s'", arg)
+	}
+
+	entry, ok, err := object.LookupPath(im.s, tree, src)
+	if tree == "" {
+		ok, err = false, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("path %s not in branch", src)
+	}
+	if rename {
+		if tree, err = replace(im.s, tree, src, nil); err != nil {
+			return "", err
+		}
+	}
+	return replace(im.s, tree, dst, &entry)
+}
+
+// onePath: 줄 끝까지가 경로다. 따옴표로 감쌌으면 푼다
+func onePath(s string) (string, error) {
+	p, rest, err := diff.UnquotePath(s)
+	if err != nil {
+		return "", err
+	}
+	if rest != "" {
+		return "", fmt.Errorf("garbage after path: %s", s)
+	}
+	return p, nil
+}
+
+// tag: annotated tag 를 만들고 refs/tags/<name> 에 둔다
+func (im *importer) tag(name string) error {
+	n, err := im.mark()
+	if err != nil {
+		return err
+	}
+	from, ok, err := im.optional("from")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("tag %s: expected from", name)
+	}
+	target, err := im.resolve(from)
+	if err != nil {
+		return err
+	}
+	if _, _, err := im.optional("original-oid"); err != nil {
+		return err
+	}
+	t := &object.Tag{Object: target, Name: name}
+	if t.Tagger, err = im.signature("tagger", false); err != nil {
+		return err
+	}
+	message, err := im.data()
+	if err != nil {
+		return err
+	}
+	t.Message = string(message)
+	r, err := im.s.Open(target)
+	if err != nil {
+		return err
+	}
+	t.ObjectType = r.Type
+	r.Close()
+
+	hash, err := object.WriteObject(im.s, t)
+	if err != nil {
+		return err
+	}
+	im.setMark(n, hash)
+	im.setBranch("refs/tags/"+name, hash)
+	im.res.Tags++
+	return nil
+}
@@ -0,0 +1,111 @@
+package gogit
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// CommitFilter: log 등에서 커밋을 고르는 조건. 비어 있는 조건은 검사하지 않는다.
+// 같은 종류의 조건이 여러 개면 하나만 맞아도 되고(OR), 종류가 다르면 모두 맞아야 한다(AND). (git 과 동일)
+type CommitFilter struct {
+	// Since/Until: committer 시간의 범위 (경계 포함)
+	Since time.Time
+	Until time.Time
+	// Authors: "Name <email>" 에 대한 정규식
+	Authors []*regexp.Regexp
+	// Greps: 메시지의 각 줄에 대한 정규식
+	Greps []*regexp.Regexp
+}
+
+// Match: 커밋이 모든 조건을 만족하는지
+func (f *CommitFilter) Match(c *object.Commit) bool {
+	when := c.Committer.When
+	if !f.Since.IsZero() && when.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && when.After(f.Until) {
+		return false
+	}
+	if len(f.Authors) > 0 && !matchAny(f.Authors, fmt.Sprintf("%s <%s>", c.Author.Name, c.Author.Email)) {
+		return false
+	}
+	if len(f.Greps) > 0 && !matchAny(f.Greps, c.Message) {
+		return false
+	}
+	return true
+}
+
+func matchAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompileLinePattern: ^ 와 $ 가 줄 단위로 맞도록 컴파일한다. (git 은 메시지를 줄마다 검사한다)
+func CompileLinePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?m)" + pattern)
+}
+
+var relativeDate = regexp.MustCompile(`^(\d+)[\s.]*(second|minute|hour|day|week|month|year)s?[\s.]+ago$`)
+
+// ParseDate: --since/--until 에 쓰는 날짜를 해석한다.
+//
+//	2024-03-01, 2024-03-01 12:30:00, RFC 3339, @1700000000 (unix 시간)
+//	now, yesterday, "3 days ago", "2 weeks ago", "2.weeks.ago" (git 설정에 쓰는 형식)
+//
+// 시간대가 없는 날짜는 now 의 시간대로 본다.
+func ParseDate(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	switch s {
+	case "now":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	if ts, ok := strings.CutPrefix(s, "@"); ok {
+		sec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date: %s", s)
+		}
+		return time.Unix(sec, 0), nil
+	}
+
+	if m := relativeDate.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "second":
+			return now.Add(-time.Duration(n) * time.Second), nil
+		case "minute":
+			return now.Add(-time.Duration(n) * time.Minute), nil
+		case "hour":
+			return now.Add(-time.Duration(n) * time.Hour), nil
+		case "day":
+			return now.AddDate(0, 0, -n), nil
+		case "week":
+			return now.AddDate(0, 0, -7*n), nil
+		case "month":
+			return now.AddDate(0, -n, 0), nil
+		case "year":
+			return now.AddDate(-n, 0, 0), nil
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(s)); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %s", s)
+}
@@ -0,0 +1,371 @@
+// Package fsck 는 객체 저장소가 망가지지 않았는지 확인한다. (git fsck)
+//
+//	report, _ := fsck.Check(ctx, store, fsck.Options{Refs: refs})
+//	report.WriteText(os.Stdout)
+//
+// 모든 객체를 다시 해시해 이름과 맞는지 보고, commit/tree/tag 의 문법을 git 의 fsck 처럼 엄격하게 검사한다.
+// 그다음 ref 에서 출발해 가리키는 객체가 모두 있는지(연결성) 확인하고,
+// 어디에서도 가리키지 않는 객체를 dangling 으로 알려 준다.
+package fsck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Options: 검사 옵션
+type Options struct {
+	// Refs: ref 이름과 가리키는 해시. 객체가 없으면 문제로 보고한다.
+	Refs map[string]string
+	// Extra: 도달 가능성을 따질 때만 쓰는 시작점 (reflog 에 남은 커밋 등)
+	Extra []string
+	// RepoDir: tree 항목으로 있으면 안 되는 저장소 디렉토리 이름. 빈 문자열이면 ".gogit"
+	// ".git" 은 언제나 문제로 본다. (object.Tree.ValidateRepoDir)
+	RepoDir string
+}
+
+// Problem: 읽을 수 없거나 문법이 잘못된 객체, 또는 잘못된 ref
+type Problem struct {
+	// Name: 객체 해시 또는 ref 이름
+	Name string
+	// Type: 객체의 타입 (알 수 없으면 빈 문자열)
+	Type    object.Type
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Type == "" {
+		return fmt.Sprintf("error in %s: %s", p.Name, p.Message)
+	}
+	return fmt.Sprintf("error in %s %s: %s", p.Type, p.Name, p.Message)
+}
+
+// Link: 한 객체가 다른 객체를 가리키는 것
+type Link struct {
+	From     string
+	FromType object.Type
+	To       string
+	ToType   object.Type
+}
+
+// Dangling: 어디에서도 가리키지 않고 ref 에서도 도달할 수 없는 객체
+type Dangling struct {
+	Hash string
+	Type object.Type
+}
+
+// Report: Check 의 결과
+type Report struct {
+	// Checked: 검사한 객체 수
+	Checked  int
+	Problems []Problem
+	// Missing: 가리키는 객체가 저장소에 없는 링크 (ref 가 가리키는 경우 From 은 ref 이름)
+	Missing []Link
+	// Dangling: 문제는 아니다. 지워진 브랜치의 커밋이나 커밋하지 않은 write-tree 결과 등
+	Dangling []Dangling
+}
+
+// OK: 문제나 없는 객체가 없는지. dangling 객체는 문제가 아니다.
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0 && len(r.Missing) == 0
+}
+
+// WriteText: git fsck 와 비슷한 형식으로 쓴다.
+func (r *Report) WriteText(w io.Writer, dangling bool) error {
+	var b strings.Builder
+	for _, p := range r.Problems {
+		b.WriteString(p.String() + "\n")
+	}
+	for _, l := range r.Missing {
+		if l.FromType == "" {
+			fmt.Fprintf(&b, "broken link from %s\n", l.From)
+		} else {
+			fmt.Fprintf(&b, "broken link from %s %s\n", l.FromType, l.From)
+		}
+		fmt.Fprintf(&b, "              to %s %s\n", l.ToType, l.To)
+	}
+	for _, hash := range r.missingHashes() {
+		fmt.Fprintf(&b, "missing %s %s\n", hash.Type, hash.Hash)
+	}
+	if dangling {
+		for _, d := range r.Dangling {
+			fmt.Fprintf(&b, "dangling %s %s\n", d.Type, d.Hash)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// missingHashes: 없는 객체를 한 번씩만
+func (r *Report) missingHashes() []Dangling {
+	seen := map[string]bool{}
+	var out []Dangling
+	for _, l := range r.Missing {
+		if !seen[l.To] {
+			seen[l.To] = true
+			out = append(out, Dangling{Hash: l.To, Type: l.ToType})
+		}
+	}
+	return out
+}
+
+// node: 읽을 수 있고 문법이 맞는 객체와 그 객체가 가리키는 것들
+type node struct {
+	typ   object.Type
+	links []Link
+}
+
+// Check: s 의 모든 객체를 검사한다. 발견한 문제는 에러가 아니라 Report 에 모은다.
+// 저장소 자체를 읽을 수 없을 때만 에러를 돌려준다.
+func Check(ctx context.Context, s object.Storer, opts Options) (*Report, error) {
+	hashes, err := object.AllHashes(s)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(hashes)
+
+	report := &Report{Checked: len(hashes)}
+	nodes := make(map[string]*node, len(hashes))
+	// broken: 저장소에는 있지만 망가진 객체. 없는 객체로 보고하지 않는다.
+	broken := map[string]bool{}
+	for _, hash := range hashes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		typ, links, err := checkObject(s, hash, opts.RepoDir)
+		if err != nil {
+			report.Problems = append(report.Problems, Problem{Name: hash, Type: typ, Message: err.Error()})
+			broken[hash] = true
+			continue
+		}
+		nodes[hash] = &node{typ: typ, links: links}
+	}
+
+	// 가리키는 객체가 있고 타입이 맞는지
+	referenced := map[string]bool{}
+	for _, hash := range hashes {
+		n, ok := nodes[hash]
+		if !ok {
+			continue
+		}
+		for _, l := range n.links {
+			referenced[l.To] = true
+			report.checkLink(l, nodes, broken)
+		}
+	}
+	refNames := make([]string, 0, len(opts.Refs))
+	for name := range opts.Refs {
+		refNames = append(refNames, name)
+	}
+	sort.Strings(refNames)
+	for _, name := range refNames {
+		hash := opts.Refs[name]
+		if !s.Algorithm().IsHash(hash) {
+			report.Problems = append(report.Problems, Problem{Name: name, Message: fmt.Sprintf("invalid object name %q", hash)})
+			continue
+		}
+		if _, ok := nodes[hash]; !ok && !broken[hash] {
+			report.Missing = append(report.Missing, Link{From: name, To: hash, ToType: object.TypeCommit})
+		}
+	}
+
+	// ref 와 추가 시작점에서 도달할 수 있는 객체
+	reachable := map[string]bool{}
+	stack := make([]string, 0, len(refNames)+len(opts.Extra))
+	for _, name := range refNames {
+		stack = append(stack, opts.Refs[name])
+	}
+	stack = append(stack, opts.Extra...)
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if reachable[hash] {
+			continue
+		}
+		reachable[hash] = true
+		if n, ok := nodes[hash]; ok {
+			for _, l := range n.links {
+				stack = append(stack, l.To)
+			}
+		}
+	}
+	for _, hash := range hashes {
+		n, ok := nodes[hash]
+		if ok && !reachable[hash] && !referenced[hash] {
+			report.Dangling = append(report.Dangling, Dangling{Hash: hash, Type: n.typ})
+		}
+	}
+	return report, nil
+}
+
+func (r *Report) checkLink(l Link, nodes map[string]*node, broken map[string]bool) {
+	if broken[l.To] {
+		return
+	}
+	target, ok := nodes[l.To]
+	if !ok {
+		r.Missing = append(r.Missing, l)
+		return
+	}
+	if target.typ != l.ToType {
+		r.Problems = append(r.Problems, Problem{
+			Name:    l.From,
+			Type:    l.FromType,
+			Message: fmt.Sprintf("%s points to %s %s, not a %s", l.From, target.typ, l.To, l.ToType),
+		})
+	}
+}
+
+// checkObject: 객체를 다시 해시하고 문법을 검사한다. 가리키는 객체들을 돌려준다.
+func checkObject(s object.Storer, hash string, repoDir string) (object.Type, []Link, error) {
+	raw, err := s.ReadRaw(hash)
+	if errors.Is(err, object.ErrCorrupt) {
+		return "", nil, err
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to read: %v", err)
+	}
+	a := s.Algorithm()
+	if actual := a.Sum(raw); actual != hash {
+		return "", nil, fmt.Errorf("hash mismatch (content hashes to %s)", actual)
+	}
+	typ, payload, err := object.Parse(raw)
+	if err != nil {
+		return "", nil, err
+	}
+	var links []Link
+	switch typ {
+	case object.TypeBlob:
+	case object.TypeCommit:
+		links, err = checkCommit(a, hash, payload)
+	case object.TypeTree:
+		links, err = checkTree(a, hash, payload, repoDir)
+	case object.TypeTag:
+		links, err = checkTag(a, hash, payload)
+	default:
+		return "", nil, fmt.Errorf("unknown object type %q", typ)
+	}
+	return typ, links, err
+}
+
+// checkCommit: git 과 같이 tree, parent..., author, committer 가 이 순서로 와야 한다. 해시는 a 의 길이여야 한다.
+func checkCommit(a *object.Algorithm, hash string, payload []byte) ([]Link, error) {
+	var c object.Commit
+	if err := c.Decode(payload); err != nil {
+		return nil, err
+	}
+	lines := headerLines(payload)
+	i := 0
+	if i >= len(lines) || !strings.HasPrefix(lines[i], "tree ") {
+		return nil, fmt.Errorf("invalid format - expected 'tree' line")
+	}
+	if !a.IsHash(c.Tree) {
+		return nil, fmt.Errorf("invalid 'tree' line format - bad sha1")
+	}
+	for i++; i < len(lines) && strings.HasPrefix(lines[i], "parent "); i++ {
+	}
+	for _, parent := range c.Parents {
+		if !a.IsHash(parent) {
+			return nil, fmt.Errorf("invalid 'parent' line format - bad sha1")
+		}
+	}
+	if i >= len(lines) || !strings.HasPrefix(lines[i], "author ") {
+		return nil, fmt.Errorf("invalid format - expected 'author' line")
+	}
+	i++
+	if i >= len(lines) || !strings.HasPrefix(lines[i], "committer ") {
+		return nil, fmt.Errorf("invalid format - expected 'committer' line")
+	}
+
+	links := []Link{{From: hash, FromType: object.TypeCommit, To: c.Tree, ToType: object.TypeTree}}
+	for _, parent := range c.Parents {
+		links = append(links, Link{From: hash, FromType: object.TypeCommit, To: parent, ToType: object.TypeCommit})
+	}
+	return links, nil
+}
+
+// checkTag: object, type, tag 가 이 순서로 와야 한다. tagger 가 없는 오래된 tag 는 허용한다.
+func checkTag(a *object.Algorithm, hash string, payload []byte) ([]Link, error) {
+	var t object.Tag
+	if err := t.Decode(payload); err != nil {
+		return nil, err
+	}
+	lines := headerLines(payload)
+	for i, key := range []string{"object ", "type ", "tag "} {
+		if i >= len(lines) || !strings.HasPrefix(lines[i], key) {
+			return nil, fmt.Errorf("invalid format - expected '%s' line", strings.TrimSpace(key))
+		}
+	}
+	if !a.IsHash(t.Object) {
+		return nil, fmt.Errorf("invalid 'object' line format - bad sha1")
+	}
+	switch t.ObjectType {
+	case object.TypeBlob, object.TypeTree, object.TypeCommit, object.TypeTag:
+	default:
+		return nil, fmt.Errorf("invalid 'type' value %q", t.ObjectType)
+	}
+	if t.Name == "" {
+		return nil, fmt.Errorf("invalid 'tag' line - empty name")
+	}
+	return []Link{{From: hash, FromType: object.TypeTag, To: t.Object, ToType: t.ObjectType}}, nil
+}
+
+// checkTree: 항목의 이름, 모드, 정렬 순서를 검사한다. submodule(gitlink) 항목은 다른 저장소의 커밋이라 따라가지 않는다.
+// repoDir 은 Options.RepoDir 이다.
+func checkTree(a *object.Algorithm, hash string, payload []byte, repoDir string) ([]Link, error) {
+	var t object.Tree
+	if err := t.DecodeAlgorithm(payload, a); err != nil {
+		return nil, err
+	}
+	if repoDir == "" {
+		repoDir = ".gogit"
+	}
+	if err := t.ValidateRepoDir(repoDir); err != nil {
+		return nil, err
+	}
+	var links []Link
+	for i, e := range t.Entries {
+		switch e.Mode {
+		case object.ModeTree, object.ModeRegular, object.ModeExecutable, object.ModeSymlink, object.ModeGitlink:
+		default:
+			return nil, fmt.Errorf("entry %q has bad mode %s", e.Name, e.Mode)
+		}
+		if i > 0 && !sortedBefore(t.Entries[i-1], e) {
+			return nil, fmt.Errorf("not properly sorted")
+		}
+		if e.Mode != object.ModeGitlink {
+			links = append(links, Link{From: hash, FromType: object.TypeTree, To: e.Hash, ToType: e.Mode.ObjectType()})
+		}
+	}
+	return links, nil
+}
+
+// sortedBefore: git 의 tree 정렬 순서에서 a 가 b 보다 앞인지 (디렉토리는 이름 뒤에 '/' 가 있는 것처럼)
+func sortedBefore(a, b object.TreeEntry) bool {
+	key := func(e object.TreeEntry) string {
+		if e.Mode == object.ModeTree {
+			return e.Name + "/"
+		}
+		return e.Name
+	}
+	return key(a) < key(b)
+}
+
+// headerLines: 메시지 앞 헤더 줄들 (이어지는 줄은 뺀다)
+func headerLines(payload []byte) []string {
+	head, _, _ := bytes.Cut(payload, []byte("\n\n"))
+	var lines []string
+	for _, line := range strings.Split(string(head), "\n") {
+		if !strings.HasPrefix(line, " ") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,55 @@
+package fsck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// storeWithTree: name 이라는 디렉토리 하나를 가진 tree 를 Validate 없이 저장한다. (다른 저장소에서 받은 객체처럼)
+func storeWithTree(t *testing.T, name string) (object.Storer, string) {
+	t.Helper()
+	s := object.NewMemoryStore()
+	blob, err := s.Write(object.TypeBlob, []byte("x\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := &object.Tree{Entries: []object.TreeEntry{{Mode: object.ModeRegular, Name: "config", Hash: blob}}}
+	innerHash, err := object.WriteObject(s, inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := &object.Tree{Entries: []object.TreeEntry{{Mode: object.ModeTree, Name: name, Hash: innerHash}}}
+	hash, err := s.Write(object.TypeTree, root.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, hash
+}
+
+func TestCheckRepoDirEntries(t *testing.T) {
+	tests := []struct {
+		entry   string
+		repoDir string
+		ok      bool
+	}{
+		{".gogit", "", false},
+		{".gogit", ".gogit", false},
+		{".GoGit", ".gogit", false},
+		{".gogit", ".git", true},
+		{".git", ".git", false},
+		{".git", ".gogit", false},
+		{"gogit", ".gogit", true},
+	}
+	for _, tt := range tests {
+		s, hash := storeWithTree(t, tt.entry)
+		report, err := Check(context.Background(), s, Options{Refs: map[string]string{"refs/heads/main": hash}, RepoDir: tt.repoDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.OK() != tt.ok {
+			t.Errorf("entry %q with RepoDir %q: OK() = %v, want %v (problems %v)", tt.entry, tt.repoDir, report.OK(), tt.ok, report.Problems)
+		}
+	}
+}
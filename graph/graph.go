@@ -0,0 +1,265 @@
+// Package graph 은 커밋 DAG(원하면 tree 와 blob 까지)를 Graphviz DOT 나 Mermaid 로 내보낸다.
+//
+//	it := object.NewCommitIter(store, heads, object.OrderTopo)
+//	graph.Export(ctx, os.Stdout, store, it, graph.Options{Format: graph.DOT, Refs: refs})
+//
+// 화살표는 git 의 객체가 가리키는 방향(자식 → 부모, 커밋 → tree → 항목, ref → 커밋)이다.
+// 내보낸 커밋 밖의 부모(MaxCount 로 잘린 이력)로 가는 화살표는 그리지 않는다.
+package graph
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Format: 출력 형식
+type Format string
+
+const (
+	DOT     Format = "dot"
+	Mermaid Format = "mermaid"
+)
+
+// ParseFormat: --format 값
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case DOT, Mermaid:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown graph format '%s' (expected dot or mermaid)", s)
+}
+
+// Ref: 커밋에 붙일 이름표. Hash 는 커밋이어야 한다. (태그는 벗겨서 넘긴다)
+type Ref struct {
+	Name string
+	Hash string
+}
+
+// Options: 내보내기 옵션
+type Options struct {
+	Format Format
+	// Objects: 커밋의 tree 와 그 아래의 tree, blob 도 그린다. 객체가 많으면 읽기 어려우므로 작은 저장소용
+	Objects bool
+	// MaxCount: 그릴 커밋 수. 0 이하면 제한 없음
+	MaxCount int
+	Refs     []Ref
+}
+
+// subjectWidth: 커밋 상자에 넣을 제목의 최대 글자 수
+const subjectWidth = 40
+
+type node struct {
+	id    string
+	kind  string
+	label string
+}
+
+type edge struct {
+	from, to string
+	label    string
+	// weak: 이력이 아닌 연결 (ref, 커밋 → tree) 은 점선으로 그린다
+	weak bool
+}
+
+type builder struct {
+	s     object.Storer
+	nodes []node
+	edges []edge
+	seen  map[string]bool
+}
+
+// Export: it 가 돌려주는 커밋들을 그린다.
+func Export(ctx context.Context, w io.Writer, s object.Storer, it *object.CommitIter, opts Options) error {
+	b := &builder{s: s, seen: map[string]bool{}}
+	type parentEdge struct{ from, to string }
+	var parents []parentEdge
+	n := 0
+	err := it.ForEachContext(ctx, func(hash string, c *object.Commit) error {
+		if opts.MaxCount > 0 && n >= opts.MaxCount {
+			return io.EOF
+		}
+		n++
+		b.add(node{id: "c" + hash, kind: "commit", label: hash[:7] + "\n" + subject(c.Message)})
+		for _, p := range c.Parents {
+			parents = append(parents, parentEdge{"c" + hash, "c" + p})
+		}
+		if opts.Objects {
+			b.edges = append(b.edges, edge{from: "c" + hash, to: "t" + c.Tree, weak: true})
+			return b.addTree(c.Tree)
+		}
+		return nil
+	})
+	if err != nil && err != io.EOF {
+		return err
+	}
+	for _, p := range parents {
+		if b.seen[p.to] {
+			b.edges = append(b.edges, edge{from: p.from, to: p.to})
+		}
+	}
+	for i, ref := range opts.Refs {
+		if !b.seen["c"+ref.Hash] {
+			continue
+		}
+		id := fmt.Sprintf("r%d", i)
+		b.add(node{id: id, kind: "ref", label: shortRef(ref.Name)})
+		b.edges = append(b.edges, edge{from: id, to: "c" + ref.Hash, weak: true})
+	}
+
+	switch opts.Format {
+	case Mermaid:
+		return b.writeMermaid(w)
+	case DOT, "":
+		return b.writeDOT(w)
+	}
+	return fmt.Errorf("unknown graph format '%s'", opts.Format)
+}
+
+func (b *builder) add(n node) bool {
+	if b.seen[n.id] {
+		return false
+	}
+	b.seen[n.id] = true
+	b.nodes = append(b.nodes, n)
+	return true
+}
+
+// addTree: tree 와 그 아래 항목들. 이미 그린 tree 는 다시 내려가지 않는다.
+func (b *builder) addTree(hash string) error {
+	if !b.add(node{id: "t" + hash, kind: "tree", label: "tree " + hash[:7]}) {
+		return nil
+	}
+	tree, err := object.ReadTree(b.s, hash)
+	if err != nil {
+		return err
+	}
+	for _, e := range tree.Entries {
+		switch e.Mode {
+		case object.ModeTree:
+			if err := b.addTree(e.Hash); err != nil {
+				return err
+			}
+			b.edges = append(b.edges, edge{from: "t" + hash, to: "t" + e.Hash, label: e.Name + "/"})
+		case object.ModeGitlink:
+			// submodule 의 커밋은 이 저장소에 없다
+			b.add(node{id: "g" + e.Hash, kind: "gitlink", label: "submodule " + e.Hash[:7]})
+			b.edges = append(b.edges, edge{from: "t" + hash, to: "g" + e.Hash, label: e.Name})
+		default:
+			b.add(node{id: "b" + e.Hash, kind: "blob", label: "blob " + e.Hash[:7]})
+			b.edges = append(b.edges, edge{from: "t" + hash, to: "b" + e.Hash, label: e.Name})
+		}
+	}
+	return nil
+}
+
+// dotShapes: 종류별 Graphviz 모양
+var dotShapes = map[string]string{
+	"commit":  `shape=box, style="rounded,filled", fillcolor="#dbeafe"`,
+	"tree":    `shape=folder, style=filled, fillcolor="#dcfce7"`,
+	"blob":    `shape=note, style=filled, fillcolor="#fef9c3"`,
+	"gitlink": `shape=box3d`,
+	"ref":     `shape=cds, style=filled, fillcolor="#fde68a"`,
+}
+
+func (b *builder) writeDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("digraph gogit {\n")
+	sb.WriteString("\tnode [fontname=\"monospace\"];\n")
+	sb.WriteString("\tedge [fontname=\"monospace\", fontsize=10];\n")
+	for _, n := range b.nodes {
+		fmt.Fprintf(&sb, "\t%s [label=%s, %s];\n", n.id, dotQuote(n.label), dotShapes[n.kind])
+	}
+	for _, e := range b.edges {
+		var attrs []string
+		if e.label != "" {
+			attrs = append(attrs, "label="+dotQuote(e.label))
+		}
+		if e.weak {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&sb, "\t%s -> %s", e.from, e.to)
+		if len(attrs) > 0 {
+			fmt.Fprintf(&sb, " [%s]", strings.Join(attrs, ", "))
+		}
+		sb.WriteString(";\n")
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
+
+// mermaidShapes: 종류별 Mermaid 모양의 여는/닫는 괄호
+var mermaidShapes = map[string][2]string{
+	"commit":  {"(", ")"},
+	"tree":    {"[/", "\\]"},
+	"blob":    {"[", "]"},
+	"gitlink": {"[[", "]]"},
+	"ref":     {">", "]"},
+}
+
+// mermaidClasses: 종류별 색 (DOT 와 같은 색)
+var mermaidClasses = [][2]string{
+	{"commit", "fill:#dbeafe"},
+	{"tree", "fill:#dcfce7"},
+	{"blob", "fill:#fef9c3"},
+	{"gitlink", "fill:#e5e7eb"},
+	{"ref", "fill:#fde68a"},
+}
+
+func (b *builder) writeMermaid(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("flowchart TB\n")
+	for _, c := range mermaidClasses {
+		fmt.Fprintf(&sb, "    classDef %s %s\n", c[0], c[1])
+	}
+	for _, n := range b.nodes {
+		shape := mermaidShapes[n.kind]
+		fmt.Fprintf(&sb, "    %s%s%s%s:::%s\n", n.id, shape[0], mermaidQuote(n.label), shape[1], n.kind)
+	}
+	for _, e := range b.edges {
+		arrow := "-->"
+		if e.weak {
+			arrow = "-.->"
+		}
+		if e.label != "" {
+			arrow += "|" + mermaidQuote(e.label) + "|"
+		}
+		fmt.Fprintf(&sb, "    %s %s %s\n", e.from, arrow, e.to)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// mermaidQuote: 따옴표 안에서는 괄호 같은 문법 문자를 그대로 쓸 수 있다. 따옴표 자체는 엔티티로 바꾼다.
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return `"` + strings.ReplaceAll(s, "\n", "<br/>") + `"`
+}
+
+func subject(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	if utf8.RuneCountInString(line) > subjectWidth {
+		line = string([]rune(line)[:subjectWidth-1]) + "…"
+	}
+	return line
+}
+
+func shortRef(name string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "refs/remotes/"} {
+		if short, ok := strings.CutPrefix(name, prefix); ok {
+			return short
+		}
+	}
+	return name
+}
@@ -0,0 +1,136 @@
+// Package grep 은 tree 의 blob 들에서 정규식과 맞는 줄을 찾는다. (git grep)
+//
+//	re := regexp.MustCompile(`TODO`)
+//	grep.Tree(ctx, store, tree, re, grep.Options{}, func(m grep.Match) error { ... })
+//
+// 파일들은 여러 goroutine 이 나누어 읽고 찾지만, 결과는 tree 의 경로 순서대로 fn 에 넘긴다.
+package grep
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"runtime"
+
+	"github.com/tmdgusya/gogit/diff"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Options: 찾을 범위
+type Options struct {
+	// Match: 찾을 경로. nil 이면 모든 파일
+	Match func(p string) bool
+	// Workers: 동시에 찾는 파일 수. 0 이하면 CPU 수
+	Workers int
+}
+
+// Match: 맞은 줄 하나. 바이너리 파일은 줄 대신 파일마다 Binary 인 Match 하나를 돌려준다
+type Match struct {
+	Path string
+	// Line: 1부터 센 줄 번호
+	Line   int
+	Text   string
+	Binary bool
+}
+
+type file struct {
+	path    string
+	hash    string
+	matches []Match
+	err     error
+	done    chan struct{}
+}
+
+// Tree: tree 아래 파일(일반 파일, 실행 파일, 심볼릭 링크)에서 re 와 맞는 줄을 찾는다. submodule 은 건너뛴다.
+// fn 이 에러를 돌려주면 멈추고 그 에러를 돌려준다.
+func Tree(ctx context.Context, s object.Storer, tree string, re *regexp.Regexp, opts Options, fn func(Match) error) error {
+	var files []*file
+	walker, err := object.NewTreeWalker(s, tree)
+	if err != nil {
+		return err
+	}
+	err = walker.ForEachContext(ctx, func(e object.WalkEntry) error {
+		if e.Mode == object.ModeTree || e.Mode == object.ModeGitlink {
+			return nil
+		}
+		if opts.Match == nil || opts.Match(e.Path) {
+			files = append(files, &file{path: e.Path, hash: e.Hash, done: make(chan struct{})})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	next := make(chan *file)
+	go func() {
+		defer close(next)
+		for _, f := range files {
+			select {
+			case next <- f:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for range workers {
+		go func() {
+			for f := range next {
+				f.matches, f.err = search(ctx, s, f, re)
+				close(f.done)
+			}
+		}()
+	}
+
+	for _, f := range files {
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if f.err != nil {
+			return f.err
+		}
+		for _, m := range f.matches {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func search(ctx context.Context, s object.Storer, f *file, re *regexp.Regexp) ([]Match, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	_, content, err := s.Read(f.hash)
+	if err != nil {
+		return nil, err
+	}
+	if diff.IsBinary(content) {
+		if re.Match(content) {
+			return []Match{{Path: f.path, Binary: true}}, nil
+		}
+		return nil, nil
+	}
+	var matches []Match
+	for n := 1; len(content) > 0; n++ {
+		line := content
+		if i := bytes.IndexByte(content, '\n'); i >= 0 {
+			line, content = content[:i], content[i+1:]
+		} else {
+			content = nil
+		}
+		if re.Match(line) {
+			matches = append(matches, Match{Path: f.path, Line: n, Text: string(line)})
+		}
+	}
+	return matches, nil
+}
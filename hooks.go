@@ -0,0 +1,162 @@
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// ErrHookFailed: hook 이 0 이 아닌 상태로 끝나 작업을 멈춤
+var ErrHookFailed = errors.New("hook failed")
+
+// hooksDir: 저장소 안의 hook 디렉토리
+const hooksDir = "hooks"
+
+// HookPath: name hook 의 실행 파일 경로. 없거나 실행 권한이 없으면 빈 문자열
+// core.hooksPath 가 있으면 git 과 같이 그 디렉토리를 쓴다. (상대 경로는 작업 트리 기준)
+// 디스크에 있지 않은 저장소에는 hook 이 없다.
+func (r *Repository) HookPath(name string) (string, error) {
+	if r.CommonDir == "" {
+		return "", nil
+	}
+	dir := filepath.Join(r.CommonDir, hooksDir)
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	if p, ok := cfg.Get("core.hooksPath"); ok && p != "" {
+		dir = p
+		if !filepath.IsAbs(dir) && r.WorkTree != "" {
+			dir = filepath.Join(r.WorkTree, dir)
+		}
+	}
+	p := filepath.Join(dir, name)
+	info, err := os.Stat(p)
+	if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+		return "", nil
+	}
+	return p, nil
+}
+
+// RunHook: name hook 이 있으면 args 와 stdin 을 넘겨 실행한다. hook 이 없으면 아무것도 하지 않는다.
+// git 과 같이 작업 트리(bare 면 저장소 디렉토리)에서 실행하고, GOGIT_DIR 로 저장소를 알려 준다.
+// 0 이 아닌 상태로 끝나면 ErrHookFailed 를 감싼 에러를 돌려준다.
+func (r *Repository) RunHook(name string, stdin io.Reader, stdout, stderr io.Writer, args ...string) error {
+	p, err := r.HookPath(name)
+	if err != nil || p == "" {
+		return err
+	}
+	gogitDir, err := filepath.Abs(r.GogitDir)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(p, args...)
+	cmd.Dir = r.WorkTree
+	if r.IsBare() {
+		cmd.Dir = gogitDir
+	}
+	cmd.Env = append(os.Environ(), "GOGIT_DIR="+gogitDir)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return fmt.Errorf("%w: %s (%v)", ErrHookFailed, name, err)
+		}
+		return fmt.Errorf("running %s hook: %w", name, err)
+	}
+	return nil
+}
+
+// sampleHooks: init 이 hooks 디렉토리에 만들어 두는 예제. ".sample" 을 떼고 실행 권한을 주면 동작한다.
+var sampleHooks = map[string]string{
+	"pre-commit": `#!/bin/sh
+#
+# 커밋을 만들기 전에 인자 없이 실행된다. 0 이 아닌 상태로 끝나면 커밋하지 않는다.
+# 이 예제는 공백 문자로 끝나는 줄이 있는 파일을 찾는다.
+
+if grep -rnI --exclude-dir=.gogit --exclude-dir=.git '[[:space:]]$' . >&2; then
+	echo "error: trailing whitespace" >&2
+	exit 1
+fi
+`,
+	"prepare-commit-msg": `#!/bin/sh
+#
+# 편집기를 열기 전에 실행된다.
+# $1: 메시지 파일, $2: 메시지 출처 (message, template, commit), $3: 커밋 (--amend)
+# 이 예제는 메시지 맨 위에 브랜치 이름을 적는다.
+
+COMMIT_MSG_FILE=$1
+COMMIT_SOURCE=$2
+
+if [ -z "$COMMIT_SOURCE" ]; then
+	branch=$(sed -n 's|^ref: refs/heads/||p' "$GOGIT_DIR/HEAD")
+	printf '%s: \n%s' "$branch" "$(cat "$COMMIT_MSG_FILE")" > "$COMMIT_MSG_FILE"
+fi
+`,
+	"commit-msg": `#!/bin/sh
+#
+# 메시지를 정한 뒤 실행된다. $1: 메시지 파일 (고쳐 써도 된다)
+# 0 이 아닌 상태로 끝나면 커밋하지 않는다. 이 예제는 같은 Signed-off-by 줄이 두 번 있으면 거부한다.
+
+test "" = "$(grep '^Signed-off-by: ' "$1" | sort | uniq -c | sed -e '/^[ 	]*1[ 	]/d')" || {
+	echo >&2 Duplicate Signed-off-by lines.
+	exit 1
+}
+`,
+	"post-commit": `#!/bin/sh
+#
+# 커밋을 만든 뒤 인자 없이 실행된다. 결과는 커밋에 영향을 주지 않는다.
+
+echo "committed $(sed -n 's|^ref: ||p' "$GOGIT_DIR/HEAD")"
+`,
+	"pre-push": `#!/bin/sh
+#
+# push 하기 전에 실행된다. $1: remote 이름, $2: remote URL
+# stdin 으로 "<local ref> <local sha1> <remote ref> <remote sha1>" 줄을 받는다.
+# 0 이 아닌 상태로 끝나면 push 하지 않는다. 이 예제는 "WIP" 로 시작하는 커밋이 있으면 거부한다.
+
+zero=0000000000000000000000000000000000000000
+while read local_ref local_sha remote_ref remote_sha; do
+	if [ "$local_sha" = "$zero" ]; then
+		continue
+	fi
+	if git log --format=%s "$remote_sha..$local_sha" 2>/dev/null | grep -q '^WIP'; then
+		echo >&2 "Found WIP commit in $local_ref, not pushing"
+		exit 1
+	fi
+done
+exit 0
+`,
+	"post-checkout": `#!/bin/sh
+#
+# 작업 트리를 바꾼 뒤 실행된다. 결과는 작업에 영향을 주지 않는다.
+# $1: 이전 HEAD, $2: 새 HEAD, $3: 브랜치를 옮겼으면 1, 파일만 바꿨으면 0
+
+echo "HEAD is now at $2"
+`,
+}
+
+// installSampleHooks: hooks 디렉토리가 없을 때만 예제 hook 들을 만든다. (이미 있는 저장소는 그대로 둔다)
+func installSampleHooks(fsys vfs.Filesystem) error {
+	if vfs.Exists(fsys, hooksDir) {
+		return nil
+	}
+	if err := fsys.MkdirAll(hooksDir, 0755); err != nil {
+		return fmt.Errorf("creating directory %s: %w", hooksDir, err)
+	}
+	for name, script := range sampleHooks {
+		p := hooksDir + "/" + name + ".sample"
+		if err := vfs.WriteFile(fsys, p, []byte(script)); err != nil {
+			return err
+		}
+		if err := vfs.Chmod(fsys, p, 0755); err != nil && !errors.Is(err, errors.ErrUnsupported) {
+			return err
+		}
+	}
+	return nil
+}
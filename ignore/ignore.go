@@ -0,0 +1,113 @@
+// Package ignore 는 .gitignore 형식의 규칙으로 작업 트리의 경로가 무시되는지 정한다.
+//
+//	m := ignore.New()
+//	m.Add("", rootGitignore)
+//	m.Add("sub", subGitignore)
+//	m.Ignored("sub/build/out.o", false)
+//
+// 한 줄이 패턴 하나다. 빈 줄과 '#' 으로 시작하는 줄은 건너뛴다.
+//
+//	!pattern   앞의 규칙이 무시한 경로를 다시 포함한다
+//	pattern/   디렉토리와만 맞는다
+//	/pattern   .gitignore 가 있는 디렉토리 기준의 경로와 맞는다 (가운데에 "/" 가 있어도 같다)
+//	pattern    "/" 가 없으면 어느 깊이의 이름과도 맞는다
+//
+// glob 은 attr 패키지와 같다. 여러 규칙이 맞으면 나중에 Add 한 규칙이 이긴다.
+// git 과 같이 무시된 디렉토리 아래의 경로는 ! 로 다시 포함할 수 없다.
+package ignore
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/tmdgusya/gogit/attr"
+)
+
+// Matcher: 여러 .gitignore 의 규칙
+type Matcher struct {
+	rules []rule
+}
+
+type rule struct {
+	// dir: 규칙을 읽은 .gitignore 가 있는 디렉토리 (루트는 빈 문자열)
+	dir     string
+	pattern *regexp.Regexp
+	// basename: "/" 가 없는 패턴이라 이름과만 맞춰 본다
+	basename bool
+	negate   bool
+	dirOnly  bool
+}
+
+func New() *Matcher {
+	return &Matcher{}
+}
+
+// Add: dir 디렉토리(루트 기준, "/" 로 구분)에 있는 .gitignore 의 내용을 더한다.
+// info/exclude 처럼 루트 기준인 파일은 dir 을 빈 문자열로 준다. 해석할 수 없는 패턴은 무시한다.
+func (m *Matcher) Add(dir string, data []byte) {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		// 끝의 공백은 "\ " 로 적었을 때만 남긴다
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+			line = line[:len(line)-1]
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r := rule{dir: dir}
+		if rest, ok := strings.CutPrefix(line, "!"); ok {
+			r.negate, line = true, rest
+		}
+		if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if rest, ok := strings.CutSuffix(line, "/"); ok {
+			r.dirOnly, line = true, rest
+		}
+		if line == "" {
+			continue
+		}
+		r.basename = !strings.Contains(line, "/")
+		re, err := regexp.Compile(attr.GlobRegexp(strings.TrimPrefix(line, "/")))
+		if err != nil {
+			continue
+		}
+		r.pattern = re
+		m.rules = append(m.rules, r)
+	}
+}
+
+// Ignored: p(루트 기준 경로)가 무시되는지. isDir 은 p 가 디렉토리인지다.
+// p 의 상위 디렉토리가 무시되면 p 도 무시된다.
+func (m *Matcher) Ignored(p string, isDir bool) bool {
+	for i := 0; i < len(p); i++ {
+		if p[i] == '/' && m.match(p[:i], true) {
+			return true
+		}
+	}
+	return m.match(p, isDir)
+}
+
+// match: p 자신과 맞는 마지막 규칙이 무시하는 규칙인지
+func (m *Matcher) match(p string, isDir bool) bool {
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		r := m.rules[i]
+		if r.dirOnly && !isDir {
+			continue
+		}
+		rel := p
+		if r.dir != "" {
+			var ok bool
+			if rel, ok = strings.CutPrefix(p, r.dir+"/"); !ok {
+				continue
+			}
+		}
+		if r.basename {
+			rel = rel[strings.LastIndexByte(rel, '/')+1:]
+		}
+		if r.pattern.MatchString(rel) {
+			return !r.negate
+		}
+	}
+	return false
+}
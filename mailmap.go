@@ -0,0 +1,83 @@
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tmdgusya/gogit/mailmap"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Mailmap: 작업 트리 루트의 .mailmap, config 의 mailmap.blob 과 mailmap.file 을 차례로 읽은 규칙
+// 뒤에 읽은 것이 우선한다. bare 저장소는 git 과 같이 mailmap.blob 이 없으면 HEAD:.mailmap 을 읽는다.
+// 파일이나 blob 이 없으면 규칙이 없는 것으로 본다.
+func (r *Repository) Mailmap() (*mailmap.Map, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+	m := mailmap.New()
+	if !r.IsBare() {
+		data, err := os.ReadFile(filepath.Join(r.WorkTree, ".mailmap"))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		m.Add(data)
+	}
+	blob, ok := cfg.Get("mailmap.blob")
+	if !ok && r.IsBare() {
+		blob = "HEAD:.mailmap"
+	}
+	if blob != "" {
+		data, err := r.mailmapBlob(blob)
+		if err != nil {
+			return nil, err
+		}
+		m.Add(data)
+	}
+	if file, ok := cfg.Get("mailmap.file"); ok && file != "" {
+		if rest, ok := strings.CutPrefix(file, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				file = filepath.Join(home, rest)
+			}
+		}
+		data, err := os.ReadFile(file)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		m.Add(data)
+	}
+	return m, nil
+}
+
+// mailmapBlob: "<rev>:<path>" 가 가리키는 blob 의 내용. 리비전이나 경로가 없으면 빈 내용이다
+func (r *Repository) mailmapBlob(spec string) ([]byte, error) {
+	rev, p, ok := strings.Cut(spec, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid mailmap.blob '%s': expected <rev>:<path>", spec)
+	}
+	tree, err := r.ResolveTree(rev)
+	if err != nil {
+		if errors.Is(err, ErrUnknownRevision) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	entry, found, err := object.LookupPath(r.Objects, tree, p)
+	if err != nil || !found {
+		return nil, err
+	}
+	obj, err := object.ReadObject(r.Objects, entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := obj.(*object.Blob)
+	if !ok {
+		return nil, fmt.Errorf("%w: mailmap.blob %s is a %s, not a blob", object.ErrWrongType, spec, obj.Type())
+	}
+	return b.Data, nil
+}
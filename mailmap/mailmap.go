@@ -0,0 +1,127 @@
+// Package mailmap 은 .mailmap 파일을 읽고 커밋의 이름과 이메일을 정식 이름과 이메일로 바꾼다.
+//
+//	Proper Name <commit@email>                              이메일로 찾아 이름만 바꾼다
+//	<proper@email> <commit@email>                           이메일로 찾아 이메일만 바꾼다
+//	Proper Name <proper@email> <commit@email>               이메일로 찾아 둘 다 바꾼다
+//	Proper Name <proper@email> Commit Name <commit@email>   이름과 이메일로 찾아 둘 다 바꾼다
+//
+// 찾을 때 이름과 이메일의 대소문자는 가리지 않는다. '#' 으로 시작하는 줄은 주석이다.
+package mailmap
+
+import (
+	"strings"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// replacement: 바꿀 이름과 이메일. 빈 문자열이면 바꾸지 않는다
+type replacement struct {
+	name  string
+	email string
+}
+
+// entry: 이메일 하나에 대한 규칙들
+type entry struct {
+	// def: 이름과 상관없이 쓰는 규칙
+	def replacement
+	// names: 커밋의 이름(소문자)까지 맞아야 쓰는 규칙
+	names map[string]replacement
+}
+
+// Map: 이메일(소문자)별 규칙. nil Map 은 아무것도 바꾸지 않는다
+type Map struct {
+	entries map[string]*entry
+}
+
+func New() *Map {
+	return &Map{entries: map[string]*entry{}}
+}
+
+// Parse: .mailmap 파일 내용을 읽는다. 형식에 맞지 않는 줄은 git 과 같이 무시한다.
+func Parse(data []byte) *Map {
+	m := New()
+	m.Add(data)
+	return m
+}
+
+// Add: 다른 mailmap 파일의 규칙을 더한다. 같은 것을 찾는 규칙은 나중 것이 이긴다
+func (m *Map) Add(data []byte) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		name1, email1, rest, ok := parseNameEmail(line)
+		if !ok {
+			continue
+		}
+		name2, email2, _, ok := parseNameEmail(rest)
+		if !ok {
+			// "Proper Name <commit@email>"
+			m.add("", email1, name1, "")
+			continue
+		}
+		m.add(name2, email2, name1, email1)
+	}
+}
+
+// add: oldName(빈 문자열이면 아무 이름) 과 oldEmail 인 사람을 newName, newEmail 로 바꾸는 규칙
+func (m *Map) add(oldName, oldEmail, newName, newEmail string) {
+	key := strings.ToLower(oldEmail)
+	e, ok := m.entries[key]
+	if !ok {
+		e = &entry{names: map[string]replacement{}}
+		m.entries[key] = e
+	}
+	if oldName == "" {
+		if newName != "" {
+			e.def.name = newName
+		}
+		if newEmail != "" {
+			e.def.email = newEmail
+		}
+		return
+	}
+	e.names[strings.ToLower(oldName)] = replacement{name: newName, email: newEmail}
+}
+
+// parseNameEmail: "이름 <이메일>" 하나를 읽고 '>' 뒤의 나머지를 돌려준다. 이름은 없을 수 있다
+func parseNameEmail(s string) (name, email, rest string, ok bool) {
+	left := strings.IndexByte(s, '<')
+	if left < 0 {
+		return "", "", "", false
+	}
+	right := strings.IndexByte(s[left+1:], '>')
+	if right < 0 {
+		return "", "", "", false
+	}
+	name = strings.TrimSpace(s[:left])
+	email = s[left+1 : left+1+right]
+	return name, email, s[left+1+right+1:], true
+}
+
+// Lookup: sig 의 정식 이름과 이메일. 맞는 규칙이 없으면 sig 를 그대로 돌려준다
+func (m *Map) Lookup(sig object.Signature) object.Signature {
+	if m == nil {
+		return sig
+	}
+	e, ok := m.entries[strings.ToLower(sig.Email)]
+	if !ok {
+		return sig
+	}
+	r, ok := e.names[strings.ToLower(sig.Name)]
+	if !ok {
+		r = e.def
+	}
+	if r.name != "" {
+		sig.Name = r.name
+	}
+	if r.email != "" {
+		sig.Email = r.email
+	}
+	return sig
+}
+
+// Empty: 규칙이 하나도 없는지
+func (m *Map) Empty() bool {
+	return m == nil || len(m.entries) == 0
+}
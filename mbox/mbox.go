@@ -0,0 +1,386 @@
+// Package mbox 는 커밋을 메일(format-patch) 로 쓰고, 받은 메일을 다시 커밋 정보와 patch 로 나눈다(am).
+//
+//	From <커밋 해시> Mon Sep 17 00:00:00 2001
+//	From: 작성자 <이메일>
+//	Date: Thu, 7 Apr 2005 15:13:13 -0700
+//	Subject: [PATCH 1/2] 제목
+//
+//	본문
+//	---
+//	 diffstat
+//
+//	diff --git ...
+//	--
+//	gogit
+//
+// 첫 줄의 날짜는 git 과 같이 메일 파일임을 알리는 고정값이다. 여러 메일을 이어 붙인 것이 mbox 다.
+package mbox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// magicDate: "From " 줄의 고정된 날짜 (git 과 동일)
+const magicDate = "Mon Sep 17 00:00:00 2001"
+
+// dateLayout: RFC 2822 의 Date 헤더
+const dateLayout = "Mon, 2 Jan 2006 15:04:05 -0700"
+
+// Patch: format-patch 로 쓰는 메일 하나
+type Patch struct {
+	Hash   string
+	Commit *object.Commit
+	// Numbered: 제목 앞에 "[PATCH <Number>/<Total>]" 을 붙인다. false 면 "[PATCH]" 만 붙인다
+	Numbered bool
+	Number   int
+	Total    int
+	// Stat: "---" 아래에 쓰는 diffstat. 비어 있으면 git 의 --no-stat 과 같이 구분 줄도 쓰지 않는다
+	Stat string
+	Diff string
+	// Signature: 메일 끝의 "-- " 아래에 쓰는 줄
+	Signature string
+}
+
+// Write: p 를 메일로 쓴다. 제목이나 작성자 이름에 ASCII 밖의 글자가 있으면 RFC 2047 로 인코딩하고,
+// 본문이 ASCII 가 아니면 UTF-8 임을 알리는 MIME 헤더를 붙인다.
+func Write(w io.Writer, p Patch) error {
+	subject, body := SplitMessage(p.Commit.Message)
+	prefix := "[PATCH]"
+	if p.Numbered {
+		prefix = fmt.Sprintf("[PATCH %d/%d]", p.Number, p.Total)
+	}
+	author := p.Commit.Author
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From %s %s\n", p.Hash, magicDate)
+	fmt.Fprintf(&b, "From: %s <%s>\n", encodeName(author.Name), author.Email)
+	fmt.Fprintf(&b, "Date: %s\n", author.When.Format(dateLayout))
+	head := "Subject: " + prefix + " "
+	fmt.Fprintf(&b, "%s%s\n", head, encodeSubject(len(head), subject))
+	if !isASCII(p.Commit.Message) || !isASCII(author.Name) {
+		b.WriteString("MIME-Version: 1.0\nContent-Type: text/plain; charset=UTF-8\nContent-Transfer-Encoding: 8bit\n")
+	}
+	b.WriteString("\n")
+	if body != "" {
+		b.WriteString(body)
+	}
+	if p.Stat != "" {
+		b.WriteString("---\n")
+		b.WriteString(p.Stat)
+	}
+	b.WriteString("\n")
+	b.WriteString(p.Diff)
+	fmt.Fprintf(&b, "-- \n%s\n\n", p.Signature)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// SplitMessage: 커밋 메시지를 제목과 본문으로 나눈다. 첫 문단의 줄들은 공백으로 이어 제목이 된다. (git 과 동일)
+// 본문은 줄바꿈으로 끝나고 없으면 빈 문자열이다.
+func SplitMessage(message string) (subject, body string) {
+	lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	var parts []string
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+		parts = append(parts, strings.TrimSpace(lines[i]))
+	}
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	subject = strings.Join(parts, " ")
+	if i < len(lines) {
+		body = strings.Join(lines[i:], "\n") + "\n"
+	}
+	return subject, body
+}
+
+// FileName: n 번째 patch 의 파일 이름. 제목 첫 줄에서 글자, 숫자, '.', '_' 만 남기고 그 사이는 '-' 하나로 잇는다.
+// git 과 같이 ".patch" 를 뺀 이름이 64-7 자를 넘으면 자른다.
+func FileName(n int, message string) string {
+	first, _, _ := strings.Cut(strings.TrimLeft(message, "\n"), "\n")
+	var b strings.Builder
+	sep := false
+	for i := 0; i < len(first); i++ {
+		c := first[i]
+		if !isAlnum(c) && c != '.' && c != '_' {
+			sep = b.Len() > 0
+			continue
+		}
+		if sep {
+			b.WriteByte('-')
+			sep = false
+		}
+		b.WriteByte(c)
+		for c == '.' && i+1 < len(first) && first[i+1] == '.' {
+			i++
+		}
+	}
+	const suffix = ".patch"
+	name := fmt.Sprintf("%04d-%s", n, strings.TrimRight(b.String(), ".-"))
+	if maxLen := 64 - len(suffix) - 1; len(name) > maxLen {
+		name = name[:maxLen]
+	}
+	return name + suffix
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeName: 주소의 이름 부분. ASCII 가 아니면 RFC 2047 로 인코딩하고,
+// '.' 같은 특수 문자가 있으면 git 과 같이 따옴표로 감싼다
+func encodeName(s string) string {
+	if !isASCII(s) {
+		return encodeWord(len("From: "), s, true)
+	}
+	if !strings.ContainsAny(s, "()<>@,;:\\\".[]") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// encodeSubject: 제목. 인코딩할 필요가 없으면 git 과 같이 단어 사이에서 78 자로 접는다.
+// used 는 같은 줄에 이미 쓴 "Subject: [PATCH] " 의 길이다
+func encodeSubject(used int, s string) string {
+	if !isASCII(s) || strings.Contains(s, "=?") {
+		return encodeWord(used, s, false)
+	}
+	const width = 78
+	var b strings.Builder
+	w := used
+	for i, word := range strings.Split(s, " ") {
+		if i > 0 {
+			if w+1+len(word) <= width {
+				b.WriteString(" ")
+				w++
+			} else {
+				b.WriteString("\n ")
+				w = 1
+			}
+		}
+		b.WriteString(word)
+		w += len(word)
+	}
+	return b.String()
+}
+
+// encodeWord: RFC 2047 의 Q 인코딩. git 과 같이 공백도 "=20" 으로 쓰고, 줄이 76 자를 넘으면
+// 글자 경계에서 encoded-word 를 나눠 다음 줄로 접는다. address 면 이름에 쓸 수 없는 글자도 인코딩한다
+func encodeWord(used int, s string, address bool) string {
+	const width = 76
+	const open = "=?UTF-8?q?"
+	var b strings.Builder
+	b.WriteString(open)
+	w := used + len(open)
+	for len(s) > 0 {
+		_, size := utf8.DecodeRuneInString(s)
+		c := s[0]
+		special := size > 1 || c >= 0x80 || c == ' ' || c == '=' || c == '?' || c == '_' ||
+			address && !(isAlnum(c) || strings.IndexByte("!*+-/", c) >= 0)
+		enc := string(c)
+		if special {
+			enc = ""
+			for i := 0; i < size; i++ {
+				enc += fmt.Sprintf("=%02X", s[i])
+			}
+		}
+		if w+len(enc)+2 > width {
+			b.WriteString("?=\n " + open)
+			w = len(open) + 1
+		}
+		b.WriteString(enc)
+		w += len(enc)
+		s = s[size:]
+	}
+	b.WriteString("?=")
+	return b.String()
+}
+
+// fromLine: mbox 에서 메일이 시작하는 줄. "From <무엇이든> <요일> <월> <일> <시각> <연도>"
+var fromLine = regexp.MustCompile(`^From \S+ +\w{3} \w{3} [ \d]\d \d\d:\d\d:\d\d \d{4}$`)
+
+// Split: mbox 를 메일 하나씩으로 나눈다. "From " 줄로 시작하지 않으면 전체를 메일 하나로 본다.
+func Split(data []byte) [][]byte {
+	var mails [][]byte
+	start := -1
+	for pos := 0; pos < len(data); {
+		end := bytes.IndexByte(data[pos:], '\n')
+		next := len(data)
+		if end >= 0 {
+			next = pos + end + 1
+		}
+		line := strings.TrimRight(string(data[pos:next]), "\r\n")
+		if fromLine.MatchString(line) {
+			if start >= 0 {
+				mails = append(mails, data[start:pos])
+			}
+			start = next
+		} else if start < 0 {
+			// 첫 메일에 "From " 줄이 없다
+			return [][]byte{data}
+		}
+		pos = next
+	}
+	if start >= 0 {
+		mails = append(mails, data[start:])
+	}
+	return mails
+}
+
+// Message: 받은 메일 하나
+type Message struct {
+	Author  object.Signature
+	Subject string
+	// Body: 제목 아래의 설명. 줄바꿈으로 끝나고 없으면 빈 문자열
+	Body string
+	// Patch: 본문의 "---" 또는 "diff -" 부터의 내용
+	Patch []byte
+}
+
+// CommitMessage: 커밋 메시지. 제목과 본문 사이에 빈 줄을 둔다
+func (m *Message) CommitMessage() string {
+	if m.Body == "" {
+		return m.Subject + "\n"
+	}
+	return m.Subject + "\n\n" + m.Body
+}
+
+// ErrNoAuthor: From 헤더가 없거나 읽을 수 없는 메일
+var ErrNoAuthor = errors.New("patch does not have a valid e-mail address")
+
+// subjectPrefix: 제목 앞의 "Re:", "[PATCH 1/2]" 같은 것 (git mailinfo 가 지우는 것)
+var subjectPrefix = regexp.MustCompile(`^\s*(?:(?i:re|aw|fwd?):\s*|\[[^\]]*\]\s*)`)
+
+// Parse: 메일 하나를 읽는다. 본문 맨 위의 "From:", "Date:", "Subject:" 줄은 헤더보다 우선한다.
+// (다른 사람이 쓴 patch 를 보낼 때 git 이 쓰는 방식)
+func Parse(data []byte) (*Message, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid e-mail: %w", err)
+	}
+	content, err := io.ReadAll(msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(msg.Header.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		if content, err = io.ReadAll(quotedprintable.NewReader(bytes.NewReader(content))); err != nil {
+			return nil, err
+		}
+	case "base64":
+		if content, err = io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(content))); err != nil {
+			return nil, err
+		}
+	}
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+
+	header := map[string]string{
+		"from":    msg.Header.Get("From"),
+		"date":    msg.Header.Get("Date"),
+		"subject": msg.Header.Get("Subject"),
+	}
+	// 본문 맨 위의 헤더 줄들
+	text = strings.TrimLeft(text, "\n")
+	for {
+		line, rest, _ := strings.Cut(text, "\n")
+		key, value, ok := strings.Cut(line, ":")
+		key = strings.ToLower(key)
+		if _, known := header[key]; !ok || !known {
+			break
+		}
+		header[key] = strings.TrimSpace(value)
+		text = rest
+	}
+
+	m := &Message{}
+	dec := new(mime.WordDecoder)
+	if m.Author.Name, m.Author.Email, err = parseAddress(dec, header["from"]); err != nil {
+		return nil, err
+	}
+	if m.Author.Name == "" {
+		m.Author.Name, _, _ = strings.Cut(m.Author.Email, "@")
+	}
+	if header["date"] != "" {
+		if m.Author.When, err = mail.ParseDate(header["date"]); err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", header["date"], err)
+		}
+	} else {
+		m.Author.When = time.Now()
+	}
+	subject, err := dec.DecodeHeader(header["subject"])
+	if err != nil {
+		subject = header["subject"]
+	}
+	for {
+		loc := subjectPrefix.FindStringIndex(subject)
+		if loc == nil {
+			break
+		}
+		subject = subject[loc[1]:]
+	}
+	m.Subject = strings.TrimSpace(subject)
+
+	// 설명과 patch 를 나눈다
+	text = strings.TrimLeft(text, "\n")
+	body, patch := text, ""
+	for pos := 0; pos < len(text); {
+		end := strings.IndexByte(text[pos:], '\n')
+		next := len(text)
+		if end >= 0 {
+			next = pos + end + 1
+		}
+		line := strings.TrimRight(text[pos:next], "\n")
+		if line == "---" || strings.HasPrefix(line, "diff -") || strings.HasPrefix(line, "Index: ") {
+			body, patch = text[:pos], text[pos:]
+			break
+		}
+		pos = next
+	}
+	body = strings.TrimRight(body, "\n")
+	if body != "" {
+		body += "\n"
+	}
+	m.Body, m.Patch = body, []byte(patch)
+	return m, nil
+}
+
+// parseAddress: "이름 <이메일>". RFC 5322 에 맞지 않는 주소(따옴표 없는 '.' 등)도 받아 준다
+func parseAddress(dec *mime.WordDecoder, s string) (name, email string, err error) {
+	if addr, err := mail.ParseAddress(s); err == nil {
+		return addr.Name, addr.Address, nil
+	}
+	left, right := strings.LastIndexByte(s, '<'), strings.LastIndexByte(s, '>')
+	if left < 0 || right < left {
+		return "", "", ErrNoAuthor
+	}
+	name = strings.Trim(strings.TrimSpace(s[:left]), `"`)
+	if decoded, err := dec.DecodeHeader(name); err == nil {
+		name = decoded
+	}
+	return name, s[left+1 : right], nil
+}
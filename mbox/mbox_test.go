@@ -0,0 +1,108 @@
+package mbox
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+func TestWriteParseRoundTrip(t *testing.T) {
+	when := time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("", 9*3600))
+	commits := []*object.Commit{
+		{Author: object.Signature{Name: "홍길동", Email: "hong@example.com", When: when}, Message: "한글 제목\n\n본문 첫 줄\n"},
+		{Author: object.Signature{Name: "J. Doe", Email: "jdoe@example.com", When: when},
+			Message: "a fairly long subject line that has to be folded because it does not fit in one header line\n"},
+	}
+	var mbox bytes.Buffer
+	for i, c := range commits {
+		p := Patch{Hash: strings.Repeat("a", 40), Commit: c, Numbered: true, Number: i + 1, Total: len(commits),
+			Stat: " f | 1 +\n", Diff: "diff --git a/f b/f\n--- a/f\n+++ b/f\n@@ -0,0 +1 @@\n+x\n", Signature: "gogit"}
+		if err := Write(&mbox, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mails := Split(mbox.Bytes())
+	if len(mails) != len(commits) {
+		t.Fatalf("Split = %d mails, want %d", len(mails), len(commits))
+	}
+	for i, mail := range mails {
+		m, err := Parse(mail)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := commits[i]
+		if m.Author.Name != c.Author.Name || m.Author.Email != c.Author.Email || !m.Author.When.Equal(when) {
+			t.Errorf("mail %d author = %+v, want %+v", i, m.Author, c.Author)
+		}
+		if got := m.CommitMessage(); got != c.Message {
+			t.Errorf("mail %d message = %q, want %q", i, got, c.Message)
+		}
+		if !bytes.HasPrefix(m.Patch, []byte("---\n f | 1 +\n")) || !bytes.Contains(m.Patch, []byte("+++ b/f\n")) {
+			t.Errorf("mail %d patch = %q", i, m.Patch)
+		}
+	}
+}
+
+func TestSplitWithoutFromLine(t *testing.T) {
+	data := []byte("From: a <a@example.com>\nSubject: x\n\nbody\n")
+	if mails := Split(data); len(mails) != 1 || !bytes.Equal(mails[0], data) {
+		t.Errorf("Split = %q", mails)
+	}
+}
+
+func TestParseInBodyHeaders(t *testing.T) {
+	mail := "From: Sender <sender@example.com>\nSubject: Re: [PATCH v2 3/7] wrong\n\n" +
+		"From: Real Author <real@example.com>\nSubject: real subject\n\nwhy\n---\ndiff --git a/f b/f\n"
+	m, err := Parse([]byte(mail))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Author.Email != "real@example.com" || m.Subject != "real subject" || m.Body != "why\n" {
+		t.Errorf("message = %+v", m)
+	}
+}
+
+func TestParseNoAuthor(t *testing.T) {
+	if _, err := Parse([]byte("Subject: x\n\nbody\n")); !errors.Is(err, ErrNoAuthor) {
+		t.Errorf("err = %v, want ErrNoAuthor", err)
+	}
+}
+
+func TestSubjectPrefixStripped(t *testing.T) {
+	m, err := Parse([]byte("From: a <a@example.com>\nSubject: Re: [PATCH 1/2] [RFC] fix it\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Subject != "fix it" {
+		t.Errorf("subject = %q, want %q", m.Subject, "fix it")
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		n       int
+		message string
+		want    string
+	}{
+		{1, "Fix the bug\n\nbody", "0001-Fix-the-bug.patch"},
+		{12, "feat(cli): add --foo...bar!\n", "0012-feat-cli-add-foo.bar.patch"},
+		{3, strings.Repeat("x", 100), "0003-" + strings.Repeat("x", 52) + ".patch"},
+	}
+	for _, tt := range tests {
+		if got := FileName(tt.n, tt.message); got != tt.want {
+			t.Errorf("FileName(%d, %q) = %q, want %q", tt.n, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	subject, body := SplitMessage("\nfirst\nsecond\n\n\nbody\n")
+	if subject != "first second" || body != "body\n" {
+		t.Errorf("SplitMessage = %q, %q", subject, body)
+	}
+}
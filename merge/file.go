@@ -0,0 +1,149 @@
+package merge
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/tmdgusya/gogit/diff"
+)
+
+// markerLen: 충돌 표시의 길이 (git 과 같은 7)
+const markerLen = 7
+
+// File: 세 버전의 내용을 줄 단위로 합친다.
+// 같은 곳을 양쪽이 다르게 바꿨으면 결과에 충돌 표시를 넣고 conflict 가 true 다.
+//
+//	<<<<<<< ours
+//	우리 쪽 줄
+//	=======
+//	상대 쪽 줄
+//	>>>>>>> theirs
+//
+// git 과 같이 충돌 구간 안에서도 양쪽이 같은 줄은 충돌 밖으로 빼낸다.
+func File(base, ours, theirs []byte, oursLabel, theirsLabel string) (result []byte, conflict bool) {
+	a := diff.SplitLines(base)
+	o := diff.SplitLines(ours)
+	t := diff.SplitLines(theirs)
+	eo := diff.Lines(a, o)
+	et := diff.Lines(a, t)
+
+	var out strings.Builder
+	pos := 0
+	// deltaO, deltaT: 지금까지 지나온 변경으로 생긴 base 와 각 쪽의 줄 번호 차이
+	deltaO, deltaT := 0, 0
+	for len(eo) > 0 || len(et) > 0 {
+		// base 에서 겹치거나 맞닿은 변경들을 한 덩어리로 묶는다
+		start := chunkStart(eo, et)
+		end := start
+		var co, ct []diff.Edit
+		for {
+			switch {
+			case len(eo) > 0 && eo[0].OldPos <= end && (len(co) > 0 || len(ct) > 0 || eo[0].OldPos == start):
+				end = max(end, eo[0].OldPos+eo[0].OldLen)
+				co, eo = append(co, eo[0]), eo[1:]
+				continue
+			case len(et) > 0 && et[0].OldPos <= end && (len(co) > 0 || len(ct) > 0 || et[0].OldPos == start):
+				end = max(end, et[0].OldPos+et[0].OldLen)
+				ct, et = append(ct, et[0]), et[1:]
+				continue
+			}
+			break
+		}
+
+		for _, line := range a[pos:start] {
+			out.WriteString(line)
+		}
+		oStart, oEnd := start+deltaO, end+deltaO+growth(co)
+		tStart, tEnd := start+deltaT, end+deltaT+growth(ct)
+		switch {
+		case len(ct) == 0:
+			writeLines(&out, o[oStart:oEnd])
+		case len(co) == 0:
+			writeLines(&out, t[tStart:tEnd])
+		default:
+			if writeConflict(&out, o[oStart:oEnd], t[tStart:tEnd], oursLabel, theirsLabel) {
+				conflict = true
+			}
+		}
+		deltaO += growth(co)
+		deltaT += growth(ct)
+		pos = end
+	}
+	for _, line := range a[pos:] {
+		out.WriteString(line)
+	}
+	return []byte(out.String()), conflict
+}
+
+func chunkStart(eo, et []diff.Edit) int {
+	switch {
+	case len(eo) == 0:
+		return et[0].OldPos
+	case len(et) == 0:
+		return eo[0].OldPos
+	}
+	return min(eo[0].OldPos, et[0].OldPos)
+}
+
+// growth: 변경들로 늘어난 줄 수
+func growth(edits []diff.Edit) int {
+	n := 0
+	for _, e := range edits {
+		n += e.NewLen - e.OldLen
+	}
+	return n
+}
+
+func writeLines(out *strings.Builder, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+}
+
+// writeConflict: 양쪽이 모두 바꾼 구간. 두 쪽을 다시 비교해서 다른 부분만 충돌로 쓴다.
+// 양쪽이 같으면 충돌이 아니다.
+func writeConflict(out *strings.Builder, o, t []string, oursLabel, theirsLabel string) bool {
+	// 양쪽이 같은 줄을 지운 경우처럼 둘 다 비어 있어도 같은 변경이다
+	if slices.Equal(o, t) {
+		writeLines(out, o)
+		return false
+	}
+	// 한쪽이 비어 있으면 나눌 것이 없다 (git 과 동일)
+	if len(o) == 0 || len(t) == 0 {
+		writeMarkers(out, o, t, oursLabel, theirsLabel)
+		return true
+	}
+
+	edits := diff.Lines(o, t)
+	p := 0
+	for _, e := range edits {
+		writeLines(out, o[p:e.OldPos])
+		writeMarkers(out, o[e.OldPos:e.OldPos+e.OldLen], t[e.NewPos:e.NewPos+e.NewLen], oursLabel, theirsLabel)
+		p = e.OldPos + e.OldLen
+	}
+	writeLines(out, o[p:])
+	return true
+}
+
+func writeMarkers(out *strings.Builder, o, t []string, oursLabel, theirsLabel string) {
+	out.WriteString(strings.Repeat("<", markerLen) + label(oursLabel) + "\n")
+	writeSide(out, o)
+	out.WriteString(strings.Repeat("=", markerLen) + "\n")
+	writeSide(out, t)
+	out.WriteString(strings.Repeat(">", markerLen) + label(theirsLabel) + "\n")
+}
+
+func label(name string) string {
+	if name == "" {
+		return ""
+	}
+	return " " + name
+}
+
+// writeSide: 마지막 줄에 줄바꿈이 없어도 충돌 표시는 새 줄에서 시작해야 한다.
+func writeSide(out *strings.Builder, lines []string) {
+	writeLines(out, lines)
+	if n := len(lines); n > 0 && !strings.HasSuffix(lines[n-1], "\n") {
+		out.WriteString("\n")
+	}
+}
@@ -0,0 +1,30 @@
+package merge
+
+import "testing"
+
+func TestFileIdenticalDeletion(t *testing.T) {
+	base := "a\nb\nc\nd\ne\nf\ng\n"
+	ours := "A\nb\nd\ne\nf\ng\n"
+	theirs := "a\nb\nd\ne\nf\nG\n"
+	got, conflict := File([]byte(base), []byte(ours), []byte(theirs), "ours", "theirs")
+	if conflict {
+		t.Fatalf("conflict on identical deletion:\n%s", got)
+	}
+	if want := "A\nb\nd\ne\nf\nG\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFileConflict(t *testing.T) {
+	base := "a\nb\nc\n"
+	ours := "a\nB\nc\n"
+	theirs := "a\nX\nc\n"
+	got, conflict := File([]byte(base), []byte(ours), []byte(theirs), "ours", "theirs")
+	if !conflict {
+		t.Fatalf("no conflict:\n%s", got)
+	}
+	want := "a\n<<<<<<< ours\nB\n=======\nX\n>>>>>>> theirs\nc\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,215 @@
+// Package merge 는 공통 조상(base)을 기준으로 두 갈래의 변경을 합친다. (3-way merge)
+//
+//	result, _ := merge.Trees(store, baseTree, oursTree, theirsTree, merge.Options{Ours: "HEAD", Theirs: "topic"})
+//	if !result.Clean() { ... result.Conflicts ... }
+//
+// 한쪽만 바꾼 파일은 그쪽을 따르고, 양쪽이 같은 파일을 다르게 바꿨으면 줄 단위로 합친다(File).
+// 합칠 수 없는 파일은 충돌 표시를 넣거나 우리 쪽 버전을 결과 tree 에 남기고 Conflicts 에 기록한다.
+// 이름 바꾸기는 따라가지 않는다. (삭제와 추가로 본다)
+package merge
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tmdgusya/gogit/diff"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Options: 충돌 표시와 메시지에 쓸 양쪽의 이름
+type Options struct {
+	Ours   string
+	Theirs string
+}
+
+// Conflict: 자동으로 합치지 못한 파일
+type Conflict struct {
+	Path string
+	// Message: git 과 같은 "CONFLICT (...): ..." 설명
+	Message string
+}
+
+// Result: Trees 의 결과
+type Result struct {
+	// Tree: 합친 tree. 충돌한 파일은 충돌 표시가 들어간 내용(또는 남긴 쪽의 버전)으로 들어 있다.
+	Tree string
+	// Merged: 양쪽이 모두 바꿔서 줄 단위로 합친 파일 (충돌한 것 포함)
+	Merged    []string
+	Conflicts []Conflict
+}
+
+// Clean: 충돌 없이 합쳐졌는지
+func (r *Result) Clean() bool {
+	return len(r.Conflicts) == 0
+}
+
+// Trees: base 에서 ours 와 theirs 로 갈라진 변경을 합친 tree 를 저장한다.
+// 결과는 ours 에서 시작해 theirs 쪽 변경을 얹은 것이다. base 가 빈 문자열이면 빈 tree 로 본다.
+func Trees(s object.Storer, base, ours, theirs string, opts Options) (*Result, error) {
+	oursChanges, err := changedFiles(s, base, ours)
+	if err != nil {
+		return nil, err
+	}
+	theirsChanges, err := changedFiles(s, base, theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(theirsChanges))
+	for p := range theirsChanges {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	result := &Result{Tree: ours}
+	for _, p := range paths {
+		t := theirsChanges[p]
+		o, changed := oursChanges[p]
+		if changed && o.Hash == t.Hash && o.Mode == t.Mode {
+			continue
+		}
+		f := t
+		if changed {
+			var conflict string
+			f, conflict, err = mergeFile(s, base, o, t, opts)
+			if err != nil {
+				return nil, err
+			}
+			if o.Hash != "" && t.Hash != "" && o.Hash != t.Hash {
+				result.Merged = append(result.Merged, p)
+			}
+			if conflict != "" {
+				result.Conflicts = append(result.Conflicts, Conflict{Path: p, Message: conflict})
+			}
+			if f == o {
+				continue
+			}
+		}
+		if result.Tree, err = replace(s, result.Tree, f); err != nil {
+			return nil, err
+		}
+	}
+	if result.Tree == "" {
+		if result.Tree, err = object.WriteObject(s, &object.Tree{}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// changedFiles: base 에서 바뀐 경로 -> 바뀐 뒤의 파일. 지워진 파일은 Hash 가 비어 있다.
+func changedFiles(s object.Storer, base, tree string) (map[string]diff.File, error) {
+	changes, err := diff.Trees(s, base, tree)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]diff.File{}
+	for _, c := range changes {
+		if c.Status() == 'R' {
+			files[c.From.Path] = diff.File{Path: c.From.Path}
+		}
+		if c.Status() == 'D' {
+			files[c.From.Path] = diff.File{Path: c.From.Path}
+			continue
+		}
+		files[c.To.Path] = c.To
+	}
+	return files, nil
+}
+
+func replace(s object.Storer, tree string, f diff.File) (string, error) {
+	if f.Hash == "" {
+		if tree == "" {
+			return "", nil
+		}
+		return object.ReplacePath(s, tree, f.Path, nil)
+	}
+	return object.ReplacePath(s, tree, f.Path, &object.TreeEntry{Mode: f.Mode, Hash: f.Hash})
+}
+
+// mergeFile: 양쪽이 모두 바꾼 파일. 충돌이면 설명 메시지도 돌려준다.
+func mergeFile(s object.Storer, baseTree string, o, t diff.File, opts Options) (diff.File, string, error) {
+	p := o.Path
+	var base diff.File
+	if baseTree != "" {
+		entry, ok, err := object.LookupPath(s, baseTree, p)
+		if err != nil {
+			return diff.File{}, "", err
+		}
+		if ok && entry.Mode != object.ModeTree {
+			base = diff.File{Path: p, Mode: entry.Mode, Hash: entry.Hash}
+		}
+	}
+
+	switch {
+	case o.Hash == "":
+		return t, fmt.Sprintf("CONFLICT (modify/delete): %s deleted in %s and modified in %s.  Version %s of %s left in tree.",
+			p, opts.Ours, opts.Theirs, opts.Theirs, p), nil
+	case t.Hash == "":
+		return o, fmt.Sprintf("CONFLICT (modify/delete): %s deleted in %s and modified in %s.  Version %s of %s left in tree.",
+			p, opts.Theirs, opts.Ours, opts.Ours, p), nil
+	}
+
+	kind := "content"
+	if base.Hash == "" {
+		kind = "add/add"
+	}
+	conflict := fmt.Sprintf("CONFLICT (%s): Merge conflict in %s", kind, p)
+
+	// 모드는 한쪽만 바꿨으면 그쪽을 따른다
+	mode := o.Mode
+	if o.Mode == base.Mode {
+		mode = t.Mode
+	}
+	if o.Hash == t.Hash {
+		return diff.File{Path: p, Mode: mode, Hash: o.Hash}, "", nil
+	}
+	if o.Hash == base.Hash {
+		return diff.File{Path: p, Mode: mode, Hash: t.Hash}, "", nil
+	}
+	if t.Hash == base.Hash {
+		return diff.File{Path: p, Mode: mode, Hash: o.Hash}, "", nil
+	}
+	if !isRegular(o.Mode) || !isRegular(t.Mode) || base.Hash != "" && !isRegular(base.Mode) {
+		// 심볼릭 링크나 submodule 은 줄 단위로 합칠 수 없다
+		return o, conflict, nil
+	}
+
+	baseData, err := read(s, base)
+	if err != nil {
+		return diff.File{}, "", err
+	}
+	oursData, err := read(s, o)
+	if err != nil {
+		return diff.File{}, "", err
+	}
+	theirsData, err := read(s, t)
+	if err != nil {
+		return diff.File{}, "", err
+	}
+	if diff.IsBinary(baseData) || diff.IsBinary(oursData) || diff.IsBinary(theirsData) {
+		return o, conflict, nil
+	}
+
+	merged, conflicted := File(baseData, oursData, theirsData, opts.Ours, opts.Theirs)
+	hash, err := s.Write(object.TypeBlob, merged)
+	if err != nil {
+		return diff.File{}, "", err
+	}
+	if !conflicted {
+		conflict = ""
+	}
+	return diff.File{Path: p, Mode: mode, Hash: hash}, conflict, nil
+}
+
+func isRegular(m object.Mode) bool {
+	return m == object.ModeRegular || m == object.ModeExecutable
+}
+
+func read(s object.Storer, f diff.File) ([]byte, error) {
+	if f.Hash == "" {
+		return nil, nil
+	}
+	_, data, err := s.Read(f.Hash)
+	return data, err
+}
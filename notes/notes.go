@@ -0,0 +1,128 @@
+// Package notes 는 커밋을 다시 쓰지 않고 덧붙이는 메모(git notes)의 tree 를 읽고 고친다.
+//
+// 메모는 refs/notes/commits 같은 ref 가 가리키는 커밋의 tree 에 blob 으로 들어가고,
+// 항목 이름은 메모를 붙인 객체의 해시다. 메모가 많아지면 git 은 해시 앞부분을 디렉토리로 나누므로
+// (예: "ab/cdef...") 읽을 때는 나눈 경로도 받는다. 새 메모는 나누지 않고 루트에 쓴다.
+package notes
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// DefaultRef: 메모를 두는 기본 ref (git 과 같다)
+const DefaultRef = "refs/notes/commits"
+
+// Note: 객체 하나에 붙은 메모
+type Note struct {
+	// Object: 메모를 붙인 객체의 해시
+	Object string
+	// Blob: 메모 내용의 blob 해시
+	Blob string
+	// Path: notes tree 안에서의 경로
+	Path string
+}
+
+// ExpandRef: --ref 의 값을 전체 ref 이름으로 바꾼다. (git 과 같이 "foo" 와 "notes/foo" 모두 refs/notes/foo)
+func ExpandRef(name string) string {
+	switch {
+	case strings.HasPrefix(name, "refs/notes/"):
+		return name
+	case strings.HasPrefix(name, "notes/"):
+		return "refs/" + name
+	}
+	return "refs/notes/" + name
+}
+
+// List: notes tree 의 메모들을 객체 해시 순서로 나열한다. tree 가 빈 문자열이면 메모가 없다.
+// 해시가 아닌 이름의 항목은 메모가 아니므로 건너뛴다.
+func List(s object.Storer, tree string) ([]Note, error) {
+	var result []Note
+	if tree != "" {
+		if err := list(s, tree, "", &result); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Object < result[j].Object })
+	return result, nil
+}
+
+// list: dir 아래의 메모. dir 의 디렉토리 이름들을 이으면 그 아래 메모들의 해시 앞부분이다
+func list(s object.Storer, tree string, dir string, result *[]Note) error {
+	t, err := object.ReadTree(s, tree)
+	if err != nil {
+		return err
+	}
+	prefix := strings.ReplaceAll(dir, "/", "")
+	for _, e := range t.Entries {
+		name := prefix + e.Name
+		switch {
+		case e.Mode == object.ModeTree && len(e.Name) == 2 && isHex(e.Name) && len(name) < s.Algorithm().HexSize():
+			if err := list(s, e.Hash, path.Join(dir, e.Name), result); err != nil {
+				return err
+			}
+		case e.Mode.ObjectType() == object.TypeBlob && s.Algorithm().IsHash(name):
+			*result = append(*result, Note{Object: name, Blob: e.Hash, Path: path.Join(dir, e.Name)})
+		}
+	}
+	return nil
+}
+
+func isHex(s string) bool {
+	return strings.Trim(s, "0123456789abcdef") == ""
+}
+
+// Find: obj 에 붙은 메모. 없으면 ok 가 false
+func Find(s object.Storer, tree string, obj string) (note Note, ok bool, err error) {
+	if tree == "" {
+		return Note{}, false, nil
+	}
+	// 나누지 않은 경로부터, 앞부분을 두 글자씩 디렉토리로 나눈 경로까지 차례로 찾는다
+	for depth := 0; 2*depth < len(obj)-2; depth++ {
+		var parts []string
+		for i := range depth {
+			parts = append(parts, obj[2*i:2*i+2])
+		}
+		p := path.Join(append(parts, obj[2*depth:])...)
+		e, found, err := object.LookupPath(s, tree, p)
+		if err != nil {
+			return Note{}, false, err
+		}
+		if found && e.Mode.ObjectType() == object.TypeBlob {
+			return Note{Object: obj, Blob: e.Hash, Path: p}, true, nil
+		}
+		if depth > 0 {
+			if e, found, err := object.LookupPath(s, tree, path.Join(parts...)); err != nil || !found || e.Mode != object.ModeTree {
+				return Note{}, false, err
+			}
+		}
+	}
+	return Note{}, false, nil
+}
+
+// Set: obj 의 메모를 blob 으로 바꾼 notes tree 를 저장하고 그 해시를 돌려준다. blob 이 빈 문자열이면 메모를 지운다.
+// 이미 있는 메모는 그 자리에서 바꾸고, 새 메모는 루트에 더한다.
+func Set(s object.Storer, tree string, obj string, blob string) (string, error) {
+	if !object.IsHash(obj) {
+		return "", fmt.Errorf("invalid object name '%s'", obj)
+	}
+	old, found, err := Find(s, tree, obj)
+	if err != nil {
+		return "", err
+	}
+	p := obj
+	if found {
+		p = old.Path
+	}
+	if blob == "" {
+		if !found {
+			return "", fmt.Errorf("object %s has no note", obj)
+		}
+		return object.ReplacePath(s, tree, p, nil)
+	}
+	return object.ReplacePath(s, tree, p, &object.TreeEntry{Mode: object.ModeRegular, Hash: blob})
+}
@@ -0,0 +1,83 @@
+package object
+
+import (
+	"strings"
+)
+
+// Commit: 스냅샷(tree) 하나와 그 이력(parents)
+// 부모가 여러 개면 merge commit, 없으면 root commit 이다.
+type Commit struct {
+	Tree      string
+	Parents   []string
+	Author    Signature
+	Committer Signature
+	// ExtraHeaders: tree/parent/author/committer 외의 헤더 (encoding, gpgsig 등)
+	ExtraHeaders []Header
+	Message      string
+}
+
+func (c *Commit) Type() Type { return TypeCommit }
+
+func (c *Commit) Encode() []byte {
+	var b strings.Builder
+	writeHeader(&b, "tree", c.Tree)
+	for _, parent := range c.Parents {
+		writeHeader(&b, "parent", parent)
+	}
+	writeHeader(&b, "author", c.Author.String())
+	writeHeader(&b, "committer", c.Committer.String())
+	for _, h := range c.ExtraHeaders {
+		writeHeader(&b, h.Key, h.Value)
+	}
+	b.WriteString("\n")
+	b.WriteString(c.Message)
+	return []byte(b.String())
+}
+
+func (c *Commit) Decode(data []byte) error {
+	headers, message, err := parseHeaders(data)
+	if err != nil {
+		return invalidf("commit: %v", err)
+	}
+
+	*c = Commit{Message: message}
+	for _, h := range headers {
+		switch h.Key {
+		case "tree":
+			c.Tree = h.Value
+		case "parent":
+			c.Parents = append(c.Parents, h.Value)
+		case "author":
+			if c.Author, err = ParseSignature(h.Value); err != nil {
+				return invalidf("commit author: %v", err)
+			}
+		case "committer":
+			if c.Committer, err = ParseSignature(h.Value); err != nil {
+				return invalidf("commit committer: %v", err)
+			}
+		default:
+			c.ExtraHeaders = append(c.ExtraHeaders, h)
+		}
+	}
+
+	if c.Tree == "" {
+		return invalidf("commit is missing its tree")
+	}
+	return nil
+}
+
+// Header: 추가 헤더 값 조회
+func (c *Commit) Header(key string) (string, bool) {
+	for _, h := range c.ExtraHeaders {
+		if h.Key == key {
+			return h.Value, true
+		}
+	}
+	return "", false
+}
+
+// Subject: 메시지의 첫 줄
+func (c *Commit) Subject() string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return subject
+}
@@ -0,0 +1,145 @@
+package object
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Counts: 객체 디렉토리의 통계 (git count-objects -v)
+// 크기는 파일 크기의 합(바이트)이다.
+type Counts struct {
+	// Count, Size: loose 객체
+	Count int
+	Size  int64
+	// InPack: 모든 pack 에 든 객체 수의 합 (여러 pack 에 든 객체는 여러 번 센다)
+	InPack   int
+	Packs    int
+	SizePack int64
+	// PrunePackable: pack 에도 들어 있어서 지워도 되는 loose 객체
+	PrunePackable int
+	// Garbage: 객체 디렉토리에 있지만 객체도 pack 도 아닌 파일 (objects 기준 경로)
+	// 짝이 없는 .idx/.pack, 중단된 쓰기가 남긴 임시 파일 등
+	Garbage     []string
+	SizeGarbage int64
+}
+
+// packExtras: git 이 pack 옆에 두는 보조 파일들. gogit 은 쓰지 않지만 garbage 는 아니다.
+var packExtras = map[string]bool{".keep": true, ".rev": true, ".bitmap": true, ".promisor": true, ".mtimes": true}
+
+// Count: 객체 디렉토리를 훑어 통계를 낸다.
+func (s *Store) Count() (*Counts, error) {
+	c := &Counts{}
+	packs, err := s.loadPacks(true)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.fs.ReadDir(".")
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		switch {
+		case e.Name() == packDir || e.Name() == "info":
+			// pack 은 아래에서, info 는 alternates 같은 설정이라 세지 않는다
+		case e.IsDir() && len(e.Name()) == 2:
+			if err := s.countLoose(c, e.Name(), packs); err != nil {
+				return nil, err
+			}
+		default:
+			if err := c.addGarbage(e, e.Name()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	files, err := s.fs.ReadDir(packDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, f := range files {
+		names[f.Name()] = true
+	}
+	for _, f := range files {
+		name := f.Name()
+		base, ext := strings.TrimSuffix(name, path.Ext(name)), path.Ext(name)
+		if names[base+".pack"] && packExtras[ext] {
+			continue
+		}
+		paired := (ext == ".pack" && names[base+".idx"]) || (ext == ".idx" && names[base+".pack"])
+		if !paired || !strings.HasPrefix(base, "pack-") {
+			if err := c.addGarbage(f, packDir+"/"+name); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			return nil, err
+		}
+		c.SizePack += info.Size()
+		if ext == ".pack" {
+			c.Packs++
+		}
+	}
+	for _, p := range packs {
+		c.InPack += len(p.hashes)
+	}
+	return c, nil
+}
+
+func (s *Store) countLoose(c *Counts, dir string, packs []*pack) error {
+	files, err := s.fs.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		hash := dir + f.Name()
+		if f.IsDir() || !s.algo.IsHash(hash) {
+			if err := c.addGarbage(f, dir+"/"+f.Name()); err != nil {
+				return err
+			}
+			continue
+		}
+		info, err := f.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		c.Count++
+		c.Size += info.Size()
+		for _, p := range packs {
+			if _, ok := p.find(hash); ok {
+				c.PrunePackable++
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Counts) addGarbage(e fs.DirEntry, name string) error {
+	info, err := e.Info()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.Garbage = append(c.Garbage, name)
+	if !e.IsDir() {
+		c.SizeGarbage += info.Size()
+	}
+	return nil
+}
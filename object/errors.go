@@ -0,0 +1,34 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 호출하는 쪽이 errors.Is 로 실패 종류를 구분할 수 있도록 모든 에러는 아래 값 중 하나를 감싼다.
+var (
+	// ErrNotFound: 저장소에 없는 객체 (또는 올바르지 않은 객체 이름)
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalid: 헤더나 내용의 문법이 잘못된 객체
+	ErrInvalid = errors.New("invalid object")
+	// ErrWrongType: commit 을 기대했는데 tree 가 오는 것처럼 타입이 다른 객체
+	ErrWrongType = errors.New("unexpected object type")
+	// ErrCorrupt: 내용을 다시 해시한 값이 객체 이름과 다른 객체 (디스크 손상 등)
+	ErrCorrupt = errors.New("object is corrupt")
+)
+
+func invalidf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalid, fmt.Sprintf(format, args...))
+}
+
+func notFound(hash string) error {
+	return fmt.Errorf("%w: %s", ErrNotFound, hash)
+}
+
+func corrupt(hash string, actual string) error {
+	return fmt.Errorf("%w: %s hashes to %s", ErrCorrupt, hash, actual)
+}
+
+func wrongType(hash string, got Type, want Type) error {
+	return fmt.Errorf("%w: %s is a %s, not a %s", ErrWrongType, hash, got, want)
+}
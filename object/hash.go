@@ -0,0 +1,72 @@
+package object
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// Algorithm: 객체 이름을 만드는 해시 함수 (git 의 object format)
+// 저장소 하나는 한 가지만 쓴다. SHA-256 저장소는 git 과 같이 config 의 extensions.objectFormat 에 기록한다.
+type Algorithm struct {
+	// Name: extensions.objectFormat 에 쓰는 이름 ("sha1", "sha256")
+	Name string
+	// Size: 해시의 바이트 수. tree 항목, pack, pack index 에는 이 길이로 들어간다
+	Size int
+	new  func() hash.Hash
+}
+
+var (
+	SHA1   = &Algorithm{Name: "sha1", Size: sha1.Size, new: sha1.New}
+	SHA256 = &Algorithm{Name: "sha256", Size: sha256.Size, new: sha256.New}
+)
+
+// ParseAlgorithm: extensions.objectFormat 값에 맞는 Algorithm. 빈 문자열은 SHA-1
+func ParseAlgorithm(name string) (*Algorithm, error) {
+	switch strings.ToLower(name) {
+	case "", SHA1.Name:
+		return SHA1, nil
+	case SHA256.Name:
+		return SHA256, nil
+	}
+	return nil, fmt.Errorf("unknown object format '%s'", name)
+}
+
+// New: 새 해시 계산기
+func (a *Algorithm) New() hash.Hash {
+	return a.new()
+}
+
+// HexSize: 16진수로 쓴 해시의 길이 (40 또는 64)
+func (a *Algorithm) HexSize() int {
+	return a.Size * 2
+}
+
+// Sum: data(헤더가 포함된 저장 포맷)의 해시
+func (a *Algorithm) Sum(data []byte) string {
+	h := a.new()
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ZeroHash: "없는 객체" 를 나타내는 0 으로만 된 해시 (reflog, hook 입력)
+func (a *Algorithm) ZeroHash() string {
+	return strings.Repeat("0", a.HexSize())
+}
+
+// IsHash: 이 해시 함수의 길이에 맞는 16진수 문자열인지
+func (a *Algorithm) IsHash(s string) bool {
+	if len(s) != a.HexSize() {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// IsZeroHash: 길이와 상관없이 0 으로만 된 해시인지
+func IsZeroHash(s string) bool {
+	return s != "" && strings.Trim(s, "0") == ""
+}
@@ -0,0 +1,101 @@
+package object
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// git hash-object 와 같은 값 (빈 blob, 빈 tree)
+func TestAlgorithmKnownHashes(t *testing.T) {
+	tests := []struct {
+		a     *Algorithm
+		typ   Type
+		empty string
+	}{
+		{SHA1, TypeBlob, "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"},
+		{SHA1, TypeTree, "4b825dc642cb6eb9a060e54bf8d69288fbee4904"},
+		{SHA256, TypeBlob, "473a0f4c3be8a93681a267e3b1e9a7dcda1185436fe141f7749120a303721813"},
+		{SHA256, TypeTree, "6ef19b41225c5369f1c104d45d8d85efa9b057b53b14b4b9b939dd74decc5321"},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Sum(Format(tt.typ, nil)); got != tt.empty {
+			t.Errorf("%s empty %s = %s, want %s", tt.a.Name, tt.typ, got, tt.empty)
+		}
+		if !tt.a.IsHash(tt.empty) || AlgorithmOf(tt.empty) != tt.a {
+			t.Errorf("%s does not recognise %s", tt.a.Name, tt.empty)
+		}
+		if len(tt.a.ZeroHash()) != tt.a.HexSize() || !IsZeroHash(tt.a.ZeroHash()) {
+			t.Errorf("%s zero hash = %s", tt.a.Name, tt.a.ZeroHash())
+		}
+	}
+	if SHA1.IsHash(tests[2].empty) || SHA256.IsHash(tests[0].empty) {
+		t.Error("hash accepted by the wrong algorithm")
+	}
+	if AlgorithmOf("xyz") != nil {
+		t.Error("AlgorithmOf accepted a non-hash")
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	for name, want := range map[string]*Algorithm{"": SHA1, "sha1": SHA1, "SHA256": SHA256} {
+		if got, err := ParseAlgorithm(name); err != nil || got != want {
+			t.Errorf("ParseAlgorithm(%q) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := ParseAlgorithm("md5"); err == nil {
+		t.Error("ParseAlgorithm(md5): no error")
+	}
+}
+
+// TestSHA256Store: SHA-256 저장소에서 32 바이트 해시가 든 tree 를 쓰고 읽고, pack 으로 옮길 수 있다.
+func TestSHA256Store(t *testing.T) {
+	s := NewStore(vfs.NewOS(t.TempDir()))
+	s.SetAlgorithm(SHA256)
+	blob, err := WriteObject(s, &Blob{Data: []byte("hello\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !SHA256.IsHash(blob) {
+		t.Fatalf("blob hash %s is not SHA-256", blob)
+	}
+	tree, err := WriteObject(s, &Tree{Entries: []TreeEntry{{Name: "hello.txt", Mode: ModeRegular, Hash: blob}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := ReadObject(s, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := obj.(*Tree).Entries; len(entries) != 1 || entries[0].Hash != blob {
+		t.Fatalf("tree entries = %+v", entries)
+	}
+	// SHA-1 으로 읽으면 항목의 해시 길이가 맞지 않는다
+	_, data, err := s.Read(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wrong Tree
+	if err := wrong.Decode(data); err == nil && len(wrong.Entries) == 1 && wrong.Entries[0].Hash == blob {
+		t.Error("SHA-256 tree decoded as SHA-1")
+	}
+
+	var pack bytes.Buffer
+	if _, err := EncodePack(&pack, s, []string{blob, tree}); err != nil {
+		t.Fatal(err)
+	}
+	dst := NewStore(vfs.NewOS(t.TempDir()))
+	dst.SetAlgorithm(SHA256)
+	if _, err := dst.IndexPack(&pack); err != nil {
+		t.Fatal(err)
+	}
+	for _, hash := range []string{blob, tree} {
+		if !dst.Has(hash) {
+			t.Errorf("pack lacks %s", hash)
+		}
+	}
+	if _, data, err := dst.Read(blob); err != nil || string(data) != "hello\n" {
+		t.Errorf("blob from pack = %q, %v", data, err)
+	}
+}
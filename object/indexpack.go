@@ -0,0 +1,358 @@
+package object
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"slices"
+	"sort"
+)
+
+// IndexPack: r 에서 pack 하나를 읽어 objects/pack 에 넣고 index 를 만든다. (git index-pack)
+// 이름("pack-<checksum>")을 돌려준다. 끝의 checksum 이 맞지 않거나 풀 수 없는 delta 가 있으면 에러다.
+//
+// delta 의 base 는 같은 pack 에 있거나 이미 저장소에 있어야 한다. (thin pack)
+// 저장소에 있던 base 는 git 의 index-pack --fix-thin 처럼 pack 끝에 붙여서, pack 하나만으로 읽을 수 있게 한다.
+func (s *Store) IndexPack(r io.Reader) (string, error) {
+	if err := s.fs.MkdirAll(packDir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := tempName()
+	if err != nil {
+		return "", err
+	}
+	tmpPack, tmpIdx := packDir+"/"+tmp+".pack", packDir+"/"+tmp+".idx"
+	defer s.fs.Remove(tmpPack)
+	defer s.fs.Remove(tmpIdx)
+
+	f, err := s.fs.Create(tmpPack)
+	if err != nil {
+		return "", err
+	}
+	scanned, checksum, err := scanPack(r, f, s.algo)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	entries, external, err := s.resolvePack(tmp, scanned)
+	if err != nil {
+		return "", err
+	}
+	if len(external) > 0 {
+		thin := tmpPack
+		tmpPack = packDir + "/" + tmp + "-fixed.pack"
+		defer s.fs.Remove(tmpPack)
+		if entries, checksum, err = s.fixThin(thin, tmpPack, entries, external); err != nil {
+			return "", err
+		}
+	}
+
+	f, err = s.fs.Create(tmpIdx)
+	if err != nil {
+		return "", err
+	}
+	err = writePackIndex(f, entries, checksum, s.algo)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	return s.installPack(tmpPack, tmpIdx, checksum)
+}
+
+// scannedEntry: pack 을 처음 훑으며 알아낸 객체 하나. delta 면 hash 는 아직 비어 있다.
+type scannedEntry struct {
+	offset int64
+	crc    uint32
+	kind   byte
+	hash   string
+	// baseOffset, baseHash: OFS_DELTA / REF_DELTA 의 base
+	baseOffset int64
+	baseHash   string
+}
+
+// packScanner: 앞에서부터 읽으며 읽은 바이트를 그대로 out 과 pack 전체의 해시, 객체 하나의 CRC32 에 넘긴다.
+// ReadByte 가 있어서 zlib 이 필요한 만큼만 읽으므로 다음 객체의 시작 위치를 알 수 있다.
+type packScanner struct {
+	r   *bufio.Reader
+	out io.Writer
+	sum hash.Hash
+	crc hash.Hash32
+	n   int64
+}
+
+func (p *packScanner) consume(b []byte) error {
+	p.sum.Write(b)
+	p.crc.Write(b)
+	p.n += int64(len(b))
+	_, err := p.out.Write(b)
+	return err
+}
+
+func (p *packScanner) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if werr := p.consume(b[:n]); werr != nil {
+		return n, werr
+	}
+	return n, err
+}
+
+func (p *packScanner) ReadByte() (byte, error) {
+	c, err := p.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	return c, p.consume([]byte{c})
+}
+
+// scanPack: r 의 pack 을 out 에 복사하면서 객체마다 위치, CRC32, (delta 가 아니면) 해시를 구한다.
+// 객체 이름, REF_DELTA 의 base, 끝의 checksum 은 모두 a 의 해시다.
+func scanPack(r io.Reader, out io.Writer, a *Algorithm) ([]scannedEntry, []byte, error) {
+	sc := &packScanner{r: bufio.NewReader(r), out: out, sum: a.New(), crc: crc32.NewIEEE()}
+
+	var header [12]byte
+	if _, err := io.ReadFull(sc, header[:]); err != nil {
+		return nil, nil, invalidf("truncated pack header")
+	}
+	if !bytes.Equal(header[:4], []byte("PACK")) || binary.BigEndian.Uint32(header[4:8]) != 2 {
+		return nil, nil, invalidf("unsupported pack")
+	}
+	n := binary.BigEndian.Uint32(header[8:])
+
+	entries := make([]scannedEntry, 0, n)
+	for i := uint32(0); i < n; i++ {
+		e := scannedEntry{offset: sc.n}
+		sc.crc.Reset()
+
+		c, err := sc.ReadByte()
+		if err != nil {
+			return nil, nil, invalidf("truncated pack")
+		}
+		e.kind = (c >> 4) & 7
+		size := int64(c & 0x0f)
+		for shift := 4; c&0x80 != 0; shift += 7 {
+			if c, err = sc.ReadByte(); err != nil {
+				return nil, nil, invalidf("truncated pack")
+			}
+			size |= int64(c&0x7f) << shift
+		}
+
+		var typ Type
+		switch e.kind {
+		case packCommit:
+			typ = TypeCommit
+		case packTree:
+			typ = TypeTree
+		case packBlob:
+			typ = TypeBlob
+		case packTag:
+			typ = TypeTag
+		case packOfsDelta:
+			c, err := sc.ReadByte()
+			if err != nil {
+				return nil, nil, invalidf("truncated pack")
+			}
+			dist := int64(c & 0x7f)
+			for c&0x80 != 0 {
+				if c, err = sc.ReadByte(); err != nil {
+					return nil, nil, invalidf("truncated pack")
+				}
+				dist = (dist+1)<<7 | int64(c&0x7f)
+			}
+			if dist <= 0 || dist > e.offset {
+				return nil, nil, invalidf("bad delta base offset at %d", e.offset)
+			}
+			e.baseOffset = e.offset - dist
+		case packRefDelta:
+			raw := make([]byte, a.Size)
+			if _, err := io.ReadFull(sc, raw); err != nil {
+				return nil, nil, invalidf("truncated pack")
+			}
+			e.baseHash = hex.EncodeToString(raw)
+		default:
+			return nil, nil, invalidf("unknown object type %d at %d", e.kind, e.offset)
+		}
+
+		// delta 가 아니면 풀면서 바로 해시를 구한다
+		h := a.New()
+		if typ != "" {
+			fmt.Fprintf(h, "%s %d%s", typ, size, NUL)
+		}
+		zr, err := getZlibReader(sc)
+		if err != nil {
+			return nil, nil, err
+		}
+		got, err := io.Copy(h, zr)
+		putZlibReader(zr)
+		if err != nil {
+			return nil, nil, err
+		}
+		if got != size {
+			return nil, nil, invalidf("object size mismatch at %d", e.offset)
+		}
+		if typ != "" {
+			e.hash = hex.EncodeToString(h.Sum(nil))
+		}
+		e.crc = sc.crc.Sum32()
+		entries = append(entries, e)
+	}
+
+	// 끝의 checksum 은 그 앞 전체의 해시다
+	checksum := sc.sum.Sum(nil)
+	trailer := make([]byte, len(checksum))
+	if _, err := io.ReadFull(sc.r, trailer); err != nil {
+		return nil, nil, invalidf("truncated pack")
+	}
+	if !bytes.Equal(trailer, checksum) {
+		return nil, nil, invalidf("pack checksum mismatch")
+	}
+	if _, err := out.Write(trailer); err != nil {
+		return nil, nil, err
+	}
+	return entries, checksum, nil
+}
+
+// resolvePack: delta 객체들을 풀어 해시를 구하고 index 에 넣을 항목과, pack 밖에 있던 REF_DELTA base 들을 돌려준다.
+// base 가 뒤에 나오는 REF_DELTA 도 있으므로, 더 풀리는 것이 없을 때까지 여러 번 돈다.
+func (s *Store) resolvePack(name string, scanned []scannedEntry) ([]packEntry, []string, error) {
+	hashAt := map[int64]string{}
+	var pending []int
+	for i, e := range scanned {
+		if e.hash != "" {
+			hashAt[e.offset] = e.hash
+		} else {
+			pending = append(pending, i)
+		}
+	}
+
+	for len(pending) > 0 {
+		p := &pack{name: name}
+		for off, h := range hashAt {
+			p.hashes = append(p.hashes, h)
+			p.offsets = append(p.offsets, off)
+		}
+		sort.Sort(byHash{p})
+
+		var next []int
+		for _, i := range pending {
+			e := &scanned[i]
+			ready := hashAt[e.baseOffset] != ""
+			if e.kind == packRefDelta {
+				_, inPack := p.find(e.baseHash)
+				ready = inPack || s.Has(e.baseHash)
+			}
+			if !ready {
+				next = append(next, i)
+				continue
+			}
+			typ, data, err := s.readPacked(p, e.offset)
+			if err != nil {
+				return nil, nil, err
+			}
+			e.hash = s.algo.Sum(Format(typ, data))
+			hashAt[e.offset] = e.hash
+		}
+		if len(next) == len(pending) {
+			return nil, nil, invalidf("pack has %d unresolved deltas", len(next))
+		}
+		pending = next
+	}
+
+	inPack := map[string]bool{}
+	entries := make([]packEntry, len(scanned))
+	for i, e := range scanned {
+		raw, err := hex.DecodeString(e.hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries[i] = packEntry{hash: raw, offset: e.offset, crc: e.crc}
+		inPack[e.hash] = true
+	}
+	var external []string
+	for _, e := range scanned {
+		if e.kind == packRefDelta && !inPack[e.baseHash] && !slices.Contains(external, e.baseHash) {
+			external = append(external, e.baseHash)
+		}
+	}
+	return entries, external, nil
+}
+
+// fixThin: thin pack(src)의 객체들 뒤에 pack 밖의 base 들을 통째로 붙인 pack 을 dst 에 쓴다.
+// 앞의 객체들은 위치가 그대로이므로 entries 는 그대로 쓰고 붙인 객체만 더한다. 객체 수와 checksum 은 새로 쓴다.
+func (s *Store) fixThin(src, dst string, entries []packEntry, bases []string) ([]packEntry, []byte, error) {
+	info, err := s.fs.Stat(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	in, err := s.fs.Open(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer in.Close()
+	out, err := s.fs.Create(dst)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer out.Close()
+
+	sum := s.algo.New()
+	bw := bufio.NewWriter(out)
+	cw := &countWriter{w: io.MultiWriter(bw, sum)}
+	var header [12]byte
+	if _, err := io.ReadFull(in, header[:]); err != nil {
+		return nil, nil, err
+	}
+	binary.BigEndian.PutUint32(header[8:], uint32(len(entries)+len(bases)))
+	if _, err := cw.Write(header[:]); err != nil {
+		return nil, nil, err
+	}
+	// 원래 checksum 은 빼고 복사한다
+	if _, err := io.CopyN(cw, in, info.Size()-int64(len(header))-int64(s.algo.Size)); err != nil {
+		return nil, nil, err
+	}
+	for _, hash := range bases {
+		typ, content, err := s.Read(hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		obj, err := encodePackObject(hash, typ, content)
+		if err != nil {
+			return nil, nil, err
+		}
+		raw, err := hex.DecodeString(hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, packEntry{hash: raw, offset: cw.n, crc: crc32.ChecksumIEEE(obj)})
+		if _, err := cw.Write(obj); err != nil {
+			return nil, nil, err
+		}
+	}
+	checksum := sum.Sum(nil)
+	if _, err := bw.Write(checksum); err != nil {
+		return nil, nil, err
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, nil, err
+	}
+	return entries, checksum, out.Close()
+}
+
+// byHash: pack 의 hashes 와 offsets 를 함께 해시 순서로 정렬한다.
+type byHash struct{ p *pack }
+
+func (b byHash) Len() int           { return len(b.p.hashes) }
+func (b byHash) Less(i, j int) bool { return b.p.hashes[i] < b.p.hashes[j] }
+func (b byHash) Swap(i, j int) {
+	b.p.hashes[i], b.p.hashes[j] = b.p.hashes[j], b.p.hashes[i]
+	b.p.offsets[i], b.p.offsets[j] = b.p.offsets[j], b.p.offsets[i]
+}
@@ -0,0 +1,425 @@
+package object
+
+import (
+	"container/heap"
+	"context"
+	"io"
+	"path"
+	"slices"
+	"sort"
+)
+
+// Order: 커밋 순회 순서
+type Order int
+
+const (
+	// OrderDate: committer 시간이 최신인 것부터 (git log 기본값)
+	OrderDate Order = iota
+	// OrderTopo: 자식이 항상 부모보다 먼저 나오고, 한 갈래의 이력을 이어서 보여준다. (git log --topo-order)
+	OrderTopo
+)
+
+// CommitIter: 커밋 이력 순회
+// merge commit 때문에 같은 커밋에 여러 경로로 도달할 수 있으므로 방문한 커밋은 다시 돌려주지 않는다.
+type CommitIter struct {
+	store Storer
+	order Order
+
+	// OrderDate: 아직 내보내지 않은 커밋들의 우선순위 큐
+	queue   commitQueue
+	pushed  int
+	visited map[string]bool
+	// hidden: 내보내지도, 따라가지도 않는 커밋 (A..B 의 A 쪽 이력)
+	hidden map[string]bool
+
+	// OrderTopo: 미리 계산한 결과를 차례로 돌려준다
+	sorted []hashCommit
+	topoOK bool
+	starts []string
+}
+
+type hashCommit struct {
+	hash   string
+	commit *Commit
+	// seq: 큐에 들어간 순서. 시간이 같으면 먼저 들어간 커밋이 먼저 나온다 (git 과 동일)
+	seq int
+}
+
+// NewCommitIter: starts 에서 도달 가능한 모든 커밋을 순회한다.
+func NewCommitIter(s Storer, starts []string, order Order) *CommitIter {
+	return &CommitIter{
+		store:   s,
+		order:   order,
+		visited: map[string]bool{},
+		starts:  starts,
+	}
+}
+
+// Hide: hidden 에 있는 커밋은 내보내지 않고 그 부모도 따라가지 않는다.
+// 순회를 시작하기 전에 호출해야 한다. 보통 Reachable 의 결과를 넘긴다.
+func (it *CommitIter) Hide(hidden map[string]bool) {
+	it.hidden = hidden
+}
+
+// Reachable: starts 에서 도달 가능한 모든 커밋의 집합
+func Reachable(ctx context.Context, s Storer, starts []string) (map[string]bool, error) {
+	set := map[string]bool{}
+	err := NewCommitIter(s, starts, OrderDate).ForEachContext(ctx, func(hash string, _ *Commit) error {
+		set[hash] = true
+		return nil
+	})
+	return set, err
+}
+
+// ReachableObjects: starts(커밋, tag, tree, blob 어느 것이든)에서 도달 가능한 모든 객체
+// 커밋의 부모와 tree, tree 의 항목, tag 가 가리키는 객체를 따라가며 submodule(gitlink)은 따라가지 않는다.
+// 커밋과 tag, tree, blob 순서로 모아서 돌려준다. blob 은 내용을 읽지 않는다.
+func ReachableObjects(ctx context.Context, s Storer, starts []string) ([]string, error) {
+	return ReachableObjectsFilter(ctx, s, starts, nil)
+}
+
+// BlobFilter: tree 안의 path 에 있는 blob 을 담을지. false 면 뺀다
+type BlobFilter func(path string, mode Mode) bool
+
+// ReachableObjectsFilter: ReachableObjects 와 같지만 filter 가 false 를 돌려준 blob 은 뺀다. (filter 가 nil 이면 모두 담는다)
+// 같은 blob 이 여러 경로에 있으면 한 경로에서라도 빠지면 뺀다. 경로를 알아야 하므로 starts 에서 바로 가리킨 blob 은 뺄 수 없다.
+// filter 가 있으면 같은 tree 라도 경로마다 따로 살펴본다.
+func ReachableObjectsFilter(ctx context.Context, s Storer, starts []string, filter BlobFilter) ([]string, error) {
+	seen := map[string]bool{}
+	// listed: 담은 tree. filter 가 있으면 같은 tree 를 여러 경로에서 살펴보지만 담는 것은 한 번이다
+	listed := map[string]bool{}
+	hidden := map[string]bool{}
+	var commits, trees, blobs []string
+	type item struct {
+		hash string
+		// typ: 이미 아는 종류. 비어 있으면 읽어서 알아낸다
+		typ Type
+		// path: tree 와 blob 의 경로 (filter 가 있을 때만 쓴다)
+		path string
+	}
+	stack := make([]item, 0, len(starts))
+	for _, hash := range starts {
+		stack = append(stack, item{hash: hash})
+	}
+	for len(stack) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		it := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		key := it.hash
+		if filter != nil && it.typ == TypeTree {
+			key = it.hash + "\x00" + it.path
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if it.typ == TypeBlob {
+			if !s.Has(it.hash) {
+				return nil, notFound(it.hash)
+			}
+			blobs = append(blobs, it.hash)
+			continue
+		}
+		obj, err := ReadObject(s, it.hash)
+		if err != nil {
+			return nil, err
+		}
+		switch o := obj.(type) {
+		case *Commit:
+			commits = append(commits, it.hash)
+			stack = append(stack, item{hash: o.Tree, typ: TypeTree})
+			for _, p := range o.Parents {
+				stack = append(stack, item{hash: p, typ: TypeCommit})
+			}
+		case *Tag:
+			commits = append(commits, it.hash)
+			stack = append(stack, item{hash: o.Object})
+		case *Tree:
+			if !listed[it.hash] {
+				listed[it.hash] = true
+				trees = append(trees, it.hash)
+			}
+			for _, e := range o.Entries {
+				p := path.Join(it.path, e.Name)
+				switch {
+				case e.Mode == ModeTree:
+					stack = append(stack, item{hash: e.Hash, typ: TypeTree, path: p})
+				case e.Mode == ModeGitlink:
+				case filter != nil && !filter(p, e.Mode):
+					hidden[e.Hash] = true
+				default:
+					stack = append(stack, item{hash: e.Hash, typ: TypeBlob, path: p})
+				}
+			}
+		default:
+			blobs = append(blobs, it.hash)
+		}
+	}
+	if len(hidden) > 0 {
+		blobs = slices.DeleteFunc(blobs, func(hash string) bool { return hidden[hash] })
+	}
+	return append(append(commits, trees...), blobs...), nil
+}
+
+// Next: 다음 커밋을 돌려준다. 더 없으면 io.EOF
+func (it *CommitIter) Next() (string, *Commit, error) {
+	if it.order == OrderTopo {
+		return it.nextTopo()
+	}
+	return it.nextDate()
+}
+
+// ForEach: 모든 커밋에 대해 fn 을 호출한다. fn 이 에러를 돌려주면 멈춘다.
+func (it *CommitIter) ForEach(fn func(hash string, commit *Commit) error) error {
+	return it.ForEachContext(context.Background(), fn)
+}
+
+// ForEachContext: ForEach 와 같지만 커밋마다 ctx 를 확인해서 취소되면 ctx.Err() 를 돌려준다.
+func (it *CommitIter) ForEachContext(ctx context.Context, fn func(hash string, commit *Commit) error) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hash, commit, err := it.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(hash, commit); err != nil {
+			return err
+		}
+	}
+}
+
+func (it *CommitIter) push(hash string) error {
+	if it.visited[hash] || it.hidden[hash] {
+		return nil
+	}
+	it.visited[hash] = true
+
+	commit, err := ReadCommit(it.store, hash)
+	if err != nil {
+		return err
+	}
+	it.pushed++
+	heap.Push(&it.queue, hashCommit{hash: hash, commit: commit, seq: it.pushed})
+	return nil
+}
+
+func (it *CommitIter) nextDate() (string, *Commit, error) {
+	for _, start := range it.starts {
+		if err := it.push(start); err != nil {
+			return "", nil, err
+		}
+	}
+	it.starts = nil
+
+	if it.queue.Len() == 0 {
+		return "", nil, io.EOF
+	}
+
+	next := heap.Pop(&it.queue).(hashCommit)
+	for _, parent := range next.commit.Parents {
+		if err := it.push(parent); err != nil {
+			return "", nil, err
+		}
+	}
+	return next.hash, next.commit, nil
+}
+
+func (it *CommitIter) nextTopo() (string, *Commit, error) {
+	if !it.topoOK {
+		sorted, err := topoSort(it.store, it.starts, it.hidden)
+		if err != nil {
+			return "", nil, err
+		}
+		it.sorted = sorted
+		it.topoOK = true
+	}
+
+	if len(it.sorted) == 0 {
+		return "", nil, io.EOF
+	}
+	next := it.sorted[0]
+	it.sorted = it.sorted[1:]
+	return next.hash, next.commit, nil
+}
+
+// 위상 정렬
+// 먼저 도달 가능한 커밋을 모두 읽어 각 커밋의 자식 수를 센 뒤,
+// 자식이 모두 나온 커밋만 스택에 올린다. 스택을 쓰기 때문에 한 갈래를 끝까지 따라간 뒤 다른 갈래로 넘어간다.
+func topoSort(s Storer, starts []string, hidden map[string]bool) ([]hashCommit, error) {
+	commits := map[string]*Commit{}
+	children := map[string]int{}
+
+	pending := append([]string(nil), starts...)
+	for len(pending) > 0 {
+		hash := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if _, ok := commits[hash]; ok || hidden[hash] {
+			continue
+		}
+
+		commit, err := ReadCommit(s, hash)
+		if err != nil {
+			return nil, err
+		}
+		commits[hash] = commit
+		for _, parent := range commit.Parents {
+			if hidden[parent] {
+				continue
+			}
+			children[parent]++
+			pending = append(pending, parent)
+		}
+	}
+
+	var stack []string
+	seen := map[string]bool{}
+	for _, start := range starts {
+		if children[start] == 0 && !seen[start] && !hidden[start] {
+			seen[start] = true
+			stack = append(stack, start)
+		}
+	}
+	// 시작 커밋은 최신 커밋이 스택 맨 위에 오도록 오래된 순으로 쌓는다
+	sort.SliceStable(stack, func(i, j int) bool {
+		return commits[stack[i]].Committer.When.Before(commits[stack[j]].Committer.When)
+	})
+
+	var result []hashCommit
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		commit := commits[hash]
+		result = append(result, hashCommit{hash: hash, commit: commit})
+
+		// git 과 같이 부모 순서대로 쌓으므로 merge 된 쪽 갈래(마지막 부모)가 먼저 나온다
+		for _, parent := range commit.Parents {
+			if hidden[parent] {
+				continue
+			}
+			children[parent]--
+			if children[parent] == 0 && !seen[parent] {
+				seen[parent] = true
+				stack = append(stack, parent)
+			}
+		}
+	}
+	return result, nil
+}
+
+// commitQueue: committer 시간이 최신인 커밋이 먼저 나오는 힙
+type commitQueue []hashCommit
+
+func (q commitQueue) Len() int { return len(q) }
+func (q commitQueue) Less(i, j int) bool {
+	a, b := q[i].commit.Committer.When, q[j].commit.Committer.When
+	if a.Equal(b) {
+		return q[i].seq < q[j].seq
+	}
+	return a.After(b)
+}
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x any)   { *q = append(*q, x.(hashCommit)) }
+func (q *commitQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// WalkEntry: TreeWalker 가 돌려주는 항목. Path 는 최상위 tree 기준 전체 경로
+type WalkEntry struct {
+	Path string
+	Mode Mode
+	Hash string
+}
+
+// TreeWalker: tree 를 깊이 우선으로 재귀 순회한다.
+// 하위 디렉토리 항목은 그 안의 항목들보다 먼저 나온다. submodule(gitlink)은 따라 들어가지 않는다.
+type TreeWalker struct {
+	store Storer
+	stack []walkFrame
+}
+
+type walkFrame struct {
+	prefix  string
+	entries []TreeEntry
+}
+
+// NewTreeWalker: treeHash 를 루트로 순회한다.
+func NewTreeWalker(s Storer, treeHash string) (*TreeWalker, error) {
+	tree, err := ReadTree(s, treeHash)
+	if err != nil {
+		return nil, err
+	}
+	return &TreeWalker{
+		store: s,
+		stack: []walkFrame{{entries: sortedEntries(tree)}},
+	}, nil
+}
+
+// Next: 다음 항목을 돌려준다. 더 없으면 io.EOF
+func (w *TreeWalker) Next() (WalkEntry, error) {
+	for len(w.stack) > 0 {
+		top := &w.stack[len(w.stack)-1]
+		if len(top.entries) == 0 {
+			w.stack = w.stack[:len(w.stack)-1]
+			continue
+		}
+
+		entry := top.entries[0]
+		top.entries = top.entries[1:]
+		walkEntry := WalkEntry{
+			Path: path.Join(top.prefix, entry.Name),
+			Mode: entry.Mode,
+			Hash: entry.Hash,
+		}
+
+		if entry.Mode == ModeTree {
+			subtree, err := ReadTree(w.store, entry.Hash)
+			if err != nil {
+				return WalkEntry{}, err
+			}
+			w.stack = append(w.stack, walkFrame{prefix: walkEntry.Path, entries: sortedEntries(subtree)})
+		}
+		return walkEntry, nil
+	}
+	return WalkEntry{}, io.EOF
+}
+
+// ForEach: 모든 항목에 대해 fn 을 호출한다. fn 이 에러를 돌려주면 멈춘다.
+func (w *TreeWalker) ForEach(fn func(entry WalkEntry) error) error {
+	return w.ForEachContext(context.Background(), fn)
+}
+
+// ForEachContext: ForEach 와 같지만 항목마다 ctx 를 확인해서 취소되면 ctx.Err() 를 돌려준다.
+func (w *TreeWalker) ForEachContext(ctx context.Context, fn func(entry WalkEntry) error) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry, err := w.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
+
+func sortedEntries(tree *Tree) []TreeEntry {
+	entries := append([]TreeEntry(nil), tree.Entries...)
+	SortEntries(entries)
+	return entries
+}
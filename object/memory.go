@@ -0,0 +1,104 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// MemoryStore: 메모리 위의 객체 저장소
+// 압축도 파일도 없이 map 에 저장 포맷을 그대로 보관한다. 테스트나 디스크를 쓰지 않는 서비스용
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+	algo    *Algorithm
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: map[string][]byte{}, algo: SHA1}
+}
+
+// SetAlgorithm: 객체 이름을 만들 해시 함수를 정한다. (기본값 SHA-1) 저장소를 쓰기 전에 한 번만 정해야 한다.
+func (m *MemoryStore) SetAlgorithm(a *Algorithm) {
+	m.algo = a
+}
+
+func (m *MemoryStore) Algorithm() *Algorithm {
+	return m.algo
+}
+
+func (m *MemoryStore) Has(hash string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.objects[hash]
+	return ok
+}
+
+// ForEachHash: 호출하는 동안 저장된 객체들의 해시. fn 안에서 저장소에 써도 된다.
+func (m *MemoryStore) ForEachHash(fn func(hash string) error) error {
+	m.mu.RLock()
+	hashes := make([]string, 0, len(m.objects))
+	for hash := range m.objects {
+		hashes = append(hashes, hash)
+	}
+	m.mu.RUnlock()
+
+	for _, hash := range hashes {
+		if err := fn(hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) ReadRaw(hash string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.objects[hash]
+	if !ok {
+		return nil, notFound(hash)
+	}
+	return data, nil
+}
+
+func (m *MemoryStore) Read(hash string) (Type, []byte, error) {
+	data, err := m.ReadRaw(hash)
+	if err != nil {
+		return "", nil, err
+	}
+	return Parse(data)
+}
+
+func (m *MemoryStore) Write(typ Type, content []byte) (string, error) {
+	data := Format(typ, content)
+	hash := m.algo.Sum(data)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.objects[hash]; !ok {
+		m.objects[hash] = data
+	}
+	return hash, nil
+}
+
+// WriteStream: 어차피 메모리에 보관하므로 내용을 모두 읽은 뒤 Write 한다.
+func (m *MemoryStore) WriteStream(typ Type, size int64, r io.Reader) (string, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if int64(len(content)) != size {
+		return "", fmt.Errorf("object size mismatch: expected %d bytes, read %d", size, len(content))
+	}
+	return m.Write(typ, content)
+}
+
+func (m *MemoryStore) Open(hash string) (*ObjectReader, error) {
+	typ, content, err := m.Read(hash)
+	if err != nil {
+		return nil, err
+	}
+	return NewObjectReader(typ, int64(len(content)), bytes.NewReader(content), nil), nil
+}
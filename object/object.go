@@ -0,0 +1,85 @@
+// Package object 는 git 객체(blob, tree, commit, tag)의 포맷과 저장소를 다룬다.
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// header 를 제외한 컨텐츠를 구분하기 위해서는 구분자가 필요함
+const NUL = "\000"
+
+// Type: 객체의 종류
+type Type string
+
+const (
+	TypeBlob   Type = "blob"
+	TypeTree   Type = "tree"
+	TypeCommit Type = "commit"
+	TypeTag    Type = "tag"
+)
+
+// Format: 저장 포맷 생성
+// Git 은 객체의 종류(blob, tree, commit)와 크기를 헤더에 명시함.
+// 이 Header 를 통해 나중에 어디까지 읽어야 할지(offset) 을 알 수 있다.
+func Format(typ Type, content []byte) []byte {
+	header := fmt.Sprintf("%s %d%s", typ, len(content), NUL)
+	return append([]byte(header), content...)
+}
+
+// Hash: Checksum 계산 (SHA-1 Hashing)
+// Hash 함수기 때문에 content 가 바뀌지 않는다면 동일한 해시값이 생성됨.
+// data 는 헤더가 포함된 저장 포맷이어야 한다.
+// SHA-256 저장소도 다루는 코드는 저장소의 Algorithm().Sum 을 쓴다.
+func Hash(data []byte) string {
+	return SHA1.Sum(data)
+}
+
+// IsHash: 객체 이름이 될 수 있는 16진수 문자열인지 (SHA-1 의 40자리 또는 SHA-256 의 64자리)
+// 어느 저장소의 것인지까지 따지려면 Algorithm.IsHash 를 쓴다.
+func IsHash(s string) bool {
+	return SHA1.IsHash(s) || SHA256.IsHash(s)
+}
+
+// AlgorithmOf: 해시 길이로 알아낸 해시 함수. 객체 이름이 아니면 nil
+func AlgorithmOf(hash string) *Algorithm {
+	for _, a := range []*Algorithm{SHA1, SHA256} {
+		if a.IsHash(hash) {
+			return a
+		}
+	}
+	return nil
+}
+
+// Split: 저장 포맷을 헤더와 페이로드로 분리
+func Split(data []byte) (header []byte, payload []byte, err error) {
+	nullIndex := bytes.IndexByte(data, 0)
+	if nullIndex == -1 {
+		return nil, nil, invalidf("missing header")
+	}
+	return data[:nullIndex], data[nullIndex+1:], nil
+}
+
+// Parse: 저장 포맷에서 타입과 페이로드를 꺼낸다.
+// 헤더에 적힌 크기와 실제 페이로드 크기가 다르면 손상된 객체로 본다.
+func Parse(data []byte) (Type, []byte, error) {
+	header, payload, err := Split(data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	typ, sizeStr, ok := bytes.Cut(header, []byte(" "))
+	if !ok {
+		return "", nil, invalidf("bad header %q", header)
+	}
+	size, err := strconv.Atoi(string(sizeStr))
+	if err != nil {
+		return "", nil, invalidf("bad size %q", sizeStr)
+	}
+	if size != len(payload) {
+		return "", nil, invalidf("size mismatch: header says %d, got %d", size, len(payload))
+	}
+
+	return Type(typ), payload, nil
+}
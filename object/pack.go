@@ -0,0 +1,576 @@
+package object
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// packfile (objects/pack/pack-<checksum>.pack 와 .idx)
+//
+// git 과 같은 version 2 형식이다. 쓸 때는 객체를 delta 없이 통째로 압축해서 넣지만,
+// 읽을 때는 git 이 만든 pack 의 delta(OFS_DELTA, REF_DELTA)도 풀어서 읽는다.
+const packDir = "pack"
+
+// pack 안의 객체 종류 번호
+const (
+	packCommit   = 1
+	packTree     = 2
+	packBlob     = 3
+	packTag      = 4
+	packOfsDelta = 6
+	packRefDelta = 7
+)
+
+var packTypes = map[Type]byte{TypeCommit: packCommit, TypeTree: packTree, TypeBlob: packBlob, TypeTag: packTag}
+
+// idxMagic: version 2 index 파일의 시작 ("\377tOc")
+var idxMagic = []byte{0xff, 't', 'O', 'c'}
+
+// PackFile: 저장소에 있는 pack 하나
+type PackFile struct {
+	// Name: "pack-<checksum>" (확장자 제외)
+	Name    string
+	ModTime time.Time
+	// Hashes: pack 에 든 객체들 (해시 순서)
+	Hashes []string
+}
+
+// pack: 읽기 위해 index 를 메모리에 올려 둔 pack
+type pack struct {
+	name    string
+	modTime time.Time
+	hashes  []string
+	offsets []int64
+}
+
+func (p *pack) find(hash string) (int64, bool) {
+	i := sort.SearchStrings(p.hashes, hash)
+	if i < len(p.hashes) && p.hashes[i] == hash {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+// loadPacks: pack 디렉토리의 index 들을 읽는다. 디렉토리의 수정 시각이 그대로면 전에 읽은 것을 쓰고,
+// 바뀌었으면 (다른 프로세스가 gc 를 했으면) 다시 읽는다.
+// force 면 수정 시각과 상관없이 다시 읽는다. (이 프로세스가 pack 을 쓰거나 지운 뒤)
+func (s *Store) loadPacks(force bool) ([]*pack, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := s.fs.Stat(packDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.packs, s.packsLoaded = nil, true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.packsLoaded && !force && info.ModTime().Equal(s.packsMod) {
+		return s.packs, nil
+	}
+
+	entries, err := s.fs.ReadDir(packDir)
+	if err != nil {
+		return nil, err
+	}
+	var packs []*pack
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".idx")
+		if !ok || !vfs.Exists(s.fs, packDir+"/"+name+".pack") {
+			continue
+		}
+		p, err := s.readPackIndex(name)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s.idx: %w", name, err)
+		}
+		packs = append(packs, p)
+	}
+	s.packs, s.packsMod, s.packsLoaded = packs, info.ModTime(), true
+	return packs, nil
+}
+
+// findPacked: hash 가 든 pack 과 그 안의 위치
+func (s *Store) findPacked(hash string) (*pack, int64, bool) {
+	packs, err := s.loadPacks(false)
+	if err != nil {
+		return nil, 0, false
+	}
+	for _, p := range packs {
+		if off, ok := p.find(hash); ok {
+			return p, off, true
+		}
+	}
+	return nil, 0, false
+}
+
+func (s *Store) readPackIndex(name string) (*pack, error) {
+	data, err := vfs.ReadFile(s.fs, packDir+"/"+name+".idx")
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.fs.Stat(packDir + "/" + name + ".pack")
+	if err != nil {
+		return nil, err
+	}
+	const header = 8 + 256*4
+	if len(data) < header || !bytes.Equal(data[:4], idxMagic) || binary.BigEndian.Uint32(data[4:8]) != 2 {
+		return nil, invalidf("unsupported pack index")
+	}
+	// 이름과 끝의 checksum 두 개는 저장소의 해시 길이다 (git 의 SHA-256 저장소도 같은 version 2 형식)
+	size := s.algo.Size
+	n := int(binary.BigEndian.Uint32(data[header-4 : header]))
+	names := header
+	crcs := names + n*size
+	offsets := crcs + n*4
+	large := offsets + n*4
+	if len(data) < large+2*size {
+		return nil, invalidf("truncated pack index")
+	}
+
+	p := &pack{name: name, modTime: info.ModTime(), hashes: make([]string, n), offsets: make([]int64, n)}
+	for i := 0; i < n; i++ {
+		p.hashes[i] = hex.EncodeToString(data[names+i*size : names+(i+1)*size])
+		off := binary.BigEndian.Uint32(data[offsets+i*4:])
+		if off&0x80000000 == 0 {
+			p.offsets[i] = int64(off)
+			continue
+		}
+		// 2GB 를 넘는 위치는 뒤쪽의 8바이트 표에 있다
+		j := large + int(off&0x7fffffff)*8
+		if j+8 > len(data)-2*size {
+			return nil, invalidf("bad large offset in pack index")
+		}
+		p.offsets[i] = int64(binary.BigEndian.Uint64(data[j:]))
+	}
+	return p, nil
+}
+
+// readPacked: pack 의 offset 에 있는 객체를 읽는다. delta 면 base 를 찾아 적용한다.
+func (s *Store) readPacked(p *pack, offset int64) (Type, []byte, error) {
+	f, err := s.fs.Open(packDir + "/" + p.name + ".pack")
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	return s.readPackedAt(f, p, offset, 0)
+}
+
+// maxDeltaDepth: delta 가 이보다 깊게 이어지면 손상된 pack 으로 본다.
+const maxDeltaDepth = 10000
+
+func (s *Store) readPackedAt(f vfs.File, p *pack, offset int64, depth int) (Type, []byte, error) {
+	if depth > maxDeltaDepth {
+		return "", nil, invalidf("delta chain too deep in %s", p.name)
+	}
+	r, err := packReaderAt(f, offset)
+	if err != nil {
+		return "", nil, err
+	}
+
+	c, err := r.ReadByte()
+	if err != nil {
+		return "", nil, err
+	}
+	kind := (c >> 4) & 7
+	size := int64(c & 0x0f)
+	for shift := 4; c&0x80 != 0; shift += 7 {
+		if c, err = r.ReadByte(); err != nil {
+			return "", nil, err
+		}
+		size |= int64(c&0x7f) << shift
+	}
+
+	var baseType Type
+	var base []byte
+	switch kind {
+	case packOfsDelta:
+		// base 는 같은 pack 의 앞쪽에 있다. 거리는 바이트마다 1 을 더해 가며 인코딩된다
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", nil, err
+		}
+		dist := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = r.ReadByte(); err != nil {
+				return "", nil, err
+			}
+			dist = (dist+1)<<7 | int64(c&0x7f)
+		}
+		// 같은 파일을 다른 위치에서 다시 읽어야 하므로 새로 연다
+		g, err := s.fs.Open(packDir + "/" + p.name + ".pack")
+		if err != nil {
+			return "", nil, err
+		}
+		baseType, base, err = s.readPackedAt(g, p, offset-dist, depth+1)
+		g.Close()
+		if err != nil {
+			return "", nil, err
+		}
+	case packRefDelta:
+		raw := make([]byte, s.algo.Size)
+		if _, err := io.ReadFull(r, raw); err != nil {
+			return "", nil, err
+		}
+		baseHash := hex.EncodeToString(raw)
+		if baseOffset, ok := p.find(baseHash); ok {
+			// 같은 pack 안의 base 는 그 위치에서 바로 읽는다 (index 를 만드는 중인 pack 도 이 경로를 탄다)
+			g, err := s.fs.Open(packDir + "/" + p.name + ".pack")
+			if err != nil {
+				return "", nil, err
+			}
+			baseType, base, err = s.readPackedAt(g, p, baseOffset, depth+1)
+			g.Close()
+			if err != nil {
+				return "", nil, err
+			}
+		} else if baseType, base, err = s.Read(baseHash); err != nil {
+			return "", nil, err
+		}
+	}
+
+	zr, err := getZlibReader(r)
+	if err != nil {
+		return "", nil, err
+	}
+	defer putZlibReader(zr)
+	data, err := io.ReadAll(io.LimitReader(zr, size))
+	if err != nil {
+		return "", nil, err
+	}
+	if int64(len(data)) != size {
+		return "", nil, invalidf("truncated object in %s at %d", p.name, offset)
+	}
+
+	switch kind {
+	case packCommit:
+		return TypeCommit, data, nil
+	case packTree:
+		return TypeTree, data, nil
+	case packBlob:
+		return TypeBlob, data, nil
+	case packTag:
+		return TypeTag, data, nil
+	case packOfsDelta, packRefDelta:
+		result, err := ApplyDelta(base, data)
+		return baseType, result, err
+	}
+	return "", nil, invalidf("unknown object type %d in %s at %d", kind, p.name, offset)
+}
+
+// packReaderAt: pack 파일의 offset 부터 읽는 reader
+// 파일이 io.ReaderAt 이면 바로 그 위치부터 읽고, 아니면 앞부분을 읽어 버린다.
+func packReaderAt(f vfs.File, offset int64) (*bufio.Reader, error) {
+	if ra, ok := f.(io.ReaderAt); ok {
+		return bufio.NewReader(io.NewSectionReader(ra, offset, 1<<62)), nil
+	}
+	if _, err := io.CopyN(io.Discard, f, offset); err != nil {
+		return nil, err
+	}
+	return bufio.NewReader(f), nil
+}
+
+// ApplyDelta: git 의 delta(base 에서 복사하거나 새 바이트를 넣는 명령들)를 적용한다. pack 과 binary patch 가 같은 형식을 쓴다.
+func ApplyDelta(base, delta []byte) ([]byte, error) {
+	readSize := func() (int, error) {
+		size, shift := 0, 0
+		for {
+			if len(delta) == 0 {
+				return 0, invalidf("truncated delta")
+			}
+			c := delta[0]
+			delta = delta[1:]
+			size |= int(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				return size, nil
+			}
+		}
+	}
+	baseSize, err := readSize()
+	if err != nil {
+		return nil, err
+	}
+	if baseSize != len(base) {
+		return nil, invalidf("delta base size mismatch")
+	}
+	resultSize, err := readSize()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, resultSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		if op&0x80 == 0 {
+			// 새 바이트 op 개
+			if op == 0 || int(op) > len(delta) {
+				return nil, invalidf("bad delta insert")
+			}
+			out = append(out, delta[:op]...)
+			delta = delta[op:]
+			continue
+		}
+		// base 에서 복사: 위치 4바이트와 길이 3바이트 중 있는 것만 들어 있다
+		var off, n int
+		for i := 0; i < 7; i++ {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			if len(delta) == 0 {
+				return nil, invalidf("truncated delta")
+			}
+			if i < 4 {
+				off |= int(delta[0]) << (8 * i)
+			} else {
+				n |= int(delta[0]) << (8 * (i - 4))
+			}
+			delta = delta[1:]
+		}
+		if n == 0 {
+			n = 0x10000
+		}
+		if off+n > len(base) {
+			return nil, invalidf("delta copy out of range")
+		}
+		out = append(out, base[off:off+n]...)
+	}
+	if len(out) != resultSize {
+		return nil, invalidf("delta result size mismatch")
+	}
+	return out, nil
+}
+
+// Packs: 저장소에 있는 pack 들
+func (s *Store) Packs() ([]PackFile, error) {
+	packs, err := s.loadPacks(true)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]PackFile, len(packs))
+	for i, p := range packs {
+		result[i] = PackFile{Name: p.name, ModTime: p.modTime, Hashes: p.hashes}
+	}
+	return result, nil
+}
+
+// RemovePack: pack 과 그 index 를 지운다. index 를 먼저 지워서 읽는 쪽이 반쪽짜리 pack 을 보지 않게 한다.
+func (s *Store) RemovePack(name string) error {
+	if err := s.fs.Remove(packDir + "/" + name + ".idx"); err != nil {
+		return err
+	}
+	if err := s.fs.Remove(packDir + "/" + name + ".pack"); err != nil {
+		return err
+	}
+	_, err := s.loadPacks(true)
+	return err
+}
+
+// packEntry: index 에 들어갈 객체 하나
+type packEntry struct {
+	hash   []byte
+	offset int64
+	crc    uint32
+}
+
+// WritePack: hashes 의 객체들을 pack 하나로 묶어 objects/pack 에 쓰고 이름("pack-<checksum>")을 돌려준다.
+// 객체는 hashes 순서대로 delta 없이 들어간다. 임시 파일에 다 쓴 뒤 .pack, .idx 순서로 rename 하므로
+// 읽는 쪽은 완성된 pack 만 본다. 같은 pack 이 이미 있으면 그대로 둔다.
+func (s *Store) WritePack(hashes []string) (string, error) {
+	if err := s.fs.MkdirAll(packDir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := tempName()
+	if err != nil {
+		return "", err
+	}
+	tmpPack, tmpIdx := packDir+"/"+tmp+".pack", packDir+"/"+tmp+".idx"
+	defer s.fs.Remove(tmpPack)
+	defer s.fs.Remove(tmpIdx)
+
+	f, err := s.fs.Create(tmpPack)
+	if err != nil {
+		return "", err
+	}
+	entries, checksum, err := writePackData(f, s, hashes)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	f, err = s.fs.Create(tmpIdx)
+	if err != nil {
+		return "", err
+	}
+	err = writePackIndex(f, entries, checksum, s.algo)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return s.installPack(tmpPack, tmpIdx, checksum)
+}
+
+// installPack: 다 쓴 임시 pack 과 index 를 "pack-<checksum>" 이름으로 옮긴다. .pack 을 먼저 옮겨서
+// 읽는 쪽(index 가 있어야 pack 을 본다)이 반쪽짜리 pack 을 보지 않게 한다. 같은 pack 이 이미 있으면 그대로 둔다.
+func (s *Store) installPack(tmpPack, tmpIdx string, checksum []byte) (string, error) {
+	name := "pack-" + hex.EncodeToString(checksum)
+	if !vfs.Exists(s.fs, packDir+"/"+name+".idx") {
+		if err := s.fs.Rename(tmpPack, packDir+"/"+name+".pack"); err != nil {
+			return "", err
+		}
+		if err := s.fs.Rename(tmpIdx, packDir+"/"+name+".idx"); err != nil {
+			return "", err
+		}
+	}
+	if _, err := s.loadPacks(true); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// EncodePack: hashes 의 객체들을 WritePack 과 같은 형식의 pack 으로 w 에 쓰고 pack 의 checksum 을 돌려준다.
+// index 는 만들지 않는다. (bundle 처럼 pack 을 다른 파일 안에 넣을 때)
+func EncodePack(w io.Writer, s Storer, hashes []string) ([]byte, error) {
+	_, checksum, err := writePackData(w, s, hashes)
+	return checksum, err
+}
+
+// writePackData: pack 본문. 객체마다 위치와 CRC32 를 기록하고, 끝에 전체의 해시(저장소의 해시 함수)를 붙인다.
+func writePackData(w io.Writer, s Storer, hashes []string) ([]packEntry, []byte, error) {
+	sum := s.Algorithm().New()
+	cw := &countWriter{w: io.MultiWriter(w, sum)}
+
+	var header [12]byte
+	copy(header[:], "PACK")
+	binary.BigEndian.PutUint32(header[4:], 2)
+	binary.BigEndian.PutUint32(header[8:], uint32(len(hashes)))
+	if _, err := cw.Write(header[:]); err != nil {
+		return nil, nil, err
+	}
+
+	entries := make([]packEntry, 0, len(hashes))
+	for _, hash := range hashes {
+		typ, content, err := s.Read(hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		obj, err := encodePackObject(hash, typ, content)
+		if err != nil {
+			return nil, nil, err
+		}
+		raw, err := hex.DecodeString(hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, packEntry{hash: raw, offset: cw.n, crc: crc32.ChecksumIEEE(obj)})
+		if _, err := cw.Write(obj); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	checksum := sum.Sum(nil)
+	if _, err := w.Write(checksum); err != nil {
+		return nil, nil, err
+	}
+	return entries, checksum, nil
+}
+
+// encodePackObject: delta 없이 통째로 압축한 pack 항목 하나 (종류와 크기 헤더 + zlib)
+func encodePackObject(hash string, typ Type, content []byte) ([]byte, error) {
+	kind, ok := packTypes[typ]
+	if !ok {
+		return nil, invalidf("cannot pack object %s of type %s", hash, typ)
+	}
+	var obj bytes.Buffer
+	size := len(content)
+	c := kind<<4 | byte(size&0x0f)
+	size >>= 4
+	for size > 0 {
+		obj.WriteByte(c | 0x80)
+		c = byte(size & 0x7f)
+		size >>= 7
+	}
+	obj.WriteByte(c)
+	zw := getZlibWriter(&obj)
+	defer putZlibWriter(zw)
+	if _, err := zw.Write(content); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return obj.Bytes(), nil
+}
+
+// writePackIndex: version 2 index. 해시 순서로 이름, CRC32, 위치를 쓰고 pack 과 index 자신의 해시(a)로 끝난다.
+func writePackIndex(w io.Writer, entries []packEntry, packChecksum []byte, a *Algorithm) error {
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].hash, entries[j].hash) < 0 })
+
+	sum := a.New()
+	bw := bufio.NewWriter(io.MultiWriter(w, sum))
+	bw.Write(idxMagic)
+	binary.Write(bw, binary.BigEndian, uint32(2))
+
+	var fanout [256]uint32
+	for _, e := range entries {
+		fanout[e.hash[0]]++
+	}
+	for i := 1; i < 256; i++ {
+		fanout[i] += fanout[i-1]
+	}
+	binary.Write(bw, binary.BigEndian, fanout)
+	for _, e := range entries {
+		bw.Write(e.hash)
+	}
+	for _, e := range entries {
+		binary.Write(bw, binary.BigEndian, e.crc)
+	}
+	var large []int64
+	for _, e := range entries {
+		if e.offset < 0x80000000 {
+			binary.Write(bw, binary.BigEndian, uint32(e.offset))
+			continue
+		}
+		binary.Write(bw, binary.BigEndian, uint32(0x80000000|len(large)))
+		large = append(large, e.offset)
+	}
+	for _, off := range large {
+		binary.Write(bw, binary.BigEndian, uint64(off))
+	}
+	bw.Write(packChecksum)
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	_, err := w.Write(sum.Sum(nil))
+	return err
+}
+
+// countWriter: 지금까지 쓴 바이트 수를 센다. (객체의 pack 안 위치)
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,286 @@
+package object
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// Store: 객체 저장소 (.gogit/objects)
+// 새 객체는 loose 객체(objects/xx/yyyy...)로 쓰고, 읽을 때는 loose 객체가 없으면 packfile(objects/pack)에서 찾는다.
+//
+// 동시성: 객체는 내용으로 주소가 정해지고 한 번 쓰이면 바뀌지 않으므로 읽기에는 잠금이 없다.
+// 쓰기는 임시 파일 + rename 으로 원자적으로 반영된다. 같은 객체를 동시에 써도 결과는 같다.
+// 읽어 둔 pack index 목록만 잠금으로 보호한다.
+//
+// 읽은 객체는 기본적으로 다시 해시해서 이름과 맞는지 확인한다. 망가진 객체가 새 tree 나 pack 으로 조용히 옮겨지지 않게 하기 위해서다.
+type Store struct {
+	fs vfs.Filesystem
+	// noVerify: 읽을 때 해시를 확인하지 않는다 (SetVerify)
+	noVerify bool
+	// onWrite: 새 loose 객체를 만들 때마다 불린다 (OnWrite)
+	onWrite func(hash string, typ Type)
+	// algo: 객체 이름을 만드는 해시 함수 (SetAlgorithm)
+	algo *Algorithm
+
+	mu          sync.Mutex
+	packs       []*pack
+	packsMod    time.Time
+	packsLoaded bool
+}
+
+// NewStore: fs 의 루트는 objects 디렉토리여야 한다.
+func NewStore(fs vfs.Filesystem) *Store {
+	return &Store{fs: fs, algo: SHA1}
+}
+
+// SetAlgorithm: 객체 이름을 만들 해시 함수를 정한다. (기본값 SHA-1)
+// loose 객체, pack, pack index 모두 이 해시 함수를 쓴다. 저장소를 쓰기 전에 한 번만 정해야 한다.
+func (s *Store) SetAlgorithm(a *Algorithm) {
+	s.algo = a
+}
+
+// Algorithm: 객체 이름을 만드는 해시 함수
+func (s *Store) Algorithm() *Algorithm {
+	return s.algo
+}
+
+// SetVerify: 읽을 때 내용을 다시 해시해서 확인할지 정한다. (기본값 true)
+// 저장소를 쓰기 전에 한 번만 정해야 한다.
+func (s *Store) SetVerify(verify bool) {
+	s.noVerify = !verify
+}
+
+// OnWrite: 새 loose 객체 파일을 만들 때마다 fn 을 부른다. 이미 있던 객체를 다시 쓰는 것은 알리지 않는다.
+// 여러 goroutine 에서 동시에 불릴 수 있다. 저장소를 쓰기 전에 한 번만 정해야 한다.
+func (s *Store) OnWrite(fn func(hash string, typ Type)) {
+	s.onWrite = fn
+}
+
+// written: onWrite 에 새 객체를 알린다.
+func (s *Store) written(hash string, typ Type) {
+	if s.onWrite != nil {
+		s.onWrite(hash, typ)
+	}
+}
+
+// 2글자로 하는 이유는 적당하게 디렉토리를 생성하기 위해서 hash 당 dir 이 생기면 너무 많아지기 때문
+func (s *Store) path(hash string) string {
+	return path.Join(hash[:2], hash[2:])
+}
+
+// Has: 객체 존재 여부
+func (s *Store) Has(hash string) bool {
+	if !s.algo.IsHash(hash) {
+		return false
+	}
+	if vfs.Exists(s.fs, s.path(hash)) {
+		return true
+	}
+	_, _, ok := s.findPacked(hash)
+	return ok
+}
+
+// ForEachHash: pack 에 든 객체와 loose 객체를 모두 훑는다. 양쪽에 다 있는 객체는 한 번만 나온다.
+func (s *Store) ForEachHash(fn func(hash string) error) error {
+	packed := map[string]bool{}
+	packs, err := s.loadPacks(false)
+	if err != nil {
+		return err
+	}
+	for _, p := range packs {
+		for _, hash := range p.hashes {
+			if packed[hash] {
+				continue
+			}
+			packed[hash] = true
+			if err := fn(hash); err != nil {
+				return err
+			}
+		}
+	}
+	return s.ForEachLoose(func(hash string, _ time.Time) error {
+		if packed[hash] {
+			return nil
+		}
+		return fn(hash)
+	})
+}
+
+// ForEachLoose: objects/xx/yyyy... 파일을 모두 훑는다. 쓰는 중인 임시 파일은 건너뛴다.
+// modTime 은 파일의 수정 시각이다. (gc 가 오래된 객체만 지울 때 쓴다)
+func (s *Store) ForEachLoose(fn func(hash string, modTime time.Time) error) error {
+	dirs, err := s.fs.ReadDir(".")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() || len(dir.Name()) != 2 {
+			continue
+		}
+		files, err := s.fs.ReadDir(dir.Name())
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			hash := dir.Name() + f.Name()
+			if f.IsDir() || !s.algo.IsHash(hash) {
+				continue
+			}
+			info, err := f.Info()
+			if errors.Is(err, fs.ErrNotExist) {
+				// 그사이 다른 프로세스가 지웠다
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := fn(hash, info.ModTime()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RemoveLoose: loose 객체 파일을 지운다. pack 에 든 같은 객체는 그대로다.
+func (s *Store) RemoveLoose(hash string) error {
+	if !s.algo.IsHash(hash) {
+		return notFound(hash)
+	}
+	return s.fs.Remove(s.path(hash))
+}
+
+// freshen: 이미 있는 loose 객체의 수정 시각을 지금으로 바꾼다. 객체가 없으면 false
+// gc 와 prune 은 도달할 수 없는 객체를 수정 시각으로 고르므로, 새로 쓰는 tree 가 다시 쓴 오래된 객체가
+// 방금 쓴 것처럼 보여야 지워지지 않는다. (git 의 freshen) 시각을 바꿀 수 없으면 다시 쓰도록 false 를 돌려준다.
+func (s *Store) freshen(name string) bool {
+	if !vfs.Exists(s.fs, name) {
+		return false
+	}
+	now := time.Now()
+	err := vfs.Chtimes(s.fs, name, now, now)
+	return err == nil || errors.Is(err, errors.ErrUnsupported)
+}
+
+// Write: 객체를 저장하고 해시를 돌려준다.
+func (s *Store) Write(typ Type, content []byte) (string, error) {
+	data := Format(typ, content)
+	hash := s.algo.Sum(data)
+	if err := s.WriteRaw(hash, data); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// WriteRaw: 이미 헤더가 붙은 저장 포맷을 hash 위치에 저장
+// 해시값을 이용하여 경로를 생성하고, 내용은 zlib 으로 압축하여 저장
+// 임시 파일에 다 쓴 뒤 rename 하므로 읽는 쪽은 잠금 없이도 반쯤 쓰인 객체를 보지 않는다.
+func (s *Store) WriteRaw(hash string, data []byte) error {
+	fullPath := s.path(hash)
+
+	// 이미 존재하는 객체라면 덮어쓰지 않고 수정 시각만 새로 한다
+	if s.freshen(fullPath) {
+		return nil
+	}
+
+	tmpName, err := tempName()
+	if err != nil {
+		return err
+	}
+	f, err := s.fs.Create(tmpName)
+	if err != nil {
+		return err
+	}
+	defer s.fs.Remove(tmpName)
+
+	zw := getZlibWriter(f)
+	defer putZlibWriter(zw)
+	if _, err := zw.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := s.fs.MkdirAll(path.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+	if err := s.fs.Rename(tmpName, fullPath); err != nil {
+		return err
+	}
+	if s.onWrite != nil {
+		typ, _, _ := bytes.Cut(data, []byte(" "))
+		s.written(hash, Type(typ))
+	}
+	return nil
+}
+
+// ReadRaw: 압축을 푼 저장 포맷(헤더 + 페이로드)을 그대로 돌려준다.
+// 해시가 이름과 다르면 ErrCorrupt 를 돌려준다. (SetVerify(false) 가 아니면)
+func (s *Store) ReadRaw(hash string) ([]byte, error) {
+	data, err := s.readRaw(hash)
+	if err != nil {
+		return nil, err
+	}
+	if !s.noVerify {
+		if actual := s.algo.Sum(data); actual != hash {
+			return nil, corrupt(hash, actual)
+		}
+	}
+	return data, nil
+}
+
+func (s *Store) readRaw(hash string) ([]byte, error) {
+	if !s.algo.IsHash(hash) {
+		return nil, notFound(hash)
+	}
+
+	f, err := s.fs.Open(s.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		p, offset, ok := s.findPacked(hash)
+		if !ok {
+			return nil, notFound(hash)
+		}
+		typ, content, err := s.readPacked(p, offset)
+		if err != nil {
+			return nil, err
+		}
+		return Format(typ, content), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := getZlibReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer putZlibReader(zr)
+
+	return io.ReadAll(zr)
+}
+
+// Read: 객체의 타입과 페이로드를 읽는다.
+func (s *Store) Read(hash string) (Type, []byte, error) {
+	data, err := s.ReadRaw(hash)
+	if err != nil {
+		return "", nil, err
+	}
+	return Parse(data)
+}
@@ -0,0 +1,99 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// 여러 goroutine 이 같은 객체와 다른 객체를 동시에 쓰고 읽는다. go test -race 로 돌려야 의미가 있다.
+func TestStoreConcurrentWriteRead(t *testing.T) {
+	s := NewStore(vfs.NewOS(t.TempDir()))
+	const workers, objects = 16, 50
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < objects; i++ {
+				// 절반은 모든 goroutine 이 같은 내용을 쓰고, 나머지는 goroutine 마다 다르다
+				shared := &Blob{Data: []byte(fmt.Sprintf("shared %d\n", i))}
+				own := &Blob{Data: []byte(fmt.Sprintf("worker %d object %d\n", w, i))}
+				for _, blob := range []*Blob{shared, own} {
+					hash, err := WriteObject(s, blob)
+					if err != nil {
+						errs <- err
+						return
+					}
+					obj, err := ReadObject(s, hash)
+					if err != nil {
+						errs <- err
+						return
+					}
+					if got := string(obj.(*Blob).Data); got != string(blob.Data) {
+						errs <- fmt.Errorf("read %s = %q, want %q", hash, got, blob.Data)
+						return
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	hashes, err := AllHashes(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := objects + workers*objects; len(hashes) != want {
+		t.Errorf("store has %d objects, want %d", len(hashes), want)
+	}
+}
+
+// 이미 있는 객체를 다시 쓰면 수정 시각을 새로 한다. gc 가 오래된 객체로 보고 지우지 않도록
+func TestWriteFreshensExistingObject(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(vfs.NewOS(dir))
+	content := []byte("reused\n")
+	hash, err := s.Write(TypeBlob, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(dir, s.path(hash))
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	for _, write := range []struct {
+		name string
+		fn   func() error
+	}{
+		{"Write", func() error { _, err := s.Write(TypeBlob, content); return err }},
+		{"WriteStream", func() error {
+			_, err := s.WriteStream(TypeBlob, int64(len(content)), bytes.NewReader(content))
+			return err
+		}},
+	} {
+		if err := os.Chtimes(name, old, old); err != nil {
+			t.Fatal(err)
+		}
+		if err := write.fn(); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().After(old.Add(time.Hour)) {
+			t.Errorf("%s left the mtime at %v", write.name, info.ModTime())
+		}
+	}
+}
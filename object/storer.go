@@ -0,0 +1,116 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Storer: 객체 저장소 인터페이스
+// 디스크(Store)와 메모리(MemoryStore) 구현이 있으며, 다른 저장 장치도 이 인터페이스만 맞추면 된다.
+type Storer interface {
+	Has(hash string) bool
+	// Read: 객체의 타입과 페이로드
+	Read(hash string) (Type, []byte, error)
+	// ReadRaw: 헤더가 포함된 저장 포맷
+	ReadRaw(hash string) ([]byte, error)
+	Write(typ Type, content []byte) (string, error)
+	WriteStream(typ Type, size int64, r io.Reader) (string, error)
+	Open(hash string) (*ObjectReader, error)
+	// Algorithm: 객체 이름을 만드는 해시 함수
+	Algorithm() *Algorithm
+}
+
+var (
+	_ Storer = (*Store)(nil)
+	_ Storer = (*MemoryStore)(nil)
+	_ Lister = (*Store)(nil)
+	_ Lister = (*MemoryStore)(nil)
+)
+
+// Lister: 저장된 모든 객체를 나열할 수 있는 저장소가 구현한다. (Store, MemoryStore)
+// 도달 가능성과 상관없이 저장소 안의 객체를 훑어볼 때(cat-file --batch-all-objects) 쓴다.
+type Lister interface {
+	// ForEachHash: 모든 객체의 해시로 fn 을 호출한다. 순서는 정해져 있지 않다.
+	ForEachHash(fn func(hash string) error) error
+}
+
+// AllHashes: 저장소의 모든 객체 해시를 정렬해서 돌려준다. 나열을 지원하지 않는 저장소면 errors.ErrUnsupported
+func AllHashes(s Storer) ([]string, error) {
+	l, ok := s.(Lister)
+	if !ok {
+		return nil, fmt.Errorf("listing objects: %w", errors.ErrUnsupported)
+	}
+	var hashes []string
+	err := l.ForEachHash(func(hash string) error {
+		hashes = append(hashes, hash)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(hashes)
+	return hashes, nil
+}
+
+// ReadObject: 객체를 읽어 타입에 맞는 구조체로 돌려준다.
+func ReadObject(s Storer, hash string) (Object, error) {
+	typ, content, err := s.Read(hash)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeAlgorithm(typ, content, s.Algorithm())
+}
+
+// ReadCommit: commit 객체를 읽는다. 다른 타입이면 에러
+func ReadCommit(s Storer, hash string) (*Commit, error) {
+	obj, err := ReadObject(s, hash)
+	if err != nil {
+		return nil, err
+	}
+	commit, ok := obj.(*Commit)
+	if !ok {
+		return nil, wrongType(hash, obj.Type(), TypeCommit)
+	}
+	return commit, nil
+}
+
+// ReadTree: tree 객체를 읽는다. 다른 타입이면 에러
+func ReadTree(s Storer, hash string) (*Tree, error) {
+	obj, err := ReadObject(s, hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, ok := obj.(*Tree)
+	if !ok {
+		return nil, wrongType(hash, obj.Type(), TypeTree)
+	}
+	return tree, nil
+}
+
+// WriteStreamContext: ctx 가 취소되면 중간에 멈추는 WriteStream
+// 멈춘 경우 저장소 구현이 읽기 에러를 처리하듯 임시 데이터를 정리하고 ctx.Err() 를 돌려준다.
+func WriteStreamContext(ctx context.Context, s Storer, typ Type, size int64, r io.Reader) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return s.WriteStream(typ, size, contextReader{ctx: ctx, r: r})
+}
+
+// WriteObject: 구조체를 인코딩해서 저장한다. tree 는 저장하기 전에 Validate 로 검사하고,
+// 항목의 해시가 저장소의 해시 함수와 길이가 다르면 거부한다.
+func WriteObject(s Storer, obj Object) (string, error) {
+	if t, ok := obj.(*Tree); ok {
+		if err := t.Validate(); err != nil {
+			return "", err
+		}
+		for _, e := range t.Entries {
+			if !s.Algorithm().IsHash(e.Hash) {
+				return "", invalidf("tree entry %q has a hash %q that is not %s", e.Name, e.Hash, s.Algorithm().Name)
+			}
+		}
+	}
+	return s.Write(obj.Type(), obj.Encode())
+}
@@ -0,0 +1,301 @@
+package object
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// WriteStream: 내용을 메모리에 올리지 않고 객체를 저장한다.
+// size 는 헤더에 들어가야 하므로 미리 알아야 한다. (파일이라면 Stat 으로 얻을 수 있다)
+// 해시는 내용을 다 읽어야 알 수 있기 때문에 임시 파일에 압축하며 쓰고,
+// 동시에 해시를 계산한 뒤 마지막에 최종 경로로 rename 한다.
+func (s *Store) WriteStream(typ Type, size int64, r io.Reader) (string, error) {
+	tmpName, err := tempName()
+	if err != nil {
+		return "", err
+	}
+
+	f, err := s.fs.Create(tmpName)
+	if err != nil {
+		return "", err
+	}
+	// 실패하면 임시 파일을 지운다. 성공하면 rename 되어 이미 없으므로 에러는 무시
+	defer s.fs.Remove(tmpName)
+
+	hasher := s.algo.New()
+	zw := getZlibWriter(f)
+	defer putZlibWriter(zw)
+	w := io.MultiWriter(hasher, zw)
+
+	header := fmt.Sprintf("%s %d%s", typ, size, NUL)
+	if _, err := io.WriteString(w, header); err != nil {
+		f.Close()
+		return "", err
+	}
+
+	written, err := io.Copy(w, r)
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+	if written != size {
+		f.Close()
+		return "", fmt.Errorf("object size mismatch: expected %d bytes, read %d", size, written)
+	}
+
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	fullPath := s.path(hash)
+
+	// 이미 존재하는 객체라면 덮어쓰지 않고 수정 시각만 새로 한다
+	if s.freshen(fullPath) {
+		return hash, nil
+	}
+	if err := s.fs.MkdirAll(path.Dir(fullPath), 0755); err != nil {
+		return "", err
+	}
+	if err := s.fs.Rename(tmpName, fullPath); err != nil {
+		return "", err
+	}
+	s.written(hash, typ)
+	return hash, nil
+}
+
+// contextReader: 읽을 때마다 ctx 를 확인해서 취소되면 ctx.Err() 를 돌려준다.
+// 큰 파일을 쓰는 도중에도 io.Copy 가 다음 Read 에서 멈추게 된다.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// 임시 파일은 objects 바로 아래에 만든다. 같은 파일시스템이어야 rename 이 원자적이다.
+func tempName() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "tmp_obj_" + hex.EncodeToString(buf), nil
+}
+
+// ObjectReader: 압축을 풀면서 페이로드를 조금씩 읽는다.
+// 헤더는 Open 할 때 미리 읽어 두므로 Type/Size 를 바로 알 수 있다.
+type ObjectReader struct {
+	Type Type
+	Size int64
+
+	payload io.Reader
+	close   func() error
+}
+
+// NewObjectReader: 다른 Storer 구현이 ObjectReader 를 만들 때 사용한다.
+// close 는 Close 할 때 호출되며 nil 이어도 된다.
+func NewObjectReader(typ Type, size int64, payload io.Reader, close func() error) *ObjectReader {
+	return &ObjectReader{Type: typ, Size: size, payload: io.LimitReader(payload, size), close: close}
+}
+
+// Open: 객체를 스트리밍으로 읽기 위해 연다. 다 읽은 뒤에는 Close 해야 한다.
+func (s *Store) Open(hash string) (*ObjectReader, error) {
+	if !s.algo.IsHash(hash) {
+		return nil, notFound(hash)
+	}
+
+	f, err := s.fs.Open(s.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		// pack 의 객체는 delta 를 풀어야 하므로 통째로 읽는다
+		p, offset, ok := s.findPacked(hash)
+		if !ok {
+			return nil, notFound(hash)
+		}
+		typ, content, err := s.readPacked(p, offset)
+		if err != nil {
+			return nil, err
+		}
+		if !s.noVerify {
+			if actual := s.algo.Sum(Format(typ, content)); actual != hash {
+				return nil, corrupt(hash, actual)
+			}
+		}
+		return NewObjectReader(typ, int64(len(content)), bytes.NewReader(content), nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := getZlibReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(zr)
+	header, err := br.ReadString(0)
+	if err != nil {
+		putZlibReader(zr)
+		f.Close()
+		return nil, invalidf("missing header")
+	}
+
+	typ, sizeStr, ok := strings.Cut(strings.TrimSuffix(header, NUL), " ")
+	size, err := strconv.ParseInt(sizeStr, 10, 64)
+	if !ok || err != nil {
+		putZlibReader(zr)
+		f.Close()
+		return nil, invalidf("bad header %q", header)
+	}
+
+	r := NewObjectReader(Type(typ), size, br, func() error {
+		zerr := zr.Close()
+		putZlibReader(zr)
+		if err := f.Close(); err != nil {
+			return err
+		}
+		return zerr
+	})
+	if !s.noVerify {
+		r.payload = newVerifyingReader(r.payload, s.algo, hash, header, size)
+	}
+	return r, nil
+}
+
+// VerifyBufferLimit: 이 크기 이하의 loose 객체는 처음 읽을 때 통째로 풀어 해시를 확인한 뒤에 내용을 내준다.
+// 더 큰 객체는 스트리밍하면서 확인하므로, 내용을 밖으로 내보내는 쪽은 먼저 Spool 해야 한다.
+const VerifyBufferLimit = 16 << 20
+
+// verifyingReader: 읽으면서 해시를 계산하고, 끝까지 읽었을 때 이름과 다르면 EOF 대신 ErrCorrupt 를 돌려준다.
+// VerifyBufferLimit 이하의 객체는 첫 Read 에서 모두 읽어 확인하므로 손상된 내용을 한 바이트도 내주지 않는다.
+// 큰 객체를 중간에 읽기를 그만두면 확인하지 않는다.
+type verifyingReader struct {
+	r    io.Reader
+	h    hash.Hash
+	want string
+	size int64
+	// buf: 확인을 마친 작은 객체의 내용
+	buf *bytes.Reader
+}
+
+func newVerifyingReader(r io.Reader, a *Algorithm, want string, header string, size int64) *verifyingReader {
+	h := a.New()
+	io.WriteString(h, header)
+	return &verifyingReader{r: r, h: h, want: want, size: size}
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	if v.buf == nil && v.size <= VerifyBufferLimit {
+		if err := v.fill(); err != nil {
+			return 0, err
+		}
+	}
+	if v.buf != nil {
+		return v.buf.Read(p)
+	}
+
+	n, err := v.r.Read(p)
+	v.h.Write(p[:n])
+	if err == io.EOF {
+		if err := v.check(); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+// fill: 내용을 모두 메모리에 읽고 해시를 확인한다
+func (v *verifyingReader) fill() error {
+	data, err := io.ReadAll(v.r)
+	if err != nil {
+		return err
+	}
+	v.h.Write(data)
+	if err := v.check(); err != nil {
+		return err
+	}
+	v.buf = bytes.NewReader(data)
+	return nil
+}
+
+func (v *verifyingReader) check() error {
+	if actual := hex.EncodeToString(v.h.Sum(nil)); actual != v.want {
+		return corrupt(v.want, actual)
+	}
+	return nil
+}
+
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	return r.payload.Read(p)
+}
+
+// Spool: 아직 읽지 않은 객체의 내용을 모두 풀어 해시를 먼저 확인한다.
+// 작은 객체는 메모리에, 큰 객체는 임시 파일에 풀고 이후의 Read 는 확인을 마친 내용에서 읽는다. 임시 파일은 Close 할 때 지운다.
+// 내용을 stdout 등 되돌릴 수 없는 곳에 쓰기 전에 부른다. 확인하지 않는 객체는 아무것도 하지 않는다.
+func (r *ObjectReader) Spool() error {
+	v, ok := r.payload.(*verifyingReader)
+	if !ok || v.buf != nil {
+		return nil
+	}
+	if v.size <= VerifyBufferLimit {
+		return v.fill()
+	}
+	f, err := os.CreateTemp("", "gogit-object-")
+	if err != nil {
+		return err
+	}
+	cleanup := func() error {
+		f.Close()
+		return os.Remove(f.Name())
+	}
+	if _, err := io.Copy(f, r.payload); err != nil {
+		cleanup()
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return err
+	}
+	r.payload = f
+	closeObject := r.close
+	r.close = func() error {
+		err := cleanup()
+		if closeObject != nil {
+			return cmp.Or(closeObject(), err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Close: 두 번 불러도 된다. (압축 해제기를 pool 에 두 번 돌려주지 않도록 처음 한 번만 닫는다)
+func (r *ObjectReader) Close() error {
+	if r.close == nil {
+		return nil
+	}
+	fn := r.close
+	r.close = nil
+	return fn()
+}
@@ -0,0 +1,82 @@
+package object
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// corruptLoose: hash 의 loose 파일을 같은 크기의 다른 내용으로 바꿔 쓴다
+func corruptLoose(t *testing.T, dir string, s *Store, hash string, content []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(Format(TypeBlob, content))
+	zw.Close()
+	if err := os.WriteFile(filepath.Join(dir, s.path(hash)), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 작은 객체는 첫 Read 에서 해시를 확인하므로 손상된 내용을 한 바이트도 내주지 않는다
+func TestOpenCorruptSmallObject(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(vfs.NewOS(dir))
+	hash, err := s.Write(TypeBlob, []byte("hello\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	corruptLoose(t, dir, s, hash, []byte("jello\n"))
+
+	r, err := s.Open(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	n, err := r.Read(make([]byte, 64))
+	if !errors.Is(err, ErrCorrupt) || n != 0 {
+		t.Errorf("Read = %d, %v; want 0, ErrCorrupt", n, err)
+	}
+}
+
+// 큰 객체는 Spool 이 해시를 확인하고, 성공하면 같은 내용을 다시 읽을 수 있다
+func TestSpoolLargeObject(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(vfs.NewOS(dir))
+	content := bytes.Repeat([]byte("0123456789abcdef"), VerifyBufferLimit/16+1)
+	hash, err := s.Write(TypeBlob, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := s.Open(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Spool(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("read %d bytes, %v; want the original %d bytes", len(got), err, len(content))
+	}
+
+	bad := bytes.Clone(content)
+	bad[len(bad)-1] = 'x'
+	corruptLoose(t, dir, s, hash, bad)
+	r, err = s.Open(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err := r.Spool(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Spool = %v, want ErrCorrupt", err)
+	}
+}
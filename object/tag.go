@@ -0,0 +1,66 @@
+package object
+
+import (
+	"strings"
+)
+
+// Tag: annotated tag
+// 다른 객체(보통 commit)를 가리키며 tagger 와 메시지를 가진다.
+type Tag struct {
+	Object     string
+	ObjectType Type
+	Name       string
+	Tagger     Signature
+	// ExtraHeaders: object/type/tag/tagger 외의 헤더
+	ExtraHeaders []Header
+	Message      string
+}
+
+func (t *Tag) Type() Type { return TypeTag }
+
+func (t *Tag) Encode() []byte {
+	var b strings.Builder
+	writeHeader(&b, "object", t.Object)
+	writeHeader(&b, "type", string(t.ObjectType))
+	writeHeader(&b, "tag", t.Name)
+	// 아주 오래된 tag 는 tagger 가 없다
+	if t.Tagger != (Signature{}) {
+		writeHeader(&b, "tagger", t.Tagger.String())
+	}
+	for _, h := range t.ExtraHeaders {
+		writeHeader(&b, h.Key, h.Value)
+	}
+	b.WriteString("\n")
+	b.WriteString(t.Message)
+	return []byte(b.String())
+}
+
+func (t *Tag) Decode(data []byte) error {
+	headers, message, err := parseHeaders(data)
+	if err != nil {
+		return invalidf("tag: %v", err)
+	}
+
+	*t = Tag{Message: message}
+	for _, h := range headers {
+		switch h.Key {
+		case "object":
+			t.Object = h.Value
+		case "type":
+			t.ObjectType = Type(h.Value)
+		case "tag":
+			t.Name = h.Value
+		case "tagger":
+			if t.Tagger, err = ParseSignature(h.Value); err != nil {
+				return invalidf("tagger: %v", err)
+			}
+		default:
+			t.ExtraHeaders = append(t.ExtraHeaders, h)
+		}
+	}
+
+	if t.Object == "" || t.ObjectType == "" {
+		return invalidf("tag is missing its object or type")
+	}
+	return nil
+}
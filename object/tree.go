@@ -0,0 +1,152 @@
+package object
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Mode: tree entry 의 파일 모드
+type Mode uint32
+
+const (
+	ModeTree       Mode = 0040000
+	ModeRegular    Mode = 0100644
+	ModeExecutable Mode = 0100755
+	ModeSymlink    Mode = 0120000
+	ModeGitlink    Mode = 0160000
+)
+
+// tree 에는 "40000" 처럼 앞의 0 없이 8진수로 기록된다.
+func (m Mode) String() string {
+	return strconv.FormatUint(uint64(m), 8)
+}
+
+// ObjectType: 모드가 가리키는 객체의 타입
+func (m Mode) ObjectType() Type {
+	switch m {
+	case ModeTree:
+		return TypeTree
+	case ModeGitlink:
+		return TypeCommit
+	default:
+		return TypeBlob
+	}
+}
+
+// TreeEntry: tree 의 한 항목 (파일 또는 하위 디렉토리)
+type TreeEntry struct {
+	Mode Mode
+	Name string
+	Hash string
+}
+
+// Tree: 디렉토리 하나
+type Tree struct {
+	Entries []TreeEntry
+}
+
+func (t *Tree) Type() Type { return TypeTree }
+
+// Encode: "<mode> <name>\0<해시 바이트>" 를 이어 붙인다. (SHA-1 은 20바이트, SHA-256 은 32바이트)
+// git 은 항목이 이름순(디렉토리는 이름 뒤에 '/' 가 붙은 것처럼)으로 정렬되어 있어야 하므로 정렬해서 쓴다.
+func (t *Tree) Encode() []byte {
+	entries := make([]TreeEntry, len(t.Entries))
+	copy(entries, t.Entries)
+	SortEntries(entries)
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "%s %s%s", e.Mode, e.Name, NUL)
+		hash, _ := hex.DecodeString(e.Hash)
+		buf.Write(hash)
+	}
+	return buf.Bytes()
+}
+
+// Validate: 저장하기 전의 검사. git 이 받아들이지 않는 tree 를 만들지 않기 위해
+// 이름이 비었거나 '/' 나 NUL 이 들어 있는 항목, ".", "..", ".git", ".gogit" 항목, 같은 이름의 항목을 거부한다.
+func (t *Tree) Validate() error {
+	return t.ValidateRepoDir(".gogit")
+}
+
+// ValidateRepoDir: Validate 와 같지만 ".gogit" 대신 repoDir 을 저장소 디렉토리 이름으로 보고 거부한다.
+// ".git" 은 git 과 같이 언제나 거부한다. --git-compat 으로 다루는 git 저장소에서는 ".gogit" 이 평범한 디렉토리다.
+func (t *Tree) ValidateRepoDir(repoDir string) error {
+	names := make(map[string]bool, len(t.Entries))
+	for _, e := range t.Entries {
+		switch {
+		case e.Name == "":
+			return invalidf("tree has an entry with an empty name")
+		case strings.ContainsAny(e.Name, "/\x00"):
+			return invalidf("tree entry %q contains a slash or NUL", e.Name)
+		case e.Name == "." || e.Name == "..":
+			return invalidf("tree entry %q is not a valid name", e.Name)
+		case strings.EqualFold(e.Name, ".git") || strings.EqualFold(e.Name, repoDir):
+			return invalidf("tree entry %q is a repository directory", e.Name)
+		case names[e.Name]:
+			return invalidf("tree has duplicate entries named %q", e.Name)
+		case !IsHash(e.Hash):
+			return invalidf("tree entry %q has a bad hash %q", e.Name, e.Hash)
+		}
+		names[e.Name] = true
+	}
+	return nil
+}
+
+// Decode: SHA-1 저장소의 tree 를 해석한다. 다른 해시 함수의 tree 는 DecodeAlgorithm
+func (t *Tree) Decode(data []byte) error {
+	return t.DecodeAlgorithm(data, SHA1)
+}
+
+// DecodeAlgorithm: 항목마다 a.Size 바이트의 해시가 든 tree 를 해석한다.
+func (t *Tree) DecodeAlgorithm(data []byte, a *Algorithm) error {
+	t.Entries = nil
+	for len(data) > 0 {
+		space := bytes.IndexByte(data, ' ')
+		if space == -1 {
+			return invalidf("tree entry is missing its mode")
+		}
+		mode, err := strconv.ParseUint(string(data[:space]), 8, 32)
+		if err != nil {
+			return invalidf("bad tree entry mode %q", data[:space])
+		}
+		data = data[space+1:]
+
+		nul := bytes.IndexByte(data, 0)
+		if nul == -1 {
+			return invalidf("tree entry name is not terminated")
+		}
+		name := string(data[:nul])
+		data = data[nul+1:]
+
+		if len(data) < a.Size {
+			return invalidf("tree entry %s has a truncated hash", name)
+		}
+		t.Entries = append(t.Entries, TreeEntry{
+			Mode: Mode(mode),
+			Name: name,
+			Hash: hex.EncodeToString(data[:a.Size]),
+		})
+		data = data[a.Size:]
+	}
+	return nil
+}
+
+// SortEntries: git 의 tree 정렬 순서로 정렬
+// 디렉토리는 이름 뒤에 '/' 가 있는 것처럼 비교한다. (예: "a.txt" < "a/" )
+func SortEntries(entries []TreeEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return sortKey(entries[i]) < sortKey(entries[j])
+	})
+}
+
+func sortKey(e TreeEntry) string {
+	if e.Mode == ModeTree {
+		return e.Name + "/"
+	}
+	return e.Name
+}
@@ -0,0 +1,115 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LookupPath: tree 안에서 "a/b/c" 경로의 항목을 찾는다. 없으면 ok 가 false
+func LookupPath(s Storer, treeHash string, p string) (entry TreeEntry, ok bool, err error) {
+	parts := splitPath(p)
+	if len(parts) == 0 {
+		return TreeEntry{Mode: ModeTree, Hash: treeHash}, true, nil
+	}
+
+	hash := treeHash
+	for i, name := range parts {
+		tree, err := ReadTree(s, hash)
+		if err != nil {
+			return TreeEntry{}, false, err
+		}
+		e, found := findEntry(tree, name)
+		if !found {
+			return TreeEntry{}, false, nil
+		}
+		if i == len(parts)-1 {
+			return e, true, nil
+		}
+		if e.Mode != ModeTree {
+			return TreeEntry{}, false, nil
+		}
+		hash = e.Hash
+	}
+	return TreeEntry{}, false, nil
+}
+
+// ReplacePath: tree 의 p 경로를 entry 로 바꾼 새 tree 를 저장하고 그 해시를 돌려준다.
+// entry 가 nil 이면 항목을 지운다. 중간 디렉토리가 없으면 만들고, 지운 뒤 비게 된 디렉토리는 없앤다.
+// 경로 위쪽의 tree 만 다시 쓰므로 나머지 객체는 그대로 공유된다.
+func ReplacePath(s Storer, treeHash string, p string, entry *TreeEntry) (string, error) {
+	parts := splitPath(p)
+	if len(parts) == 0 {
+		return "", fmt.Errorf("cannot replace the root tree")
+	}
+	hash, err := replacePath(s, treeHash, parts, entry)
+	if err == nil && hash == "" {
+		// 마지막 항목을 지웠으면 빈 tree
+		return WriteObject(s, &Tree{})
+	}
+	return hash, err
+}
+
+func replacePath(s Storer, treeHash string, parts []string, entry *TreeEntry) (string, error) {
+	tree := &Tree{}
+	if treeHash != "" {
+		var err error
+		if tree, err = ReadTree(s, treeHash); err != nil {
+			return "", err
+		}
+	}
+
+	name := parts[0]
+	existing, found := findEntry(tree, name)
+
+	var replacement *TreeEntry
+	if len(parts) == 1 {
+		if entry != nil {
+			replacement = &TreeEntry{Mode: entry.Mode, Name: name, Hash: entry.Hash}
+		}
+	} else {
+		sub := ""
+		if found && existing.Mode == ModeTree {
+			sub = existing.Hash
+		}
+		hash, err := replacePath(s, sub, parts[1:], entry)
+		if err != nil {
+			return "", err
+		}
+		if hash != "" {
+			replacement = &TreeEntry{Mode: ModeTree, Name: name, Hash: hash}
+		}
+	}
+
+	entries := make([]TreeEntry, 0, len(tree.Entries)+1)
+	for _, e := range tree.Entries {
+		if e.Name != name {
+			entries = append(entries, e)
+		}
+	}
+	if replacement != nil {
+		entries = append(entries, *replacement)
+	}
+	if len(entries) == 0 {
+		return "", nil
+	}
+	return WriteObject(s, &Tree{Entries: entries})
+}
+
+func findEntry(tree *Tree, name string) (TreeEntry, bool) {
+	for _, e := range tree.Entries {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return TreeEntry{}, false
+}
+
+func splitPath(p string) []string {
+	var parts []string
+	for _, part := range strings.Split(p, "/") {
+		if part != "" && part != "." {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
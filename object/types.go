@@ -0,0 +1,171 @@
+package object
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Object: 타입이 있는 객체 공통 인터페이스
+// Encode 는 헤더를 제외한 페이로드만 만든다. (헤더는 Format 이 붙인다)
+type Object interface {
+	Type() Type
+	Encode() []byte
+	Decode(data []byte) error
+}
+
+// Decode: 타입에 맞는 구조체로 SHA-1 저장소의 페이로드를 해석한다.
+func Decode(typ Type, data []byte) (Object, error) {
+	return DecodeAlgorithm(typ, data, SHA1)
+}
+
+// DecodeAlgorithm: Decode 와 같고, tree 는 a 의 해시 길이로 해석한다.
+func DecodeAlgorithm(typ Type, data []byte, a *Algorithm) (Object, error) {
+	var obj Object
+	switch typ {
+	case TypeBlob:
+		obj = &Blob{}
+	case TypeTree:
+		obj = &Tree{}
+	case TypeCommit:
+		obj = &Commit{}
+	case TypeTag:
+		obj = &Tag{}
+	default:
+		return nil, invalidf("unknown object type %q", typ)
+	}
+
+	if t, ok := obj.(*Tree); ok {
+		if err := t.DecodeAlgorithm(data, a); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	if err := obj.Decode(data); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Blob: 파일 내용 그 자체
+type Blob struct {
+	Data []byte
+}
+
+func (b *Blob) Type() Type { return TypeBlob }
+
+func (b *Blob) Encode() []byte { return b.Data }
+
+func (b *Blob) Decode(data []byte) error {
+	b.Data = data
+	return nil
+}
+
+// Signature: author/committer/tagger 정보
+// 저장 포맷은 "Name <email> 1700000000 +0900" 이다.
+type Signature struct {
+	Name  string
+	Email string
+	When  time.Time
+}
+
+func (s Signature) String() string {
+	return fmt.Sprintf("%s <%s> %d %s", s.Name, s.Email, s.When.Unix(), s.When.Format("-0700"))
+}
+
+// ParseSignature: "Name <email> timestamp tz" 형식을 해석
+func ParseSignature(value string) (Signature, error) {
+	open := strings.LastIndex(value, "<")
+	close := strings.LastIndex(value, ">")
+	if open == -1 || close < open {
+		return Signature{}, fmt.Errorf("invalid signature: %q", value)
+	}
+
+	sig := Signature{
+		Name:  strings.TrimSpace(value[:open]),
+		Email: value[open+1 : close],
+	}
+
+	fields := strings.Fields(value[close+1:])
+	if len(fields) != 2 {
+		return Signature{}, fmt.Errorf("invalid signature time: %q", value)
+	}
+	seconds, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return Signature{}, fmt.Errorf("invalid signature timestamp: %q", fields[0])
+	}
+	tz, err := parseTimezone(fields[1])
+	if err != nil {
+		return Signature{}, err
+	}
+	sig.When = time.Unix(seconds, 0).In(tz)
+
+	return sig, nil
+}
+
+// "+0900" 같은 오프셋을 고정 타임존으로 변환
+// time.Parse 는 로컬 타임존과 오프셋이 같으면 Local 을 돌려주므로 직접 계산한다.
+func parseTimezone(value string) (*time.Location, error) {
+	if len(value) != 5 || (value[0] != '+' && value[0] != '-') {
+		return nil, fmt.Errorf("invalid signature timezone: %q", value)
+	}
+	hours, err1 := strconv.Atoi(value[1:3])
+	minutes, err2 := strconv.Atoi(value[3:5])
+	if err1 != nil || err2 != nil {
+		return nil, fmt.Errorf("invalid signature timezone: %q", value)
+	}
+
+	offset := hours*3600 + minutes*60
+	if value[0] == '-' {
+		offset = -offset
+	}
+	return time.FixedZone("", offset), nil
+}
+
+// Header: commit/tag 의 알 수 없는 헤더 (gpgsig, encoding 등)
+// 다시 Encode 했을 때 같은 해시가 나오도록 순서와 값을 그대로 보존한다.
+type Header struct {
+	Key   string
+	Value string
+}
+
+// 헤더 영역 파싱
+// 각 줄은 "key value" 형식이며, 공백으로 시작하는 줄은 앞 헤더 값의 연속이다. (gpgsig 같은 여러 줄 값)
+// 첫 빈 줄 이후는 메시지다.
+func parseHeaders(data []byte) ([]Header, string, error) {
+	text := string(data)
+	headerPart, message, found := strings.Cut(text, "\n\n")
+	if !found {
+		// 메시지가 없는 객체도 헤더는 줄바꿈으로 끝난다
+		headerPart = strings.TrimSuffix(text, "\n")
+	}
+
+	var headers []Header
+	for _, line := range strings.Split(headerPart, "\n") {
+		if strings.HasPrefix(line, " ") {
+			if len(headers) == 0 {
+				return nil, "", fmt.Errorf("invalid header continuation: %q", line)
+			}
+			last := &headers[len(headers)-1]
+			last.Value += "\n" + line[1:]
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, "", fmt.Errorf("invalid header line: %q", line)
+		}
+		headers = append(headers, Header{Key: key, Value: value})
+	}
+
+	return headers, message, nil
+}
+
+// 헤더 한 줄 쓰기. 여러 줄 값은 다음 줄부터 공백 한 칸으로 들여쓴다.
+func writeHeader(b *strings.Builder, key string, value string) {
+	b.WriteString(key)
+	b.WriteString(" ")
+	b.WriteString(strings.ReplaceAll(value, "\n", "\n "))
+	b.WriteString("\n")
+}
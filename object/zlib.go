@@ -0,0 +1,45 @@
+package object
+
+import (
+	"compress/zlib"
+	"io"
+	"sync"
+)
+
+// zlib writer 는 만들 때마다 압축용 해시 표와 창(window)으로 1MB 가까이 할당하고, reader 도 창을 할당한다.
+// 객체마다 새로 만들면 많은 객체를 쓰는 import 나 index-pack 이 할당에 시간을 쓰므로 Reset 해서 다시 쓴다.
+// (git 의 객체 형식은 미리 정한 사전(preset dictionary)을 쓰지 않으므로 Reset 에 사전은 넘기지 않는다)
+var (
+	zlibWriters = sync.Pool{New: func() any { return zlib.NewWriter(nil) }}
+	zlibReaders sync.Pool
+)
+
+// getZlibWriter: w 에 쓰는 zlib writer. 다 쓰면 Close 한 뒤 putZlibWriter 로 돌려준다
+func getZlibWriter(w io.Writer) *zlib.Writer {
+	zw := zlibWriters.Get().(*zlib.Writer)
+	zw.Reset(w)
+	return zw
+}
+
+func putZlibWriter(zw *zlib.Writer) {
+	zw.Reset(nil)
+	zlibWriters.Put(zw)
+}
+
+// getZlibReader: r 을 푸는 zlib reader. zlib 헤더가 잘못되었으면 에러다. 다 읽으면 putZlibReader 로 돌려준다
+func getZlibReader(r io.Reader) (io.ReadCloser, error) {
+	zr, ok := zlibReaders.Get().(io.ReadCloser)
+	if !ok {
+		return zlib.NewReader(r)
+	}
+	if err := zr.(zlib.Resetter).Reset(r, nil); err != nil {
+		zlibReaders.Put(zr)
+		return nil, err
+	}
+	return zr, nil
+}
+
+// putZlibReader: 에러를 돌려주는 것 외에는 하는 일이 없으므로 Close 하지 않아도 된다
+func putZlibReader(zr io.ReadCloser) {
+	zlibReaders.Put(zr)
+}
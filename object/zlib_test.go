@@ -0,0 +1,101 @@
+package object
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"testing"
+)
+
+// benchPayload: 작은 소스 파일 크기의 객체. 객체가 작을수록 writer/reader 를 만드는 비용이 두드러진다
+var benchPayload = bytes.Repeat([]byte("func main() { fmt.Println(\"hello, world\") }\n"), 40)
+
+func BenchmarkCompress(b *testing.B) {
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf bytes.Buffer
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			zw := getZlibWriter(&buf)
+			zw.Write(benchPayload)
+			if err := zw.Close(); err != nil {
+				b.Fatal(err)
+			}
+			putZlibWriter(zw)
+		}
+	})
+	b.Run("fresh", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf bytes.Buffer
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			zw := zlib.NewWriter(&buf)
+			zw.Write(benchPayload)
+			if err := zw.Close(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkDecompress(b *testing.B) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(benchPayload)
+	zw.Close()
+	data := compressed.Bytes()
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			zr, err := getZlibReader(bytes.NewReader(data))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := io.Copy(io.Discard, zr); err != nil {
+				b.Fatal(err)
+			}
+			putZlibReader(zr)
+		}
+	})
+	b.Run("fresh", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := io.Copy(io.Discard, zr); err != nil {
+				b.Fatal(err)
+			}
+			zr.Close()
+		}
+	})
+}
+
+// 풀에서 꺼낸 writer/reader 가 앞의 stream 의 상태를 남기지 않는지
+func TestZlibPoolRoundTrip(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		payload := bytes.Repeat([]byte{byte('a' + i)}, 100*(i+1))
+		var buf bytes.Buffer
+		zw := getZlibWriter(&buf)
+		zw.Write(payload)
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		putZlibWriter(zw)
+
+		zr, err := getZlibReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(zr)
+		putZlibReader(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Fatalf("round %d: got %d bytes, want %d", i, len(got), len(payload))
+		}
+	}
+}
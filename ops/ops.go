@@ -0,0 +1,235 @@
+// Package ops 는 ref 를 바꾼 명령들의 기록(operation journal)을 남겨 되돌릴 수 있게 한다.
+//
+//	before, _ := ops.Capture(repo.Refs)
+//	// ... 명령 실행 ...
+//	after, _ := ops.Capture(repo.Refs)
+//	ops.Record(repo.FS, ops.Op{Command: "commit -m x", Before: before, After: after})
+//
+// 기록은 저장소 디렉토리의 ops/<번호> 파일에 하나씩 남는다. 번호가 클수록 최근이다.
+//
+//	command commit -m x
+//	time 1792113646
+//	undoes 3                          (undo 가 남긴 기록이면 되돌린 기록의 번호)
+//	tree <hash>                       (작업 트리를 바꾸는 명령이면 실행 전 작업 트리의 스냅샷)
+//	before HEAD ref: refs/heads/main
+//	before refs/heads/main <hash>
+//	after refs/heads/main <hash>
+package ops
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmdgusya/gogit/refs"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// Dir: 기록을 모아 두는 디렉토리
+const Dir = "ops"
+
+// symrefPrefix: State 에서 symbolic ref 의 값 앞에 붙는다. (ref 파일과 같은 형식)
+const symrefPrefix = "ref: "
+
+// State: ref 이름과 값. 해시, 또는 symbolic ref 면 "ref: <target>"
+type State map[string]string
+
+// Capture: 지금의 HEAD 와 refs/ 아래의 모든 ref
+func Capture(s refs.Storer) (State, error) {
+	list, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	state := State{}
+	for _, ref := range list {
+		state[ref.Name] = refValue(ref)
+	}
+	head, err := s.Read("HEAD")
+	if err != nil && !errors.Is(err, refs.ErrNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		state["HEAD"] = refValue(head)
+	}
+	return state, nil
+}
+
+func refValue(ref refs.Ref) string {
+	if ref.Target != "" {
+		return symrefPrefix + ref.Target
+	}
+	return ref.Hash
+}
+
+// Target: v 가 symbolic ref 값이면 가리키는 ref 이름
+func Target(v string) (string, bool) {
+	return strings.CutPrefix(v, symrefPrefix)
+}
+
+// Op: 기록 하나
+type Op struct {
+	ID      int
+	Time    time.Time
+	Command string
+	// Undoes: undo 가 남긴 기록이면 되돌린 기록의 ID, 아니면 0
+	Undoes int
+	// Tree: 명령 전 작업 트리의 스냅샷. 작업 트리를 바꾸지 않는 명령(commit 등)은 빈 문자열이고,
+	// 이때 undo 는 ref 만 되돌리고 파일은 그대로 둔다.
+	Tree   string
+	Before State
+	After  State
+}
+
+// Changed: Before 와 After 가 다른 ref 이름들 (이름순)
+func (op *Op) Changed() []string {
+	var names []string
+	for name, v := range op.Before {
+		if op.After[name] != v {
+			names = append(names, name)
+		}
+	}
+	for name := range op.After {
+		if _, ok := op.Before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Record: op 를 다음 번호로 기록하고 그 번호를 돌려준다. 바뀐 ref 만 남긴다.
+func Record(fsys vfs.Filesystem, op Op) (int, error) {
+	list, err := List(fsys)
+	if err != nil {
+		return 0, err
+	}
+	op.ID = 1
+	if len(list) > 0 {
+		op.ID = list[len(list)-1].ID + 1
+	}
+	if op.Time.IsZero() {
+		op.Time = time.Now()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "command %s\n", strings.ReplaceAll(op.Command, "\n", " "))
+	fmt.Fprintf(&b, "time %d\n", op.Time.Unix())
+	if op.Undoes != 0 {
+		fmt.Fprintf(&b, "undoes %d\n", op.Undoes)
+	}
+	if op.Tree != "" {
+		fmt.Fprintf(&b, "tree %s\n", op.Tree)
+	}
+	for _, name := range op.Changed() {
+		if v, ok := op.Before[name]; ok {
+			fmt.Fprintf(&b, "before %s %s\n", name, v)
+		}
+		if v, ok := op.After[name]; ok {
+			fmt.Fprintf(&b, "after %s %s\n", name, v)
+		}
+	}
+	if err := fsys.MkdirAll(Dir, 0755); err != nil {
+		return 0, err
+	}
+	// 번호가 겹치지 않도록 새 파일로만 만든다
+	f, err := fsys.CreateExclusive(Dir + "/" + strconv.Itoa(op.ID))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Write([]byte(b.String())); err != nil {
+		f.Close()
+		return 0, err
+	}
+	return op.ID, f.Close()
+}
+
+// List: 모든 기록 (오래된 것부터). 기록이 없으면 빈 목록
+func List(fsys vfs.Filesystem) ([]Op, error) {
+	entries, err := fsys.ReadDir(Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []Op
+	for _, e := range entries {
+		id, err := strconv.Atoi(e.Name())
+		if err != nil || e.IsDir() {
+			continue
+		}
+		data, err := vfs.ReadFile(fsys, Dir+"/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+		op, err := parse(id, string(data))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, op)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func parse(id int, data string) (Op, error) {
+	op := Op{ID: id, Before: State{}, After: State{}}
+	for _, line := range strings.Split(data, "\n") {
+		if line == "" {
+			continue
+		}
+		key, rest, _ := strings.Cut(line, " ")
+		switch key {
+		case "command":
+			op.Command = rest
+		case "time":
+			sec, err := strconv.ParseInt(rest, 10, 64)
+			if err != nil {
+				return Op{}, fmt.Errorf("corrupt %s/%d: bad time %q", Dir, id, rest)
+			}
+			op.Time = time.Unix(sec, 0)
+		case "undoes":
+			n, err := strconv.Atoi(rest)
+			if err != nil {
+				return Op{}, fmt.Errorf("corrupt %s/%d: bad undoes %q", Dir, id, rest)
+			}
+			op.Undoes = n
+		case "tree":
+			op.Tree = rest
+		case "before", "after":
+			name, v, ok := strings.Cut(rest, " ")
+			if !ok {
+				return Op{}, fmt.Errorf("corrupt %s/%d line: %q", Dir, id, line)
+			}
+			if key == "before" {
+				op.Before[name] = v
+			} else {
+				op.After[name] = v
+			}
+		default:
+			return Op{}, fmt.Errorf("corrupt %s/%d line: %q", Dir, id, line)
+		}
+	}
+	return op, nil
+}
+
+// LastUndoable: 아직 되돌리지 않은 가장 최근 기록. undo 가 남긴 기록은 건너뛰므로
+// undo 를 거듭하면 더 이전의 기록을 차례로 되돌린다. 없으면 ok 가 false
+func LastUndoable(list []Op) (Op, bool) {
+	undone := map[int]bool{}
+	for _, op := range list {
+		if op.Undoes != 0 {
+			undone[op.Undoes] = true
+		}
+	}
+	for i := len(list) - 1; i >= 0; i-- {
+		if op := list[i]; op.Undoes == 0 && !undone[op.ID] {
+			return op, true
+		}
+	}
+	return Op{}, false
+}
@@ -0,0 +1,206 @@
+// Package pktline 은 git 프로토콜의 pkt-line 형식을 읽고 쓴다.
+//
+//	0006a\n        길이(16진수 4자리, 길이 자신 포함) + 내용
+//	0000           flush-pkt: 메시지의 끝
+//	0001           delim-pkt: 메시지 안의 구역 나눔 (protocol v2)
+//	0002           response-end-pkt: 응답의 끝 (protocol v2 의 stateless 연결)
+//
+// 예:
+//
+//	w := pktline.NewWriter(conn)
+//	w.WriteString("command=ls-refs\n")
+//	w.Delim()
+//	w.Flush()
+//
+//	r := pktline.NewReader(conn)
+//	for {
+//		kind, data, err := r.Read()
+//		...
+//	}
+//
+// sideband 로 나뉜 응답(pack 과 진행 메시지)은 Demuxer 로 읽는다.
+package pktline
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	// MaxPacketLen: 길이 4바이트를 포함한 pkt-line 하나의 최대 길이
+	MaxPacketLen = 65520
+	// MaxPayload: 내용의 최대 길이
+	MaxPayload = MaxPacketLen - 4
+)
+
+// Kind: 읽은 packet 의 종류
+type Kind int
+
+const (
+	// Data: 내용이 있는 packet
+	Data Kind = iota
+	// Flush: "0000"
+	Flush
+	// Delim: "0001"
+	Delim
+	// ResponseEnd: "0002"
+	ResponseEnd
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Data:
+		return "data"
+	case Flush:
+		return "flush"
+	case Delim:
+		return "delim"
+	case ResponseEnd:
+		return "response-end"
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// ErrInvalidPacket: 길이가 16진수가 아니거나 허용되지 않는 값이다
+var ErrInvalidPacket = errors.New("invalid pkt-line")
+
+// ErrTooLong: 내용이 MaxPayload 보다 길다
+var ErrTooLong = errors.New("pkt-line payload too long")
+
+// Writer: pkt-line 을 쓴다. 버퍼링하지 않으므로 필요하면 bufio.Writer 를 넘긴다
+type Writer struct {
+	w   io.Writer
+	buf []byte
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write: data 를 packet 하나로 쓴다. 빈 data 는 flush-pkt 가 아니라 "0004" 가 된다
+func (w *Writer) Write(data []byte) (int, error) {
+	if len(data) > MaxPayload {
+		return 0, ErrTooLong
+	}
+	w.buf = fmt.Appendf(w.buf[:0], "%04x", len(data)+4)
+	w.buf = append(w.buf, data...)
+	if _, err := w.w.Write(w.buf); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// WriteString: s 를 packet 하나로 쓴다
+func (w *Writer) WriteString(s string) (int, error) {
+	if len(s) > MaxPayload {
+		return 0, ErrTooLong
+	}
+	w.buf = fmt.Appendf(w.buf[:0], "%04x", len(s)+4)
+	w.buf = append(w.buf, s...)
+	if _, err := w.w.Write(w.buf); err != nil {
+		return 0, err
+	}
+	return len(s), nil
+}
+
+// Writef: 형식에 맞춘 문자열을 packet 하나로 쓴다
+func (w *Writer) Writef(format string, args ...any) error {
+	_, err := w.WriteString(fmt.Sprintf(format, args...))
+	return err
+}
+
+// Flush: flush-pkt 를 쓴다
+func (w *Writer) Flush() error {
+	_, err := io.WriteString(w.w, "0000")
+	return err
+}
+
+// Delim: delim-pkt 를 쓴다
+func (w *Writer) Delim() error {
+	_, err := io.WriteString(w.w, "0001")
+	return err
+}
+
+// ResponseEnd: response-end-pkt 를 쓴다
+func (w *Writer) ResponseEnd() error {
+	_, err := io.WriteString(w.w, "0002")
+	return err
+}
+
+// Reader: pkt-line 을 읽는다. 네 바이트씩 작게 읽으므로 필요하면 bufio.Reader 를 넘긴다
+type Reader struct {
+	r      io.Reader
+	header [4]byte
+	buf    []byte
+	// peeked: Peek 로 미리 읽어 둔 packet
+	peeked    bool
+	peekKind  Kind
+	peekData  []byte
+	peekError error
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+// Read: 다음 packet 을 읽는다. data 는 다음 Read 까지만 유효하다.
+// 입력이 packet 사이에서 끝나면 io.EOF, packet 중간에서 끝나면 io.ErrUnexpectedEOF 다.
+func (r *Reader) Read() (Kind, []byte, error) {
+	if r.peeked {
+		r.peeked = false
+		return r.peekKind, r.peekData, r.peekError
+	}
+	if _, err := io.ReadFull(r.r, r.header[:]); err != nil {
+		return 0, nil, err
+	}
+	n, err := strconv.ParseUint(string(r.header[:]), 16, 16)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: bad length %q", ErrInvalidPacket, r.header[:])
+	}
+	switch {
+	case n == 0:
+		return Flush, nil, nil
+	case n == 1:
+		return Delim, nil, nil
+	case n == 2:
+		return ResponseEnd, nil, nil
+	case n == 3 || n > MaxPacketLen:
+		return 0, nil, fmt.Errorf("%w: bad length %q", ErrInvalidPacket, r.header[:])
+	}
+	if cap(r.buf) < int(n)-4 {
+		r.buf = make([]byte, n-4)
+	}
+	r.buf = r.buf[:n-4]
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	return Data, r.buf, nil
+}
+
+// Peek: 다음 packet 을 읽지만 다음 Read 가 같은 packet 을 다시 돌려준다.
+// data 는 다음 Read 까지만 유효하다.
+func (r *Reader) Peek() (Kind, []byte, error) {
+	if !r.peeked {
+		r.peekKind, r.peekData, r.peekError = r.Read()
+		r.peeked = true
+	}
+	return r.peekKind, r.peekData, r.peekError
+}
+
+// ReadLine: 다음 data packet 을 끝의 줄바꿈을 떼고 문자열로 돌려준다.
+// flush 등 data 가 아닌 packet 이면 ok 가 false 다.
+func (r *Reader) ReadLine() (line string, ok bool, err error) {
+	kind, data, err := r.Read()
+	if err != nil || kind != Data {
+		return "", false, err
+	}
+	if n := len(data); n > 0 && data[n-1] == '\n' {
+		data = data[:n-1]
+	}
+	return string(data), true, nil
+}
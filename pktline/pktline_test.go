@@ -0,0 +1,107 @@
+package pktline
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	steps := []func() error{
+		func() error { _, err := w.WriteString("command=ls-refs\n"); return err },
+		w.Delim,
+		func() error { _, err := w.Write([]byte{}); return err },
+		func() error { return w.Writef("peel %d\n", 1) },
+		func() error { _, err := w.Write(bytes.Repeat([]byte("x"), MaxPayload)); return err },
+		w.Flush,
+		w.ResponseEnd,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := buf.String()[:24]; got != "0014command=ls-refs\n0001" {
+		t.Fatalf("encoding = %q", got)
+	}
+
+	want := []struct {
+		kind Kind
+		data string
+	}{
+		{Data, "command=ls-refs\n"},
+		{Delim, ""},
+		{Data, ""},
+		{Data, "peel 1\n"},
+		{Data, strings.Repeat("x", MaxPayload)},
+		{Flush, ""},
+		{ResponseEnd, ""},
+	}
+	r := NewReader(&buf)
+	for i, w := range want {
+		kind, data, err := r.Read()
+		if err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		if kind != w.kind || string(data) != w.data {
+			t.Fatalf("packet %d = %s %.20q, want %s %.20q", i, kind, data, w.kind, w.data)
+		}
+	}
+	if _, _, err := r.Read(); err != io.EOF {
+		t.Fatalf("after the last packet: err = %v, want io.EOF", err)
+	}
+}
+
+func TestWriteTooLong(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if _, err := w.Write(make([]byte, MaxPayload+1)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Write: err = %v, want ErrTooLong", err)
+	}
+	if _, err := w.WriteString(strings.Repeat("x", MaxPayload+1)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("WriteString: err = %v, want ErrTooLong", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("wrote %q for a rejected packet", buf.String())
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"not hex", "00zz", ErrInvalidPacket},
+		{"length 3", "0003", ErrInvalidPacket},
+		{"longer than the maximum", "fff1" + strings.Repeat("x", 0xfff1-4), ErrInvalidPacket},
+		{"short header", "00", io.ErrUnexpectedEOF},
+		{"short payload", "0009ab", io.ErrUnexpectedEOF},
+		{"empty", "", io.EOF},
+	}
+	for _, tt := range tests {
+		_, _, err := NewReader(strings.NewReader(tt.input)).Read()
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestPeekAndReadLine(t *testing.T) {
+	r := NewReader(strings.NewReader("0008abc\n0000"))
+	kind, data, err := r.Peek()
+	if err != nil || kind != Data || string(data) != "abc\n" {
+		t.Fatalf("Peek = %s %q %v", kind, data, err)
+	}
+	line, ok, err := r.ReadLine()
+	if err != nil || !ok || line != "abc" {
+		t.Fatalf("ReadLine = %q %v %v", line, ok, err)
+	}
+	if _, ok, err := r.ReadLine(); ok || err != nil {
+		t.Fatalf("ReadLine at flush = %v %v, want not ok", ok, err)
+	}
+}
@@ -0,0 +1,123 @@
+package pktline
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sideband 의 채널. packet 의 첫 바이트가 채널 번호다
+const (
+	// BandData: pack 데이터
+	BandData byte = 1
+	// BandProgress: 사람이 읽을 진행 메시지 (remote: ...)
+	BandProgress byte = 2
+	// BandError: 치명적인 에러 메시지. 이것을 받으면 전송이 끝난다
+	BandError byte = 3
+)
+
+const (
+	// MaxSidebandPayload: side-band-64k 에서 packet 하나에 담는 데이터의 최대 길이 (채널 바이트 제외)
+	MaxSidebandPayload = MaxPayload - 1
+	// MaxSmallSidebandPayload: 옛 side-band 의 최대 길이 (packet 전체 1000 바이트)
+	MaxSmallSidebandPayload = 1000 - 4 - 1
+)
+
+// RemoteError: 상대가 sideband 3번이나 "ERR " packet 으로 보낸 에러
+type RemoteError struct {
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return "remote error: " + e.Message
+}
+
+// Demuxer: sideband 로 나뉜 packet 들에서 데이터 채널만 이어서 읽는 io.Reader
+// 진행 메시지는 Progress 에 쓰고(nil 이면 버린다), 에러 채널은 *RemoteError 로 돌려준다.
+// flush-pkt 를 만나면 io.EOF 다.
+type Demuxer struct {
+	r        *Reader
+	progress io.Writer
+	// pending: 지난 packet 에서 아직 돌려주지 않은 데이터
+	pending []byte
+	err     error
+}
+
+func NewDemuxer(r *Reader, progress io.Writer) *Demuxer {
+	return &Demuxer{r: r, progress: progress}
+}
+
+func (d *Demuxer) Read(p []byte) (int, error) {
+	for len(d.pending) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.pending, d.err = d.next()
+	}
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// next: 다음 데이터 채널 packet 의 내용
+func (d *Demuxer) next() ([]byte, error) {
+	kind, data, err := d.r.Read()
+	switch {
+	case errors.Is(err, io.EOF):
+		return nil, io.ErrUnexpectedEOF
+	case err != nil:
+		return nil, err
+	case kind == Flush:
+		return nil, io.EOF
+	case kind != Data:
+		return nil, fmt.Errorf("%w: unexpected %s packet in sideband stream", ErrInvalidPacket, kind)
+	case len(data) == 0:
+		return nil, fmt.Errorf("%w: empty sideband packet", ErrInvalidPacket)
+	}
+	switch data[0] {
+	case BandData:
+		return data[1:], nil
+	case BandProgress:
+		if d.progress != nil {
+			if _, err := d.progress.Write(data[1:]); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case BandError:
+		return nil, &RemoteError{Message: strings.TrimRight(string(data[1:]), "\n")}
+	}
+	if msg, ok := strings.CutPrefix(string(data), "ERR "); ok {
+		return nil, &RemoteError{Message: strings.TrimRight(msg, "\n")}
+	}
+	return nil, fmt.Errorf("%w: unknown sideband channel %d", ErrInvalidPacket, data[0])
+}
+
+// SidebandWriter: 쓴 내용을 한 채널의 packet 들로 나누어 쓰는 io.Writer
+type SidebandWriter struct {
+	w    *Writer
+	band byte
+	max  int
+	buf  []byte
+}
+
+// NewSidebandWriter: band 채널로 쓴다. max 는 packet 하나에 담을 최대 길이다.
+// (side-band-64k 면 MaxSidebandPayload, 옛 side-band 면 MaxSmallSidebandPayload)
+func NewSidebandWriter(w *Writer, band byte, max int) *SidebandWriter {
+	return &SidebandWriter{w: w, band: band, max: max}
+}
+
+func (s *SidebandWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), s.max)
+		s.buf = append(append(s.buf[:0], s.band), p[:n]...)
+		if _, err := s.w.Write(s.buf); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
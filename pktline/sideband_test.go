@@ -0,0 +1,109 @@
+package pktline
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// sideband: packets(첫 바이트가 채널)를 packet 하나씩 쓰고 flush 를 붙인 stream 의 Reader
+func sideband(t *testing.T, packets ...string) *Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, p := range packets {
+		if _, err := w.WriteString(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return NewReader(&buf)
+}
+
+func TestDemuxer(t *testing.T) {
+	tests := []struct {
+		name     string
+		packets  []string
+		data     string
+		progress string
+		err      string
+	}{
+		{
+			name:     "data and progress",
+			packets:  []string{"\x01PACK", "\x02Counting objects: 1\r", "\x01rest", "\x02done\n"},
+			data:     "PACKrest",
+			progress: "Counting objects: 1\rdone\n",
+		},
+		{
+			name:    "error channel",
+			packets: []string{"\x01PA", "\x03access denied\n", "\x01CK"},
+			data:    "PA",
+			err:     "remote error: access denied",
+		},
+		{
+			name:    "ERR packet",
+			packets: []string{"ERR no such repository\n"},
+			err:     "remote error: no such repository",
+		},
+		{
+			name:    "unknown channel",
+			packets: []string{"\x05?"},
+			err:     "invalid pkt-line: unknown sideband channel 5",
+		},
+	}
+	for _, tt := range tests {
+		var progress bytes.Buffer
+		d := NewDemuxer(sideband(t, tt.packets...), &progress)
+		data, err := io.ReadAll(d)
+		if string(data) != tt.data {
+			t.Errorf("%s: data = %q, want %q", tt.name, data, tt.data)
+		}
+		if progress.String() != tt.progress {
+			t.Errorf("%s: progress = %q, want %q", tt.name, progress.String(), tt.progress)
+		}
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: err = %v", tt.name, err)
+		case tt.err != "" && (err == nil || err.Error() != tt.err):
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestDemuxerRemoteErrorType(t *testing.T) {
+	_, err := io.ReadAll(NewDemuxer(sideband(t, "\x03boom"), nil))
+	var remote *RemoteError
+	if !errors.As(err, &remote) || remote.Message != "boom" {
+		t.Fatalf("err = %v, want *RemoteError boom", err)
+	}
+}
+
+func TestDemuxerTruncated(t *testing.T) {
+	// flush 없이 끝난 stream
+	r := NewReader(strings.NewReader("0009\x01PACK"))
+	if _, err := io.ReadAll(NewDemuxer(r, nil)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestSidebandWriterSplits(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewSidebandWriter(NewWriter(&buf), BandData, 4)
+	if n, err := sw.Write([]byte("abcdefghij")); err != nil || n != 10 {
+		t.Fatalf("Write = %d %v", n, err)
+	}
+	if err := NewWriter(&buf).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "0009\x01abcd0009\x01efgh0007\x01ij0000" {
+		t.Fatalf("packets = %q", got)
+	}
+	data, err := io.ReadAll(NewDemuxer(NewReader(&buf), nil))
+	if err != nil || string(data) != "abcdefghij" {
+		t.Fatalf("demuxed = %q %v", data, err)
+	}
+}
@@ -0,0 +1,351 @@
+// Package policy 는 커밋이 저장소 규칙(메시지 형식, 파일 크기, 금지 경로, 작성자, 커밋 시각)을 지키는지 검사한다.
+//
+// 규칙은 config 의 [check] 섹션에서 읽는다.
+//
+//	[check]
+//		conventional = true
+//		messagePattern = "^[A-Z]"
+//		maxFileSize = 1m
+//		forbiddenPath = *.key
+//		allowedAuthor = *@example.com
+//		whitespace = true
+//
+// whitespace 는 core.whitespace 의 규칙으로 커밋이 더한 줄을 검사한다. (whitespace 패키지 참고)
+//
+// push 를 받는 쪽에서 쓰는 규칙은 [receive] 섹션에 둔다. 시계가 틀린 컴퓨터에서 만든 커밋이나
+// 허용하지 않은 메일 주소로 만든 커밋을 막는다.
+//
+//	[receive]
+//		maxCommitAge = 1.year
+//		maxFutureSkew = 1.hour
+//		allowedCommitterDomain = example.com
+//		allowedCommitterDomain = *.example.com
+//
+// 기간은 "<n>.<단위>" (second, minute, hour, day, week, month, year. 복수형과 ".ago" 도 받는다)나
+// Go 의 기간 형식(72h)이다.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmdgusya/gogit/config"
+	"github.com/tmdgusya/gogit/diff"
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/whitespace"
+)
+
+// conventional commit: "type(scope)!: subject"
+var conventionalPattern = regexp.MustCompile(`^(feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert)(\([^)]+\))?!?: \S`)
+
+// Policy: 검사 규칙 모음. 값이 비어 있는 규칙은 검사하지 않는다.
+type Policy struct {
+	// Conventional: 메시지 첫 줄이 conventional commit 형식이어야 함
+	Conventional bool
+	// MessagePatterns: 메시지가 모두 만족해야 하는 정규식
+	MessagePatterns []*regexp.Regexp
+	// MaxFileSize: 커밋이 추가/변경한 파일의 최대 크기 (0 이면 제한 없음)
+	MaxFileSize int64
+	// ForbiddenPaths: 추가/변경하면 안 되는 경로의 glob. 전체 경로나 파일 이름 중 하나라도 맞으면 위반
+	ForbiddenPaths []string
+	// AllowedAuthors: 허용된 작성자 이메일 glob. 비어 있으면 모두 허용
+	AllowedAuthors []string
+	// Whitespace: 커밋이 더한 줄에 적용할 공백 규칙. nil 이면 검사하지 않는다
+	Whitespace *whitespace.Rules
+	// MaxCommitAge: committer 시각이 이보다 오래된 커밋은 위반 (0 이면 제한 없음)
+	MaxCommitAge time.Duration
+	// MaxFutureSkew: committer 시각이 지금보다 이만큼 넘게 미래인 커밋은 위반 (0 이면 제한 없음)
+	MaxFutureSkew time.Duration
+	// AllowedCommitterDomains: 허용된 committer 이메일 도메인 glob. 비어 있으면 모두 허용
+	AllowedCommitterDomains []string
+	// Now: 시각 규칙의 기준. zero 면 검사하는 때의 현재 시각
+	Now time.Time
+}
+
+// Violation: 규칙 위반 하나
+type Violation struct {
+	Commit  string
+	Rule    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s", v.Commit, v.Rule, v.Message)
+}
+
+// FromConfig: [check] 와 [receive] 섹션에서 규칙을 읽는다.
+func FromConfig(cfg *config.Config) (*Policy, error) {
+	p := &Policy{
+		ForbiddenPaths:          cfg.GetAll("check.forbiddenPath"),
+		AllowedAuthors:          cfg.GetAll("check.allowedAuthor"),
+		AllowedCommitterDomains: cfg.GetAll("receive.allowedCommitterDomain"),
+	}
+
+	var err error
+	if p.Conventional, err = cfg.GetBool("check.conventional", false); err != nil {
+		return nil, err
+	}
+	if p.MaxFileSize, err = cfg.GetInt("check.maxFileSize", 0); err != nil {
+		return nil, err
+	}
+	if on, err := cfg.GetBool("check.whitespace", false); err != nil {
+		return nil, err
+	} else if on {
+		value, _ := cfg.Get("core.whitespace")
+		rules, err := whitespace.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid core.whitespace: %w", err)
+		}
+		p.Whitespace = &rules
+	}
+	for _, pattern := range cfg.GetAll("check.messagePattern") {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid check.messagePattern %q: %w", pattern, err)
+		}
+		p.MessagePatterns = append(p.MessagePatterns, re)
+	}
+	for _, name := range []string{"receive.maxCommitAge", "receive.maxFutureSkew"} {
+		value, ok := cfg.Get(name)
+		if !ok {
+			continue
+		}
+		d, err := parseAge(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if name == "receive.maxCommitAge" {
+			p.MaxCommitAge = d
+		} else {
+			p.MaxFutureSkew = d
+		}
+	}
+	globs := append(append([]string(nil), p.ForbiddenPaths...), p.AllowedAuthors...)
+	for _, glob := range append(globs, p.AllowedCommitterDomains...) {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid check pattern %q: %w", glob, err)
+		}
+	}
+
+	return p, nil
+}
+
+// Check: 커밋 하나를 검사한다. 위반이 없으면 빈 슬라이스
+// 파일 규칙은 커밋이 새로 들여온 파일(모든 부모와 내용이 다른 경로)에만 적용한다.
+func (p *Policy) Check(s object.Storer, hash string) ([]Violation, error) {
+	return p.CheckContext(context.Background(), s, hash)
+}
+
+// CheckContext: 큰 tree 를 훑는 도중에도 ctx 가 취소되면 멈추는 Check
+func (p *Policy) CheckContext(ctx context.Context, s object.Storer, hash string) ([]Violation, error) {
+	commit, err := object.ReadCommit(s, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []Violation
+	add := func(rule string, format string, args ...any) {
+		violations = append(violations, Violation{Commit: hash, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if p.Conventional && !conventionalPattern.MatchString(commit.Subject()) {
+		add("conventional", "subject %q is not a conventional commit", commit.Subject())
+	}
+	for _, re := range p.MessagePatterns {
+		if !re.MatchString(commit.Message) {
+			add("message-pattern", "message does not match %q", re.String())
+		}
+	}
+
+	if len(p.AllowedAuthors) > 0 && !matchAny(p.AllowedAuthors, commit.Author.Email) {
+		add("author", "author %s <%s> is not allowed", commit.Author.Name, commit.Author.Email)
+	}
+
+	now := p.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	when := commit.Committer.When
+	if p.MaxCommitAge > 0 && when.Before(now.Add(-p.MaxCommitAge)) {
+		add("commit-age", "committer date %s is older than %s", formatDate(when), formatDate(now.Add(-p.MaxCommitAge)))
+	}
+	if p.MaxFutureSkew > 0 && when.After(now.Add(p.MaxFutureSkew)) {
+		add("future-date", "committer date %s is in the future", formatDate(when))
+	}
+	if len(p.AllowedCommitterDomains) > 0 {
+		_, domain, _ := strings.Cut(commit.Committer.Email, "@")
+		if !matchAny(p.AllowedCommitterDomains, strings.ToLower(domain)) {
+			add("committer-domain", "committer %s <%s> is not in an allowed domain", commit.Committer.Name, commit.Committer.Email)
+		}
+	}
+
+	if p.MaxFileSize > 0 || len(p.ForbiddenPaths) > 0 {
+		changed, err := changedFiles(ctx, s, commit)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range changed {
+			if matchAny(p.ForbiddenPaths, file.Path) || matchAny(p.ForbiddenPaths, path.Base(file.Path)) {
+				add("forbidden-path", "%s is a forbidden path", file.Path)
+			}
+			if p.MaxFileSize > 0 {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				size, err := blobSize(s, file.Hash)
+				if err != nil {
+					return nil, err
+				}
+				if size > p.MaxFileSize {
+					add("max-file-size", "%s is %d bytes (limit %d)", file.Path, size, p.MaxFileSize)
+				}
+			}
+		}
+	}
+
+	// merge commit 이 들여온 줄은 이미 각 부모 쪽 커밋에서 검사했으므로 보지 않는다
+	if p.Whitespace != nil && len(commit.Parents) <= 1 {
+		problems, err := CommitWhitespace(s, *p.Whitespace, commit)
+		if err != nil {
+			return nil, err
+		}
+		for _, problem := range problems {
+			add("whitespace", "%s:%d: %s", problem.Path, problem.Line, problem.Errors)
+		}
+	}
+
+	return violations, nil
+}
+
+// CommitWhitespace: 커밋이 첫 부모(root commit 이면 빈 tree)에 더한 줄의 공백 문제
+func CommitWhitespace(s object.Storer, rules whitespace.Rules, commit *object.Commit) ([]whitespace.Problem, error) {
+	var parentTree string
+	if len(commit.Parents) > 0 {
+		parent, err := object.ReadCommit(s, commit.Parents[0])
+		if err != nil {
+			return nil, err
+		}
+		parentTree = parent.Tree
+	}
+	changes, err := diff.Trees(s, parentTree, commit.Tree)
+	if err != nil {
+		return nil, err
+	}
+	return rules.CheckChanges(s, changes)
+}
+
+func formatDate(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05 -0700")
+}
+
+// parseAge: "90.days", "1 year", "2.weeks.ago", "72h" 같은 기간. 한 달은 30일, 한 해는 365일로 센다
+func parseAge(value string) (time.Duration, error) {
+	s := strings.TrimSpace(strings.ToLower(value))
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "ago"), ".")
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("bad duration '%s'", value)
+	}
+	units := map[string]time.Duration{
+		"second": time.Second, "minute": time.Minute, "hour": time.Hour, "day": 24 * time.Hour,
+		"week": 7 * 24 * time.Hour, "month": 30 * 24 * time.Hour, "year": 365 * 24 * time.Hour,
+	}
+	unit, ok := units[strings.TrimSuffix(strings.TrimLeft(strings.TrimSpace(s[i:]), "."), "s")]
+	if !ok {
+		return 0, fmt.Errorf("bad duration '%s'", value)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+func matchAny(globs []string, name string) bool {
+	for _, glob := range globs {
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// 커밋이 새로 들여온 파일 목록
+// merge commit 은 어느 한 부모에라도 같은 내용이 있으면 그쪽에서 온 것이므로 제외한다.
+func changedFiles(ctx context.Context, s object.Storer, commit *object.Commit) ([]object.WalkEntry, error) {
+	files, err := treeFiles(ctx, s, commit.Tree)
+	if err != nil {
+		return nil, err
+	}
+
+	var parentFiles []map[string]string
+	for _, parent := range commit.Parents {
+		parentCommit, err := object.ReadCommit(s, parent)
+		if err != nil {
+			return nil, err
+		}
+		pf, err := treeFiles(ctx, s, parentCommit.Tree)
+		if err != nil {
+			return nil, err
+		}
+		parentFiles = append(parentFiles, pf)
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var changed []object.WalkEntry
+	for _, p := range paths {
+		if !fromParent(parentFiles, p, files[p]) {
+			changed = append(changed, object.WalkEntry{Path: p, Hash: files[p]})
+		}
+	}
+	return changed, nil
+}
+
+func fromParent(parentFiles []map[string]string, name string, hash string) bool {
+	for _, pf := range parentFiles {
+		if pf[name] == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// tree 안의 파일(blob) 경로 -> 해시
+func treeFiles(ctx context.Context, s object.Storer, treeHash string) (map[string]string, error) {
+	files := map[string]string{}
+	w, err := object.NewTreeWalker(s, treeHash)
+	if err != nil {
+		return nil, err
+	}
+	err = w.ForEachContext(ctx, func(entry object.WalkEntry) error {
+		if entry.Mode.ObjectType() == object.TypeBlob {
+			files[entry.Path] = entry.Hash
+		}
+		return nil
+	})
+	return files, err
+}
+
+// 헤더만 읽어서 크기를 얻는다. (내용 전체를 읽지 않음)
+func blobSize(s object.Storer, hash string) (int64, error) {
+	r, err := s.Open(hash)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	return r.Size, nil
+}
@@ -0,0 +1,496 @@
+package pretty
+
+import "strings"
+
+// Graph: log --graph 의 ASCII 그래프
+// git 의 graph.c 와 같은 상태 기계로 줄마다 가지(column)를 배치하므로 출력이 git 과 같다.
+// 커밋은 위상 정렬 순서(자식이 부모보다 먼저)로 Update 해야 한다.
+//
+// 한 커밋의 출력은 여러 줄로 이루어진다.
+//
+//	PRE_COMMIT   부모가 3개 이상일 때 자리를 넓히는 줄
+//	COMMIT       '*' 가 있는 줄
+//	POST_MERGE   merge 의 가지가 갈라지는 줄 ("|\")
+//	COLLAPSING   같은 부모를 가리키게 된 가지를 모으는 줄 ("|/")
+//	PADDING      가지를 그대로 잇는 줄
+type Graph struct {
+	// interesting: 그래프에 나올 부모인지 (A..B 의 제외된 쪽은 그리지 않는다)
+	interesting func(hash string) bool
+
+	commit     string
+	parents    []string
+	numParents int
+	width      int
+
+	expansionRow    int
+	state           graphState
+	prevState       graphState
+	commitIndex     int
+	prevCommitIndex int
+	mergeLayout     int
+	edgesAdded      int
+	prevEdgesAdded  int
+
+	// columns: 이번 커밋 전의 가지들, newColumns: 이번 커밋 뒤의 가지들
+	columns    []string
+	newColumns []string
+	// mapping: 화면 열(2칸 단위) -> newColumns 의 인덱스 (-1 은 빈 칸)
+	mapping    []int
+	oldMapping []int
+}
+
+type graphState int
+
+const (
+	statePadding graphState = iota
+	stateSkip
+	statePreCommit
+	stateCommit
+	statePostMerge
+	stateCollapsing
+)
+
+// NewGraph: interesting 이 nil 이면 모든 부모를 그린다.
+func NewGraph(interesting func(hash string) bool) *Graph {
+	if interesting == nil {
+		interesting = func(string) bool { return true }
+	}
+	return &Graph{interesting: interesting, state: statePadding, prevState: statePadding}
+}
+
+func (g *Graph) setState(s graphState) {
+	g.prevState = g.state
+	g.state = s
+}
+
+// Update: 다음에 출력할 커밋을 알려 준다.
+func (g *Graph) Update(hash string, parents []string) {
+	g.commit = hash
+	g.parents = g.parents[:0]
+	for _, p := range parents {
+		if g.interesting(p) {
+			g.parents = append(g.parents, p)
+		}
+	}
+	g.numParents = len(g.parents)
+	g.prevCommitIndex = g.commitIndex
+
+	g.updateColumns()
+	g.expansionRow = 0
+
+	// 이전 커밋의 출력이 끝나지 않았으면 "..." 줄로 빠진 부분을 표시한다
+	switch {
+	case g.state != statePadding:
+		g.state = stateSkip
+	case g.needsPreCommitLine():
+		g.state = statePreCommit
+	default:
+		g.state = stateCommit
+	}
+}
+
+func (g *Graph) findNewColumn(hash string) int {
+	for i, c := range g.newColumns {
+		if c == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+func (g *Graph) insertIntoNewColumns(hash string, idx int) {
+	i := g.findNewColumn(hash)
+	if i < 0 {
+		i = len(g.newColumns)
+		g.newColumns = append(g.newColumns, hash)
+	}
+
+	var mappingIdx int
+	switch {
+	case g.numParents > 1 && idx > -1 && g.mergeLayout == -1:
+		// merge 의 첫 부모: 부모가 merge 보다 왼쪽에 있는지에 따라 가지 모양을 정한다
+		dist := idx - i
+		shift := 1
+		if dist > 1 {
+			shift = 2*dist - 3
+		}
+		g.mergeLayout = 1
+		if dist > 0 {
+			g.mergeLayout = 0
+		}
+		g.edgesAdded = g.numParents + g.mergeLayout - 2
+		mappingIdx = g.width + (g.mergeLayout-1)*shift
+		g.width += 2 * g.mergeLayout
+	case g.edgesAdded > 0 && i == g.mapping[g.width-2]:
+		// merge 로 늘어난 가지가 바로 옆 가지와 합쳐지는 경우
+		mappingIdx = g.width - 2
+		g.edgesAdded = -1
+	default:
+		mappingIdx = g.width
+		g.width += 2
+	}
+	g.mapping[mappingIdx] = i
+}
+
+func (g *Graph) updateColumns() {
+	g.columns, g.newColumns = g.newColumns, g.columns[:0]
+
+	maxNewColumns := len(g.columns) + g.numParents
+	g.mapping = make([]int, 2*maxNewColumns)
+	for i := range g.mapping {
+		g.mapping[i] = -1
+	}
+	// oldMapping 은 직전 COLLAPSING 줄의 결과를 커밋 줄에서 쓰므로 내용을 유지한 채 늘린다
+	for len(g.oldMapping) < len(g.mapping) {
+		g.oldMapping = append(g.oldMapping, -1)
+	}
+
+	g.width = 0
+	g.prevEdgesAdded = g.edgesAdded
+	g.edgesAdded = 0
+
+	seenThis := false
+	for i := 0; i <= len(g.columns); i++ {
+		var col string
+		if i == len(g.columns) {
+			if seenThis {
+				break
+			}
+			col = g.commit
+		} else {
+			col = g.columns[i]
+		}
+
+		if col == g.commit {
+			seenThis = true
+			g.commitIndex = i
+			g.mergeLayout = -1
+			for _, p := range g.parents {
+				g.insertIntoNewColumns(p, i)
+			}
+			// 부모가 없어도 커밋 자신이 2칸을 차지한다
+			if g.numParents == 0 {
+				g.width += 2
+			}
+		} else {
+			g.insertIntoNewColumns(col, -1)
+		}
+	}
+
+	for len(g.mapping) > 1 && g.mapping[len(g.mapping)-1] < 0 {
+		g.mapping = g.mapping[:len(g.mapping)-1]
+	}
+}
+
+func (g *Graph) numDashedParents() int {
+	return g.numParents + g.mergeLayout - 3
+}
+
+func (g *Graph) needsPreCommitLine() bool {
+	return g.numParents >= 3 &&
+		g.commitIndex < len(g.columns)-1 &&
+		g.expansionRow < g.numDashedParents()*2
+}
+
+func (g *Graph) isMappingCorrect() bool {
+	for i, target := range g.mapping {
+		if target >= 0 && target != i/2 {
+			return false
+		}
+	}
+	return true
+}
+
+// Finished: 이번 커밋의 그래프 줄을 모두 출력했는지
+func (g *Graph) Finished() bool {
+	return g.state == statePadding
+}
+
+// NextLine: 그래프 한 줄(줄바꿈 없음)과 그 줄이 커밋 줄인지
+func (g *Graph) NextLine() (string, bool) {
+	var b strings.Builder
+	commitLine := false
+	switch g.state {
+	case statePadding:
+		for range g.newColumns {
+			b.WriteString("| ")
+		}
+	case stateSkip:
+		b.WriteString("...")
+		if g.needsPreCommitLine() {
+			g.setState(statePreCommit)
+		} else {
+			g.setState(stateCommit)
+		}
+	case statePreCommit:
+		g.preCommitLine(&b)
+	case stateCommit:
+		g.commitLine(&b)
+		commitLine = true
+	case statePostMerge:
+		g.postMergeLine(&b)
+	case stateCollapsing:
+		g.collapsingLine(&b)
+	}
+	return g.pad(b.String()), commitLine
+}
+
+// PaddingLine: 커밋 사이나 메시지 옆에 쓰는, 가지를 그대로 잇는 줄
+func (g *Graph) PaddingLine() string {
+	if g.state != stateCommit {
+		line, _ := g.NextLine()
+		return line
+	}
+
+	var b strings.Builder
+	for _, col := range g.columns {
+		b.WriteByte('|')
+		if col == g.commit && g.numParents > 2 {
+			b.WriteString(strings.Repeat(" ", (g.numParents-2)*2))
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	g.prevState = statePadding
+	return g.pad(b.String())
+}
+
+// 한 커밋의 모든 줄이 같은 너비가 되도록 오른쪽을 공백으로 채운다
+func (g *Graph) pad(line string) string {
+	if len(line) < g.width {
+		return line + strings.Repeat(" ", g.width-len(line))
+	}
+	return line
+}
+
+func (g *Graph) preCommitLine(b *strings.Builder) {
+	seenThis := false
+	for i, col := range g.columns {
+		switch {
+		case col == g.commit:
+			seenThis = true
+			b.WriteByte('|')
+			b.WriteString(strings.Repeat(" ", g.expansionRow))
+		case seenThis && g.expansionRow == 0:
+			if g.prevState == statePostMerge && g.prevCommitIndex < i {
+				b.WriteByte('\\')
+			} else {
+				b.WriteByte('|')
+			}
+		case seenThis && g.expansionRow > 0:
+			b.WriteByte('\\')
+		default:
+			b.WriteByte('|')
+		}
+		b.WriteByte(' ')
+	}
+
+	g.expansionRow++
+	if !g.needsPreCommitLine() {
+		g.setState(stateCommit)
+	}
+}
+
+func (g *Graph) commitLine(b *strings.Builder) {
+	seenThis := false
+	for i := 0; i <= len(g.columns); i++ {
+		var col string
+		if i == len(g.columns) {
+			if seenThis {
+				break
+			}
+			col = g.commit
+		} else {
+			col = g.columns[i]
+		}
+
+		switch {
+		case col == g.commit:
+			seenThis = true
+			b.WriteByte('*')
+			if g.numParents > 2 {
+				// octopus merge 는 부모 수만큼 "-" 를 잇는다
+				dashed := g.numDashedParents()
+				for j := 0; j < dashed; j++ {
+					b.WriteByte('-')
+					if j == dashed-1 {
+						b.WriteByte('.')
+					} else {
+						b.WriteByte('-')
+					}
+				}
+			}
+		case seenThis && g.edgesAdded > 1:
+			b.WriteByte('\\')
+		case seenThis && g.edgesAdded == 1:
+			if g.prevState == statePostMerge && g.prevEdgesAdded > 0 && g.prevCommitIndex < i {
+				b.WriteByte('\\')
+			} else {
+				b.WriteByte('|')
+			}
+		case g.prevState == stateCollapsing && at(g.oldMapping, 2*i+1) == i && at(g.mapping, 2*i) < i:
+			b.WriteByte('/')
+		default:
+			b.WriteByte('|')
+		}
+		b.WriteByte(' ')
+	}
+
+	switch {
+	case g.numParents > 1:
+		g.setState(statePostMerge)
+	case g.isMappingCorrect():
+		g.setState(statePadding)
+	default:
+		g.setState(stateCollapsing)
+	}
+}
+
+// at: 범위를 벗어난 칸은 빈 칸(-1)으로 본다
+func at(mapping []int, i int) int {
+	if i < len(mapping) {
+		return mapping[i]
+	}
+	return -1
+}
+
+var mergeChars = []byte{'/', '|', '\\'}
+
+func (g *Graph) postMergeLine(b *strings.Builder) {
+	seenThis := false
+	firstParent := g.parents[0]
+	parentSeen := false
+	for i := 0; i <= len(g.columns); i++ {
+		var col string
+		if i == len(g.columns) {
+			if seenThis {
+				break
+			}
+			col = g.commit
+		} else {
+			col = g.columns[i]
+		}
+
+		switch {
+		case col == g.commit:
+			seenThis = true
+			idx := g.mergeLayout
+			for j := range g.parents {
+				b.WriteByte(mergeChars[idx])
+				if idx == 2 {
+					if g.edgesAdded > 0 || j < g.numParents-1 {
+						b.WriteByte(' ')
+					}
+				} else {
+					idx++
+				}
+			}
+			if g.edgesAdded == 0 {
+				b.WriteByte(' ')
+			}
+		case seenThis:
+			if g.edgesAdded > 0 {
+				b.WriteByte('\\')
+			} else {
+				b.WriteByte('|')
+			}
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('|')
+			if g.mergeLayout != 0 || i != g.commitIndex-1 {
+				if parentSeen {
+					b.WriteByte('_')
+				} else {
+					b.WriteByte(' ')
+				}
+			}
+		}
+
+		if col == firstParent {
+			parentSeen = true
+		}
+	}
+
+	if g.isMappingCorrect() {
+		g.setState(statePadding)
+	} else {
+		g.setState(stateCollapsing)
+	}
+}
+
+func (g *Graph) collapsingLine(b *strings.Builder) {
+	usedHorizontal := false
+	horizontalEdge := -1
+	horizontalEdgeTarget := -1
+
+	size := len(g.mapping)
+	g.mapping, g.oldMapping = g.oldMapping[:size], g.mapping
+	for i := range g.mapping {
+		g.mapping[i] = -1
+	}
+
+	for i := 0; i < size; i++ {
+		target := g.oldMapping[i]
+		if target < 0 {
+			continue
+		}
+
+		// 가지는 항상 제자리에 있거나 왼쪽으로만 움직인다
+		switch {
+		case target*2 == i:
+			g.mapping[i] = target
+		case g.mapping[i-1] < 0:
+			// 왼쪽이 비어 있으면 한 칸 왼쪽으로
+			g.mapping[i-1] = target
+			if horizontalEdge == -1 {
+				horizontalEdge = i
+				horizontalEdgeTarget = target
+				for j := target*2 + 3; j < i-2; j += 2 {
+					g.mapping[j] = target
+				}
+			}
+		case g.mapping[i-1] == target:
+			// 왼쪽 가지와 부모가 같으면 합친다
+		default:
+			// 다른 가지를 건너가야 한다
+			g.mapping[i-2] = target
+			if horizontalEdge == -1 {
+				horizontalEdgeTarget = target
+				horizontalEdge = i - 1
+				for j := target*2 + 3; j < i-2; j += 2 {
+					g.mapping[j] = target
+				}
+			}
+		}
+	}
+
+	copy(g.oldMapping, g.mapping)
+	if g.mapping[len(g.mapping)-1] < 0 {
+		g.mapping = g.mapping[:len(g.mapping)-1]
+	}
+
+	for i, target := range g.mapping {
+		switch {
+		case target < 0:
+			b.WriteByte(' ')
+		case target*2 == i:
+			b.WriteByte('|')
+		case target == horizontalEdgeTarget && i != horizontalEdge-1:
+			// 가로선은 첫 칸만 다음 줄로 이어진다
+			if i != target*2+3 {
+				g.mapping[i] = -1
+			}
+			usedHorizontal = true
+			b.WriteByte('_')
+		default:
+			if usedHorizontal && i < horizontalEdge {
+				g.mapping[i] = -1
+			}
+			b.WriteByte('/')
+		}
+	}
+
+	if g.isMappingCorrect() {
+		g.setState(statePadding)
+	}
+}
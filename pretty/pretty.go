@@ -0,0 +1,464 @@
+// Package pretty 는 커밋을 log/show 의 출력 형식으로 바꾼다.
+//
+// 미리 정의된 형식(medium, oneline)과 --pretty=format: 의 자리표시자를 지원한다.
+//
+//	%H  커밋 해시        %h  짧은 커밋 해시
+//	%T  tree 해시        %t  짧은 tree 해시
+//	%P  부모 해시들      %p  짧은 부모 해시들
+//	%an %ae %ad %ai %at  작성자 이름, 이메일, 날짜 (기본, ISO, unix)
+//	%cn %ce %cd %ci %ct  커미터 이름, 이메일, 날짜
+//	%aN %aE %cN %cE      mailmap 을 적용한 이름, 이메일 (Writer.SetMailmap)
+//	%s  제목             %b  본문             %B  메시지 전체
+//	%N  메모 (Writer.SetNotes)
+//	%n  줄바꿈           %%  '%'
+package pretty
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/tmdgusya/gogit/charset"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// AbbrevLen: 짧은 해시의 길이
+const AbbrevLen = 7
+
+// DateFormat: git 의 기본 날짜 형식
+const DateFormat = "Mon Jan 2 15:04:05 2006 -0700"
+
+// Short: 짧은 해시
+func Short(hash string) string {
+	if len(hash) > AbbrevLen {
+		return hash[:AbbrevLen]
+	}
+	return hash
+}
+
+// Subject: 메시지의 첫 문단을 한 줄로 이은 것 (git 의 %s)
+func Subject(message string) string {
+	subject, _ := splitMessage(message)
+	return subject
+}
+
+// Body: 첫 문단 뒤의 나머지 (git 의 %b)
+func Body(message string) string {
+	_, body := splitMessage(message)
+	return body
+}
+
+func splitMessage(message string) (string, string) {
+	lines := strings.Split(message, "\n")
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	var subject []string
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+		subject = append(subject, strings.TrimSpace(lines[i]))
+		i++
+	}
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	return strings.Join(subject, " "), strings.Join(lines[i:], "\n")
+}
+
+// Reencode: encoding 헤더의 인코딩으로 쓰인 메시지와 작성자/커미터 이름을 out 인코딩으로 바꾼 복사본
+// 헤더가 없으면 UTF-8 로 본다. 지원하지 않는 인코딩이거나 바꿀 수 없는 문자가 있으면 git 과 같이 원래 바이트를 그대로 둔다.
+func Reencode(c *object.Commit, out string) *object.Commit {
+	from, _ := c.Header("encoding")
+	if from == "" {
+		from = charset.UTF8
+	}
+	if out == "" {
+		out = charset.UTF8
+	}
+	if charset.Same(from, out) || !charset.Supported(out) {
+		return c
+	}
+	convert := func(s string) (string, bool) {
+		text, err := charset.ToUTF8(from, []byte(s))
+		if err != nil {
+			return "", false
+		}
+		data, err := charset.FromUTF8(out, text)
+		return string(data), err == nil
+	}
+
+	copied := *c
+	var ok [3]bool
+	copied.Message, ok[0] = convert(c.Message)
+	copied.Author.Name, ok[1] = convert(c.Author.Name)
+	copied.Committer.Name, ok[2] = convert(c.Committer.Name)
+	if ok != [3]bool{true, true, true} {
+		return c
+	}
+	return &copied
+}
+
+// Format: --pretty 의 값 하나
+// Name 이 "format" 이면 Template 의 자리표시자를 채운다.
+// Separator 가 true 면 커밋 사이에만 줄바꿈을 넣고(format:), false 면 각 커밋 뒤에 넣는다(tformat:).
+type Format struct {
+	Name      string
+	Template  string
+	Separator bool
+}
+
+var (
+	Medium  = Format{Name: "medium"}
+	Oneline = Format{Name: "oneline"}
+)
+
+// ParseFormat: --pretty/--format 의 값을 해석한다.
+// "format:..." 과 "tformat:..." 외에 '%' 가 들어 있는 값은 tformat 으로 본다. (git 과 동일)
+func ParseFormat(s string) (Format, error) {
+	switch {
+	case s == "medium" || s == "oneline":
+		return Format{Name: s}, nil
+	case strings.HasPrefix(s, "format:"):
+		return Format{Name: "format", Template: strings.TrimPrefix(s, "format:"), Separator: true}, nil
+	case strings.HasPrefix(s, "tformat:"):
+		return Format{Name: "format", Template: strings.TrimPrefix(s, "tformat:")}, nil
+	case strings.Contains(s, "%"):
+		return Format{Name: "format", Template: s}, nil
+	}
+	return Format{}, fmt.Errorf("invalid --pretty format: %s", s)
+}
+
+// Writer: 커밋을 차례로 출력한다. 형식마다 커밋 사이의 구분 방식이 달라서 출력한 개수를 기억한다.
+type Writer struct {
+	w      io.Writer
+	format Format
+	// abbrev: oneline 에서 짧은 해시를 쓸지 (--oneline 은 짧게, --pretty=oneline 은 전체)
+	abbrev bool
+	count  int
+	// graph: --graph 일 때 각 줄 앞에 붙일 그래프
+	graph *Graph
+	// missingNewline: 직전 커밋의 출력이 줄바꿈으로 끝나지 않았는지
+	missingNewline bool
+	// encoding: 출력 인코딩. 빈 문자열이면 UTF-8
+	encoding string
+	// mailmap: 작성자/커미터를 정식 이름으로 바꾸는 함수. nil 이면 바꾸지 않는다
+	mailmap func(object.Signature) object.Signature
+	// notes: 커밋에 붙은 메모. nil 이거나 빈 문자열이면 메모가 없다
+	notes func(hash string) string
+	// unsupported: encoding 헤더의 인코딩을 바꿀 수 없을 때 부른다. nil 이면 알리지 않는다
+	unsupported func(encoding string)
+}
+
+func NewWriter(w io.Writer, format Format, abbrev bool) *Writer {
+	return &Writer{w: w, format: format, abbrev: abbrev}
+}
+
+// SetGraph: 커밋마다 그래프를 함께 그린다. 커밋은 위상 정렬 순서로 Write 해야 한다.
+func (pw *Writer) SetGraph(g *Graph) {
+	pw.graph = g
+}
+
+// SetOutputEncoding: 커밋 메시지와 이름을 이 인코딩으로 바꿔서 출력한다. (i18n.logOutputEncoding)
+func (pw *Writer) SetOutputEncoding(name string) {
+	pw.encoding = name
+}
+
+// SetMailmap: medium 의 Author 줄과 %aN, %aE, %cN, %cE 에 fn 으로 바꾼 이름과 이메일을 쓴다.
+// %an, %ae 등은 커밋에 적힌 그대로다. (git log --use-mailmap)
+func (pw *Writer) SetMailmap(fn func(object.Signature) object.Signature) {
+	pw.mailmap = fn
+}
+
+// SetNotes: medium 의 메시지 뒤 "Notes:" 아래와 %N 에 fn 이 돌려준 메모를 쓴다. (git log 의 notes 표시)
+func (pw *Writer) SetNotes(fn func(hash string) string) {
+	pw.notes = fn
+}
+
+// SetUnsupportedEncoding: encoding 헤더의 인코딩을 지원하지 않아 메시지를 바꾸지 않고 출력할 때마다 fn 을 부른다.
+func (pw *Writer) SetUnsupportedEncoding(fn func(encoding string)) {
+	pw.unsupported = fn
+}
+
+// Write: 커밋 하나를 출력한다.
+func (pw *Writer) Write(hash string, commit *object.Commit) error {
+	if from, ok := commit.Header("encoding"); ok && pw.unsupported != nil && !charset.Supported(from) {
+		pw.unsupported(from)
+	}
+	commit = Reencode(commit, pw.encoding)
+	var note string
+	if pw.notes != nil {
+		note = pw.notes(hash)
+	}
+	g := pw.graph
+	if g != nil {
+		g.Update(hash, commit.Parents)
+	}
+
+	// oneline 과 tformat: 은 각 커밋 뒤에, 나머지는 커밋 사이에 줄바꿈을 넣는다
+	terminator := pw.format.Name == "oneline" || (pw.format.Name == "format" && !pw.format.Separator)
+
+	var b strings.Builder
+	if pw.count > 0 && !terminator {
+		if !pw.missingNewline && g != nil {
+			b.WriteString(g.PaddingLine())
+		}
+		b.WriteString("\n")
+	}
+	if g != nil {
+		// 커밋 줄까지의 그래프. 커밋 줄 뒤에는 줄바꿈 없이 내용이 이어진다
+		for !g.Finished() {
+			line, commitLine := g.NextLine()
+			b.WriteString(line)
+			if commitLine {
+				break
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	var msg string
+	switch pw.format.Name {
+	case "oneline":
+		h := hash
+		if pw.abbrev {
+			h = Short(hash)
+		}
+		b.WriteString(h + " ")
+		msg = Subject(commit.Message)
+	case "format":
+		msg = expand(pw.format.Template, hash, commit, pw.mailmap, note)
+	default:
+		fmt.Fprintf(&b, "commit %s\n", hash)
+		if g != nil {
+			line, _ := g.NextLine()
+			b.WriteString(line)
+		}
+		author := commit.Author
+		if pw.mailmap != nil {
+			author = pw.mailmap(author)
+		}
+		msg = medium(commit, author, note)
+	}
+	pw.writeMessage(&b, msg)
+
+	pw.missingNewline = !strings.HasSuffix(msg, "\n")
+	if terminator {
+		if !pw.missingNewline && g != nil {
+			b.WriteString(g.PaddingLine())
+		}
+		b.WriteString("\n")
+	}
+	pw.count++
+	_, err := io.WriteString(pw.w, b.String())
+	return err
+}
+
+// WritePatch: 방금 Write 한 커밋 뒤에 patch 를 붙인다. 빈 patch 면 아무것도 쓰지 않는다.
+// oneline 이 아니면 메시지와 patch 사이에 빈 줄을 넣고, --graph 면 patch 의 각 줄 앞에 그래프를 붙인다.
+func (pw *Writer) WritePatch(patch string) error {
+	if patch == "" {
+		return nil
+	}
+	g := pw.graph
+	prefix := func() string {
+		if g == nil {
+			return ""
+		}
+		return g.PaddingLine()
+	}
+
+	var b strings.Builder
+	if pw.format.Name != "oneline" && !(pw.format.Name == "format" && pw.format.Template == "") {
+		b.WriteString(prefix() + "\n")
+	}
+	for _, line := range strings.SplitAfter(patch, "\n") {
+		if line != "" {
+			b.WriteString(prefix() + line)
+		}
+	}
+	_, err := io.WriteString(pw.w, b.String())
+	return err
+}
+
+// WriteMergeSeparator: git show 가 merge 커밋 뒤(combined diff 앞)에 두는 빈 줄
+func (pw *Writer) WriteMergeSeparator() error {
+	line := "\n"
+	if pw.graph != nil {
+		line = pw.graph.PaddingLine() + line
+	}
+	_, err := io.WriteString(pw.w, line)
+	return err
+}
+
+// Shown: 지금까지 무언가를 출력했는지
+func (pw *Writer) Shown() bool {
+	return pw.count > 0
+}
+
+// MarkShown: Writer 밖에서 다른 내용(show 의 tag, tree 등)을 출력했음을 알린다.
+// 다음 커밋 앞에 형식에 맞는 구분 줄이 들어간다.
+func (pw *Writer) MarkShown() {
+	pw.count++
+	pw.missingNewline = false
+}
+
+// writeMessage: 둘째 줄부터 그래프를 앞에 붙이고, 남은 그래프 줄(merge 의 가지 등)을 마저 쓴다.
+func (pw *Writer) writeMessage(b *strings.Builder, msg string) {
+	g := pw.graph
+	if g == nil {
+		b.WriteString(msg)
+		return
+	}
+
+	lines := strings.SplitAfter(msg, "\n")
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		b.WriteString(line)
+		if i+1 < len(lines) && lines[i+1] != "" {
+			l, _ := g.NextLine()
+			b.WriteString(l)
+		}
+	}
+
+	if g.Finished() {
+		return
+	}
+	terminated := strings.HasSuffix(msg, "\n")
+	if !terminated {
+		b.WriteString("\n")
+	}
+	for {
+		line, _ := g.NextLine()
+		b.WriteString(line)
+		if g.Finished() {
+			break
+		}
+		b.WriteString("\n")
+	}
+	if terminated {
+		b.WriteString("\n")
+	}
+}
+
+// medium: "commit <hash>" 줄 뒤의 내용. 메모가 있으면 메시지 뒤에 "Notes:" 와 함께 들여 쓴다
+func medium(commit *object.Commit, author object.Signature, note string) string {
+	var b strings.Builder
+	if len(commit.Parents) > 1 {
+		short := make([]string, len(commit.Parents))
+		for i, p := range commit.Parents {
+			short[i] = Short(p)
+		}
+		fmt.Fprintf(&b, "Merge: %s\n", strings.Join(short, " "))
+	}
+	fmt.Fprintf(&b, "Author: %s <%s>\n", author.Name, author.Email)
+	fmt.Fprintf(&b, "Date:   %s\n\n", author.When.Format(DateFormat))
+	for _, line := range strings.Split(strings.TrimRight(commit.Message, "\n"), "\n") {
+		fmt.Fprintf(&b, "    %s\n", line)
+	}
+	if note != "" {
+		b.WriteString("\nNotes:\n")
+		for _, line := range strings.Split(strings.TrimRight(note, "\n"), "\n") {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+	}
+	return b.String()
+}
+
+// Expand: 자리표시자를 채운다. 모르는 자리표시자는 그대로 둔다. (git 과 동일)
+func Expand(template string, hash string, commit *object.Commit) string {
+	return expand(template, hash, commit, nil, "")
+}
+
+// expand: Expand 와 같지만 %aN 등에 mailmap 을 적용하고 %N 에 note 를 쓴다. mailmap 이 nil 이면 %an 등과 같다
+func expand(template string, hash string, commit *object.Commit, mailmap func(object.Signature) object.Signature, note string) string {
+	var b strings.Builder
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if c != '%' || i+1 >= len(template) {
+			b.WriteByte(c)
+			continue
+		}
+
+		rest := template[i+1:]
+		value, n := placeholder(rest, hash, commit, mailmap, note)
+		if n == 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString(value)
+		i += n
+	}
+	return b.String()
+}
+
+// placeholder: rest 의 앞에 있는 자리표시자의 값과 길이. 모르는 것이면 길이 0
+func placeholder(rest string, hash string, commit *object.Commit, mailmap func(object.Signature) object.Signature, note string) (string, int) {
+	switch rest[0] {
+	case 'H':
+		return hash, 1
+	case 'h':
+		return Short(hash), 1
+	case 'T':
+		return commit.Tree, 1
+	case 't':
+		return Short(commit.Tree), 1
+	case 'P':
+		return strings.Join(commit.Parents, " "), 1
+	case 'p':
+		short := make([]string, len(commit.Parents))
+		for i, p := range commit.Parents {
+			short[i] = Short(p)
+		}
+		return strings.Join(short, " "), 1
+	case 's':
+		return Subject(commit.Message), 1
+	case 'b':
+		return Body(commit.Message), 1
+	case 'B':
+		return commit.Message, 1
+	case 'N':
+		return note, 1
+	case 'n':
+		return "\n", 1
+	case '%':
+		return "%", 1
+	case 'a', 'c':
+		if len(rest) < 2 {
+			return "", 0
+		}
+		sig := commit.Author
+		if rest[0] == 'c' {
+			sig = commit.Committer
+		}
+		field := rest[1]
+		if field == 'N' || field == 'E' {
+			if mailmap != nil {
+				sig = mailmap(sig)
+			}
+			field += 'a' - 'A'
+		}
+		if value, ok := person(field, sig); ok {
+			return value, 2
+		}
+	}
+	return "", 0
+}
+
+func person(field byte, sig object.Signature) (string, bool) {
+	switch field {
+	case 'n':
+		return sig.Name, true
+	case 'e':
+		return sig.Email, true
+	case 'd':
+		return sig.When.Format(DateFormat), true
+	case 'i':
+		return sig.When.Format("2006-01-02 15:04:05 -0700"), true
+	case 't':
+		return strconv.FormatInt(sig.When.Unix(), 10), true
+	}
+	return "", false
+}
@@ -0,0 +1,147 @@
+// Package provenance 는 서명 없이 커밋 이력의 무결성을 확인하는 해시 체인을 만든다.
+//
+// 켜져 있으면(provenance.enabled = true) 새 커밋마다 헤더 하나를 붙인다.
+//
+//	provenance sha256:<부모들의 체인 값과 이 커밋 내용을 이은 것의 해시>
+//
+// 부모의 체인 값이 다음 커밋에 들어가므로, 이력 중간의 커밋을 바꾸면 그 뒤의 체인이 모두 어긋난다.
+// 누구나 다시 계산할 수 있으므로 서명처럼 작성자를 증명하지는 않는다. 이력이 조용히 바뀌지 않았는지만 확인한다.
+package provenance
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Header: 체인 값을 담는 커밋 헤더 이름
+const Header = "provenance"
+
+const prefix = "sha256:"
+
+// signatureHeader: 커밋 서명 헤더. 서명은 체인 값을 붙인 뒤에 하므로 계산에서 뺀다
+const signatureHeader = "gpgsig"
+
+// Checksum: 커밋의 체인 값. c 에 이미 있는 provenance 헤더와 서명은 계산에서 뺀다.
+// 헤더가 없는 부모(체인을 켜기 전의 커밋)는 그 커밋 해시를 체인의 시작점으로 쓴다.
+func Checksum(s object.Storer, c *object.Commit) (string, error) {
+	h := sha256.New()
+	io.WriteString(h, "provenance v1\n")
+	for _, parent := range c.Parents {
+		p, err := object.ReadCommit(s, parent)
+		if err != nil {
+			return "", err
+		}
+		link, ok := p.Header(Header)
+		if !ok {
+			link = "commit " + parent
+		}
+		fmt.Fprintf(h, "%s\n", link)
+	}
+	h.Write(strip(c, signatureHeader).Encode())
+	return prefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// strip: provenance 헤더와 keys 헤더들을 뺀 복사본
+func strip(c *object.Commit, keys ...string) *object.Commit {
+	copied := *c
+	copied.ExtraHeaders = nil
+	for _, h := range c.ExtraHeaders {
+		if h.Key != Header && !slices.Contains(keys, h.Key) {
+			copied.ExtraHeaders = append(copied.ExtraHeaders, h)
+		}
+	}
+	return &copied
+}
+
+// Stamp: 커밋에 체인 값을 붙인다. 이미 있으면 다시 계산한 값으로 바꾼다.
+func Stamp(s object.Storer, c *object.Commit) error {
+	sum, err := Checksum(s, c)
+	if err != nil {
+		return err
+	}
+	*c = *strip(c)
+	c.ExtraHeaders = append(c.ExtraHeaders, object.Header{Key: Header, Value: sum})
+	return nil
+}
+
+// Wrap: Write 로 저장하는 커밋마다 Stamp 하는 Storer. 다른 타입의 객체는 그대로 저장한다.
+func Wrap(s object.Storer) object.Storer {
+	return &storer{Storer: s}
+}
+
+type storer struct {
+	object.Storer
+}
+
+func (s *storer) Write(typ object.Type, content []byte) (string, error) {
+	if typ != object.TypeCommit {
+		return s.Storer.Write(typ, content)
+	}
+	var c object.Commit
+	if err := c.Decode(content); err != nil {
+		return "", err
+	}
+	if err := Stamp(s.Storer, &c); err != nil {
+		return "", err
+	}
+	return s.Storer.Write(typ, c.Encode())
+}
+
+// Broken: 체인 값이 맞지 않는 커밋
+type Broken struct {
+	Hash     string
+	Expected string
+	Actual   string
+}
+
+func (b Broken) String() string {
+	return fmt.Sprintf("%s: provenance mismatch (recorded %s, computed %s)", b.Hash, b.Actual, b.Expected)
+}
+
+// Report: Verify 의 결과
+type Report struct {
+	// Chained: 체인 값이 맞는 커밋 수
+	Chained int
+	// Unchained: 헤더가 없는 커밋 수 (체인을 켜기 전의 커밋 등)
+	Unchained int
+	Broken    []Broken
+}
+
+// OK: 어긋난 커밋이 없는지
+func (r *Report) OK() bool {
+	return len(r.Broken) == 0
+}
+
+// Verify: head 에서 도달 가능한 모든 커밋의 체인 값을 다시 계산해 본다.
+// 어긋난 커밋이 있어도 에러가 아니라 Report.Broken 에 모은다.
+func Verify(ctx context.Context, s object.Storer, head string) (*Report, error) {
+	report := &Report{}
+	it := object.NewCommitIter(s, []string{head}, object.OrderTopo)
+	err := it.ForEachContext(ctx, func(hash string, c *object.Commit) error {
+		recorded, ok := c.Header(Header)
+		if !ok {
+			report.Unchained++
+			return nil
+		}
+		sum, err := Checksum(s, c)
+		if err != nil {
+			return err
+		}
+		if sum != recorded {
+			report.Broken = append(report.Broken, Broken{Hash: hash, Expected: sum, Actual: recorded})
+			return nil
+		}
+		report.Chained++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
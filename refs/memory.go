@@ -0,0 +1,80 @@
+package refs
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryStore: 메모리 위의 ref 저장소
+type MemoryStore struct {
+	mu   sync.RWMutex
+	refs map[string]Ref
+	logs map[string][]ReflogEntry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{refs: map[string]Ref{}}
+}
+
+func (m *MemoryStore) Read(name string) (Ref, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.read(name)
+}
+
+// 디스크 구현과 같이 없는 ref 는 ErrNotFound 로 알린다.
+func (m *MemoryStore) read(name string) (Ref, error) {
+	ref, ok := m.refs[name]
+	if !ok {
+		return Ref{}, notFound(name)
+	}
+	return ref, nil
+}
+
+func (m *MemoryStore) Resolve(name string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return resolve(m.read, name)
+}
+
+func (m *MemoryStore) List() ([]Ref, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []Ref
+	for name, ref := range m.refs {
+		if strings.HasPrefix(name, "refs/") {
+			result = append(result, ref)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (m *MemoryStore) Update(name string, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refs[name] = Ref{Name: name, Hash: hash}
+	return nil
+}
+
+func (m *MemoryStore) Delete(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.refs[name]; !ok {
+		return notFound(name)
+	}
+	delete(m.refs, name)
+	delete(m.logs, name)
+	return nil
+}
+
+func (m *MemoryStore) SetSymbolic(name string, target string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refs[name] = Ref{Name: name, Target: target}
+	return nil
+}
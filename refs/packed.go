@@ -0,0 +1,149 @@
+package refs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// packedRefs: ref 들을 한 파일에 모아 둔 곳 (git 과 같은 이름과 형식)
+//
+//	# pack-refs with: sorted
+//	<hash> refs/heads/master
+//	<hash> refs/tags/v1.0
+//	^<hash>              (바로 위 annotated tag 가 가리키는 객체. 읽을 때는 무시한다)
+//
+// 같은 이름의 loose ref 파일이 있으면 그쪽이 더 최근 값이다.
+const packedRefs = "packed-refs"
+
+const packedHeader = "# pack-refs with: sorted \n"
+
+// readPacked: packed-refs 의 ref 들. 파일이 없으면 빈 map
+func (s *Store) readPacked() (map[string]string, error) {
+	data, err := vfs.ReadFile(s.fs, packedRefs)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	refs := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		hash, name, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("corrupt %s line: %q", packedRefs, line)
+		}
+		refs[name] = hash
+	}
+	return refs, nil
+}
+
+func encodePacked(packed map[string]string) string {
+	names := make([]string, 0, len(packed))
+	for name := range packed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(packedHeader)
+	for _, name := range names {
+		b.WriteString(packed[name] + " " + name + "\n")
+	}
+	return b.String()
+}
+
+// removePacked: packed-refs 에서 name 을 뺀다. 없으면 파일을 건드리지 않고 false
+// s.mu 를 잡은 채로 부른다.
+func (s *Store) removePacked(name string) (bool, error) {
+	packed, err := s.readPacked()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := packed[name]; !ok {
+		return false, nil
+	}
+	delete(packed, name)
+
+	lockName := packedRefs + ".lock"
+	f, err := s.fs.CreateExclusive(lockName)
+	if errors.Is(err, fs.ErrExist) {
+		return false, fmt.Errorf("unable to lock %s: %w", packedRefs, ErrLocked)
+	}
+	if err != nil {
+		return false, err
+	}
+	defer s.fs.Remove(lockName)
+	if _, err := f.Write([]byte(encodePacked(packed))); err != nil {
+		f.Close()
+		return false, err
+	}
+	if err := f.Close(); err != nil {
+		return false, err
+	}
+	return true, s.fs.Rename(lockName, packedRefs)
+}
+
+// Pack: refs/ 아래의 loose ref 들을 packed-refs 에 모으고 loose 파일을 지운다. (git pack-refs --all)
+// symbolic ref 는 옮기지 않는다. packed-refs.lock 으로 다른 Pack 과 조율하고,
+// 지우기 전에 loose 파일이 그사이 바뀌지 않았는지 확인한다.
+func (s *Store) Pack() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lockName := packedRefs + ".lock"
+	f, err := s.fs.CreateExclusive(lockName)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("unable to lock %s: %w", packedRefs, ErrLocked)
+	}
+	if err != nil {
+		return err
+	}
+	defer s.fs.Remove(lockName)
+
+	packed, err := s.readPacked()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	var loose []Ref
+	if err := s.walkLoose("refs", &loose); err != nil {
+		f.Close()
+		return err
+	}
+	var moved []Ref
+	for _, ref := range loose {
+		if ref.Target == "" {
+			packed[ref.Name] = ref.Hash
+			moved = append(moved, ref)
+		}
+	}
+
+	if _, err := f.Write([]byte(encodePacked(packed))); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := s.fs.Rename(lockName, packedRefs); err != nil {
+		return err
+	}
+
+	for _, ref := range moved {
+		current, err := s.read(ref.Name)
+		if err != nil || current != ref {
+			continue
+		}
+		if err := s.fs.Remove(ref.Name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
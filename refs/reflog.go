@@ -0,0 +1,180 @@
+package refs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// ZeroHash: reflog 에서 "없던 ref" 를 나타내는 해시 (SHA-1 저장소. 다른 해시 함수는 object.Algorithm.ZeroHash)
+const ZeroHash = "0000000000000000000000000000000000000000"
+
+// ReflogEntry: ref 가 한 번 움직인 기록
+// 디스크에는 git 과 같이 logs/<ref> 파일에 한 줄씩 쌓인다.
+//
+//	<old> <new> Name <email> 1700000000 +0900\t<message>
+type ReflogEntry struct {
+	// Old: 움직이기 전의 해시. 새로 만든 ref 면 ZeroHash
+	Old     string
+	New     string
+	Who     object.Signature
+	Message string
+}
+
+func (e ReflogEntry) String() string {
+	// 메시지는 한 줄이어야 한다
+	message := strings.ReplaceAll(e.Message, "\n", " ")
+	return fmt.Sprintf("%s %s %s\t%s", e.Old, e.New, e.Who, message)
+}
+
+// ParseReflogEntry: reflog 파일의 한 줄을 해석한다.
+func ParseReflogEntry(line string) (ReflogEntry, error) {
+	head, message, _ := strings.Cut(strings.TrimRight(line, "\n"), "\t")
+	fields := strings.SplitN(head, " ", 3)
+	if len(fields) != 3 || !object.IsHash(fields[0]) || !object.IsHash(fields[1]) {
+		return ReflogEntry{}, fmt.Errorf("invalid reflog entry: %q", line)
+	}
+	who, err := object.ParseSignature(fields[2])
+	if err != nil {
+		return ReflogEntry{}, err
+	}
+	return ReflogEntry{Old: fields[0], New: fields[1], Who: who, Message: message}, nil
+}
+
+// ReflogStorer: reflog 를 기록할 수 있는 Storer 가 구현한다. (Store, MemoryStore)
+type ReflogStorer interface {
+	AppendReflog(name string, entry ReflogEntry) error
+	// Reflog: 오래된 기록부터 돌려준다. 기록이 없으면 빈 목록
+	Reflog(name string) ([]ReflogEntry, error)
+	// ExpireReflog: keep 이 false 인 기록을 지우고 지운 수를 돌려준다.
+	ExpireReflog(name string, keep func(ReflogEntry) bool) (int, error)
+}
+
+// AppendReflog: ref 의 reflog 에 기록을 더한다. s 가 reflog 를 지원하지 않으면 errors.ErrUnsupported
+func AppendReflog(s Storer, name string, entry ReflogEntry) error {
+	if rs, ok := s.(ReflogStorer); ok {
+		return rs.AppendReflog(name, entry)
+	}
+	return fmt.Errorf("reflog of %s: %w", name, errors.ErrUnsupported)
+}
+
+// ExpireReflog: ref 의 reflog 에서 keep 이 false 인 기록을 지운다. s 가 reflog 를 지원하지 않으면 errors.ErrUnsupported
+func ExpireReflog(s Storer, name string, keep func(ReflogEntry) bool) (int, error) {
+	if rs, ok := s.(ReflogStorer); ok {
+		return rs.ExpireReflog(name, keep)
+	}
+	return 0, fmt.Errorf("reflog of %s: %w", name, errors.ErrUnsupported)
+}
+
+// ReadReflog: ref 의 reflog. s 가 reflog 를 지원하지 않으면 errors.ErrUnsupported
+func ReadReflog(s Storer, name string) ([]ReflogEntry, error) {
+	if rs, ok := s.(ReflogStorer); ok {
+		return rs.Reflog(name)
+	}
+	return nil, fmt.Errorf("reflog of %s: %w", name, errors.ErrUnsupported)
+}
+
+// AppendReflog: logs/<name> 에 한 줄을 더한다. ref 와 같은 .lock 방식으로 다른 쓰기와 조율한다.
+func (s *Store) AppendReflog(name string, entry ReflogEntry) error {
+	logName := "logs/" + name
+	return s.writeFunc(logName, func() (string, error) {
+		data, err := vfs.ReadFile(s.fs, logName)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		return string(data) + entry.String() + "\n", nil
+	})
+}
+
+func (s *Store) Reflog(name string) ([]ReflogEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := vfs.ReadFile(s.fs, "logs/"+name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []ReflogEntry
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		e, err := ParseReflogEntry(line)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// ExpireReflog: 남길 기록만 다시 쓴다. AppendReflog 와 같은 .lock 으로 조율한다.
+func (s *Store) ExpireReflog(name string, keep func(ReflogEntry) bool) (int, error) {
+	logName := "logs/" + name
+	if !vfs.Exists(s.fs, logName) {
+		return 0, nil
+	}
+	removed := 0
+	err := s.writeFunc(logName, func() (string, error) {
+		data, err := vfs.ReadFile(s.fs, logName)
+		if err != nil {
+			return "", err
+		}
+		var b strings.Builder
+		for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+			e, err := ParseReflogEntry(line)
+			if err != nil {
+				return "", err
+			}
+			if !keep(e) {
+				removed++
+				continue
+			}
+			b.WriteString(line + "\n")
+		}
+		return b.String(), nil
+	})
+	return removed, err
+}
+
+func (m *MemoryStore) AppendReflog(name string, entry ReflogEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.logs == nil {
+		m.logs = map[string][]ReflogEntry{}
+	}
+	m.logs[name] = append(m.logs[name], entry)
+	return nil
+}
+
+func (m *MemoryStore) Reflog(name string) ([]ReflogEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]ReflogEntry(nil), m.logs[name]...), nil
+}
+
+func (m *MemoryStore) ExpireReflog(name string, keep func(ReflogEntry) bool) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var kept []ReflogEntry
+	for _, e := range m.logs[name] {
+		if keep(e) {
+			kept = append(kept, e)
+		}
+	}
+	removed := len(m.logs[name]) - len(kept)
+	if m.logs != nil {
+		m.logs[name] = kept
+	}
+	return removed, nil
+}
@@ -0,0 +1,236 @@
+// Package refs 는 HEAD 와 refs/ 아래의 참조를 읽고 쓴다.
+package refs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// symbolic ref 는 "ref: refs/heads/master" 처럼 다른 ref 를 가리킨다.
+const symrefPrefix = "ref: "
+
+// Ref: 참조 하나
+// Target 이 있으면 symbolic ref, 없으면 Hash 를 직접 가리키는 ref 이다.
+type Ref struct {
+	Name   string
+	Target string
+	Hash   string
+}
+
+// Store: ref 저장소 (gogitDir 기준 HEAD, refs/...)
+//
+// 동시성: 하나의 Store 는 여러 goroutine 에서 함께 사용해도 안전하다.
+// 읽기(Read/Resolve/List)는 읽기 잠금 아래에서 수행되므로 진행 중인 쓰기의 중간 상태를 보지 않는다.
+// 쓰기는 git 과 같이 "<ref>.lock" 파일을 배타적으로 만든 뒤 내용을 쓰고 rename 하므로
+// 다른 프로세스와도 충돌하지 않고, 읽는 쪽은 항상 완전한 파일만 보게 된다.
+type Store struct {
+	fs vfs.Filesystem
+	mu sync.RWMutex
+}
+
+var (
+	// ErrLocked: 다른 쓰기가 진행 중이라 ref 를 잠글 수 없음
+	ErrLocked = errors.New("ref is locked by another writer")
+	// ErrNotFound: 없는 ref (init 직후 아직 커밋이 없는 브랜치 포함)
+	ErrNotFound = errors.New("ref not found")
+)
+
+// NewStore: fs 의 루트는 .gogit 디렉토리여야 한다.
+func NewStore(fs vfs.Filesystem) *Store {
+	return &Store{fs: fs}
+}
+
+// Read: ref 파일 하나를 읽는다. symbolic ref 를 따라가지는 않는다.
+func (s *Store) Read(name string) (Ref, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.read(name)
+}
+
+func (s *Store) read(name string) (Ref, error) {
+	content, err := vfs.ReadFile(s.fs, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		packed, err := s.readPacked()
+		if err != nil {
+			return Ref{}, err
+		}
+		if hash, ok := packed[name]; ok {
+			return Ref{Name: name, Hash: hash}, nil
+		}
+		return Ref{}, notFound(name)
+	}
+	if err != nil {
+		return Ref{}, err
+	}
+
+	value := strings.TrimSpace(string(content))
+	if target, ok := strings.CutPrefix(value, symrefPrefix); ok {
+		return Ref{Name: name, Target: target}, nil
+	}
+	return Ref{Name: name, Hash: value}, nil
+}
+
+// Resolve: symbolic ref 를 끝까지 따라가서 해시를 돌려준다.
+// 아직 커밋이 없는 브랜치(예: init 직후의 HEAD)는 에러를 돌려준다.
+// 따라가는 동안 잠금을 유지하므로 중간에 ref 가 바뀌어도 섞인 결과를 보지 않는다.
+func (s *Store) Resolve(name string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return resolve(s.read, name)
+}
+
+// List: refs/ 아래의 모든 ref 를 이름순으로 돌려준다. (HEAD 제외)
+// loose ref 와 packed-refs 를 합치며, 같은 이름이면 loose ref 가 이긴다.
+// 한 번의 읽기 잠금 안에서 모두 읽으므로 같은 시점의 스냅샷이다.
+func (s *Store) List() ([]Ref, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []Ref
+	if err := s.walkLoose("refs", &result); err != nil {
+		return nil, err
+	}
+	packed, err := s.readPacked()
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range result {
+		delete(packed, ref.Name)
+	}
+	for name, hash := range packed {
+		result = append(result, Ref{Name: name, Hash: hash})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// walkLoose: dir 아래의 ref 파일들
+func (s *Store) walkLoose(dir string, result *[]Ref) error {
+	entries, err := s.fs.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if err := s.walkLoose(name, result); err != nil {
+				return err
+			}
+			continue
+		}
+		// 쓰기 중인 잠금 파일은 ref 가 아니다
+		if strings.HasSuffix(name, ".lock") {
+			continue
+		}
+		ref, err := s.read(name)
+		if err != nil {
+			return err
+		}
+		*result = append(*result, ref)
+	}
+	return nil
+}
+
+// Update: ref 가 hash 를 가리키도록 기록
+func (s *Store) Update(name string, hash string) error {
+	return s.write(name, hash+"\n")
+}
+
+// SetSymbolic: ref 가 다른 ref(target)를 가리키도록 기록
+func (s *Store) SetSymbolic(name string, target string) error {
+	return s.write(name, symrefPrefix+target+"\n")
+}
+
+// Delete: ref 와 그 reflog 를 지운다. loose 파일과 packed-refs 양쪽에서 지우고, 어디에도 없으면 ErrNotFound
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lockName := name + ".lock"
+	f, err := s.fs.CreateExclusive(lockName)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("unable to lock %s: %w", name, ErrLocked)
+	}
+	if err != nil {
+		return err
+	}
+	f.Close()
+	defer s.fs.Remove(lockName)
+
+	found := true
+	if err := s.fs.Remove(name); errors.Is(err, fs.ErrNotExist) {
+		found = false
+	} else if err != nil {
+		return err
+	}
+	packed, err := s.removePacked(name)
+	if err != nil {
+		return err
+	}
+	if !found && !packed {
+		return notFound(name)
+	}
+	if err := s.fs.Remove("logs/" + name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// write: "<name>.lock" 을 배타적으로 만들어 잠근 뒤 내용을 쓰고 rename 한다.
+// 다른 프로세스가 이미 잠금을 잡고 있으면 ErrLocked 를 돌려준다.
+func (s *Store) write(name string, content string) error {
+	return s.writeFunc(name, func() (string, error) { return content, nil })
+}
+
+// writeFunc: write 와 같지만 쓸 내용을 잠금을 잡은 뒤에 만든다. (지금 내용에 덧붙일 때)
+func (s *Store) writeFunc(name string, content func() (string, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.fs.MkdirAll(path.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	lockName := name + ".lock"
+	f, err := s.fs.CreateExclusive(lockName)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("unable to lock %s: %w", name, ErrLocked)
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := content()
+	if err != nil {
+		f.Close()
+		s.fs.Remove(lockName)
+		return err
+	}
+	if _, err := f.Write([]byte(data)); err != nil {
+		f.Close()
+		s.fs.Remove(lockName)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		s.fs.Remove(lockName)
+		return err
+	}
+	if err := s.fs.Rename(lockName, name); err != nil {
+		s.fs.Remove(lockName)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package refs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Refspec: 원격의 ref 를 로컬의 어느 ref 로 가져올지 정하는 규칙 (git refspec)
+//
+//	+refs/heads/*:refs/remotes/origin/*
+//
+// 앞의 + 는 fast-forward 가 아니어도 덮어쓴다는 뜻이다. * 는 양쪽에 하나씩만 쓸 수 있다.
+// Dst 가 비어 있으면 가져오기만 하고 로컬 ref 는 바꾸지 않는다.
+type Refspec struct {
+	Force bool
+	Src   string
+	Dst   string
+}
+
+// ParseRefspec: "[+]<src>[:<dst>]"
+func ParseRefspec(s string) (Refspec, error) {
+	var spec Refspec
+	rest, force := strings.CutPrefix(s, "+")
+	spec.Force = force
+	spec.Src, spec.Dst, _ = strings.Cut(rest, ":")
+	if spec.Src == "" || strings.Count(spec.Src, "*") > 1 || strings.Count(spec.Src, "*") != strings.Count(spec.Dst, "*") && spec.Dst != "" {
+		return Refspec{}, fmt.Errorf("invalid refspec '%s'", s)
+	}
+	return spec, nil
+}
+
+// Map: 원격 ref name 이 Src 와 맞으면 가져올 로컬 ref 이름. Dst 가 비어 있으면 빈 문자열과 true
+func (r Refspec) Map(name string) (string, bool) {
+	prefix, suffix, glob := strings.Cut(r.Src, "*")
+	if !glob {
+		return r.Dst, name == r.Src
+	}
+	if len(name) < len(prefix)+len(suffix) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return "", false
+	}
+	return strings.Replace(r.Dst, "*", name[len(prefix):len(name)-len(suffix)], 1), true
+}
+
+// Reverse: Src 와 Dst 를 바꾼 refspec. 로컬 ref 가 원격의 어느 ref 에서 왔는지 찾을 때 쓴다
+func (r Refspec) Reverse() Refspec {
+	return Refspec{Force: r.Force, Src: r.Dst, Dst: r.Src}
+}
+
+// String: ParseRefspec 이 읽는 형식
+func (r Refspec) String() string {
+	s := r.Src
+	if r.Dst != "" {
+		s += ":" + r.Dst
+	}
+	if r.Force {
+		s = "+" + s
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+package refs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Storer: ref 저장소 인터페이스
+// 디스크(Store)와 메모리(MemoryStore) 구현이 있다.
+type Storer interface {
+	// Read: ref 하나를 읽는다. symbolic ref 를 따라가지는 않는다.
+	Read(name string) (Ref, error)
+	// Resolve: symbolic ref 를 끝까지 따라가서 해시를 돌려준다.
+	Resolve(name string) (string, error)
+	// List: refs/ 아래의 모든 ref 를 이름순으로 돌려준다. (HEAD 제외)
+	List() ([]Ref, error)
+	Update(name string, hash string) error
+	SetSymbolic(name string, target string) error
+}
+
+var (
+	_ Storer = (*Store)(nil)
+	_ Storer = (*MemoryStore)(nil)
+
+	_ ReflogStorer = (*Store)(nil)
+	_ ReflogStorer = (*MemoryStore)(nil)
+
+	_ Packer = (*Store)(nil)
+
+	_ Deleter = (*Store)(nil)
+	_ Deleter = (*MemoryStore)(nil)
+)
+
+// Packer: loose ref 들을 한 파일로 모을 수 있는 저장소가 구현한다. (Store)
+type Packer interface {
+	Pack() error
+}
+
+// Deleter: ref 를 지울 수 있는 저장소가 구현한다.
+type Deleter interface {
+	// Delete: ref 와 그 reflog 를 지운다. 없는 ref 면 ErrNotFound
+	Delete(name string) error
+}
+
+// Delete: s 가 Deleter 가 아니면 errors.ErrUnsupported
+func Delete(s Storer, name string) error {
+	if d, ok := s.(Deleter); ok {
+		return d.Delete(name)
+	}
+	return fmt.Errorf("deleting %s: %w", name, errors.ErrUnsupported)
+}
+
+// symbolic ref 를 따라가는 공통 로직. read 는 잠금 없이 ref 하나를 읽는 함수
+func resolve(read func(name string) (Ref, error), name string) (string, error) {
+	// HEAD -> refs/heads/master -> ... 가 순환하지 않도록 깊이를 제한
+	for depth := 0; depth < 5; depth++ {
+		ref, err := read(name)
+		if err != nil {
+			return "", fmt.Errorf("cannot resolve ref %s: %w", name, err)
+		}
+		if ref.Target == "" {
+			return ref.Hash, nil
+		}
+		name = ref.Target
+	}
+	return "", fmt.Errorf("symbolic ref %s is nested too deeply", name)
+}
+
+func notFound(name string) error {
+	return fmt.Errorf("%w: %s", ErrNotFound, name)
+}
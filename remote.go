@@ -0,0 +1,83 @@
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"github.com/tmdgusya/gogit/refs"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// Remote: config 의 [remote "<name>"] 섹션. URL 은 모두 url.<base>.insteadOf 를 적용한 것이다.
+//
+//	[remote "origin"]
+//		url = https://example.com/repo.git
+//		pushurl = ssh://a.example.com/repo.git   (여러 개면 push 할 때 모두에 보낸다)
+//		pushurl = ssh://b.example.com/repo.git
+//		fetch = +refs/heads/*:refs/remotes/origin/*
+type Remote struct {
+	Name string
+	// URLs: 가져올 때 쓰는 url 들. 첫 번째를 쓴다
+	URLs []string
+	// PushURLs: 보낼 곳들. pushurl 이 없으면 url 에 pushInsteadOf 를 적용한 것, 그것도 없으면 URLs 와 같다
+	PushURLs []string
+	Fetch    []refs.Refspec
+}
+
+// Remote: 이름이 name 인 remote. url 이 없으면 ErrNoSuchRemote
+// pushurl 과 pushInsteadOf 의 관계는 git 과 같다: pushurl 이 있으면 pushInsteadOf 는 쓰지 않는다.
+func (r *Repository) Remote(name string) (*Remote, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+	urls := cfg.GetAll("remote." + name + ".url")
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("%w '%s'", ErrNoSuchRemote, name)
+	}
+	remote := &Remote{Name: name}
+	for _, u := range cfg.GetAll("remote." + name + ".pushurl") {
+		u, _ = cfg.RewriteURL(u, false)
+		remote.PushURLs = append(remote.PushURLs, u)
+	}
+	aliased := len(remote.PushURLs) == 0
+	for _, u := range urls {
+		if aliased {
+			if p, ok := cfg.RewriteURL(u, true); ok {
+				remote.PushURLs = append(remote.PushURLs, p)
+			}
+		}
+		u, _ = cfg.RewriteURL(u, false)
+		remote.URLs = append(remote.URLs, u)
+	}
+	if len(remote.PushURLs) == 0 {
+		remote.PushURLs = slices.Clone(remote.URLs)
+	}
+	for _, s := range cfg.GetAll("remote." + name + ".fetch") {
+		spec, err := refs.ParseRefspec(s)
+		if err != nil {
+			return nil, err
+		}
+		remote.Fetch = append(remote.Fetch, spec)
+	}
+	return remote, nil
+}
+
+// EditConfig: .gogit/config 의 내용을 edit 으로 고쳐서 다시 쓴다. 파일이 없으면 빈 내용에서 시작한다.
+// config 패키지의 Add, Unset, Replace, RemoveSection 을 edit 안에서 쓰면 된다.
+func (r *Repository) EditConfig(edit func(data []byte) ([]byte, error)) error {
+	if r.FS == nil {
+		return fmt.Errorf("editing config: %w", errors.ErrUnsupported)
+	}
+	data, err := vfs.ReadFile(r.FS, "config")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	data, err = edit(data)
+	if err != nil {
+		return err
+	}
+	return vfs.WriteFile(r.FS, "config", data)
+}
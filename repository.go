@@ -0,0 +1,660 @@
+// Package gogit 은 git 의 내부 구조를 직접 구현해 보는 라이브러리다.
+// Init/Open 으로 *Repository 를 얻은 뒤 Objects, Refs 로 객체와 참조를 다룬다.
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tmdgusya/gogit/attr"
+	"github.com/tmdgusya/gogit/config"
+	"github.com/tmdgusya/gogit/ignore"
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/provenance"
+	"github.com/tmdgusya/gogit/refs"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// DefaultDirName: 작업 트리 안의 저장소 디렉토리 이름
+const DefaultDirName = ".gogit"
+
+// errors.Is 로 구분할 수 있는 에러 값
+// 객체 관련 에러는 object 패키지의 값을 그대로 쓰므로 어느 쪽 이름으로 비교해도 된다.
+var (
+	// ErrNotARepository: 저장소 디렉토리를 찾지 못함
+	ErrNotARepository = errors.New("not a gogit repository")
+	// ErrNoWorkTree: 작업 트리가 필요한 명령을 bare 저장소에서 실행함
+	ErrNoWorkTree = errors.New("this operation must be run in a work tree")
+	// ErrUnknownRevision: 해시로도 ref 로도 해석되지 않는 이름
+	ErrUnknownRevision = errors.New("unknown revision")
+	// ErrAmbiguousRevision: 짧은 해시에 맞는 객체가 여럿임
+	ErrAmbiguousRevision = errors.New("ambiguous revision")
+	// ErrNoSuchRemote: config 에 없는 remote
+	ErrNoSuchRemote = errors.New("no such remote")
+	// ErrObjectNotFound: 없는 객체
+	ErrObjectNotFound = object.ErrNotFound
+	// ErrInvalidObject: 형식이 잘못된 객체
+	ErrInvalidObject = object.ErrInvalid
+	// ErrCorruptObject: 내용의 해시가 이름과 다른 객체
+	ErrCorruptObject = object.ErrCorrupt
+)
+
+// Options: 저장소를 만들거나 열 때의 옵션
+// DirName 을 ".git" 으로 주면 실제 git 저장소를 그대로 다룰 수 있다. (객체 포맷이 같기 때문)
+type Options struct {
+	DirName string
+	Bare    bool
+	// NoVerify: 객체를 읽을 때 해시를 다시 계산해 확인하지 않는다. (object.Store.SetVerify)
+	NoVerify bool
+	// ObjectFormat: 새 저장소의 해시 함수 ("sha1" 또는 "sha256", 빈 문자열이면 sha1). 여는 저장소는 config 를 따른다
+	ObjectFormat string
+}
+
+// RepoDirName: 작업 트리 안의 저장소 디렉토리 이름. DirName 이 비어 있으면 DefaultDirName
+func (o Options) RepoDirName() string {
+	if o.DirName == "" {
+		return DefaultDirName
+	}
+	return o.DirName
+}
+
+// Repository: 저장소 핸들
+// GogitDir 은 객체와 ref 가 저장되는 곳, WorkTree 는 사용자가 작업하는 파일이 있는 곳이다.
+// bare 저장소는 WorkTree 가 빈 문자열이다.
+// FS 는 GogitDir 을 루트로 하는 파일시스템으로, 저장소 내부 접근은 모두 FS 를 거친다.
+// 메모리 위의 저장소(InitFS/OpenFS)는 GogitDir 이 비어 있다.
+// 추가 작업 트리(worktrees.go)에서 열면 GogitDir 은 그 작업 트리의 상태 디렉토리이고,
+// 객체와 ref 가 있는 함께 쓰는 디렉토리는 CommonDir 이다. 그 밖에는 CommonDir 과 GogitDir 이 같다.
+// Objects/Refs 는 인터페이스이므로 New 로 다른 저장소 구현을 끼울 수 있고, 이때 FS 는 nil 이다.
+//
+// 하나의 Repository 는 여러 goroutine 에서 동시에 사용해도 안전하다.
+// 객체 읽기는 잠금 없이 동작하고, ref 읽기는 일관된 스냅샷을 보며,
+// ref 쓰기는 .lock 파일로 다른 goroutine/프로세스와 조율한다. (object.Store, refs.Store 참고)
+// 필드 자체는 Open 이후 바꾸지 않는 것을 전제로 한다.
+type Repository struct {
+	GogitDir  string
+	CommonDir string
+	WorkTree  string
+
+	FS      vfs.Filesystem
+	Objects object.Storer
+	Refs    refs.Storer
+}
+
+// New: 임의의 객체/ref 저장소로 Repository 를 만든다. 작업 트리는 없다.
+func New(objects object.Storer, refs refs.Storer) *Repository {
+	return &Repository{Objects: objects, Refs: refs}
+}
+
+// InitMemory: 디스크를 전혀 쓰지 않는 메모리 저장소를 만든다. (HEAD 는 refs/heads/master)
+func InitMemory() (*Repository, error) {
+	repo := New(object.NewMemoryStore(), refs.NewMemoryStore())
+	if err := repo.Refs.SetSymbolic("HEAD", "refs/heads/master"); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// Init: path 에 저장소를 만든다. (path/.gogit)
+func Init(path string) (*Repository, error) {
+	return InitWithOptions(path, Options{})
+}
+
+// InitWithOptions: 저장소 초기화
+// bare 저장소는 작업 트리 없이 objects/refs/HEAD 가 path 에 바로 생긴다.
+// 이미 저장소가 있으면 HEAD 와 config 는 건드리지 않는다.
+func InitWithOptions(path string, opts Options) (*Repository, error) {
+	gogitDir := filepath.Join(path, opts.RepoDirName())
+	workTree := path
+	if opts.Bare {
+		gogitDir = path
+		workTree = ""
+	}
+	return InitDirWithOptions(gogitDir, workTree, opts)
+}
+
+// InitDir: 저장소 디렉토리를 직접 지정해서 초기화 (GOGIT_DIR 처럼 작업 트리와 분리된 경우)
+func InitDir(gogitDir string, workTree string, bare bool) (*Repository, error) {
+	return InitDirWithOptions(gogitDir, workTree, Options{Bare: bare})
+}
+
+// InitDirWithOptions: InitDir 과 같고 opts 의 Bare, ObjectFormat 을 따른다.
+func InitDirWithOptions(gogitDir string, workTree string, opts Options) (*Repository, error) {
+	repo, err := initFS(vfs.NewOS(gogitDir), opts)
+	if err != nil {
+		return nil, err
+	}
+	repo.GogitDir = gogitDir
+	repo.CommonDir = gogitDir
+	repo.WorkTree = workTree
+	return repo, nil
+}
+
+// InitFS: 임의의 파일시스템(fsys 의 루트가 저장소 디렉토리)에 저장소를 만든다.
+// vfs.NewMemory() 를 넘기면 디스크를 전혀 건드리지 않는 저장소가 된다.
+func InitFS(fsys vfs.Filesystem, bare bool) (*Repository, error) {
+	return initFS(fsys, Options{Bare: bare})
+}
+
+func initFS(fsys vfs.Filesystem, opts Options) (*Repository, error) {
+	algo, err := object.ParseAlgorithm(opts.ObjectFormat)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range []string{".", "objects", "refs"} {
+		if err := fsys.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("creating directory %s: %w", dir, err)
+		}
+	}
+
+	// 나중에 저장소를 열 때 bare 여부를 알 수 있도록 config 에 기록
+	// filemode 는 이 파일시스템이 실행 비트를 보존하는지 직접 확인해서 정한다. (git 과 동일)
+	// SHA-256 저장소는 git 과 같이 format version 1 과 extensions.objectFormat 으로 표시한다.
+	// 저장소를 열 때 config 를 읽어 해시 함수를 정하므로 config 를 먼저 쓴다.
+	if !vfs.Exists(fsys, "config") {
+		config := fmt.Sprintf("[core]\n\tbare = %t\n\tfilemode = %t\n", opts.Bare, probeFileMode(fsys))
+		if algo != object.SHA1 {
+			config = "[core]\n\trepositoryformatversion = 1\n" + strings.TrimPrefix(config, "[core]\n") +
+				"[extensions]\n\tobjectFormat = " + algo.Name + "\n"
+		}
+		if err := vfs.WriteFile(fsys, "config", []byte(config)); err != nil {
+			return nil, err
+		}
+	}
+
+	repo, err := newRepository(fsys, "", "")
+	if err != nil {
+		return nil, err
+	}
+	if opts.ObjectFormat != "" && repo.Objects.Algorithm() != algo {
+		return nil, fmt.Errorf("attempt to reinitialize repository with different hash")
+	}
+
+	if !vfs.Exists(fsys, "HEAD") {
+		if err := repo.Refs.SetSymbolic("HEAD", "refs/heads/master"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := installSampleHooks(fsys); err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// probeFileMode: fsys 에서 실행 비트를 켜고 끈 것이 그대로 보이는지. 권한을 바꿀 수 없는 파일시스템이면 false
+func probeFileMode(fsys vfs.Filesystem) bool {
+	const name = "filemode-probe"
+	f, err := fsys.Create(name)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	defer fsys.Remove(name)
+	for _, mode := range []os.FileMode{0755, 0644} {
+		if err := vfs.Chmod(fsys, name, mode); err != nil {
+			return false
+		}
+		info, err := fsys.Stat(name)
+		if err != nil || info.Mode()&0100 != mode&0100 {
+			return false
+		}
+	}
+	return true
+}
+
+// Open: path 부터 상위로 올라가며 저장소를 찾아 연다.
+func Open(path string) (*Repository, error) {
+	return OpenWithOptions(path, Options{})
+}
+
+// OpenWithOptions: 저장소 탐색
+// path 부터 상위로 올라가며 DirName(.gogit 또는 .git) 디렉토리를 찾는다. (git 의 discovery 와 동일)
+// 덕분에 하위 디렉토리에서 실행해도 같은 저장소를 사용할 수 있다.
+// 각 단계에서 디렉토리 자체가 bare 저장소인지도 확인한다.
+// DirName 이 디렉토리가 아니라 "gitdir: <경로>" 파일이면 추가 작업 트리이므로 그 경로의 저장소를 연다.
+func OpenWithOptions(path string, opts Options) (*Repository, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if repo, err := openWorkTree(dir, opts.RepoDirName()); repo != nil || err != nil {
+			return repo, err
+		}
+
+		if fsys := vfs.NewOS(dir); isBareRepo(fsys) {
+			return newRepository(fsys, dir, "")
+		}
+
+		parent := filepath.Dir(dir)
+		// 루트(/)에 도달하면 Dir 이 자기 자신을 반환함
+		if parent == dir {
+			return nil, fmt.Errorf("%w (or any of the parent directories): %s", ErrNotARepository, opts.RepoDirName())
+		}
+		dir = parent
+	}
+}
+
+// openWorkTree: dir 이 저장소 디렉토리 dirName 을 가진 작업 트리면 연다. 아니면 nil
+// dirName 이 디렉토리가 아니라 "gitdir: <경로>" 파일이면 그 경로의 저장소를 연다.
+func openWorkTree(dir string, dirName string) (*Repository, error) {
+	gogitDir := filepath.Join(dir, dirName)
+	info, err := os.Stat(gogitDir)
+	if err != nil {
+		return nil, nil
+	}
+	if info.IsDir() {
+		return newRepository(vfs.NewOS(gogitDir), gogitDir, dir)
+	}
+	linked, err := readGitdirFile(gogitDir)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(linked); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: '%s'", ErrNotARepository, linked)
+	}
+	return openGogitDir(linked, dir)
+}
+
+// OpenDir: 저장소 디렉토리를 직접 지정해서 연다. (GOGIT_DIR)
+// bare 저장소면 workTree 는 무시된다.
+func OpenDir(gogitDir string, workTree string) (*Repository, error) {
+	if info, err := os.Stat(gogitDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: '%s'", ErrNotARepository, gogitDir)
+	}
+	if isBareRepo(vfs.NewOS(gogitDir)) {
+		workTree = ""
+	}
+	return openGogitDir(gogitDir, workTree)
+}
+
+// OpenFS: 임의의 파일시스템 위의 저장소를 연다. 작업 트리는 없다.
+func OpenFS(fsys vfs.Filesystem) (*Repository, error) {
+	if !vfs.Exists(fsys, "HEAD") || !vfs.Exists(fsys, "objects") {
+		return nil, ErrNotARepository
+	}
+	return newRepository(fsys, "", "")
+}
+
+// newRepository: config 의 extensions.objectFormat 에 맞는 해시 함수로 객체 저장소를 연다.
+func newRepository(fsys vfs.Filesystem, gogitDir string, workTree string) (*Repository, error) {
+	store := object.NewStore(fsys.Chroot("objects"))
+	if vfs.Exists(fsys, "config") {
+		cfg, err := readConfig(fsys)
+		if err != nil {
+			return nil, err
+		}
+		format, _ := cfg.Get("extensions.objectFormat")
+		algo, err := object.ParseAlgorithm(format)
+		if err != nil {
+			return nil, err
+		}
+		store.SetAlgorithm(algo)
+	}
+	return &Repository{
+		GogitDir:  gogitDir,
+		CommonDir: gogitDir,
+		WorkTree:  workTree,
+		FS:        fsys,
+		Objects:   store,
+		Refs:      refs.NewStore(fsys),
+	}, nil
+}
+
+// IsBare: 작업 트리가 없는 저장소인지
+func (r *Repository) IsBare() bool {
+	return r.WorkTree == ""
+}
+
+// RequireWorkTree: 작업 트리가 필요한 명령은 실행 전에 이 검사를 거친다.
+// 서버용 bare 저장소에서는 plumbing 명령만 동작해야 하기 때문
+func (r *Repository) RequireWorkTree(command string) error {
+	if r.IsBare() {
+		return fmt.Errorf("%s: %w", command, ErrNoWorkTree)
+	}
+	return nil
+}
+
+// bare 저장소 판별
+// HEAD, objects, refs 가 모두 있고 config 에 bare = true 가 기록되어 있어야 한다.
+func isBareRepo(fsys vfs.Filesystem) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if !vfs.Exists(fsys, name) {
+			return false
+		}
+	}
+
+	cfg, err := readConfig(fsys)
+	if err != nil {
+		return false
+	}
+	bare, _ := cfg.GetBool("core.bare", false)
+	return bare
+}
+
+func readConfig(fsys vfs.Filesystem) (*config.Config, error) {
+	data, err := vfs.ReadFile(fsys, "config")
+	if err != nil {
+		return nil, err
+	}
+	return config.Parse(data)
+}
+
+// Config: 저장소 설정(.gogit/config)을 읽는다. 파일이 없거나 FS 가 없는 저장소는 빈 설정
+func (r *Repository) Config() (*config.Config, error) {
+	if r.FS == nil || !vfs.Exists(r.FS, "config") {
+		return &config.Config{}, nil
+	}
+	return readConfig(r.FS)
+}
+
+// UpdateHead: HEAD 가 가리키는 브랜치를 hash 로 옮긴다. detached HEAD 면 HEAD 자체를 바꾼다.
+// git 과 같이 브랜치와 HEAD 의 reflog 에 message 로 기록을 남긴다.
+func (r *Repository) UpdateHead(hash string, message string) error {
+	ref, err := r.Refs.Read("HEAD")
+	if err != nil {
+		return err
+	}
+	if ref.Target == "" {
+		return r.UpdateRef("HEAD", hash, message)
+	}
+	old, err := r.Refs.Resolve("HEAD")
+	if err != nil {
+		old = r.Objects.Algorithm().ZeroHash()
+	}
+	if err := r.UpdateRef(ref.Target, hash, message); err != nil {
+		return err
+	}
+	return r.appendReflog("HEAD", old, hash, message)
+}
+
+// UpdateRef: ref 를 hash 로 옮기고 reflog 에 message 로 기록을 남긴다.
+// Refs 가 reflog 를 지원하지 않으면 ref 만 옮긴다.
+func (r *Repository) UpdateRef(name string, hash string, message string) error {
+	old, err := r.Refs.Resolve(name)
+	if err != nil {
+		old = r.Objects.Algorithm().ZeroHash()
+	}
+	if err := r.Refs.Update(name, hash); err != nil {
+		return err
+	}
+	return r.appendReflog(name, old, hash, message)
+}
+
+// SwitchHead: HEAD 가 브랜치 target 을 가리키게 하고 HEAD 의 reflog 에 message 로 기록을 남긴다.
+// 브랜치는 움직이지 않는다. (rebase 를 마치거나 그만두고 원래 브랜치로 돌아갈 때 쓴다)
+func (r *Repository) SwitchHead(target string, message string) error {
+	old, err := r.Refs.Resolve("HEAD")
+	if err != nil {
+		old = r.Objects.Algorithm().ZeroHash()
+	}
+	hash, err := r.Refs.Resolve(target)
+	if err != nil {
+		return err
+	}
+	if err := r.Refs.SetSymbolic("HEAD", target); err != nil {
+		return err
+	}
+	return r.appendReflog("HEAD", old, hash, message)
+}
+
+func (r *Repository) appendReflog(name string, old string, hash string, message string) error {
+	who, err := r.Committer()
+	if err != nil {
+		return err
+	}
+	err = refs.AppendReflog(r.Refs, name, refs.ReflogEntry{Old: old, New: hash, Who: who, Message: message})
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	return err
+}
+
+// CommitStorer: 새 커밋을 저장할 때 쓸 Storer
+// config 의 provenance.enabled 가 true 면 커밋마다 provenance 헤더를 붙인다. (provenance 패키지 참고)
+func (r *Repository) CommitStorer() (object.Storer, error) {
+	enabled, err := r.ProvenanceEnabled()
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return provenance.Wrap(r.Objects), nil
+	}
+	return r.Objects, nil
+}
+
+// ProvenanceEnabled: config 의 provenance.enabled
+func (r *Repository) ProvenanceEnabled() (bool, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return false, err
+	}
+	return cfg.GetBool("provenance.enabled", false)
+}
+
+// Author: 새 커밋의 작성자
+// GOGIT_AUTHOR_NAME/GOGIT_AUTHOR_EMAIL 환경 변수가 config 의 user.name/user.email 보다 우선한다.
+func (r *Repository) Author() (object.Signature, error) {
+	return r.signature("AUTHOR")
+}
+
+// Committer: 새 커밋의 커미터. GOGIT_COMMITTER_NAME/GOGIT_COMMITTER_EMAIL 이 우선한다.
+func (r *Repository) Committer() (object.Signature, error) {
+	return r.signature("COMMITTER")
+}
+
+// Attributes: 작업 트리 루트의 .gitattributes 와 저장소의 info/attributes 를 읽은 경로별 속성
+// info/attributes 가 우선한다. 파일이 없으면 규칙이 없는 것으로 본다.
+func (r *Repository) Attributes() (*attr.Rules, error) {
+	var data [][]byte
+	if !r.IsBare() {
+		content, err := os.ReadFile(filepath.Join(r.WorkTree, ".gitattributes"))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		data = append(data, content)
+	}
+	if r.FS != nil {
+		content, err := vfs.ReadFile(r.FS, "info/attributes")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		data = append(data, content)
+	}
+	return attr.Parse(data...), nil
+}
+
+// Excludes: 작업 트리 밖에서 정한 무시 규칙. config 의 core.excludesFile 과 저장소의 info/exclude 를 읽는다.
+// info/exclude 가 우선한다. 작업 트리 안의 .gitignore 는 디렉토리를 훑는 쪽에서 Add 로 더한다.
+func (r *Repository) Excludes() (*ignore.Matcher, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+	m := ignore.New()
+	if file, ok := cfg.Get("core.excludesFile"); ok && file != "" {
+		if rest, ok := strings.CutPrefix(file, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				file = filepath.Join(home, rest)
+			}
+		}
+		data, err := os.ReadFile(file)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		m.Add("", data)
+	}
+	if r.FS != nil {
+		data, err := vfs.ReadFile(r.FS, "info/exclude")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		m.Add("", data)
+	}
+	return m, nil
+}
+
+// Editor: 커밋 메시지나 rebase todo 를 고칠 편집기 명령. 셸이 해석하므로 인자를 넣어도 된다.
+// git 과 같이 GOGIT_EDITOR, config 의 core.editor, VISUAL, EDITOR 순으로 찾고 없으면 vi 다.
+func (r *Repository) Editor() (string, error) {
+	if v := os.Getenv("GOGIT_EDITOR"); v != "" {
+		return v, nil
+	}
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	if v, ok := cfg.Get("core.editor"); ok && v != "" {
+		return v, nil
+	}
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if v := os.Getenv(name); v != "" {
+			return v, nil
+		}
+	}
+	return "vi", nil
+}
+
+func (r *Repository) signature(role string) (object.Signature, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return object.Signature{}, err
+	}
+
+	name, _ := cfg.Get("user.name")
+	email, _ := cfg.Get("user.email")
+	if v := os.Getenv("GOGIT_" + role + "_NAME"); v != "" {
+		name = v
+	}
+	if v := os.Getenv("GOGIT_" + role + "_EMAIL"); v != "" {
+		email = v
+	}
+	if name == "" || email == "" {
+		return object.Signature{}, fmt.Errorf("%s identity unknown: set user.name and user.email in config", strings.ToLower(role))
+	}
+	return object.Signature{Name: name, Email: email, When: time.Now()}, nil
+}
+
+// ResolveRevision: 사용자가 입력한 이름을 커밋(또는 객체) 해시로 바꾼다.
+// 40자리 해시, HEAD, 전체 ref 이름(refs/...), 브랜치 이름, 태그 이름, 짧은 해시 순으로 찾는다.
+func (r *Repository) ResolveRevision(rev string) (string, error) {
+	if object.IsHash(rev) {
+		if !r.Objects.Has(rev) {
+			return "", fmt.Errorf("%w: %s", ErrObjectNotFound, rev)
+		}
+		return rev, nil
+	}
+
+	for _, name := range []string{rev, "refs/" + rev, "refs/heads/" + rev, "refs/tags/" + rev} {
+		if name != "HEAD" && !strings.HasPrefix(name, "refs/") {
+			continue
+		}
+		if hash, err := r.Refs.Resolve(name); err == nil {
+			return hash, nil
+		}
+	}
+	if isShortHash(rev) {
+		return r.resolveShortHash(rev)
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownRevision, rev)
+}
+
+// minShortHash: 짧은 해시로 받아들이는 최소 길이 (git 과 같은 4)
+const minShortHash = 4
+
+// isShortHash: 4자리 이상의 16진수 (소문자) 문자열인지
+func isShortHash(s string) bool {
+	if len(s) < minShortHash || len(s) > object.SHA256.Size*2 {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveShortHash: prefix 로 시작하는 객체가 하나뿐이면 그 해시.
+// log --oneline 등이 출력하는 짧은 해시를 그대로 받기 위해서다.
+func (r *Repository) resolveShortHash(prefix string) (string, error) {
+	hashes, err := object.AllHashes(r.Objects)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return "", fmt.Errorf("%w: %s", ErrUnknownRevision, prefix)
+	}
+	if err != nil {
+		return "", err
+	}
+	i, _ := slices.BinarySearch(hashes, prefix)
+	var found string
+	for ; i < len(hashes) && strings.HasPrefix(hashes[i], prefix); i++ {
+		if found != "" {
+			return "", fmt.Errorf("%w: short hash %s matches several objects", ErrAmbiguousRevision, prefix)
+		}
+		found = hashes[i]
+	}
+	if found == "" {
+		return "", fmt.Errorf("%w: %s", ErrUnknownRevision, prefix)
+	}
+	return found, nil
+}
+
+// ResolveCommit: ResolveRevision 결과가 annotated tag 면 가리키는 커밋까지 따라간다.
+func (r *Repository) ResolveCommit(rev string) (string, error) {
+	hash, err := r.ResolveRevision(rev)
+	if err != nil {
+		return "", err
+	}
+	return PeelToCommit(r.Objects, hash)
+}
+
+// ResolveTree: 리비전이 가리키는 tree 해시. commit 이면 그 tree, tag 면 벗겨서 찾는다.
+func (r *Repository) ResolveTree(rev string) (string, error) {
+	hash, err := r.ResolveRevision(rev)
+	if err != nil {
+		return "", err
+	}
+	for {
+		obj, err := object.ReadObject(r.Objects, hash)
+		if err != nil {
+			return "", err
+		}
+		switch o := obj.(type) {
+		case *object.Tree:
+			return hash, nil
+		case *object.Commit:
+			return o.Tree, nil
+		case *object.Tag:
+			hash = o.Object
+		default:
+			return "", fmt.Errorf("%w: %s is a %s, not a tree", object.ErrWrongType, hash, obj.Type())
+		}
+	}
+}
+
+// PeelToCommit: tag 객체를 벗겨 커밋 해시를 얻는다. (tag 가 tag 를 가리킬 수도 있다)
+func PeelToCommit(s object.Storer, hash string) (string, error) {
+	for {
+		obj, err := object.ReadObject(s, hash)
+		if err != nil {
+			return "", err
+		}
+		switch o := obj.(type) {
+		case *object.Commit:
+			return hash, nil
+		case *object.Tag:
+			hash = o.Object
+		default:
+			return "", fmt.Errorf("%w: %s is a %s, not a commit", object.ErrWrongType, hash, obj.Type())
+		}
+	}
+}
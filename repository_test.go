@@ -0,0 +1,107 @@
+package gogit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/refs"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// TestInitSHA256: SHA-256 저장소는 config 에 표시되고, 다시 열어도 같은 해시 함수를 쓴다.
+func TestInitSHA256(t *testing.T) {
+	t.Setenv("GOGIT_COMMITTER_NAME", "Tester")
+	t.Setenv("GOGIT_COMMITTER_EMAIL", "tester@example.com")
+	dir := t.TempDir()
+	repo, err := InitWithOptions(dir, Options{ObjectFormat: "sha256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Objects.Algorithm() != object.SHA256 {
+		t.Fatalf("algorithm = %s", repo.Objects.Algorithm().Name)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := cfg.Get("extensions.objectFormat"); v != "sha256" {
+		t.Errorf("extensions.objectFormat = %q", v)
+	}
+	if v, _ := cfg.Get("core.repositoryformatversion"); v != "1" {
+		t.Errorf("core.repositoryformatversion = %q", v)
+	}
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Objects.Algorithm() != object.SHA256 {
+		t.Fatalf("reopened algorithm = %s", reopened.Objects.Algorithm().Name)
+	}
+	blob, err := object.WriteObject(reopened.Objects, &object.Blob{Data: []byte("x")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := object.WriteObject(reopened.Objects, &object.Tree{Entries: []object.TreeEntry{{Name: "x", Mode: object.ModeRegular, Hash: blob}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := object.Signature{Name: "Tester", Email: "tester@example.com"}
+	commit, err := object.WriteObject(reopened.Objects, &object.Commit{Tree: tree, Author: sig, Committer: sig, Message: "x\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.UpdateRef("refs/heads/master", commit, "commit (initial): x"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := reopened.ResolveTree("HEAD"); err != nil || got != tree {
+		t.Errorf("HEAD^{tree} = %s, %v; want %s", got, err, tree)
+	}
+	entries, err := refs.ReadReflog(reopened.Refs, "refs/heads/master")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("reflog = %+v, %v", entries, err)
+	}
+	if entries[0].Old != strings.Repeat("0", 64) || entries[0].New != commit {
+		t.Errorf("reflog entry = %+v", entries[0])
+	}
+
+	// 해시 함수를 바꿔 다시 초기화할 수는 없다. 형식을 주지 않으면 그대로 둔다
+	if _, err := InitWithOptions(dir, Options{ObjectFormat: "sha1"}); err == nil {
+		t.Error("reinit with sha1: no error")
+	}
+	if again, err := InitWithOptions(dir, Options{}); err != nil || again.Objects.Algorithm() != object.SHA256 {
+		t.Errorf("reinit without format: %v", err)
+	}
+}
+
+func TestInitUnknownFormat(t *testing.T) {
+	if _, err := InitWithOptions(t.TempDir(), Options{ObjectFormat: "md5"}); err == nil {
+		t.Error("init with md5: no error")
+	}
+}
+
+// TestInitFSMemory: 메모리 파일시스템 위의 저장소도 객체와 ref 를 읽고 쓴다.
+func TestInitFSMemory(t *testing.T) {
+	fsys := vfs.NewMemory()
+	repo, err := InitFS(fsys, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, err := object.WriteObject(repo.Objects, &object.Blob{Data: []byte("in memory\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Refs.Update("refs/tags/blob", blob); err != nil {
+		t.Fatal(err)
+	}
+	if !vfs.Exists(fsys, "objects/"+blob[:2]+"/"+blob[2:]) {
+		t.Error("loose object not written to the memory filesystem")
+	}
+	if got, err := repo.Refs.Resolve("refs/tags/blob"); err != nil || got != blob {
+		t.Errorf("refs/tags/blob = %s, %v", got, err)
+	}
+	if head, err := repo.Refs.Read("HEAD"); err != nil || head.Target != "refs/heads/master" {
+		t.Errorf("HEAD = %+v, %v", head, err)
+	}
+}
@@ -0,0 +1,294 @@
+// Package rerere 는 충돌을 해결한 방법을 기록해 두었다가 같은 충돌이 다시 나면 그대로 적용한다. (git rerere)
+//
+//	msgs, _ := rerere.Record(repo.FS, work, conflictedPaths) // 충돌 직후
+//	msgs, _ = rerere.Update(repo.FS, work)                   // 충돌을 해결하고 커밋하기 전
+//
+// 충돌은 충돌 표시 안의 두 쪽 내용으로 구분한다. 표시의 이름(HEAD, 커밋 제목)은 보지 않고 두 쪽을 정렬하므로
+// 어느 쪽에서 merge 하든 같은 충돌이다. git 과 같은 형식이라 실제 git 저장소의 rr-cache 와도 섞어 쓸 수 있다.
+//
+//	rr-cache/<id>/preimage   충돌 표시가 들어간 파일 (정규화된 것)
+//	rr-cache/<id>/postimage  해결한 파일
+//	MERGE_RR                 지금 해결 중인 충돌들 ("<id>\t<경로>\0")
+package rerere
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/tmdgusya/gogit/diff"
+	"github.com/tmdgusya/gogit/merge"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+const (
+	// CacheDir: 기록을 모아 두는 디렉토리. 이 디렉토리가 있으면 rerere 를 쓰는 저장소로 본다. (git 과 동일)
+	CacheDir = "rr-cache"
+	// mergeRR: 해결 중인 충돌 목록
+	mergeRR = "MERGE_RR"
+)
+
+// markerLen: 충돌 표시의 길이 (merge 패키지와 같은 7)
+const markerLen = 7
+
+// Entry: 해결 중인 충돌 하나
+type Entry struct {
+	ID   string
+	Path string
+}
+
+// Normalize: 충돌 표시가 있는 파일에서 충돌의 ID 와 정규화한 내용(preimage)을 만든다.
+// 충돌 표시의 이름과 base 구간(|||||||)은 지우고 두 쪽은 작은 것이 앞에 오게 바꾼다.
+// 충돌 표시가 없거나 짝이 맞지 않으면 ok 가 false 다.
+func Normalize(data []byte) (id string, preimage []byte, ok bool) {
+	const (
+		outside = iota
+		inOurs
+		inBase
+		inTheirs
+	)
+	h := sha1.New()
+	var out, ours, theirs strings.Builder
+	state := outside
+	hunks := 0
+	for _, line := range diff.SplitLines(data) {
+		switch {
+		case isMarker(line, '<'):
+			if state != outside {
+				return "", nil, false
+			}
+			state = inOurs
+			ours.Reset()
+			theirs.Reset()
+		case isMarker(line, '|') && state == inOurs:
+			state = inBase
+		case isMarker(line, '=') && (state == inOurs || state == inBase):
+			state = inTheirs
+		case isMarker(line, '>') && state == inTheirs:
+			one, two := ours.String(), theirs.String()
+			if one > two {
+				one, two = two, one
+			}
+			fmt.Fprintf(&out, "%s\n%s%s\n%s%s\n", strings.Repeat("<", markerLen), one, strings.Repeat("=", markerLen), two, strings.Repeat(">", markerLen))
+			io.WriteString(h, one+"\x00"+two+"\x00")
+			hunks++
+			state = outside
+		case state == inOurs:
+			ours.WriteString(line)
+		case state == inTheirs:
+			theirs.WriteString(line)
+		case state == outside:
+			out.WriteString(line)
+		}
+	}
+	if state != outside || hunks == 0 {
+		return "", nil, false
+	}
+	return hex.EncodeToString(h.Sum(nil)), []byte(out.String()), true
+}
+
+// isMarker: 같은 글자 7개로 시작하고 그 뒤가 공백이나 줄 끝인 줄
+func isMarker(line string, c byte) bool {
+	if len(line) < markerLen || line[:markerLen] != strings.Repeat(string(c), markerLen) {
+		return false
+	}
+	rest := line[markerLen:]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\n'
+}
+
+// Record: 충돌한 파일들을 MERGE_RR 에 올리고 preimage 를 기록한다.
+// 같은 충돌을 해결한 기록(postimage)이 있으면 작업 트리의 파일에 적용한다.
+// cache 는 저장소 디렉토리, work 는 작업 트리다. 결과는 git 과 같은 안내 메시지들이다.
+func Record(cache, work vfs.Filesystem, paths []string) ([]string, error) {
+	entries, err := Entries(cache)
+	if err != nil {
+		return nil, err
+	}
+	var msgs []string
+	for _, p := range paths {
+		data, err := vfs.ReadFile(work, p)
+		if errors.Is(err, fs.ErrNotExist) {
+			// 수정/삭제 충돌처럼 충돌 표시가 없는 충돌은 기록하지 않는다
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		id, preimage, ok := Normalize(data)
+		if !ok {
+			continue
+		}
+		entries = setEntry(entries, Entry{ID: id, Path: p})
+		dir := CacheDir + "/" + id
+
+		// 충돌 밖의 내용은 기록할 때와 다를 수 있으므로 기록한 preimage 에서 postimage 로의 변경을 merge 한다
+		postimage, err := vfs.ReadFile(cache, dir+"/postimage")
+		if err == nil {
+			recorded, err := vfs.ReadFile(cache, dir+"/preimage")
+			if err != nil {
+				return nil, err
+			}
+			resolved, conflict := merge.File(recorded, preimage, postimage, "", "")
+			if !conflict {
+				if err := vfs.WriteFile(work, p, resolved); err != nil {
+					return nil, err
+				}
+				msgs = append(msgs, fmt.Sprintf("Resolved '%s' using previous resolution.", p))
+				continue
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		if err := cache.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		if err := vfs.WriteFile(cache, dir+"/preimage", preimage); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, fmt.Sprintf("Recorded preimage for '%s'", p))
+	}
+	return msgs, writeEntries(cache, entries)
+}
+
+// Update: MERGE_RR 의 파일 중 충돌 표시가 없어진 것의 해결을 postimage 로 기록하고 목록에서 뺀다.
+// 아직 충돌 표시가 남아 있는 파일은 그대로 둔다.
+func Update(cache, work vfs.Filesystem) ([]string, error) {
+	entries, err := Entries(cache)
+	if err != nil {
+		return nil, err
+	}
+	var msgs []string
+	var remaining []Entry
+	for _, e := range entries {
+		data, err := vfs.ReadFile(work, e.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			// 파일을 지운 것은 해결로 기록하지 않는다
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, _, ok := Normalize(data); ok {
+			remaining = append(remaining, e)
+			continue
+		}
+		post := CacheDir + "/" + e.ID + "/postimage"
+		if vfs.Exists(cache, post) {
+			continue
+		}
+		if err := cache.MkdirAll(CacheDir+"/"+e.ID, 0755); err != nil {
+			return nil, err
+		}
+		if err := vfs.WriteFile(cache, post, data); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, fmt.Sprintf("Recorded resolution for '%s'.", e.Path))
+	}
+	return msgs, writeEntries(cache, remaining)
+}
+
+// Clear: 해결 중인 충돌 목록을 지운다. (merge 를 그만두었을 때) 기록한 해결은 남는다.
+func Clear(cache vfs.Filesystem) error {
+	if err := cache.Remove(mergeRR); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Forget: match 에 맞는 해결 중인 충돌의 기록된 해결을 지운다. 다음 Update 에서 새 해결을 기록한다.
+func Forget(cache vfs.Filesystem, match func(p string) bool) ([]string, error) {
+	entries, err := Entries(cache)
+	if err != nil {
+		return nil, err
+	}
+	var msgs []string
+	for _, e := range entries {
+		if !match(e.Path) {
+			continue
+		}
+		err := cache.Remove(CacheDir + "/" + e.ID + "/postimage")
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, fmt.Sprintf("Forgot resolution for '%s'", e.Path))
+	}
+	return msgs, nil
+}
+
+// Diff: 해결 중인 충돌마다 preimage 와 작업 트리의 파일을 비교한 diff 를 쓴다. (지금까지 해결한 내용)
+func Diff(w io.Writer, cache, work vfs.Filesystem) error {
+	entries, err := Entries(cache)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		preimage, err := vfs.ReadFile(cache, CacheDir+"/"+e.ID+"/preimage")
+		if err != nil {
+			return err
+		}
+		current, err := vfs.ReadFile(work, e.Path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "--- a/%s\n+++ b/%s\n", e.Path, e.Path); err != nil {
+			return err
+		}
+		if err := diff.WriteUnified(w, diff.SplitLines(preimage), diff.SplitLines(current), diff.DefaultContext); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Entries: MERGE_RR 의 해결 중인 충돌들. 파일이 없으면 빈 목록
+func Entries(cache vfs.Filesystem) ([]Entry, error) {
+	data, err := vfs.ReadFile(cache, mergeRR)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, record := range strings.Split(string(data), "\x00") {
+		if record == "" {
+			continue
+		}
+		id, p, ok := strings.Cut(record, "\t")
+		if !ok {
+			return nil, fmt.Errorf("corrupt %s: %q", mergeRR, record)
+		}
+		entries = append(entries, Entry{ID: id, Path: p})
+	}
+	return entries, nil
+}
+
+func setEntry(entries []Entry, e Entry) []Entry {
+	for i := range entries {
+		if entries[i].Path == e.Path {
+			entries[i] = e
+			return entries
+		}
+	}
+	return append(entries, e)
+}
+
+// writeEntries: MERGE_RR 을 쓴다. 목록이 비면 파일을 지운다.
+func writeEntries(cache vfs.Filesystem, entries []Entry) error {
+	if len(entries) == 0 {
+		return Clear(cache)
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(e.ID + "\t" + e.Path + "\x00")
+	}
+	return vfs.WriteFile(cache, mergeRR, []byte(b.String()))
+}
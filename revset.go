@@ -0,0 +1,143 @@
+package gogit
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// RevSet: rev-list 식 인자로 고른 커밋 집합
+// Include 에서 도달 가능한 커밋 중 Hidden 에 없는 것들이다.
+type RevSet struct {
+	Include []string
+	Hidden  map[string]bool
+}
+
+// ResolveRevSet: 리비전 인자들을 해석한다.
+//
+//	B       B 에서 도달 가능한 커밋
+//	^A      A 에서 도달 가능한 커밋을 뺀다
+//	A..B    ^A B 와 같다. 한쪽을 비우면 HEAD
+//	A...B   A 와 B 중 한쪽에서만 도달 가능한 커밋
+func (r *Repository) ResolveRevSet(ctx context.Context, args []string) (*RevSet, error) {
+	set := &RevSet{Hidden: map[string]bool{}}
+	var exclude []string
+
+	resolve := func(rev string) (string, error) {
+		if rev == "" {
+			rev = "HEAD"
+		}
+		return r.ResolveCommit(rev)
+	}
+
+	for _, arg := range args {
+		if left, right, ok := strings.Cut(arg, "..."); ok {
+			a, err := resolve(left)
+			if err != nil {
+				return nil, err
+			}
+			b, err := resolve(right)
+			if err != nil {
+				return nil, err
+			}
+			// 양쪽 모두에서 도달 가능한 커밋(공통 이력)을 뺀다
+			fromA, err := object.Reachable(ctx, r.Objects, []string{a})
+			if err != nil {
+				return nil, err
+			}
+			fromB, err := object.Reachable(ctx, r.Objects, []string{b})
+			if err != nil {
+				return nil, err
+			}
+			for hash := range fromA {
+				if fromB[hash] {
+					set.Hidden[hash] = true
+				}
+			}
+			set.Include = append(set.Include, a, b)
+			continue
+		}
+
+		if left, right, ok := strings.Cut(arg, ".."); ok {
+			a, err := resolve(left)
+			if err != nil {
+				return nil, err
+			}
+			b, err := resolve(right)
+			if err != nil {
+				return nil, err
+			}
+			exclude = append(exclude, a)
+			set.Include = append(set.Include, b)
+			continue
+		}
+
+		if rev, ok := strings.CutPrefix(arg, "^"); ok {
+			hash, err := resolve(rev)
+			if err != nil {
+				return nil, err
+			}
+			exclude = append(exclude, hash)
+			continue
+		}
+
+		hash, err := resolve(arg)
+		if err != nil {
+			return nil, err
+		}
+		set.Include = append(set.Include, hash)
+	}
+
+	if len(exclude) > 0 {
+		hidden, err := object.Reachable(ctx, r.Objects, exclude)
+		if err != nil {
+			return nil, err
+		}
+		for hash := range hidden {
+			set.Hidden[hash] = true
+		}
+	}
+	return set, nil
+}
+
+// AllRefs: HEAD 와 refs/ 아래의 모든 ref 가 가리키는 커밋 (rev-list --all)
+// 커밋이 아닌 객체(예: blob 을 가리키는 태그)를 가리키는 ref 는 건너뛴다.
+func (r *Repository) AllRefs() ([]string, error) {
+	refs, err := r.Refs.List()
+	if err != nil {
+		return nil, err
+	}
+	// git 과 같이 ref 를 먼저, HEAD 를 마지막에 넣는다 (시간이 같을 때의 순서가 같아진다)
+	var names []string
+	for _, ref := range refs {
+		names = append(names, ref.Name)
+	}
+	names = append(names, "HEAD")
+
+	var hashes []string
+	for _, name := range names {
+		hash, err := r.Refs.Resolve(name)
+		if err != nil {
+			// 아직 커밋이 없는 HEAD
+			continue
+		}
+		commit, err := PeelToCommit(r.Objects, hash)
+		if errors.Is(err, object.ErrWrongType) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, commit)
+	}
+	return hashes, nil
+}
+
+// Iter: 집합의 커밋을 순회한다.
+func (s *RevSet) Iter(store object.Storer, order object.Order) *object.CommitIter {
+	it := object.NewCommitIter(store, s.Include, order)
+	it.Hide(s.Hidden)
+	return it
+}
@@ -0,0 +1,122 @@
+// Package shortlog 는 커밋을 작성자별로 모아 센다. (git shortlog)
+//
+//	log := shortlog.New(shortlog.Options{Email: true})
+//	for each commit: log.Add(commit)
+//	log.Write(os.Stdout, shortlog.ByCount, false)
+//
+// 릴리스 노트에 기여자 목록을 붙일 때 쓴다.
+package shortlog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/pretty"
+)
+
+// Options: 작성자를 나누는 방법
+type Options struct {
+	// Email: 이름과 이메일이 모두 같아야 같은 작성자다. 출력에도 이메일을 붙인다
+	Email bool
+	// Committer: 작성자 대신 커미터로 모은다
+	Committer bool
+	// Map: 모으기 전에 사람을 바꾼다 (여러 이름과 이메일을 한 사람으로 합칠 때). nil 이면 그대로 쓴다
+	Map func(object.Signature) object.Signature
+}
+
+// Author: 작성자 한 명과 그 사람의 커밋 제목들 (Add 한 순서)
+type Author struct {
+	// Name: "이름" 또는 Email 이면 "이름 <이메일>"
+	Name     string
+	Subjects []string
+}
+
+// Order: 작성자를 늘어놓는 순서
+type Order int
+
+const (
+	// ByName: 이름 순서 (git shortlog 기본값)
+	ByName Order = iota
+	// ByCount: 커밋이 많은 사람부터, 같으면 이름 순서 (-n)
+	ByCount
+)
+
+// Log: 작성자별로 모은 커밋들
+type Log struct {
+	opts    Options
+	authors map[string]*Author
+}
+
+func New(opts Options) *Log {
+	return &Log{opts: opts, authors: map[string]*Author{}}
+}
+
+// Add: 커밋 하나를 센다. 제목은 git 과 같이 첫 문단을 한 줄로 잇고 "[PATCH...]" 머리를 뗀다.
+func (l *Log) Add(c *object.Commit) {
+	sig := c.Author
+	if l.opts.Committer {
+		sig = c.Committer
+	}
+	if l.opts.Map != nil {
+		sig = l.opts.Map(sig)
+	}
+	name := sig.Name
+	if l.opts.Email {
+		name += " <" + sig.Email + ">"
+	}
+	a, ok := l.authors[name]
+	if !ok {
+		a = &Author{Name: name}
+		l.authors[name] = a
+	}
+	subject := pretty.Subject(c.Message)
+	if strings.HasPrefix(subject, "[PATCH") {
+		if _, rest, ok := strings.Cut(subject, "]"); ok {
+			subject = rest
+		}
+	}
+	a.Subjects = append(a.Subjects, strings.TrimLeft(subject, " \t"))
+}
+
+// Authors: 모은 작성자들
+func (l *Log) Authors(order Order) []*Author {
+	list := make([]*Author, 0, len(l.authors))
+	for _, a := range l.authors {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if order == ByCount && len(list[i].Subjects) != len(list[j].Subjects) {
+			return len(list[i].Subjects) > len(list[j].Subjects)
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Write: git shortlog 와 같은 형식으로 쓴다. summary 면 제목 없이 "수\t이름" 만 쓴다.
+// 제목은 오래된 것부터 쓰므로 Add 는 log 와 같이 최신 커밋부터 했다고 본다.
+func (l *Log) Write(w io.Writer, order Order, summary bool) error {
+	for _, a := range l.Authors(order) {
+		if summary {
+			if _, err := fmt.Fprintf(w, "%6d\t%s\n", len(a.Subjects), a.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s (%d):\n", a.Name, len(a.Subjects)); err != nil {
+			return err
+		}
+		for i := len(a.Subjects) - 1; i >= 0; i-- {
+			if _, err := fmt.Fprintf(w, "      %s\n", a.Subjects[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+// Package signing 은 커밋과 tag 에 git 과 같은 형식의 서명을 넣고 확인한다.
+// 서명을 만들고 확인하는 일은 gpg 나 ssh-keygen 을 실행해서 맡긴다.
+//
+// 커밋은 gpgsig 헤더에, tag 는 메시지 끝에 서명을 둔다. 서명하는 내용은 서명을 뺀 객체 그대로다.
+// 설정은 git 과 같은 이름을 쓰고, gpg.keyring 만 gogit 에서 더한 것이다.
+//
+//	[user]
+//		signingKey = <gpg 키 ID, 또는 ssh 키 파일이나 "ssh-ed25519 ..." 공개 키(ssh-agent 에 있어야 함)>
+//	[gpg]
+//		format = openpgp | ssh      (기본 openpgp)
+//		program = gpg
+//		keyring = <공개 키 파일>    확인할 때 기본 keyring 대신 쓴다
+//	[gpg "ssh"]
+//		program = ssh-keygen
+//		allowedSignersFile = <파일>  ssh 서명을 확인할 때 믿을 서명자 (ssh-keygen 의 ALLOWED SIGNERS 형식)
+//
+// signingKey 가 없으면 gpg 는 서명하는 사람의 "이름 <이메일>" 로 키를 찾는다.
+package signing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tmdgusya/gogit/config"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// 서명 형식 (gpg.format 의 값)
+const (
+	OpenPGP = "openpgp"
+	SSH     = "ssh"
+)
+
+// CommitHeader: 커밋에서 서명을 담는 헤더
+const CommitHeader = "gpgsig"
+
+const (
+	pgpBegin = "-----BEGIN PGP SIGNATURE-----"
+	sshBegin = "-----BEGIN SSH SIGNATURE-----"
+)
+
+// ssh 서명의 namespace (git 과 같다)
+const sshNamespace = "git"
+
+// ErrNoSignature: 서명이 없는 객체
+var ErrNoSignature = errors.New("no signature found")
+
+// Signer: 서명을 만들고 확인하는 설정
+type Signer struct {
+	Format string
+	// Key: 서명할 키. 비어 있으면 gpg 는 서명하는 사람으로 찾는다
+	Key            string
+	GPGProgram     string
+	SSHProgram     string
+	Keyring        string
+	AllowedSigners string
+}
+
+// FromConfig: [user], [gpg] 설정에서 Signer 를 만든다.
+func FromConfig(cfg *config.Config) (*Signer, error) {
+	s := &Signer{Format: OpenPGP, GPGProgram: "gpg", SSHProgram: "ssh-keygen"}
+	if v, ok := cfg.Get("gpg.format"); ok {
+		s.Format = strings.ToLower(v)
+	}
+	if s.Format != OpenPGP && s.Format != SSH {
+		return nil, fmt.Errorf("invalid value for 'gpg.format': '%s'", s.Format)
+	}
+	s.Key, _ = cfg.Get("user.signingKey")
+	if v, ok := cfg.Get("gpg.program"); ok && v != "" {
+		s.GPGProgram = v
+	}
+	if v, ok := cfg.Get("gpg.openpgp.program"); ok && v != "" {
+		s.GPGProgram = v
+	}
+	if v, ok := cfg.Get("gpg.ssh.program"); ok && v != "" {
+		s.SSHProgram = v
+	}
+	s.Keyring, _ = cfg.Get("gpg.keyring")
+	s.AllowedSigners, _ = cfg.Get("gpg.ssh.allowedSignersFile")
+	s.Keyring = expandHome(s.Keyring)
+	s.AllowedSigners = expandHome(s.AllowedSigners)
+	return s, nil
+}
+
+func expandHome(p string) string {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return p
+}
+
+// Sign: payload 의 서명(armor 된 텍스트, 줄바꿈으로 끝남)을 만든다. ident 는 키가 없을 때 gpg 가 찾을 "이름 <이메일>"
+func (s *Signer) Sign(payload []byte, ident string) (string, error) {
+	if s.Format == SSH {
+		return s.signSSH(payload)
+	}
+	key := s.Key
+	if key == "" {
+		key = ident
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(s.GPGProgram, "--status-fd=2", "-bsau", key)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(payload), &stdout, &stderr
+	err := cmd.Run()
+	if err != nil || !strings.Contains(stderr.String(), "[GNUPG:] SIG_CREATED ") {
+		return "", fmt.Errorf("gpg failed to sign the data:\n%s", strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func (s *Signer) signSSH(payload []byte) (string, error) {
+	if s.Key == "" {
+		return "", errors.New("user.signingKey needs to be set for ssh signing")
+	}
+	dir, err := os.MkdirTemp("", "gogit-sign-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	// 공개 키를 그대로 적었으면 파일로 써서 ssh-agent 의 키로 서명한다
+	args := []string{"-Y", "sign", "-n", sshNamespace}
+	key := strings.TrimPrefix(s.Key, "key::")
+	if strings.HasPrefix(key, "ssh-") || strings.HasPrefix(key, "ecdsa-") || strings.HasPrefix(key, "sk-") {
+		file := filepath.Join(dir, "key.pub")
+		if err := os.WriteFile(file, []byte(key+"\n"), 0600); err != nil {
+			return "", err
+		}
+		args = append(args, "-U", "-f", file)
+	} else {
+		args = append(args, "-f", expandHome(key))
+	}
+	file := filepath.Join(dir, "payload")
+	if err := os.WriteFile(file, payload, 0600); err != nil {
+		return "", err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(s.SSHProgram, append(args, file)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("ssh-keygen failed to sign the data:\n%s", strings.TrimSpace(stderr.String()))
+	}
+	sig, err := os.ReadFile(file + ".sig")
+	if err != nil {
+		return "", err
+	}
+	return string(sig), nil
+}
+
+// Result: 서명 확인 결과
+type Result struct {
+	// Good: 서명이 맞고 믿는 키로 만든 것
+	Good bool
+	// Output: gpg 나 ssh-keygen 이 사람에게 보여 주는 내용
+	Output string
+}
+
+// Verify: signature 가 payload 의 올바른 서명인지 확인한다. 형식은 서명 자체에서 알아낸다.
+// 서명이 틀린 것은 에러가 아니라 Result.Good 이 false 다. 프로그램을 실행하지 못하면 에러
+func (s *Signer) Verify(payload []byte, signature string) (*Result, error) {
+	dir, err := os.MkdirTemp("", "gogit-verify-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	sigFile := filepath.Join(dir, "signature")
+	if err := os.WriteFile(sigFile, []byte(signature), 0600); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(signature, sshBegin) {
+		return s.verifySSH(payload, sigFile)
+	}
+
+	args := []string{"--status-fd=1", "--keyid-format=long"}
+	if s.Keyring != "" {
+		args = append(args, "--no-default-keyring", "--keyring", s.Keyring)
+	}
+	var status, output bytes.Buffer
+	cmd := exec.Command(s.GPGProgram, append(args, "--verify", sigFile, "-")...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(payload), &status, &output
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("could not run %s: %w", s.GPGProgram, err)
+	}
+	good := err == nil && strings.Contains(status.String(), "[GNUPG:] GOODSIG ")
+	return &Result{Good: good, Output: output.String()}, nil
+}
+
+func (s *Signer) verifySSH(payload []byte, sigFile string) (*Result, error) {
+	if s.AllowedSigners == "" {
+		return nil, errors.New("gpg.ssh.allowedSignersFile needs to be configured and exist for ssh signature verification")
+	}
+	var principals, stderr bytes.Buffer
+	cmd := exec.Command(s.SSHProgram, "-Y", "find-principals", "-f", s.AllowedSigners, "-s", sigFile)
+	cmd.Stdout, cmd.Stderr = &principals, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("could not run %s: %w", s.SSHProgram, err)
+	}
+	if err != nil {
+		return &Result{Output: stderr.String()}, nil
+	}
+
+	// 맞는 서명자가 여럿이면 그중 하나로 확인되면 된다
+	var output strings.Builder
+	for _, principal := range strings.Fields(principals.String()) {
+		var out bytes.Buffer
+		cmd := exec.Command(s.SSHProgram, "-Y", "verify", "-f", s.AllowedSigners, "-I", principal, "-n", sshNamespace, "-s", sigFile)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(payload), &out, &out
+		err := cmd.Run()
+		output.Write(out.Bytes())
+		if err == nil {
+			return &Result{Good: true, Output: out.String()}, nil
+		}
+	}
+	return &Result{Output: output.String()}, nil
+}
+
+// SignCommit: 커밋에 gpgsig 헤더를 붙인다. 이미 있던 서명은 버리고 committer 로 서명한다.
+func (s *Signer) SignCommit(c *object.Commit) error {
+	*c = *unsignedCommit(c)
+	sig, err := s.Sign(c.Encode(), ident(c.Committer))
+	if err != nil {
+		return err
+	}
+	// 헤더 값은 마지막 줄바꿈 없이 담는다 (이어지는 줄은 Encode 가 공백으로 들여 쓴다)
+	c.ExtraHeaders = append(c.ExtraHeaders, object.Header{Key: CommitHeader, Value: strings.TrimSuffix(sig, "\n")})
+	return nil
+}
+
+// CommitSignature: 커밋의 서명과 그 서명이 덮는 내용. 서명이 없으면 ErrNoSignature
+func CommitSignature(c *object.Commit) (payload []byte, signature string, err error) {
+	value, ok := c.Header(CommitHeader)
+	if !ok {
+		return nil, "", ErrNoSignature
+	}
+	return unsignedCommit(c).Encode(), value + "\n", nil
+}
+
+// unsignedCommit: gpgsig 헤더를 뺀 복사본
+func unsignedCommit(c *object.Commit) *object.Commit {
+	copied := *c
+	copied.ExtraHeaders = nil
+	for _, h := range c.ExtraHeaders {
+		if h.Key != CommitHeader {
+			copied.ExtraHeaders = append(copied.ExtraHeaders, h)
+		}
+	}
+	return &copied
+}
+
+// SignTag: tag 메시지 끝에 tagger 로 만든 서명을 붙인다.
+func (s *Signer) SignTag(t *object.Tag) error {
+	if _, _, err := TagSignature(t); err == nil {
+		return errors.New("tag is already signed")
+	}
+	sig, err := s.Sign(t.Encode(), ident(t.Tagger))
+	if err != nil {
+		return err
+	}
+	t.Message += sig
+	return nil
+}
+
+// TagSignature: tag 메시지 끝의 서명과 그 서명이 덮는 내용(서명 앞까지). 서명이 없으면 ErrNoSignature
+func TagSignature(t *object.Tag) (payload []byte, signature string, err error) {
+	i := signatureStart(t.Message)
+	if i < 0 {
+		return nil, "", ErrNoSignature
+	}
+	unsigned := *t
+	unsigned.Message = t.Message[:i]
+	return unsigned.Encode(), t.Message[i:], nil
+}
+
+// signatureStart: 메시지에서 서명이 시작하는 줄의 위치. 없으면 -1
+func signatureStart(message string) int {
+	for i := 0; i < len(message); {
+		line := message[i:]
+		if strings.HasPrefix(line, pgpBegin) || strings.HasPrefix(line, sshBegin) {
+			return i
+		}
+		next := strings.IndexByte(line, '\n')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return -1
+}
+
+func ident(sig object.Signature) string {
+	return fmt.Sprintf("%s <%s>", sig.Name, sig.Email)
+}
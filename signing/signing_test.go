@@ -0,0 +1,135 @@
+package signing
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmdgusya/gogit/config"
+	"github.com/tmdgusya/gogit/object"
+)
+
+func TestFromConfig(t *testing.T) {
+	cfg, err := config.Parse([]byte("[user]\n\tsigningKey = ABCD\n[gpg]\n\tprogram = gpg2\n[gpg \"ssh\"]\n\tallowedSignersFile = /etc/signers\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Format != OpenPGP || s.Key != "ABCD" || s.GPGProgram != "gpg2" || s.SSHProgram != "ssh-keygen" || s.AllowedSigners != "/etc/signers" {
+		t.Errorf("signer = %+v", s)
+	}
+
+	cfg, err = config.Parse([]byte("[gpg]\n\tformat = x509\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromConfig(cfg); err == nil {
+		t.Error("gpg.format = x509: no error")
+	}
+}
+
+func testCommit() *object.Commit {
+	sig := object.Signature{Name: "Tester", Email: "tester@example.com", When: time.Unix(1700000000, 0).UTC()}
+	return &object.Commit{
+		Tree: strings.Repeat("a", 40), Author: sig, Committer: sig,
+		ExtraHeaders: []object.Header{{Key: "encoding", Value: "UTF-8"}},
+		Message:      "signed\n",
+	}
+}
+
+func TestUnsignedObjects(t *testing.T) {
+	if _, _, err := CommitSignature(testCommit()); !errors.Is(err, ErrNoSignature) {
+		t.Errorf("CommitSignature err = %v", err)
+	}
+	tag := &object.Tag{Object: strings.Repeat("a", 40), ObjectType: object.TypeCommit, Name: "v1", Message: "release\n"}
+	if _, _, err := TagSignature(tag); !errors.Is(err, ErrNoSignature) {
+		t.Errorf("TagSignature err = %v", err)
+	}
+}
+
+// TestSSHSignVerify: ssh-keygen 으로 커밋과 tag 에 서명하고, 서명이 덮는 내용이 바뀌면 확인에 실패한다.
+func TestSSHSignVerify(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not found")
+	}
+	dir := t.TempDir()
+	key := filepath.Join(dir, "id_ed25519")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen: %v\n%s", err, out)
+	}
+	pub, err := os.ReadFile(key + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := filepath.Join(dir, "allowed_signers")
+	if err := os.WriteFile(signers, []byte("tester@example.com "+string(pub)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := &Signer{Format: SSH, Key: key, SSHProgram: "ssh-keygen", AllowedSigners: signers}
+
+	c := testCommit()
+	if err := s.SignCommit(c); err != nil {
+		t.Fatal(err)
+	}
+	// 객체로 쓰고 다시 읽어도 서명과 내용이 그대로다
+	decoded := &object.Commit{}
+	if err := decoded.Decode(c.Encode()); err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, err := CommitSignature(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(signature, sshBegin) || string(payload) != string(testCommit().Encode()) {
+		t.Errorf("payload = %q, signature = %q", payload, signature)
+	}
+	if r, err := s.Verify(payload, signature); err != nil || !r.Good {
+		t.Fatalf("Verify = %+v, %v", r, err)
+	}
+	decoded.Message = "tampered\n"
+	payload, signature, _ = CommitSignature(decoded)
+	if r, err := s.Verify(payload, signature); err != nil || r.Good {
+		t.Errorf("tampered Verify = %+v, %v", r, err)
+	}
+
+	tag := &object.Tag{Object: strings.Repeat("a", 40), ObjectType: object.TypeCommit, Name: "v1",
+		Tagger: testCommit().Committer, Message: "release\n"}
+	if err := s.SignTag(tag); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SignTag(tag); err == nil {
+		t.Error("signed a tag twice")
+	}
+	payload, signature, err = TagSignature(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := s.Verify(payload, signature); err != nil || !r.Good {
+		t.Errorf("tag Verify = %+v, %v", r, err)
+	}
+
+	// 믿는 서명자 목록에 없는 키는 좋은 서명이 아니다
+	if err := os.WriteFile(signers, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := s.Verify(payload, signature); err != nil || r.Good {
+		t.Errorf("untrusted Verify = %+v, %v", r, err)
+	}
+}
+
+func TestSSHSignWithoutKey(t *testing.T) {
+	s := &Signer{Format: SSH, SSHProgram: "ssh-keygen"}
+	if _, err := s.Sign([]byte("x"), "Tester <tester@example.com>"); err == nil {
+		t.Error("ssh signing without user.signingKey: no error")
+	}
+	if _, err := s.Verify([]byte("x"), sshBegin+"\n"); err == nil {
+		t.Error("ssh verify without allowedSignersFile: no error")
+	}
+}
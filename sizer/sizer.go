@@ -0,0 +1,342 @@
+// Package sizer 는 저장소가 무엇 때문에 커졌는지 분석한다.
+//
+//	report, _ := sizer.Analyze(ctx, store, heads, sizer.Options{Top: 10})
+//	report.WriteText(os.Stdout)
+//
+// heads 에서 도달 가능한 커밋과 그 tree, blob 을 한 번씩만 센다. 크기는 압축 전 내용의 크기다.
+// 같은 내용의 파일은 하나로 세고, 경로는 그 객체를 처음 만난 경로를 쓴다.
+package sizer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// DefaultTop: 목록마다 보여 줄 기본 항목 수
+const DefaultTop = 10
+
+// Options: 분석 옵션
+type Options struct {
+	// Top: 목록마다 보여 줄 항목 수. 0 이면 DefaultTop
+	Top int
+}
+
+// Object: 크기 순위에 오른 객체
+type Object struct {
+	Hash string
+	Size int64
+	// Path: 이 객체를 처음 만난 경로 (루트 tree 는 빈 문자열)
+	Path string
+	// Entries: tree 의 항목 수 (blob 은 0)
+	Entries int
+}
+
+// Path: 깊이 순위에 오른 파일 경로
+type Path struct {
+	Path  string
+	Depth int
+}
+
+// Extension: 확장자별 합계. 확장자가 없는 파일은 Ext 가 빈 문자열이다.
+type Extension struct {
+	Ext   string
+	Blobs int
+	Size  int64
+}
+
+// Period: 한 달 동안 새로 생긴 객체
+type Period struct {
+	// Month: "2006-01" (커밋의 committer 시간 기준, UTC)
+	Month   string
+	Commits int
+	// Added: 이 기간의 커밋이 처음 들여온 객체들의 크기
+	Added int64
+	// Total: 이 기간까지의 누적 크기
+	Total int64
+}
+
+// Report: Analyze 의 결과
+type Report struct {
+	Commits     int
+	CommitBytes int64
+	Trees       int
+	TreeBytes   int64
+	Blobs       int
+	BlobBytes   int64
+
+	LargestBlobs []Object
+	LargestTrees []Object
+	DeepestPaths []Path
+	// Extensions: 크기가 큰 것부터
+	Extensions []Extension
+	// Growth: 오래된 달부터
+	Growth []Period
+}
+
+// Analyze: heads(커밋 해시)에서 도달 가능한 모든 객체를 훑어 Report 를 만든다.
+// 이력이 저장소에 쌓인 순서를 보기 위해 커밋은 committer 시간이 오래된 것부터 처리한다.
+func Analyze(ctx context.Context, s object.Storer, heads []string, opts Options) (*Report, error) {
+	if opts.Top <= 0 {
+		opts.Top = DefaultTop
+	}
+
+	var commits []commitInfo
+	it := object.NewCommitIter(s, heads, object.OrderDate)
+	err := it.ForEachContext(ctx, func(hash string, c *object.Commit) error {
+		commits = append(commits, commitInfo{hash: hash, tree: c.Tree, when: c.Committer.When})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].when.Before(commits[j].when)
+	})
+
+	a := &analyzer{
+		ctx:     ctx,
+		store:   s,
+		top:     opts.Top,
+		seen:    map[string]bool{},
+		deepest: map[string][]Path{},
+		exts:    map[string]*Extension{},
+		report:  &Report{},
+	}
+	paths := map[string]Path{}
+	var total int64
+	for _, c := range commits {
+		size, err := a.size(c.hash)
+		if err != nil {
+			return nil, err
+		}
+		a.report.Commits++
+		a.report.CommitBytes += size
+
+		before := a.report.TreeBytes + a.report.BlobBytes
+		if err := a.walkTree(c.tree, ""); err != nil {
+			return nil, err
+		}
+		added := size + a.report.TreeBytes + a.report.BlobBytes - before
+		total += added
+		a.addGrowth(c.when.UTC().Format("2006-01"), added, total)
+
+		for _, p := range a.deepest[c.tree] {
+			paths[p.Path] = p
+		}
+	}
+
+	r := a.report
+	for _, p := range paths {
+		r.DeepestPaths = append(r.DeepestPaths, p)
+	}
+	sortPaths(r.DeepestPaths)
+	r.DeepestPaths = r.DeepestPaths[:min(len(r.DeepestPaths), a.top)]
+	for _, e := range a.exts {
+		r.Extensions = append(r.Extensions, *e)
+	}
+	sort.Slice(r.Extensions, func(i, j int) bool {
+		if r.Extensions[i].Size != r.Extensions[j].Size {
+			return r.Extensions[i].Size > r.Extensions[j].Size
+		}
+		return r.Extensions[i].Ext < r.Extensions[j].Ext
+	})
+	return r, nil
+}
+
+type commitInfo struct {
+	hash string
+	tree string
+	when time.Time
+}
+
+type analyzer struct {
+	ctx   context.Context
+	store object.Storer
+	top   int
+	seen  map[string]bool
+	// deepest: tree 해시 -> 그 안에서 가장 깊은 경로들 (tree 기준 상대 경로)
+	deepest map[string][]Path
+	exts    map[string]*Extension
+	report  *Report
+}
+
+// size: 객체 내용을 읽지 않고 헤더의 크기만 본다.
+func (a *analyzer) size(hash string) (int64, error) {
+	r, err := a.store.Open(hash)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	return r.Size, nil
+}
+
+// walkTree: 처음 보는 tree 와 그 아래의 처음 보는 객체를 센다.
+func (a *analyzer) walkTree(hash string, p string) error {
+	if a.seen[hash] {
+		return nil
+	}
+	a.seen[hash] = true
+	if err := a.ctx.Err(); err != nil {
+		return err
+	}
+
+	size, err := a.size(hash)
+	if err != nil {
+		return err
+	}
+	tree, err := object.ReadTree(a.store, hash)
+	if err != nil {
+		return err
+	}
+	a.report.Trees++
+	a.report.TreeBytes += size
+	a.report.LargestTrees = a.rank(a.report.LargestTrees, Object{Hash: hash, Size: size, Path: p, Entries: len(tree.Entries)})
+
+	var deepest []Path
+	for _, e := range tree.Entries {
+		child := path.Join(p, e.Name)
+		switch e.Mode {
+		case object.ModeTree:
+			if err := a.walkTree(e.Hash, child); err != nil {
+				return err
+			}
+			for _, d := range a.deepest[e.Hash] {
+				deepest = append(deepest, Path{Path: e.Name + "/" + d.Path, Depth: d.Depth + 1})
+			}
+		case object.ModeGitlink:
+			// 다른 저장소의 커밋이라 여기에는 없다
+		default:
+			deepest = append(deepest, Path{Path: e.Name, Depth: 1})
+			if err := a.addBlob(e.Hash, child); err != nil {
+				return err
+			}
+		}
+	}
+	sortPaths(deepest)
+	a.deepest[hash] = deepest[:min(len(deepest), a.top)]
+	return nil
+}
+
+func (a *analyzer) addBlob(hash string, p string) error {
+	if a.seen[hash] {
+		return nil
+	}
+	a.seen[hash] = true
+
+	size, err := a.size(hash)
+	if err != nil {
+		return err
+	}
+	a.report.Blobs++
+	a.report.BlobBytes += size
+	a.report.LargestBlobs = a.rank(a.report.LargestBlobs, Object{Hash: hash, Size: size, Path: p})
+
+	ext := strings.ToLower(path.Ext(path.Base(p)))
+	if ext == path.Base(p) {
+		// ".gitignore" 같은 이름은 확장자가 아니다
+		ext = ""
+	}
+	e := a.exts[ext]
+	if e == nil {
+		e = &Extension{Ext: ext}
+		a.exts[ext] = e
+	}
+	e.Blobs++
+	e.Size += size
+	return nil
+}
+
+// rank: 크기 순으로 top 개만 남긴다.
+func (a *analyzer) rank(list []Object, o Object) []Object {
+	i := sort.Search(len(list), func(i int) bool { return list[i].Size < o.Size })
+	if i >= a.top {
+		return list
+	}
+	list = append(list[:i], append([]Object{o}, list[i:]...)...)
+	return list[:min(len(list), a.top)]
+}
+
+func (a *analyzer) addGrowth(month string, added, total int64) {
+	growth := a.report.Growth
+	if n := len(growth); n == 0 || growth[n-1].Month != month {
+		a.report.Growth = append(growth, Period{Month: month})
+	}
+	last := &a.report.Growth[len(a.report.Growth)-1]
+	last.Commits++
+	last.Added += added
+	last.Total = total
+}
+
+// sortPaths: 깊은 것부터, 깊이가 같으면 경로 이름순
+func sortPaths(paths []Path) {
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i].Depth != paths[j].Depth {
+			return paths[i].Depth > paths[j].Depth
+		}
+		return paths[i].Path < paths[j].Path
+	})
+}
+
+// WriteText: 사람이 읽는 보고서를 쓴다.
+// gogit 은 loose 객체와 gc 가 만드는 packfile 모두 delta 없이 객체를 통째로 저장한다.
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Commits       %8d  %10s\n", r.Commits, FormatSize(r.CommitBytes))
+	fmt.Fprintf(&b, "Trees         %8d  %10s\n", r.Trees, FormatSize(r.TreeBytes))
+	fmt.Fprintf(&b, "Blobs         %8d  %10s\n", r.Blobs, FormatSize(r.BlobBytes))
+	fmt.Fprintf(&b, "Total                   %10s\n", FormatSize(r.CommitBytes+r.TreeBytes+r.BlobBytes))
+	b.WriteString("Delta chains  none (objects are stored whole, without deltas)\n")
+
+	b.WriteString("\nLargest blobs\n")
+	for _, o := range r.LargestBlobs {
+		fmt.Fprintf(&b, "  %10s  %s  %s\n", FormatSize(o.Size), o.Hash[:7], o.Path)
+	}
+	b.WriteString("\nLargest trees\n")
+	for _, o := range r.LargestTrees {
+		name := o.Path + "/"
+		if o.Path == "" {
+			name = "(root)"
+		}
+		fmt.Fprintf(&b, "  %10s  %s  %5d entries  %s\n", FormatSize(o.Size), o.Hash[:7], o.Entries, name)
+	}
+	b.WriteString("\nDeepest paths\n")
+	for _, p := range r.DeepestPaths {
+		fmt.Fprintf(&b, "  %3d  %s\n", p.Depth, p.Path)
+	}
+	b.WriteString("\nBy extension\n")
+	for _, e := range r.Extensions {
+		ext := e.Ext
+		if ext == "" {
+			ext = "(none)"
+		}
+		fmt.Fprintf(&b, "  %-12s %6d blobs  %10s\n", ext, e.Blobs, FormatSize(e.Size))
+	}
+	b.WriteString("\nGrowth\n")
+	for _, p := range r.Growth {
+		fmt.Fprintf(&b, "  %s  %5d commits  %10s  total %10s\n", p.Month, p.Commits, "+"+FormatSize(p.Added), FormatSize(p.Total))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// FormatSize: 1024 단위의 읽기 쉬운 크기 ("512 B", "1.5 KiB", "3.2 MiB")
+func FormatSize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	v := float64(n)
+	for _, unit := range []string{"KiB", "MiB", "GiB"} {
+		v /= 1024
+		if v < 1024 || unit == "GiB" {
+			return fmt.Sprintf("%.1f %s", v, unit)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,193 @@
+// Package stack 은 서로 위에 쌓인 토픽 브랜치들의 부모/자식 관계를 기록한다.
+//
+//	main ← feature-a ← feature-b
+//
+// 브랜치마다 부모 브랜치와, 그 브랜치를 부모 위에 마지막으로 올렸을 때의 부모 커밋(base)을 기억한다.
+// 부모가 움직이면(새 커밋, rebase, amend) base..브랜치 의 커밋을 부모의 새 끝으로 옮기면 된다.
+// (rebase --onto <부모> <base> <브랜치>)
+//
+//	stack    <브랜치>\t<부모>\t<base>  (한 줄에 하나, 브랜치 이름은 refs/heads/ 를 뺀 것)
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// File: 관계를 기록하는 파일 (저장소 디렉토리 기준)
+const File = "stack"
+
+// ErrCycle: 부모를 따라가다 자기 자신으로 돌아오는 관계
+var ErrCycle = errors.New("stack would contain a cycle")
+
+// Branch: 쌓인 브랜치 하나
+type Branch struct {
+	Name   string
+	Parent string
+	// Base: 이 브랜치를 마지막으로 올린 부모 커밋
+	Base string
+}
+
+// Load: 기록된 브랜치들. 파일이 없으면 빈 목록
+func Load(fsys vfs.Filesystem) ([]Branch, error) {
+	data, err := vfs.ReadFile(fsys, File)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var branches []Branch
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("corrupt %s line: %q", File, line)
+		}
+		branches = append(branches, Branch{Name: fields[0], Parent: fields[1], Base: fields[2]})
+	}
+	return branches, nil
+}
+
+// Save: 브랜치들을 이름순으로 기록한다. 목록이 비면 파일을 지운다.
+func Save(fsys vfs.Filesystem, branches []Branch) error {
+	if len(branches) == 0 {
+		if err := fsys.Remove(File); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	sorted := make([]Branch, len(branches))
+	copy(sorted, branches)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	var b strings.Builder
+	for _, br := range sorted {
+		b.WriteString(br.Name + "\t" + br.Parent + "\t" + br.Base + "\n")
+	}
+	return vfs.WriteFile(fsys, File, []byte(b.String()))
+}
+
+// Set: br 을 추가하거나 같은 이름의 기록을 바꾼다. 부모를 따라가다 br 로 돌아오면 ErrCycle
+func Set(branches []Branch, br Branch) ([]Branch, error) {
+	parents := map[string]string{br.Name: br.Parent}
+	for _, b := range branches {
+		if b.Name != br.Name {
+			parents[b.Name] = b.Parent
+		}
+	}
+	for name, n := br.Parent, 0; name != ""; name, n = parents[name], n+1 {
+		if name == br.Name || n > len(parents) {
+			return nil, fmt.Errorf("%w: %s", ErrCycle, br.Name)
+		}
+	}
+	for i := range branches {
+		if branches[i].Name == br.Name {
+			branches[i] = br
+			return branches, nil
+		}
+	}
+	return append(branches, br), nil
+}
+
+// Remove: name 의 기록을 뺀다. name 위에 쌓인 브랜치들은 name 의 부모 위에 쌓인 것으로 바꾼다.
+// 그 브랜치들의 base 도 name 의 base 로 바꾸어, restack 할 때 name 의 커밋들을 함께 옮기게 한다.
+func Remove(branches []Branch, name string) ([]Branch, bool) {
+	var removed *Branch
+	var out []Branch
+	for i := range branches {
+		if branches[i].Name == name {
+			removed = &branches[i]
+			continue
+		}
+		out = append(out, branches[i])
+	}
+	if removed == nil {
+		return branches, false
+	}
+	for i := range out {
+		if out[i].Parent == name {
+			out[i].Parent = removed.Parent
+			out[i].Base = removed.Base
+		}
+	}
+	return out, true
+}
+
+// Children: parent 바로 위에 쌓인 브랜치들 (이름순)
+func Children(branches []Branch, parent string) []Branch {
+	var out []Branch
+	for _, b := range branches {
+		if b.Parent == parent {
+			out = append(out, b)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Roots: 기록되지 않은 부모들 (스택의 맨 아래, 보통 main). 이름순
+func Roots(branches []Branch) []string {
+	tracked := map[string]bool{}
+	for _, b := range branches {
+		tracked[b.Name] = true
+	}
+	var roots []string
+	for _, b := range branches {
+		if !tracked[b.Parent] && !slices.Contains(roots, b.Parent) {
+			roots = append(roots, b.Parent)
+		}
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// Order: 부모가 자식보다 먼저 오는 순서. restack 은 이 순서로 옮겨야 자식이 옮겨진 부모 위에 올라간다.
+func Order(branches []Branch) []Branch {
+	var out []Branch
+	var visit func(parent string)
+	visit = func(parent string) {
+		for _, b := range Children(branches, parent) {
+			out = append(out, b)
+			visit(b.Name)
+		}
+	}
+	for _, root := range Roots(branches) {
+		visit(root)
+	}
+	return out
+}
+
+// ForkPoint: branch 가 parent 에서 갈라진 커밋. parent 가 branch 의 조상이면 parent 그 자체다.
+// branch 에서 도달할 수 있는 커밋 중 parent 에서도 도달할 수 있는 가장 최근 커밋을 고른다.
+func ForkPoint(ctx context.Context, s object.Storer, branch, parent string) (string, error) {
+	onParent, err := object.Reachable(ctx, s, []string{parent})
+	if err != nil {
+		return "", err
+	}
+	var base string
+	errFound := errors.New("found")
+	err = object.NewCommitIter(s, []string{branch}, object.OrderDate).ForEachContext(ctx, func(hash string, _ *object.Commit) error {
+		if onParent[hash] {
+			base = hash
+			return errFound
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errFound) {
+		return "", err
+	}
+	if base == "" {
+		return "", fmt.Errorf("%s and %s have no common history", branch[:7], parent[:7])
+	}
+	return base, nil
+}
@@ -0,0 +1,90 @@
+// Package submodule 은 작업 트리 루트의 .gogitmodules (git 저장소면 .gitmodules) 파일을 읽고 고친다.
+// 형식은 git config 와 같고, submodule 마다 이름을 하위 섹션으로 하는 섹션이 하나씩 있다.
+//
+//	[submodule "lib"]
+//		path = vendor/lib
+//		url = ../lib.bundle
+//
+// 상위 저장소의 tree 에는 path 에 submodule 의 커밋을 가리키는 gitlink(모드 160000) 항목이 들어간다.
+package submodule
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/tmdgusya/gogit/config"
+)
+
+// Module: submodule 하나
+type Module struct {
+	// Name: 하위 섹션 이름. add 는 path 를 이름으로 쓴다
+	Name string
+	// Path: 상위 작업 트리에서의 경로
+	Path string
+	// URL: 가져올 곳. "./" 나 "../" 로 시작하면 상위 저장소의 origin 기준 상대 경로다
+	URL string
+}
+
+// Parse: 파일 내용에서 submodule 들을 파일에 나온 순서로 읽는다. path 가 없는 항목은 잘못된 것이다.
+func Parse(data []byte) ([]Module, error) {
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	var modules []Module
+	for _, name := range cfg.Subsections("submodule") {
+		m := Module{Name: name}
+		m.Path, _ = cfg.Get("submodule." + name + ".path")
+		m.URL, _ = cfg.Get("submodule." + name + ".url")
+		if err := checkPath(m.Path); err != nil {
+			return nil, fmt.Errorf("submodule '%s': %w", name, err)
+		}
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+// Add: m 의 섹션을 파일 내용 끝에 더한다
+func Add(data []byte, m Module) ([]byte, error) {
+	if err := checkPath(m.Path); err != nil {
+		return nil, err
+	}
+	data, err := config.Add(data, "submodule."+m.Name+".path", m.Path)
+	if err != nil {
+		return nil, err
+	}
+	return config.Add(data, "submodule."+m.Name+".url", m.URL)
+}
+
+// ByPath: 경로가 p 인 submodule
+func ByPath(modules []Module, p string) (Module, bool) {
+	for _, m := range modules {
+		if m.Path == p {
+			return m, true
+		}
+	}
+	return Module{}, false
+}
+
+// IsRelativeURL: 상위 저장소의 origin 을 기준으로 풀어야 하는 url 인지
+func IsRelativeURL(url string) bool {
+	return strings.HasPrefix(url, "./") || strings.HasPrefix(url, "../")
+}
+
+// checkPath: 작업 트리 밖이나 저장소 디렉토리를 가리키지 않는 상대 경로여야 한다 (git 의 submodule 경로 검사와 같은 목적)
+func checkPath(p string) error {
+	if p == "" {
+		return errors.New("missing path")
+	}
+	if path.IsAbs(p) || path.Clean(p) != p || p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return fmt.Errorf("invalid path '%s'", p)
+	}
+	for _, part := range strings.Split(p, "/") {
+		if strings.EqualFold(part, ".git") || strings.EqualFold(part, ".gogit") {
+			return fmt.Errorf("invalid path '%s'", p)
+		}
+	}
+	return nil
+}
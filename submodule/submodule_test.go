@@ -0,0 +1,53 @@
+package submodule
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAdd(t *testing.T) {
+	data, err := Add(nil, Module{Name: "lib", Path: "vendor/lib", URL: "../lib.bundle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err = Add(data, Module{Name: "docs", Path: "docs", URL: "/srv/docs.bundle"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "[submodule \"lib\"]") {
+		t.Errorf("file = %q", data)
+	}
+	modules, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Module{{"lib", "vendor/lib", "../lib.bundle"}, {"docs", "docs", "/srv/docs.bundle"}}
+	if len(modules) != len(want) || modules[0] != want[0] || modules[1] != want[1] {
+		t.Fatalf("modules = %+v", modules)
+	}
+	if m, ok := ByPath(modules, "docs"); !ok || m.Name != "docs" {
+		t.Errorf("ByPath(docs) = %+v, %t", m, ok)
+	}
+	if _, ok := ByPath(modules, "vendor"); ok {
+		t.Error("ByPath matched a parent directory")
+	}
+}
+
+func TestInvalidPath(t *testing.T) {
+	for _, p := range []string{"", "/abs", "..", "../outside", "a/../b", "a/./b", "a/", ".git", "sub/.GIT/hooks", ".gogit"} {
+		if _, err := Add(nil, Module{Name: "x", Path: p, URL: "u"}); err == nil {
+			t.Errorf("Add accepted path %q", p)
+		}
+		data := "[submodule \"x\"]\n\tpath = " + p + "\n\turl = u\n"
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse accepted path %q", p)
+		}
+	}
+}
+
+func TestIsRelativeURL(t *testing.T) {
+	for url, want := range map[string]bool{"./lib": true, "../lib.bundle": true, "/srv/lib": false, "lib": false, "https://example.com/lib": false} {
+		if got := IsRelativeURL(url); got != want {
+			t.Errorf("IsRelativeURL(%q) = %t", url, got)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/tmdgusya/gogit/submodule"
+)
+
+// ModulesFile: 작업 트리 루트의 submodule 목록 파일 이름
+// 저장소 디렉토리 이름을 따르므로 .gogit 이면 .gogitmodules, git 저장소(.git)면 git 과 같은 .gitmodules 다.
+func (r *Repository) ModulesFile() string {
+	if filepath.Base(r.CommonDir) == ".git" {
+		return ".gitmodules"
+	}
+	return ".gogitmodules"
+}
+
+// Submodules: 작업 트리의 목록 파일에 적힌 submodule 들. 파일이 없으면 빈 목록
+func (r *Repository) Submodules() ([]submodule.Module, error) {
+	if err := r.RequireWorkTree("submodule"); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(r.WorkTree, r.ModulesFile()))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	modules, err := submodule.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r.ModulesFile(), err)
+	}
+	return modules, nil
+}
+
+// OpenSubmodule: 작업 트리의 path 에 받아 둔 submodule 저장소를 연다. 상위 디렉토리로 올라가며 찾지 않으므로
+// 아직 받지 않았으면 상위 저장소 대신 ErrNotARepository 를 돌려준다.
+// submodule 의 저장소 디렉토리는 .gogit 과 .git 중 있는 쪽이다.
+func (r *Repository) OpenSubmodule(path string) (*Repository, error) {
+	if err := r.RequireWorkTree("submodule"); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(r.WorkTree, filepath.FromSlash(path))
+	for _, name := range []string{DefaultDirName, ".git"} {
+		repo, err := openWorkTree(dir, name)
+		if repo != nil || err != nil {
+			return repo, err
+		}
+	}
+	return nil, fmt.Errorf("%w: '%s'", ErrNotARepository, path)
+}
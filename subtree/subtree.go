@@ -0,0 +1,180 @@
+// Package subtree 는 하위 디렉토리 단위로 이력을 떼어 내거나(split) 다른 이력을 하위 디렉토리로 들여온다(add/merge).
+//
+// 모두 객체만 다루므로 결과는 새 커밋 해시다. 브랜치를 옮기는 것은 호출하는 쪽의 몫이다.
+package subtree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tmdgusya/gogit/object"
+)
+
+// ErrUpToDate: merge 할 내용이 이미 prefix 에 들어 있음
+var ErrUpToDate = errors.New("subtree is already up to date")
+
+// CommitOptions: add/merge 가 만드는 커밋의 정보. Message 가 비어 있으면 git subtree 와 같은 기본 메시지
+type CommitOptions struct {
+	Author    object.Signature
+	Committer object.Signature
+	Message   string
+}
+
+// Split: head 의 이력 중 prefix 디렉토리만 뽑아 prefix 가 루트인 새 이력을 만들고 그 끝 커밋을 돌려준다.
+// prefix 를 바꾸지 않은 커밋은 건너뛰고, 작성자/커미터/메시지는 원래 커밋 것을 그대로 쓰므로
+// 같은 입력이면 언제나 같은 해시가 나온다. (다시 split 해도 이전 결과에 이어진다)
+func Split(ctx context.Context, s object.Storer, head string, prefix string) (string, error) {
+	// 부모를 먼저 처리해야 하므로 위상 정렬 결과를 뒤집어서 쓴다
+	var order []string
+	commits := map[string]*object.Commit{}
+	err := object.NewCommitIter(s, []string{head}, object.OrderTopo).ForEachContext(ctx, func(hash string, commit *object.Commit) error {
+		order = append(order, hash)
+		commits[hash] = commit
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// 원래 커밋 -> split 결과 커밋 ("" 이면 prefix 가 아직 없던 커밋)
+	mapped := map[string]string{}
+	trees := map[string]string{}
+	for i := len(order) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		hash := order[i]
+		commit := commits[hash]
+
+		var parents []string
+		seen := map[string]bool{}
+		for _, parent := range commit.Parents {
+			if p := mapped[parent]; p != "" && !seen[p] {
+				seen[p] = true
+				parents = append(parents, p)
+			}
+		}
+
+		entry, ok, err := object.LookupPath(s, commit.Tree, prefix)
+		if err != nil {
+			return "", err
+		}
+		if !ok || entry.Mode != object.ModeTree {
+			// prefix 가 없는 커밋은 이력에 넣지 않는다
+			if len(parents) > 0 {
+				mapped[hash] = parents[0]
+			}
+			continue
+		}
+
+		// prefix 를 건드리지 않은 커밋은 부모의 split 결과를 그대로 쓴다
+		if len(parents) == 1 && trees[parents[0]] == entry.Hash {
+			mapped[hash] = parents[0]
+			continue
+		}
+
+		split := &object.Commit{
+			Tree:      entry.Hash,
+			Parents:   parents,
+			Author:    commit.Author,
+			Committer: commit.Committer,
+			Message:   commit.Message,
+		}
+		// 서명은 원래 tree 에 대한 것이므로 옮기지 않는다
+		for _, h := range commit.ExtraHeaders {
+			if h.Key == "encoding" {
+				split.ExtraHeaders = append(split.ExtraHeaders, h)
+			}
+		}
+		newHash, err := object.WriteObject(s, split)
+		if err != nil {
+			return "", err
+		}
+		mapped[hash] = newHash
+		trees[newHash] = entry.Hash
+	}
+
+	if mapped[head] == "" {
+		return "", fmt.Errorf("prefix %s does not exist in %s", prefix, head)
+	}
+	return mapped[head], nil
+}
+
+// Add: commit 의 tree 를 head 의 prefix 아래에 넣은 merge commit 을 만든다.
+// 두 이력이 이어지므로 이후 Merge 로 같은 곳에서 새 내용을 가져올 수 있다.
+func Add(s object.Storer, head string, prefix string, commit string, opts CommitOptions) (string, error) {
+	headCommit, err := object.ReadCommit(s, head)
+	if err != nil {
+		return "", err
+	}
+	if _, ok, err := object.LookupPath(s, headCommit.Tree, prefix); err != nil {
+		return "", err
+	} else if ok {
+		return "", fmt.Errorf("prefix '%s' already exists", prefix)
+	}
+
+	if opts.Message == "" {
+		opts.Message = fmt.Sprintf("Add '%s/' from commit '%s'\n", prefix, commit)
+	}
+	return graft(s, head, headCommit, prefix, commit, opts)
+}
+
+// Merge: prefix 아래의 내용을 commit 의 tree 로 바꾸는 merge commit 을 만든다.
+// 삼방향 병합은 하지 않는다. prefix 의 현재 내용이 commit 이나 그 조상 중 하나의 tree 와 같아야 하고,
+// 그렇지 않으면(prefix 를 로컬에서 고쳤으면) 에러를 돌려준다.
+func Merge(ctx context.Context, s object.Storer, head string, prefix string, commit string, opts CommitOptions) (string, error) {
+	headCommit, err := object.ReadCommit(s, head)
+	if err != nil {
+		return "", err
+	}
+	entry, ok, err := object.LookupPath(s, headCommit.Tree, prefix)
+	if err != nil {
+		return "", err
+	}
+	if !ok || entry.Mode != object.ModeTree {
+		return "", fmt.Errorf("prefix '%s' does not exist; use add first", prefix)
+	}
+
+	found := false
+	errFound := errors.New("found")
+	err = object.NewCommitIter(s, []string{commit}, object.OrderDate).ForEachContext(ctx, func(hash string, c *object.Commit) error {
+		if c.Tree != entry.Hash {
+			return nil
+		}
+		if hash == commit {
+			return ErrUpToDate
+		}
+		found = true
+		return errFound
+	})
+	if err != nil && err != errFound {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("prefix '%s' has local changes that are not in %s; three-way subtree merges are not supported", prefix, commit)
+	}
+
+	if opts.Message == "" {
+		opts.Message = fmt.Sprintf("Merge commit '%s'\n", commit)
+	}
+	return graft(s, head, headCommit, prefix, commit, opts)
+}
+
+func graft(s object.Storer, head string, headCommit *object.Commit, prefix string, commit string, opts CommitOptions) (string, error) {
+	other, err := object.ReadCommit(s, commit)
+	if err != nil {
+		return "", err
+	}
+	tree, err := object.ReplacePath(s, headCommit.Tree, prefix, &object.TreeEntry{Mode: object.ModeTree, Hash: other.Tree})
+	if err != nil {
+		return "", err
+	}
+	return object.WriteObject(s, &object.Commit{
+		Tree:      tree,
+		Parents:   []string{head, commit},
+		Author:    opts.Author,
+		Committer: opts.Committer,
+		Message:   opts.Message,
+	})
+}
@@ -0,0 +1,248 @@
+package vfs
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory: 메모리 위의 파일시스템
+// 디스크를 건드리지 않아야 하는 임베딩 환경이나 빠른 실험에 사용한다.
+// Chroot 로 만든 하위 파일시스템은 같은 데이터를 공유한다.
+type Memory struct {
+	data   *memData
+	prefix string
+}
+
+type memData struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+	dirs  map[string]bool
+}
+
+// NewMemory: 비어 있는 메모리 파일시스템
+func NewMemory() *Memory {
+	return &Memory{
+		data: &memData{
+			files: map[string][]byte{},
+			dirs:  map[string]bool{".": true},
+		},
+		prefix: ".",
+	}
+}
+
+func (m *Memory) clean(name string) string {
+	return path.Join(m.prefix, name)
+}
+
+func pathError(op string, name string, err error) error {
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+func (m *Memory) Open(name string) (File, error) {
+	p := m.clean(name)
+
+	m.data.mu.RLock()
+	defer m.data.mu.RUnlock()
+
+	content, ok := m.data.files[p]
+	if !ok {
+		return nil, pathError("open", name, fs.ErrNotExist)
+	}
+	return &memFile{reader: bytes.NewReader(content), name: p}, nil
+}
+
+func (m *Memory) Create(name string) (File, error) {
+	return m.create(name, false)
+}
+
+func (m *Memory) CreateExclusive(name string) (File, error) {
+	return m.create(name, true)
+}
+
+func (m *Memory) create(name string, exclusive bool) (File, error) {
+	p := m.clean(name)
+
+	m.data.mu.Lock()
+	defer m.data.mu.Unlock()
+
+	if _, ok := m.data.files[p]; ok && exclusive {
+		return nil, pathError("create", name, fs.ErrExist)
+	}
+	if !m.data.dirs[path.Dir(p)] {
+		return nil, pathError("create", name, fs.ErrNotExist)
+	}
+	if m.data.dirs[p] {
+		return nil, pathError("create", name, fs.ErrExist)
+	}
+	m.data.files[p] = nil
+	return &memFile{fs: m.data, name: p}, nil
+}
+
+func (m *Memory) Stat(name string) (fs.FileInfo, error) {
+	p := m.clean(name)
+
+	m.data.mu.RLock()
+	defer m.data.mu.RUnlock()
+
+	if m.data.dirs[p] {
+		return memInfo{name: path.Base(p), dir: true}, nil
+	}
+	if content, ok := m.data.files[p]; ok {
+		return memInfo{name: path.Base(p), size: int64(len(content))}, nil
+	}
+	return nil, pathError("stat", name, fs.ErrNotExist)
+}
+
+func (m *Memory) MkdirAll(name string, perm os.FileMode) error {
+	p := m.clean(name)
+
+	m.data.mu.Lock()
+	defer m.data.mu.Unlock()
+
+	for dir := p; !m.data.dirs[dir]; dir = path.Dir(dir) {
+		if _, ok := m.data.files[dir]; ok {
+			return pathError("mkdir", name, fs.ErrExist)
+		}
+		m.data.dirs[dir] = true
+	}
+	return nil
+}
+
+func (m *Memory) ReadDir(name string) ([]fs.DirEntry, error) {
+	p := m.clean(name)
+
+	m.data.mu.RLock()
+	defer m.data.mu.RUnlock()
+
+	if !m.data.dirs[p] {
+		return nil, pathError("readdir", name, fs.ErrNotExist)
+	}
+
+	var entries []fs.DirEntry
+	for dir := range m.data.dirs {
+		if dir != p && path.Dir(dir) == p {
+			entries = append(entries, fs.FileInfoToDirEntry(memInfo{name: path.Base(dir), dir: true}))
+		}
+	}
+	for file, content := range m.data.files {
+		if path.Dir(file) == p {
+			entries = append(entries, fs.FileInfoToDirEntry(memInfo{name: path.Base(file), size: int64(len(content))}))
+		}
+	}
+
+	// os.ReadDir 과 같이 이름순으로 정렬
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+func (m *Memory) Remove(name string) error {
+	p := m.clean(name)
+
+	m.data.mu.Lock()
+	defer m.data.mu.Unlock()
+
+	if _, ok := m.data.files[p]; ok {
+		delete(m.data.files, p)
+		return nil
+	}
+	if m.data.dirs[p] {
+		for other := range m.data.dirs {
+			if strings.HasPrefix(other, p+"/") {
+				return pathError("remove", name, fs.ErrInvalid)
+			}
+		}
+		for other := range m.data.files {
+			if strings.HasPrefix(other, p+"/") {
+				return pathError("remove", name, fs.ErrInvalid)
+			}
+		}
+		delete(m.data.dirs, p)
+		return nil
+	}
+	return pathError("remove", name, fs.ErrNotExist)
+}
+
+// Rename: 파일만 지원한다. (객체/ref 저장소는 임시 파일 -> 최종 파일 이동에만 사용)
+func (m *Memory) Rename(oldname string, newname string) error {
+	oldPath := m.clean(oldname)
+	newPath := m.clean(newname)
+
+	m.data.mu.Lock()
+	defer m.data.mu.Unlock()
+
+	content, ok := m.data.files[oldPath]
+	if !ok {
+		return pathError("rename", oldname, fs.ErrNotExist)
+	}
+	if !m.data.dirs[path.Dir(newPath)] {
+		return pathError("rename", newname, fs.ErrNotExist)
+	}
+	m.data.files[newPath] = content
+	delete(m.data.files, oldPath)
+	return nil
+}
+
+func (m *Memory) Chroot(dir string) Filesystem {
+	return &Memory{data: m.data, prefix: m.clean(dir)}
+}
+
+// memFile: 읽기용이면 reader 를, 쓰기용이면 buf 를 사용한다.
+// 쓰기 내용은 Close 할 때 파일시스템에 반영된다.
+type memFile struct {
+	reader *bytes.Reader
+
+	fs   *memData
+	name string
+	buf  bytes.Buffer
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.reader == nil {
+		return 0, pathError("read", f.name, fs.ErrPermission)
+	}
+	return f.reader.Read(p)
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if f.fs == nil {
+		return 0, pathError("write", f.name, fs.ErrPermission)
+	}
+	return f.buf.Write(p)
+}
+
+func (f *memFile) Close() error {
+	if f.fs == nil {
+		return nil
+	}
+
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	f.fs.files[f.name] = bytes.Clone(f.buf.Bytes())
+	return nil
+}
+
+type memInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i memInfo) Name() string { return i.name }
+func (i memInfo) Size() int64  { return i.size }
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return i.dir }
+func (i memInfo) Sys() any           { return nil }
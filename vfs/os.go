@@ -0,0 +1,84 @@
+package vfs
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OS: 실제 디스크의 root 디렉토리를 루트로 하는 파일시스템
+type OS struct {
+	root string
+}
+
+// NewOS: root 를 기준으로 하는 디스크 파일시스템
+func NewOS(root string) *OS {
+	return &OS{root: root}
+}
+
+// Root: 디스크 상의 루트 경로
+func (o *OS) Root() string {
+	return o.root
+}
+
+func (o *OS) abs(name string) string {
+	return filepath.Join(o.root, filepath.FromSlash(name))
+}
+
+func (o *OS) Open(name string) (File, error) {
+	return os.Open(o.abs(name))
+}
+
+func (o *OS) Create(name string) (File, error) {
+	return os.Create(o.abs(name))
+}
+
+func (o *OS) CreateExclusive(name string) (File, error) {
+	return os.OpenFile(o.abs(name), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+}
+
+func (o *OS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(o.abs(name))
+}
+
+func (o *OS) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(o.abs(name))
+}
+
+func (o *OS) MkdirAll(name string, perm os.FileMode) error {
+	return os.MkdirAll(o.abs(name), perm)
+}
+
+func (o *OS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(o.abs(name))
+}
+
+func (o *OS) Remove(name string) error {
+	return os.Remove(o.abs(name))
+}
+
+func (o *OS) Rename(oldname string, newname string) error {
+	return os.Rename(o.abs(oldname), o.abs(newname))
+}
+
+func (o *OS) Readlink(name string) (string, error) {
+	target, err := os.Readlink(o.abs(name))
+	return filepath.ToSlash(target), err
+}
+
+func (o *OS) Symlink(target string, name string) error {
+	return os.Symlink(filepath.FromSlash(target), o.abs(name))
+}
+
+func (o *OS) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(o.abs(name), mode)
+}
+
+func (o *OS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return os.Chtimes(o.abs(name), atime, mtime)
+}
+
+func (o *OS) Chroot(dir string) Filesystem {
+	return NewOS(o.abs(dir))
+}
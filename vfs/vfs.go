@@ -0,0 +1,137 @@
+// Package vfs 는 저장소가 사용하는 파일시스템을 추상화한다.
+// object/refs 저장소는 이 인터페이스만 사용하므로 디스크 대신
+// 메모리나 다른 저장 장치 위에서도 같은 코드로 동작한다.
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+)
+
+// File: 열린 파일
+// 읽기용(Open) 또는 쓰기용(Create) 중 하나로만 사용한다.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
+// Filesystem: 파일시스템 인터페이스
+// 경로는 항상 '/' 로 구분된 상대 경로이며 파일시스템의 루트를 기준으로 한다.
+type Filesystem interface {
+	Open(name string) (File, error)
+	// Create: 파일을 새로 만들거나 비우고 연다. 상위 디렉토리는 미리 있어야 한다.
+	Create(name string) (File, error)
+	// CreateExclusive: 파일이 없을 때만 새로 만든다. 이미 있으면 fs.ErrExist 에러
+	// 여러 프로세스 사이의 잠금(.lock 파일)에 사용한다.
+	CreateExclusive(name string) (File, error)
+	Stat(name string) (fs.FileInfo, error)
+	MkdirAll(name string, perm os.FileMode) error
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Remove(name string) error
+	Rename(oldname string, newname string) error
+	// Chroot: dir 을 루트로 하는 하위 파일시스템
+	Chroot(dir string) Filesystem
+}
+
+// LinkReader: 심볼릭 링크를 지원하는 파일시스템이 구현한다. (OS)
+type LinkReader interface {
+	Readlink(name string) (string, error)
+}
+
+// Readlink: 링크가 가리키는 경로. 파일시스템이 링크를 지원하지 않으면 에러
+func Readlink(fsys Filesystem, name string) (string, error) {
+	if lr, ok := fsys.(LinkReader); ok {
+		return lr.Readlink(name)
+	}
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: errors.ErrUnsupported}
+}
+
+// Lstater: 심볼릭 링크를 따라가지 않고 링크 자체의 정보를 줄 수 있는 파일시스템이 구현한다. (OS)
+type Lstater interface {
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// Lstat: name 이 링크면 링크 자체의 정보. 링크가 없는 파일시스템이면 Stat 과 같다
+func Lstat(fsys Filesystem, name string) (fs.FileInfo, error) {
+	if l, ok := fsys.(Lstater); ok {
+		return l.Lstat(name)
+	}
+	return fsys.Stat(name)
+}
+
+// LinkWriter: 심볼릭 링크를 만들 수 있는 파일시스템이 구현한다. (OS)
+type LinkWriter interface {
+	Symlink(target string, name string) error
+}
+
+// Symlink: target 을 가리키는 링크 name 을 만든다. 파일시스템이 링크를 지원하지 않으면 에러
+func Symlink(fsys Filesystem, target string, name string) error {
+	if lw, ok := fsys.(LinkWriter); ok {
+		return lw.Symlink(target, name)
+	}
+	return &fs.PathError{Op: "symlink", Path: name, Err: errors.ErrUnsupported}
+}
+
+// Chmoder: 파일 권한을 바꿀 수 있는 파일시스템이 구현한다. (OS)
+type Chmoder interface {
+	Chmod(name string, mode os.FileMode) error
+}
+
+// Chmod: 파일 권한을 바꾼다. 파일시스템이 권한을 지원하지 않으면 에러
+func Chmod(fsys Filesystem, name string, mode os.FileMode) error {
+	if c, ok := fsys.(Chmoder); ok {
+		return c.Chmod(name, mode)
+	}
+	return &fs.PathError{Op: "chmod", Path: name, Err: errors.ErrUnsupported}
+}
+
+// Chtimeser: 파일의 접근/수정 시각을 바꿀 수 있는 파일시스템이 구현한다. (OS)
+type Chtimeser interface {
+	Chtimes(name string, atime time.Time, mtime time.Time) error
+}
+
+// Chtimes: 파일의 접근/수정 시각을 바꾼다. 파일시스템이 시각을 지원하지 않으면 에러
+func Chtimes(fsys Filesystem, name string, atime time.Time, mtime time.Time) error {
+	if c, ok := fsys.(Chtimeser); ok {
+		return c.Chtimes(name, atime, mtime)
+	}
+	return &fs.PathError{Op: "chtimes", Path: name, Err: errors.ErrUnsupported}
+}
+
+// ReadFile: 파일 전체를 읽는다.
+func ReadFile(fsys Filesystem, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// WriteFile: 파일 전체를 쓴다. 상위 디렉토리가 없으면 만든다.
+func WriteFile(fsys Filesystem, name string, data []byte) error {
+	if err := fsys.MkdirAll(path.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	f, err := fsys.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Exists: 파일 또는 디렉토리 존재 여부
+func Exists(fsys Filesystem, name string) bool {
+	_, err := fsys.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,162 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+// filesystems: 같은 동작을 확인할 구현들
+func filesystems(t *testing.T) map[string]Filesystem {
+	return map[string]Filesystem{"memory": NewMemory(), "os": NewOS(t.TempDir())}
+}
+
+func TestReadWriteFile(t *testing.T) {
+	for name, fsys := range filesystems(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := WriteFile(fsys, "a/b/c.txt", []byte("hello")); err != nil {
+				t.Fatal(err)
+			}
+			data, err := ReadFile(fsys, "a/b/c.txt")
+			if err != nil || string(data) != "hello" {
+				t.Fatalf("ReadFile = %q, %v", data, err)
+			}
+			info, err := fsys.Stat("a/b")
+			if err != nil || !info.IsDir() {
+				t.Errorf("Stat(a/b) = %v, %v", info, err)
+			}
+			if info, err := fsys.Stat("a/b/c.txt"); err != nil || info.Size() != 5 || info.IsDir() {
+				t.Errorf("Stat(a/b/c.txt) = %v, %v", info, err)
+			}
+			if _, err := fsys.Open("missing"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Open(missing) err = %v", err)
+			}
+			if _, err := fsys.Create("no/such/dir/file"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Create without parent err = %v", err)
+			}
+			if !Exists(fsys, "a") || Exists(fsys, "b") {
+				t.Error("Exists is wrong")
+			}
+		})
+	}
+}
+
+func TestCreateExclusive(t *testing.T) {
+	for name, fsys := range filesystems(t) {
+		t.Run(name, func(t *testing.T) {
+			f, err := fsys.CreateExclusive("lock")
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+			if _, err := fsys.CreateExclusive("lock"); !errors.Is(err, fs.ErrExist) {
+				t.Errorf("second CreateExclusive err = %v, want ErrExist", err)
+			}
+		})
+	}
+}
+
+func TestReadDirRenameRemove(t *testing.T) {
+	for name, fsys := range filesystems(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, p := range []string{"d/b", "d/a", "d/sub/x"} {
+				if err := WriteFile(fsys, p, []byte(p)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			entries, err := fsys.ReadDir("d")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+			if len(names) != 3 || names[0] != "a" || names[1] != "b" || names[2] != "sub" || !entries[2].IsDir() {
+				t.Errorf("ReadDir = %v", names)
+			}
+
+			if err := fsys.Rename("d/a", "d/sub/a"); err != nil {
+				t.Fatal(err)
+			}
+			if Exists(fsys, "d/a") {
+				t.Error("renamed file still exists")
+			}
+			if data, _ := ReadFile(fsys, "d/sub/a"); string(data) != "d/a" {
+				t.Errorf("renamed content = %q", data)
+			}
+
+			if err := fsys.Remove("d/sub"); err == nil {
+				t.Error("removed a non-empty directory")
+			}
+			for _, p := range []string{"d/sub/a", "d/sub/x", "d/sub"} {
+				if err := fsys.Remove(p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := fsys.Remove("d/sub"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Remove(missing) err = %v", err)
+			}
+		})
+	}
+}
+
+func TestChroot(t *testing.T) {
+	for name, fsys := range filesystems(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := fsys.MkdirAll("objects", 0755); err != nil {
+				t.Fatal(err)
+			}
+			sub := fsys.Chroot("objects")
+			if err := WriteFile(sub, "ab/cd", []byte("x")); err != nil {
+				t.Fatal(err)
+			}
+			if data, err := ReadFile(fsys, "objects/ab/cd"); err != nil || string(data) != "x" {
+				t.Errorf("ReadFile through parent = %q, %v", data, err)
+			}
+		})
+	}
+}
+
+// TestOptionalInterfaces: 메모리 파일시스템은 링크와 권한, 시각을 지원하지 않는다고 알린다.
+func TestOptionalInterfaces(t *testing.T) {
+	m := NewMemory()
+	if err := WriteFile(m, "f", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	for op, err := range map[string]error{
+		"readlink": func() error { _, err := Readlink(m, "f"); return err }(),
+		"symlink":  Symlink(m, "f", "link"),
+		"chmod":    Chmod(m, "f", 0755),
+		"chtimes":  Chtimes(m, "f", time.Now(), time.Now()),
+	} {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("%s err = %v, want ErrUnsupported", op, err)
+		}
+	}
+	if info, err := Lstat(m, "f"); err != nil || info.Size() != 1 {
+		t.Errorf("Lstat = %v, %v", info, err)
+	}
+
+	o := NewOS(t.TempDir())
+	if err := WriteFile(o, "f", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Symlink(o, "f", "link"); err != nil {
+		t.Fatal(err)
+	}
+	if target, err := Readlink(o, "link"); err != nil || target != "f" {
+		t.Errorf("Readlink = %q, %v", target, err)
+	}
+	if info, err := Lstat(o, "link"); err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("Lstat(link) = %v, %v", info, err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := Chtimes(o, "f", old, old); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := o.Stat("f"); err != nil || !info.ModTime().Equal(old) {
+		t.Errorf("ModTime = %v, %v; want %v", info.ModTime(), err, old)
+	}
+}
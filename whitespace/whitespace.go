@@ -0,0 +1,223 @@
+// Package whitespace 는 diff 가 더한 줄의 공백 문제를 찾는다. (git diff --check 와 같은 규칙)
+//
+// 검사할 규칙은 config 의 core.whitespace 에서 읽는다. 쉼표로 나눈 이름이고 앞에 '-' 를 붙이면 끈다.
+//
+//	blank-at-eol         줄 끝의 공백 (기본으로 켜짐)
+//	space-before-tab     들여쓰기에서 탭 앞의 공백 (기본으로 켜짐)
+//	blank-at-eof         파일 끝에 더한 빈 줄 (기본으로 켜짐)
+//	trailing-space       blank-at-eol 과 blank-at-eof 를 함께
+//	indent-with-non-tab  tabwidth 칸 이상을 공백으로 들여씀
+//	tab-in-indent        들여쓰기에 탭이 있음
+//	cr-at-eol            줄 끝의 CR 은 공백 문제로 보지 않는다 (CRLF 파일)
+//	tabwidth=<n>         indent-with-non-tab 의 기준 (기본 8)
+//
+// cr-at-eol 이 없으면 git 과 같이 줄 끝의 CR 도 줄 끝 공백으로 센다.
+package whitespace
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tmdgusya/gogit/diff"
+	"github.com/tmdgusya/gogit/object"
+)
+
+// Rule: 검사 규칙이자 찾은 문제의 종류
+type Rule uint
+
+const (
+	BlankAtEOL Rule = 1 << iota
+	SpaceBeforeTab
+	IndentWithNonTab
+	TabInIndent
+	BlankAtEOF
+	CRAtEOL
+)
+
+// Default: core.whitespace 가 없을 때의 규칙 (git 과 같다)
+const Default = BlankAtEOL | SpaceBeforeTab | BlankAtEOF
+
+var ruleNames = []struct {
+	name string
+	rule Rule
+}{
+	{"blank-at-eol", BlankAtEOL},
+	{"space-before-tab", SpaceBeforeTab},
+	{"indent-with-non-tab", IndentWithNonTab},
+	{"tab-in-indent", TabInIndent},
+	{"blank-at-eof", BlankAtEOF},
+	{"trailing-space", BlankAtEOL | BlankAtEOF},
+	{"cr-at-eol", CRAtEOL},
+}
+
+// Rules: 켜진 규칙과 탭 폭
+type Rules struct {
+	Rule     Rule
+	TabWidth int
+}
+
+// Parse: core.whitespace 값을 기본 규칙 위에 적용한다. 모르는 이름은 git 과 같이 무시한다.
+func Parse(value string) (Rules, error) {
+	r := Rules{Rule: Default, TabWidth: 8}
+	for _, word := range strings.Split(value, ",") {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+		if n, ok := strings.CutPrefix(word, "tabwidth="); ok {
+			width, err := strconv.Atoi(n)
+			if err != nil || width < 1 || width > 63 {
+				return Rules{}, fmt.Errorf("tabwidth %q out of range", n)
+			}
+			r.TabWidth = width
+			continue
+		}
+		name, negate := strings.CutPrefix(word, "-")
+		for _, rn := range ruleNames {
+			if rn.name != name {
+				continue
+			}
+			if negate {
+				r.Rule &^= rn.rule
+			} else {
+				r.Rule |= rn.rule
+			}
+		}
+	}
+	if r.Rule&IndentWithNonTab != 0 && r.Rule&TabInIndent != 0 {
+		return Rules{}, fmt.Errorf("cannot enforce both tab-in-indent and indent-with-non-tab")
+	}
+	return r, nil
+}
+
+// CheckLine: 줄 하나(줄바꿈 제외)의 문제들. 파일 끝 빈 줄은 줄 하나로 알 수 없으므로 여기서 보지 않는다.
+func (r Rules) CheckLine(line string) Rule {
+	var found Rule
+	end := len(line)
+	if r.Rule&BlankAtEOL != 0 {
+		if r.Rule&CRAtEOL != 0 && strings.HasSuffix(line, "\r") {
+			end--
+		}
+		trimmed := strings.TrimRight(line[:end], " \t\r\v\f")
+		if len(trimmed) < end {
+			found |= BlankAtEOL
+		}
+		end = len(trimmed)
+	}
+
+	// 들여쓰기: 마지막 탭까지(written)와 그 뒤의 공백들
+	written, i := 0, 0
+	for ; i < end; i++ {
+		if line[i] == ' ' {
+			continue
+		}
+		if line[i] != '\t' {
+			break
+		}
+		if r.Rule&SpaceBeforeTab != 0 && written < i {
+			found |= SpaceBeforeTab
+		}
+		written = i + 1
+	}
+	if r.Rule&IndentWithNonTab != 0 && i-written >= r.TabWidth {
+		found |= IndentWithNonTab
+	}
+	if r.Rule&TabInIndent != 0 && strings.Contains(line[:i], "\t") {
+		found |= TabInIndent
+	}
+	return found
+}
+
+// String: git 과 같은 문제 설명. 여럿이면 쉼표로 잇는다
+func (f Rule) String() string {
+	var parts []string
+	for _, m := range []struct {
+		rule Rule
+		text string
+	}{
+		{BlankAtEOL, "trailing whitespace"},
+		{SpaceBeforeTab, "space before tab in indent"},
+		{IndentWithNonTab, "indent with spaces"},
+		{TabInIndent, "tab in indent"},
+		{BlankAtEOF, "new blank line at EOF"},
+	} {
+		if f&m.rule != 0 {
+			parts = append(parts, m.text)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Problem: 더한 줄 하나의 문제. 파일 끝 빈 줄이면 Line 은 처음 더한 빈 줄이고 Text 는 비어 있다
+type Problem struct {
+	Path   string
+	Line   int
+	Errors Rule
+	Text   string
+}
+
+// String: git diff --check 와 같은 "경로:줄: 설명." 과 (파일 끝 빈 줄이 아니면) "+내용" 두 줄
+func (p Problem) String() string {
+	if p.Errors == BlankAtEOF {
+		return fmt.Sprintf("%s:%d: %s.", p.Path, p.Line, p.Errors)
+	}
+	return fmt.Sprintf("%s:%d: %s.\n+%s", p.Path, p.Line, p.Errors, p.Text)
+}
+
+// CheckChanges: 변경마다 새로 더한 줄을 검사한다. 지운 파일, 바이너리 파일, 심볼릭 링크, submodule 은 건너뛴다.
+func (r Rules) CheckChanges(s object.Storer, changes []diff.Change) ([]Problem, error) {
+	var problems []Problem
+	for _, c := range changes {
+		if c.Status() == 'D' || c.To.Mode == object.ModeGitlink || c.To.Mode == object.ModeSymlink || c.From.Hash == c.To.Hash {
+			continue
+		}
+		var old []byte
+		if c.Status() != 'A' && c.From.Mode != object.ModeGitlink && c.From.Mode != object.ModeSymlink {
+			_, data, err := s.Read(c.From.Hash)
+			if err != nil {
+				return nil, err
+			}
+			old = data
+		}
+		_, data, err := s.Read(c.To.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if diff.IsBinary(old) || diff.IsBinary(data) {
+			continue
+		}
+		problems = append(problems, r.checkFile(c.To.Path, diff.SplitLines(old), diff.SplitLines(data))...)
+	}
+	return problems, nil
+}
+
+// checkFile: old 에서 lines 로 바뀔 때 더한 줄의 문제들
+func (r Rules) checkFile(path string, old, lines []string) []Problem {
+	var problems []Problem
+	added := make([]bool, len(lines))
+	for _, e := range diff.Lines(old, lines) {
+		for i := e.NewPos; i < e.NewPos+e.NewLen; i++ {
+			added[i] = true
+			text := strings.TrimSuffix(lines[i], "\n")
+			if found := r.CheckLine(text); found != 0 {
+				problems = append(problems, Problem{Path: path, Line: i + 1, Errors: found, Text: text})
+			}
+		}
+	}
+
+	// 파일 끝의 빈 줄 중 이번에 더한 것이 있으면 그 첫 줄을 알린다
+	if r.Rule&BlankAtEOF != 0 {
+		first := len(lines)
+		for first > 0 && strings.TrimSpace(lines[first-1]) == "" {
+			first--
+		}
+		for i := first; i < len(lines); i++ {
+			if added[i] {
+				problems = append(problems, Problem{Path: path, Line: i + 1, Errors: BlankAtEOF})
+				break
+			}
+		}
+	}
+	return problems
+}
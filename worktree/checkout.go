@@ -0,0 +1,157 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/tmdgusya/gogit/diff"
+	"github.com/tmdgusya/gogit/events"
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// Checkout: 작업 트리를 from tree 의 상태에서 to tree 의 상태로 바꾼다.
+// 두 tree 사이에 바뀐 파일만 쓰거나 지우므로, 작업 트리가 from 과 같은지는 호출하는 쪽이 확인해야 한다.
+// 파일을 지워 비게 된 디렉토리도 지운다. submodule 은 빈 디렉토리만 만든다.
+func Checkout(ctx context.Context, work vfs.Filesystem, s object.Storer, from, to string) error {
+	return CheckoutPaths(ctx, work, s, from, to, nil)
+}
+
+// CheckoutPaths: Checkout 과 같지만 match 가 true 인 경로만 바꾼다. match 가 nil 이면 모든 경로
+// 이름이 바뀐 파일은 원래 경로와 새 경로를 따로 본다.
+// ctx 에 events.Stream 이 있으면 파일 하나를 지우거나 쓸 때마다 "checkout" 진행 이벤트를 보낸다.
+func CheckoutPaths(ctx context.Context, work vfs.Filesystem, s object.Storer, from, to string, match func(p string) bool) error {
+	changes, err := diff.Trees(s, from, to)
+	if err != nil {
+		return err
+	}
+	if match == nil {
+		match = func(string) bool { return true }
+	}
+
+	removes := func(c diff.Change) bool {
+		return c.From.Hash != "" && (c.To.Hash == "" || c.From.Path != c.To.Path) && match(c.From.Path)
+	}
+	writes := func(c diff.Change) bool {
+		return c.To.Hash != "" && match(c.To.Path)
+	}
+	stream := events.FromContext(ctx)
+	done, total := 0, 0
+	for _, c := range changes {
+		if removes(c) {
+			total++
+		}
+		if writes(c) {
+			total++
+		}
+	}
+
+	// 파일이 디렉토리로(또는 반대로) 바뀔 수 있으므로 지우기를 먼저 한다
+	for _, c := range changes {
+		if !removes(c) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := removeEntry(work, c.From); err != nil {
+			return err
+		}
+		done++
+		stream.Progress("checkout", done, total, c.From.Path)
+	}
+	for _, c := range changes {
+		if !writes(c) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := writeFile(work, s, c.To); err != nil {
+			return err
+		}
+		done++
+		stream.Progress("checkout", done, total, c.To.Path)
+	}
+	return nil
+}
+
+// removeEntry: 파일을 지우고 비게 된 상위 디렉토리를 지운다. 이미 없으면 무시한다.
+// submodule 디렉토리는 git 과 같이 비어 있을 때만 지우고, 안에 파일이 있으면 그대로 둔다.
+func removeEntry(work vfs.Filesystem, f diff.File) error {
+	name := f.Path
+	if f.Mode == object.ModeGitlink {
+		if entries, err := work.ReadDir(name); err == nil && len(entries) > 0 {
+			return nil
+		}
+	}
+	if err := work.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		entries, err := work.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			break
+		}
+		if err := work.Remove(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearPath: f 를 쓸 자리를 비운다. 링크를 따라가서 쓰지 않도록 원래 파일이나 링크는 지우고 새로 만든다.
+// 종류가 바뀐 경우(파일과 링크, submodule 사이) 도 여기서 처리한다. 디렉토리는 비어 있을 때만 지우므로
+// 내용이 있는 submodule 을 파일로 바꾸려 하면 에러다. submodule 을 쓸 자리의 디렉토리는 그대로 둔다.
+func clearPath(work vfs.Filesystem, f diff.File) error {
+	info, err := vfs.Lstat(work, f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		if f.Mode == object.ModeGitlink {
+			return nil
+		}
+		if entries, err := work.ReadDir(f.Path); err == nil && len(entries) > 0 {
+			return fmt.Errorf("cannot replace directory '%s' with a file: directory not empty", f.Path)
+		}
+	}
+	return work.Remove(f.Path)
+}
+
+func writeFile(work vfs.Filesystem, s object.Storer, f diff.File) error {
+	if err := work.MkdirAll(path.Dir(f.Path), 0755); err != nil {
+		return err
+	}
+	if err := clearPath(work, f); err != nil {
+		return err
+	}
+	if f.Mode == object.ModeGitlink {
+		return work.MkdirAll(f.Path, 0755)
+	}
+
+	_, data, err := s.Read(f.Hash)
+	if err != nil {
+		return err
+	}
+	if f.Mode == object.ModeSymlink {
+		return vfs.Symlink(work, string(data), f.Path)
+	}
+	if err := vfs.WriteFile(work, f.Path, data); err != nil {
+		return err
+	}
+	perm := fs.FileMode(0644)
+	if f.Mode == object.ModeExecutable {
+		perm = 0755
+	}
+	if err := vfs.Chmod(work, f.Path, perm); err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package worktree
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// nestedRepo: dir 안에 저장소(.git 또는 .gogit)가 있으면 그 저장소 디렉토리 경로
+// .git 이 "gitdir: <경로>" 파일이면(git submodule) 그 경로를 따라간다. 작업 트리 밖의 절대 경로는 읽을 수 없으므로 없는 것으로 본다.
+func nestedRepo(work vfs.Filesystem, dir string) (string, bool) {
+	for _, name := range []string{".git", ".gogit"} {
+		p := path.Join(dir, name)
+		info, err := work.Stat(p)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			return p, true
+		}
+		data, err := vfs.ReadFile(work, p)
+		if err != nil {
+			continue
+		}
+		target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		if !ok || path.IsAbs(target) {
+			continue
+		}
+		return path.Join(dir, target), true
+	}
+	return "", false
+}
+
+// nestedHead: 저장소 디렉토리 gitDir 의 HEAD 가 가리키는 커밋. 아직 커밋이 없으면 빈 문자열
+func nestedHead(work vfs.Filesystem, gitDir string) (string, error) {
+	data, err := vfs.ReadFile(work, path.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", err
+	}
+	head := strings.TrimSpace(string(data))
+	// 심볼릭 ref 를 몇 번까지만 따라간다 (순환 방지)
+	for range 5 {
+		ref, ok := strings.CutPrefix(head, "ref: ")
+		if !ok {
+			break
+		}
+		if head, err = readNestedRef(work, gitDir, ref); err != nil || head == "" {
+			return "", err
+		}
+	}
+	if !object.IsHash(head) {
+		return "", nil
+	}
+	return head, nil
+}
+
+// readNestedRef: loose ref 파일, 없으면 packed-refs 에서 ref 의 값을 찾는다. 없으면 빈 문자열
+func readNestedRef(work vfs.Filesystem, gitDir, ref string) (string, error) {
+	data, err := vfs.ReadFile(work, path.Join(gitDir, ref))
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	data, err = vfs.ReadFile(work, path.Join(gitDir, "packed-refs"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		hash, name, ok := strings.Cut(scanner.Text(), " ")
+		if ok && name == ref && object.IsHash(hash) {
+			return hash, nil
+		}
+	}
+	return "", nil
+}
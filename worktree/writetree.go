@@ -0,0 +1,348 @@
+// Package worktree 는 작업 트리의 파일을 객체로 옮기거나(WriteTree) tree 의 내용을 작업 트리에 쓴다(Checkout).
+//
+// 아직 index 가 없으므로 작업 트리 전체(.gogit, .git 디렉토리 제외)를 그대로 스냅샷으로 본다.
+// Options.Ignore 를 주면 base tree 에 없는(추적하지 않는) 파일 중 무시 규칙에 맞는 것은 빼놓는다.
+package worktree
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/tmdgusya/gogit/ignore"
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// 저장소 디렉토리는 어느 깊이에 있든 스냅샷에 넣지 않는다
+var skipNames = map[string]bool{".gogit": true, ".git": true}
+
+// TreeCache: 디렉토리의 stat 서명 -> tree 해시
+// 서명은 하위의 모든 파일 이름/모드/크기/수정 시각으로 만들기 때문에, 서명이 같으면 내용을 읽지 않고
+// 전에 만든 tree 를 다시 쓴다. 파일로 저장해 두면 다음 명령에서도 쓸 수 있다.
+type TreeCache struct {
+	entries map[string]string
+	// 이번에 쓰인 항목만 저장해서 파일이 계속 커지지 않게 한다
+	used map[string]string
+}
+
+func NewTreeCache() *TreeCache {
+	return &TreeCache{entries: map[string]string{}, used: map[string]string{}}
+}
+
+// LoadTreeCache: "<서명> <tree 해시>" 줄로 된 캐시 파일을 읽는다. 파일이 없거나 깨져 있으면 빈 캐시
+// 캐시는 성능을 위한 것이므로 잘못된 줄은 버리기만 한다.
+func LoadTreeCache(fsys vfs.Filesystem, name string) *TreeCache {
+	c := NewTreeCache()
+	data, err := vfs.ReadFile(fsys, name)
+	if err != nil {
+		return c
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		sig, hash, ok := strings.Cut(scanner.Text(), " ")
+		if ok && object.IsHash(hash) {
+			c.entries[sig] = hash
+		}
+	}
+	return c
+}
+
+// Save: 이번에 쓰인 항목을 저장한다. 임시 파일에 쓴 뒤 rename 하므로 동시에 읽는 쪽이 깨진 파일을 보지 않는다.
+func (c *TreeCache) Save(fsys vfs.Filesystem, name string) error {
+	var b strings.Builder
+	for sig, hash := range c.used {
+		fmt.Fprintf(&b, "%s %s\n", sig, hash)
+	}
+	tmp := name + ".tmp"
+	if err := vfs.WriteFile(fsys, tmp, []byte(b.String())); err != nil {
+		return err
+	}
+	return fsys.Rename(tmp, name)
+}
+
+func (c *TreeCache) get(sig string) (string, bool) {
+	hash, ok := c.entries[sig]
+	if ok {
+		c.used[sig] = hash
+	}
+	return hash, ok
+}
+
+func (c *TreeCache) put(sig string, hash string) {
+	c.entries[sig] = hash
+	c.used[sig] = hash
+}
+
+// Options: WriteTreeWithOptions 의 설정
+type Options struct {
+	// Base: 추적하는 파일을 정하는 tree (보통 HEAD 의 tree). 빈 문자열이면 아무것도 추적하지 않는다
+	// base 의 submodule 중 받아 두지 않은 것(빈 디렉토리)은 지운 것으로 보지 않고 base 의 커밋을 그대로 둔다.
+	Base string
+	// KeepModes: 파일시스템의 실행 비트를 믿지 않는다. (core.fileMode = false)
+	// 일반 파일이 실행 파일인지는 Base 의 같은 경로에서 가져오고, Base 에 없으면 일반 파일이다.
+	KeepModes bool
+	// Ignore: 무시 규칙. nil 이 아니면 Base 에 없는 경로 중 무시되는 것은 넣지 않는다.
+	// 훑는 디렉토리의 .gitignore 를 Add 하므로 부를 때마다 새 Matcher 를 준다.
+	Ignore *ignore.Matcher
+}
+
+// WriteTree: 작업 트리를 tree 객체로 저장하고 루트 tree 해시를 돌려준다.
+// cache 가 nil 이 아니면 바뀌지 않은 디렉토리는 파일을 읽지 않고 캐시된 tree 를 쓴다.
+// git 과 같이 빈 디렉토리는 tree 에 들어가지 않는다.
+func WriteTree(ctx context.Context, work vfs.Filesystem, s object.Storer, cache *TreeCache) (string, error) {
+	return WriteTreeWithOptions(ctx, work, s, cache, Options{})
+}
+
+// WriteTreeWithBase: WriteTree 와 같지만 base tree 를 참고한다. (Options.Base)
+func WriteTreeWithBase(ctx context.Context, work vfs.Filesystem, s object.Storer, cache *TreeCache, base string) (string, error) {
+	return WriteTreeWithOptions(ctx, work, s, cache, Options{Base: base})
+}
+
+// WriteTreeKeepModes: WriteTreeWithBase 와 같지만 실행 비트를 base 에서 가져온다. (Options.KeepModes)
+// 실행 비트를 보존하지 않는 파일시스템(마운트한 Windows 드라이브 등)에서 모든 파일이 바뀐 것으로 보이지 않게 한다.
+func WriteTreeKeepModes(ctx context.Context, work vfs.Filesystem, s object.Storer, cache *TreeCache, base string) (string, error) {
+	return WriteTreeWithOptions(ctx, work, s, cache, Options{Base: base, KeepModes: true})
+}
+
+// WriteTreeWithOptions: opts 에 따라 작업 트리를 tree 객체로 저장한다.
+func WriteTreeWithOptions(ctx context.Context, work vfs.Filesystem, s object.Storer, cache *TreeCache, opts Options) (string, error) {
+	if cache == nil {
+		cache = NewTreeCache()
+	}
+	w := &treeWriter{ctx: ctx, work: work, store: s, cache: cache, start: time.Now(), trustExec: !opts.KeepModes, ignore: opts.Ignore}
+	root, err := w.scan("", opts.Base)
+	if err != nil {
+		return "", err
+	}
+	return w.build(root)
+}
+
+type treeWriter struct {
+	ctx   context.Context
+	work  vfs.Filesystem
+	store object.Storer
+	cache *TreeCache
+	start time.Time
+	// trustExec: false 면 실행 비트를 파일시스템 대신 base tree 에서 가져온다
+	trustExec bool
+	// ignore: nil 이 아니면 추적하지 않는 무시된 경로를 건너뛴다
+	ignore *ignore.Matcher
+}
+
+// dirNode: stat 만으로 읽은 디렉토리 정보
+type dirNode struct {
+	path  string
+	sig   string
+	files []fileNode
+	dirs  []*dirNode
+	// newest: 하위에서 가장 최근 수정 시각
+	newest time.Time
+}
+
+// fileNode: tree 의 파일 항목. submodule 은 디렉토리를 읽지 않고 hash 에 그 저장소의 HEAD 커밋을 담는다
+type fileNode struct {
+	name string
+	mode object.Mode
+	hash string
+}
+
+// scan: dir 을 읽는다. base 는 dir 에 해당하는 base tree 이고 없으면 빈 문자열
+func (w *treeWriter) scan(dir, base string) (*dirNode, error) {
+	if err := w.ctx.Err(); err != nil {
+		return nil, err
+	}
+	entries, err := w.work.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var baseEntries map[string]object.TreeEntry
+	if base != "" {
+		if baseEntries, err = w.readBase(base); err != nil {
+			return nil, err
+		}
+	}
+
+	if w.ignore != nil {
+		if data, err := vfs.ReadFile(w.work, path.Join(dir, ".gitignore")); err == nil {
+			w.ignore.Add(dir, data)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	node := &dirNode{path: dir}
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\x00", dir)
+	for _, entry := range entries {
+		if skipNames[entry.Name()] {
+			continue
+		}
+		// 이미 추적하는 경로는 무시 규칙에 맞아도 남긴다 (git 과 같음)
+		if _, tracked := baseEntries[entry.Name()]; !tracked && w.ignore != nil && w.ignore.Ignored(path.Join(dir, entry.Name()), entry.IsDir()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		if info.ModTime().After(node.newest) {
+			node.newest = info.ModTime()
+		}
+
+		if entry.IsDir() {
+			name := path.Join(dir, entry.Name())
+			baseEntry := baseEntries[entry.Name()]
+			// 안에 저장소가 있으면 내용 대신 그 HEAD 커밋을 submodule 로 넣는다
+			if gitDir, ok := nestedRepo(w.work, name); ok {
+				commit, err := nestedHead(w.work, gitDir)
+				if err != nil {
+					return nil, err
+				}
+				if commit == "" {
+					continue
+				}
+				node.files = append(node.files, fileNode{name: entry.Name(), mode: object.ModeGitlink, hash: commit})
+				fmt.Fprintf(h, "g %s %s\n", entry.Name(), commit)
+				continue
+			}
+			var childBase string
+			if baseEntry.Mode == object.ModeTree {
+				childBase = baseEntry.Hash
+			}
+			child, err := w.scan(name, childBase)
+			if err != nil {
+				return nil, err
+			}
+			if child == nil {
+				if baseEntry.Mode == object.ModeGitlink {
+					node.files = append(node.files, fileNode{name: entry.Name(), mode: object.ModeGitlink, hash: baseEntry.Hash})
+					fmt.Fprintf(h, "g %s %s\n", entry.Name(), baseEntry.Hash)
+				}
+				continue
+			}
+			if child.newest.After(node.newest) {
+				node.newest = child.newest
+			}
+			node.dirs = append(node.dirs, child)
+			fmt.Fprintf(h, "d %s %s\n", entry.Name(), child.sig)
+			continue
+		}
+
+		mode, ok := fileMode(info)
+		if !ok {
+			continue
+		}
+		if !w.trustExec && mode != object.ModeSymlink {
+			mode = object.ModeRegular
+			if baseEntries[entry.Name()].Mode == object.ModeExecutable {
+				mode = object.ModeExecutable
+			}
+		}
+		node.files = append(node.files, fileNode{name: entry.Name(), mode: mode})
+		fmt.Fprintf(h, "f %s %o %d %d\n", entry.Name(), uint32(mode), info.Size(), info.ModTime().UnixNano())
+	}
+
+	if len(node.files) == 0 && len(node.dirs) == 0 {
+		return nil, nil
+	}
+	node.sig = hex.EncodeToString(h.Sum(nil))
+	return node, nil
+}
+
+// readBase: base tree 의 항목을 이름으로 찾을 수 있게 읽는다
+func (w *treeWriter) readBase(hash string) (map[string]object.TreeEntry, error) {
+	tree, err := object.ReadTree(w.store, hash)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]object.TreeEntry, len(tree.Entries))
+	for _, e := range tree.Entries {
+		entries[e.Name] = e
+	}
+	return entries, nil
+}
+
+// 일반 파일, 실행 파일, 심볼릭 링크만 저장한다. (소켓, 장치 파일 등은 건너뜀)
+func fileMode(info fs.FileInfo) (object.Mode, bool) {
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		return object.ModeSymlink, true
+	case !info.Mode().IsRegular():
+		return 0, false
+	case info.Mode()&0111 != 0:
+		return object.ModeExecutable, true
+	default:
+		return object.ModeRegular, true
+	}
+}
+
+func (w *treeWriter) build(node *dirNode) (string, error) {
+	if node == nil {
+		return object.WriteObject(w.store, &object.Tree{})
+	}
+	if hash, ok := w.cache.get(node.sig); ok && w.store.Has(hash) {
+		return hash, nil
+	}
+
+	tree := &object.Tree{}
+	for _, f := range node.files {
+		if err := w.ctx.Err(); err != nil {
+			return "", err
+		}
+		hash := f.hash
+		if f.mode != object.ModeGitlink {
+			var err error
+			if hash, err = w.writeBlob(path.Join(node.path, f.name), f.mode); err != nil {
+				return "", err
+			}
+		}
+		tree.Entries = append(tree.Entries, object.TreeEntry{Mode: f.mode, Name: f.name, Hash: hash})
+	}
+	for _, d := range node.dirs {
+		hash, err := w.build(d)
+		if err != nil {
+			return "", err
+		}
+		tree.Entries = append(tree.Entries, object.TreeEntry{Mode: object.ModeTree, Name: path.Base(d.path), Hash: hash})
+	}
+
+	hash, err := object.WriteObject(w.store, tree)
+	if err != nil {
+		return "", err
+	}
+	// 방금 수정된 파일은 같은 시각 안에 다시 바뀌어도 서명이 같을 수 있으므로 캐시하지 않는다 (git 의 racy clean 문제)
+	if node.newest.Before(w.start.Add(-time.Second)) {
+		w.cache.put(node.sig, hash)
+	}
+	return hash, nil
+}
+
+func (w *treeWriter) writeBlob(name string, mode object.Mode) (string, error) {
+	if mode == object.ModeSymlink {
+		target, err := vfs.Readlink(w.work, name)
+		if err != nil {
+			return "", err
+		}
+		return w.store.Write(object.TypeBlob, []byte(target))
+	}
+
+	f, err := w.work.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := w.work.Stat(name)
+	if err != nil {
+		return "", err
+	}
+	return w.store.WriteStream(object.TypeBlob, info.Size(), f)
+}
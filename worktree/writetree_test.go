@@ -0,0 +1,113 @@
+package worktree
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/tmdgusya/gogit/ignore"
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// treePaths: tree 아래의 모든 파일 경로
+func treePaths(t *testing.T, s object.Storer, hash string, prefix string) []string {
+	t.Helper()
+	tree, err := object.ReadTree(s, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, e := range tree.Entries {
+		if e.Mode == object.ModeTree {
+			paths = append(paths, treePaths(t, s, e.Hash, prefix+e.Name+"/")...)
+			continue
+		}
+		paths = append(paths, prefix+e.Name)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func writeFiles(t *testing.T, work vfs.Filesystem, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := vfs.WriteFile(work, name, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWriteTreeSkipsIgnoredFiles(t *testing.T) {
+	ctx := context.Background()
+	work := vfs.NewMemory()
+	s := object.NewMemoryStore()
+	writeFiles(t, work, map[string]string{
+		".gitignore":      "*.log\nbuild/\n",
+		"main.go":         "package main\n",
+		"debug.log":       "noise\n",
+		"build/out":       "binary\n",
+		"sub/.gitignore":  "local.txt\n",
+		"sub/keep.txt":    "keep\n",
+		"sub/local.txt":   "ignored\n",
+		"sub/deep/x.log":  "ignored\n",
+		"other/local.txt": "not ignored here\n",
+	})
+
+	hash, err := WriteTreeWithOptions(ctx, work, s, nil, Options{Ignore: ignore.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := treePaths(t, s, hash, "")
+	want := []string{".gitignore", "main.go", "other/local.txt", "sub/.gitignore", "sub/keep.txt"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+}
+
+func TestWriteTreeKeepsTrackedIgnoredFiles(t *testing.T) {
+	ctx := context.Background()
+	work := vfs.NewMemory()
+	s := object.NewMemoryStore()
+	writeFiles(t, work, map[string]string{
+		"main.go":       "package main\n",
+		"tracked.log":   "committed before the rule\n",
+		"build/tracked": "committed before the rule\n",
+	})
+	base, err := WriteTree(ctx, work, s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFiles(t, work, map[string]string{
+		".gitignore": "*.log\nbuild/\n",
+		"new.log":    "untracked\n",
+		"build/new":  "untracked\n",
+	})
+	hash, err := WriteTreeWithOptions(ctx, work, s, nil, Options{Base: base, Ignore: ignore.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := treePaths(t, s, hash, "")
+	want := []string{".gitignore", "build/tracked", "main.go", "tracked.log"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+}
+
+func TestWriteTreeWithoutIgnoreKeepsEverything(t *testing.T) {
+	work := vfs.NewMemory()
+	s := object.NewMemoryStore()
+	writeFiles(t, work, map[string]string{
+		".gitignore": "*.log\n",
+		"debug.log":  "noise\n",
+	})
+	hash, err := WriteTree(context.Background(), work, s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := treePaths(t, s, hash, ""); len(got) != 2 {
+		t.Fatalf("paths = %v, want .gitignore and debug.log", got)
+	}
+}
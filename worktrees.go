@@ -0,0 +1,290 @@
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tmdgusya/gogit/refs"
+	"github.com/tmdgusya/gogit/vfs"
+)
+
+// 추가 작업 트리 (git worktree)
+//
+// 작업 트리를 더 만들면 저장소 디렉토리에 worktrees/<name> 이 생기고, 그 작업 트리의 HEAD 와
+// 진행 중인 작업의 상태(rebase-apply, am, tree-cache 등)는 그 안에 따로 둔다. 객체, 브랜치, 설정은 함께 쓴다.
+// 형식은 git 과 같다:
+//
+//	<작업 트리>/.gogit              "gitdir: <저장소>/worktrees/<name>"
+//	<저장소>/worktrees/<name>/gitdir     작업 트리의 .gogit 파일 경로 (list 와 정리에 쓴다)
+//	<저장소>/worktrees/<name>/commondir  "../.." (함께 쓰는 저장소 디렉토리)
+//	<저장소>/worktrees/<name>/HEAD
+
+// worktreesDir: 추가 작업 트리의 상태를 두는 디렉토리
+const worktreesDir = "worktrees"
+
+// commonNames: 작업 트리들이 함께 쓰는 저장소 디렉토리의 항목 (git 의 common_list 에 브랜치 관계를 적는 stack 을 더했다)
+// 여기에 없는 것(HEAD, 진행 중인 작업의 상태 등)은 작업 트리마다 따로 둔다.
+var commonNames = map[string]bool{
+	"objects": true, "refs": true, "logs": true, "config": true, "packed-refs": true, "hooks": true,
+	"info": true, "remotes": true, "branches": true, "rr-cache": true, "shallow": true, "description": true,
+	"stack": true, worktreesDir: true,
+}
+
+// privatePaths: commonNames 아래에 있지만 작업 트리마다 따로 두는 것
+var privatePaths = []string{"logs/HEAD", "refs/bisect", "refs/worktree", "refs/rewritten",
+	"logs/refs/bisect", "logs/refs/worktree", "logs/refs/rewritten"}
+
+// isCommon: 저장소 디렉토리 안의 name 이 함께 쓰는 항목인지. 잠금 파일은 원래 파일을 따른다
+func isCommon(name string) bool {
+	name = strings.TrimSuffix(path.Clean(name), ".lock")
+	for _, p := range privatePaths {
+		if name == p || strings.HasPrefix(name, p+"/") {
+			return false
+		}
+	}
+	first, _, _ := strings.Cut(name, "/")
+	return commonNames[first]
+}
+
+// linkedFS: 추가 작업 트리의 저장소 파일시스템. 경로에 따라 함께 쓰는 디렉토리나 작업 트리의 디렉토리로 보낸다
+type linkedFS struct {
+	common  vfs.Filesystem
+	private vfs.Filesystem
+}
+
+func (l *linkedFS) pick(name string) vfs.Filesystem {
+	if isCommon(name) {
+		return l.common
+	}
+	return l.private
+}
+
+func (l *linkedFS) Open(name string) (vfs.File, error) { return l.pick(name).Open(name) }
+
+func (l *linkedFS) Create(name string) (vfs.File, error) { return l.pick(name).Create(name) }
+
+func (l *linkedFS) CreateExclusive(name string) (vfs.File, error) {
+	return l.pick(name).CreateExclusive(name)
+}
+
+func (l *linkedFS) Stat(name string) (fs.FileInfo, error) { return l.pick(name).Stat(name) }
+
+// MkdirAll: logs 처럼 작업 트리마다 따로 두는 것(logs/HEAD)을 품은 디렉토리는 양쪽에 만든다
+func (l *linkedFS) MkdirAll(name string, perm os.FileMode) error {
+	if err := l.pick(name).MkdirAll(name, perm); err != nil {
+		return err
+	}
+	dir := path.Clean(name) + "/"
+	for _, p := range privatePaths {
+		if strings.HasPrefix(p, dir) && isCommon(name) {
+			return l.private.MkdirAll(name, perm)
+		}
+	}
+	return nil
+}
+
+func (l *linkedFS) ReadDir(name string) ([]fs.DirEntry, error) { return l.pick(name).ReadDir(name) }
+
+func (l *linkedFS) Remove(name string) error { return l.pick(name).Remove(name) }
+
+// Rename: 잠금 파일을 원래 이름으로 옮기는 것처럼 같은 쪽 안에서만 쓴다
+func (l *linkedFS) Rename(oldname string, newname string) error {
+	fsys := l.pick(oldname)
+	if fsys != l.pick(newname) {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: errors.ErrUnsupported}
+	}
+	return fsys.Rename(oldname, newname)
+}
+
+func (l *linkedFS) Chroot(dir string) vfs.Filesystem { return l.pick(dir).Chroot(dir) }
+
+func (l *linkedFS) Readlink(name string) (string, error) { return vfs.Readlink(l.pick(name), name) }
+
+func (l *linkedFS) Lstat(name string) (fs.FileInfo, error) { return vfs.Lstat(l.pick(name), name) }
+
+func (l *linkedFS) Symlink(target string, name string) error {
+	return vfs.Symlink(l.pick(name), target, name)
+}
+
+func (l *linkedFS) Chmod(name string, mode os.FileMode) error {
+	return vfs.Chmod(l.pick(name), name, mode)
+}
+
+func (l *linkedFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return vfs.Chtimes(l.pick(name), name, atime, mtime)
+}
+
+// readGitdirFile: 작업 트리의 ".gogit" 파일("gitdir: <경로>")이 가리키는 저장소 디렉토리. 상대 경로는 파일 위치 기준
+func readGitdirFile(name string) (string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	dir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return "", fmt.Errorf("%w: invalid gitfile format: %s", ErrNotARepository, name)
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(name), dir)
+	}
+	return dir, nil
+}
+
+// openGogitDir: 저장소 디렉토리를 연다. 추가 작업 트리의 디렉토리(commondir 이 있는 곳)면
+// 함께 쓰는 디렉토리와 묶은 파일시스템을 쓴다.
+func openGogitDir(gogitDir string, workTree string) (*Repository, error) {
+	data, err := os.ReadFile(filepath.Join(gogitDir, "commondir"))
+	if err != nil {
+		return newRepository(vfs.NewOS(gogitDir), gogitDir, workTree)
+	}
+	common := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gogitDir, common)
+	}
+	repo, err := newRepository(&linkedFS{common: vfs.NewOS(common), private: vfs.NewOS(gogitDir)}, gogitDir, workTree)
+	if err != nil {
+		return nil, err
+	}
+	repo.CommonDir = common
+	return repo, nil
+}
+
+// Worktree: 저장소에 딸린 작업 트리 하나
+type Worktree struct {
+	// Name: worktrees 아래의 이름. 주 작업 트리는 빈 문자열
+	Name string
+	// Path: 작업 트리 경로. bare 저장소의 주 항목은 저장소 디렉토리다
+	Path string
+	Bare bool
+	// Head: 그 작업 트리의 HEAD. 브랜치에 있으면 Target, detached 면 Hash 가 있다
+	Head refs.Ref
+	// Prunable: 작업 트리 디렉토리가 사라짐
+	Prunable bool
+}
+
+// Worktrees: 주 작업 트리와 추가 작업 트리를 이름순으로 나열한다. 주 작업 트리가 맨 앞이다.
+func (r *Repository) Worktrees() ([]Worktree, error) {
+	if r.CommonDir == "" {
+		return nil, fmt.Errorf("worktree: repository is not on disk: %w", errors.ErrUnsupported)
+	}
+	main := Worktree{Path: filepath.Dir(r.CommonDir)}
+	if isBareRepo(vfs.NewOS(r.CommonDir)) {
+		main = Worktree{Path: r.CommonDir, Bare: true}
+	}
+	if !main.Bare {
+		head, err := refs.NewStore(vfs.NewOS(r.CommonDir)).Read("HEAD")
+		if err != nil {
+			return nil, err
+		}
+		main.Head = head
+	}
+	result := []Worktree{main}
+
+	entries, err := os.ReadDir(filepath.Join(r.CommonDir, worktreesDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(r.CommonDir, worktreesDir, e.Name())
+		wt := Worktree{Name: e.Name()}
+		data, err := os.ReadFile(filepath.Join(dir, "gitdir"))
+		if err != nil {
+			wt.Prunable = true
+		} else {
+			pointer := strings.TrimSpace(string(data))
+			wt.Path = filepath.Dir(pointer)
+			if _, err := os.Stat(pointer); err != nil {
+				wt.Prunable = true
+			}
+		}
+		if wt.Head, err = refs.NewStore(vfs.NewOS(dir)).Read("HEAD"); err != nil && !errors.Is(err, refs.ErrNotFound) {
+			return nil, err
+		}
+		result = append(result, wt)
+	}
+	return result, nil
+}
+
+// AddWorktree: path 에 작업 트리를 더 만들고 그 작업 트리의 저장소 핸들을 돌려준다.
+// path 는 없거나 빈 디렉토리여야 한다. 이름은 path 의 마지막 부분이고, 이미 있으면 뒤에 숫자를 붙인다.
+// HEAD 는 돌려받은 저장소에서 호출한 쪽이 정하고, 파일도 호출한 쪽이 받는다.
+// opts.DirName 은 작업 트리에 만들 포인터 파일의 이름이다. (".git" 이면 git 도 그 작업 트리를 쓸 수 있다)
+func (r *Repository) AddWorktree(path string, opts Options) (*Repository, error) {
+	if r.CommonDir == "" {
+		return nil, fmt.Errorf("worktree: repository is not on disk: %w", errors.ErrUnsupported)
+	}
+	workTree, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if entries, err := os.ReadDir(workTree); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("'%s' already exists", path)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	base := strings.TrimPrefix(filepath.Base(workTree), ".")
+	if base == "" {
+		base = "worktree"
+	}
+	name := base
+	for i := 1; ; i++ {
+		if _, err := os.Stat(filepath.Join(r.CommonDir, worktreesDir, name)); errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	gogitDir := filepath.Join(r.CommonDir, worktreesDir, name)
+	pointer := filepath.Join(workTree, opts.RepoDirName())
+
+	if err := os.MkdirAll(gogitDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(workTree, 0755); err != nil {
+		return nil, err
+	}
+	files := []struct{ name, content string }{
+		{filepath.Join(gogitDir, "gitdir"), pointer + "\n"},
+		{filepath.Join(gogitDir, "commondir"), "../..\n"},
+		{pointer, "gitdir: " + gogitDir + "\n"},
+	}
+	for _, f := range files {
+		if err := os.WriteFile(f.name, []byte(f.content), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return openGogitDir(gogitDir, workTree)
+}
+
+// RemoveWorktree: 추가 작업 트리 name 의 상태 디렉토리를 지운다. 작업 트리의 파일은 호출한 쪽이 지운다.
+func (r *Repository) RemoveWorktree(name string) error {
+	if r.CommonDir == "" {
+		return fmt.Errorf("worktree: repository is not on disk: %w", errors.ErrUnsupported)
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("invalid worktree name '%s'", name)
+	}
+	dir := filepath.Join(r.CommonDir, worktreesDir)
+	if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+		return err
+	}
+	// 마지막 작업 트리였으면 worktrees 디렉토리도 지운다
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) == 0 {
+		return os.Remove(dir)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package gogit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/gogit/object"
+	"github.com/tmdgusya/gogit/refs"
+)
+
+func TestIsCommon(t *testing.T) {
+	tests := map[string]bool{
+		"objects/ab/cd":         true,
+		"refs/heads/main":       true,
+		"refs/heads/main.lock":  true,
+		"config":                true,
+		"packed-refs.lock":      true,
+		"logs/refs/heads/main":  true,
+		"HEAD":                  false,
+		"HEAD.lock":             false,
+		"logs/HEAD":             false,
+		"refs/bisect/bad":       false,
+		"logs/refs/bisect/bad":  false,
+		"refs/worktree/x":       false,
+		"am/next":               false,
+		"rebase-apply/onto":     false,
+		"worktrees/other/HEAD":  true,
+		"refs/rewritten/onto":   false,
+		"refs/bisectnot/shared": true,
+	}
+	for name, want := range tests {
+		if got := isCommon(name); got != want {
+			t.Errorf("isCommon(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+// TestAddWorktree: 추가 작업 트리는 객체와 브랜치를 함께 쓰고 HEAD 와 bisect ref 는 따로 둔다.
+func TestAddWorktree(t *testing.T) {
+	main, err := Init(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "feature")
+	linked, err := main.AddWorktree(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if linked.WorkTree != path || linked.CommonDir != main.CommonDir {
+		t.Errorf("linked work tree %s, common dir %s", linked.WorkTree, linked.CommonDir)
+	}
+
+	blob, err := object.WriteObject(linked.Objects, &object.Blob{Data: []byte("shared\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !main.Objects.Has(blob) {
+		t.Error("object written in the linked work tree is missing from the main one")
+	}
+	if err := linked.Refs.Update("refs/heads/feature", blob); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := main.Refs.Resolve("refs/heads/feature"); err != nil || got != blob {
+		t.Errorf("main refs/heads/feature = %s, %v", got, err)
+	}
+	if err := linked.Refs.SetSymbolic("HEAD", "refs/heads/feature"); err != nil {
+		t.Fatal(err)
+	}
+	if head, err := main.Refs.Read("HEAD"); err != nil || head.Target != "refs/heads/master" {
+		t.Errorf("main HEAD = %+v, %v", head, err)
+	}
+	if err := linked.Refs.Update("refs/bisect/bad", blob); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := main.Refs.Resolve("refs/bisect/bad"); !errors.Is(err, refs.ErrNotFound) {
+		t.Errorf("main sees the linked work tree's refs/bisect/bad: %v", err)
+	}
+
+	// 작업 트리 안에서 열어도 같은 저장소다
+	opened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head, err := opened.Refs.Read("HEAD"); err != nil || head.Target != "refs/heads/feature" {
+		t.Errorf("opened HEAD = %+v, %v", head, err)
+	}
+
+	list, err := main.Worktrees()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "" || list[1].Name != "feature" || list[1].Path != path ||
+		list[1].Head.Target != "refs/heads/feature" || list[1].Prunable {
+		t.Fatalf("Worktrees = %+v", list)
+	}
+
+	// 같은 이름의 작업 트리를 하나 더 만들면 뒤에 숫자를 붙인다
+	again, err := main.AddWorktree(filepath.Join(t.TempDir(), "feature"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(again.GogitDir) != "feature1" {
+		t.Errorf("second worktree dir = %s", again.GogitDir)
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatal(err)
+	}
+	if list, err = main.Worktrees(); err != nil || !list[1].Prunable {
+		t.Errorf("removed work tree not prunable: %+v, %v", list, err)
+	}
+	if err := main.RemoveWorktree("feature"); err != nil {
+		t.Fatal(err)
+	}
+	if err := main.RemoveWorktree("../config"); err == nil {
+		t.Error("RemoveWorktree accepted a path")
+	}
+	if list, err = main.Worktrees(); err != nil || len(list) != 2 || list[1].Name != "feature1" {
+		t.Errorf("after remove Worktrees = %+v, %v", list, err)
+	}
+}
+
+func TestAddWorktreeNonEmpty(t *testing.T) {
+	main, err := Init(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := main.AddWorktree(dir, Options{}); err == nil {
+		t.Error("AddWorktree into a non-empty directory: no error")
+	}
+}